@@ -41,6 +41,7 @@ const FileManager = () => {
   const [uploadModalVisible, setUploadModalVisible] = useState(false);
   const [selectedFile, setSelectedFile] = useState(null);
   const [fileContent, setFileContent] = useState('');
+  const [fileVersion, setFileVersion] = useState('');
   const [newFileName, setNewFileName] = useState('');
   const [createType, setCreateType] = useState('file'); // 'file' or 'directory'
 
@@ -87,8 +88,10 @@ const FileManager = () => {
         const response = await axios.get('/api/files/content', {
           params: { path: file.path }
         });
+        const { content, version } = response.data.data;
         setSelectedFile(file);
-        setFileContent(response.data.content);
+        setFileContent(content);
+        setFileVersion(version);
         setEditModalVisible(true);
       } catch (error) {
         console.error('Failed to read file:', error);
@@ -99,16 +102,22 @@ const FileManager = () => {
 
   const saveFile = async () => {
     try {
-      await axios.post('/api/files/content', {
+      // Send back the version we read so the server rejects the save (409) if the file changed meanwhile
+      await axios.put('/api/files/content', {
         path: selectedFile.path,
-        content: fileContent
+        content: fileContent,
+        version: fileVersion
       });
       message.success('文件保存成功');
       setEditModalVisible(false);
       fetchFiles(currentPath);
     } catch (error) {
       console.error('Failed to save file:', error);
-      message.error('保存文件失败');
+      if (error.response?.status === 409) {
+        message.error('文件已被修改，请重新打开后再保存');
+      } else {
+        message.error('保存文件失败');
+      }
     }
   };
 
@@ -148,7 +157,7 @@ const FileManager = () => {
         await axios.post('/api/files/mkdir', { path: newPath });
         message.success('目录创建成功');
       } else {
-        await axios.post('/api/files/content', {
+        await axios.put('/api/files/content', {
           path: newPath,
           content: ''
         });
@@ -4,6 +4,46 @@ import { message } from 'antd';
 
 const AuthContext = createContext();
 
+// Requests that must not trigger a token refresh when they fail with 401
+const NO_REFRESH_PATHS = ['/api/auth/login', '/api/auth/refresh'];
+
+const storeTokens = ({ token, refresh_token }) => {
+  localStorage.setItem('token', token);
+  if (refresh_token) {
+    localStorage.setItem('refresh_token', refresh_token);
+  }
+  axios.defaults.headers.common['Authorization'] = `Bearer ${token}`;
+};
+
+const clearTokens = () => {
+  localStorage.removeItem('token');
+  localStorage.removeItem('refresh_token');
+  delete axios.defaults.headers.common['Authorization'];
+};
+
+// Access tokens are short-lived. Every request that gets a 401 at the same time shares one
+// refresh call: the server rotates the refresh token, so a second concurrent refresh would
+// present an already-used token and get the whole session revoked as stolen.
+let refreshPromise = null;
+
+const refreshTokens = () => {
+  if (!refreshPromise) {
+    const refreshToken = localStorage.getItem('refresh_token');
+    const request = refreshToken
+      ? axios.post('/api/auth/refresh', { refresh_token: refreshToken })
+      : Promise.reject(new Error('No refresh token'));
+    refreshPromise = request
+      .then((response) => {
+        storeTokens(response.data.data);
+        return response.data.data.token;
+      })
+      .finally(() => {
+        refreshPromise = null;
+      });
+  }
+  return refreshPromise;
+};
+
 export const useAuth = () => {
   const context = useContext(AuthContext);
   if (!context) {
@@ -24,6 +64,39 @@ export const AuthProvider = ({ children }) => {
     }
   }, []);
 
+  // Refresh the access token once and retry when a request fails with 401
+  useEffect(() => {
+    const interceptor = axios.interceptors.response.use(
+      (response) => response,
+      async (error) => {
+        const original = error.config;
+        if (
+          error.response?.status !== 401 ||
+          !original ||
+          original._retried ||
+          NO_REFRESH_PATHS.some((path) => original.url?.startsWith(path))
+        ) {
+          return Promise.reject(error);
+        }
+
+        original._retried = true;
+        try {
+          const token = await refreshTokens();
+          original.headers['Authorization'] = `Bearer ${token}`;
+          return axios(original);
+        } catch (refreshError) {
+          clearTokens();
+          setUser(null);
+          return Promise.reject(error);
+        }
+      }
+    );
+
+    return () => {
+      axios.interceptors.response.eject(interceptor);
+    };
+  }, []);
+
   // Check if user is authenticated on app load
   useEffect(() => {
     const checkAuth = async () => {
@@ -37,8 +110,7 @@ export const AuthProvider = ({ children }) => {
           setUser(response.data.user);
         } catch (error) {
           console.error('AuthContext: Verification failed:', error);
-          localStorage.removeItem('token');
-          delete axios.defaults.headers.common['Authorization'];
+          clearTokens();
         }
       } else {
         console.log('AuthContext: No token found');
@@ -58,13 +130,10 @@ export const AuthProvider = ({ children }) => {
         password
       });
 
-      const { token, user } = response.data.data;
+      const { user } = response.data.data;
       
-      // Store token in localStorage
-      localStorage.setItem('token', token);
-      
-      // Set default authorization header
-      axios.defaults.headers.common['Authorization'] = `Bearer ${token}`;
+      // Store the access and refresh tokens and set the default authorization header
+      storeTokens(response.data.data);
       
       // Update user state
       setUser(user);
@@ -79,11 +148,8 @@ export const AuthProvider = ({ children }) => {
   };
 
   const logout = () => {
-    // Remove token from localStorage
-    localStorage.removeItem('token');
-    
-    // Remove authorization header
-    delete axios.defaults.headers.common['Authorization'];
+    // Remove tokens and the authorization header
+    clearTokens();
     
     // Clear user state
     setUser(null);
@@ -2,6 +2,9 @@ import React, { createContext, useContext, useEffect, useState, useRef, useCallb
 
 const WebSocketContext = createContext();
 
+// Logged instead of the full URL, which carries the JWT in its query string
+const WS_ENDPOINT = 'ws://localhost:3001/ws';
+
 export const useWebSocket = () => {
   const context = useContext(WebSocketContext);
   if (!context) {
@@ -20,21 +23,25 @@ export const WebSocketProvider = ({ children }) => {
 
   const connectWebSocket = useCallback(() => {
     try {
-      const wsUrl = `ws://localhost:3001/ws`;
-      console.log('Attempting to connect to:', wsUrl);
+      // Browsers cannot set headers on the handshake, so the JWT goes in the query string
+      const token = localStorage.getItem('token') || '';
+      const wsUrl = `${WS_ENDPOINT}?token=${encodeURIComponent(token)}`;
+      console.log('Attempting to connect to:', WS_ENDPOINT);
       
       wsRef.current = new WebSocket(wsUrl);
       
       wsRef.current.onopen = () => {
-        console.log('WebSocket connected successfully to:', wsUrl);
+        console.log('WebSocket connected successfully to:', WS_ENDPOINT);
         setConnected(true);
         reconnectAttempts.current = 0;
+        // Broadcast topics are opt-in; the dashboard only needs system stats
+        wsRef.current.send(JSON.stringify({ type: 'subscribe', data: { topics: ['system_stats'] } }));
       };
       
       wsRef.current.onmessage = (event) => {
         try {
           const data = JSON.parse(event.data);
-          if (data.type === 'systemStats') {
+          if (data.type === 'system_stats') {
             setSystemStats(data.data);
           }
         } catch (error) {
@@ -59,7 +66,7 @@ export const WebSocketProvider = ({ children }) => {
       
       wsRef.current.onerror = (error) => {
         console.error('WebSocket error occurred:', error);
-        console.error('WebSocket URL:', wsUrl);
+        console.error('WebSocket URL:', WS_ENDPOINT);
         console.error('WebSocket readyState:', wsRef.current?.readyState);
       };
     } catch (error) {
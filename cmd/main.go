@@ -13,9 +13,13 @@ import (
 	"web-panel-go/internal/config"
 	"web-panel-go/internal/database"
 	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
 	"web-panel-go/internal/router"
+	"web-panel-go/internal/scheduler"
 	"web-panel-go/internal/service"
 	"web-panel-go/internal/websocket"
+
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -55,28 +59,43 @@ func main() {
 	services := service.NewServices(db, cfg)
 
 	// 初始化WebSocket管理器
-	wsManager := websocket.NewWebSocketManager()
+	wsManager := websocket.NewWebSocketManager(cfg.WebSocket)
 	go wsManager.Run()
+	services.User.SetNotifier(wsManager)
+	services.User.SetSecurityAlerter(wsManager)
+	services.User.SetAccessNotifier(wsManager)
+	if cfg.Mail.Enabled {
+		services.User.SetMailer(service.NewSMTPMailer(cfg.Mail))
+	}
+	services.Permission.SetSecurityAlerter(wsManager)
+	services.Permission.SetAccessNotifier(wsManager)
+	services.Role.SetSecurityAlerter(wsManager)
+	services.Role.SetAccessNotifier(wsManager)
+	services.Auth.SetSecurityAlerter(wsManager)
+	services.Setting.SetConfigBroadcaster(wsManager)
+	services.Session.SetSessionCloser(wsManager)
+	wsManager.SetFileTailService(services.FileTail)
 
-	// 启动系统监控定时任务
-	go startSystemMonitor(services.System, wsManager)
+	// 启动后台任务
+	services.Jobs.SetNotifier(wsManager)
+	services.Alerts.SetNotifier(wsManager)
+	registerJobs(cfg, db, services, wsManager)
+	services.Jobs.Scheduler().Start(context.Background())
 
 	// 初始化路由
-	r := router.Setup(cfg, services, wsManager)
+	r, stopRouter := router.Setup(cfg, services, wsManager)
 
 	// 创建HTTP服务器
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.System.Port),
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	srv, err := newHTTPServer(cfg.System, r)
+	if err != nil {
+		log.Fatalf("监听地址配置错误: %v", err)
 	}
+	addr := srv.Addr
 
 	// 启动服务器
 	go func() {
-		fmt.Printf("服务器启动在端口: %d\n", cfg.System.Port)
-		logger.Logger.Infof("服务器启动在端口: %d", cfg.System.Port)
+		fmt.Printf("服务器启动在: %s\n", addr)
+		logger.Logger.Infof("服务器启动在: %s", addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Logger.Fatalf("服务器启动失败: %v", err)
 		}
@@ -90,35 +109,153 @@ func main() {
 	fmt.Println("服务器正在关闭...")
 	logger.Logger.Info("服务器正在关闭...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownServer(srv, wsManager, cfg.System.ShutdownTimeout)
+
+	// 停止后台任务和限流记录清理
+	services.Jobs.Scheduler().Stop()
+	stopRouter()
+
+	fmt.Println("服务器已关闭")
+	logger.Logger.Info("服务器已关闭")
+}
+
+// newHTTPServer 创建HTTP服务器，监听地址、请求头读取超时和请求头大小上限来自配置
+func newHTTPServer(cfg config.SystemConfig, handler http.Handler) (*http.Server, error) {
+	addr, err := cfg.ListenAddr()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      15 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}, nil
+}
+
+// shutdownServer 在 timeout 内优雅关闭：拒绝新的WebSocket连接并通知客户端，停止接受新请求并等待处理中的请求完成，
+// 最后关闭WebSocket连接；超时后强制关闭
+func shutdownServer(srv *http.Server, wsManager *websocket.WebSocketManager, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	wsManager.BeginShutdown()
+
 	if err := srv.Shutdown(ctx); err != nil {
 		fmt.Printf("服务器强制关闭: %v\n", err)
-		logger.Logger.Fatalf("服务器强制关闭: %v", err)
+		logger.Logger.Errorf("等待请求完成超时，服务器强制关闭: %v", err)
+		srv.Close()
 	}
 
-	fmt.Println("服务器已关闭")
-	logger.Logger.Info("服务器已关闭")
+	wsManager.CloseAll(ctx)
 }
 
-// startSystemMonitor 启动系统监控定时任务
-func startSystemMonitor(systemService *service.SystemService, wsManager *websocket.WebSocketManager) {
-	ticker := time.NewTicker(5 * time.Second) // 每5秒更新一次
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			// 获取系统统计信息
-			stats, err := systemService.GetSystemOverview()
-			if err != nil {
-				logger.Error("获取系统统计信息失败", "error", err)
-				continue
-			}
-
-			// 广播系统统计信息给所有WebSocket客户端
-			wsManager.BroadcastSystemStats(stats)
+// registerJobs 注册后台任务
+func registerJobs(cfg *config.Config, db *gorm.DB, services *service.Services, wsManager *websocket.WebSocketManager) {
+	jobs := []scheduler.Job{
+		{
+			// 系统监控：定期广播系统统计信息给所有WebSocket客户端，检查资源使用率告警并保存指标历史
+			// 采集超时或上一次采集仍未结束时跳过本次广播，不发送过期数据
+			Name:     "system_monitor",
+			Interval: cfg.Monitoring.BroadcastInterval,
+			Run: func(ctx context.Context) error {
+				if cfg.Monitoring.CollectTimeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, cfg.Monitoring.CollectTimeout)
+					defer cancel()
+				}
+				stats, err := services.System.CollectOverview(ctx)
+				if err != nil {
+					return fmt.Errorf("获取系统统计信息失败: %w", err)
+				}
+				wsManager.BroadcastSystemStats(stats)
+				services.Alerts.Evaluate(stats)
+				if err := services.Metrics.Record(stats); err != nil {
+					logger.Logger.Warn(err.Error())
+				}
+				return nil
+			},
+		},
+	}
+
+	// 进程列表：定期向订阅了进程列表的客户端广播使用率最高的进程，无人订阅时不采集
+	if cfg.Monitoring.ProcessBroadcastInterval > 0 {
+		jobs = append(jobs, scheduler.Job{
+			Name:     "process_monitor",
+			Interval: cfg.Monitoring.ProcessBroadcastInterval,
+			Run: func(ctx context.Context) error {
+				if !wsManager.HasSubscribers(websocket.MessageTypeProcessList) {
+					return nil
+				}
+				processes, total, err := services.System.GetTopProcesses(cfg.Monitoring.ProcessBroadcastLimit, cfg.Monitoring.ProcessBroadcastSort)
+				if err != nil {
+					return fmt.Errorf("获取进程列表失败: %w", err)
+				}
+				wsManager.BroadcastProcessList(processes, total, cfg.Monitoring.ProcessBroadcastSort)
+				return nil
+			},
+		})
+	}
+
+	// 分片上传清理：每小时清理超过保留时间仍未完成的分片
+	jobs = append(jobs, scheduler.Job{
+		Name:     "chunk_upload_cleanup",
+		Interval: time.Hour,
+		Jitter:   time.Minute,
+		Run: func(ctx context.Context) error {
+			return services.ChunkedUpload.CleanupExpired()
+		},
+	})
+
+	// 回收站清理：每小时清除超过保留时间的条目
+	jobs = append(jobs, scheduler.Job{
+		Name:     "trash_purge",
+		Interval: time.Hour,
+		Jitter:   time.Minute,
+		Run: func(ctx context.Context) error {
+			return services.File.PurgeExpiredTrash()
+		},
+	})
+
+	// 指标历史清理：每小时清除超过保留时间的采样
+	jobs = append(jobs, scheduler.Job{
+		Name:     "metrics_purge",
+		Interval: time.Hour,
+		Jitter:   time.Minute,
+		Run: func(ctx context.Context) error {
+			return services.Metrics.PurgeExpired()
+		},
+	})
+
+	// 缩略图缓存清理：每小时按最近使用时间删除超出容量上限的缩略图
+	jobs = append(jobs, scheduler.Job{
+		Name:     "thumbnail_cache_prune",
+		Interval: time.Hour,
+		Jitter:   time.Minute,
+		Run: func(ctx context.Context) error {
+			return services.File.PruneThumbnailCache()
+		},
+	})
+
+	// 会话清理：每小时清理过期会话（会话表未迁移时跳过）
+	if db.Migrator().HasTable(&model.Session{}) {
+		jobs = append(jobs, scheduler.Job{
+			Name:       "session_cleanup",
+			Interval:   time.Hour,
+			RunOnStart: true,
+			Jitter:     time.Minute,
+			Run: func(ctx context.Context) error {
+				return services.Auth.CleanExpiredSessions()
+			},
+		})
+	}
+
+	for _, job := range jobs {
+		if err := services.Jobs.Scheduler().Register(job); err != nil {
+			logger.Logger.Fatalf("注册后台任务失败: %v", err)
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/websocket"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// startSlowServer 启动一个处理耗时 delay 的测试服务器，请求开始处理时向 started 发送信号
+func startSlowServer(t *testing.T, delay time.Duration) (*httptest.Server, chan struct{}) {
+	t.Helper()
+	started := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-time.After(delay):
+			io.WriteString(w, "done")
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts, started
+}
+
+// getAsync 在后台发送请求，返回接收结果的通道
+func getAsync(url string) chan error {
+	result := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		result <- err
+	}()
+	return result
+}
+
+func TestShutdownServerDrainsInFlightRequests(t *testing.T) {
+	ts, started := startSlowServer(t, 200*time.Millisecond)
+	result := getAsync(ts.URL)
+	<-started
+
+	start := time.Now()
+	shutdownServer(ts.Config, websocket.NewWebSocketManager(config.WebSocketConfig{}), 5*time.Second)
+
+	// 处理中的请求在关闭前完成
+	if err := <-result; err != nil {
+		t.Fatalf("处理中的请求失败: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("关闭没有等待处理中的请求（%v）", elapsed)
+	}
+
+	// 关闭后不再接受新请求
+	if resp, err := http.Get(ts.URL); err == nil {
+		resp.Body.Close()
+		t.Fatal("关闭后仍接受新请求")
+	}
+}
+
+func TestShutdownServerForcesCloseAfterTimeout(t *testing.T) {
+	ts, started := startSlowServer(t, time.Minute)
+	result := getAsync(ts.URL)
+	<-started
+
+	start := time.Now()
+	shutdownServer(ts.Config, websocket.NewWebSocketManager(config.WebSocketConfig{}), 100*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("超时后没有强制关闭，耗时 %v", elapsed)
+	}
+	if err := <-result; err == nil {
+		t.Fatal("超出关闭时限的请求应被中断")
+	}
+}
+
+// serveTestServer 按配置创建HTTP服务器并在本地随机端口上启动，返回监听地址
+func serveTestServer(t *testing.T, cfg config.SystemConfig) string {
+	t.Helper()
+	srv, err := newHTTPServer(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return ln.Addr().String()
+}
+
+func TestHTTPServerDisconnectsSlowHeaders(t *testing.T) {
+	addr := serveTestServer(t, config.SystemConfig{Port: 3001, ReadHeaderTimeout: 200 * time.Millisecond})
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// 逐字节缓慢发送请求头，超过读取请求头超时后连接被服务器关闭
+	start := time.Now()
+	request := "GET / HTTP/1.1\r\nHost: localhost\r\nX-Slow: " + strings.Repeat("a", 100)
+	closed := false
+	for i := 0; i < len(request) && time.Since(start) < 5*time.Second; i++ {
+		if _, err := conn.Write([]byte{request[i]}); err != nil {
+			closed = true
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !closed {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		data, err := io.ReadAll(conn)
+		if err != nil {
+			t.Fatalf("服务器没有关闭缓慢发送请求头的连接: %v", err)
+		}
+		if strings.Contains(string(data), "200 OK") {
+			t.Fatalf("缓慢发送的请求被正常处理: %q", data)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("连接在 %v 后才关闭", elapsed)
+	}
+}
+
+func TestHTTPServerRejectsOversizedHeaders(t *testing.T) {
+	addr := serveTestServer(t, config.SystemConfig{Port: 3001, ReadHeaderTimeout: time.Second, MaxHeaderBytes: 1024})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/", nil)
+	req.Header.Set("X-Large", strings.Repeat("a", 16<<10))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("超大请求头返回 %d，期望 431", resp.StatusCode)
+	}
+
+	resp, err = http.Get("http://" + addr + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("正常请求返回 %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPServerUsesConfiguredAddress(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"", ":3001"},
+		{"127.0.0.1", "127.0.0.1:3001"},
+		{" 127.0.0.1 ", "127.0.0.1:3001"},
+		{"::1", "[::1]:3001"},
+	}
+	for _, tt := range tests {
+		srv, err := newHTTPServer(config.SystemConfig{Host: tt.host, Port: 3001}, http.NotFoundHandler())
+		if err != nil {
+			t.Fatalf("host %q 创建服务器失败: %v", tt.host, err)
+		}
+		if srv.Addr != tt.want {
+			t.Errorf("host %q 监听地址 = %q，期望 %q", tt.host, srv.Addr, tt.want)
+		}
+	}
+
+	if _, err := newHTTPServer(config.SystemConfig{Host: "127.0.0.1", Port: 70000}, http.NotFoundHandler()); err == nil {
+		t.Fatal("无效端口应返回错误")
+	}
+
+	// 只监听回环地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	srv, err := newHTTPServer(config.SystemConfig{Host: "127.0.0.1", Port: port}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.ListenAndServe()
+	t.Cleanup(func() { srv.Close() })
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		resp, err := http.Get("http://" + srv.Addr + "/")
+		if err == nil {
+			resp.Body.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("服务器未在 %s 上监听: %v", srv.Addr, err)
+		}
+	}
+}
@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -17,11 +20,15 @@ type Config struct {
 	Security   SecurityConfig   `mapstructure:"security"`
 	Log        LogConfig        `mapstructure:"log"`
 	Monitoring MonitoringConfig `mapstructure:"monitoring"`
+	Alerts     AlertsConfig     `mapstructure:"alerts"`
 	WebSocket  WebSocketConfig  `mapstructure:"websocket"`
+	File       FileConfig       `mapstructure:"file"`
+	Mail       MailConfig       `mapstructure:"mail"`
 }
 
 // SystemConfig 系统配置
 type SystemConfig struct {
+	Host      string `mapstructure:"host"` // 监听地址，为空表示监听所有网卡
 	Port      int    `mapstructure:"port"`
 	Mode      string `mapstructure:"mode"`
 	BaseDir   string `mapstructure:"base_dir"`
@@ -29,6 +36,12 @@ type SystemConfig struct {
 	LogDir    string `mapstructure:"log_dir"`
 	DataDir   string `mapstructure:"data_dir"`
 	BackupDir string `mapstructure:"backup_dir"`
+
+	FileRootDir string `mapstructure:"file_root_dir"` // 文件管理允许访问的根目录，为空表示不限制
+
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"` // 读取请求头超时，防御慢速请求头攻击
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes"`    // 请求头最大字节数
+	ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`    // 优雅关闭时等待处理中请求和WebSocket连接结束的总时长，超时后强制关闭
 }
 
 // DatabaseConfig 数据库配置
@@ -38,13 +51,70 @@ type DatabaseConfig struct {
 	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	JournalMode     string        `mapstructure:"journal_mode"`
+	BusyTimeout     time.Duration `mapstructure:"busy_timeout"`
+	Synchronous     string        `mapstructure:"synchronous"`
+	ForeignKeys     bool          `mapstructure:"foreign_keys"`
+	Seed            SeedConfig    `mapstructure:"seed"`
+
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"` // SQL耗时超过该值时记录warn日志，0表示不检查
+}
+
+// SeedConfig 默认数据初始化配置
+type SeedConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`        // 启动时写入默认权限、角色和管理员
+	AdminUsername string `mapstructure:"admin_username"` // 首次启动创建的管理员用户名
+	AdminEmail    string `mapstructure:"admin_email"`
+	AdminPassword string `mapstructure:"admin_password"` // 管理员初始密码，仅在创建时使用；为空时随机生成
+
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"-"` // 初始密码需满足的密码策略，加载配置时取 auth.password_policy
+	PasswordFile   string               `mapstructure:"-"` // 随机生成的初始密码写入的文件（权限0600），加载配置时取数据库目录下的 initial_admin_password；为空时输出到标准错误
 }
 
 // AuthConfig 认证配置
 type AuthConfig struct {
-	JWTSecret  string        `mapstructure:"jwt_secret"`
-	JWTExpire  time.Duration `mapstructure:"jwt_expire"`
-	BcryptCost int           `mapstructure:"bcrypt_cost"`
+	JWTSecret         string        `mapstructure:"jwt_secret"`
+	JWTExpire         time.Duration `mapstructure:"jwt_expire"`
+	AccessTokenExpire time.Duration `mapstructure:"access_token_expire"` // 访问令牌有效期，过期后用刷新令牌换取新令牌；0表示与会话同时过期
+	BcryptCost        int           `mapstructure:"bcrypt_cost"`
+	MaxFailedAttempts int           `mapstructure:"max_failed_attempts"` // 连续失败多少次后锁定账户，0表示不锁定
+	LockoutDuration   time.Duration `mapstructure:"lockout_duration"`    // 账户锁定时长
+
+	NotifyPasswordReset bool `mapstructure:"notify_password_reset"` // 管理员重置密码后通过WebSocket通知用户
+	EmailPasswordReset  bool `mapstructure:"email_password_reset"`  // 管理员重置密码后发送邮件通知用户，需启用 mail 且用户设置了邮箱
+	RevokeOnReset       bool `mapstructure:"revoke_on_reset"`       // 管理员重置密码后强制用户重新登录
+
+	AlertThreshold int           `mapstructure:"alert_threshold"` // 同一账户或IP登录失败达到该次数时推送安全告警，0表示不告警
+	AlertWindow    time.Duration `mapstructure:"alert_window"`    // 统计同一IP登录失败次数的时间窗口
+	AlertCooldown  time.Duration `mapstructure:"alert_cooldown"`  // 同一账户或IP的告警间隔，避免刷屏
+
+	SessionExtend         bool          `mapstructure:"session_extend"`          // 有请求时自动延长会话，jwt_expire作为空闲超时
+	SessionExtendInterval time.Duration `mapstructure:"session_extend_interval"` // 同一会话两次延长的最小间隔
+	SessionMaxLifetime    time.Duration `mapstructure:"session_max_lifetime"`    // 会话从登录起的最长有效期，不再延长
+
+	MaxRolesPerUser int `mapstructure:"max_roles_per_user"` // 单个用户最多拥有的角色数，0表示不限制
+
+	UsernameMinLength int      `mapstructure:"username_min_length"` // 用户名最短长度
+	UsernameMaxLength int      `mapstructure:"username_max_length"` // 用户名最长长度，不超过50
+	PasswordMinLength int      `mapstructure:"password_min_length"` // 已废弃，请使用 password_policy.min_length；设置时取两者中较大的值
+	ReservedUsernames []string `mapstructure:"reserved_usernames"`  // 不允许新建或改用的用户名（不区分大小写）
+
+	RequireApproval  bool `mapstructure:"require_approval"`   // 启用账户审核，待审核账户需管理员批准后才能登录
+	NotifyOnApproval bool `mapstructure:"notify_on_approval"` // 账户审核通过后通知用户
+
+	TOTPIssuer string `mapstructure:"totp_issuer"` // 两步验证在验证器应用中显示的发行方名称
+
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"password_policy"`
+}
+
+// PasswordPolicyConfig 密码复杂度策略，创建用户、重置密码、修改密码和初始化管理员时校验
+type PasswordPolicyConfig struct {
+	MinLength     int  `mapstructure:"min_length"`     // 密码最短长度
+	RequireUpper  bool `mapstructure:"require_upper"`  // 必须包含大写字母
+	RequireLower  bool `mapstructure:"require_lower"`  // 必须包含小写字母
+	RequireDigit  bool `mapstructure:"require_digit"`  // 必须包含数字
+	RequireSymbol bool `mapstructure:"require_symbol"` // 必须包含字母和数字以外的字符
+	RejectCommon  bool `mapstructure:"reject_common"`  // 拒绝内置常见弱密码列表中的密码（不区分大小写）
 }
 
 // SecurityConfig 安全配置
@@ -52,12 +122,18 @@ type SecurityConfig struct {
 	CORSOrigins []string   `mapstructure:"cors_origins"`
 	RateLimit   RateLimit  `mapstructure:"rate_limit"`
 	CSRFEnabled bool       `mapstructure:"csrf_enabled"`
+
+	ConfirmActions []string `mapstructure:"confirm_actions"` // 必须二次确认的危险操作，列出的操作未携带确认令牌时拒绝执行
 }
 
 // RateLimit 限流配置
 type RateLimit struct {
 	Window      time.Duration `mapstructure:"window"`
 	MaxRequests int           `mapstructure:"max_requests"`
+	ExemptPaths []string      `mapstructure:"exempt_paths"` // 不计入限流的路径，匹配路径本身及其子路径
+
+	AdminExempt      bool `mapstructure:"admin_exempt"`       // 已认证的管理员请求不计入限流
+	AdminMaxRequests int  `mapstructure:"admin_max_requests"` // 已认证的管理员按用户计数的限额，0表示与普通请求一样按IP计数；admin_exempt 为true时忽略
 }
 
 // LogConfig 日志配置
@@ -69,6 +145,8 @@ type LogConfig struct {
 	MaxBackups int    `mapstructure:"max_backups"`
 	MaxAge     int    `mapstructure:"max_age"`
 	Compress   bool   `mapstructure:"compress"`
+
+	SlowRequestThreshold time.Duration `mapstructure:"slow_request_threshold"` // 请求耗时超过该值时记录warn日志，0表示不检查
 }
 
 // MonitoringConfig 监控配置
@@ -76,6 +154,35 @@ type MonitoringConfig struct {
 	MetricsEnabled       bool          `mapstructure:"metrics_enabled"`
 	HealthCheckInterval  time.Duration `mapstructure:"health_check_interval"`
 	SystemInfoCache      time.Duration `mapstructure:"system_info_cache"`
+	JobFailureThreshold  int           `mapstructure:"job_failure_threshold"` // 后台任务连续失败多少次后通知管理员，0表示不通知
+
+	BroadcastInterval        time.Duration `mapstructure:"broadcast_interval"`         // 向WebSocket客户端广播系统统计的间隔
+	CollectTimeout           time.Duration `mapstructure:"collect_timeout"`            // 单次采集系统统计的超时时间，超时则放弃本次广播，0表示不限制
+	MaxConcurrentCollections int           `mapstructure:"max_concurrent_collections"` // 同时进行的采集数上限（含超时后仍未结束的采集），达到上限时跳过本次广播
+
+	ProcessBroadcastInterval time.Duration `mapstructure:"process_broadcast_interval"` // 向订阅了进程列表的客户端广播的间隔，0表示不广播
+	ProcessBroadcastLimit    int           `mapstructure:"process_broadcast_limit"`    // 每次广播的进程数（按使用率从高到低）
+	ProcessBroadcastSort     string        `mapstructure:"process_broadcast_sort"`     // 广播进程列表的排序方式：cpu 或 memory
+
+	MetricsSampleInterval time.Duration `mapstructure:"metrics_sample_interval"` // 保存指标历史采样的间隔，0表示不保存
+	MetricsRetention      time.Duration `mapstructure:"metrics_retention"`       // 指标历史保留时间，超过后由后台任务清除，0表示永久保留
+}
+
+// AlertsConfig 资源使用率告警配置，每次采集系统统计后检查
+type AlertsConfig struct {
+	Enabled bool      `mapstructure:"enabled"`
+	CPU     AlertRule `mapstructure:"cpu"`
+	Memory  AlertRule `mapstructure:"memory"`
+	Disk    AlertRule `mapstructure:"disk"`
+}
+
+// AlertRule 单项指标的告警规则
+// 连续 Samples 次采样超过 Threshold 时告警，之后连续 Samples 次低于 Threshold-Hysteresis 时发送恢复通知
+type AlertRule struct {
+	Threshold  float64 `mapstructure:"threshold"`  // 使用率阈值（百分比），0表示不检查
+	Hysteresis float64 `mapstructure:"hysteresis"` // 恢复前需要回落到阈值以下的幅度（百分点），防止在阈值附近反复告警
+	Samples    int     `mapstructure:"samples"`    // 连续多少次采样满足条件才告警或恢复
+	Level      string  `mapstructure:"level"`      // 告警通知级别：warning 或 error
 }
 
 // WebSocketConfig WebSocket配置
@@ -85,6 +192,64 @@ type WebSocketConfig struct {
 	ReadBufferSize  int    `mapstructure:"read_buffer_size"`
 	WriteBufferSize int    `mapstructure:"write_buffer_size"`
 	CheckOrigin     bool   `mapstructure:"check_origin"`
+
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"` // 超过该时间未收到客户端消息则关闭连接（ping/pong不计入），0表示不启用
+
+	BroadcastBuffer int `mapstructure:"broadcast_buffer"` // 广播队列容量，队列满时新的广播消息被丢弃
+}
+
+// FileConfig 文件管理配置
+type FileConfig struct {
+	MaxConcurrentUploads        int `mapstructure:"max_concurrent_uploads"`          // 全局同时上传数上限，0表示不限制
+	MaxConcurrentUploadsPerUser int `mapstructure:"max_concurrent_uploads_per_user"` // 单用户同时上传数上限，0表示不限制
+
+	TrashEnabled   bool          `mapstructure:"trash_enabled"`   // 删除时移入回收站而不是永久删除
+	TrashRetention time.Duration `mapstructure:"trash_retention"` // 回收站条目保留时间，超过后由后台任务清除，0表示永久保留
+
+	MaxArchiveSize int64 `mapstructure:"max_archive_size"` // 打包下载的文件总大小上限（字节），0表示不限制
+
+	MaxListEntries    int `mapstructure:"max_list_entries"`     // 单次列目录最多读取的条目数，超出部分不再返回，0表示不限制
+	ListAllMaxEntries int `mapstructure:"list_all_max_entries"` // 不分页列目录（page_size=0 或 all=true）时最多返回的条目数，超出时退回分页，0表示不限制
+
+	DiskReserve int64 `mapstructure:"disk_reserve"` // 写入文件时目标磁盘必须保留的可用空间（字节），0表示不检查
+
+	ChunkSize      int64         `mapstructure:"chunk_size"`       // 分片上传单个分片的最大字节数
+	ChunkUploadTTL time.Duration `mapstructure:"chunk_upload_ttl"` // 分片上传未完成时临时文件的保留时间
+
+	MaxChecksumSize int64         `mapstructure:"max_checksum_size"` // 计算校验和的文件大小上限（字节），0表示不限制
+	ChecksumTimeout time.Duration `mapstructure:"checksum_timeout"`  // 单次计算校验和的超时时间，0表示不限制
+
+	TailMaxPerUser   int           `mapstructure:"tail_max_per_user"`  // 单用户同时跟踪（tail -f）的文件数上限，0表示不限制
+	TailMaxLines     int           `mapstructure:"tail_max_lines"`     // 开始跟踪时最多返回的末尾行数
+	TailPollInterval time.Duration `mapstructure:"tail_poll_interval"` // 检查文件新增内容的间隔
+
+	ThumbnailMaxSourceSize int64  `mapstructure:"thumbnail_max_source_size"` // 生成缩略图的原图文件大小上限（字节）
+	ThumbnailMaxPixels     int64  `mapstructure:"thumbnail_max_pixels"`      // 原图解码后的像素数上限，防止解码超大图片耗尽内存
+	ThumbnailMaxSize       int    `mapstructure:"thumbnail_max_size"`        // 缩略图宽高上限（像素）
+	ThumbnailConcurrency   int    `mapstructure:"thumbnail_concurrency"`     // 同时生成缩略图的数量上限，解码原图占用大量内存，0表示不限制
+	ThumbnailCacheDir      string `mapstructure:"thumbnail_cache_dir"`       // 缩略图缓存目录
+	ThumbnailCacheMaxSize  int64  `mapstructure:"thumbnail_cache_max_size"`  // 缩略图缓存总大小上限（字节），超出时按最近使用时间清除，0表示不限制
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`       // 启用邮件发送，关闭时不发送任何邮件
+	SMTPHost     string `mapstructure:"smtp_host"`     // SMTP服务器地址
+	SMTPPort     int    `mapstructure:"smtp_port"`     // SMTP服务器端口，服务器支持时使用STARTTLS
+	SMTPUser     string `mapstructure:"smtp_user"`     // SMTP认证用户名，为空时不认证
+	SMTPPassword string `mapstructure:"smtp_password"` // SMTP认证密码
+	From         string `mapstructure:"from"`          // 发件人地址
+}
+
+// secretEnvKeys 显式绑定环境变量的敏感配置，不依赖默认值或配置文件中是否出现该键，
+// 便于在容器或密钥管理系统中只通过环境变量注入
+var secretEnvKeys = []string{
+	"auth.jwt_secret",
+	"database.path",
+	"database.seed.admin_username",
+	"database.seed.admin_email",
+	"database.seed.admin_password",
+	"mail.smtp_password",
 }
 
 // Load 加载配置
@@ -98,9 +263,16 @@ func Load() (*Config, error) {
 	v.AddConfigPath("../config")
 	v.AddConfigPath("/opt/web-panel-go/config")
 
-	// 设置环境变量前缀
+	// 环境变量覆盖配置文件，配置文件覆盖默认值；键名加 WPG_ 前缀、点号换成下划线，
+	// 如 auth.jwt_secret 对应 WPG_AUTH_JWT_SECRET
 	v.SetEnvPrefix("WPG")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
+	for _, key := range secretEnvKeys {
+		if err := v.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("绑定环境变量失败: %w", err)
+		}
+	}
 
 	// 设置默认值
 	setDefaults(v)
@@ -119,17 +291,59 @@ func Load() (*Config, error) {
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("解析配置失败: %w", err)
 	}
+	cfg.Database.Seed.PasswordPolicy = cfg.Auth.PasswordPolicy
+	cfg.Database.Seed.PasswordFile = filepath.Join(filepath.Dir(cfg.Database.Path), "initial_admin_password")
 
 	// 创建必要的目录
 	if err := createDirectories(&cfg); err != nil {
 		return nil, fmt.Errorf("创建目录失败: %w", err)
 	}
 
+	// 校验全部配置，有问题时拒绝启动
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
 
+// Defaults 返回只包含默认值的配置，不读取配置文件和环境变量，也不创建目录，用于测试
+func Defaults() (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	cfg.Database.Seed.PasswordPolicy = cfg.Auth.PasswordPolicy
+	return &cfg, nil
+}
+
+// ListenAddr 返回HTTP服务监听地址并校验host和port
+func (c SystemConfig) ListenAddr() (string, error) {
+	if c.Port <= 0 || c.Port > 65535 {
+		return "", fmt.Errorf("无效的监听端口: %d", c.Port)
+	}
+
+	host := strings.TrimSpace(c.Host)
+	addr := net.JoinHostPort(host, strconv.Itoa(c.Port))
+	if host == "" {
+		return addr, nil
+	}
+
+	if net.ParseIP(host) == nil {
+		if _, err := net.ResolveTCPAddr("tcp", addr); err != nil {
+			return "", fmt.Errorf("无效的监听地址 %s: %w", host, err)
+		}
+	}
+
+	return addr, nil
+}
+
 // setDefaults 设置默认配置值
 func setDefaults(v *viper.Viper) {
+	v.SetDefault("system.host", "")
 	v.SetDefault("system.port", 3001)
 	v.SetDefault("system.mode", "production")
 	v.SetDefault("system.base_dir", "./")
@@ -137,16 +351,59 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("system.log_dir", "./logs")
 	v.SetDefault("system.data_dir", "./data")
 	v.SetDefault("system.backup_dir", "./backup")
+	v.SetDefault("system.file_root_dir", "")
+	v.SetDefault("system.read_header_timeout", "5s")
+	v.SetDefault("system.max_header_bytes", 64*1024)
+	v.SetDefault("system.shutdown_timeout", "30s")
+
+	v.SetDefault("security.rate_limit.exempt_paths", []string{"/health", "/metrics"})
+	v.SetDefault("security.rate_limit.admin_exempt", false)
+	v.SetDefault("security.rate_limit.admin_max_requests", 0)
+	v.SetDefault("security.confirm_actions", []string{})
 
 	v.SetDefault("database.type", "sqlite")
 	v.SetDefault("database.path", "./data/database.sqlite")
 	v.SetDefault("database.max_idle_conns", 10)
 	v.SetDefault("database.max_open_conns", 100)
 	v.SetDefault("database.conn_max_lifetime", "1h")
+	v.SetDefault("database.journal_mode", "WAL")
+	v.SetDefault("database.busy_timeout", "5s")
+	v.SetDefault("database.synchronous", "NORMAL")
+	v.SetDefault("database.foreign_keys", true)
+	v.SetDefault("database.slow_query_threshold", "200ms")
+	v.SetDefault("database.seed.enabled", true)
+	v.SetDefault("database.seed.admin_username", "admin")
+	v.SetDefault("database.seed.admin_email", "admin@localhost")
+	v.SetDefault("database.seed.admin_password", "")
 
-	v.SetDefault("auth.jwt_secret", "your-secret-key-change-in-production")
+	v.SetDefault("auth.jwt_secret", defaultJWTSecret)
 	v.SetDefault("auth.jwt_expire", "24h")
+	v.SetDefault("auth.access_token_expire", "15m")
 	v.SetDefault("auth.bcrypt_cost", 12)
+	v.SetDefault("auth.max_failed_attempts", 5)
+	v.SetDefault("auth.lockout_duration", "15m")
+	v.SetDefault("auth.notify_password_reset", true)
+	v.SetDefault("auth.email_password_reset", false)
+	v.SetDefault("auth.revoke_on_reset", true)
+	v.SetDefault("auth.alert_threshold", 3)
+	v.SetDefault("auth.alert_window", "10m")
+	v.SetDefault("auth.alert_cooldown", "5m")
+	v.SetDefault("auth.session_extend", false)
+	v.SetDefault("auth.session_extend_interval", "5m")
+	v.SetDefault("auth.session_max_lifetime", "168h")
+	v.SetDefault("auth.max_roles_per_user", 0)
+	v.SetDefault("auth.username_min_length", 3)
+	v.SetDefault("auth.username_max_length", 50)
+	v.SetDefault("auth.reserved_usernames", []string{"admin", "root", "administrator", "system"})
+	v.SetDefault("auth.require_approval", false)
+	v.SetDefault("auth.notify_on_approval", true)
+	v.SetDefault("auth.totp_issuer", "Web Panel")
+	v.SetDefault("auth.password_policy.min_length", 8)
+	v.SetDefault("auth.password_policy.require_upper", true)
+	v.SetDefault("auth.password_policy.require_lower", true)
+	v.SetDefault("auth.password_policy.require_digit", true)
+	v.SetDefault("auth.password_policy.require_symbol", false)
+	v.SetDefault("auth.password_policy.reject_common", true)
 
 	v.SetDefault("log.level", "info")
 	v.SetDefault("log.format", "json")
@@ -155,12 +412,68 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("log.max_backups", 10)
 	v.SetDefault("log.max_age", 30)
 	v.SetDefault("log.compress", true)
+	v.SetDefault("log.slow_request_threshold", "1s")
+
+	v.SetDefault("monitoring.job_failure_threshold", 3)
+	v.SetDefault("monitoring.broadcast_interval", "5s")
+	v.SetDefault("monitoring.collect_timeout", "4s")
+	v.SetDefault("monitoring.max_concurrent_collections", 1)
+	v.SetDefault("monitoring.process_broadcast_interval", "5s")
+	v.SetDefault("monitoring.process_broadcast_limit", 20)
+	v.SetDefault("monitoring.process_broadcast_sort", "cpu")
+	v.SetDefault("monitoring.metrics_sample_interval", "1m")
+	v.SetDefault("monitoring.metrics_retention", "168h")
+
+	v.SetDefault("alerts.enabled", true)
+	v.SetDefault("alerts.cpu.threshold", 90)
+	v.SetDefault("alerts.cpu.hysteresis", 5)
+	v.SetDefault("alerts.cpu.samples", 3)
+	v.SetDefault("alerts.cpu.level", "warning")
+	v.SetDefault("alerts.memory.threshold", 90)
+	v.SetDefault("alerts.memory.hysteresis", 5)
+	v.SetDefault("alerts.memory.samples", 3)
+	v.SetDefault("alerts.memory.level", "warning")
+	v.SetDefault("alerts.disk.threshold", 95)
+	v.SetDefault("alerts.disk.hysteresis", 2)
+	v.SetDefault("alerts.disk.samples", 1)
+	v.SetDefault("alerts.disk.level", "error")
 
 	v.SetDefault("websocket.enabled", true)
 	v.SetDefault("websocket.path", "/ws")
 	v.SetDefault("websocket.read_buffer_size", 1024)
 	v.SetDefault("websocket.write_buffer_size", 1024)
 	v.SetDefault("websocket.check_origin", false)
+	v.SetDefault("websocket.idle_timeout", 0)
+	v.SetDefault("websocket.broadcast_buffer", 256)
+
+	v.SetDefault("file.max_concurrent_uploads", 8)
+	v.SetDefault("file.max_concurrent_uploads_per_user", 2)
+	v.SetDefault("file.trash_enabled", false)
+	v.SetDefault("file.trash_retention", "720h")
+	v.SetDefault("file.max_archive_size", 1<<30)
+	v.SetDefault("file.max_list_entries", 10000)
+	v.SetDefault("file.list_all_max_entries", 1000)
+	v.SetDefault("file.disk_reserve", 256<<20)
+	v.SetDefault("file.chunk_size", 16<<20)
+	v.SetDefault("file.chunk_upload_ttl", "24h")
+	v.SetDefault("file.max_checksum_size", 4<<30)
+	v.SetDefault("file.checksum_timeout", "2m")
+	v.SetDefault("file.tail_max_per_user", 4)
+	v.SetDefault("file.tail_max_lines", 1000)
+	v.SetDefault("file.tail_poll_interval", "500ms")
+	v.SetDefault("file.thumbnail_max_source_size", 32<<20)
+	v.SetDefault("file.thumbnail_max_pixels", 50000000)
+	v.SetDefault("file.thumbnail_max_size", 1024)
+	v.SetDefault("file.thumbnail_concurrency", 2)
+	v.SetDefault("file.thumbnail_cache_dir", "./data/thumbnails")
+	v.SetDefault("file.thumbnail_cache_max_size", 256<<20)
+
+	v.SetDefault("mail.enabled", false)
+	v.SetDefault("mail.smtp_host", "")
+	v.SetDefault("mail.smtp_port", 587)
+	v.SetDefault("mail.smtp_user", "")
+	v.SetDefault("mail.smtp_password", "")
+	v.SetDefault("mail.from", "")
 }
 
 // createDirectories 创建必要的目录
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// chdirWithConfig 切换到临时目录，content 非空时写入 config/app.yaml
+func chdirWithConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if content == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config", "app.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadEnvOverridesNestedKeys(t *testing.T) {
+	chdirWithConfig(t, `
+system:
+  port: 4000
+  mode: development
+auth:
+  jwt_secret: file-secret
+  lockout_duration: 20m
+`)
+	t.Setenv("WPG_AUTH_JWT_SECRET", "env-secret")
+	t.Setenv("WPG_AUTH_LOCKOUT_DURATION", "30m")
+	t.Setenv("WPG_DATABASE_SEED_ADMIN_PASSWORD", "Env@Passw0rd")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 环境变量 > 配置文件 > 默认值
+	if cfg.Auth.JWTSecret != "env-secret" {
+		t.Errorf("auth.jwt_secret = %q，期望环境变量的值", cfg.Auth.JWTSecret)
+	}
+	if cfg.Auth.LockoutDuration.String() != "30m0s" {
+		t.Errorf("auth.lockout_duration = %v，期望环境变量的值", cfg.Auth.LockoutDuration)
+	}
+	if cfg.Database.Seed.AdminPassword != "Env@Passw0rd" {
+		t.Errorf("database.seed.admin_password = %q，期望环境变量的值", cfg.Database.Seed.AdminPassword)
+	}
+	if cfg.System.Port != 4000 {
+		t.Errorf("system.port = %d，期望配置文件的值", cfg.System.Port)
+	}
+	if cfg.Auth.MaxFailedAttempts != 5 {
+		t.Errorf("auth.max_failed_attempts = %d，期望默认值", cfg.Auth.MaxFailedAttempts)
+	}
+}
+
+func TestLoadEnvWithoutConfigFile(t *testing.T) {
+	chdirWithConfig(t, "")
+	t.Setenv("WPG_AUTH_JWT_SECRET", "env-only-secret")
+	t.Setenv("WPG_SYSTEM_PORT", "5000")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	if cfg.Auth.JWTSecret != "env-only-secret" || cfg.System.Port != 5000 {
+		t.Fatalf("没有配置文件时环境变量未生效: jwt_secret=%q port=%d", cfg.Auth.JWTSecret, cfg.System.Port)
+	}
+}
@@ -0,0 +1,281 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultJWTSecret 默认的JWT密钥，生产环境必须修改
+const defaultJWTSecret = "your-secret-key-change-in-production"
+
+// validModes 允许的运行模式
+var validModes = map[string]bool{
+	"development": true,
+	"debug":       true,
+	"test":        true,
+	"production":  true,
+	"release":     true,
+}
+
+// confirmableActions 支持二次确认的危险操作
+var confirmableActions = map[string]bool{
+	"kill_process": true,
+}
+
+// validLogLevels 允许的日志级别
+var validLogLevels = map[string]bool{
+	"trace": true,
+	"debug": true,
+	"info":  true,
+	"warn":  true,
+	"error": true,
+	"fatal": true,
+	"panic": true,
+}
+
+// ValidationError 配置校验错误，包含全部发现的问题
+type ValidationError struct {
+	Problems []string
+}
+
+// Error 实现 error 接口，每个问题占一行
+func (e *ValidationError) Error() string {
+	return "配置校验失败:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// IsRelease 是否为生产模式（release 或 production）
+func (c SystemConfig) IsRelease() bool {
+	return c.Mode == "release" || c.Mode == "production"
+}
+
+// Validate 校验配置的取值范围、生产模式下必需的密钥、目录是否可写以及相互依赖的配置项，
+// 返回包含所有问题的 *ValidationError；目录需已创建
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// system
+	if !validModes[c.System.Mode] {
+		addf("system.mode 不支持: %q（可选 development、debug、test、production、release）", c.System.Mode)
+	}
+	if _, err := c.System.ListenAddr(); err != nil {
+		addf("system: %v", err)
+	}
+	if c.System.ReadHeaderTimeout < 0 {
+		addf("system.read_header_timeout 不能为负数")
+	}
+	if c.System.MaxHeaderBytes < 0 {
+		addf("system.max_header_bytes 不能为负数")
+	}
+	if c.System.ShutdownTimeout <= 0 {
+		addf("system.shutdown_timeout 必须大于0")
+	}
+	for _, dir := range []struct{ key, path string }{
+		{"system.upload_dir", c.System.UploadDir},
+		{"system.log_dir", c.System.LogDir},
+		{"system.data_dir", c.System.DataDir},
+		{"system.backup_dir", c.System.BackupDir},
+		{"database.path 所在目录", filepath.Dir(c.Database.Path)},
+	} {
+		if err := checkWritableDir(dir.path); err != nil {
+			addf("%s: %v", dir.key, err)
+		}
+	}
+	if root := c.System.FileRootDir; root != "" {
+		if info, err := os.Stat(root); err != nil {
+			addf("system.file_root_dir: %v", err)
+		} else if !info.IsDir() {
+			addf("system.file_root_dir 不是目录: %s", root)
+		}
+	}
+
+	// database
+	if c.Database.Type != "sqlite" {
+		addf("database.type 不支持: %q（仅支持 sqlite）", c.Database.Type)
+	}
+	if strings.TrimSpace(c.Database.Path) == "" {
+		addf("database.path 不能为空")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxOpenConns < 0 {
+		addf("database.max_idle_conns 和 max_open_conns 不能为负数")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		addf("database.max_idle_conns (%d) 不能大于 max_open_conns (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.BusyTimeout < 0 {
+		addf("database.conn_max_lifetime 和 busy_timeout 不能为负数")
+	}
+	if c.Database.SlowQueryThreshold < 0 {
+		addf("database.slow_query_threshold 不能为负数")
+	}
+
+	// auth
+	if strings.TrimSpace(c.Auth.JWTSecret) == "" {
+		addf("auth.jwt_secret 不能为空")
+	} else if c.System.IsRelease() && c.Auth.JWTSecret == defaultJWTSecret {
+		addf("auth.jwt_secret 在 %s 模式下必须修改默认值（可通过 WPG_AUTH_JWT_SECRET 设置）", c.System.Mode)
+	}
+	if c.Auth.JWTExpire <= 0 {
+		addf("auth.jwt_expire 必须大于0")
+	}
+	if c.Auth.AccessTokenExpire < 0 {
+		addf("auth.access_token_expire 不能为负数")
+	}
+	if c.Auth.MaxFailedAttempts < 0 {
+		addf("auth.max_failed_attempts 不能为负数")
+	} else if c.Auth.MaxFailedAttempts > 0 && c.Auth.LockoutDuration <= 0 {
+		addf("auth.max_failed_attempts 大于0时 lockout_duration 必须大于0")
+	}
+	if c.Auth.AlertThreshold < 0 || c.Auth.AlertWindow < 0 || c.Auth.AlertCooldown < 0 {
+		addf("auth.alert_threshold、alert_window 和 alert_cooldown 不能为负数")
+	}
+	if c.Auth.SessionExtend && c.Auth.SessionExtendInterval <= 0 {
+		addf("auth.session_extend 启用时 session_extend_interval 必须大于0")
+	}
+	if c.Auth.SessionExtend && c.Auth.SessionMaxLifetime <= c.Auth.JWTExpire {
+		addf("auth.session_extend 启用时 session_max_lifetime 必须大于 jwt_expire，否则会话不会延长")
+	}
+	if c.Auth.MaxRolesPerUser < 0 {
+		addf("auth.max_roles_per_user 不能为负数")
+	}
+	if c.Auth.UsernameMinLength > 0 && c.Auth.UsernameMaxLength > 0 && c.Auth.UsernameMinLength > c.Auth.UsernameMaxLength {
+		addf("auth.username_min_length (%d) 不能大于 username_max_length (%d)", c.Auth.UsernameMinLength, c.Auth.UsernameMaxLength)
+	}
+	if c.Auth.PasswordPolicy.MinLength < 0 {
+		addf("auth.password_policy.min_length 不能为负数")
+	}
+
+	// security
+	rl := c.Security.RateLimit
+	if rl.MaxRequests < 0 || rl.AdminMaxRequests < 0 {
+		addf("security.rate_limit.max_requests 和 admin_max_requests 不能为负数")
+	}
+	if rl.MaxRequests > 0 && rl.Window <= 0 {
+		addf("security.rate_limit.max_requests 大于0时 window 必须大于0")
+	}
+	for _, action := range c.Security.ConfirmActions {
+		if !confirmableActions[action] {
+			addf("security.confirm_actions 不支持: %q（可选 kill_process）", action)
+		}
+	}
+
+	// log
+	if c.Log.Level != "" && !validLogLevels[strings.ToLower(c.Log.Level)] {
+		addf("log.level 不支持: %q", c.Log.Level)
+	}
+	if c.Log.MaxSize < 0 || c.Log.MaxBackups < 0 || c.Log.MaxAge < 0 {
+		addf("log.max_size、max_backups 和 max_age 不能为负数")
+	}
+
+	// monitoring
+	if c.Monitoring.BroadcastInterval <= 0 {
+		addf("monitoring.broadcast_interval 必须大于0")
+	}
+	if c.Monitoring.CollectTimeout < 0 || c.Monitoring.JobFailureThreshold < 0 || c.Monitoring.MaxConcurrentCollections < 0 {
+		addf("monitoring.collect_timeout、job_failure_threshold 和 max_concurrent_collections 不能为负数")
+	}
+	if c.Monitoring.ProcessBroadcastInterval < 0 || c.Monitoring.ProcessBroadcastLimit < 0 {
+		addf("monitoring.process_broadcast_interval 和 process_broadcast_limit 不能为负数")
+	}
+	if c.Monitoring.MetricsSampleInterval < 0 || c.Monitoring.MetricsRetention < 0 {
+		addf("monitoring.metrics_sample_interval 和 metrics_retention 不能为负数")
+	}
+	if c.Monitoring.ProcessBroadcastInterval > 0 && c.Monitoring.ProcessBroadcastSort != "cpu" && c.Monitoring.ProcessBroadcastSort != "memory" {
+		addf("monitoring.process_broadcast_sort 不支持: %q（可选 cpu、memory）", c.Monitoring.ProcessBroadcastSort)
+	}
+
+	// alerts
+	for _, rule := range []struct {
+		key  string
+		rule AlertRule
+	}{
+		{"alerts.cpu", c.Alerts.CPU},
+		{"alerts.memory", c.Alerts.Memory},
+		{"alerts.disk", c.Alerts.Disk},
+	} {
+		r := rule.rule
+		if r.Threshold < 0 || r.Threshold > 100 {
+			addf("%s.threshold 必须在 0 到 100 之间", rule.key)
+		}
+		if r.Hysteresis < 0 || (r.Threshold > 0 && r.Hysteresis >= r.Threshold) {
+			addf("%s.hysteresis 不能为负数且必须小于 threshold", rule.key)
+		}
+		if r.Samples < 0 {
+			addf("%s.samples 不能为负数", rule.key)
+		}
+		if r.Threshold > 0 && r.Level != "warning" && r.Level != "error" {
+			addf("%s.level 不支持: %q（可选 warning、error）", rule.key, r.Level)
+		}
+	}
+
+	// websocket
+	if c.WebSocket.Enabled && !strings.HasPrefix(c.WebSocket.Path, "/") {
+		addf("websocket.path 必须以 / 开头: %q", c.WebSocket.Path)
+	}
+	if c.WebSocket.ReadBufferSize < 0 || c.WebSocket.WriteBufferSize < 0 || c.WebSocket.BroadcastBuffer < 0 {
+		addf("websocket.read_buffer_size、write_buffer_size 和 broadcast_buffer 不能为负数")
+	}
+	if c.WebSocket.IdleTimeout < 0 {
+		addf("websocket.idle_timeout 不能为负数")
+	}
+
+	// file
+	f := c.File
+	if f.MaxConcurrentUploads < 0 || f.MaxConcurrentUploadsPerUser < 0 {
+		addf("file.max_concurrent_uploads 和 max_concurrent_uploads_per_user 不能为负数")
+	}
+	if f.MaxArchiveSize < 0 || f.MaxListEntries < 0 || f.ListAllMaxEntries < 0 || f.DiskReserve < 0 || f.MaxChecksumSize < 0 || f.ChunkSize < 0 {
+		addf("file.max_archive_size、max_list_entries、list_all_max_entries、disk_reserve、max_checksum_size 和 chunk_size 不能为负数")
+	}
+	if f.TrashRetention < 0 || f.ChunkUploadTTL < 0 || f.ChecksumTimeout < 0 {
+		addf("file.trash_retention、chunk_upload_ttl 和 checksum_timeout 不能为负数")
+	}
+	if f.ThumbnailMaxSourceSize < 0 || f.ThumbnailMaxPixels < 0 || f.ThumbnailMaxSize < 0 || f.ThumbnailConcurrency < 0 || f.ThumbnailCacheMaxSize < 0 {
+		addf("file.thumbnail_max_source_size、thumbnail_max_pixels、thumbnail_max_size、thumbnail_concurrency 和 thumbnail_cache_max_size 不能为负数")
+	}
+	if f.TailMaxPerUser < 0 || f.TailMaxLines < 0 || f.TailPollInterval < 0 {
+		addf("file.tail_max_per_user、tail_max_lines 和 tail_poll_interval 不能为负数")
+	}
+
+	// mail
+	if c.Mail.Enabled {
+		if strings.TrimSpace(c.Mail.SMTPHost) == "" {
+			addf("mail.enabled 启用时 smtp_host 不能为空")
+		}
+		if c.Mail.SMTPPort <= 0 || c.Mail.SMTPPort > 65535 {
+			addf("mail.smtp_port 必须在 1 到 65535 之间: %d", c.Mail.SMTPPort)
+		}
+		if strings.TrimSpace(c.Mail.From) == "" {
+			addf("mail.enabled 启用时 from 不能为空")
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// checkWritableDir 检查目录存在且可写
+func checkWritableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("不是目录: %s", dir)
+	}
+
+	f, err := os.CreateTemp(dir, ".wpg-write-check-*")
+	if err != nil {
+		return fmt.Errorf("目录不可写: %s", dir)
+	}
+	name := f.Name()
+	f.Close()
+	os.Remove(name)
+	return nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validTestConfig 返回目录均已创建、可以通过校验的默认配置
+func validTestConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := Defaults()
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+	cfg.System.UploadDir = t.TempDir()
+	cfg.System.LogDir = t.TempDir()
+	cfg.System.DataDir = t.TempDir()
+	cfg.System.BackupDir = t.TempDir()
+	cfg.Database.Path = filepath.Join(cfg.System.DataDir, "database.sqlite")
+	cfg.Auth.JWTSecret = "test-secret"
+	return cfg
+}
+
+func TestValidateAcceptsDefaults(t *testing.T) {
+	if err := validTestConfig(t).Validate(); err != nil {
+		t.Fatalf("默认配置校验失败: %v", err)
+	}
+}
+
+func TestValidateRejectsInvalidConfigs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"端口为0", func(c *Config) { c.System.Port = 0 }, "无效的监听端口"},
+		{"端口超出范围", func(c *Config) { c.System.Port = 65536 }, "无效的监听端口"},
+		{"生产模式使用默认密钥", func(c *Config) { c.System.Mode, c.Auth.JWTSecret = "release", defaultJWTSecret }, "auth.jwt_secret 在 release 模式下必须修改默认值"},
+		{"密钥为空", func(c *Config) { c.Auth.JWTSecret = " " }, "auth.jwt_secret 不能为空"},
+		{"限流为负数", func(c *Config) { c.Security.RateLimit.MaxRequests = -1 }, "security.rate_limit.max_requests"},
+		{"目录不存在", func(c *Config) { c.System.LogDir = filepath.Join(file, "logs") }, "system.log_dir"},
+		{"文件根目录不是目录", func(c *Config) { c.System.FileRootDir = file }, "system.file_root_dir 不是目录"},
+		{"空闲连接数大于最大连接数", func(c *Config) { c.Database.MaxIdleConns, c.Database.MaxOpenConns = 20, 10 }, "max_idle_conns (20)"},
+		{"锁定时长与失败次数不一致", func(c *Config) { c.Auth.LockoutDuration = 0 }, "lockout_duration 必须大于0"},
+		{"未知的确认操作", func(c *Config) { c.Security.ConfirmActions = []string{"reboot"} }, `security.confirm_actions 不支持: "reboot"`},
+		{"启用邮件但未设置服务器", func(c *Config) { c.Mail.Enabled, c.Mail.From = true, "panel@example.com" }, "mail.enabled 启用时 smtp_host 不能为空"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig(t)
+			tt.modify(cfg)
+			err := cfg.Validate()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("期望 *ValidationError，实际 %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("错误 %q 中缺少 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAggregatesProblems(t *testing.T) {
+	cfg := validTestConfig(t)
+	cfg.System.Port = -1
+	cfg.System.Mode = "staging"
+	cfg.Log.Level = "verbose"
+
+	var verr *ValidationError
+	if err := cfg.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("期望 *ValidationError，实际 %v", err)
+	}
+	if len(verr.Problems) != 3 {
+		t.Fatalf("期望一次返回全部 3 个问题，实际 %d 个: %v", len(verr.Problems), verr.Problems)
+	}
+}
+
+func TestLoadRefusesInvalidConfig(t *testing.T) {
+	chdirWithConfig(t, `
+system:
+  mode: production
+`)
+
+	cfg, err := Load()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || cfg != nil {
+		t.Fatalf("校验失败时应拒绝加载，实际 %v, %v", cfg, err)
+	}
+}
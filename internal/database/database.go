@@ -1,9 +1,12 @@
 package database
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"web-panel-go/internal/config"
@@ -16,6 +19,33 @@ import (
 
 var db *gorm.DB
 
+// ErrDatabaseBusy 重试后数据库仍处于锁定状态
+var ErrDatabaseBusy = errors.New("数据库繁忙，请稍后重试")
+
+const (
+	// defaultBusyTimeout 未配置时的SQLite锁等待时间
+	defaultBusyTimeout = 5 * time.Second
+	// maxWriteRetries 写操作遇到锁冲突时的最大重试次数
+	maxWriteRetries = 5
+	// retryBaseDelay 重试的初始退避时间
+	retryBaseDelay = 50 * time.Millisecond
+	// maxRetryDuration 写操作连同重试的总耗时上限，需低于HTTP服务器15秒的WriteTimeout，否则重试成功时响应已无法写出
+	maxRetryDuration = 10 * time.Second
+)
+
+// busyTimeout 当前连接配置的锁等待时间，每次尝试最多阻塞这么久，用于判断剩余时间是否还够再试一次
+var busyTimeout = defaultBusyTimeout
+
+// journalModes 支持的SQLite日志模式
+var journalModes = map[string]bool{
+	"DELETE": true, "TRUNCATE": true, "PERSIST": true, "MEMORY": true, "WAL": true, "OFF": true,
+}
+
+// synchronousLevels 支持的SQLite同步级别
+var synchronousLevels = map[string]bool{
+	"OFF": true, "NORMAL": true, "FULL": true, "EXTRA": true,
+}
+
 // Init 初始化数据库连接
 func Init(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	// 确保数据库目录存在
@@ -25,11 +55,15 @@ func Init(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	}
 
 	// 配置GORM日志
-	gormLog := logger.NewGormLogger()
+	gormLog := logger.NewGormLogger(cfg.SlowQueryThreshold)
 
 	// 使用GORM SQLite驱动（纯Go实现，无需CGO）
-	var err error
-	db, err = gorm.Open(sqlite.Open(cfg.Path), &gorm.Config{
+	dsn, err := buildDSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+	busyTimeout = resolveBusyTimeout(cfg.BusyTimeout)
+	db, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{
 		Logger: gormLog,
 		DisableForeignKeyConstraintWhenMigrating: true,
 	})
@@ -50,39 +84,83 @@ func Init(cfg config.DatabaseConfig) (*gorm.DB, error) {
 
 	// 自动迁移数据库表
 	fmt.Println("开始数据库迁移...")
-	if err := autoMigrate(); err != nil {
+	if err := Migrate(db); err != nil {
 		fmt.Printf("数据库迁移详细错误: %v\n", err)
 		return nil, fmt.Errorf("数据库迁移失败: %w", err)
 	}
 	fmt.Println("数据库迁移成功")
 
 	// 初始化默认数据
-	if err := initDefaultData(); err != nil {
-		return nil, fmt.Errorf("初始化默认数据失败: %w", err)
+	if cfg.Seed.Enabled {
+		if err := Seed(db, cfg.Seed); err != nil {
+			return nil, fmt.Errorf("初始化默认数据失败: %w", err)
+		}
+	} else {
+		logger.Info("已跳过默认数据初始化")
 	}
 
-	logger.Info("数据库初始化成功", "path", cfg.Path)
+	logger.Info("数据库初始化成功", "path", cfg.Path, "journal_mode", cfg.JournalMode, "synchronous", cfg.Synchronous)
 	return db, nil
 }
 
-// autoMigrate 自动迁移数据库表
-func autoMigrate() error {
+// buildDSN 构建SQLite连接串
+// 通过 _pragma 参数设置的PRAGMA会由驱动在连接池每次新建连接时执行，保证所有连接配置一致
+func buildDSN(cfg config.DatabaseConfig) (string, error) {
+	pragmas := []string{
+		fmt.Sprintf("busy_timeout(%d)", resolveBusyTimeout(cfg.BusyTimeout).Milliseconds()),
+	}
+
+	if cfg.JournalMode != "" {
+		mode := strings.ToUpper(cfg.JournalMode)
+		if !journalModes[mode] {
+			return "", fmt.Errorf("不支持的journal_mode: %s", cfg.JournalMode)
+		}
+		pragmas = append(pragmas, fmt.Sprintf("journal_mode(%s)", mode))
+	}
+
+	if cfg.Synchronous != "" {
+		level := strings.ToUpper(cfg.Synchronous)
+		if !synchronousLevels[level] {
+			return "", fmt.Errorf("不支持的synchronous: %s", cfg.Synchronous)
+		}
+		pragmas = append(pragmas, fmt.Sprintf("synchronous(%s)", level))
+	}
+
+	if cfg.ForeignKeys {
+		pragmas = append(pragmas, "foreign_keys(1)")
+	} else {
+		pragmas = append(pragmas, "foreign_keys(0)")
+	}
+
+	sep := "?"
+	if strings.Contains(cfg.Path, "?") {
+		sep = "&"
+	}
+	return cfg.Path + sep + "_pragma=" + strings.Join(pragmas, "&_pragma="), nil
+}
+
+// Migrate 自动迁移数据库表
+func Migrate(conn *gorm.DB) error {
 	models := []interface{}{
 		&model.User{},
 		&model.Role{},
 		&model.Permission{},
 		&model.UserRole{},
 		&model.RolePermission{},
-		// &model.Session{}, // 临时跳过Session模型
+		&model.Session{},
 		&model.AuditLog{},
 		&model.SystemConfig{},
+		&model.UserPreference{},
+		&model.FileFavorite{},
+		&model.UserTOTP{},
 		&model.FileInfo{},
 		&model.ProcessInfo{},
+		&model.MetricSample{},
 	}
 	
 	for i, model := range models {
 		fmt.Printf("迁移模型 %d: %T\n", i+1, model)
-		if err := db.AutoMigrate(model); err != nil {
+		if err := conn.AutoMigrate(model); err != nil {
 			fmt.Printf("迁移模型 %T 失败: %v\n", model, err)
 			return err
 		}
@@ -92,20 +170,20 @@ func autoMigrate() error {
 	return nil
 }
 
-// initDefaultData 初始化默认数据
-func initDefaultData() error {
+// Seed 初始化默认数据（权限、角色、管理员），已存在的数据不会重复创建，可重复执行
+func Seed(conn *gorm.DB, seed config.SeedConfig) error {
 	// 初始化默认权限
-	if err := initDefaultPermissions(); err != nil {
+	if err := initDefaultPermissions(conn); err != nil {
 		return fmt.Errorf("初始化默认权限失败: %w", err)
 	}
 
 	// 初始化默认角色
-	if err := initDefaultRoles(); err != nil {
+	if err := initDefaultRoles(conn); err != nil {
 		return fmt.Errorf("初始化默认角色失败: %w", err)
 	}
 
 	// 初始化默认管理员用户
-	if err := initDefaultAdmin(); err != nil {
+	if err := initDefaultAdmin(conn, seed); err != nil {
 		return fmt.Errorf("初始化默认管理员失败: %w", err)
 	}
 
@@ -113,7 +191,7 @@ func initDefaultData() error {
 }
 
 // initDefaultPermissions 初始化默认权限
-func initDefaultPermissions() error {
+func initDefaultPermissions(conn *gorm.DB) error {
 	permissions := []model.Permission{
 		{Name: model.PermissionUserView, DisplayName: "查看用户", Resource: "user", Action: "view", IsSystem: true},
 		{Name: model.PermissionUserCreate, DisplayName: "创建用户", Resource: "user", Action: "create", IsSystem: true},
@@ -127,6 +205,7 @@ func initDefaultPermissions() error {
 		{Name: model.PermissionSystemMonitor, DisplayName: "系统监控", Resource: "system", Action: "monitor", IsSystem: true},
 		{Name: model.PermissionSystemConfig, DisplayName: "系统配置", Resource: "system", Action: "config", IsSystem: true},
 		{Name: model.PermissionFileView, DisplayName: "查看文件", Resource: "file", Action: "view", IsSystem: true},
+		{Name: model.PermissionFileDownload, DisplayName: "下载文件", Resource: "file", Action: "download", IsSystem: true},
 		{Name: model.PermissionFileCreate, DisplayName: "创建文件", Resource: "file", Action: "create", IsSystem: true},
 		{Name: model.PermissionFileUpdate, DisplayName: "更新文件", Resource: "file", Action: "update", IsSystem: true},
 		{Name: model.PermissionFileDelete, DisplayName: "删除文件", Resource: "file", Action: "delete", IsSystem: true},
@@ -136,19 +215,48 @@ func initDefaultPermissions() error {
 
 	for _, permission := range permissions {
 		var count int64
-		db.Model(&model.Permission{}).Where("name = ?", permission.Name).Count(&count)
+		conn.Model(&model.Permission{}).Where("name = ?", permission.Name).Count(&count)
 		if count == 0 {
-			if err := db.Create(&permission).Error; err != nil {
+			if err := conn.Create(&permission).Error; err != nil {
 				return err
 			}
+			if source, ok := permissionUpgrades[permission.Name]; ok {
+				if err := grantToRolesWith(conn, permission.ID, source); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
 	return nil
 }
 
+// permissionUpgrades 从已有权限中拆分出的新权限 -> 原权限
+// 新权限首次创建时授予已拥有原权限的角色，升级后各角色的访问能力保持不变
+var permissionUpgrades = map[string]string{
+	model.PermissionFileDownload: model.PermissionFileView,
+}
+
+// grantToRolesWith 把权限授予所有拥有 source 权限的角色
+func grantToRolesWith(conn *gorm.DB, permissionID uint, source string) error {
+	var roleIDs []uint
+	if err := conn.Model(&model.RolePermission{}).
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("permissions.name = ?", source).
+		Pluck("role_permissions.role_id", &roleIDs).Error; err != nil {
+		return err
+	}
+
+	for _, roleID := range roleIDs {
+		if err := conn.Create(&model.RolePermission{RoleID: roleID, PermissionID: permissionID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // initDefaultRoles 初始化默认角色
-func initDefaultRoles() error {
+func initDefaultRoles(conn *gorm.DB) error {
 	roles := []model.Role{
 		{Name: model.RoleAdmin, DisplayName: "超级管理员", Description: "拥有所有权限的超级管理员", IsSystem: true, Status: model.RoleStatusActive},
 		{Name: model.RoleUser, DisplayName: "普通用户", Description: "普通用户角色", IsSystem: true, Status: model.RoleStatusActive},
@@ -158,9 +266,9 @@ func initDefaultRoles() error {
 
 	for _, role := range roles {
 		var count int64
-		db.Model(&model.Role{}).Where("name = ?", role.Name).Count(&count)
+		conn.Model(&model.Role{}).Where("name = ?", role.Name).Count(&count)
 		if count == 0 {
-			if err := db.Create(&role).Error; err != nil {
+			if err := conn.Create(&role).Error; err != nil {
 				return err
 			}
 		}
@@ -168,24 +276,74 @@ func initDefaultRoles() error {
 
 	// 为管理员角色分配所有权限
 	var adminRole model.Role
-	if err := db.Where("name = ?", model.RoleAdmin).First(&adminRole).Error; err != nil {
+	if err := conn.Where("name = ?", model.RoleAdmin).First(&adminRole).Error; err != nil {
 		return err
 	}
 
 	var permissions []model.Permission
-	if err := db.Find(&permissions).Error; err != nil {
+	if err := conn.Find(&permissions).Error; err != nil {
 		return err
 	}
 
 	for _, permission := range permissions {
 		var count int64
-		db.Model(&model.RolePermission{}).Where("role_id = ? AND permission_id = ?", adminRole.ID, permission.ID).Count(&count)
+		conn.Model(&model.RolePermission{}).Where("role_id = ? AND permission_id = ?", adminRole.ID, permission.ID).Count(&count)
 		if count == 0 {
 			rolePermission := model.RolePermission{
 				RoleID:       adminRole.ID,
 				PermissionID: permission.ID,
 			}
-			if err := db.Create(&rolePermission).Error; err != nil {
+			if err := conn.Create(&rolePermission).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return initBuiltinRolePermissions(conn)
+}
+
+// builtinRolePermissions 内置角色的默认权限
+var builtinRolePermissions = map[string][]string{
+	model.RoleUser: {
+		model.PermissionSystemView,
+		model.PermissionFileView, model.PermissionFileDownload, model.PermissionFileCreate, model.PermissionFileUpdate,
+		model.PermissionFileDelete, model.PermissionFileUpload,
+	},
+	model.RoleModerator: {
+		model.PermissionSystemView, model.PermissionSystemMonitor,
+		model.PermissionUserView, model.PermissionAuditView,
+		model.PermissionFileView, model.PermissionFileDownload, model.PermissionFileCreate, model.PermissionFileUpdate,
+		model.PermissionFileDelete, model.PermissionFileUpload,
+	},
+	model.RoleGuest: {
+		model.PermissionSystemView,
+		model.PermissionFileView, model.PermissionFileDownload,
+	},
+}
+
+// initBuiltinRolePermissions 为还没有任何权限的内置角色分配默认权限
+// 已分配过权限的角色保持不变，管理员调整后的权限不会在重启时被覆盖
+func initBuiltinRolePermissions(conn *gorm.DB) error {
+	for roleName, permissionNames := range builtinRolePermissions {
+		var role model.Role
+		if err := conn.Where("name = ?", roleName).First(&role).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := conn.Model(&model.RolePermission{}).Where("role_id = ?", role.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		var permissions []model.Permission
+		if err := conn.Where("name IN ?", permissionNames).Find(&permissions).Error; err != nil {
+			return err
+		}
+		for _, permission := range permissions {
+			if err := conn.Create(&model.RolePermission{RoleID: role.ID, PermissionID: permission.ID}).Error; err != nil {
 				return err
 			}
 		}
@@ -195,49 +353,143 @@ func initDefaultRoles() error {
 }
 
 // initDefaultAdmin 初始化默认管理员用户
-func initDefaultAdmin() error {
+// 已有任何用户拥有管理员角色或同名用户已存在时不再创建
+func initDefaultAdmin(conn *gorm.DB, seed config.SeedConfig) error {
+	var adminRole model.Role
+	if err := conn.Where("name = ?", model.RoleAdmin).First(&adminRole).Error; err != nil {
+		return fmt.Errorf("查找管理员角色失败: %w", err)
+	}
+
 	// 检查是否已存在管理员用户
 	var count int64
-	if err := db.Model(&model.User{}).Where("username = ?", "admin").Count(&count).Error; err != nil {
+	if err := conn.Model(&model.UserRole{}).
+		Joins("JOIN users ON users.id = user_roles.user_id AND users.deleted_at IS NULL").
+		Where("user_roles.role_id = ?", adminRole.ID).
+		Count(&count).Error; err != nil {
 		return fmt.Errorf("检查管理员用户失败: %w", err)
 	}
+	if count > 0 {
+		return nil
+	}
+
+	username := strings.TrimSpace(seed.AdminUsername)
+	if username == "" {
+		username = "admin"
+	}
+	if err := conn.Model(&model.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return fmt.Errorf("检查管理员用户失败: %w", err)
+	}
+	if count > 0 {
+		logger.Warn("默认管理员用户名已被占用，跳过创建", "username", username)
+		return nil
+	}
 
-	// 如果没有管理员用户，创建默认管理员
-	if count == 0 {
-		adminUser := &model.User{
-			Username: "admin",
-			Email:    "admin@localhost",
-			Nickname: "系统管理员",
-			Status:   model.UserStatusActive,
+	email := strings.TrimSpace(seed.AdminEmail)
+	if email == "" {
+		email = username + "@localhost"
+	}
+	// 未配置初始密码时随机生成，避免所有部署共用同一个默认密码
+	password := seed.AdminPassword
+	generated := password == ""
+	if generated {
+		var err error
+		if password, err = generateAdminPassword(seed.PasswordPolicy); err != nil {
+			return err
 		}
+	}
 
-		// 设置默认密码 (需要在User模型中实现SetPassword方法)
-		adminUser.Password = "$2a$10$N9qo8uLOickgx2ZMRZoMye.IjPFvmRaN7eU9h/.OFGOyDoBKXukdK" // admin123的bcrypt哈希
+	adminUser := &model.User{
+		Username: username,
+		Email:    email,
+		Nickname: "系统管理员",
+		Status:   model.UserStatusActive,
+	}
+	if err := adminUser.SetPassword(password, seed.PasswordPolicy); err != nil {
+		return fmt.Errorf("管理员初始密码 database.seed.admin_password 不满足密码策略: %w", err)
+	}
 
-		if err := db.Create(adminUser).Error; err != nil {
+	// 创建用户和分配角色在同一事务中完成，避免留下没有角色的管理员
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(adminUser).Error; err != nil {
 			return fmt.Errorf("创建默认管理员失败: %w", err)
 		}
 
-		// 为管理员分配管理员角色
-		var adminRole model.Role
-		if err := db.Where("name = ?", model.RoleAdmin).First(&adminRole).Error; err != nil {
-			return fmt.Errorf("查找管理员角色失败: %w", err)
-		}
-
 		userRole := model.UserRole{
 			UserID: adminUser.ID,
 			RoleID: adminRole.ID,
 		}
-		if err := db.Create(&userRole).Error; err != nil {
+		if err := tx.Create(&userRole).Error; err != nil {
 			return fmt.Errorf("分配管理员角色失败: %w", err)
 		}
-
-		logger.Info("创建默认管理员用户", "username", "admin", "password", "admin123")
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	logger.Info("创建默认管理员用户", "username", username, "email", email)
+	if generated {
+		revealAdminPassword(username, password, seed.PasswordFile)
+	}
 	return nil
 }
 
+// revealAdminPassword 把随机生成的初始密码交给部署者，不写入日志
+// 优先写入权限为0600的文件，未配置文件或写入失败时只输出一次到标准错误
+func revealAdminPassword(username, password, passwordFile string) {
+	if passwordFile != "" {
+		err := writeSecretFile(passwordFile, fmt.Sprintf("username: %s\npassword: %s\n", username, password))
+		if err == nil {
+			logger.Warn("已为默认管理员生成随机初始密码，请查看密码文件，登录修改密码后删除该文件", "username", username, "file", passwordFile)
+			return
+		}
+		logger.Error("写入管理员初始密码文件失败", "error", err, "file", passwordFile)
+	}
+	logger.Warn("已为默认管理员生成随机初始密码，已输出到标准错误", "username", username)
+	fmt.Fprintf(os.Stderr, "默认管理员 %s 的初始密码（仅显示一次，请登录后立即修改）: %s\n", username, password)
+}
+
+// writeSecretFile 以0600权限写入文件，已存在的文件会被覆盖并收紧权限
+func writeSecretFile(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// adminPasswordChars 随机初始密码的字符集，共64个字符，去掉了易混淆的字符
+const adminPasswordChars = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789@#%+=-_"
+
+// generateAdminPassword 生成满足密码策略的随机初始密码，长度至少16位
+func generateAdminPassword(policy config.PasswordPolicyConfig) (string, error) {
+	length := 16
+	if policy.MinLength > length {
+		length = policy.MinLength
+	}
+	buf := make([]byte, length)
+	for attempt := 0; attempt < 100; attempt++ {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("生成管理员初始密码失败: %w", err)
+		}
+		for i, b := range buf {
+			buf[i] = adminPasswordChars[int(b)%len(adminPasswordChars)]
+		}
+		if model.ValidatePassword(string(buf), policy) == nil {
+			return string(buf), nil
+		}
+	}
+	return "", errors.New("生成管理员初始密码失败: 无法满足密码策略")
+}
+
 // GetDB 获取数据库实例
 func GetDB() *gorm.DB {
 	return db
@@ -260,6 +512,58 @@ func Transaction(fn func(*gorm.DB) error) error {
 	return db.Transaction(fn)
 }
 
+// IsBusyError 判断是否为SQLite锁冲突错误（database is locked / SQLITE_BUSY）
+func IsBusyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// resolveBusyTimeout 未配置锁等待时间时使用默认值
+func resolveBusyTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return defaultBusyTimeout
+	}
+	return timeout
+}
+
+// WithRetry 执行写操作，遇到锁冲突时按指数退避进行有限次数的重试
+// 每次尝试最多等待 busy_timeout，剩余时间不足以再完整等待一次时不再重试，总耗时不超过 maxRetryDuration
+func WithRetry(fn func() error) error {
+	return withRetry(fn, maxRetryDuration, busyTimeout)
+}
+
+// withRetry WithRetry 的实现，budget 为总耗时上限，wait 为单次尝试最长的锁等待时间
+func withRetry(fn func() error, budget, wait time.Duration) error {
+	start := time.Now()
+	delay := retryBaseDelay
+	var err error
+	for attempt := 1; attempt <= maxWriteRetries; attempt++ {
+		err = fn()
+		if !IsBusyError(err) {
+			return err
+		}
+		if attempt == maxWriteRetries || time.Since(start)+delay+wait > budget {
+			break
+		}
+		logger.Warn("数据库被锁定，准备重试", "attempt", attempt, "delay", delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return fmt.Errorf("%w: %v", ErrDatabaseBusy, err)
+}
+
+// RetryTransaction 在指定连接上执行带锁冲突重试的事务
+func RetryTransaction(conn *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return WithRetry(func() error {
+		return conn.Transaction(fn)
+	})
+}
+
 // Paginate 分页查询
 func Paginate(page, pageSize int) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
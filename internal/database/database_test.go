@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	// 重试时会记录警告日志，测试中丢弃输出
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// errLocked 模拟SQLite锁冲突
+var errLocked = errors.New("database is locked (5) (SQLITE_BUSY)")
+
+func TestWithRetrySucceedsAfterBusy(t *testing.T) {
+	attempts := 0
+	err := withRetry(func() error {
+		attempts++
+		if attempts < 3 {
+			return errLocked
+		}
+		return nil
+	}, time.Second, 0)
+	if err != nil || attempts != 3 {
+		t.Fatalf("withRetry 返回 %v，尝试 %d 次，期望第 3 次成功", err, attempts)
+	}
+}
+
+func TestWithRetryDoesNotRetryOtherErrors(t *testing.T) {
+	attempts := 0
+	other := errors.New("UNIQUE constraint failed")
+	if err := withRetry(func() error { attempts++; return other }, time.Second, 0); err != other || attempts != 1 {
+		t.Fatalf("withRetry 返回 %v，尝试 %d 次，期望直接返回原错误", err, attempts)
+	}
+}
+
+func TestWithRetryStaysWithinBudget(t *testing.T) {
+	// 每次尝试都等满锁等待时间后失败，剩余时间不够再等一次时停止重试
+	const wait, budget = 100 * time.Millisecond, 300 * time.Millisecond
+	attempts := 0
+	start := time.Now()
+	err := withRetry(func() error {
+		attempts++
+		time.Sleep(wait)
+		return errLocked
+	}, budget, wait)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrDatabaseBusy) {
+		t.Fatalf("withRetry 返回 %v，期望 ErrDatabaseBusy", err)
+	}
+	if elapsed > budget {
+		t.Fatalf("总耗时 %v 超过上限 %v", elapsed, budget)
+	}
+	if attempts != 2 {
+		t.Fatalf("尝试 %d 次，期望 2 次", attempts)
+	}
+}
+
+func TestWithRetryBudgetBelowWriteTimeout(t *testing.T) {
+	// 默认锁等待时间下，最坏情况的总耗时仍低于HTTP服务器15秒的WriteTimeout
+	if maxRetryDuration >= 15*time.Second {
+		t.Fatalf("maxRetryDuration = %v，需低于 15s", maxRetryDuration)
+	}
+	if defaultBusyTimeout >= maxRetryDuration {
+		t.Fatalf("defaultBusyTimeout = %v 不小于 maxRetryDuration，无法重试", defaultBusyTimeout)
+	}
+}
+
+// seedPolicy 与配置默认值相同的密码策略
+var seedPolicy = config.PasswordPolicyConfig{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RejectCommon: true}
+
+func TestSeedRejectsWeakAdminPassword(t *testing.T) {
+	for _, password := range []string{"admin123", "Short1", "alllowercase1"} {
+		if _, err := OpenInMemory(config.SeedConfig{Enabled: true, AdminPassword: password, PasswordPolicy: seedPolicy}); !errors.Is(err, model.ErrInvalidPassword) {
+			t.Fatalf("初始密码 %q 返回 %v，期望 ErrInvalidPassword", password, err)
+		}
+	}
+}
+
+func TestSeedGeneratesAdminPassword(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "initial_admin_password")
+	conn, err := OpenInMemory(config.SeedConfig{Enabled: true, AdminUsername: "admin", PasswordPolicy: seedPolicy, PasswordFile: passwordFile})
+	if err != nil {
+		t.Fatalf("未配置初始密码时初始化失败: %v", err)
+	}
+	var admin model.User
+	if err := conn.Where("username = ?", "admin").First(&admin).Error; err != nil {
+		t.Fatalf("没有创建默认管理员: %v", err)
+	}
+	if admin.Password == "" {
+		t.Fatal("默认管理员没有密码")
+	}
+
+	// 生成的密码只写入权限为0600的文件
+	info, err := os.Stat(passwordFile)
+	if err != nil {
+		t.Fatalf("没有写入初始密码文件: %v", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Fatalf("初始密码文件权限 = %v, 期望 0600", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(passwordFile)
+	_, password, _ := strings.Cut(string(data), "password: ")
+	if err := admin.CheckPassword(strings.TrimSpace(password)); err != nil {
+		t.Fatalf("密码文件中的密码无法登录: %q", data)
+	}
+
+	strict := seedPolicy
+	strict.MinLength = 24
+	strict.RequireSymbol = true
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		password, err := generateAdminPassword(strict)
+		if err != nil {
+			t.Fatalf("生成初始密码失败: %v", err)
+		}
+		if err := model.ValidatePassword(password, strict); err != nil {
+			t.Fatalf("生成的密码 %q 不满足策略: %v", password, err)
+		}
+		if seen[password] {
+			t.Fatalf("生成了重复的密码 %q", password)
+		}
+		seen[password] = true
+	}
+}
+
+func TestInitAppliesPragmasToEveryConnection(t *testing.T) {
+	conn, err := Init(config.DatabaseConfig{
+		Type:         "sqlite",
+		Path:         filepath.Join(t.TempDir(), "panel.db"),
+		MaxIdleConns: 2,
+		MaxOpenConns: 4,
+		JournalMode:  "wal",
+		BusyTimeout:  2 * time.Second,
+		Synchronous:  "normal",
+		ForeignKeys:  true,
+	})
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// 同时持有两个连接，第二个必然是连接池新建的连接
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		c, err := sqlDB.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		var journalMode string
+		var busyTimeout, synchronous, foreignKeys int
+		if err := c.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode); err != nil {
+			t.Fatal(err)
+		}
+		c.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout)
+		c.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&synchronous)
+		c.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys)
+
+		// synchronous: 0=OFF 1=NORMAL 2=FULL 3=EXTRA
+		if journalMode != "wal" || busyTimeout != 2000 || synchronous != 1 || foreignKeys != 1 {
+			t.Fatalf("连接 %d 的PRAGMA: journal_mode=%s busy_timeout=%d synchronous=%d foreign_keys=%d",
+				i, journalMode, busyTimeout, synchronous, foreignKeys)
+		}
+	}
+}
+
+func TestBuildDSNRejectsUnknownPragmaValues(t *testing.T) {
+	if _, err := buildDSN(config.DatabaseConfig{Path: "panel.db", JournalMode: "fast"}); err == nil {
+		t.Fatal("不支持的 journal_mode 应返回错误")
+	}
+	if _, err := buildDSN(config.DatabaseConfig{Path: "panel.db", Synchronous: "sometimes"}); err == nil {
+		t.Fatal("不支持的 synchronous 应返回错误")
+	}
+}
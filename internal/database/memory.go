@@ -0,0 +1,41 @@
+package database
+
+import (
+	"fmt"
+
+	"web-panel-go/internal/config"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// OpenInMemory 创建独立的内存SQLite数据库并完成迁移，seed.Enabled 为true时写入默认数据
+// 用于测试和本地调试，返回的连接不会替换全局数据库实例
+func OpenInMemory(seed config.SeedConfig) (*gorm.DB, error) {
+	conn, err := gorm.Open(sqlite.Open(":memory:?_pragma=foreign_keys(1)"), &gorm.Config{
+		Logger:                                   gormlogger.Default.LogMode(gormlogger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("打开内存数据库失败: %w", err)
+	}
+
+	// 内存数据库每个连接都是独立的库，只保留一个连接
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := Migrate(conn); err != nil {
+		return nil, fmt.Errorf("数据库迁移失败: %w", err)
+	}
+	if seed.Enabled {
+		if err := Seed(conn, seed); err != nil {
+			return nil, fmt.Errorf("初始化默认数据失败: %w", err)
+		}
+	}
+
+	return conn, nil
+}
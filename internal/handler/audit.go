@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/middleware"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler 审计日志处理器
+type AuditHandler struct {
+	auditService *service.AuditService
+	authService  *service.AuthService
+}
+
+// NewAuditHandler 创建审计日志处理器实例
+func NewAuditHandler(auditService *service.AuditService, authService *service.AuthService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		authService:  authService,
+	}
+}
+
+// GetAuditLogs 获取审计日志列表
+// @Summary 获取审计日志列表
+// @Description 分页查询审计日志，支持按用户、操作、状态、IP和时间范围过滤
+// @Tags 审计日志
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param user_id query int false "用户ID"
+// @Param action query string false "操作"
+// @Param status query string false "状态"
+// @Param ip query string false "IP地址"
+// @Param from query string false "开始时间（RFC3339或2006-01-02）"
+// @Param to query string false "结束时间（RFC3339或2006-01-02）"
+// @Success 200 {object} model.APIResponse{data=model.PaginatedResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/audit [get]
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	// 获取分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	// 参数验证
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	logs, total, err := h.auditService.GetAuditLogs(filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取审计日志失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 构建分页响应
+	response := model.PaginatedResponse{
+		Data:     logs,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取审计日志成功",
+		Data:    response,
+	})
+}
+
+// GetAuditStats 获取审计日志统计
+// @Summary 获取审计日志统计
+// @Description 按操作和小时/天统计审计日志数量及成功/失败比例
+// @Tags 审计日志
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param interval query string false "统计间隔 hour|day" default(hour)
+// @Param ip query string false "IP地址"
+// @Param from query string false "开始时间（RFC3339或2006-01-02）"
+// @Param to query string false "结束时间（RFC3339或2006-01-02）"
+// @Success 200 {object} model.APIResponse{data=model.AuditStats}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/audit/stats [get]
+func (h *AuditHandler) GetAuditStats(c *gin.Context) {
+	interval := c.DefaultQuery("interval", "hour")
+	if interval != "hour" && interval != "day" {
+		c.JSON(http.StatusBadRequest, model.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "统计间隔只能为hour或day",
+		})
+		return
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	stats, err := h.auditService.GetAuditStats(filter, interval)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取审计统计失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取审计统计成功",
+		Data:    stats,
+	})
+}
+
+// GetAuditLog 获取审计日志详情
+// @Summary 获取审计日志详情
+// @Description 根据ID获取单条审计日志，包含操作者用户名及解析后的结构化详情
+// @Tags 审计日志
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "审计日志ID"
+// @Success 200 {object} model.APIResponse{data=model.AuditLogDetail}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Router /api/audit/{id} [get]
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的审计日志ID",
+		})
+		return
+	}
+
+	auditLog, err := h.auditService.GetAuditLog(uint(id))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrAuditLogNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, model.ErrorResponse{
+			Code:    statusCode,
+			Message: "获取审计日志失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取审计日志成功",
+		Data:    auditLog,
+	})
+}
+
+// ExportAuditLogs 导出审计日志
+// @Summary 导出审计日志
+// @Description 按查询条件流式导出审计日志，支持csv和jsonl格式，过滤条件与列表查询相同
+// @Tags 审计日志
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "导出格式 csv|jsonl" default(csv)
+// @Param user_id query int false "用户ID"
+// @Param action query string false "操作"
+// @Param status query string false "状态"
+// @Param ip query string false "IP地址"
+// @Param from query string false "开始时间（RFC3339或2006-01-02）"
+// @Param to query string false "结束时间（RFC3339或2006-01-02）"
+// @Success 200 {file} file
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/audit/export [get]
+func (h *AuditHandler) ExportAuditLogs(c *gin.Context) {
+	format := c.DefaultQuery("format", service.AuditExportCSV)
+	if format != service.AuditExportCSV && format != service.AuditExportJSONL {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "不支持的导出格式",
+			Error:   fmt.Sprintf("format 必须为 %s 或 %s", service.AuditExportCSV, service.AuditExportJSONL),
+		})
+		return
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	// 设置响应头
+	contentType := "text/csv; charset=utf-8"
+	if format == service.AuditExportJSONL {
+		contentType = "application/x-ndjson"
+	}
+	filename := fmt.Sprintf("audit-logs-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	clearWriteDeadline(c)
+
+	if _, err := h.auditService.ExportAuditLogs(&flushWriter{w: c.Writer}, filter, format, userID, clientIP, userAgent); err != nil {
+		logger.Error("审计日志导出中断", "error", err, "user_id", userID)
+		c.Abort()
+	}
+}
+
+// parseAuditFilter 解析审计日志查询条件
+func parseAuditFilter(c *gin.Context) (*model.AuditLogFilter, error) {
+	filter := &model.AuditLogFilter{
+		Action:    c.Query("action"),
+		Status:    c.Query("status"),
+		IPAddress: c.Query("ip"),
+	}
+
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.ParseUint(userIDStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("无效的用户ID: %s", userIDStr)
+		}
+		id := uint(userID)
+		filter.UserID = &id
+	}
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		from, err := parseTimeParam(fromStr)
+		if err != nil {
+			return nil, err
+		}
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		to, err := parseTimeParam(toStr)
+		if err != nil {
+			return nil, err
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// parseTimeParam 解析时间参数，支持RFC3339和日期格式
+func parseTimeParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("无效的时间格式: %s", value)
+}
+
+// RegisterAuditRoutes 注册审计日志相关路由
+func RegisterAuditRoutes(r *gin.RouterGroup, auditHandler *AuditHandler) {
+	audit := r.Group("/audit")
+	audit.Use(middleware.AuthMiddleware(auditHandler.authService))
+	audit.Use(middleware.RequirePermission(model.PermissionAuditView))
+	{
+		audit.GET("", auditHandler.GetAuditLogs)
+		audit.GET("/stats", auditHandler.GetAuditStats)
+		audit.GET("/export", auditHandler.ExportAuditLogs)
+		audit.GET("/:id", auditHandler.GetAuditLog)
+	}
+}
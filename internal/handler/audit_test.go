@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetAuditLogByID(t *testing.T) {
+	db := newTestDB(t)
+	auth := service.NewAuthService(db, newTestConfig(t), service.NewRBACCache(db))
+	router := gin.New()
+	RegisterAuditRoutes(router.Group("/api"), NewAuditHandler(service.NewAuditService(db), auth))
+	createUserWithPermissions(t, db, "auditor", model.PermissionAuditView)
+	createUserWithPermissions(t, db, "viewer", model.PermissionFileView)
+	auditorToken := loginAs(t, auth, "auditor")
+	viewerToken := loginAs(t, auth, "viewer")
+
+	var auditor model.User
+	if err := db.Where("username = ?", "auditor").First(&auditor).Error; err != nil {
+		t.Fatal(err)
+	}
+	entry := &model.AuditLog{UserID: &auditor.ID, Action: "update_settings", Resource: "system", Details: `{"key":"file.show_hidden","value":"false"}`, Status: "success"}
+	if err := db.Create(entry).Error; err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/audit/" + strconv.FormatUint(uint64(entry.ID), 10)
+
+	w := authRequest(router, http.MethodGet, path, auditorToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("查询审计日志返回 %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data model.AuditLogDetail `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.ID != entry.ID || resp.Data.Username != "auditor" {
+		t.Fatalf("审计日志 %+v，期望ID %d、操作者 auditor", resp.Data, entry.ID)
+	}
+	if details, ok := resp.Data.DetailsData.(map[string]interface{}); !ok || details["key"] != "file.show_hidden" {
+		t.Fatalf("结构化详情未解析: %#v", resp.Data.DetailsData)
+	}
+
+	tests := []struct {
+		path  string
+		token string
+		want  int
+	}{
+		{"/api/audit/99999", auditorToken, http.StatusNotFound},
+		{"/api/audit/abc", auditorToken, http.StatusBadRequest},
+		{path, viewerToken, http.StatusForbidden},
+		{path, "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if w := authRequest(router, http.MethodGet, tt.path, tt.token, ""); w.Code != tt.want {
+			t.Errorf("GET %s 返回 %d，期望 %d: %s", tt.path, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"web-panel-go/internal/middleware"
 	"web-panel-go/internal/model"
@@ -12,13 +15,17 @@ import (
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
-	authService *service.AuthService
+	authService       *service.AuthService
+	auditService      *service.AuditService
+	preferenceService *service.PreferenceService
 }
 
 // NewAuthHandler 创建认证处理器实例
-func NewAuthHandler(authService *service.AuthService) *AuthHandler {
+func NewAuthHandler(authService *service.AuthService, auditService *service.AuditService, preferenceService *service.PreferenceService) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:       authService,
+		auditService:      auditService,
+		preferenceService: preferenceService,
 	}
 }
 
@@ -31,7 +38,7 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 // @Param request body model.LoginRequest true "登录请求"
 // @Success 200 {object} model.APIResponse{data=model.LoginResponse} "登录成功"
 // @Failure 400 {object} model.ErrorResponse "请求参数错误"
-// @Failure 401 {object} model.ErrorResponse "认证失败"
+// @Failure 401 {object} model.ErrorResponse "认证失败（启用两步验证时需提供验证码）"
 // @Router /api/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req model.LoginRequest
@@ -183,8 +190,10 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	// 执行密码修改
 	if err := h.authService.ChangePassword(userID, &req, clientIP, userAgent); err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "旧密码错误" {
+		if errors.Is(err, service.ErrWrongPassword) {
 			statusCode = http.StatusUnauthorized
+		} else if service.IsCredentialValidationError(err) {
+			statusCode = http.StatusBadRequest
 		}
 
 		c.JSON(statusCode, model.ErrorResponse{
@@ -201,18 +210,19 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	})
 }
 
-// RefreshToken 刷新令牌
-// @Summary 刷新令牌
-// @Description 刷新JWT令牌
+// SetupTwoFactor 设置两步验证
+// @Summary 设置两步验证
+// @Description 生成新的TOTP密钥和otpauth链接（用于二维码），需调用启用接口验证后才生效
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} model.APIResponse{data=model.LoginResponse} "刷新成功"
+// @Success 200 {object} model.APIResponse{data=model.TwoFactorSetupResponse} "生成成功"
 // @Failure 401 {object} model.ErrorResponse "未认证"
-// @Router /api/auth/refresh [post]
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	user, exists := middleware.GetCurrentUser(c)
+// @Failure 409 {object} model.ErrorResponse "两步验证已启用"
+// @Router /api/auth/2fa/setup [post]
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
 			Code:    http.StatusUnauthorized,
@@ -221,21 +231,123 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 生成新令牌
-	token, expiresAt, err := h.authService.GenerateToken(user)
+	resp, err := h.authService.SetupTwoFactor(userID, c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "生成令牌失败",
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrTwoFactorEnabled) {
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, model.ErrorResponse{
+			Code:    statusCode,
+			Message: "设置两步验证失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "请使用验证器应用扫描二维码后输入验证码启用",
+		Data:    resp,
+	})
+}
+
+// EnableTwoFactor 启用两步验证
+// @Summary 启用两步验证
+// @Description 校验验证器应用生成的验证码后启用两步验证，返回只显示一次的恢复码
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.EnableTwoFactorRequest true "验证码"
+// @Success 200 {object} model.APIResponse{data=model.EnableTwoFactorResponse} "启用成功"
+// @Failure 400 {object} model.ErrorResponse "请求参数错误、未设置或验证码错误"
+// @Failure 401 {object} model.ErrorResponse "未认证"
+// @Failure 409 {object} model.ErrorResponse "两步验证已启用"
+// @Router /api/auth/2fa/enable [post]
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req model.EnableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "未认证的用户",
+		})
+		return
+	}
+
+	resp, err := h.authService.EnableTwoFactor(userID, req.Code, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrTwoFactorEnabled):
+			statusCode = http.StatusConflict
+		case errors.Is(err, service.ErrTwoFactorNotSetup), errors.Is(err, service.ErrTwoFactorInvalid):
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, model.ErrorResponse{
+			Code:    statusCode,
+			Message: "启用两步验证失败",
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	resp := &model.LoginResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
-		User:      user.ToSafeJSON(),
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "两步验证已启用，请妥善保存恢复码",
+		Data:    resp,
+	})
+}
+
+// RefreshToken 刷新令牌
+// @Summary 刷新令牌
+// @Description 使用刷新令牌换取新的访问令牌和刷新令牌，旧令牌随即失效；重复使用已轮换的刷新令牌会撤销整个会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body model.RefreshTokenRequest true "刷新令牌请求"
+// @Success 200 {object} model.APIResponse{data=model.LoginResponse} "刷新成功"
+// @Failure 400 {object} model.ErrorResponse "请求参数错误"
+// @Failure 401 {object} model.ErrorResponse "刷新令牌无效或已被使用"
+// @Failure 500 {object} model.ErrorResponse "服务器内部错误"
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req model.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	resp, err := h.authService.RefreshSession(req.RefreshToken, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrRefreshTokenInvalid) || errors.Is(err, service.ErrRefreshTokenReused) ||
+			errors.Is(err, service.ErrUserNotFound) || errors.Is(err, service.ErrUserDisabled) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "令牌刷新失败",
+			Error:   err.Error(),
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, model.APIResponse{
@@ -275,6 +387,166 @@ func (h *AuthHandler) ValidateToken(c *gin.Context) {
 	})
 }
 
+// GetActivity 获取当前用户的活动记录
+// @Summary 获取当前用户的活动记录
+// @Description 分页获取当前用户本人的审计记录（登录、修改密码等）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} model.APIResponse{data=model.PaginatedResponse}
+// @Failure 401 {object} model.ErrorResponse "未认证"
+// @Failure 500 {object} model.ErrorResponse "服务器错误"
+// @Router /api/auth/activity [get]
+func (h *AuthHandler) GetActivity(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "未认证的用户",
+		})
+		return
+	}
+
+	// 获取分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	// 参数验证
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	logs, total, err := h.auditService.GetUserActivity(userID, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取活动记录失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 构建分页响应
+	response := model.PaginatedResponse{
+		Data:     logs,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取活动记录成功",
+		Data:    response,
+	})
+}
+
+// GetPreferences 获取当前用户的界面偏好
+// @Summary 获取当前用户的界面偏好
+// @Description 获取当前用户保存的界面偏好（主题、默认路径、分页大小等）
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=map[string]interface{}}
+// @Failure 401 {object} model.ErrorResponse "未认证"
+// @Failure 500 {object} model.ErrorResponse "服务器错误"
+// @Router /api/auth/preferences [get]
+func (h *AuthHandler) GetPreferences(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "未认证的用户",
+		})
+		return
+	}
+
+	preferences, err := h.preferenceService.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取偏好失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取偏好成功",
+		Data:    preferences,
+	})
+}
+
+// UpdatePreferences 更新当前用户的界面偏好
+// @Summary 更新当前用户的界面偏好
+// @Description 合并更新当前用户的界面偏好，值为null的键会被删除
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body map[string]interface{} true "偏好键值"
+// @Success 200 {object} model.APIResponse{data=map[string]interface{}}
+// @Failure 400 {object} model.ErrorResponse "请求参数错误"
+// @Failure 401 {object} model.ErrorResponse "未认证"
+// @Failure 413 {object} model.ErrorResponse "偏好数据过大"
+// @Router /api/auth/preferences [put]
+func (h *AuthHandler) UpdatePreferences(c *gin.Context) {
+	userID, exists := middleware.GetCurrentUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:    http.StatusUnauthorized,
+			Message: "未认证的用户",
+		})
+		return
+	}
+
+	// 限制请求体大小
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxPreferencesPayloadSize)
+
+	var req map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&req); err != nil {
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	preferences, err := h.preferenceService.UpdatePreferences(userID, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrPreferencesTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "更新偏好失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "更新偏好成功",
+		Data:    preferences,
+	})
+}
+
 // RegisterRoutes 注册认证相关路由
 // RegisterAuthRoutes 注册认证路由
 func RegisterAuthRoutes(r *gin.RouterGroup, authHandler *AuthHandler) {
@@ -282,6 +554,7 @@ func RegisterAuthRoutes(r *gin.RouterGroup, authHandler *AuthHandler) {
 	{
 		// 公开路由（无需认证）
 		auth.POST("/login", authHandler.Login)
+		auth.POST("/refresh", authHandler.RefreshToken)
 
 		// 需要认证的路由
 		authenticated := auth.Group("")
@@ -290,8 +563,12 @@ func RegisterAuthRoutes(r *gin.RouterGroup, authHandler *AuthHandler) {
 			authenticated.POST("/logout", authHandler.Logout)
 			authenticated.GET("/profile", authHandler.GetProfile)
 			authenticated.POST("/change-password", authHandler.ChangePassword)
-			authenticated.POST("/refresh", authHandler.RefreshToken)
+			authenticated.POST("/2fa/setup", authHandler.SetupTwoFactor)
+			authenticated.POST("/2fa/enable", authHandler.EnableTwoFactor)
 			authenticated.GET("/validate", authHandler.ValidateToken)
+			authenticated.GET("/activity", authHandler.GetActivity)
+			authenticated.GET("/preferences", authHandler.GetPreferences)
+			authenticated.PUT("/preferences", authHandler.UpdatePreferences)
 		}
 	}
 }
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newTestAuthRouter 创建注册了认证路由的测试路由
+func newTestAuthRouter(t *testing.T) (*gin.Engine, *gorm.DB, *service.AuthService) {
+	t.Helper()
+	db := newTestDB(t)
+	auth := service.NewAuthService(db, newTestConfig(t), service.NewRBACCache(db))
+	router := gin.New()
+	RegisterAuthRoutes(router.Group("/api"), NewAuthHandler(auth, service.NewAuditService(db), service.NewPreferenceService(db)))
+	return router, db, auth
+}
+
+func TestGetActivityReturnsOnlyOwnEntries(t *testing.T) {
+	router, db, auth := newTestAuthRouter(t)
+
+	// 普通用户没有 audit:view 权限
+	createUserWithPermissions(t, db, "alice", model.PermissionFileView)
+	createUserWithPermissions(t, db, "bob", model.PermissionFileView)
+	aliceToken := loginAs(t, auth, "alice")
+	loginAs(t, auth, "bob")
+
+	var alice, bob model.User
+	db.Where("username = ?", "alice").First(&alice)
+	db.Where("username = ?", "bob").First(&bob)
+	for _, entry := range []*model.AuditLog{
+		{UserID: &alice.ID, Action: "change_password", Resource: "auth", Status: "success"},
+		{UserID: &alice.ID, Action: "重置用户密码", Resource: "用户", Details: "用户ID: " + strconv.FormatUint(uint64(bob.ID), 10), Status: "成功"},
+		{UserID: &bob.ID, Action: "change_password", Resource: "auth", Status: "success"},
+	} {
+		if err := db.Create(entry).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 查询参数无法指定其他用户
+	w := authRequest(router, http.MethodGet, "/api/auth/activity?user_id="+strconv.FormatUint(uint64(bob.ID), 10), aliceToken, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("查询活动记录返回 %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Data  []model.AuditLog `json:"data"`
+			Total int64            `json:"total"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Data) == 0 || int64(len(resp.Data.Data)) != resp.Data.Total {
+		t.Fatalf("活动记录 %d 条，总数 %d", len(resp.Data.Data), resp.Data.Total)
+	}
+	var changedPassword bool
+	for _, entry := range resp.Data.Data {
+		if entry.UserID == nil || *entry.UserID != alice.ID {
+			t.Fatalf("返回了其他用户的记录: %+v", entry)
+		}
+		if entry.Action == "重置用户密码" {
+			t.Fatalf("以其他用户为对象的操作不应出现在个人活动中: %+v", entry)
+		}
+		changedPassword = changedPassword || entry.Action == "change_password"
+	}
+	if !changedPassword {
+		t.Fatalf("缺少本人的修改密码记录: %+v", resp.Data.Data)
+	}
+
+	if w := authRequest(router, http.MethodGet, "/api/auth/activity", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("未认证请求返回 %d，期望 401", w.Code)
+	}
+}
+
+func TestPreferencesRoundTripAndIsolation(t *testing.T) {
+	router, db, auth := newTestAuthRouter(t)
+	createUserWithPermissions(t, db, "alice", model.PermissionFileView)
+	createUserWithPermissions(t, db, "bob", model.PermissionFileView)
+	aliceToken := loginAs(t, auth, "alice")
+	bobToken := loginAs(t, auth, "bob")
+
+	getPreferences := func(token string) map[string]json.RawMessage {
+		t.Helper()
+		w := authRequest(router, http.MethodGet, "/api/auth/preferences", token, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("获取偏好返回 %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data map[string]json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data
+	}
+
+	body := `{"theme":"dark","page_size":50,"dashboard":{"widgets":["cpu","memory"]}}`
+	if w := authRequest(router, http.MethodPut, "/api/auth/preferences", aliceToken, body); w.Code != http.StatusOK {
+		t.Fatalf("保存偏好返回 %d: %s", w.Code, w.Body.String())
+	}
+	prefs := getPreferences(aliceToken)
+	if string(prefs["theme"]) != `"dark"` || string(prefs["page_size"]) != "50" || string(prefs["dashboard"]) != `{"widgets":["cpu","memory"]}` {
+		t.Fatalf("读取的偏好与保存的不一致: %s", prefs)
+	}
+
+	// 其他用户看不到，也不会覆盖
+	if prefs := getPreferences(bobToken); len(prefs) != 0 {
+		t.Fatalf("bob 读取到了其他用户的偏好: %s", prefs)
+	}
+	if w := authRequest(router, http.MethodPut, "/api/auth/preferences", bobToken, `{"theme":"light"}`); w.Code != http.StatusOK {
+		t.Fatalf("保存偏好返回 %d", w.Code)
+	}
+
+	// 合并更新，null 删除
+	if w := authRequest(router, http.MethodPut, "/api/auth/preferences", aliceToken, `{"page_size":100,"dashboard":null}`); w.Code != http.StatusOK {
+		t.Fatalf("更新偏好返回 %d: %s", w.Code, w.Body.String())
+	}
+	prefs = getPreferences(aliceToken)
+	if len(prefs) != 2 || string(prefs["theme"]) != `"dark"` || string(prefs["page_size"]) != "100" {
+		t.Fatalf("合并更新后的偏好 = %s", prefs)
+	}
+
+	// 超出大小限制
+	large := `{"layout":"` + strings.Repeat("a", service.MaxPreferencesPayloadSize) + `"}`
+	if w := authRequest(router, http.MethodPut, "/api/auth/preferences", aliceToken, large); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("超大请求返回 %d，期望 413", w.Code)
+	}
+	if w := authRequest(router, http.MethodPut, "/api/auth/preferences", aliceToken, `{"layout":"`+strings.Repeat("a", 20<<10)+`"}`); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("超大偏好值返回 %d，期望 413", w.Code)
+	}
+	if w := authRequest(router, http.MethodPut, "/api/auth/preferences", aliceToken, `{"theme":`); w.Code != http.StatusBadRequest {
+		t.Fatalf("无效JSON返回 %d，期望 400", w.Code)
+	}
+	if prefs := getPreferences(bobToken); len(prefs) != 1 || string(prefs["theme"]) != `"light"` {
+		t.Fatalf("bob 的偏好被修改: %s", prefs)
+	}
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"web-panel-go/internal/middleware"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitChunkedUpload 初始化分片上传
+// @Summary 初始化分片上传
+// @Description 创建分片上传任务并返回上传ID，用于大文件上传和断点续传
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.ChunkUploadInitRequest true "初始化分片上传请求"
+// @Success 200 {object} model.APIResponse{data=model.ChunkUploadInitResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 507 {object} model.APIResponse
+// @Router /api/files/upload/init [post]
+func (h *FileHandler) InitChunkedUpload(c *gin.Context) {
+	var req model.ChunkUploadInitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	response, err := h.chunkedUploadService.InitUpload(&req, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInsufficientDiskSpace):
+			status = http.StatusInsufficientStorage
+		case errors.Is(err, service.ErrFileExists):
+			status = http.StatusConflict
+		case errors.Is(err, service.ErrInvalidPath), errors.Is(err, service.ErrInvalidUploadSize):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "初始化上传失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "初始化上传成功",
+		Data:    response,
+	})
+}
+
+// UploadChunk 上传分片
+// @Summary 上传分片
+// @Description 上传单个分片，请求体为分片的原始字节；同一序号可重复上传以重试
+// @Tags 文件管理
+// @Accept application/octet-stream
+// @Produce json
+// @Security BearerAuth
+// @Param upload_id query string true "上传ID"
+// @Param chunk_index query int true "分片序号（从0开始）"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 413 {object} model.APIResponse
+// @Failure 429 {object} model.APIResponse
+// @Failure 507 {object} model.APIResponse
+// @Router /api/files/upload/chunk [post]
+func (h *FileHandler) UploadChunk(c *gin.Context) {
+	uploadID := c.Query("upload_id")
+	chunkIndex, err := strconv.Atoi(c.Query("chunk_index"))
+	if uploadID == "" || err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "上传ID和分片序号不能为空",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+
+	// 并发上传限制
+	release, ok := h.fileService.AcquireUploadSlot(userID)
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(uploadRetryAfterSeconds))
+		c.JSON(http.StatusTooManyRequests, model.ErrorResponse{
+			Code:    http.StatusTooManyRequests,
+			Message: "当前上传任务过多，请稍后重试",
+		})
+		return
+	}
+	defer release()
+
+	if err := h.chunkedUploadService.UploadChunk(uploadID, chunkIndex, c.Request.Body, userID); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrUploadNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrChunkTooLarge), errors.Is(err, service.ErrUploadSizeExceeded):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrInsufficientDiskSpace):
+			status = http.StatusInsufficientStorage
+		case errors.Is(err, service.ErrChunkOutOfRange):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "上传分片失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "分片上传成功",
+	})
+}
+
+// CompleteChunkedUpload 完成分片上传
+// @Summary 完成分片上传
+// @Description 校验所有分片均已上传，按序号合并后保存到目标路径
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.ChunkUploadCompleteRequest true "完成分片上传请求"
+// @Success 200 {object} model.APIResponse{data=model.ChunkUploadCompleteResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 507 {object} model.APIResponse
+// @Router /api/files/upload/complete [post]
+func (h *FileHandler) CompleteChunkedUpload(c *gin.Context) {
+	var req model.ChunkUploadCompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	// 合并大文件可能超过服务器写超时
+	clearWriteDeadline(c)
+	response, err := h.chunkedUploadService.CompleteUpload(req.UploadID, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrUploadNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrChunksIncomplete):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrInsufficientDiskSpace):
+			status = http.StatusInsufficientStorage
+		case errors.Is(err, service.ErrFileExists):
+			status = http.StatusConflict
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "完成上传失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "文件上传成功",
+		Data:    response,
+	})
+}
+
+// ListUploads 获取进行中的分片上传
+// @Summary 获取进行中的分片上传
+// @Description 列出未完成的分片上传及已接收的字节数，管理员可看到所有用户的上传，其他用户只能看到自己的
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.UploadSession}
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/uploads [get]
+func (h *FileHandler) ListUploads(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	isAdmin := false
+	if user, exists := middleware.GetCurrentUser(c); exists {
+		isAdmin = user.IsAdmin()
+	}
+
+	sessions, err := h.chunkedUploadService.ListUploads(userID, isAdmin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取上传列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取上传列表成功",
+		Data:    sessions,
+	})
+}
+
+// CancelUpload 取消分片上传
+// @Summary 取消分片上传
+// @Description 取消未完成的分片上传并删除已上传的分片，非管理员只能取消自己的上传
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "上传ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/uploads/{id} [delete]
+func (h *FileHandler) CancelUpload(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+	isAdmin := false
+	if user, exists := middleware.GetCurrentUser(c); exists {
+		isAdmin = user.IsAdmin()
+	}
+
+	if err := h.chunkedUploadService.CancelUpload(c.Param("id"), userID, isAdmin, clientIP, userAgent); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrUploadNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "取消上传失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "取消上传成功",
+	})
+}
@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
+	"web-panel-go/internal/logger"
 	"web-panel-go/internal/middleware"
 	"web-panel-go/internal/model"
 	"web-panel-go/internal/service"
@@ -12,17 +18,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// uploadRetryAfterSeconds 上传并发超限时建议的重试间隔（秒）
+const uploadRetryAfterSeconds = 5
+
 // FileHandler 文件处理器
 type FileHandler struct {
-	fileService *service.FileService
-	authService *service.AuthService
+	fileService          *service.FileService
+	chunkedUploadService *service.ChunkedUploadService
+	authService          *service.AuthService
 }
 
 // NewFileHandler 创建文件处理器实例
-func NewFileHandler(fileService *service.FileService, authService *service.AuthService) *FileHandler {
+func NewFileHandler(fileService *service.FileService, chunkedUploadService *service.ChunkedUploadService, authService *service.AuthService) *FileHandler {
 	return &FileHandler{
-		fileService: fileService,
-		authService: authService,
+		fileService:          fileService,
+		chunkedUploadService: chunkedUploadService,
+		authService:          authService,
 	}
 }
 
@@ -33,16 +44,25 @@ func NewFileHandler(fileService *service.FileService, authService *service.AuthS
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param path query string true "目录路径"
+// @Param path query string false "目录路径，默认使用面板设置的默认目录"
 // @Param page query int false "页码" default(1)
-// @Param page_size query int false "每页数量" default(50)
-// @Success 200 {object} model.APIResponse{data=model.PaginatedResponse}
+// @Param page_size query int false "每页数量，0表示不分页" default(50)
+// @Param all query bool false "不分页返回全部条目，条目数超过上限时退回分页并设置 truncated"
+// @Param sniff query bool false "对无扩展名文件探测内容类型"
+// @Param sort query string false "排序字段（name, size, mod_time, type），默认使用面板设置"
+// @Param order query string false "排序方向（asc, desc），默认使用面板设置"
+// @Param show_hidden query bool false "是否显示隐藏文件，默认使用面板设置"
+// @Param compute_size query bool false "递归计算当前页目录的大小，超时未完成的目录 computed 为false"
+// @Success 200 {object} model.FileListResponse
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
 // @Failure 500 {object} model.APIResponse
 // @Router /api/files [get]
 func (h *FileHandler) ListFiles(c *gin.Context) {
-	path := c.Query("path")
+	// 未指定的参数使用面板默认设置
+	defaults := h.fileService.GetBrowserDefaults()
+
+	path := c.DefaultQuery("path", defaults.HomeDir)
 	if path == "" {
 		c.JSON(http.StatusBadRequest, model.APIResponse{
 			Code:    http.StatusBadRequest,
@@ -55,15 +75,34 @@ func (h *FileHandler) ListFiles(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "50"))
 
+	// page_size=0 或 all=true 表示不分页
+	all := c.Query("all") == "true" || c.Query("page_size") == "0"
+
 	// 参数验证
-	if page < 1 {
+	if page < 1 || all {
 		page = 1
 	}
-	if pageSize < 1 || pageSize > 200 {
+	if all {
+		pageSize = 0
+	} else if pageSize < 1 || pageSize > 200 {
 		pageSize = 50
 	}
 
-	files, total, err := h.fileService.ListFiles(path, page, pageSize)
+	showHidden := defaults.ShowHidden
+	if hidden := c.Query("show_hidden"); hidden != "" {
+		showHidden = hidden == "true"
+	}
+
+	opts := service.ListOptions{
+		SniffMime:   c.Query("sniff") == "true",
+		SortBy:      c.DefaultQuery("sort", defaults.SortBy),
+		Order:       c.DefaultQuery("order", defaults.Order),
+		ShowHidden:  showHidden,
+		ComputeSize: c.Query("compute_size") == "true",
+		Context:     c.Request.Context(),
+	}
+
+	files, total, truncated, err := h.fileService.ListFiles(path, page, pageSize, opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Code:    http.StatusInternalServerError,
@@ -73,14 +112,25 @@ func (h *FileHandler) ListFiles(c *gin.Context) {
 		return
 	}
 
+	// 不分页时实际每页数量为返回的条目数（超过上限时为上限）
+	if all {
+		pageSize = len(files)
+	}
+
 	// 构建分页响应
-	response := model.PaginatedResponse{
-		Code:    http.StatusOK,
-		Message: "获取文件列表成功",
-		Data:    files,
-		Total:   total,
-		Page:    page,
-		Size:    pageSize,
+	cleanPath, parentPath, isRoot := h.fileService.GetPathMeta(path)
+	response := model.FileListResponse{
+		Code:       http.StatusOK,
+		Message:    "获取文件列表成功",
+		Data:       files,
+		Total:      total,
+		Page:       page,
+		Size:       pageSize,
+		PageSize:   pageSize,
+		Path:       cleanPath,
+		ParentPath: parentPath,
+		IsRoot:     isRoot,
+		Truncated:  truncated,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -142,15 +192,16 @@ func (h *FileHandler) CreateDirectory(c *gin.Context) {
 
 // DeleteFile 删除文件或目录
 // @Summary 删除文件或目录
-// @Description 删除指定的文件或目录
+// @Description 删除指定的文件或目录；启用回收站时默认移入回收站，permanent=true时永久删除（启用回收站时仅管理员可用）
 // @Tags 文件管理
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body model.DeleteFileRequest true "删除文件请求"
-// @Success 200 {object} model.APIResponse
+// @Success 200 {object} model.APIResponse{data=model.DeleteFileResponse}
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
 // @Failure 500 {object} model.APIResponse
 // @Router /api/files [delete]
 func (h *FileHandler) DeleteFile(c *gin.Context) {
@@ -178,8 +229,13 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 	clientIP := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
 
+	if !h.requirePermanentDelete(c, req.Permanent) {
+		return
+	}
+
 	// 删除文件
-	if err := h.fileService.DeleteFile(req.Path, userID, clientIP, userAgent); err != nil {
+	result, err := h.fileService.DeleteFile(req.Path, req.Permanent, userID, clientIP, userAgent)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Code:    http.StatusInternalServerError,
 			Message: "删除失败",
@@ -191,6 +247,63 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 	c.JSON(http.StatusOK, model.APIResponse{
 		Code:    http.StatusOK,
 		Message: "删除成功",
+		Data:    result,
+	})
+}
+
+// DeleteFiles 批量删除文件或目录
+// @Summary 批量删除文件或目录
+// @Description 逐个删除指定路径，返回每个路径的删除结果，单个路径失败不影响其他路径
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.BatchDeleteRequest true "批量删除请求"
+// @Success 200 {object} model.APIResponse{data=model.BatchDeleteResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/files/batch [delete]
+func (h *FileHandler) DeleteFiles(c *gin.Context) {
+	var req model.BatchDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 参数验证
+	if len(req.Paths) == 0 {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "路径列表不能为空",
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if !h.requirePermanentDelete(c, req.Permanent) {
+		return
+	}
+
+	result := h.fileService.DeleteFiles(req.Paths, req.Permanent, userID, clientIP, userAgent)
+
+	message := "批量删除完成"
+	if result.Failed > 0 {
+		message = fmt.Sprintf("批量删除完成，%d 个失败", result.Failed)
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: message,
+		Data:    result,
 	})
 }
 
@@ -248,6 +361,211 @@ func (h *FileHandler) RenameFile(c *gin.Context) {
 	})
 }
 
+// MoveFile 移动文件
+// @Summary 移动文件
+// @Description 将文件或目录移动到目标完整路径，支持跨目录和跨文件系统
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.MoveFileRequest true "移动文件请求"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/move [post]
+// @Router /api/files/move [put]
+func (h *FileHandler) MoveFile(c *gin.Context) {
+	var req model.MoveFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.Overwrite && !requireOverwritePermission(c) {
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if err := h.fileService.MoveFile(req.Source, req.Destination, req.Overwrite, userID, clientIP, userAgent); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrTargetExists):
+			status = http.StatusConflict
+		case errors.Is(err, service.ErrInvalidPath), errors.Is(err, service.ErrFileNotFound),
+			errors.Is(err, service.ErrTargetDirNotFound), errors.Is(err, service.ErrSamePath),
+			errors.Is(err, service.ErrMoveIntoSelf), errors.Is(err, service.ErrRootPath):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrInsufficientDiskSpace):
+			status = http.StatusInsufficientStorage
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "移动失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "移动成功",
+	})
+}
+
+// CopyFile 复制文件
+// @Summary 复制文件
+// @Description 将文件或目录复制到目标完整路径，目录递归复制并保留权限
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.CopyFileRequest true "复制文件请求"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Failure 507 {object} model.APIResponse
+// @Router /api/files/copy [post]
+func (h *FileHandler) CopyFile(c *gin.Context) {
+	var req model.CopyFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.Overwrite && !requireOverwritePermission(c) {
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if err := h.fileService.CopyFile(req.Source, req.Destination, req.Overwrite, userID, clientIP, userAgent); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrTargetExists):
+			status = http.StatusConflict
+		case errors.Is(err, service.ErrInvalidPath), errors.Is(err, service.ErrFileNotFound),
+			errors.Is(err, service.ErrTargetDirNotFound), errors.Is(err, service.ErrSamePath),
+			errors.Is(err, service.ErrCopyIntoSelf), errors.Is(err, service.ErrRootPath):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrInsufficientDiskSpace):
+			status = http.StatusInsufficientStorage
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "复制失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "复制成功",
+	})
+}
+
+// ChangePermissions 批量修改文件权限
+// @Summary 批量修改文件权限
+// @Description 批量修改多个路径的权限，可递归应用并分别指定文件和目录权限
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.ChangePermissionsRequest true "修改权限请求"
+// @Success 200 {object} model.APIResponse{data=model.ChangePermissionsResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /api/files/permissions [put]
+func (h *FileHandler) ChangePermissions(c *gin.Context) {
+	var req model.ChangePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	result, err := h.fileService.ChangePermissions(&req, userID, clientIP, userAgent)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "修改权限失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "修改权限完成",
+		Data:    result,
+	})
+}
+
+// ValidateConfig 校验配置文件语法
+// @Summary 校验配置文件语法
+// @Description 解析配置内容并返回带行号的语法错误，不写入任何文件
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.ValidateConfigRequest true "校验请求"
+// @Success 200 {object} model.APIResponse{data=model.ValidateConfigResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Router /api/files/validate [post]
+func (h *FileHandler) ValidateConfig(c *gin.Context) {
+	var req model.ValidateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := h.fileService.ValidateConfig(req.Content, req.Type)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "校验失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "校验完成",
+		Data:    result,
+	})
+}
+
 // UploadFile 上传文件
 // @Summary 上传文件
 // @Description 上传文件到指定目录
@@ -257,12 +575,30 @@ func (h *FileHandler) RenameFile(c *gin.Context) {
 // @Security BearerAuth
 // @Param path formData string true "目标目录路径"
 // @Param file formData file true "上传的文件"
-// @Success 200 {object} model.APIResponse
+// @Success 200 {object} model.APIResponse{data=model.UploadFileResponse}
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
+// @Failure 429 {object} model.APIResponse
 // @Failure 500 {object} model.APIResponse
 // @Router /api/files/upload [post]
 func (h *FileHandler) UploadFile(c *gin.Context) {
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	// 并发上传限制，在解析multipart请求体之前检查，超出限制时不读取上传内容
+	release, ok := h.fileService.AcquireUploadSlot(userID)
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(uploadRetryAfterSeconds))
+		c.JSON(http.StatusTooManyRequests, model.ErrorResponse{
+			Code:    http.StatusTooManyRequests,
+			Message: "当前上传任务过多，请稍后重试",
+		})
+		return
+	}
+	defer release()
+
 	path := c.PostForm("path")
 	if path == "" {
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
@@ -283,15 +619,15 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 		return
 	}
 
-	// 获取用户信息
-	userID, _ := middleware.GetCurrentUserID(c)
-	clientIP := c.ClientIP()
-	userAgent := c.GetHeader("User-Agent")
-
 	// 上传文件
-	if err := h.fileService.UploadFile(path, file, userID, clientIP, userAgent); err != nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Code:    http.StatusInternalServerError,
+	result, err := h.fileService.UploadFile(path, file, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInsufficientDiskSpace) {
+			status = http.StatusInsufficientStorage
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
 			Message: "上传文件失败",
 			Error:   err.Error(),
 		})
@@ -301,18 +637,21 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 	c.JSON(http.StatusOK, model.APIResponse{
 		Code:    http.StatusOK,
 		Message: "文件上传成功",
+		Data:    result,
 	})
 }
 
 // DownloadFile 下载文件
 // @Summary 下载文件
-// @Description 下载指定的文件
+// @Description 下载指定的文件，支持Range请求（断点续传、视频拖动）
 // @Tags 文件管理
 // @Accept json
 // @Produce application/octet-stream
 // @Security BearerAuth
 // @Param path query string true "文件路径"
+// @Param Range header string false "字节范围，如 bytes=0-99"
 // @Success 200 {file} binary
+// @Success 206 {file} binary
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
 // @Failure 404 {object} model.APIResponse
@@ -333,8 +672,26 @@ func (h *FileHandler) DownloadFile(c *gin.Context) {
 	clientIP := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
 
-	// 下载文件
+	// 下载文件（每个请求只在这里记录一次审计日志，Range请求同样）
 	file, err := h.fileService.DownloadFile(filePath, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidPath), errors.Is(err, service.ErrIsDirectory):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrFileNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "下载文件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Code:    http.StatusInternalServerError,
@@ -343,31 +700,36 @@ func (h *FileHandler) DownloadFile(c *gin.Context) {
 		})
 		return
 	}
-	defer file.Close()
 
 	// 设置响应头
 	filename := filepath.Base(filePath)
-	c.Header("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
 	c.Header("Content-Type", "application/octet-stream")
 
-	// 发送文件
-	c.File(filePath)
+	// 使用已打开的文件发送，ServeContent 负责 Accept-Ranges、Content-Length 和 206 响应
+	clearWriteDeadline(c)
+	http.ServeContent(c.Writer, c.Request, filename, info.ModTime(), file)
 }
 
-// GetFileContent 获取文件内容
-// @Summary 获取文件内容
-// @Description 获取文件内容用于编辑
+// GetThumbnail 获取图片缩略图
+// @Summary 获取图片缩略图
+// @Description 按比例缩小图片到指定宽高以内（不放大），支持JPEG、PNG和GIF；生成的缩略图缓存在磁盘上
 // @Tags 文件管理
-// @Accept json
-// @Produce json
+// @Produce image/jpeg
+// @Produce image/png
 // @Security BearerAuth
-// @Param path query string true "文件路径"
-// @Success 200 {object} model.APIResponse{data=model.FileContentResponse}
+// @Param path query string true "图片路径"
+// @Param w query int false "最大宽度，默认256"
+// @Param h query int false "最大高度，默认256"
+// @Success 200 {file} binary
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 413 {object} model.APIResponse
+// @Failure 415 {object} model.APIResponse
 // @Failure 500 {object} model.APIResponse
-// @Router /api/files/content [get]
-func (h *FileHandler) GetFileContent(c *gin.Context) {
+// @Router /api/files/thumbnail [get]
+func (h *FileHandler) GetThumbnail(c *gin.Context) {
 	filePath := c.Query("path")
 	if filePath == "" {
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
@@ -377,49 +739,87 @@ func (h *FileHandler) GetFileContent(c *gin.Context) {
 		return
 	}
 
-	// 获取用户信息
-	userID, _ := middleware.GetCurrentUserID(c)
-	clientIP := c.ClientIP()
-	userAgent := c.GetHeader("User-Agent")
+	var size [2]int
+	for i, key := range []string{"w", "h"} {
+		raw := c.Query(key)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "无效的缩略图尺寸",
+				Error:   fmt.Sprintf("%s 必须是正整数", key),
+			})
+			return
+		}
+		size[i] = v
+	}
 
-	// 获取文件内容
-	content, err := h.fileService.GetFileContent(filePath, userID, clientIP, userAgent)
+	thumbPath, err := h.fileService.Thumbnail(filePath, size[0], size[1])
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "获取文件内容失败",
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrNotImage):
+			status = http.StatusUnsupportedMediaType
+		case errors.Is(err, service.ErrThumbnailTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrInvalidPath):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrFileNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "生成缩略图失败",
 			Error:   err.Error(),
 		})
 		return
 	}
 
-	response := model.FileContentResponse{
-		Path:    filePath,
-		Content: content,
-	}
+	// 缓存文件名包含原图修改时间，原图变化后URL不变但内容会更新，因此只做短期缓存
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.File(thumbPath)
+}
 
-	c.JSON(http.StatusOK, model.APIResponse{
-		Code:    http.StatusOK,
-		Message: "获取文件内容成功",
-		Data:    response,
-	})
+// DownloadArchive 打包下载文件
+// @Summary 打包下载文件
+// @Description 将一个或多个文件/目录打包为tar、tar.gz或zip流式下载，保留权限、属主和符号链接
+// @Tags 文件管理
+// @Accept json
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param path query []string true "要打包的路径，可重复指定" collectionFormat(multi)
+// @Param format query string false "打包格式（tar, tar.gz, zip）" default(tar.gz)
+// @Success 200 {file} binary
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 413 {object} model.APIResponse
+// @Router /api/files/archive [get]
+func (h *FileHandler) DownloadArchive(c *gin.Context) {
+	h.streamArchive(c, c.QueryArray("path"), c.Query("format"))
 }
 
-// SaveFileContent 保存文件内容
-// @Summary 保存文件内容
-// @Description 保存编辑后的文件内容
+// CreateArchive 创建压缩包
+// @Summary 创建压缩包
+// @Description 将选中的文件/目录打包为zip、tar.gz或tar；指定output时保存到服务器，否则直接流式下载
 // @Tags 文件管理
 // @Accept json
 // @Produce json
+// @Produce application/octet-stream
 // @Security BearerAuth
-// @Param request body model.SaveFileContentRequest true "保存文件内容请求"
-// @Success 200 {object} model.APIResponse
+// @Param request body model.CreateArchiveRequest true "创建压缩包请求"
+// @Success 200 {object} model.APIResponse{data=model.CreateArchiveResponse}
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
-// @Failure 500 {object} model.APIResponse
-// @Router /api/files/content [put]
-func (h *FileHandler) SaveFileContent(c *gin.Context) {
-	var req model.SaveFileContentRequest
+// @Failure 403 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 413 {object} model.APIResponse
+// @Failure 507 {object} model.APIResponse
+// @Router /api/files/archive [post]
+func (h *FileHandler) CreateArchive(c *gin.Context) {
+	var req model.CreateArchiveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Code:    http.StatusBadRequest,
@@ -429,9 +829,343 @@ func (h *FileHandler) SaveFileContent(c *gin.Context) {
 		return
 	}
 
-	// 参数验证
-	if req.Path == "" {
-		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+	// 不指定output时直接下载文件内容，与 GET /archive 一样需要下载权限
+	if req.Output == "" {
+		if !middleware.HasPermissions(c, model.PermissionFileDownload) {
+			c.JSON(http.StatusForbidden, model.ErrorResponse{
+				Code:    http.StatusForbidden,
+				Message: "直接下载压缩包需要下载权限",
+			})
+			return
+		}
+		h.streamArchive(c, req.Paths, req.Format)
+		return
+	}
+
+	format, err := service.NormalizeArchiveFormat(req.Format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	// 打包大目录可能超过服务器写超时
+	clearWriteDeadline(c)
+	response, err := h.fileService.CreateArchive(req.Paths, format, req.Output, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrArchiveTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrInsufficientDiskSpace):
+			status = http.StatusInsufficientStorage
+		case errors.Is(err, service.ErrTargetExists):
+			status = http.StatusConflict
+		case errors.Is(err, service.ErrInvalidPath), errors.Is(err, service.ErrFileNotFound),
+			errors.Is(err, service.ErrNoArchivePaths), errors.Is(err, service.ErrArchiveOutputInSource),
+			errors.Is(err, service.ErrTargetDirNotFound):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "创建压缩包失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "创建压缩包成功",
+		Data:    response,
+	})
+}
+
+// ExtractArchive 解压文件
+// @Summary 解压文件
+// @Description 将zip、tar.gz或tar压缩包解压到目标目录，包含越界路径的压缩包会被整体拒绝，符号链接条目会被跳过
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.ExtractArchiveRequest true "解压请求"
+// @Success 200 {object} model.APIResponse{data=model.ExtractArchiveResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Failure 507 {object} model.APIResponse
+// @Router /api/files/extract [post]
+func (h *FileHandler) ExtractArchive(c *gin.Context) {
+	var req model.ExtractArchiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if req.Overwrite && !requireOverwritePermission(c) {
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	result, err := h.fileService.ExtractArchive(req.Path, req.Dest, req.Overwrite, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInsufficientDiskSpace):
+			status = http.StatusInsufficientStorage
+		case errors.Is(err, service.ErrUnsafeArchiveEntry), errors.Is(err, service.ErrArchiveEntrySize),
+			errors.Is(err, service.ErrInvalidPath), errors.Is(err, service.ErrFileNotFound),
+			errors.Is(err, service.ErrUnknownArchiveFormat):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "解压失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "解压成功",
+		Data:    result,
+	})
+}
+
+// streamArchive 校验后把压缩包流式写入响应，边打包边发送，不在内存中缓冲整个压缩包
+func (h *FileHandler) streamArchive(c *gin.Context, paths []string, rawFormat string) {
+	format, err := service.NormalizeArchiveFormat(rawFormat)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 写入响应前先校验路径和大小，之后出错无法再返回JSON
+	if _, err := h.fileService.PrepareArchive(paths); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrArchiveTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrInvalidPath), errors.Is(err, service.ErrFileNotFound),
+			errors.Is(err, service.ErrNoArchivePaths):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "打包下载失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	// 设置响应头
+	name := "archive"
+	if len(paths) == 1 {
+		name = filepath.Base(filepath.Clean(paths[0]))
+	}
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format)
+	contentType := "application/x-tar"
+	switch format {
+	case service.ArchiveFormatTarGz:
+		contentType = "application/gzip"
+	case service.ArchiveFormatZip:
+		contentType = "application/zip"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	clearWriteDeadline(c)
+
+	if err := h.fileService.WriteArchive(&flushWriter{w: c.Writer}, paths, format, userID, clientIP, userAgent); err != nil {
+		logger.Error("打包下载中断", "error", err, "user_id", userID)
+		c.Abort()
+	}
+}
+
+// flushWriter 每次写入后立即刷新响应，让大文件边生成边发送
+type flushWriter struct {
+	w gin.ResponseWriter
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err == nil {
+		fw.w.Flush()
+	}
+	return n, err
+}
+
+// GetFileChecksum 获取文件校验和
+// @Summary 获取文件校验和
+// @Description 计算文件的md5、sha1或sha256校验和，用于校验上传下载的完整性；需要下载权限，校验和可用于推测文件内容
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param path query string true "文件路径"
+// @Param algo query string false "校验算法 md5|sha1|sha256" default(sha256)
+// @Success 200 {object} model.APIResponse{data=model.FileChecksumResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 413 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Failure 504 {object} model.APIResponse
+// @Router /api/files/checksum [get]
+func (h *FileHandler) GetFileChecksum(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "路径不能为空",
+		})
+		return
+	}
+	algorithm := strings.ToLower(c.DefaultQuery("algo", service.DefaultChecksumAlgorithm))
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	checksum, err := h.fileService.ComputeChecksum(c.Request.Context(), path, algorithm, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrChecksumTooLarge):
+			status = http.StatusRequestEntityTooLarge
+		case errors.Is(err, service.ErrChecksumTimeout):
+			status = http.StatusGatewayTimeout
+		case errors.Is(err, service.ErrInvalidPath), errors.Is(err, service.ErrChecksumDirectory),
+			errors.Is(err, service.ErrUnsupportedChecksumAlgorithm):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrFileNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "计算校验和失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "计算校验和成功",
+		Data: model.FileChecksumResponse{
+			Path:      path,
+			Algorithm: algorithm,
+			Checksum:  checksum,
+		},
+	})
+}
+
+// GetFileTypeStats 获取目录文件类型统计
+// @Summary 获取目录文件类型统计
+// @Description 按扩展名分组统计目录下文件的数量和总大小，按总大小降序返回；超时或达到条目上限时返回部分结果
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param path query string true "目录路径"
+// @Param depth query int false "向下遍历的目录层数，1表示只统计直接子文件" default(5)
+// @Success 200 {object} model.APIResponse{data=model.FileTypeStatsResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/stats [get]
+func (h *FileHandler) GetFileTypeStats(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "路径不能为空",
+		})
+		return
+	}
+
+	depth := service.DefaultFileStatsDepth
+	if v := c.Query("depth"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > service.MaxFileStatsDepth {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "无效的遍历层数",
+			})
+			return
+		}
+		depth = parsed
+	}
+
+	stats, err := h.fileService.GetFileTypeStats(c.Request.Context(), path, depth)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidPath), errors.Is(err, service.ErrNotDirectory):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrPathNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "获取文件类型统计失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取文件类型统计成功",
+		Data:    stats,
+	})
+}
+
+// GetFileContent 获取文件内容
+// @Summary 获取文件内容
+// @Description 获取文件内容用于编辑；大文件可通过offset_line/line_count按行分段读取
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param path query string true "文件路径"
+// @Param offset_line query int false "起始行（从0开始），指定后按行分段读取"
+// @Param line_count query int false "读取行数，最多5000行"
+// @Success 200 {object} model.APIResponse{data=model.FileContentResponse} "整文件读取；分段读取时data为model.FileLinesResponse"
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/content [get]
+func (h *FileHandler) GetFileContent(c *gin.Context) {
+	filePath := c.Query("path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
 			Code:    http.StatusBadRequest,
 			Message: "文件路径不能为空",
 		})
@@ -443,10 +1177,117 @@ func (h *FileHandler) SaveFileContent(c *gin.Context) {
 	clientIP := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
 
-	// 保存文件内容
-	if err := h.fileService.SaveFileContent(req.Path, req.Content, userID, clientIP, userAgent); err != nil {
+	// 指定了行窗口时按行分段读取
+	if c.Query("offset_line") != "" || c.Query("line_count") != "" {
+		offsetLine, err := strconv.Atoi(c.DefaultQuery("offset_line", "0"))
+		if err != nil || offsetLine < 0 {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "无效的起始行",
+				Error:   "offset_line 必须是非负整数",
+			})
+			return
+		}
+		lineCount, err := strconv.Atoi(c.DefaultQuery("line_count", strconv.Itoa(service.MaxLineWindow)))
+		if err != nil || lineCount < 1 || lineCount > service.MaxLineWindow {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "无效的读取行数",
+				Error:   fmt.Sprintf("line_count 必须在 1 到 %d 之间", service.MaxLineWindow),
+			})
+			return
+		}
+
+		response, err := h.fileService.GetFileLines(filePath, offsetLine, lineCount, userID, clientIP, userAgent)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "获取文件内容失败",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, model.APIResponse{
+			Code:    http.StatusOK,
+			Message: "获取文件内容成功",
+			Data:    response,
+		})
+		return
+	}
+
+	// 获取文件内容
+	response, err := h.fileService.GetFileContent(filePath, userID, clientIP, userAgent)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Code:    http.StatusInternalServerError,
+			Message: "获取文件内容失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取文件内容成功",
+		Data:    response,
+	})
+}
+
+// SaveFileContent 保存文件内容
+// @Summary 保存文件内容
+// @Description 保存编辑后的文件内容；带回读取时的version时检查文件是否已被他人修改，已修改时返回409，force=true时强制覆盖
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.SaveFileContentRequest true "保存文件内容请求"
+// @Success 200 {object} model.APIResponse{data=model.SaveFileContentResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/content [put]
+func (h *FileHandler) SaveFileContent(c *gin.Context) {
+	var req model.SaveFileContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 参数验证
+	if req.Path == "" {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "文件路径不能为空",
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	// 保存文件内容
+	result, err := h.fileService.SaveFileContent(req.Path, req.Content, req.Version, req.Encoding, req.Force, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInsufficientDiskSpace):
+			status = http.StatusInsufficientStorage
+		case errors.Is(err, service.ErrFileVersionConflict):
+			status = http.StatusConflict
+		case errors.Is(err, service.ErrUnsupportedEncoding),
+			errors.Is(err, service.ErrUnencodableContent), errors.Is(err, service.ErrInvalidPath):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
 			Message: "保存文件失败",
 			Error:   err.Error(),
 		})
@@ -456,7 +1297,189 @@ func (h *FileHandler) SaveFileContent(c *gin.Context) {
 	c.JSON(http.StatusOK, model.APIResponse{
 		Code:    http.StatusOK,
 		Message: "文件保存成功",
+		Data:    result,
+	})
+}
+
+// GetRecentFiles 获取最近访问的文件
+// @Summary 获取最近访问的文件
+// @Description 根据审计日志获取当前用户最近编辑、上传或读取的文件
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "返回数量" default(20)
+// @Success 200 {object} model.APIResponse{data=[]model.RecentFile}
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/recent [get]
+func (h *FileHandler) GetRecentFiles(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+
+	files, err := h.fileService.GetRecentFiles(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取最近文件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取最近文件成功",
+		Data:    files,
+	})
+}
+
+// ListFavorites 获取收藏的文件路径
+// @Summary 获取收藏的文件路径
+// @Description 获取当前用户收藏的文件路径，已不存在的路径标记为 stale
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.FavoriteFile}
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/favorites [get]
+func (h *FileHandler) ListFavorites(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+
+	favorites, err := h.fileService.ListFavorites(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取收藏失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取收藏成功",
+		Data:    favorites,
+	})
+}
+
+// AddFavorite 收藏文件路径
+// @Summary 收藏文件路径
+// @Description 将文件或目录路径加入当前用户的收藏，重复收藏返回已有记录
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.AddFavoriteRequest true "收藏路径"
+// @Success 200 {object} model.APIResponse{data=model.FavoriteFile}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/favorites [post]
+func (h *FileHandler) AddFavorite(c *gin.Context) {
+	var req model.AddFavoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	favorite, err := h.fileService.AddFavorite(userID, req.Path, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidPath):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrPathNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "收藏路径失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "收藏路径成功",
+		Data:    favorite,
+	})
+}
+
+// RemoveFavorite 取消收藏
+// @Summary 取消收藏
+// @Description 从当前用户的收藏中移除指定记录
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "收藏ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/favorites/{id} [delete]
+func (h *FileHandler) RemoveFavorite(c *gin.Context) {
+	favoriteID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的收藏ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if err := h.fileService.RemoveFavorite(userID, uint(favoriteID), clientIP, userAgent); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrFavoriteNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "取消收藏失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "取消收藏成功",
+	})
+}
+
+// requireOverwritePermission 覆盖已存在的目标会修改并删除原有数据，除路由要求的权限外
+// 还需要同时拥有 file:update 和 file:delete 权限，不满足时返回403
+func requireOverwritePermission(c *gin.Context) bool {
+	if middleware.HasPermissions(c, model.PermissionFileUpdate, model.PermissionFileDelete) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, model.ErrorResponse{
+		Code:    http.StatusForbidden,
+		Message: "覆盖已存在的文件需要更新和删除权限",
 	})
+	return false
 }
 
 // RegisterFileRoutes 注册文件相关路由
@@ -465,21 +1488,44 @@ func RegisterFileRoutes(r *gin.RouterGroup, fileHandler *FileHandler) {
 	files.Use(middleware.AuthMiddleware(fileHandler.authService))
 	{
 		// 文件列表
-		files.GET("", fileHandler.ListFiles)
+		files.GET("", middleware.RequirePermission(model.PermissionFileView), fileHandler.ListFiles)
+		files.GET("/recent", middleware.RequirePermission(model.PermissionFileView), fileHandler.GetRecentFiles)
+		files.GET("/stats", middleware.RequirePermission(model.PermissionFileView), fileHandler.GetFileTypeStats)
+		files.GET("/favorites", middleware.RequirePermission(model.PermissionFileView), fileHandler.ListFavorites)
+		files.POST("/favorites", middleware.RequirePermission(model.PermissionFileView), fileHandler.AddFavorite)
+		files.DELETE("/favorites/:id", middleware.RequirePermission(model.PermissionFileView), fileHandler.RemoveFavorite)
 		
 		// 目录操作
-		files.POST("/directory", fileHandler.CreateDirectory)
+		files.POST("/directory", middleware.RequirePermission(model.PermissionFileCreate), fileHandler.CreateDirectory)
 		
 		// 文件操作
-		files.DELETE("", fileHandler.DeleteFile)
-		files.PUT("/rename", fileHandler.RenameFile)
+		files.DELETE("", middleware.RequirePermission(model.PermissionFileDelete), fileHandler.DeleteFile)
+		files.DELETE("/batch", middleware.RequirePermission(model.PermissionFileDelete), fileHandler.DeleteFiles)
+		files.GET("/trash", middleware.RequirePermission(model.PermissionFileView), fileHandler.ListTrash)
+		files.POST("/trash/restore", middleware.RequirePermission(model.PermissionFileUpdate), fileHandler.RestoreTrash)
+		files.PUT("/rename", middleware.RequirePermission(model.PermissionFileUpdate), fileHandler.RenameFile)
+		files.POST("/move", middleware.RequirePermission(model.PermissionFileUpdate), fileHandler.MoveFile)
+		files.PUT("/move", middleware.RequirePermission(model.PermissionFileUpdate), fileHandler.MoveFile)
+		files.POST("/copy", middleware.RequirePermission(model.PermissionFileCreate), fileHandler.CopyFile)
+		files.PUT("/permissions", middleware.RequirePermission(model.PermissionFileUpdate), fileHandler.ChangePermissions)
 		
 		// 文件上传下载
-		files.POST("/upload", fileHandler.UploadFile)
-		files.GET("/download", fileHandler.DownloadFile)
+		files.POST("/upload", middleware.RequirePermission(model.PermissionFileUpload), fileHandler.UploadFile)
+		files.POST("/upload/init", middleware.RequirePermission(model.PermissionFileUpload), fileHandler.InitChunkedUpload)
+		files.POST("/upload/chunk", middleware.RequirePermission(model.PermissionFileUpload), fileHandler.UploadChunk)
+		files.POST("/upload/complete", middleware.RequirePermission(model.PermissionFileUpload), fileHandler.CompleteChunkedUpload)
+		files.GET("/uploads", middleware.RequirePermission(model.PermissionFileUpload), fileHandler.ListUploads)
+		files.DELETE("/uploads/:id", middleware.RequirePermission(model.PermissionFileUpload), fileHandler.CancelUpload)
+		files.GET("/download", middleware.RequirePermission(model.PermissionFileDownload), fileHandler.DownloadFile)
+		files.GET("/thumbnail", middleware.RequirePermission(model.PermissionFileDownload), fileHandler.GetThumbnail)
+		files.GET("/checksum", middleware.RequirePermission(model.PermissionFileDownload), fileHandler.GetFileChecksum)
+		files.GET("/archive", middleware.RequirePermission(model.PermissionFileDownload), fileHandler.DownloadArchive)
+		files.POST("/archive", middleware.RequirePermission(model.PermissionFileCreate), fileHandler.CreateArchive)
+		files.POST("/extract", middleware.RequirePermission(model.PermissionFileCreate), fileHandler.ExtractArchive)
 		
 		// 文件内容编辑
-		files.GET("/content", fileHandler.GetFileContent)
-		files.PUT("/content", fileHandler.SaveFileContent)
+		files.GET("/content", middleware.RequirePermission(model.PermissionFileDownload), fileHandler.GetFileContent)
+		files.PUT("/content", middleware.RequirePermission(model.PermissionFileUpdate), fileHandler.SaveFileContent)
+		files.POST("/validate", middleware.RequirePermission(model.PermissionFileView), fileHandler.ValidateConfig)
 	}
 }
\ No newline at end of file
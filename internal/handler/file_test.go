@@ -0,0 +1,532 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newTestFileHandler 创建以临时目录为文件根目录、使用内存数据库的文件处理器
+func newTestFileHandler(t *testing.T) (*FileHandler, *gorm.DB, string) {
+	t.Helper()
+	db := newTestDB(t)
+	cfg := newTestConfig(t)
+	files := service.NewFileService(db, cfg, service.NewSettingService(db))
+	root, _ := filepath.EvalSymlinks(cfg.System.FileRootDir)
+	return NewFileHandler(files, nil, nil), db, root
+}
+
+// newTestFileRouter 创建注册全部文件路由（含认证和权限检查）的路由，返回路由、数据库、认证服务和文件根目录
+func newTestFileRouter(t *testing.T) (*gin.Engine, *gorm.DB, *service.AuthService, string) {
+	t.Helper()
+	db := newTestDB(t)
+	cfg := newTestConfig(t)
+	auth := service.NewAuthService(db, cfg, service.NewRBACCache(db))
+	files := service.NewFileService(db, cfg, service.NewSettingService(db))
+	router := gin.New()
+	RegisterFileRoutes(router.Group("/api"), NewFileHandler(files, service.NewChunkedUploadService(cfg, files), auth))
+	root, _ := filepath.EvalSymlinks(cfg.System.FileRootDir)
+	return router, db, auth, root
+}
+
+// openFDs 返回当前进程打开的文件描述符数，不支持 /proc 的平台跳过测试
+func openFDs(t *testing.T) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("当前平台无法统计文件描述符")
+	}
+	return len(entries)
+}
+
+func TestDownloadFileDoesNotLeakDescriptors(t *testing.T) {
+	h, db, root := newTestFileHandler(t)
+	path := filepath.Join(root, "data.bin")
+	if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/download", h.DownloadFile)
+	target := "/download?path=" + url.QueryEscape(path)
+
+	download := func(rangeHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 预热一次，让数据库连接等一次性资源先打开
+	download("")
+	before := openFDs(t)
+
+	const downloads = 200
+	for i := 0; i < downloads; i++ {
+		rangeHeader := ""
+		wantStatus, wantBody := http.StatusOK, "0123456789"
+		if i%2 == 1 {
+			rangeHeader, wantStatus, wantBody = "bytes=2-4", http.StatusPartialContent, "234"
+		}
+		w := download(rangeHeader)
+		if w.Code != wantStatus || w.Body.String() != wantBody {
+			t.Fatalf("第 %d 次下载返回 %d %q，期望 %d %q", i, w.Code, w.Body.String(), wantStatus, wantBody)
+		}
+	}
+
+	if after := openFDs(t); after > before {
+		t.Fatalf("下载 %d 次后文件描述符从 %d 增加到 %d", downloads, before, after)
+	}
+
+	// 每个请求只记录一次审计日志
+	var logs int64
+	db.Model(&model.AuditLog{}).Where("action = ?", "download_file").Count(&logs)
+	if logs != downloads+1 {
+		t.Fatalf("download_file 审计日志 %d 条，期望 %d 条", logs, downloads+1)
+	}
+}
+
+func TestDownloadArchiveFilenameEncoding(t *testing.T) {
+	h, _, root := newTestFileHandler(t)
+	dir := filepath.Join(root, `报告 "q1"`)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/archive", h.DownloadArchive)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/archive?format=zip&path="+url.QueryEscape(dir), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("打包下载返回 %d: %s", w.Code, w.Body.String())
+	}
+
+	// 文件名中的引号和非ASCII字符需按RFC 2231编码，客户端能还原原名称
+	disposition, params, err := mime.ParseMediaType(w.Header().Get("Content-Disposition"))
+	if err != nil || disposition != "attachment" {
+		t.Fatalf("Content-Disposition %q 无法解析: %v", w.Header().Get("Content-Disposition"), err)
+	}
+	if name := params["filename"]; !strings.HasPrefix(name, `报告 "q1"-`) || !strings.HasSuffix(name, ".zip") {
+		t.Fatalf("filename = %q，期望以原目录名开头、.zip 结尾", name)
+	}
+}
+
+func TestFileRoutesViewOnlyUserCannotMutate(t *testing.T) {
+	router, db, auth, root := newTestFileRouter(t)
+	createUserWithPermissions(t, db, "viewer", model.PermissionFileView)
+	token := loginAs(t, auth, "viewer")
+	path := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	quoted := strconv.Quote(path)
+	other := strconv.Quote(filepath.Join(root, "b.txt"))
+
+	if w := authRequest(router, http.MethodGet, "/api/files?path="+url.QueryEscape(root), token, ""); w.Code != http.StatusOK {
+		t.Fatalf("只读用户列目录返回 %d，期望 200: %s", w.Code, w.Body.String())
+	}
+
+	mutations := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/api/files/directory", `{"path":` + strconv.Quote(filepath.Join(root, "dir")) + `}`},
+		{http.MethodDelete, "/api/files?path=" + url.QueryEscape(path), ""},
+		{http.MethodDelete, "/api/files/batch", `{"paths":[` + quoted + `]}`},
+		{http.MethodPut, "/api/files/rename", `{"old_path":` + quoted + `,"new_path":` + other + `}`},
+		{http.MethodPost, "/api/files/move", `{"source":` + quoted + `,"destination":` + other + `}`},
+		{http.MethodPost, "/api/files/copy", `{"source":` + quoted + `,"destination":` + other + `}`},
+		{http.MethodPut, "/api/files/permissions", `{"path":` + quoted + `,"mode":"777"}`},
+		{http.MethodPost, "/api/files/archive", `{"paths":[` + quoted + `],"output":` + strconv.Quote(filepath.Join(root, "a.tar.gz")) + `}`},
+		{http.MethodPost, "/api/files/extract", `{"path":` + quoted + `,"dest":` + strconv.Quote(root) + `}`},
+		{http.MethodPut, "/api/files/content", `{"path":` + quoted + `,"content":"changed"}`},
+		{http.MethodPost, "/api/files/trash/restore", `{"id":"x"}`},
+		{http.MethodPost, "/api/files/upload/init", `{"path":` + other + `,"total_size":1,"total_chunks":1}`},
+	}
+	for _, m := range mutations {
+		if w := authRequest(router, m.method, m.path, token, m.body); w.Code != http.StatusForbidden {
+			t.Errorf("只读用户 %s %s 返回 %d，期望 403", m.method, m.path, w.Code)
+		}
+	}
+
+	entries, _ := os.ReadDir(root)
+	if data, _ := os.ReadFile(path); string(data) != "original" || len(entries) != 1 {
+		t.Fatalf("被拒绝的请求修改了文件根目录: 内容 %q，%d 个条目", data, len(entries))
+	}
+}
+
+func TestFileRoutesViewOnlyUserCannotDownloadOrUpload(t *testing.T) {
+	router, db, auth, root := newTestFileRouter(t)
+	createUserWithPermissions(t, db, "viewer", model.PermissionFileView)
+	createUserWithPermissions(t, db, "downloader", model.PermissionFileView, model.PermissionFileDownload)
+	path := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(path, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	escaped := url.QueryEscape(path)
+	token := loginAs(t, auth, "viewer")
+
+	w := authRequest(router, http.MethodGet, "/api/files?path="+url.QueryEscape(root), token, "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "a.txt") {
+		t.Fatalf("只读用户列目录返回 %d: %s", w.Code, w.Body.String())
+	}
+
+	reads := []string{
+		"/api/files/download?path=" + escaped,
+		"/api/files/content?path=" + escaped,
+		"/api/files/checksum?path=" + escaped,
+		"/api/files/thumbnail?path=" + escaped,
+		"/api/files/archive?paths=" + escaped,
+	}
+	for _, target := range reads {
+		if w := authRequest(router, http.MethodGet, target, token, ""); w.Code != http.StatusForbidden || strings.Contains(w.Body.String(), "secret") {
+			t.Errorf("只读用户 GET %s 返回 %d，期望 403", target, w.Code)
+		}
+	}
+
+	uploads := []struct {
+		method, path string
+	}{
+		{http.MethodPost, "/api/files/upload"},
+		{http.MethodPost, "/api/files/upload/chunk"},
+		{http.MethodPost, "/api/files/upload/complete"},
+		{http.MethodGet, "/api/files/uploads"},
+	}
+	for _, u := range uploads {
+		if w := authRequest(router, u.method, u.path, token, ""); w.Code != http.StatusForbidden {
+			t.Errorf("只读用户 %s %s 返回 %d，期望 403", u.method, u.path, w.Code)
+		}
+	}
+
+	// 拥有下载权限后可以下载和计算校验和
+	downloader := loginAs(t, auth, "downloader")
+	if w := authRequest(router, http.MethodGet, "/api/files/download?path="+escaped, downloader, ""); w.Code != http.StatusOK || w.Body.String() != "secret" {
+		t.Fatalf("下载返回 %d %q", w.Code, w.Body.String())
+	}
+	if w := authRequest(router, http.MethodGet, "/api/files/checksum?path="+escaped, downloader, ""); w.Code != http.StatusOK {
+		t.Fatalf("计算校验和返回 %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCreateArchiveStreamingRequiresDownload(t *testing.T) {
+	router, db, auth, root := newTestFileRouter(t)
+	createUserWithPermissions(t, db, "creator", model.PermissionFileView, model.PermissionFileCreate)
+	createUserWithPermissions(t, db, "packer", model.PermissionFileView, model.PermissionFileCreate, model.PermissionFileDownload)
+	path := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(path, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archiveBody := func(output string) string {
+		body, _ := json.Marshal(model.CreateArchiveRequest{Paths: []string{path}, Format: "tar", Output: output})
+		return string(body)
+	}
+
+	// 只有创建权限时不能直接下载压缩包
+	creator := loginAs(t, auth, "creator")
+	if w := authRequest(router, http.MethodPost, "/api/files/archive", creator, archiveBody("")); w.Code != http.StatusForbidden || strings.Contains(w.Body.String(), "secret") {
+		t.Fatalf("无下载权限直接打包返回 %d，期望 403", w.Code)
+	}
+	// 保存到服务器不需要下载权限
+	output := filepath.Join(root, "a.tar")
+	if w := authRequest(router, http.MethodPost, "/api/files/archive", creator, archiveBody(output)); w.Code != http.StatusOK {
+		t.Fatalf("保存压缩包返回 %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Fatalf("压缩包未保存: %v", err)
+	}
+
+	packer := loginAs(t, auth, "packer")
+	if w := authRequest(router, http.MethodPost, "/api/files/archive", packer, archiveBody("")); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "secret") {
+		t.Fatalf("有下载权限直接打包返回 %d", w.Code)
+	}
+}
+
+func TestFileRoutesOverwriteRequiresUpdateAndDelete(t *testing.T) {
+	router, db, auth, root := newTestFileRouter(t)
+	createUserWithPermissions(t, db, "creator", model.PermissionFileView, model.PermissionFileCreate)
+	createUserWithPermissions(t, db, "editor", model.PermissionFileCreate, model.PermissionFileUpdate, model.PermissionFileDelete)
+	token := loginAs(t, auth, "creator")
+	src := filepath.Join(root, "src.txt")
+	dst := filepath.Join(root, "dst.txt")
+	os.WriteFile(src, []byte("new"), 0644)
+	os.WriteFile(dst, []byte("keep"), 0644)
+
+	body := `{"source":` + strconv.Quote(src) + `,"destination":` + strconv.Quote(dst) + `,"overwrite":true}`
+	if w := authRequest(router, http.MethodPost, "/api/files/copy", token, body); w.Code != http.StatusForbidden {
+		t.Fatalf("只有创建权限时覆盖复制返回 %d，期望 403", w.Code)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "keep" {
+		t.Fatalf("被拒绝的覆盖复制修改了目标: %q", data)
+	}
+
+	body = `{"source":` + strconv.Quote(src) + `,"destination":` + strconv.Quote(filepath.Join(root, "copy.txt")) + `}`
+	if w := authRequest(router, http.MethodPost, "/api/files/copy", token, body); w.Code != http.StatusOK {
+		t.Fatalf("只有创建权限时复制到新路径返回 %d，期望 200: %s", w.Code, w.Body.String())
+	}
+
+	editor := loginAs(t, auth, "editor")
+	body = `{"source":` + strconv.Quote(src) + `,"destination":` + strconv.Quote(dst) + `,"overwrite":true}`
+	if w := authRequest(router, http.MethodPost, "/api/files/copy", editor, body); w.Code != http.StatusOK {
+		t.Fatalf("拥有更新和删除权限时覆盖复制返回 %d，期望 200: %s", w.Code, w.Body.String())
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "new" {
+		t.Fatalf("覆盖复制后目标内容 %q，期望 new", data)
+	}
+}
+
+func TestGetFileContentRejectsInvalidLineWindow(t *testing.T) {
+	router, db, auth, root := newTestFileRouter(t)
+	createUserWithPermissions(t, db, "reader", model.PermissionFileDownload)
+	token := loginAs(t, auth, "reader")
+	path := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"offset_line=-1", http.StatusBadRequest},
+		{"offset_line=abc", http.StatusBadRequest},
+		{"line_count=0", http.StatusBadRequest},
+		{"line_count=" + strconv.Itoa(service.MaxLineWindow+1), http.StatusBadRequest},
+		{"offset_line=1&line_count=1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := authRequest(router, http.MethodGet, "/api/files/content?path="+url.QueryEscape(path)+"&"+tt.query, token, "")
+			if w.Code != tt.want {
+				t.Fatalf("状态码 = %d, 期望 %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListFilesAndContentIncludeMetadata(t *testing.T) {
+	router, db, auth, root := newTestFileRouter(t)
+	createUserWithPermissions(t, db, "reader", model.PermissionFileView, model.PermissionFileDownload)
+	token := loginAs(t, auth, "reader")
+	dir := filepath.Join(root, "docs")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("hello"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := authRequest(router, http.MethodGet, "/api/files?page=1&page_size=1&path="+url.QueryEscape(dir), token, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("列目录返回 %d: %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Data       []model.FileInfo `json:"data"`
+		Total      int64            `json:"total"`
+		Size       int              `json:"size"`
+		PageSize   int              `json:"page_size"`
+		Path       string           `json:"path"`
+		ParentPath string           `json:"parent_path"`
+		IsRoot     bool             `json:"is_root"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 || list.Total != 2 || list.Size != 1 || list.PageSize != 1 {
+		t.Fatalf("分页字段不正确: %+v", list)
+	}
+	if list.Path != dir || list.ParentPath != root || list.IsRoot {
+		t.Fatalf("目录元数据不正确: path=%q parent=%q is_root=%v", list.Path, list.ParentPath, list.IsRoot)
+	}
+
+	w = authRequest(router, http.MethodGet, "/api/files?path="+url.QueryEscape(root), token, "")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if !list.IsRoot {
+		t.Fatalf("根目录 is_root 应为 true: %s", w.Body.String())
+	}
+
+	w = authRequest(router, http.MethodGet, "/api/files/content?path="+url.QueryEscape(filepath.Join(dir, "a.txt")), token, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("读取文件内容返回 %d: %s", w.Code, w.Body.String())
+	}
+	var content struct {
+		Data model.FileContentResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &content); err != nil {
+		t.Fatal(err)
+	}
+	got := content.Data
+	if got.Content != "hello" || got.Size != 5 || got.Permissions == "" || got.Encoding == "" || got.ModTime.IsZero() {
+		t.Fatalf("文件内容元数据不正确: %+v", got)
+	}
+}
+
+func TestFileErrorStatusCodes(t *testing.T) {
+	router, db, auth, root := newTestFileRouter(t)
+	createUserWithPermissions(t, db, "operator", model.PermissionFileView, model.PermissionFileCreate,
+		model.PermissionFileUpdate, model.PermissionFileDownload)
+	token := loginAs(t, auth, "operator")
+	dir := filepath.Join(root, "dir")
+	src := filepath.Join(root, "a.txt")
+	os.Mkdir(dir, 0755)
+	os.WriteFile(src, []byte("a"), 0644)
+	os.WriteFile(filepath.Join(root, "b.txt"), []byte("b"), 0644)
+	missing := filepath.Join(root, "missing.txt")
+
+	move := func(source, destination string) string {
+		return `{"source":` + strconv.Quote(source) + `,"destination":` + strconv.Quote(destination) + `}`
+	}
+	archive := func(output string, paths ...string) string {
+		data, _ := json.Marshal(model.CreateArchiveRequest{Paths: paths, Output: output})
+		return string(data)
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"移动到已存在的目标", http.MethodPost, "/api/files/move", move(src, filepath.Join(root, "b.txt")), http.StatusConflict},
+		{"移动不存在的文件", http.MethodPost, "/api/files/move", move(missing, filepath.Join(root, "c.txt")), http.StatusBadRequest},
+		{"目录移动到子目录", http.MethodPost, "/api/files/move", move(dir, filepath.Join(dir, "sub")), http.StatusBadRequest},
+		{"复制到相同路径", http.MethodPost, "/api/files/copy", move(src, src), http.StatusBadRequest},
+		{"下载目录", http.MethodGet, "/api/files/download?path=" + url.QueryEscape(dir), "", http.StatusBadRequest},
+		{"下载不存在的文件", http.MethodGet, "/api/files/download?path=" + url.QueryEscape(missing), "", http.StatusNotFound},
+		{"目录校验和", http.MethodGet, "/api/files/checksum?path=" + url.QueryEscape(dir), "", http.StatusBadRequest},
+		{"不存在文件的校验和", http.MethodGet, "/api/files/checksum?path=" + url.QueryEscape(missing), "", http.StatusNotFound},
+		{"不支持的校验算法", http.MethodGet, "/api/files/checksum?algo=crc32&path=" + url.QueryEscape(src), "", http.StatusBadRequest},
+		{"压缩包输出到源目录", http.MethodPost, "/api/files/archive", archive(filepath.Join(dir, "out.tar.gz"), dir), http.StatusBadRequest},
+		{"压缩包目标已存在", http.MethodPost, "/api/files/archive", archive(filepath.Join(root, "b.txt"), src), http.StatusConflict},
+		{"打包不存在的文件", http.MethodPost, "/api/files/archive", archive(filepath.Join(root, "out.tar.gz"), missing), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := authRequest(router, tt.method, tt.path, token, tt.body); w.Code != tt.status {
+				t.Fatalf("状态码 = %d, 期望 %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListFilesWithoutPagination(t *testing.T) {
+	router, db, auth, root := newTestFileRouter(t)
+	createUserWithPermissions(t, db, "reader", model.PermissionFileView)
+	token := loginAs(t, auth, "reader")
+	// 条目数超过分页允许的最大每页数量
+	for i := 0; i < 250; i++ {
+		if err := os.WriteFile(filepath.Join(root, "file"+strconv.Itoa(i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, query := range []string{"all=true", "page_size=0", "page=3&all=true"} {
+		w := authRequest(router, http.MethodGet, "/api/files?"+query+"&path="+url.QueryEscape(root), token, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s 返回 %d: %s", query, w.Code, w.Body.String())
+		}
+		var list struct {
+			Data      []model.FileInfo `json:"data"`
+			Total     int64            `json:"total"`
+			Page      int              `json:"page"`
+			Size      int              `json:"size"`
+			Truncated bool             `json:"truncated"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Data) != 250 || list.Total != 250 || list.Page != 1 || list.Size != 250 || list.Truncated {
+			t.Fatalf("%s 返回 %d/%d 条 page=%d size=%d truncated=%v，期望全部 250 条", query, len(list.Data), list.Total, list.Page, list.Size, list.Truncated)
+		}
+	}
+}
+
+// readFlag 记录请求体是否被读取
+type readFlag struct {
+	io.Reader
+	read bool
+}
+
+func (r *readFlag) Read(p []byte) (int, error) {
+	r.read = true
+	return r.Reader.Read(p)
+}
+
+func TestUploadFileConcurrencyLimit(t *testing.T) {
+	db := newTestDB(t)
+	cfg := newTestConfig(t)
+	cfg.File.MaxConcurrentUploadsPerUser = 1
+	auth := service.NewAuthService(db, cfg, service.NewRBACCache(db))
+	files := service.NewFileService(db, cfg, service.NewSettingService(db))
+	router := gin.New()
+	RegisterFileRoutes(router.Group("/api"), NewFileHandler(files, service.NewChunkedUploadService(cfg, files), auth))
+	root, _ := filepath.EvalSymlinks(cfg.System.FileRootDir)
+	createUserWithPermissions(t, db, "uploader", model.PermissionFileView, model.PermissionFileUpload)
+	token := loginAs(t, auth, "uploader")
+	var uploader model.User
+	if err := db.Where("username = ?", "uploader").First(&uploader).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func() (*httptest.ResponseRecorder, *readFlag) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("path", root)
+		part, _ := form.CreateFormFile("file", "hello.txt")
+		part.Write([]byte("hello"))
+		form.Close()
+		flag := &readFlag{Reader: &body}
+		req := httptest.NewRequest(http.MethodPost, "/api/files/upload", flag)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w, flag
+	}
+
+	// 该用户已有一个上传在进行，新的上传被拒绝且不读取请求体
+	release, ok := files.AcquireUploadSlot(uploader.ID)
+	if !ok {
+		t.Fatal("占用上传名额失败")
+	}
+	w, flag := upload()
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("超出并发上限返回 %d，Retry-After=%q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if flag.read {
+		t.Fatal("超出并发上限时不应读取上传内容")
+	}
+
+	// 进行中的上传结束后可以再次上传
+	release()
+	if w, _ := upload(); w.Code != http.StatusOK {
+		t.Fatalf("名额释放后上传返回 %d: %s", w.Code, w.Body.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "hello.txt")); err != nil || string(data) != "hello" {
+		t.Fatalf("上传的文件内容 %q, %v", data, err)
+	}
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"net/http"
+	"time"
+
 	"web-panel-go/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -8,43 +11,37 @@ import (
 
 // Handlers 处理器集合
 type Handlers struct {
-	Auth   *AuthHandler
-	User   *UserHandler
-	System *SystemHandler
-	File   *FileHandler
+	Auth       *AuthHandler
+	User       *UserHandler
+	System     *SystemHandler
+	File       *FileHandler
+	Audit      *AuditHandler
+	Setting    *SettingHandler
+	Role       *RoleHandler
+	Permission *PermissionHandler
+	Health     *HealthHandler
+	Session    *SessionHandler
 }
 
 // NewHandlers 创建处理器集合
 func NewHandlers(services *service.Services) *Handlers {
 	return &Handlers{
-		Auth:   NewAuthHandler(services.Auth),
-		User:   NewUserHandler(services.User, services.Auth),
-		System: NewSystemHandler(services.System, services.Auth),
-		File:   NewFileHandler(services.File, services.Auth),
+		Auth:       NewAuthHandler(services.Auth, services.Audit, services.Preference),
+		User:       NewUserHandler(services.User, services.Auth),
+		System:     NewSystemHandler(services.System, services.Auth, services.Jobs, services.Diagnostics, services.Metrics),
+		File:       NewFileHandler(services.File, services.ChunkedUpload, services.Auth),
+		Audit:      NewAuditHandler(services.Audit, services.Auth),
+		Setting:    NewSettingHandler(services.Setting, services.Auth),
+		Role:       NewRoleHandler(services.Role, services.Auth),
+		Permission: NewPermissionHandler(services.Permission, services.Auth),
+		Health:     NewHealthHandler(services.Health),
+		Session:    NewSessionHandler(services.Session, services.Auth),
 	}
 }
 
-// RegisterRoutes 注册所有路由
-func RegisterRoutes(r *gin.Engine, handlers *Handlers) {
-	// API 路由组
-	api := r.Group("/api")
-	
-	// 注册各模块路由
-	RegisterAuthRoutes(api, handlers.Auth)
-	RegisterUserRoutes(api, handlers.User)
-	RegisterSystemRoutes(api, handlers.System)
-	RegisterFileRoutes(api, handlers.File)
-	
-	// 健康检查路由
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-			"message": "Web Panel Go API is running",
-		})
-	})
-	
-	// 根路径重定向到健康检查
-	r.GET("/", func(c *gin.Context) {
-		c.Redirect(302, "/health")
-	})
-}
\ No newline at end of file
+// clearWriteDeadline 取消服务器 WriteTimeout 对当前响应的限制
+// 用于下载、导出等耗时可能超过写超时的响应，否则大文件会在传输中途被截断
+func clearWriteDeadline(c *gin.Context) {
+	// 测试用的 ResponseRecorder 等不支持设置截止时间，忽略错误
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClearWriteDeadlineOutlivesWriteTimeout(t *testing.T) {
+	router := gin.New()
+	slowStream := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		for i := 0; i < 4; i++ {
+			c.Writer.WriteString("chunk\n")
+			c.Writer.Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	router.GET("/limited", slowStream)
+	router.GET("/unlimited", func(c *gin.Context) {
+		clearWriteDeadline(c)
+		slowStream(c)
+	})
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.WriteTimeout = 150 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	read := func(path string) (string, error) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	if body, err := read("/limited"); err == nil && len(body) == 24 {
+		t.Fatal("未取消写超时的响应应被截断")
+	}
+	body, err := read("/unlimited")
+	if err != nil || len(body) != 24 {
+		t.Fatalf("取消写超时后应完整返回: %q, %v", body, err)
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler 健康检查处理器
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+// NewHealthHandler 创建健康检查处理器实例
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Live 存活检查
+// @Summary 存活检查
+// @Description 进程存活即返回200，不访问数据库，数据库短暂不可用不会导致容器被重启
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /health/live [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "ok",
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// Ready 就绪检查
+// @Summary 就绪检查
+// @Description 检查数据库连接和数据/日志目录，全部正常返回200，否则返回503
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} model.ReadinessReport
+// @Failure 503 {object} model.ReadinessReport
+// @Router /health/ready [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.healthService.CheckReadiness()
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// RegisterHealthRoutes 注册健康检查路由（无需认证）
+func RegisterHealthRoutes(r *gin.Engine, healthHandler *HealthHandler) {
+	r.GET("/health", healthHandler.Live)
+	r.GET("/health/live", healthHandler.Live)
+	r.GET("/health/ready", healthHandler.Ready)
+}
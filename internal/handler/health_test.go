@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestHealthRouter 创建只注册健康检查路由的路由器
+func newTestHealthRouter(t *testing.T) (*gin.Engine, func()) {
+	t.Helper()
+	db := newTestDB(t)
+	cfg := newTestConfig(t)
+	cfg.System.LogDir = t.TempDir()
+
+	r := gin.New()
+	RegisterHealthRoutes(r, NewHealthHandler(service.NewHealthService(db, cfg)))
+	closeDB := func() {
+		sqlDB, err := db.DB()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB.Close()
+	}
+	return r, closeDB
+}
+
+func TestReadyReportsDatabaseDownWithoutDetails(t *testing.T) {
+	r, closeDB := newTestHealthRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("数据库正常时状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	closeDB()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("数据库不可用时状态码 = %d, 期望 503", w.Code)
+	}
+
+	var report model.ReadinessReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Ready || report.Checks["database"] != "failed" || report.Checks["data_dir"] != "ok" {
+		t.Fatalf("就绪检查结果不正确: %+v", report)
+	}
+	// 未认证接口不返回错误信息
+	if strings.Contains(w.Body.String(), "sql") || strings.Contains(w.Body.String(), "数据库") {
+		t.Fatalf("响应包含内部错误信息: %s", w.Body.String())
+	}
+
+	// 存活检查不访问数据库
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("存活检查状态码 = %d", w.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	// 服务层直接调用全局日志器，测试中丢弃输出
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// newTestConfig 返回默认配置，文件根目录和数据目录指向测试临时目录
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg, err := config.Defaults()
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+	cfg.System.FileRootDir = t.TempDir()
+	cfg.System.DataDir = t.TempDir()
+	cfg.File.ThumbnailCacheDir = t.TempDir()
+	return cfg
+}
+
+// newTestDB 创建已迁移并写入默认权限、角色和管理员的内存数据库
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenInMemory(config.SeedConfig{Enabled: true, AdminUsername: "admin", AdminPassword: "Admin@12345"})
+	if err != nil {
+		t.Fatalf("创建内存数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// testUserPassword createUserWithPermissions 创建的用户的密码
+const testUserPassword = "Passw0rd!"
+
+// createUserWithPermissions 创建只拥有指定权限的角色和用户
+// 角色权限由认证服务缓存，需在首次登录前创建全部用户
+func createUserWithPermissions(t *testing.T, db *gorm.DB, username string, permissions ...string) {
+	t.Helper()
+	var perms []model.Permission
+	if err := db.Where("name IN ?", permissions).Find(&perms).Error; err != nil || len(perms) != len(permissions) {
+		t.Fatalf("查询权限 %v 失败: %v", permissions, err)
+	}
+	role := &model.Role{Name: username + "-role", Status: model.RoleStatusActive, Permissions: perms}
+	if err := db.Create(role).Error; err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+
+	user := &model.User{Username: username, Email: username + "@example.com", Status: model.UserStatusActive}
+	if err := user.SetPassword(testUserPassword, config.PasswordPolicyConfig{}); err != nil {
+		t.Fatalf("设置密码失败: %v", err)
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	if err := db.Create(&model.UserRole{UserID: user.ID, RoleID: role.ID}).Error; err != nil {
+		t.Fatalf("分配角色失败: %v", err)
+	}
+}
+
+// loginAs 以 createUserWithPermissions 创建的用户登录，返回访问令牌
+func loginAs(t *testing.T, auth *service.AuthService, username string) string {
+	t.Helper()
+	resp, err := auth.Login(&model.LoginRequest{Username: username, Password: testUserPassword}, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("用户 %s 登录失败: %v", username, err)
+	}
+	return resp.Token
+}
+
+// authRequest 携带访问令牌发送请求并返回响应
+func authRequest(router http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/middleware"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionHandler 权限处理器
+type PermissionHandler struct {
+	permissionService *service.PermissionService
+	authService       *service.AuthService
+}
+
+// NewPermissionHandler 创建权限处理器实例
+func NewPermissionHandler(permissionService *service.PermissionService, authService *service.AuthService) *PermissionHandler {
+	return &PermissionHandler{
+		permissionService: permissionService,
+		authService:       authService,
+	}
+}
+
+// ListPermissions 获取权限列表
+// @Summary 获取权限列表
+// @Description 获取全部权限定义，按资源和操作排序，可按资源或操作过滤
+// @Tags 权限管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param resource query string false "资源类型，如 user、file"
+// @Param action query string false "操作类型，如 view、create"
+// @Success 200 {object} model.APIResponse{data=[]model.Permission}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/permissions [get]
+func (h *PermissionHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.permissionService.ListPermissions(c.Query("resource"), c.Query("action"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取权限列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取权限列表成功",
+		Data:    permissions,
+	})
+}
+
+// GetRolePermissions 获取角色权限
+// @Summary 获取角色权限
+// @Description 获取指定角色当前拥有的权限
+// @Tags 权限管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "角色ID"
+// @Success 200 {object} model.APIResponse{data=[]model.Permission}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/roles/{id}/permissions [get]
+func (h *PermissionHandler) GetRolePermissions(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的角色ID",
+		})
+		return
+	}
+
+	permissions, err := h.permissionService.GetRolePermissions(uint(roleID))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrRoleNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "获取角色权限失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取角色权限成功",
+		Data:    permissions,
+	})
+}
+
+// SetRolePermissions 设置角色权限
+// @Summary 设置角色权限
+// @Description 用给定的权限ID集合替换角色的全部权限，任一权限不存在时不做修改
+// @Tags 权限管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "角色ID"
+// @Param request body model.SetRolePermissionsRequest true "权限ID列表"
+// @Success 200 {object} model.APIResponse{data=[]model.Permission}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Failure 503 {object} model.APIResponse
+// @Router /api/roles/{id}/permissions [put]
+func (h *PermissionHandler) SetRolePermissions(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的角色ID",
+		})
+		return
+	}
+
+	var req model.SetRolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	permissions, err := h.permissionService.SetRolePermissions(uint(roleID), req.PermissionIDs, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrRoleNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrPermissionNotFound):
+			status = http.StatusBadRequest
+		case errors.Is(err, database.ErrDatabaseBusy):
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "设置角色权限失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "设置角色权限成功",
+		Data:    permissions,
+	})
+}
+
+// RegisterPermissionRoutes 注册权限路由
+func RegisterPermissionRoutes(r *gin.RouterGroup, permissionHandler *PermissionHandler) {
+	permissions := r.Group("/permissions")
+	permissions.Use(middleware.AuthMiddleware(permissionHandler.authService))
+	{
+		permissions.GET("", middleware.RequirePermission(model.PermissionPermissionView), permissionHandler.ListPermissions)
+	}
+
+	roles := r.Group("/roles")
+	roles.Use(middleware.AuthMiddleware(permissionHandler.authService))
+	{
+		roles.GET("/:id/permissions", middleware.RequirePermission(model.PermissionRoleView), permissionHandler.GetRolePermissions)
+		roles.PUT("/:id/permissions", middleware.RequirePermission(model.PermissionRoleUpdate), permissionHandler.SetRolePermissions)
+	}
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/middleware"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleHandler 角色处理器
+type RoleHandler struct {
+	roleService *service.RoleService
+	authService *service.AuthService
+}
+
+// NewRoleHandler 创建角色处理器实例
+func NewRoleHandler(roleService *service.RoleService, authService *service.AuthService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+		authService: authService,
+	}
+}
+
+// ListRoles 获取可分配的角色列表
+// @Summary 获取角色列表
+// @Description 获取可分配的角色列表及每个角色的用户数，按角色名排序
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query int false "角色状态（0: 禁用, 1: 启用）"
+// @Success 200 {object} model.APIResponse{data=[]model.RoleSummary}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	var status *model.RoleStatus
+	if raw := c.Query("status"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || (model.RoleStatus(value) != model.RoleStatusActive && model.RoleStatus(value) != model.RoleStatusInactive) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "无效的角色状态",
+			})
+			return
+		}
+		roleStatus := model.RoleStatus(value)
+		status = &roleStatus
+	}
+
+	roles, err := h.roleService.ListRoles(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取角色列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取角色列表成功",
+		Data:    roles,
+	})
+}
+
+// CreateRole 创建角色
+// @Summary 创建角色
+// @Description 创建角色并分配权限，任一权限不存在时不创建；操作写入审计日志并通知管理员
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.CreateRoleRequest true "角色信息"
+// @Success 201 {object} model.APIResponse{data=model.Role}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Failure 503 {object} model.APIResponse
+// @Router /api/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req model.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	role, err := h.roleService.CreateRole(&req, userID, clientIP, userAgent)
+	if err != nil {
+		status := roleErrorStatus(err)
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "创建角色失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.APIResponse{
+		Code:    http.StatusCreated,
+		Message: "创建角色成功",
+		Data:    role,
+	})
+}
+
+// UpdateRole 更新角色
+// @Summary 更新角色
+// @Description 更新角色的显示名称、描述、状态和权限，permission_ids 为空时不修改权限；审计日志记录变更差异，并通知拥有该角色的用户
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "角色ID"
+// @Param request body model.UpdateRoleRequest true "角色信息"
+// @Success 200 {object} model.APIResponse{data=model.Role}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Failure 503 {object} model.APIResponse
+// @Router /api/roles/{id} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的角色ID",
+		})
+		return
+	}
+
+	var req model.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	role, err := h.roleService.UpdateRole(uint(roleID), &req, userID, clientIP, userAgent)
+	if err != nil {
+		status := roleErrorStatus(err)
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "更新角色失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "更新角色成功",
+		Data:    role,
+	})
+}
+
+// DeleteRole 删除角色
+// @Summary 删除角色
+// @Description 删除角色及其权限和用户分配，系统角色不能删除；拥有该角色的用户会收到权限变更通知
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "角色ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Failure 503 {object} model.APIResponse
+// @Router /api/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	roleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的角色ID",
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if err := h.roleService.DeleteRole(uint(roleID), userID, clientIP, userAgent); err != nil {
+		status := roleErrorStatus(err)
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "删除角色失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "删除角色成功",
+	})
+}
+
+// roleErrorStatus 角色管理错误对应的HTTP状态码
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRoleExists), errors.Is(err, service.ErrSystemRole):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrPermissionNotFound), errors.Is(err, service.ErrInvalidRoleStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, database.ErrDatabaseBusy):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// ReloadRBAC 重新加载角色权限缓存
+// @Summary 重新加载角色权限缓存
+// @Description 数据库中的角色或权限被外部修改后，使权限缓存失效并重新加载权限目录
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/system/rbac/reload [post]
+func (h *RoleHandler) ReloadRBAC(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if err := h.roleService.ReloadRBAC(userID, clientIP, userAgent); err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "重新加载角色权限失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "重新加载角色权限成功",
+	})
+}
+
+// RegisterRoleRoutes 注册角色路由
+func RegisterRoleRoutes(r *gin.RouterGroup, roleHandler *RoleHandler) {
+	roles := r.Group("/roles")
+	roles.Use(middleware.AuthMiddleware(roleHandler.authService))
+	{
+		roles.GET("", middleware.RequirePermission(model.PermissionRoleView), roleHandler.ListRoles)
+		roles.POST("", middleware.RequirePermission(model.PermissionRoleCreate), roleHandler.CreateRole)
+		roles.PUT("/:id", middleware.RequirePermission(model.PermissionRoleUpdate), roleHandler.UpdateRole)
+		roles.DELETE("/:id", middleware.RequirePermission(model.PermissionRoleDelete), roleHandler.DeleteRole)
+	}
+
+	rbac := r.Group("/system/rbac")
+	rbac.Use(middleware.AuthMiddleware(roleHandler.authService))
+	rbac.Use(middleware.RequireRole(model.RoleAdmin))
+	{
+		rbac.POST("/reload", roleHandler.ReloadRBAC)
+	}
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"web-panel-go/internal/middleware"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler 会话管理处理器
+type SessionHandler struct {
+	sessionService *service.SessionService
+	authService    *service.AuthService
+}
+
+// NewSessionHandler 创建会话管理处理器实例
+func NewSessionHandler(sessionService *service.SessionService, authService *service.AuthService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+		authService:    authService,
+	}
+}
+
+// ListMySessions 获取当前用户的会话
+// @Summary 获取当前用户的会话
+// @Description 获取当前用户未过期的会话，current 标记发起本次请求的会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.SessionInfo}
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/auth/sessions [get]
+func (h *SessionHandler) ListMySessions(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	token, _ := middleware.GetCurrentToken(c)
+
+	h.listSessions(c, userID, token)
+}
+
+// RevokeMySession 撤销当前用户的会话
+// @Summary 撤销当前用户的会话
+// @Description 撤销当前用户的指定会话，撤销当前会话等同于登出
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "会话ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/auth/sessions/{id} [delete]
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+
+	h.revokeSession(c, c.Param("id"), userID)
+}
+
+// ListUserSessions 获取指定用户的会话
+// @Summary 获取指定用户的会话
+// @Description 管理员获取指定用户未过期的会话
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} model.APIResponse{data=[]model.SessionInfo}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/users/{id}/sessions [get]
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	userID, ok := parseSessionUserID(c)
+	if !ok {
+		return
+	}
+	token, _ := middleware.GetCurrentToken(c)
+
+	h.listSessions(c, userID, token)
+}
+
+// RevokeUserSession 撤销指定用户的会话
+// @Summary 撤销指定用户的会话
+// @Description 管理员撤销指定用户的某个会话
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param session_id path string true "会话ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/users/{id}/sessions/{session_id} [delete]
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, ok := parseSessionUserID(c)
+	if !ok {
+		return
+	}
+
+	h.revokeSession(c, c.Param("session_id"), userID)
+}
+
+// listSessions 返回用户的会话列表
+func (h *SessionHandler) listSessions(c *gin.Context, userID uint, currentToken string) {
+	sessions, err := h.sessionService.ListSessions(userID, currentToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取会话列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取会话列表成功",
+		Data:    sessions,
+	})
+}
+
+// revokeSession 撤销用户的指定会话
+func (h *SessionHandler) revokeSession(c *gin.Context, sessionID string, userID uint) {
+	operatorID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if err := h.sessionService.RevokeSession(sessionID, userID, operatorID, clientIP, userAgent); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrSessionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "撤销会话失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "撤销会话成功",
+	})
+}
+
+// parseSessionUserID 解析路径中的用户ID，无效时直接返回400
+func parseSessionUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的用户ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// RegisterSessionRoutes 注册会话管理路由
+func RegisterSessionRoutes(r *gin.RouterGroup, sessionHandler *SessionHandler) {
+	auth := r.Group("/auth")
+	auth.Use(middleware.AuthMiddleware(sessionHandler.authService))
+	{
+		auth.GET("/sessions", sessionHandler.ListMySessions)
+		auth.DELETE("/sessions/:id", sessionHandler.RevokeMySession)
+	}
+
+	users := r.Group("/users")
+	users.Use(middleware.AuthMiddleware(sessionHandler.authService))
+	{
+		users.GET("/:id/sessions", middleware.RequirePermission(model.PermissionUserView), sessionHandler.ListUserSessions)
+		users.DELETE("/:id/sessions/:session_id", middleware.RequirePermission(model.PermissionUserUpdate), sessionHandler.RevokeUserSession)
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+
+	"web-panel-go/internal/middleware"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SettingHandler 面板设置处理器
+type SettingHandler struct {
+	settingService *service.SettingService
+	authService    *service.AuthService
+}
+
+// NewSettingHandler 创建面板设置处理器实例
+func NewSettingHandler(settingService *service.SettingService, authService *service.AuthService) *SettingHandler {
+	return &SettingHandler{
+		settingService: settingService,
+		authService:    authService,
+	}
+}
+
+// GetSettings 获取面板设置
+// @Summary 获取面板设置
+// @Description 获取面板设置，拥有系统配置权限的用户可看到全部设置，其他用户只能看到公开设置
+// @Tags 面板设置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.SystemConfig}
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/settings [get]
+func (h *SettingHandler) GetSettings(c *gin.Context) {
+	includePrivate := false
+	if user, exists := middleware.GetCurrentUser(c); exists {
+		includePrivate = user.IsAdmin() || user.HasPermission(model.PermissionSystemConfig)
+	}
+
+	settings, err := h.settingService.GetSettings(includePrivate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取设置失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取设置成功",
+		Data:    settings,
+	})
+}
+
+// UpdateSettings 更新面板设置
+// @Summary 更新面板设置
+// @Description 批量更新面板设置，只允许修改已定义的设置项
+// @Tags 面板设置
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.UpdateSettingsRequest true "更新设置请求"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/settings [put]
+func (h *SettingHandler) UpdateSettings(c *gin.Context) {
+	var req model.UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if err := h.settingService.UpdateSettings(req.Settings, userID, clientIP, userAgent); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "更新设置失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "更新设置成功",
+	})
+}
+
+// RegisterSettingRoutes 注册面板设置路由
+func RegisterSettingRoutes(r *gin.RouterGroup, settingHandler *SettingHandler) {
+	settings := r.Group("/settings")
+	settings.Use(middleware.AuthMiddleware(settingHandler.authService))
+	{
+		settings.GET("", settingHandler.GetSettings)
+		settings.PUT("", middleware.RequirePermission(model.PermissionSystemConfig), settingHandler.UpdateSettings)
+	}
+}
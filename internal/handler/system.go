@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"web-panel-go/internal/logger"
 	"web-panel-go/internal/middleware"
 	"web-panel-go/internal/model"
 	"web-panel-go/internal/service"
@@ -13,15 +17,21 @@ import (
 
 // SystemHandler 系统处理器
 type SystemHandler struct {
-	systemService *service.SystemService
-	authService   *service.AuthService
+	systemService      *service.SystemService
+	authService        *service.AuthService
+	jobService         *service.JobService
+	diagnosticsService *service.DiagnosticsService
+	metricsService     *service.MetricsService
 }
 
 // NewSystemHandler 创建系统处理器实例
-func NewSystemHandler(systemService *service.SystemService, authService *service.AuthService) *SystemHandler {
+func NewSystemHandler(systemService *service.SystemService, authService *service.AuthService, jobService *service.JobService, diagnosticsService *service.DiagnosticsService, metricsService *service.MetricsService) *SystemHandler {
 	return &SystemHandler{
-		systemService: systemService,
-		authService:   authService,
+		systemService:      systemService,
+		authService:        authService,
+		jobService:         jobService,
+		diagnosticsService: diagnosticsService,
+		metricsService:     metricsService,
 	}
 }
 
@@ -85,14 +95,16 @@ func (h *SystemHandler) GetNetworkStats(c *gin.Context) {
 
 // GetProcessList 获取进程列表
 // @Summary 获取进程列表
-// @Description 获取系统进程列表，支持分页
+// @Description 获取系统进程列表，支持分页；通过fields只采集需要的字段以降低开销
 // @Tags 系统监控
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param fields query string false "只返回指定字段，逗号分隔，如 pid,name,cpu_percent；不指定时返回全部字段"
 // @Success 200 {object} model.APIResponse{data=model.PaginatedResponse}
+// @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
 // @Failure 500 {object} model.APIResponse
 // @Router /api/system/processes [get]
@@ -109,7 +121,17 @@ func (h *SystemHandler) GetProcessList(c *gin.Context) {
 		pageSize = 20
 	}
 
-	processes, total, err := h.systemService.GetProcessList(page, pageSize)
+	fields, err := service.ParseProcessFields(c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	processes, total, err := h.systemService.GetProcessList(page, pageSize, fields)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Code:    http.StatusInternalServerError,
@@ -119,9 +141,18 @@ func (h *SystemHandler) GetProcessList(c *gin.Context) {
 		return
 	}
 
+	var data interface{} = processes
+	if fields != nil {
+		projected := make([]interface{}, 0, len(processes))
+		for i := range processes {
+			projected = append(projected, fields.Project(&processes[i]))
+		}
+		data = projected
+	}
+
 	// 构建分页响应
 	response := model.PaginatedResponse{
-		Data:     processes,
+		Data:     data,
 		Total:    total,
 		Page:     page,
 		PageSize: pageSize,
@@ -136,13 +167,14 @@ func (h *SystemHandler) GetProcessList(c *gin.Context) {
 
 // KillProcess 终止进程
 // @Summary 终止进程
-// @Description 根据PID终止指定进程
+// @Description 根据PID终止指定进程；confirm=true时先返回确认令牌，携带confirm_token再次请求才会执行；security.confirm_actions 包含 kill_process 时未携带confirm_token的请求返回403
 // @Tags 系统监控
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body model.KillProcessRequest true "终止进程请求"
 // @Success 200 {object} model.APIResponse
+// @Success 202 {object} model.APIResponse{data=model.ConfirmationResponse}
 // @Failure 400 {object} model.APIResponse
 // @Failure 401 {object} model.APIResponse
 // @Failure 403 {object} model.APIResponse
@@ -168,13 +200,52 @@ func (h *SystemHandler) KillProcess(c *gin.Context) {
 		return
 	}
 
+	h.killProcess(c, req)
+}
+
+// killProcess 终止进程，处理申请确认令牌、凭令牌终止和直接终止三种请求
+func (h *SystemHandler) killProcess(c *gin.Context, req model.KillProcessRequest) {
 	// 获取用户信息
 	userID, _ := middleware.GetCurrentUserID(c)
 	clientIP := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
 
+	// 申请二次确认：返回确认令牌，不立即执行
+	if req.Confirm && req.ConfirmToken == "" {
+		confirmation, err := h.systemService.RequestKillConfirmation(req.PID, userID, clientIP, userAgent)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: "申请确认失败",
+				Error:   err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, model.APIResponse{
+			Code:    http.StatusAccepted,
+			Message: "请确认后执行",
+			Data:    confirmation,
+		})
+		return
+	}
+
 	// 终止进程
-	if err := h.systemService.KillProcess(req.PID, userID, clientIP, userAgent); err != nil {
+	var err error
+	if req.ConfirmToken != "" {
+		err = h.systemService.ConfirmKillProcess(req.PID, req.ConfirmToken, userID, clientIP, userAgent)
+	} else {
+		err = h.systemService.KillProcess(req.PID, userID, clientIP, userAgent)
+	}
+	if errors.Is(err, service.ErrInvalidConfirmToken) || errors.Is(err, service.ErrConfirmationRequired) || errors.Is(err, service.ErrProtectedProcess) {
+		c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Code:    http.StatusForbidden,
+			Message: "终止进程失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Code:    http.StatusInternalServerError,
 			Message: "终止进程失败",
@@ -189,6 +260,83 @@ func (h *SystemHandler) KillProcess(c *gin.Context) {
 	})
 }
 
+// SignalProcess 向进程发送信号
+// @Summary 向进程发送信号
+// @Description 根据PID向指定进程发送信号，支持 TERM、HUP、INT、QUIT、KILL、USR1、USR2、STOP、CONT；不允许向 PID 1 和面板自身发送；KILL 与终止进程接口相同，需要时先申请确认令牌
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.SignalProcessRequest true "发送信号请求"
+// @Success 200 {object} model.APIResponse
+// @Success 202 {object} model.APIResponse{data=model.ConfirmationResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/system/processes/signal [post]
+func (h *SystemHandler) SignalProcess(c *gin.Context) {
+	var req model.SignalProcessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 参数验证
+	if req.PID <= 0 {
+		c.JSON(http.StatusBadRequest, model.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的进程ID",
+		})
+		return
+	}
+
+	// KILL 走终止进程的确认流程，审计日志同样记为 kill_process
+	if service.IsKillSignal(req.Signal) {
+		h.killProcess(c, model.KillProcessRequest{PID: req.PID, Confirm: req.Confirm, ConfirmToken: req.ConfirmToken})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	err := h.systemService.SendSignal(req.PID, req.Signal, userID, clientIP, userAgent)
+	switch {
+	case errors.Is(err, service.ErrInvalidSignal):
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "发送信号失败",
+			Error:   err.Error(),
+		})
+		return
+	case errors.Is(err, service.ErrProtectedProcess):
+		c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Code:    http.StatusForbidden,
+			Message: "发送信号失败",
+			Error:   err.Error(),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "发送信号失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "信号已发送",
+	})
+}
+
 // GetHostInfo 获取主机信息
 // @Summary 获取主机信息
 // @Description 获取主机的详细信息，包括操作系统、内核版本等
@@ -218,22 +366,433 @@ func (h *SystemHandler) GetHostInfo(c *gin.Context) {
 	})
 }
 
+// GetDiskPartitions 获取各挂载点的磁盘使用情况
+// @Summary 获取磁盘分区使用情况
+// @Description 获取每个挂载点的设备、文件系统类型和容量使用情况；系统概览中的磁盘信息只包含根分区
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param all query bool false "是否包含tmpfs、proc等伪文件系统"
+// @Success 200 {object} model.APIResponse{data=[]model.DiskPartition}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/system/disks [get]
+func (h *SystemHandler) GetDiskPartitions(c *gin.Context) {
+	partitions, err := h.systemService.GetDiskPartitions(c.Query("all") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取磁盘分区失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取磁盘分区成功",
+		Data:    partitions,
+	})
+}
+
+// GetServerTime 获取服务器时间
+// @Summary 获取服务器时间
+// @Description 获取服务器当前时间、时区和服务运行时长，客户端可据此校正本地时钟偏差后判断令牌过期时间
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=model.ServerTime}
+// @Failure 401 {object} model.APIResponse
+// @Router /api/system/time [get]
+func (h *SystemHandler) GetServerTime(c *gin.Context) {
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取服务器时间成功",
+		Data:    h.systemService.GetServerTime(),
+	})
+}
+
+// GetJobs 获取后台任务状态
+// @Summary 获取后台任务状态
+// @Description 获取所有后台任务的最近运行时间、耗时、状态和连续失败次数
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.JobStatus}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/system/jobs [get]
+func (h *SystemHandler) GetJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取后台任务状态成功",
+		Data:    h.jobService.GetJobs(),
+	})
+}
+
+// GetMetricsHistory 获取系统指标历史
+// @Summary 获取系统指标历史
+// @Description 获取CPU、内存、磁盘使用率和负载的历史数据，按时间桶取平均值降采样，用于绘制趋势图
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "起始时间（RFC3339或YYYY-MM-DD），默认为 to 之前24小时"
+// @Param to query string false "结束时间（RFC3339或YYYY-MM-DD），默认为当前时间"
+// @Param resolution query string false "时间桶大小，如 5m、1h；默认自动选择，不小于采样间隔"
+// @Success 200 {object} model.APIResponse{data=model.MetricHistory}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/system/metrics/history [get]
+func (h *SystemHandler) GetMetricsHistory(c *gin.Context) {
+	var from, to time.Time
+	var resolution time.Duration
+	var err error
+
+	if value := c.Query("from"); value != "" {
+		if from, err = parseTimeParam(value); err != nil {
+			h.badMetricsQuery(c, err)
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = parseTimeParam(value); err != nil {
+			h.badMetricsQuery(c, err)
+			return
+		}
+	}
+	if value := c.Query("resolution"); value != "" {
+		if resolution, err = time.ParseDuration(value); err != nil || resolution <= 0 {
+			h.badMetricsQuery(c, fmt.Errorf("无效的分辨率: %s", value))
+			return
+		}
+	}
+
+	history, err := h.metricsService.History(from, to, resolution)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMetricsRange) {
+			h.badMetricsQuery(c, err)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取指标历史失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取指标历史成功",
+		Data:    history,
+	})
+}
+
+// badMetricsQuery 返回指标历史查询参数错误
+func (h *SystemHandler) badMetricsQuery(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, model.ErrorResponse{
+		Code:    http.StatusBadRequest,
+		Message: "查询参数错误",
+		Error:   err.Error(),
+	})
+}
+
+// ExportDiagnostics 导出系统诊断信息
+// @Summary 导出系统诊断信息
+// @Description 导出主机信息、系统状态、数据库连接池、脱敏后的配置、最近错误日志和版本信息，用于提交支持工单
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.DiagnosticsBundle
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/system/diagnostics [get]
+func (h *SystemHandler) ExportDiagnostics(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	bundle := h.diagnosticsService.CollectBundle(userID, clientIP, userAgent)
+
+	filename := fmt.Sprintf("diagnostics-%s.json", bundle.GeneratedAt.Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.IndentedJSON(http.StatusOK, bundle)
+}
+
+// GetLogSettings 获取日志设置
+// @Summary 获取日志设置
+// @Description 获取当前日志级别和日志轮转设置
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=logger.Settings}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/system/logs/settings [get]
+func (h *SystemHandler) GetLogSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取日志设置成功",
+		Data:    logger.CurrentSettings(),
+	})
+}
+
+// SetLogRetention 修改日志保留设置
+// @Summary 修改日志保留设置
+// @Description 运行时修改日志轮转的单文件大小、备份数和保留天数，省略的项保持不变，重启后恢复为配置文件中的设置
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.SetLogRetentionRequest true "日志保留设置"
+// @Success 200 {object} model.APIResponse{data=logger.Settings}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Router /api/system/logs/settings [put]
+func (h *SystemHandler) SetLogRetention(c *gin.Context) {
+	var req model.SetLogRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	settings, err := h.systemService.SetLogRetention(&req, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, logger.ErrInvalidRetention):
+			status = http.StatusBadRequest
+		case errors.Is(err, logger.ErrRotationUnavailable):
+			status = http.StatusConflict
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "修改日志保留设置失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "日志保留设置已修改",
+		Data:    settings,
+	})
+}
+
+// RotateLogs 手动轮转日志
+// @Summary 手动轮转日志
+// @Description 将当前日志文件改名为备份并打开新文件，便于收集日志提交工单
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=logger.Settings}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/system/logs/rotate [post]
+func (h *SystemHandler) RotateLogs(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if err := h.systemService.RotateLogs(userID, clientIP, userAgent); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, logger.ErrRotationUnavailable) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "轮转日志失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "日志轮转成功",
+		Data:    logger.CurrentSettings(),
+	})
+}
+
+// SetLogLevel 修改日志级别
+// @Summary 修改日志级别
+// @Description 运行时修改日志级别，重启后恢复为配置文件中的级别
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.SetLogLevelRequest true "日志级别"
+// @Success 200 {object} model.APIResponse{data=logger.Settings}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/system/logs/level [put]
+func (h *SystemHandler) SetLogLevel(c *gin.Context) {
+	var req model.SetLogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	if err := h.systemService.SetLogLevel(req.Level, userID, clientIP, userAgent); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "修改日志级别失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "日志级别已修改",
+		Data:    logger.CurrentSettings(),
+	})
+}
+
+// ListLogFiles 获取日志文件列表
+// @Summary 获取日志文件列表
+// @Description 列出面板日志文件及其轮转备份（名称、大小、修改时间），仅管理员可用
+// @Tags 系统监控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.LogFileInfo}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/system/logs [get]
+func (h *SystemHandler) ListLogFiles(c *gin.Context) {
+	files, err := h.systemService.ListLogFiles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取日志文件列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取日志文件列表成功",
+		Data:    files,
+	})
+}
+
+// DownloadLogFile 下载日志文件
+// @Summary 下载日志文件
+// @Description 下载日志目录中的面板日志文件，name 只能是文件名；仅管理员可用，下载会记录审计日志
+// @Tags 系统监控
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param name path string true "日志文件名"
+// @Success 200 {file} file
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/system/logs/{name}/download [get]
+func (h *SystemHandler) DownloadLogFile(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	name := c.Param("name")
+	path, err := h.systemService.PrepareLogDownload(name, userID, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidLogName):
+			status = http.StatusBadRequest
+		case errors.Is(err, service.ErrLogFileNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "下载日志文件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	clearWriteDeadline(c)
+	c.FileAttachment(path, name)
+}
+
 // RegisterSystemRoutes 注册系统相关路由
 func RegisterSystemRoutes(r *gin.RouterGroup, systemHandler *SystemHandler) {
 	system := r.Group("/system")
 	system.Use(middleware.AuthMiddleware(systemHandler.authService))
 	{
 		// 系统概览
-		system.GET("/overview", systemHandler.GetSystemOverview)
+		system.GET("/overview", middleware.RequirePermission(model.PermissionSystemView), systemHandler.GetSystemOverview)
 		
+		// 磁盘分区
+		system.GET("/disks", middleware.RequirePermission(model.PermissionSystemView), systemHandler.GetDiskPartitions)
+
 		// 网络统计
-		system.GET("/network", systemHandler.GetNetworkStats)
+		system.GET("/network", middleware.RequirePermission(model.PermissionSystemMonitor), systemHandler.GetNetworkStats)
 		
 		// 进程管理
-		system.GET("/processes", systemHandler.GetProcessList)
-		system.POST("/processes/kill", middleware.RequireRole(model.RoleAdmin), systemHandler.KillProcess)
+		system.GET("/processes", middleware.RequirePermission(model.PermissionSystemMonitor), systemHandler.GetProcessList)
+		system.POST("/processes/kill", middleware.RequirePermission(model.PermissionSystemConfig), systemHandler.KillProcess)
+		system.POST("/processes/signal", middleware.RequirePermission(model.PermissionSystemConfig), systemHandler.SignalProcess)
 		
 		// 主机信息
-		system.GET("/host", systemHandler.GetHostInfo)
+		system.GET("/host", middleware.RequirePermission(model.PermissionSystemView), systemHandler.GetHostInfo)
+
+		// 服务器时间（所有已登录用户可用，用于校正令牌过期时间）
+		system.GET("/time", systemHandler.GetServerTime)
+
+		// 后台任务
+		system.GET("/jobs", middleware.RequirePermission(model.PermissionSystemMonitor), systemHandler.GetJobs)
+
+		// 指标历史
+		system.GET("/metrics/history", middleware.RequirePermission(model.PermissionSystemMonitor), systemHandler.GetMetricsHistory)
+
+		// 诊断信息导出
+		system.GET("/diagnostics", middleware.RequirePermission(model.PermissionSystemConfig), systemHandler.ExportDiagnostics)
+
+		// 日志设置
+		system.GET("/logs/settings", middleware.RequirePermission(model.PermissionSystemConfig), systemHandler.GetLogSettings)
+		system.PUT("/logs/settings", middleware.RequirePermission(model.PermissionSystemConfig), systemHandler.SetLogRetention)
+		system.POST("/logs/rotate", middleware.RequirePermission(model.PermissionSystemConfig), systemHandler.RotateLogs)
+		system.PUT("/logs/level", middleware.RequirePermission(model.PermissionSystemConfig), systemHandler.SetLogLevel)
+		// 日志文件可能包含请求参数、IP等敏感信息，只允许管理员查看和下载
+		system.GET("/logs", middleware.RequireRole(model.RoleAdmin), systemHandler.ListLogFiles)
+		system.GET("/logs/:name/download", middleware.RequireRole(model.RoleAdmin), systemHandler.DownloadLogFile)
 	}
 }
\ No newline at end of file
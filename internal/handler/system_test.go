@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// newTestSystemRouter 创建只注册进程操作接口的路由
+func newTestSystemRouter(t *testing.T, cfg *config.Config) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t)
+	h := NewSystemHandler(service.NewSystemService(db, cfg), nil, nil, nil, nil)
+	router := gin.New()
+	router.POST("/processes/kill", h.KillProcess)
+	router.POST("/processes/signal", h.SignalProcess)
+	return router, db
+}
+
+// startTestProcess 启动一个长时间运行的子进程，返回进程和退出通知
+func startTestProcess(t *testing.T) (*exec.Cmd, <-chan struct{}) {
+	t.Helper()
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("当前平台没有 sleep 命令")
+	}
+	cmd := exec.Command(sleep, "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("启动子进程失败: %v", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		<-exited
+	})
+	return cmd, exited
+}
+
+// postJSON 发送JSON请求并返回响应
+func postJSON(router *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// exitedWithin 判断进程是否在 timeout 内退出
+func exitedWithin(exited <-chan struct{}, timeout time.Duration) bool {
+	select {
+	case <-exited:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestKillProcessRequiresConfirmation(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Security.ConfirmActions = []string{"kill_process"}
+	router, db := newTestSystemRouter(t, cfg)
+	cmd, exited := startTestProcess(t)
+	pid := int32(cmd.Process.Pid)
+
+	// 未携带确认令牌直接终止被拒绝
+	if w := postJSON(router, "/processes/kill", model.KillProcessRequest{PID: pid}); w.Code != http.StatusForbidden {
+		t.Fatalf("未确认的终止请求返回 %d，期望 403", w.Code)
+	}
+	// 伪造的令牌被拒绝
+	if w := postJSON(router, "/processes/kill", model.KillProcessRequest{PID: pid, ConfirmToken: "forged"}); w.Code != http.StatusForbidden {
+		t.Fatalf("无效令牌的终止请求返回 %d，期望 403", w.Code)
+	}
+	if exitedWithin(exited, 100*time.Millisecond) {
+		t.Fatal("未确认的请求终止了进程")
+	}
+
+	// 申请确认令牌后凭令牌终止
+	w := postJSON(router, "/processes/kill", model.KillProcessRequest{PID: pid, Confirm: true})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("申请确认返回 %d，期望 202", w.Code)
+	}
+	var resp struct {
+		Data model.ConfirmationResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data.ConfirmToken == "" {
+		t.Fatalf("确认响应中没有令牌: %s", w.Body.String())
+	}
+	if w := postJSON(router, "/processes/kill", model.KillProcessRequest{PID: pid, ConfirmToken: resp.Data.ConfirmToken}); w.Code != http.StatusOK {
+		t.Fatalf("凭令牌终止返回 %d，期望 200: %s", w.Code, w.Body.String())
+	}
+	if !exitedWithin(exited, 5*time.Second) {
+		t.Fatal("凭令牌终止后进程仍在运行")
+	}
+
+	// 令牌只能使用一次
+	if w := postJSON(router, "/processes/kill", model.KillProcessRequest{PID: pid, ConfirmToken: resp.Data.ConfirmToken}); w.Code != http.StatusForbidden {
+		t.Fatalf("重复使用令牌返回 %d，期望 403", w.Code)
+	}
+
+	var requested, confirmed int64
+	db.Model(&model.AuditLog{}).Where("action = ?", "kill_process_request").Count(&requested)
+	db.Model(&model.AuditLog{}).Where("action = ? AND status = ?", "kill_process_confirm", "success").Count(&confirmed)
+	if requested != 1 || confirmed != 1 {
+		t.Fatalf("审计日志 kill_process_request=%d kill_process_confirm=%d，期望各 1 条", requested, confirmed)
+	}
+}
+
+func TestKillProcessWithoutRequiredConfirmation(t *testing.T) {
+	// 默认配置不要求确认，已有客户端直接终止进程不受影响
+	router, _ := newTestSystemRouter(t, newTestConfig(t))
+	cmd, exited := startTestProcess(t)
+
+	if w := postJSON(router, "/processes/kill", model.KillProcessRequest{PID: int32(cmd.Process.Pid)}); w.Code != http.StatusOK {
+		t.Fatalf("未配置确认时终止返回 %d，期望 200: %s", w.Code, w.Body.String())
+	}
+	if !exitedWithin(exited, 5*time.Second) {
+		t.Fatal("终止后进程仍在运行")
+	}
+}
+
+func TestSignalProcessKillRequiresConfirmation(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Security.ConfirmActions = []string{"kill_process"}
+	router, db := newTestSystemRouter(t, cfg)
+	cmd, exited := startTestProcess(t)
+	pid := int32(cmd.Process.Pid)
+
+	// 通过发送信号接口发送 KILL 同样需要确认
+	for _, signal := range []string{"KILL", "sigkill"} {
+		if w := postJSON(router, "/processes/signal", model.SignalProcessRequest{PID: pid, Signal: signal}); w.Code != http.StatusForbidden {
+			t.Fatalf("未确认的 %s 信号返回 %d，期望 403", signal, w.Code)
+		}
+	}
+	if exitedWithin(exited, 100*time.Millisecond) {
+		t.Fatal("未确认的 KILL 信号终止了进程")
+	}
+
+	w := postJSON(router, "/processes/signal", model.SignalProcessRequest{PID: pid, Signal: "KILL", Confirm: true})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("申请确认返回 %d，期望 202", w.Code)
+	}
+	var resp struct {
+		Data model.ConfirmationResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data.ConfirmToken == "" {
+		t.Fatalf("确认响应中没有令牌: %s", w.Body.String())
+	}
+	if w := postJSON(router, "/processes/signal", model.SignalProcessRequest{PID: pid, Signal: "KILL", ConfirmToken: resp.Data.ConfirmToken}); w.Code != http.StatusOK {
+		t.Fatalf("凭令牌发送 KILL 返回 %d，期望 200: %s", w.Code, w.Body.String())
+	}
+	if !exitedWithin(exited, 5*time.Second) {
+		t.Fatal("凭令牌发送 KILL 后进程仍在运行")
+	}
+
+	// KILL 的审计日志记为 kill_process，与 kill_process_request/kill_process_confirm 对应
+	var killed, signaled int64
+	db.Model(&model.AuditLog{}).Where("action = ? AND status = ?", "kill_process", "success").Count(&killed)
+	db.Model(&model.AuditLog{}).Where("action = ?", "signal_process").Count(&signaled)
+	if killed != 1 || signaled != 0 {
+		t.Fatalf("审计日志 kill_process=%d signal_process=%d，期望 1 和 0", killed, signaled)
+	}
+}
+
+func TestSignalProcessTermSkipsConfirmation(t *testing.T) {
+	router, db := newTestSystemRouter(t, newTestConfig(t))
+	cmd, exited := startTestProcess(t)
+
+	if w := postJSON(router, "/processes/signal", model.SignalProcessRequest{PID: int32(cmd.Process.Pid), Signal: "TERM"}); w.Code != http.StatusOK {
+		t.Fatalf("发送 TERM 返回 %d，期望 200: %s", w.Code, w.Body.String())
+	}
+	if !exitedWithin(exited, 5*time.Second) {
+		t.Fatal("发送 TERM 后进程仍在运行")
+	}
+
+	var signaled int64
+	db.Model(&model.AuditLog{}).Where("action = ? AND status = ?", "signal_process", "success").Count(&signaled)
+	if signaled != 1 {
+		t.Fatalf("signal_process 审计日志 %d 条，期望 1 条", signaled)
+	}
+}
+
+// newTestLogRouter 创建注册全部系统路由（含认证和权限检查）的路由，日志目录指向临时目录
+func newTestLogRouter(t *testing.T) (*gin.Engine, *gorm.DB, *service.AuthService, string) {
+	t.Helper()
+	db := newTestDB(t)
+	cfg := newTestConfig(t)
+	cfg.System.LogDir = t.TempDir()
+	auth := service.NewAuthService(db, cfg, service.NewRBACCache(db))
+	router := gin.New()
+	RegisterSystemRoutes(router.Group("/api"), NewSystemHandler(service.NewSystemService(db, cfg), auth, nil, nil, nil))
+	return router, db, auth, cfg.System.LogDir
+}
+
+func TestLogFilesRequireAdmin(t *testing.T) {
+	router, db, auth, logDir := newTestLogRouter(t)
+	createUserWithPermissions(t, db, "operator", model.PermissionSystemConfig)
+	for name, content := range map[string]string{
+		"app.log":                         "current",
+		"app-2026-01-02T03-04-05.000.log": "backup",
+		"other.txt":                       "secret",
+	} {
+		if err := os.WriteFile(filepath.Join(logDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 拥有系统配置权限的非管理员不能查看日志
+	operator := loginAs(t, auth, "operator")
+	for _, path := range []string{"/api/system/logs", "/api/system/logs/app.log/download"} {
+		if w := authRequest(router, http.MethodGet, path, operator, ""); w.Code != http.StatusForbidden {
+			t.Fatalf("非管理员 GET %s 返回 %d，期望 403", path, w.Code)
+		}
+	}
+
+	login, err := auth.Login(&model.LoginRequest{Username: "admin", Password: "Admin@12345"}, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := login.Token
+
+	w := authRequest(router, http.MethodGet, "/api/system/logs", admin, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("管理员列出日志返回 %d: %s", w.Code, w.Body.String())
+	}
+	var list struct {
+		Data []model.LogFileInfo `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 2 {
+		t.Fatalf("日志列表应只包含面板日志: %+v", list.Data)
+	}
+
+	w = authRequest(router, http.MethodGet, "/api/system/logs/app.log/download", admin, "")
+	if w.Code != http.StatusOK || w.Body.String() != "current" {
+		t.Fatalf("下载日志返回 %d %q", w.Code, w.Body.String())
+	}
+
+	// 非日志文件名和路径穿越都被拒绝
+	for _, name := range []string{"other.txt", "..", "..%5Capp.log", "app.log.bak"} {
+		w := authRequest(router, http.MethodGet, "/api/system/logs/"+name+"/download", admin, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("下载 %q 返回 %d，期望 400", name, w.Code)
+		}
+	}
+	if w := authRequest(router, http.MethodGet, "/api/system/logs/app-2026-09-09T00-00-00.000.log/download", admin, ""); w.Code != http.StatusNotFound {
+		t.Errorf("下载不存在的日志返回 %d，期望 404", w.Code)
+	}
+}
+
+func TestGetServerTimeWithinTolerance(t *testing.T) {
+	cfg := newTestConfig(t)
+	h := NewSystemHandler(service.NewSystemService(newTestDB(t), cfg), nil, nil, nil, nil)
+	router := gin.New()
+	router.GET("/time", h.GetServerTime)
+
+	before := time.Now()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/time", nil))
+	after := time.Now()
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d", w.Code)
+	}
+
+	var resp struct {
+		Data struct {
+			Time      string `json:"time"`
+			UnixMilli int64  `json:"unix_ms"`
+			Timezone  string `json:"timezone"`
+			UTCOffset int    `json:"utc_offset"`
+			Uptime    int64  `json:"uptime"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	got, err := time.Parse(time.RFC3339Nano, resp.Data.Time)
+	if err != nil {
+		t.Fatalf("时间不是RFC3339格式: %q", resp.Data.Time)
+	}
+	const tolerance = time.Second
+	if got.Before(before.Add(-tolerance)) || got.After(after.Add(tolerance)) {
+		t.Fatalf("服务器时间 %v 不在 [%v, %v] 范围内", got, before, after)
+	}
+	if diff := got.UnixMilli() - resp.Data.UnixMilli; diff < -1 || diff > 1 {
+		t.Fatalf("unix_ms 与时间相差 %dms", diff)
+	}
+	if _, offset := got.Zone(); offset != resp.Data.UTCOffset {
+		t.Fatalf("utc_offset = %d, 时间中的偏移为 %d", resp.Data.UTCOffset, offset)
+	}
+	if resp.Data.Timezone == "" || resp.Data.Uptime < 0 {
+		t.Fatalf("时区或运行时长不正确: %+v", resp.Data)
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"web-panel-go/internal/middleware"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTrash 获取回收站列表
+// @Summary 获取回收站列表
+// @Description 列出回收站中的条目及其原路径，按删除时间倒序，非管理员只能看到自己删除的条目
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.TrashItem}
+// @Failure 401 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/trash [get]
+func (h *FileHandler) ListTrash(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	isAdmin := false
+	if user, exists := middleware.GetCurrentUser(c); exists {
+		isAdmin = user.IsAdmin()
+	}
+
+	items, err := h.fileService.ListTrash(userID, isAdmin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取回收站失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取回收站成功",
+		Data:    items,
+	})
+}
+
+// RestoreTrash 从回收站恢复
+// @Summary 从回收站恢复
+// @Description 将回收站条目移回原路径，原路径已存在时返回409，非管理员只能恢复自己删除的条目
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.RestoreTrashRequest true "恢复请求"
+// @Success 200 {object} model.APIResponse{data=model.TrashItem}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/files/trash/restore [post]
+func (h *FileHandler) RestoreTrash(c *gin.Context) {
+	var req model.RestoreTrashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数无效",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// 获取用户信息
+	userID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+	isAdmin := false
+	if user, exists := middleware.GetCurrentUser(c); exists {
+		isAdmin = user.IsAdmin()
+	}
+
+	item, err := h.fileService.RestoreTrash(req.ID, userID, isAdmin, clientIP, userAgent)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrTrashNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrRestoreTargetExist):
+			status = http.StatusConflict
+		case errors.Is(err, service.ErrInvalidPath):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, model.ErrorResponse{
+			Code:    status,
+			Message: "恢复失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "恢复成功",
+		Data:    item,
+	})
+}
+
+// requirePermanentDelete 启用回收站时只有管理员可以跳过回收站永久删除
+func (h *FileHandler) requirePermanentDelete(c *gin.Context, permanent bool) bool {
+	if !permanent || !h.fileService.TrashEnabled() {
+		return true
+	}
+	if user, exists := middleware.GetCurrentUser(c); exists && user.IsAdmin() {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, model.ErrorResponse{
+		Code:    http.StatusForbidden,
+		Message: "只有管理员可以永久删除",
+	})
+	return false
+}
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"web-panel-go/internal/database"
 	"web-panel-go/internal/middleware"
 	"web-panel-go/internal/model"
 	"web-panel-go/internal/service"
@@ -34,7 +37,9 @@ func NewUserHandler(userService *service.UserService, authService *service.AuthS
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
-// @Param search query string false "搜索关键词"
+// @Param search query string false "搜索关键词（匹配用户名、邮箱、昵称、手机号，不区分大小写）"
+// @Param username query string false "用户名（精确匹配）"
+// @Param email query string false "邮箱（精确匹配，不区分大小写）"
 // @Success 200 {object} model.APIResponse{data=model.PaginatedResponse}
 // @Failure 401 {object} model.APIResponse
 // @Failure 403 {object} model.APIResponse
@@ -44,7 +49,11 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 	// 获取分页参数
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	search := c.Query("search")
+	filter := model.UserFilter{
+		Search:   strings.TrimSpace(c.Query("search")),
+		Username: c.Query("username"),
+		Email:    c.Query("email"),
+	}
 
 	// 参数验证
 	if page < 1 {
@@ -54,7 +63,7 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 		pageSize = 20
 	}
 
-	users, total, err := h.userService.GetUsers(page, pageSize, search)
+	users, total, err := h.userService.GetUsers(page, pageSize, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
 			Code:    http.StatusInternalServerError,
@@ -147,15 +156,6 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	// 参数验证
-	if req.Username == "" || req.Password == "" || req.Email == "" {
-		c.JSON(http.StatusBadRequest, model.APIResponse{
-			Code:    http.StatusBadRequest,
-			Message: "用户名、密码和邮箱不能为空",
-		})
-		return
-	}
-
 	// 获取操作用户信息
 	operatorID, _ := middleware.GetCurrentUserID(c)
 	clientIP := c.ClientIP()
@@ -165,8 +165,14 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	user, err := h.userService.CreateUser(&req, operatorID, clientIP, userAgent)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "用户名已存在" || err.Error() == "邮箱已存在" {
+		if errors.Is(err, service.ErrUsernameExists) || errors.Is(err, service.ErrEmailExists) {
 			statusCode = http.StatusConflict
+		} else if errors.Is(err, service.ErrTooManyRoles) || service.IsCredentialValidationError(err) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrAdminRequired) {
+			statusCode = http.StatusForbidden
+		} else if errors.Is(err, database.ErrDatabaseBusy) {
+			statusCode = http.StatusServiceUnavailable
 		}
 		c.JSON(statusCode, model.ErrorResponse{
 			Code:    statusCode,
@@ -197,6 +203,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 // @Failure 401 {object} model.APIResponse
 // @Failure 403 {object} model.APIResponse
 // @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse "用户名或邮箱已存在，或不能移除最后一个管理员"
 // @Failure 500 {object} model.APIResponse
 // @Router /api/users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
@@ -229,10 +236,18 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	user, err := h.userService.UpdateUser(uint(id), &req, operatorID, clientIP, userAgent)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "用户不存在" {
+		if errors.Is(err, service.ErrUserNotFound) {
 			statusCode = http.StatusNotFound
-		} else if err.Error() == "邮箱已存在" {
+		} else if errors.Is(err, service.ErrUsernameExists) || errors.Is(err, service.ErrEmailExists) {
+			statusCode = http.StatusConflict
+		} else if errors.Is(err, service.ErrTooManyRoles) || service.IsCredentialValidationError(err) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrLastAdmin) {
 			statusCode = http.StatusConflict
+		} else if errors.Is(err, service.ErrAdminRequired) {
+			statusCode = http.StatusForbidden
+		} else if errors.Is(err, database.ErrDatabaseBusy) {
+			statusCode = http.StatusServiceUnavailable
 		}
 		c.JSON(statusCode, model.ErrorResponse{
 			Code:    statusCode,
@@ -262,6 +277,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 // @Failure 401 {object} model.APIResponse
 // @Failure 403 {object} model.APIResponse
 // @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse "不能移除最后一个管理员"
 // @Failure 500 {object} model.APIResponse
 // @Router /api/users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
@@ -292,8 +308,14 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	// 删除用户
 	if err := h.userService.DeleteUser(uint(id), operatorID, clientIP, userAgent); err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "用户不存在" {
+		if errors.Is(err, service.ErrUserNotFound) {
 			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrLastAdmin) {
+			statusCode = http.StatusConflict
+		} else if errors.Is(err, service.ErrAdminRequired) {
+			statusCode = http.StatusForbidden
+		} else if errors.Is(err, database.ErrDatabaseBusy) {
+			statusCode = http.StatusServiceUnavailable
 		}
 		c.JSON(statusCode, model.ErrorResponse{
 			Code:    statusCode,
@@ -323,6 +345,7 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 // @Failure 401 {object} model.APIResponse
 // @Failure 403 {object} model.APIResponse
 // @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse "不能移除最后一个管理员"
 // @Failure 500 {object} model.APIResponse
 // @Router /api/users/{id}/status [put]
 func (h *UserHandler) ChangeUserStatus(c *gin.Context) {
@@ -364,8 +387,12 @@ func (h *UserHandler) ChangeUserStatus(c *gin.Context) {
 	_, err = h.userService.ChangeUserStatus(uint(id), req.Status, operatorID, clientIP, userAgent)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "用户不存在" {
+		if errors.Is(err, service.ErrUserNotFound) {
 			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrLastAdmin) {
+			statusCode = http.StatusConflict
+		} else if errors.Is(err, service.ErrAdminRequired) {
+			statusCode = http.StatusForbidden
 		}
 		c.JSON(statusCode, model.ErrorResponse{
 			Code:    statusCode,
@@ -423,11 +450,57 @@ func (h *UserHandler) ResetUserPassword(c *gin.Context) {
 		return
 	}
 
-	// 参数验证
-	if req.NewPassword == "" {
+	// 获取操作用户信息
+	operatorID, _ := middleware.GetCurrentUserID(c)
+	clientIP := c.ClientIP()
+	userAgent := c.GetHeader("User-Agent")
+
+	// 重置密码
+	if err := h.userService.ResetUserPassword(uint(id), req.NewPassword, operatorID, clientIP, userAgent); err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, service.ErrUserNotFound) {
+			statusCode = http.StatusNotFound
+		} else if service.IsCredentialValidationError(err) {
+			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, service.ErrAdminRequired) {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, model.ErrorResponse{
+			Code:    statusCode,
+			Message: "重置密码失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "密码重置成功",
+	})
+}
+
+// UnlockUser 解锁用户
+// @Summary 解锁用户
+// @Description 管理员清除用户的登录失败计数和锁定状态
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} model.APIResponse{data=model.UserResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, model.APIResponse{
 			Code:    http.StatusBadRequest,
-			Message: "新密码不能为空",
+			Message: "无效的用户ID",
 		})
 		return
 	}
@@ -437,15 +510,18 @@ func (h *UserHandler) ResetUserPassword(c *gin.Context) {
 	clientIP := c.ClientIP()
 	userAgent := c.GetHeader("User-Agent")
 
-	// 重置密码
-	if err := h.userService.ResetUserPassword(uint(id), req.NewPassword, operatorID, clientIP, userAgent); err != nil {
+	// 解锁用户
+	user, err := h.userService.UnlockUser(uint(id), operatorID, clientIP, userAgent)
+	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "用户不存在" {
+		if errors.Is(err, service.ErrUserNotFound) {
 			statusCode = http.StatusNotFound
+		} else if errors.Is(err, service.ErrAdminRequired) {
+			statusCode = http.StatusForbidden
 		}
 		c.JSON(statusCode, model.ErrorResponse{
 			Code:    statusCode,
-			Message: "重置密码失败",
+			Message: "解锁用户失败",
 			Error:   err.Error(),
 		})
 		return
@@ -453,24 +529,222 @@ func (h *UserHandler) ResetUserPassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, model.APIResponse{
 		Code:    http.StatusOK,
-		Message: "密码重置成功",
+		Message: "用户解锁成功",
+		Data:    user,
+	})
+}
+
+// ResetUserTwoFactor 重置用户两步验证
+// @Summary 重置用户两步验证
+// @Description 管理员清除用户的两步验证密钥和恢复码，用户可仅凭密码登录后重新设置
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} model.APIResponse
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/users/{id}/reset-2fa [post]
+func (h *UserHandler) ResetUserTwoFactor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的用户ID",
+		})
+		return
+	}
+
+	operatorID, _ := middleware.GetCurrentUserID(c)
+	if err := h.authService.ResetTwoFactor(uint(id), operatorID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		statusCode := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			statusCode = http.StatusNotFound
+		case errors.Is(err, service.ErrTwoFactorDisabled):
+			statusCode = http.StatusConflict
+		}
+		c.JSON(statusCode, model.ErrorResponse{
+			Code:    statusCode,
+			Message: "重置两步验证失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "两步验证已重置",
+	})
+}
+
+// GetPendingUsers 获取待审核用户列表
+// @Summary 获取待审核用户列表
+// @Description 管理员查看等待审核的账户，按创建时间先后排序
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.APIResponse{data=[]model.User}
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/users/pending [get]
+func (h *UserHandler) GetPendingUsers(c *gin.Context) {
+	users, err := h.userService.GetPendingUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取待审核用户失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取待审核用户成功",
+		Data:    users,
 	})
 }
 
+// ApproveUser 批准用户
+// @Summary 批准用户
+// @Description 管理员批准待审核账户，账户随即启用
+// @Tags 用户管理
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Success 200 {object} model.APIResponse{data=model.User}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/users/{id}/approve [post]
+func (h *UserHandler) ApproveUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的用户ID",
+		})
+		return
+	}
+
+	operatorID, _ := middleware.GetCurrentUserID(c)
+	user, err := h.userService.ApproveUser(uint(id), operatorID, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		statusCode := approvalErrorStatus(err)
+		c.JSON(statusCode, model.ErrorResponse{
+			Code:    statusCode,
+			Message: "批准用户失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "用户已批准",
+		Data:    user,
+	})
+}
+
+// RejectUser 拒绝用户
+// @Summary 拒绝用户
+// @Description 管理员拒绝待审核账户，账户转为禁用状态
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "用户ID"
+// @Param request body model.RejectUserRequest false "拒绝原因"
+// @Success 200 {object} model.APIResponse{data=model.User}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Failure 404 {object} model.APIResponse
+// @Failure 409 {object} model.APIResponse
+// @Failure 500 {object} model.APIResponse
+// @Router /api/users/{id}/reject [post]
+func (h *UserHandler) RejectUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的用户ID",
+		})
+		return
+	}
+
+	var req model.RejectUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "请求参数错误",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	operatorID, _ := middleware.GetCurrentUserID(c)
+	user, err := h.userService.RejectUser(uint(id), req.Reason, operatorID, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		statusCode := approvalErrorStatus(err)
+		c.JSON(statusCode, model.ErrorResponse{
+			Code:    statusCode,
+			Message: "拒绝用户失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "用户已拒绝",
+		Data:    user,
+	})
+}
+
+// approvalErrorStatus 将账户审核错误映射为HTTP状态码
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUserNotPending):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // RegisterUserRoutes 注册用户相关路由
 func RegisterUserRoutes(r *gin.RouterGroup, userHandler *UserHandler) {
 	users := r.Group("/users")
 	users.Use(middleware.AuthMiddleware(userHandler.authService))
 	{
-		// 用户列表和详情（所有认证用户都可以查看）
-		users.GET("", userHandler.GetUsers)
-		users.GET("/:id", userHandler.GetUser)
+		// 用户列表和详情
+		users.GET("", middleware.RequirePermission(model.PermissionUserView), userHandler.GetUsers)
+		users.GET("/pending", middleware.RequirePermission(model.PermissionUserView), userHandler.GetPendingUsers)
+		users.GET("/:id", middleware.RequirePermission(model.PermissionUserView), userHandler.GetUser)
 		
-		// 用户管理操作（仅管理员）
-		users.POST("", middleware.RequireRole(model.RoleAdmin), userHandler.CreateUser)
-		users.PUT("/:id", middleware.RequireRole(model.RoleAdmin), userHandler.UpdateUser)
-		users.DELETE("/:id", middleware.RequireRole(model.RoleAdmin), userHandler.DeleteUser)
-		users.PUT("/:id/status", middleware.RequireRole(model.RoleAdmin), userHandler.ChangeUserStatus)
-		users.PUT("/:id/reset-password", middleware.RequireRole(model.RoleAdmin), userHandler.ResetUserPassword)
+		// 用户管理操作
+		users.POST("", middleware.RequirePermission(model.PermissionUserCreate), userHandler.CreateUser)
+		users.PUT("/:id", middleware.RequirePermission(model.PermissionUserUpdate), userHandler.UpdateUser)
+		users.DELETE("/:id", middleware.RequirePermission(model.PermissionUserDelete), userHandler.DeleteUser)
+		users.PUT("/:id/status", middleware.RequirePermission(model.PermissionUserUpdate), userHandler.ChangeUserStatus)
+		users.PUT("/:id/reset-password", middleware.RequirePermission(model.PermissionUserUpdate), userHandler.ResetUserPassword)
+		users.POST("/:id/unlock", middleware.RequirePermission(model.PermissionUserUpdate), userHandler.UnlockUser)
+		users.POST("/:id/reset-2fa", middleware.RequirePermission(model.PermissionUserUpdate), userHandler.ResetUserTwoFactor)
+		users.POST("/:id/approve", middleware.RequirePermission(model.PermissionUserUpdate), userHandler.ApproveUser)
+		users.POST("/:id/reject", middleware.RequirePermission(model.PermissionUserUpdate), userHandler.RejectUser)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"web-panel-go/internal/middleware"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+	"web-panel-go/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebSocketHandler WebSocket连接管理处理器
+type WebSocketHandler struct {
+	wsManager      *websocket.WebSocketManager
+	requestTracker *middleware.RequestTracker
+	authService    *service.AuthService
+}
+
+// NewWebSocketHandler 创建WebSocket连接管理处理器实例
+func NewWebSocketHandler(wsManager *websocket.WebSocketManager, requestTracker *middleware.RequestTracker, authService *service.AuthService) *WebSocketHandler {
+	return &WebSocketHandler{
+		wsManager:      wsManager,
+		requestTracker: requestTracker,
+		authService:    authService,
+	}
+}
+
+// GetUserConnections 获取用户的WebSocket连接和最近请求
+// @Summary 获取用户的WebSocket连接和最近请求
+// @Description 返回指定用户当前的WebSocket连接（连接ID、连接时间、接收的消息类型）及最近的HTTP请求ID，用于关联追踪用户活动
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param userId path int true "用户ID"
+// @Success 200 {object} model.APIResponse{data=model.UserConnectionsResponse}
+// @Failure 400 {object} model.APIResponse
+// @Failure 401 {object} model.APIResponse
+// @Failure 403 {object} model.APIResponse
+// @Router /api/system/ws/clients/{userId} [get]
+func (h *WebSocketHandler) GetUserConnections(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的用户ID",
+		})
+		return
+	}
+
+	response := model.UserConnectionsResponse{
+		UserID:         uint(userID),
+		Connections:    h.wsManager.GetUserConnections(uint(userID)),
+		RecentRequests: h.requestTracker.GetUserRequests(uint(userID)),
+	}
+
+	c.JSON(http.StatusOK, model.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取连接信息成功",
+		Data:    response,
+	})
+}
+
+// RegisterWebSocketRoutes 注册WebSocket连接管理路由
+func RegisterWebSocketRoutes(r *gin.RouterGroup, wsHandler *WebSocketHandler) {
+	ws := r.Group("/system/ws")
+	ws.Use(middleware.AuthMiddleware(wsHandler.authService))
+	ws.Use(middleware.RequireRole(model.RoleAdmin))
+	{
+		ws.GET("/clients/:userId", wsHandler.GetUserConnections)
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/middleware"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+	"web-panel-go/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+	gorillaws "github.com/gorilla/websocket"
+)
+
+func TestGetUserConnectionsReturnsConnectionMetadata(t *testing.T) {
+	db := newTestDB(t)
+	auth := service.NewAuthService(db, newTestConfig(t), service.NewRBACCache(db))
+	manager := websocket.NewWebSocketManager(config.WebSocketConfig{BroadcastBuffer: 16})
+	go manager.Run()
+	tracker := middleware.NewRequestTracker()
+
+	router := gin.New()
+	router.Use(middleware.RequestIDMiddleware(), tracker.Middleware())
+	router.GET("/ws", middleware.WebSocketAuthMiddleware(auth), manager.HandleWebSocket)
+	RegisterWebSocketRoutes(router.Group("/api"), NewWebSocketHandler(manager, tracker, auth))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	createUserWithPermissions(t, db, "alice", model.PermissionFileView)
+	aliceToken := loginAs(t, auth, "alice")
+	login, err := auth.Login(&model.LoginRequest{Username: "admin", Password: "Admin@12345"}, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var alice model.User
+	db.Where("username = ?", "alice").First(&alice)
+	path := "/api/system/ws/clients/" + strconv.FormatUint(uint64(alice.ID), 10)
+
+	// 普通用户无权查看，该请求同时被记录为 alice 的最近请求
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+aliceToken)
+	req.Header.Set("X-Request-ID", "alice-http-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("普通用户查看连接返回 %d，期望 403", w.Code)
+	}
+
+	header := http.Header{}
+	header.Set("X-Request-ID", "alice-ws-1")
+	header.Set("User-Agent", "test-agent")
+	conn, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws?token="+aliceToken, header)
+	if err != nil {
+		t.Fatalf("建立WebSocket连接失败: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(map[string]interface{}{
+		"type": websocket.MessageTypeSubscribe,
+		"data": map[string]interface{}{"topics": []string{websocket.MessageTypeUserJoined}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp struct {
+		Data model.UserConnectionsResponse `json:"data"`
+	}
+	subscribed := func() bool {
+		w := authRequest(router, http.MethodGet, path, login.Token, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("查看用户连接返回 %d: %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Data.Connections) != 1 {
+			return false
+		}
+		for _, topic := range resp.Data.Connections[0].Topics {
+			if topic == websocket.MessageTypeUserJoined {
+				return true
+			}
+		}
+		return false
+	}
+	for deadline := time.Now().Add(2 * time.Second); !subscribed(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("未返回已订阅主题的连接: %+v", resp.Data)
+		}
+	}
+
+	connection := resp.Data.Connections[0]
+	if connection.ID == "" || connection.UserID != alice.ID || connection.Username != "alice" {
+		t.Fatalf("连接信息 = %+v", connection)
+	}
+	if connection.RequestID != "alice-ws-1" || connection.UserAgent != "test-agent" {
+		t.Fatalf("连接未关联握手请求: %+v", connection)
+	}
+	if time.Since(connection.ConnectedAt) > time.Minute {
+		t.Fatalf("连接时间 = %v", connection.ConnectedAt)
+	}
+
+	var tracked bool
+	for _, record := range resp.Data.RecentRequests {
+		if record.RequestID == "alice-http-1" && record.Path == path && record.Status == http.StatusForbidden {
+			tracked = true
+		}
+	}
+	if !tracked {
+		t.Fatalf("最近请求中缺少 alice-http-1: %+v", resp.Data.RecentRequests)
+	}
+
+	// 其他用户没有连接
+	w = authRequest(router, http.MethodGet, "/api/system/ws/clients/99999", login.Token, "")
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Data.Connections) != 0 {
+		t.Fatalf("不存在的用户返回 %s", w.Body.String())
+	}
+}
@@ -4,22 +4,46 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
 )
 
+// redactedValue 替换敏感值后的占位符
+const redactedValue = "'[REDACTED]'"
+
+// sensitiveColumnPattern 与敏感列比较或赋值的字符串字面量，如 password = '...'、`token` = '...'
+var sensitiveColumnPattern = regexp.MustCompile("(?i)([`\"]?\\w*(?:password|token|secret|recovery_codes)\\w*[`\"]?\\s*(?:=|<>|!=)\\s*)'(?:[^']|'')*'")
+
+// sensitiveValuePatterns 无论出现在哪一列都视为敏感的字符串字面量（INSERT 的 VALUES 中无法按列名判断）
+var sensitiveValuePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`'[^']*\$2[aby]?\$\d{2}\$[./A-Za-z0-9]{53}[^']*'`),     // bcrypt哈希（包括JSON数组中的恢复码哈希）
+	regexp.MustCompile(`'eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*'`), // JWT
+	regexp.MustCompile(`'[0-9a-fA-F]{64}'`),                                   // SHA-256（刷新令牌哈希）
+}
+
+// redactSQL 把SQL中的密码哈希、令牌等敏感字面量替换为占位符，SQL结构和其他参数保持不变
+func redactSQL(sql string) string {
+	sql = sensitiveColumnPattern.ReplaceAllString(sql, "${1}"+redactedValue)
+	for _, pattern := range sensitiveValuePatterns {
+		sql = pattern.ReplaceAllString(sql, redactedValue)
+	}
+	return sql
+}
+
 // GormLogger GORM日志适配器
 type GormLogger struct {
 	SlowThreshold time.Duration
 	LogLevel      gormLogger.LogLevel
 }
 
-// NewGormLogger 创建GORM日志适配器
-func NewGormLogger() gormLogger.Interface {
+// NewGormLogger 创建GORM日志适配器，slowThreshold 为0时不记录慢SQL
+func NewGormLogger(slowThreshold time.Duration) gormLogger.Interface {
 	return &GormLogger{
-		SlowThreshold: 200 * time.Millisecond,
+		SlowThreshold: slowThreshold,
 		LogLevel:      gormLogger.Info,
 	}
 }
@@ -52,31 +76,37 @@ func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{})
 	}
 }
 
-// Trace SQL执行日志
+// Trace SQL执行日志，记录的SQL已脱敏，source 为发起查询的业务代码位置
 func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
 	if l.LogLevel <= gormLogger.Silent {
 		return
 	}
 
 	elapsed := time.Since(begin)
-	sql, rows := fc()
 
 	switch {
 	case err != nil && l.LogLevel >= gormLogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
 		Error("SQL执行错误",
 			"error", err,
 			"elapsed", elapsed,
 			"rows", rows,
-			"sql", sql)
+			"sql", redactSQL(sql),
+			"source", utils.FileWithLineNum())
 	case elapsed > l.SlowThreshold && l.SlowThreshold != 0 && l.LogLevel >= gormLogger.Warn:
+		sql, rows := fc()
 		Warn("慢SQL查询",
 			"elapsed", elapsed,
+			"threshold", l.SlowThreshold,
 			"rows", rows,
-			"sql", sql)
+			"sql", redactSQL(sql),
+			"source", utils.FileWithLineNum())
 	case l.LogLevel == gormLogger.Info:
+		sql, rows := fc()
 		Info("SQL执行",
 			"elapsed", elapsed,
 			"rows", rows,
-			"sql", sql)
+			"sql", redactSQL(sql),
+			"source", utils.FileWithLineNum())
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gormLogger "gorm.io/gorm/logger"
+)
+
+const (
+	testPasswordHash = "$2a$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW"
+	testTokenHash    = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+)
+
+func TestRedactSQL(t *testing.T) {
+	tests := []struct {
+		sql    string
+		secret string
+		keep   string
+	}{
+		{"UPDATE `users` SET `password`='" + testPasswordHash + "',`updated_at`='2026-01-01' WHERE `id` = 1", testPasswordHash, "`updated_at`='2026-01-01'"},
+		{"SELECT * FROM sessions WHERE refresh_token_hash = '" + testTokenHash + "' LIMIT 1", testTokenHash, "LIMIT 1"},
+		{"INSERT INTO `users` (`username`,`password`) VALUES ('alice','" + testPasswordHash + "')", testPasswordHash, "'alice'"},
+		{"UPDATE users SET totp_secret = 'JBSWY3DPEHPK3PXP' WHERE id = 2", "JBSWY3DPEHPK3PXP", "WHERE id = 2"},
+	}
+	for _, tt := range tests {
+		got := redactSQL(tt.sql)
+		if strings.Contains(got, tt.secret) {
+			t.Errorf("脱敏后仍包含敏感值: %s", got)
+		}
+		if !strings.Contains(got, tt.keep) || !strings.Contains(got, redactedValue) {
+			t.Errorf("脱敏改变了其他内容: %s", got)
+		}
+	}
+}
+
+func TestGormLoggerLogsSlowQueryRedacted(t *testing.T) {
+	dir := initFileLogger(t)
+	l := NewGormLogger(50 * time.Millisecond).LogMode(gormLogger.Warn)
+	sql := "UPDATE `users` SET `password`='" + testPasswordHash + "' WHERE `id` = 1"
+	fc := func() (string, int64) { return sql, 1 }
+
+	// 未超过阈值的查询不记录
+	l.Trace(context.Background(), time.Now(), fc, nil)
+	// 慢查询记录耗时、脱敏后的SQL和调用位置
+	l.Trace(context.Background(), time.Now().Add(-100*time.Millisecond), fc, nil)
+	// 执行错误同样脱敏
+	l.Trace(context.Background(), time.Now(), fc, errors.New("database is locked"))
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := string(data)
+	if strings.Contains(output, testPasswordHash) {
+		t.Fatalf("日志中包含密码哈希: %s", output)
+	}
+	if n := strings.Count(output, "慢SQL查询"); n != 1 {
+		t.Fatalf("慢SQL日志 %d 条，期望 1 条: %s", n, output)
+	}
+	for _, want := range []string{"SQL执行错误", "WHERE `id` = 1", "gorm_test.go"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("日志中缺少 %q: %s", want, output)
+		}
+	}
+}
@@ -23,6 +23,7 @@ func Init(cfg *config.LogConfig, systemCfg *config.SystemConfig) error {
 		level = logrus.InfoLevel
 	}
 	Logger.SetLevel(level)
+	Logger.AddHook(recentErrors)
 
 	// 设置日志格式
 	if cfg.Format == "json" {
@@ -57,8 +58,10 @@ func Init(cfg *config.LogConfig, systemCfg *config.SystemConfig) error {
 		}
 
 		Logger.SetOutput(lumberjackLogger)
+		setRotator(cfg.Output, lumberjackLogger)
 	} else {
 		Logger.SetOutput(os.Stdout)
+		setRotator(cfg.Output, nil)
 	}
 
 	return nil
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxRecentErrors 内存中保留的最近错误日志条数
+const maxRecentErrors = 200
+
+// recentErrorHook 把错误级别及以上的日志保存在环形缓冲区中，用于诊断导出
+type recentErrorHook struct {
+	mutex   sync.Mutex
+	entries []string
+	next    int
+	full    bool
+}
+
+var recentErrors = &recentErrorHook{entries: make([]string, maxRecentErrors)}
+
+// Levels 实现 logrus.Hook
+func (h *recentErrorHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+// Fire 实现 logrus.Hook
+func (h *recentErrorHook) Fire(entry *logrus.Entry) error {
+	line, err := entry.String()
+	if err != nil {
+		return err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.entries[h.next] = strings.TrimRight(line, "\n")
+	h.next = (h.next + 1) % len(h.entries)
+	if h.next == 0 {
+		h.full = true
+	}
+	return nil
+}
+
+// RecentErrors 返回最近的错误日志（按时间从旧到新），最多 limit 条
+func RecentErrors(limit int) []string {
+	h := recentErrors
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var lines []string
+	if h.full {
+		lines = append(lines, h.entries[h.next:]...)
+	}
+	lines = append(lines, h.entries[:h.next]...)
+
+	if limit > 0 && len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	return lines
+}
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// ErrRotationUnavailable 日志未输出到文件，无法轮转
+var ErrRotationUnavailable = errors.New("日志未输出到文件，无法轮转")
+
+// ErrInvalidRetention 日志保留设置无效
+var ErrInvalidRetention = errors.New("无效的日志保留设置")
+
+// Settings 当前日志设置
+type Settings struct {
+	Level      string `json:"level"`
+	Output     string `json:"output"`
+	Filename   string `json:"filename,omitempty"`
+	MaxSize    int    `json:"max_size"`    // 单个日志文件最大MB
+	MaxBackups int    `json:"max_backups"` // 保留的备份数
+	MaxAge     int    `json:"max_age"`     // 保留天数
+	Compress   bool   `json:"compress"`
+	Rotatable  bool   `json:"rotatable"` // 是否支持手动轮转
+}
+
+var (
+	rotatorMutex sync.RWMutex
+	rotator      *lumberjack.Logger
+	output       string
+)
+
+// setRotator 记录当前的文件输出，供手动轮转和查询设置使用
+func setRotator(out string, r *lumberjack.Logger) {
+	rotatorMutex.Lock()
+	defer rotatorMutex.Unlock()
+	output = out
+	rotator = r
+}
+
+// Rotate 手动轮转日志文件：当前文件改名为带时间戳的备份，并打开新的日志文件
+func Rotate() error {
+	rotatorMutex.RLock()
+	defer rotatorMutex.RUnlock()
+	if rotator == nil {
+		return ErrRotationUnavailable
+	}
+	return rotator.Rotate()
+}
+
+// CurrentSettings 获取当前日志设置
+func CurrentSettings() Settings {
+	rotatorMutex.RLock()
+	defer rotatorMutex.RUnlock()
+
+	settings := Settings{
+		Level:  Logger.GetLevel().String(),
+		Output: output,
+	}
+	if rotator != nil {
+		settings.Filename = rotator.Filename
+		settings.MaxSize = rotator.MaxSize
+		settings.MaxBackups = rotator.MaxBackups
+		settings.MaxAge = rotator.MaxAge
+		settings.Compress = rotator.Compress
+		settings.Rotatable = true
+	}
+	return settings
+}
+
+// SetLevel 运行时修改日志级别
+func SetLevel(level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	Logger.SetLevel(parsed)
+	return nil
+}
+
+// SetRetention 运行时修改日志轮转的单文件大小（MB）、备份数和保留天数，为nil的项保持不变
+// 正在写入的 lumberjack.Logger 不能并发修改字段，这里用新设置创建一个写同一文件的实例替换输出
+func SetRetention(maxSize, maxBackups, maxAge *int) (Settings, error) {
+	if (maxSize != nil && *maxSize < 1) || (maxBackups != nil && *maxBackups < 0) || (maxAge != nil && *maxAge < 0) {
+		return Settings{}, ErrInvalidRetention
+	}
+
+	rotatorMutex.Lock()
+	if rotator == nil {
+		rotatorMutex.Unlock()
+		return Settings{}, ErrRotationUnavailable
+	}
+	next := &lumberjack.Logger{
+		Filename:   rotator.Filename,
+		MaxSize:    rotator.MaxSize,
+		MaxBackups: rotator.MaxBackups,
+		MaxAge:     rotator.MaxAge,
+		Compress:   rotator.Compress,
+		LocalTime:  rotator.LocalTime,
+	}
+	if maxSize != nil {
+		next.MaxSize = *maxSize
+	}
+	if maxBackups != nil {
+		next.MaxBackups = *maxBackups
+	}
+	if maxAge != nil {
+		next.MaxAge = *maxAge
+	}
+	previous := rotator
+	Logger.SetOutput(next)
+	rotator = next
+	rotatorMutex.Unlock()
+
+	// 替换输出后旧实例不会再被写入
+	previous.Close()
+	return CurrentSettings(), nil
+}
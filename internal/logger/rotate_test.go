@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/config"
+)
+
+// initFileLogger 初始化输出到临时目录的文件日志，返回日志目录
+func initFileLogger(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	err := Init(&config.LogConfig{Level: "info", Format: "json", Output: "file", MaxSize: 10, MaxBackups: 3, MaxAge: 7},
+		&config.SystemConfig{LogDir: dir})
+	if err != nil {
+		t.Fatalf("初始化日志失败: %v", err)
+	}
+	t.Cleanup(func() {
+		rotatorMutex.RLock()
+		defer rotatorMutex.RUnlock()
+		if rotator != nil {
+			rotator.Close()
+		}
+	})
+	return dir
+}
+
+// logBackups 返回日志目录中除当前日志文件外的备份文件
+func logBackups(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backups []string
+	for _, entry := range entries {
+		if entry.Name() != "app.log" && strings.HasPrefix(entry.Name(), "app-") {
+			backups = append(backups, entry.Name())
+		}
+	}
+	return backups
+}
+
+func TestRotateCreatesBackup(t *testing.T) {
+	dir := initFileLogger(t)
+	Info("轮转前的日志")
+
+	if err := Rotate(); err != nil {
+		t.Fatalf("轮转失败: %v", err)
+	}
+	Info("轮转后的日志")
+
+	backups := logBackups(t, dir)
+	if len(backups) != 1 {
+		t.Fatalf("备份文件 %v，期望 1 个", backups)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, backups[0]))
+	if err != nil || !strings.Contains(string(data), "轮转前的日志") {
+		t.Fatalf("备份内容 %q, %v", data, err)
+	}
+	current, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil || strings.Contains(string(current), "轮转前的日志") || !strings.Contains(string(current), "轮转后的日志") {
+		t.Fatalf("当前日志内容 %q, %v", current, err)
+	}
+}
+
+func TestSetRetention(t *testing.T) {
+	dir := initFileLogger(t)
+	Info("修改前的日志")
+
+	size, backups := 20, 0
+	settings, err := SetRetention(&size, &backups, nil)
+	if err != nil {
+		t.Fatalf("修改保留设置失败: %v", err)
+	}
+	if settings.MaxSize != 20 || settings.MaxBackups != 0 || settings.MaxAge != 7 {
+		t.Fatalf("修改后设置 %+v", settings)
+	}
+
+	// 替换后继续写入同一文件，轮转仍然可用
+	Info("修改后的日志")
+	current, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil || !strings.Contains(string(current), "修改前的日志") || !strings.Contains(string(current), "修改后的日志") {
+		t.Fatalf("当前日志内容 %q, %v", current, err)
+	}
+	if err := Rotate(); err != nil {
+		t.Fatalf("修改后轮转失败: %v", err)
+	}
+
+	invalid := 0
+	if _, err := SetRetention(&invalid, nil, nil); !errors.Is(err, ErrInvalidRetention) {
+		t.Fatalf("单文件大小为0应返回 ErrInvalidRetention，实际 %v", err)
+	}
+}
+
+func TestSetRetentionWithoutFileOutput(t *testing.T) {
+	if err := Init(&config.LogConfig{Level: "info", Output: "stdout"}, &config.SystemConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	size := 10
+	if _, err := SetRetention(&size, nil, nil); !errors.Is(err, ErrRotationUnavailable) {
+		t.Fatalf("未输出到文件时应返回 ErrRotationUnavailable，实际 %v", err)
+	}
+}
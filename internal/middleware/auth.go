@@ -11,11 +11,57 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware 认证中间件
+// authIdentityKey 上下文中缓存令牌解析结果的键
+const authIdentityKey = "auth_identity"
+
+// authIdentity 一次请求内令牌的解析结果，限流中间件和认证中间件共用
+type authIdentity struct {
+	token    string
+	claims   *service.JWTClaims
+	user     *model.User
+	tokenErr error // 令牌校验失败
+	userErr  error // 令牌有效但查询用户失败
+}
+
+// resolveToken 校验令牌并查询用户，结果缓存在上下文中，同一请求内令牌只校验一次、用户只查询一次
+func resolveToken(c *gin.Context, authService *service.AuthService, token string) *authIdentity {
+	if cached, exists := c.Get(authIdentityKey); exists {
+		if identity := cached.(*authIdentity); identity.token == token {
+			return identity
+		}
+	}
+
+	identity := &authIdentity{token: token}
+	identity.claims, identity.tokenErr = authService.ValidateToken(token)
+	if identity.tokenErr == nil {
+		identity.user, identity.userErr = authService.GetUserByID(identity.claims.UserID)
+	}
+	c.Set(authIdentityKey, identity)
+	return identity
+}
+
+// AuthMiddleware 认证中间件，从 Authorization: Bearer 头读取令牌
 func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
+	return authMiddleware(authService, false)
+}
+
+// WebSocketAuthMiddleware WebSocket握手认证中间件
+// 浏览器无法在WebSocket握手时设置请求头，因此未提供 Authorization 头时从查询参数 token 读取令牌；
+// 两种方式的令牌都必须对应一个有效的会话
+func WebSocketAuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
+	return authMiddleware(authService, true)
+}
+
+// authMiddleware 校验令牌并把用户信息写入上下文，allowQueryToken 为true时允许从查询参数读取令牌
+func authMiddleware(authService *service.AuthService, allowQueryToken bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 获取Authorization头
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && allowQueryToken {
+			if queryToken := c.Query("token"); queryToken != "" {
+				authHeader = "Bearer " + queryToken
+			}
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
 				Code:    http.StatusUnauthorized,
@@ -46,9 +92,9 @@ func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		// 验证令牌
-		claims, err := authService.ValidateToken(token)
-		if err != nil {
+		// 验证令牌（限流中间件已解析过时直接复用结果）
+		identity := resolveToken(c, authService, token)
+		if err := identity.tokenErr; err != nil {
 			logger.Warn("令牌验证失败", "error", err.Error(), "ip", c.ClientIP())
 			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
 				Code:    http.StatusUnauthorized,
@@ -60,9 +106,9 @@ func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 		}
 
 		// 获取用户信息
-		user, err := authService.GetUserByID(claims.UserID)
-		if err != nil {
-			logger.Warn("获取用户信息失败", "user_id", claims.UserID, "error", err.Error())
+		user := identity.user
+		if err := identity.userErr; err != nil {
+			logger.Warn("获取用户信息失败", "user_id", identity.claims.UserID, "error", err.Error())
 			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
 				Code:    http.StatusUnauthorized,
 				Message: "用户不存在或已被禁用",
@@ -77,8 +123,26 @@ func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 		c.Set("username", user.Username)
 		c.Set("user_role", user.GetRole())
 		c.Set("token", token)
+		c.Set("session_id", identity.claims.SessionID)
+
+		c.Next()
+	}
+}
 
+// SessionExtensionMiddleware 会话自动延长中间件，需全局注册
+// 令牌由各路由组的认证中间件设置，因此在请求处理完成后再读取，失败的请求不延长会话
+func SessionExtensionMiddleware(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		c.Next()
+
+		token, exists := GetCurrentToken(c)
+		if !exists || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		if err := authService.ExtendSession(token); err != nil {
+			logger.Warn("延长会话失败", "error", err.Error(), "user_id", c.GetUint("user_id"))
+		}
 	}
 }
 
@@ -159,6 +223,24 @@ func RequirePermission(permissions ...string) gin.HandlerFunc {
 	}
 }
 
+// HasPermissions 判断当前用户是否同时拥有全部指定权限，管理员拥有所有权限
+// 用于处理器内根据请求参数追加的权限检查
+func HasPermissions(c *gin.Context, permissions ...string) bool {
+	u, exists := GetCurrentUser(c)
+	if !exists {
+		return false
+	}
+	if u.IsAdmin() {
+		return true
+	}
+	for _, permission := range permissions {
+		if !u.HasPermission(permission) {
+			return false
+		}
+	}
+	return true
+}
+
 // AdminOnly 仅管理员中间件
 func AdminOnly() gin.HandlerFunc {
 	return RequireRole("admin")
@@ -189,4 +271,13 @@ func GetCurrentToken(c *gin.Context) (string, bool) {
 		return "", false
 	}
 	return token.(string), true
-}
\ No newline at end of file
+}
+
+// GetCurrentSessionID 获取当前令牌所属的会话ID
+func GetCurrentSessionID(c *gin.Context) (string, bool) {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return "", false
+	}
+	return sessionID.(string), true
+}
@@ -3,41 +3,59 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"web-panel-go/internal/config"
 	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+	"web-panel-go/internal/shardmap"
 
 	"github.com/gin-contrib/cors"
-	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 )
 
-// SetupMiddlewares 设置中间件
-func SetupMiddlewares(r *gin.Engine, cfg *config.Config) {
-	// 恢复中间件
-	r.Use(gin.Recovery())
+// slowRequests 启动以来的慢请求计数
+var slowRequests atomic.Int64
 
-	// 日志中间件
-	r.Use(LoggerMiddleware())
+// SlowRequestCount 返回启动以来的慢请求数
+func SlowRequestCount() int64 {
+	return slowRequests.Load()
+}
 
-	// CORS中间件
-	r.Use(CORSMiddleware(cfg.Security.CORSOrigins))
+// LoggerMiddleware 日志中间件，耗时超过 slowThreshold 的请求额外记录一条warn日志，slowThreshold 为0时不检查
+func LoggerMiddleware(slowThreshold time.Duration) gin.HandlerFunc {
+	access := accessLogger()
+	return func(c *gin.Context) {
+		start := time.Now()
+		access(c)
 
-	// Gzip压缩中间件
-	r.Use(gzip.Gzip(gzip.DefaultCompression))
+		latency := time.Since(start)
+		if slowThreshold <= 0 || latency < slowThreshold {
+			return
+		}
 
-	// 限流中间件
-	if cfg.Security.RateLimit.MaxRequests > 0 {
-		r.Use(RateLimitMiddleware(cfg.Security.RateLimit))
+		slowRequests.Add(1)
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		logger.Warn("慢请求",
+			"method", c.Request.Method,
+			"route", route,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"latency_ms", latency.Milliseconds(),
+			"threshold_ms", slowThreshold.Milliseconds(),
+			"request_id", c.GetString("request_id"),
+		)
 	}
-
-	// 安全头中间件
-	r.Use(SecurityHeadersMiddleware())
 }
 
-// LoggerMiddleware 日志中间件
-func LoggerMiddleware() gin.HandlerFunc {
+// accessLogger 访问日志
+func accessLogger() gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		// 记录请求日志
 		logger.LogRequest(
@@ -90,30 +108,58 @@ func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
 	return cors.New(config)
 }
 
-// RateLimitMiddleware 限流中间件
-func RateLimitMiddleware(cfg config.RateLimit) gin.HandlerFunc {
-	// 简单的内存限流实现
+// RateLimitMiddleware 限流中间件，未认证请求按IP计数
+// 配置了管理员豁免或管理员限额时，携带有效令牌的管理员请求按用户计数（或不计数），不受共享IP的影响
+// 返回的停止函数结束过期记录的后台清理，关闭服务时调用
+func RateLimitMiddleware(cfg config.RateLimit, authService *service.AuthService) (handler gin.HandlerFunc, stop func()) {
+	// 内存限流实现，按客户端分片加锁，并发请求不会在同一把锁上排队
 	// 生产环境建议使用Redis等外部存储
-	clientMap := make(map[string][]time.Time)
+	clients := shardmap.New[string, []time.Time](shardmap.DefaultShards)
+	// 窗口内没有请求的客户端由后台清理，避免不再访问的IP一直占用内存
+	stop = clients.StartSweeper(cfg.Window, func(_ string, requests []time.Time) bool {
+		return len(requests) == 0 || time.Since(requests[len(requests)-1]) >= cfg.Window
+	})
+	adminPolicy := authService != nil && (cfg.AdminExempt || cfg.AdminMaxRequests > 0)
 
-	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
+	handler = func(c *gin.Context) {
+		// 健康检查、指标采集等路径不计入限流
+		if isRateLimitExempt(c.Request.URL.Path, cfg.ExemptPaths) {
+			c.Next()
+			return
+		}
+
+		key := c.ClientIP()
+		maxRequests := cfg.MaxRequests
+		if adminPolicy {
+			if admin := rateLimitAdmin(c, authService); admin != nil {
+				if cfg.AdminExempt {
+					c.Next()
+					return
+				}
+				key = fmt.Sprintf("user:%d", admin.ID)
+				maxRequests = cfg.AdminMaxRequests
+			}
+		}
 		now := time.Now()
 
-		// 清理过期记录
-		if requests, exists := clientMap[clientIP]; exists {
-			var validRequests []time.Time
+		// 清理过期记录并检查请求数量，未超限时记录当前请求
+		limited := false
+		validRequests := clients.Update(key, func(requests []time.Time, _ bool) ([]time.Time, bool) {
+			var valid []time.Time
 			for _, reqTime := range requests {
 				if now.Sub(reqTime) < cfg.Window {
-					validRequests = append(validRequests, reqTime)
+					valid = append(valid, reqTime)
 				}
 			}
-			clientMap[clientIP] = validRequests
-		}
+			if len(valid) >= maxRequests {
+				limited = true
+				return valid, true
+			}
+			return append(valid, now), true
+		})
 
-		// 检查请求数量
-		if len(clientMap[clientIP]) >= cfg.MaxRequests {
-			logger.Warn("请求频率过高", "client_ip", clientIP, "requests", len(clientMap[clientIP]))
+		if limited {
+			logger.Warn("请求频率过高", "client", key, "requests", len(validRequests))
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"code":    http.StatusTooManyRequests,
 				"message": "请求频率过高，请稍后再试",
@@ -122,11 +168,38 @@ func RateLimitMiddleware(cfg config.RateLimit) gin.HandlerFunc {
 			return
 		}
 
-		// 记录当前请求
-		clientMap[clientIP] = append(clientMap[clientIP], now)
-
 		c.Next()
 	}
+	return handler, stop
+}
+
+// rateLimitAdmin 解析请求携带的令牌，令牌有效且用户当前为管理员时返回该用户
+// 按数据库中的角色判断，不看令牌中的主角色，同时拥有多个角色的管理员同样适用管理员限额
+// 令牌无效时返回nil，请求按IP计数，认证失败由后续的认证中间件处理；解析结果缓存在上下文中，认证中间件不再重复解析
+func rateLimitAdmin(c *gin.Context, authService *service.AuthService) *model.User {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil
+	}
+	identity := resolveToken(c, authService, strings.TrimPrefix(authHeader, "Bearer "))
+	if identity.tokenErr != nil || identity.userErr != nil || !identity.user.IsAdmin() {
+		return nil
+	}
+	return identity.user
+}
+
+// isRateLimitExempt 判断路径是否在限流豁免列表中（路径本身或其子路径）
+func isRateLimitExempt(path string, exemptPaths []string) bool {
+	for _, exempt := range exemptPaths {
+		exempt = strings.TrimSuffix(exempt, "/")
+		if exempt == "" {
+			continue
+		}
+		if path == exempt || strings.HasPrefix(path, exempt+"/") {
+			return true
+		}
+	}
+	return false
 }
 
 // SecurityHeadersMiddleware 安全头中间件
@@ -195,19 +268,3 @@ func RequestIDMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
-
-// HealthCheckMiddleware 健康检查中间件
-func HealthCheckMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if c.Request.URL.Path == "/health" {
-			c.JSON(http.StatusOK, gin.H{
-				"status":    "ok",
-				"timestamp": time.Now().Unix(),
-				"version":   "1.0.0",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
-}
\ No newline at end of file
@@ -0,0 +1,297 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	// 服务层直接调用全局日志器，测试中丢弃输出
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// newTestDB 创建带默认管理员的内存数据库，测试结束时关闭
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenInMemory(config.SeedConfig{Enabled: true, AdminUsername: "admin", AdminPassword: "Admin@12345"})
+	if err != nil {
+		t.Fatalf("创建内存数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newTestAuthService 创建使用内存数据库的认证服务，返回服务和默认管理员的访问令牌
+func newTestAuthService(t *testing.T) (*service.AuthService, string) {
+	t.Helper()
+	auth := newAuthService(t, newTestDB(t))
+	return auth, login(t, auth, "admin", "Admin@12345")
+}
+
+// newAuthService 创建使用指定数据库的认证服务
+func newAuthService(t *testing.T, db *gorm.DB) *service.AuthService {
+	t.Helper()
+	cfg, err := config.Defaults()
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+	cfg.System.DataDir = t.TempDir()
+	return service.NewAuthService(db, cfg, service.NewRBACCache(db))
+}
+
+// login 登录并返回访问令牌
+func login(t *testing.T, auth *service.AuthService, username, password string) string {
+	t.Helper()
+	resp, err := auth.Login(&model.LoginRequest{Username: username, Password: password}, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("用户 %s 登录失败: %v", username, err)
+	}
+	return resp.Token
+}
+
+// newRateLimitRouter 创建带限流的路由，/public 无需认证，/private 需要认证
+func newRateLimitRouter(t *testing.T, cfg config.RateLimit, auth *service.AuthService) *gin.Engine {
+	t.Helper()
+	rateLimit, stop := RateLimitMiddleware(cfg, auth)
+	t.Cleanup(stop)
+	router := gin.New()
+	router.Use(rateLimit)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/public", ok)
+	router.GET("/private", AuthMiddleware(auth), ok)
+	return router
+}
+
+// allowedRequests 连续发送 n 个请求，返回在第一次被限流前成功的请求数
+func allowedRequests(router *gin.Engine, path, token string, n int) int {
+	for i := 0; i < n; i++ {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code == http.StatusTooManyRequests {
+			return i
+		}
+	}
+	return n
+}
+
+func TestRateLimitAdminAndAnonymousLimits(t *testing.T) {
+	auth, token := newTestAuthService(t)
+
+	tests := []struct {
+		name          string
+		cfg           config.RateLimit
+		wantAnonymous int
+		wantAdmin     int
+	}{
+		{"无管理员策略时按IP计数", config.RateLimit{Window: time.Minute, MaxRequests: 3}, 3, 3},
+		{"管理员按用户限额", config.RateLimit{Window: time.Minute, MaxRequests: 3, AdminMaxRequests: 10}, 3, 10},
+		{"管理员豁免", config.RateLimit{Window: time.Minute, MaxRequests: 3, AdminExempt: true}, 3, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 匿名请求和管理员请求来自同一IP，分别使用新的限流器
+			if got := allowedRequests(newRateLimitRouter(t, tt.cfg, auth), "/public", "", 50); got != tt.wantAnonymous {
+				t.Errorf("匿名请求放行 %d 次，期望 %d 次", got, tt.wantAnonymous)
+			}
+			if got := allowedRequests(newRateLimitRouter(t, tt.cfg, auth), "/private", token, 50); got != tt.wantAdmin {
+				t.Errorf("管理员请求放行 %d 次，期望 %d 次", got, tt.wantAdmin)
+			}
+		})
+	}
+}
+
+func TestRateLimitAdminDoesNotShareAnonymousQuota(t *testing.T) {
+	auth, token := newTestAuthService(t)
+	router := newRateLimitRouter(t, config.RateLimit{Window: time.Minute, MaxRequests: 3, AdminMaxRequests: 10}, auth)
+
+	// 同一IP的匿名请求用尽限额后，管理员仍按自己的限额计数
+	if got := allowedRequests(router, "/public", "", 10); got != 3 {
+		t.Fatalf("匿名请求放行 %d 次，期望 3 次", got)
+	}
+	if got := allowedRequests(router, "/private", token, 20); got != 10 {
+		t.Fatalf("管理员请求放行 %d 次，期望 10 次", got)
+	}
+	// 无效令牌按IP计数，此时IP限额已用尽
+	if got := allowedRequests(router, "/private", "invalid", 1); got != 0 {
+		t.Fatalf("无效令牌请求放行 %d 次，期望按IP限流", got)
+	}
+}
+
+func TestRateLimitMultiRoleAdmin(t *testing.T) {
+	db := newTestDB(t)
+	auth := newAuthService(t, db)
+
+	var userRole, adminRole model.Role
+	db.Where("name = ?", model.RoleUser).First(&userRole)
+	db.Where("name = ?", model.RoleAdmin).First(&adminRole)
+	ops := model.User{Username: "ops", Email: "ops@example.com", Status: model.UserStatusActive}
+	if err := ops.SetPassword("Ops@12345", config.PasswordPolicyConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&ops).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	if err := db.Model(&ops).Association("Roles").Append(&userRole); err != nil {
+		t.Fatalf("分配角色失败: %v", err)
+	}
+
+	// 以普通用户身份登录，令牌中的主角色为 user，之后再授予管理员角色
+	token := login(t, auth, "ops", "Ops@12345")
+	if claims, err := auth.ValidateToken(token); err != nil || claims.Role == model.RoleAdmin {
+		t.Fatalf("令牌主角色 = %v (%v)，期望不是管理员", claims, err)
+	}
+	if err := db.Model(&ops).Association("Roles").Append(&adminRole); err != nil {
+		t.Fatalf("分配管理员角色失败: %v", err)
+	}
+
+	router := newRateLimitRouter(t, config.RateLimit{Window: time.Minute, MaxRequests: 3, AdminMaxRequests: 10}, auth)
+	if got := allowedRequests(router, "/private", token, 20); got != 10 {
+		t.Fatalf("拥有管理员及其他角色的用户放行 %d 次，期望管理员限额 10 次", got)
+	}
+}
+
+func TestRateLimitResolvesTokenOnce(t *testing.T) {
+	auth, token := newTestAuthService(t)
+	rateLimit, stop := RateLimitMiddleware(config.RateLimit{Window: time.Minute, MaxRequests: 3, AdminExempt: true}, auth)
+	defer stop()
+	router := gin.New()
+	router.Use(rateLimit)
+
+	// 限流中间件解析的身份由认证中间件直接复用
+	var limiterIdentity interface{}
+	router.GET("/private", func(c *gin.Context) {
+		limiterIdentity, _ = c.Get(authIdentityKey)
+		c.Next()
+	}, AuthMiddleware(auth), func(c *gin.Context) {
+		if identity, _ := c.Get(authIdentityKey); identity != limiterIdentity || identity == nil {
+			t.Error("认证中间件重新解析了令牌")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/private", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("请求返回 %d，期望 200", w.Code)
+	}
+}
+
+func TestRateLimitExemptPaths(t *testing.T) {
+	rateLimit, stop := RateLimitMiddleware(config.RateLimit{Window: time.Minute, MaxRequests: 3, ExemptPaths: []string{"/health", "/metrics/"}}, nil)
+	defer stop()
+	router := gin.New()
+	router.Use(rateLimit)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	for _, path := range []string{"/health", "/health/db", "/healthz", "/metrics", "/public"} {
+		router.GET(path, ok)
+	}
+
+	// 豁免路径及其子路径不限流，也不占用同一IP的限额
+	for _, path := range []string{"/health", "/health/db", "/metrics"} {
+		if got := allowedRequests(router, path, "", 50); got != 50 {
+			t.Fatalf("%s 放行 %d 次，期望不限流", path, got)
+		}
+	}
+	if got := allowedRequests(router, "/public", "", 10); got != 3 {
+		t.Fatalf("普通路径放行 %d 次，期望 3 次", got)
+	}
+	// 仅前缀相同的路径不豁免，此时IP限额已用尽
+	if got := allowedRequests(router, "/healthz", "", 1); got != 0 {
+		t.Fatalf("/healthz 放行 %d 次，期望被限流", got)
+	}
+	if got := allowedRequests(router, "/health", "", 10); got != 10 {
+		t.Fatalf("限额用尽后 /health 放行 %d 次，期望不限流", got)
+	}
+}
+func TestLoggerMiddlewareWarnsOnSlowRequests(t *testing.T) {
+	hook := logtest.NewLocal(logger.Logger)
+	t.Cleanup(func() { logger.Logger.ReplaceHooks(make(logrus.LevelHooks)) })
+
+	router := gin.New()
+	router.Use(LoggerMiddleware(20 * time.Millisecond))
+	router.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/slow/:id", func(c *gin.Context) {
+		time.Sleep(30 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	serve := func(path string) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// 访问日志为info级别，只统计warn日志
+	warnings := func() []logrus.Entry {
+		var entries []logrus.Entry
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel {
+				entries = append(entries, *entry)
+			}
+		}
+		return entries
+	}
+
+	before := SlowRequestCount()
+	serve("/fast")
+	if got := warnings(); len(got) != 0 {
+		t.Fatalf("未超过阈值的请求不应记录warn日志: %v", got)
+	}
+
+	serve("/slow/1")
+	got := warnings()
+	if len(got) != 1 || got[0].Message != "慢请求" {
+		t.Fatalf("慢请求应记录一条warn日志，实际 %v", got)
+	}
+	entry := got[0]
+	args := fmt.Sprint(entry.Data["args"])
+	for _, want := range []string{"route /slow/:id", "path /slow/1", "threshold_ms 20"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("日志字段 %s 缺少 %q", args, want)
+		}
+	}
+	if got := SlowRequestCount() - before; got != 1 {
+		t.Fatalf("慢请求计数增加 %d，期望 1", got)
+	}
+
+	// 阈值为0时不检查
+	hook.Reset()
+	router = gin.New()
+	router.Use(LoggerMiddleware(0))
+	router.GET("/slow/:id", func(c *gin.Context) {
+		time.Sleep(30 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	serve("/slow/2")
+	if got := warnings(); len(got) != 0 {
+		t.Fatalf("阈值为0时不应记录慢请求: %v", got)
+	}
+}
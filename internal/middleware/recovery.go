@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"syscall"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecoveryMiddleware panic恢复中间件
+// 记录panic及堆栈到日志和审计日志，并返回统一的JSON错误响应；exposeStack 为false时响应中不包含panic信息和堆栈
+func RecoveryMiddleware(auditService *service.AuditService, exposeStack bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			requestID := c.GetString("request_id")
+			stack := string(debug.Stack())
+
+			// 客户端已断开连接时无法再写入响应
+			if isBrokenPipe(recovered) {
+				logger.Warn("客户端连接已断开", "error", recovered, "path", c.Request.URL.Path, "request_id", requestID)
+				c.Abort()
+				return
+			}
+
+			logger.Error("请求处理发生panic",
+				"error", recovered,
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"request_id", requestID,
+				"stack", stack,
+			)
+
+			userID, _ := GetCurrentUserID(c)
+			if auditService != nil {
+				auditService.LogPanic(userID, c.Request.Method, c.Request.URL.Path, requestID, recovered, c.ClientIP(), c.GetHeader("User-Agent"))
+			}
+
+			// 响应已开始写出时只能中断
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+
+			response := model.ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Message:   "服务器内部错误",
+				RequestID: requestID,
+			}
+			if exposeStack {
+				response.Error = fmt.Sprintf("%v\n%s", recovered, stack)
+			}
+
+			c.AbortWithStatusJSON(http.StatusInternalServerError, response)
+		}()
+
+		c.Next()
+	}
+}
+
+// isBrokenPipe 判断panic是否由客户端断开连接引起
+func isBrokenPipe(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	var syscallErr *os.SyscallError
+	if errors.As(opErr, &syscallErr) {
+		return errors.Is(syscallErr.Err, syscall.EPIPE) || errors.Is(syscallErr.Err, syscall.ECONNRESET)
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecoveryMiddlewareHidesStackInRelease(t *testing.T) {
+	tests := []struct {
+		mode      string
+		wantStack bool
+	}{
+		{"production", false},
+		{"release", false},
+		{"development", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			db := newTestDB(t)
+			// 与 router.Setup 相同，按运行模式决定是否在响应中返回堆栈
+			system := config.SystemConfig{Mode: tt.mode}
+			router := gin.New()
+			router.Use(RequestIDMiddleware())
+			router.Use(RecoveryMiddleware(service.NewAuditService(db), !system.IsRelease()))
+			router.GET("/panic", func(c *gin.Context) {
+				panic("secret-panic-value")
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("panic 请求返回 %d，期望 500", w.Code)
+			}
+
+			var resp model.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("响应不是JSON: %s", w.Body.String())
+			}
+			if resp.RequestID == "" {
+				t.Error("响应中没有 request_id")
+			}
+			body := w.Body.String()
+			leaked := strings.Contains(body, "secret-panic-value") || strings.Contains(body, "goroutine")
+			if leaked != tt.wantStack {
+				t.Fatalf("响应包含panic信息 = %v，期望 %v: %s", leaked, tt.wantStack, body)
+			}
+
+			var audit model.AuditLog
+			if err := db.Where("action = ? AND status = ?", "panic", "failed").First(&audit).Error; err != nil {
+				t.Fatalf("没有记录panic审计日志: %v", err)
+			}
+			if !strings.Contains(audit.Details, resp.RequestID) {
+				t.Fatalf("审计日志 %q 中没有 request_id %q", audit.Details, resp.RequestID)
+			}
+		})
+	}
+}
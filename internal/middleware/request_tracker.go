@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"web-panel-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTrackedRequestsPerUser 每个用户保留的最近请求数
+const maxTrackedRequestsPerUser = 50
+
+// RequestTracker 按用户记录最近的HTTP请求（内存），用于关联用户的HTTP和WebSocket活动
+type RequestTracker struct {
+	mutex    sync.RWMutex
+	requests map[uint][]model.RequestRecord
+}
+
+// NewRequestTracker 创建请求跟踪器
+func NewRequestTracker() *RequestTracker {
+	return &RequestTracker{requests: make(map[uint][]model.RequestRecord)}
+}
+
+// Middleware 请求跟踪中间件，需在RequestIDMiddleware之后注册
+// 用户身份由各路由组的认证中间件设置，因此在请求处理完成后再读取
+func (t *RequestTracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		userID, exists := GetCurrentUserID(c)
+		if !exists {
+			return
+		}
+
+		t.record(userID, model.RequestRecord{
+			RequestID:  c.GetString("request_id"),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Status:     c.Writer.Status(),
+			DurationMs: time.Since(start).Milliseconds(),
+			StartedAt:  start,
+		})
+	}
+}
+
+// record 记录请求，超出上限时丢弃最早的记录
+func (t *RequestTracker) record(userID uint, record model.RequestRecord) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	records := append(t.requests[userID], record)
+	if len(records) > maxTrackedRequestsPerUser {
+		records = records[len(records)-maxTrackedRequestsPerUser:]
+	}
+	t.requests[userID] = records
+}
+
+// GetUserRequests 获取用户最近的请求，按时间倒序
+func (t *RequestTracker) GetUserRequests(userID uint) []model.RequestRecord {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	records := t.requests[userID]
+	result := make([]model.RequestRecord, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		result = append(result, records[i])
+	}
+	return result
+}
@@ -0,0 +1,25 @@
+package model
+
+import "strings"
+
+// commonPasswords 常见弱密码列表（小写），密码策略启用 reject_common 时拒绝使用
+var commonPasswords = map[string]bool{
+	"123456": true, "1234567": true, "12345678": true, "123456789": true, "1234567890": true,
+	"000000": true, "111111": true, "123123": true, "654321": true, "666666": true,
+	"888888": true, "112233": true, "121212": true, "123321": true, "5201314": true,
+	"password": true, "password1": true, "password123": true, "passw0rd": true, "p@ssw0rd": true,
+	"p@ssword": true, "qwerty": true, "qwerty123": true, "qwertyuiop": true, "asdfghjkl": true,
+	"1q2w3e4r": true, "1qaz2wsx": true, "zxcvbnm": true, "abc123": true, "abc12345": true,
+	"abcd1234": true, "a123456": true, "aa123456": true, "iloveyou": true, "welcome": true,
+	"welcome1": true, "welcome123": true, "letmein": true, "monkey": true, "dragon": true,
+	"sunshine": true, "princess": true, "football": true, "baseball": true, "superman": true,
+	"trustno1": true, "master": true, "shadow": true, "michael": true, "login": true,
+	"admin": true, "admin123": true, "admin1234": true, "admin@123": true, "administrator": true,
+	"root": true, "root123": true, "toor": true, "test": true, "test123": true,
+	"changeme": true, "secret": true, "default": true, "guest": true, "qazwsx": true,
+}
+
+// isCommonPassword 判断密码是否在常见弱密码列表中（不区分大小写）
+func isCommonPassword(password string) bool {
+	return commonPasswords[strings.ToLower(password)]
+}
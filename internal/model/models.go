@@ -6,14 +6,16 @@ import (
 
 // Session 会话模型
 type Session struct {
-	ID        string    `json:"id" gorm:"primaryKey;size:128"`
-	UserID    uint      `json:"user_id" gorm:"not null;index"`
-	Token     string    `json:"-" gorm:"uniqueIndex;not null;size:512"`
-	IPAddress string    `json:"ip_address" gorm:"size:45"`
-	UserAgent string    `json:"user_agent" gorm:"size:512"`
-	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                string    `json:"id" gorm:"primaryKey;size:128"`
+	UserID            uint      `json:"user_id" gorm:"not null;index"`
+	Token             string    `json:"-" gorm:"uniqueIndex;not null;size:512"` // 当前有效的访问令牌，刷新后旧访问令牌立即失效
+	RefreshTokenHash  string    `json:"-" gorm:"size:64"`                       // 当前刷新令牌的SHA-256
+	RefreshGeneration int       `json:"-"`                                      // 刷新令牌代数，每次轮换加一；使用旧代数的令牌视为被盗用
+	IPAddress         string    `json:"ip_address" gorm:"size:45"`
+	UserAgent         string    `json:"user_agent" gorm:"size:512"`
+	ExpiresAt         time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 // TableName 指定表名
@@ -26,6 +28,17 @@ func (s *Session) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt)
 }
 
+// SessionInfo 会话信息（不包含令牌）
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	UserID    uint      `json:"user_id"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Current   bool      `json:"current"` // 是否为发起本次请求的会话
+}
+
 // AuditLog 审计日志模型
 type AuditLog struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
@@ -44,6 +57,48 @@ func (AuditLog) TableName() string {
 	return "audit_logs"
 }
 
+// AuditLogDetail 审计日志详情（包含操作者用户名和解析后的详情）
+type AuditLogDetail struct {
+	AuditLog
+	Username    string      `json:"username"`
+	DetailsData interface{} `json:"details_data,omitempty"`
+}
+
+// AuditLogFilter 审计日志查询条件
+type AuditLogFilter struct {
+	UserID    *uint
+	Action    string
+	Status    string
+	IPAddress string
+	From      *time.Time
+	To        *time.Time
+}
+
+// AuditActionCount 按操作分组的审计日志计数
+type AuditActionCount struct {
+	Action string `json:"action"`
+	Count  int64  `json:"count"`
+}
+
+// AuditBucketCount 按时间段分组的审计日志计数
+type AuditBucketCount struct {
+	Bucket  string `json:"bucket"`
+	Total   int64  `json:"total"`
+	Success int64  `json:"success"`
+	Failed  int64  `json:"failed"`
+}
+
+// AuditStats 审计日志统计
+type AuditStats struct {
+	Total       int64              `json:"total"`
+	Success     int64              `json:"success"`
+	Failed      int64              `json:"failed"`
+	FailureRate float64            `json:"failure_rate"`
+	Interval    string             `json:"interval"`
+	ByAction    []AuditActionCount `json:"by_action"`
+	Buckets     []AuditBucketCount `json:"buckets"`
+}
+
 // SystemConfig 系统配置模型
 type SystemConfig struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
@@ -61,6 +116,65 @@ func (SystemConfig) TableName() string {
 	return "system_configs"
 }
 
+// UserPreference 用户界面偏好（每个用户一组键值，值为JSON）
+type UserPreference struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_preference_key"`
+	Key       string    `json:"key" gorm:"not null;size:100;uniqueIndex:idx_user_preference_key"`
+	Value     string    `json:"value" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserPreference) TableName() string {
+	return "user_preferences"
+}
+
+// UserTOTP 用户的TOTP两步验证配置
+type UserTOTP struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;uniqueIndex"`
+	Secret        string     `json:"-" gorm:"not null;size:64"`    // base32编码的共享密钥
+	Enabled       bool       `json:"enabled" gorm:"default:false"` // 验证通过后才启用，启用前登录不要求验证码
+	LastUsedStep  int64      `json:"-"`                            // 最近一次使用的时间步，防止同一验证码被重复使用
+	RecoveryCodes string     `json:"-" gorm:"type:text"`           // 恢复码的bcrypt哈希（JSON数组），使用后移除
+	EnabledAt     *time.Time `json:"enabled_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserTOTP) TableName() string {
+	return "user_totps"
+}
+
+// FileFavorite 用户收藏（置顶）的文件路径
+type FileFavorite struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_favorite_path"`
+	Path      string    `json:"path" gorm:"not null;size:1024;uniqueIndex:idx_user_favorite_path"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (FileFavorite) TableName() string {
+	return "file_favorites"
+}
+
+// UpdateSettingsRequest 更新面板设置请求
+type UpdateSettingsRequest struct {
+	Settings map[string]string `json:"settings" binding:"required"`
+}
+
+// FileBrowserSettings 文件浏览器默认设置
+type FileBrowserSettings struct {
+	SortBy     string `json:"sort_by"`
+	Order      string `json:"order"`
+	ShowHidden bool   `json:"show_hidden"`
+	HomeDir    string `json:"home_dir"`
+}
+
 // FileInfo 文件信息模型
 type FileInfo struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
@@ -69,11 +183,14 @@ type FileInfo struct {
 	Size        int64     `json:"size" gorm:"default:0"`
 	FileType    string    `json:"file_type" gorm:"size:20"`
 	FileExt     string    `json:"file_ext" gorm:"size:10"`
+	MimeType    string    `json:"mime_type" gorm:"size:100"`
 	IsDirectory bool      `json:"is_directory" gorm:"default:false"`
 	Permissions string    `json:"permissions" gorm:"size:10"`
+	Octal       string    `json:"octal"` // 八进制权限（含setuid/setgid/sticky位），如 0644
 	Owner       string    `json:"owner" gorm:"size:50"`
 	Group       string    `json:"group" gorm:"size:50"`
 	Hidden      bool      `json:"hidden" gorm:"default:false"`
+	Computed    bool      `json:"computed" gorm:"-"` // Size 是否为准确值（目录需递归计算后才为true）
 	ModTime     time.Time `json:"mod_time"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -140,6 +257,17 @@ type DiskStats struct {
 	UsedPercent float64 `json:"used_percent"`
 }
 
+// DiskPartition 单个挂载点的磁盘使用情况
+type DiskPartition struct {
+	Device      string  `json:"device"`
+	Mountpoint  string  `json:"mountpoint"`
+	Fstype      string  `json:"fstype"`
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	Free        uint64  `json:"free"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
 // LoadStats 系统负载信息
 type LoadStats struct {
 	Load1  float64 `json:"load1"`
@@ -147,6 +275,54 @@ type LoadStats struct {
 	Load15 float64 `json:"load15"`
 }
 
+// MetricSample 系统指标历史采样，由系统监控任务按 monitoring.metrics_sample_interval 写入
+type MetricSample struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	DiskPercent   float64   `json:"disk_percent"`
+	Load1         float64   `json:"load1"`
+	Load5         float64   `json:"load5"`
+	Load15        float64   `json:"load15"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (MetricSample) TableName() string {
+	return "metric_samples"
+}
+
+// MetricPoint 降采样后的指标数据点，取时间桶内所有采样的平均值
+type MetricPoint struct {
+	Time          time.Time `json:"time"` // 时间桶的起始时间
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	DiskPercent   float64   `json:"disk_percent"`
+	Load1         float64   `json:"load1"`
+	Load5         float64   `json:"load5"`
+	Load15        float64   `json:"load15"`
+	Samples       int       `json:"samples"` // 时间桶内的采样数
+}
+
+// MetricHistory 指标历史查询结果
+type MetricHistory struct {
+	From       time.Time     `json:"from"`
+	To         time.Time     `json:"to"`
+	Resolution string        `json:"resolution"` // 实际使用的时间桶大小
+	Points     []MetricPoint `json:"points"`     // 按时间升序，没有采样的时间桶不返回
+}
+
+// ServerTime 服务器时间信息
+type ServerTime struct {
+	Time      time.Time `json:"time"`       // 服务器当前时间（RFC3339，带时区偏移）
+	UnixMilli int64     `json:"unix_ms"`    // 当前时间的Unix毫秒时间戳，便于客户端计算时钟偏差
+	Timezone  string    `json:"timezone"`   // IANA时区名称，未配置时为时区缩写
+	Zone      string    `json:"zone"`       // 当前时区缩写（随夏令时变化）
+	UTCOffset int       `json:"utc_offset"` // 当前相对UTC的偏移（秒）
+	StartedAt time.Time `json:"started_at"` // 服务启动时间
+	Uptime    int64     `json:"uptime"`     // 服务运行时长（秒），按单调时钟计算
+}
+
 // NetworkStats 网络统计信息
 type NetworkStats struct {
 	BytesSent   uint64 `json:"bytes_sent"`
@@ -173,29 +349,70 @@ type PaginatedResponse struct {
 	PageSize int         `json:"page_size"`
 }
 
+// FileListResponse 文件列表响应（在分页结构基础上附带目录元数据）
+type FileListResponse struct {
+	Code       int         `json:"code"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	Size       int         `json:"size"`
+	PageSize   int         `json:"page_size"` // 与 size 相同，保留分页结构原有的字段
+	Path       string      `json:"path"`
+	ParentPath string      `json:"parent_path"`
+	IsRoot     bool        `json:"is_root"`
+	Truncated  bool        `json:"truncated"` // 目录条目超出上限，只列出了部分条目；不分页请求超出上限时同样为true
+}
+
 // ErrorResponse 错误响应
 type ErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Error   string `json:"error,omitempty"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	Error     string `json:"error,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // 用户相关请求响应结构体
 
+// UserFilter 用户查询条件
+// Search 对用户名、邮箱、昵称、手机号做不区分大小写的模糊匹配；Username、Email 为精确匹配
+type UserFilter struct {
+	Search   string
+	Username string
+	Email    string
+}
+
+// RoleSummary 角色概要（用于分配角色时的下拉列表）
+type RoleSummary struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	DisplayName string     `json:"display_name"`
+	Description string     `json:"description"`
+	IsSystem    bool       `json:"is_system"`
+	Status      RoleStatus `json:"status"`
+	UserCount   int64      `json:"user_count"`
+}
+
 // CreateUserRequest 创建用户请求
 type CreateUserRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Username string `json:"username" binding:"required"` // 长度、字符集和保留名称由服务层统一校验
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
 	Nickname string `json:"nickname" binding:"omitempty,max=50"`
 	Phone    string `json:"phone" binding:"omitempty,max=20"`
 	RoleIDs  []uint `json:"role_ids" binding:"required"`
+	Pending  bool   `json:"pending"` // 创建为待审核账户，仅在启用账户审核时生效
+}
+
+// RejectUserRequest 拒绝账户申请请求
+type RejectUserRequest struct {
+	Reason string `json:"reason" binding:"omitempty,max=200"`
 }
 
 // UpdateUserRequest 更新用户请求
 type UpdateUserRequest struct {
-	Username string `json:"username" binding:"omitempty,min=3,max=50"`
-	Email    string `json:"email" binding:"omitempty,email"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
 	Nickname string `json:"nickname" binding:"omitempty,max=50"`
 	Phone    string `json:"phone" binding:"omitempty,max=20"`
 	Status   *UserStatus `json:"status"`
@@ -205,7 +422,7 @@ type UpdateUserRequest struct {
 // ChangePasswordRequest 修改密码请求
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 // ChangeUserStatusRequest 修改用户状态请求
@@ -215,20 +432,44 @@ type ChangeUserStatusRequest struct {
 
 // ResetPasswordRequest 重置密码请求
 type ResetPasswordRequest struct {
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Code     string `json:"code"` // 启用两步验证时必填：TOTP验证码或恢复码
+}
+
+// TwoFactorSetupResponse 两步验证设置响应
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"` // 供验证器应用扫描的二维码内容
+}
+
+// EnableTwoFactorRequest 启用两步验证请求
+type EnableTwoFactorRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// EnableTwoFactorResponse 启用两步验证响应，恢复码只返回这一次
+type EnableTwoFactorResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token     string                 `json:"token"`
-	ExpiresAt int64                  `json:"expires_at"`
-	User      map[string]interface{} `json:"user"`
+	Token            string                 `json:"token"`
+	ExpiresAt        int64                  `json:"expires_at"`
+	RefreshToken     string                 `json:"refresh_token"`
+	RefreshExpiresAt int64                  `json:"refresh_expires_at"`
+	User             map[string]interface{} `json:"user"`
+}
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 // CreateRoleRequest 创建角色请求
@@ -255,18 +496,340 @@ type CreateDirectoryRequest struct {
 
 // FileContentResponse 文件内容响应
 type FileContentResponse struct {
-	Path    string `json:"path"`
-	Content string `json:"content"`
+	Path        string    `json:"path"`
+	Content     string    `json:"content"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mod_time"`
+	Permissions string    `json:"permissions"`
+	Encoding    string    `json:"encoding"` // utf-8, utf-8-bom, utf-16le, utf-16be, iso-8859-1, binary
+	IsBinary    bool      `json:"is_binary"` // 为true时不返回内容
+	Version     string    `json:"version"`   // 版本号，保存时原样带回用于检测并发修改
+}
+
+// ChunkUploadInitRequest 初始化分片上传请求
+type ChunkUploadInitRequest struct {
+	Path        string `json:"path" binding:"required"`      // 目标目录
+	FileName    string `json:"file_name" binding:"required"` // 文件名
+	TotalSize   int64  `json:"total_size" binding:"required,min=1"`
+	TotalChunks int    `json:"total_chunks" binding:"required,min=1,max=10000"` // 不超过 service.MaxUploadChunks
+}
+
+// ChunkUploadInitResponse 初始化分片上传响应
+type ChunkUploadInitResponse struct {
+	UploadID  string     `json:"upload_id"`
+	ChunkSize int64      `json:"chunk_size"`           // 单个分片最大字节数
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 未完成的上传在此时间后被清理，未配置保留时间时省略
+}
+
+// UploadSession 进行中的分片上传
+type UploadSession struct {
+	UploadID       string     `json:"upload_id"`
+	TargetPath     string     `json:"target_path"`
+	TotalSize      int64      `json:"total_size"`
+	ReceivedBytes  int64      `json:"received_bytes"`
+	TotalChunks    int        `json:"total_chunks"`
+	ReceivedChunks int        `json:"received_chunks"`
+	OwnerID        uint       `json:"owner_id"`
+	OwnerName      string     `json:"owner_name"`
+	StartedAt      time.Time  `json:"started_at"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"` // 未配置保留时间时省略
+}
+
+// ChunkUploadCompleteRequest 完成分片上传请求
+type ChunkUploadCompleteRequest struct {
+	UploadID string `json:"upload_id" binding:"required"`
+}
+
+// ChunkUploadCompleteResponse 完成分片上传响应
+type ChunkUploadCompleteResponse struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"` // 文件内容的SHA-256
+}
+
+// UploadFileResponse 上传文件响应
+type UploadFileResponse struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"` // 文件内容的SHA-256，客户端可据此校验上传结果
+}
+
+// FileLinesResponse 按行分段读取文件内容响应
+type FileLinesResponse struct {
+	Path       string    `json:"path"`
+	Lines      []string  `json:"lines"`
+	OffsetLine int       `json:"offset_line"` // 起始行（从0开始）
+	LineCount  int       `json:"line_count"`  // 实际返回的行数
+	TotalLines int       `json:"total_lines"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+}
+
+// LogFileInfo 面板日志文件信息
+type LogFileInfo struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time"`
+	Current    bool      `json:"current"`    // 当前正在写入的日志文件
+	Compressed bool      `json:"compressed"` // 轮转后压缩的备份（.gz）
+}
+
+// DiagnosticsBundle 系统诊断信息包
+type DiagnosticsBundle struct {
+	GeneratedAt  time.Time              `json:"generated_at"`
+	Build        BuildInfo              `json:"build"`
+	Host         map[string]interface{} `json:"host,omitempty"`
+	Stats        *SystemStats           `json:"stats,omitempty"`
+	Database     DatabasePoolStats      `json:"database"`
+	Config       interface{}            `json:"config"` // 敏感配置已脱敏
+	RecentErrors []string               `json:"recent_errors"`
+	Errors       []string               `json:"errors,omitempty"` // 收集过程中出现的错误
+}
+
+// BuildInfo 版本和构建信息
+type BuildInfo struct {
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module"`
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// DatabasePoolStats 数据库连接池统计
+type DatabasePoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+// 后台任务状态
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusSuccess = "success"
+	JobStatusFailed  = "failed"
+)
+
+// JobStatus 后台任务运行状态
+type JobStatus struct {
+	Name                string     `json:"name"`
+	Interval            string     `json:"interval"`
+	Status              string     `json:"status"`
+	LastRun             *time.Time `json:"last_run"`
+	LastDurationMs      int64      `json:"last_duration_ms"`
+	LastError           string     `json:"last_error,omitempty"`
+	NextRun             *time.Time `json:"next_run"`
+	RunCount            int64      `json:"run_count"`
+	FailureCount        int64      `json:"failure_count"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+// WebSocketConnection WebSocket连接信息
+type WebSocketConnection struct {
+	ID          string    `json:"id"`
+	UserID      uint      `json:"user_id"`
+	Username    string    `json:"username"`
+	RemoteAddr  string    `json:"remote_addr"`
+	UserAgent   string    `json:"user_agent"`
+	RequestID   string    `json:"request_id"` // 建立连接的HTTP请求ID
+	ConnectedAt time.Time `json:"connected_at"`
+	Topics      []string  `json:"topics"` // 该连接会收到的消息类型
+}
+
+// RequestRecord 用户最近的HTTP请求记录
+type RequestRecord struct {
+	RequestID  string    `json:"request_id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	DurationMs int64     `json:"duration_ms"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// UserConnectionsResponse 用户连接与请求关联信息
+type UserConnectionsResponse struct {
+	UserID         uint                  `json:"user_id"`
+	Connections    []WebSocketConnection `json:"connections"`
+	RecentRequests []RequestRecord       `json:"recent_requests"`
+}
+
+// 安全告警类型
+const (
+	SecurityAlertAccountFailures = "login_failures_account"   // 同一账户连续登录失败
+	SecurityAlertIPFailures      = "login_failures_ip"        // 同一IP频繁登录失败
+	SecurityAlertRolePermissions = "role_permissions_changed" // 角色的权限被修改
+	SecurityAlertUserRoles       = "user_roles_changed"       // 用户的角色被修改
+	SecurityAlertRoleChanged     = "role_changed"             // 角色被创建、修改或删除
+	SecurityAlertRefreshReuse    = "refresh_token_reuse"      // 已轮换的刷新令牌被再次使用
+)
+
+// SecurityAlert 安全告警（通过WebSocket推送给管理员）
+type SecurityAlert struct {
+	Type      string    `json:"type"`
+	Username  string    `json:"username,omitempty"`
+	UserID    uint      `json:"user_id,omitempty"`
+	IPAddress string    `json:"ip_address"`
+	Failures  int       `json:"failures"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RecentFile 最近访问的文件
+type RecentFile struct {
+	Path       string    `json:"path"`
+	Name       string    `json:"name"`
+	Action     string    `json:"action"`
+	AccessedAt time.Time `json:"accessed_at"`
+}
+
+// AddFavoriteRequest 收藏文件路径请求
+type AddFavoriteRequest struct {
+	Path string `json:"path" binding:"required"`
+}
+
+// FavoriteFile 收藏的文件路径，Stale 表示路径已不存在
+type FavoriteFile struct {
+	ID        uint      `json:"id"`
+	Path      string    `json:"path"`
+	Name      string    `json:"name"`
+	IsDir     bool      `json:"is_dir"`
+	Stale     bool      `json:"stale"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // KillProcessRequest 终止进程请求
 type KillProcessRequest struct {
-	PID int32 `json:"pid" binding:"required"`
+	PID          int32  `json:"pid" binding:"required"`
+	Confirm      bool   `json:"confirm"`       // 为true时先返回确认令牌，不立即执行
+	ConfirmToken string `json:"confirm_token"` // 二次确认时携带的令牌
+}
+
+// SignalProcessRequest 向进程发送信号请求
+type SignalProcessRequest struct {
+	PID          int32  `json:"pid" binding:"required"`
+	Signal       string `json:"signal" binding:"required"` // 信号名，如 TERM、HUP、INT、KILL，可带 SIG 前缀
+	Confirm      bool   `json:"confirm"`                   // 仅 KILL：与终止进程接口相同，为true时先返回确认令牌
+	ConfirmToken string `json:"confirm_token"`             // 仅 KILL：二次确认时携带的令牌
+}
+
+// ConfirmationResponse 危险操作二次确认响应
+type ConfirmationResponse struct {
+	ConfirmToken string `json:"confirm_token"`
+	Action       string `json:"action"`
+	Description  string `json:"description"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+// FileChecksumResponse 文件校验和响应
+type FileChecksumResponse struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+	Checksum  string `json:"checksum"`
+}
+
+// FileTypeStat 按扩展名汇总的文件统计
+type FileTypeStat struct {
+	Extension string `json:"extension"` // 小写扩展名（不含点），无扩展名时为空
+	Category  string `json:"category"`  // text, image, audio, video, application, other
+	Count     int64  `json:"count"`
+	Size      int64  `json:"size"`
+}
+
+// FileTypeStatsResponse 目录文件类型统计响应
+type FileTypeStatsResponse struct {
+	Path       string         `json:"path"`
+	Depth      int            `json:"depth"`
+	TotalFiles int64          `json:"total_files"`
+	TotalSize  int64          `json:"total_size"`
+	Types      []FileTypeStat `json:"types"`     // 按总大小降序
+	Skipped    int64          `json:"skipped"`   // 无法读取而跳过的条目数
+	Truncated  bool           `json:"truncated"` // 达到条目上限，只统计了部分文件
+	Partial    bool           `json:"partial"`   // 超时，只统计了部分文件
+}
+
+// SetRolePermissionsRequest 设置角色权限请求，空列表表示清空角色的全部权限
+type SetRolePermissionsRequest struct {
+	PermissionIDs []uint `json:"permission_ids" binding:"required"`
+}
+
+// SetLogRetentionRequest 修改日志保留设置请求，省略的项保持不变
+type SetLogRetentionRequest struct {
+	MaxSize    *int `json:"max_size" binding:"omitempty,min=1"`    // 单个日志文件最大MB
+	MaxBackups *int `json:"max_backups" binding:"omitempty,min=0"` // 保留的备份数，0表示不限
+	MaxAge     *int `json:"max_age" binding:"omitempty,min=0"`     // 保留天数，0表示不限
+}
+
+// SetLogLevelRequest 修改日志级别请求
+type SetLogLevelRequest struct {
+	Level string `json:"level" binding:"required"` // trace, debug, info, warn, error
+}
+
+// ReadinessReport 就绪检查结果，checks 中每项为 "ok" 或 "failed"
+type ReadinessReport struct {
+	Ready     bool              `json:"ready"`
+	Checks    map[string]string `json:"checks"`
+	Timestamp int64             `json:"timestamp"`
 }
 
 // DeleteFileRequest 删除文件请求
 type DeleteFileRequest struct {
-	Path string `json:"path" binding:"required"`
+	Path      string `json:"path" binding:"required"`
+	Permanent bool   `json:"permanent"` // 为true时跳过回收站直接删除
+}
+
+// DeleteFileResponse 删除文件响应
+type DeleteFileResponse struct {
+	Path      string `json:"path"`
+	Mode      string `json:"mode"` // trash 或 permanent
+	TrashID   string `json:"trash_id,omitempty"`
+	TrashPath string `json:"trash_path,omitempty"`
+}
+
+// TrashItem 回收站条目
+type TrashItem struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"original_path"`
+	IsDirectory  bool      `json:"is_directory"`
+	Size         int64     `json:"size"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	DeletedBy    uint      `json:"deleted_by"`
+}
+
+// RestoreTrashRequest 从回收站恢复请求
+type RestoreTrashRequest struct {
+	ID string `json:"id" binding:"required"`
+}
+
+// BatchDeleteRequest 批量删除请求
+type BatchDeleteRequest struct {
+	Paths     []string `json:"paths"`
+	Permanent bool     `json:"permanent"` // 为true时跳过回收站直接删除
+}
+
+// BatchDeleteResult 单个路径的删除结果
+type BatchDeleteResult struct {
+	Path      string `json:"path"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	Mode      string `json:"mode,omitempty"` // trash 或 permanent
+	TrashID   string `json:"trash_id,omitempty"`
+	TrashPath string `json:"trash_path,omitempty"`
+}
+
+// BatchDeleteResponse 批量删除响应
+type BatchDeleteResponse struct {
+	Results   []BatchDeleteResult `json:"results"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
 }
 
 // RenameFileRequest 重命名文件请求
@@ -275,8 +838,99 @@ type RenameFileRequest struct {
 	NewPath string `json:"new_path" binding:"required"`
 }
 
+// MoveFileRequest 移动文件请求
+type MoveFileRequest struct {
+	Source      string `json:"source" binding:"required"`
+	Destination string `json:"destination" binding:"required"` // 目标完整路径（不是目标目录）
+	Overwrite   bool   `json:"overwrite"`                      // 目标已存在时是否覆盖
+}
+
+// CreateArchiveRequest 创建压缩包请求
+type CreateArchiveRequest struct {
+	Paths  []string `json:"paths" binding:"required,min=1"`
+	Format string   `json:"format"` // zip, targz（默认）, tar
+	Output string   `json:"output"` // 保存到服务器上的完整路径，为空时直接下载
+}
+
+// CreateArchiveResponse 创建压缩包响应
+type CreateArchiveResponse struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// ExtractArchiveRequest 解压请求
+type ExtractArchiveRequest struct {
+	Path      string `json:"path" binding:"required"` // 压缩包路径
+	Dest      string `json:"dest" binding:"required"` // 解压目标目录
+	Overwrite bool   `json:"overwrite"`               // 覆盖已存在的文件，否则跳过
+}
+
+// ExtractArchiveResponse 解压结果
+type ExtractArchiveResponse struct {
+	Format      string `json:"format"`
+	Destination string `json:"destination"`
+	Files       int    `json:"files"`       // 写入的文件数
+	Directories int    `json:"directories"` // 新建的目录数
+	Skipped     int    `json:"skipped"`     // 跳过的条目数（已存在、符号链接或特殊文件）
+}
+
+// CopyFileRequest 复制文件请求
+type CopyFileRequest struct {
+	Source      string `json:"source" binding:"required"`
+	Destination string `json:"destination" binding:"required"` // 目标完整路径（不是目标目录）
+	Overwrite   bool   `json:"overwrite"`                      // 目标已存在时是否覆盖
+}
+
+// ChangePermissionsRequest 修改权限请求，path 和 paths 至少指定一个
+// 权限为3-4位八进制字符串（如 "755"、"0644"），file_mode/dir_mode 未指定时使用 mode
+type ChangePermissionsRequest struct {
+	Path      string   `json:"path"`
+	Paths     []string `json:"paths"`
+	Mode      string   `json:"mode"`
+	FileMode  string   `json:"file_mode"`
+	DirMode   string   `json:"dir_mode"`
+	Recursive bool     `json:"recursive"` // 为true时递归应用到目录下所有条目
+}
+
+// ChangePermissionsResponse 批量修改权限响应
+type ChangePermissionsResponse struct {
+	Changed int      `json:"changed"`
+	Skipped int      `json:"skipped"`
+	Failed  []string `json:"failed,omitempty"`
+}
+
+// ValidateConfigRequest 配置文件语法校验请求
+type ValidateConfigRequest struct {
+	Content string `json:"content"`
+	Type    string `json:"type" binding:"required"` // yaml, json, toml, ini
+}
+
+// ConfigSyntaxError 配置文件语法错误，行列号为0表示未知
+type ConfigSyntaxError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// ValidateConfigResponse 配置文件语法校验响应
+type ValidateConfigResponse struct {
+	Valid  bool                `json:"valid"`
+	Type   string              `json:"type"`
+	Errors []ConfigSyntaxError `json:"errors,omitempty"`
+}
+
 // SaveFileContentRequest 保存文件内容请求
 type SaveFileContentRequest struct {
 	Path    string `json:"path" binding:"required"`
 	Content string `json:"content"`
+	Version  string `json:"version"`  // 读取时返回的版本号，为空时不检查文件是否已被修改
+	Encoding string `json:"encoding"` // 写回使用的编码，为空时沿用原文件编码
+	Force    bool   `json:"force"`    // 为true时忽略版本号强制覆盖
+}
+
+// SaveFileContentResponse 保存文件内容响应
+type SaveFileContentResponse struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Version string `json:"version"` // 保存后的新版本号
 }
\ No newline at end of file
@@ -13,6 +13,7 @@ const (
 	UserStatusInactive UserStatus = 0 // 禁用
 	UserStatusActive   UserStatus = 1 // 启用
 	UserStatusBlocked  UserStatus = 2 // 封禁
+	UserStatusPending  UserStatus = 3 // 待审核
 )
 
 // String 返回用户状态字符串
@@ -24,6 +25,8 @@ func (s UserStatus) String() string {
 		return "启用"
 	case UserStatusBlocked:
 		return "封禁"
+	case UserStatusPending:
+		return "待审核"
 	default:
 		return "未知"
 	}
@@ -44,6 +47,10 @@ type User struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// 登录锁定
+	FailedAttempts int        `json:"failed_attempts" gorm:"default:0"` // 连续登录失败次数
+	LockedUntil    *time.Time `json:"locked_until"`                     // 锁定截止时间
+
 	// 关联关系
 	Roles []Role `json:"roles,omitempty" gorm:"many2many:user_roles;"`
 }
@@ -71,6 +78,16 @@ func (u *User) IsBlocked() bool {
 	return u.Status == UserStatusBlocked
 }
 
+// IsPending 检查用户是否等待管理员审核
+func (u *User) IsPending() bool {
+	return u.Status == UserStatusPending
+}
+
+// IsLocked 检查用户是否处于登录锁定状态
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
 // HasRole 检查用户是否拥有指定角色
 func (u *User) HasRole(roleName string) bool {
 	for _, role := range u.Roles {
@@ -86,10 +103,10 @@ func (u *User) IsAdmin() bool {
 	return u.HasRole(RoleAdmin)
 }
 
-// HasPermission 检查用户是否拥有指定权限
+// HasPermission 检查用户是否拥有指定权限（已禁用的角色不授予权限）
 func (u *User) HasPermission(permissionName string) bool {
 	for _, role := range u.Roles {
-		if role.HasPermission(permissionName) {
+		if role.IsActive() && role.HasPermission(permissionName) {
 			return true
 		}
 	}
@@ -233,11 +250,12 @@ const (
 	PermissionSystemConfig  = "system:config"  // 系统配置
 
 	// 文件管理权限
-	PermissionFileView   = "file:view"   // 查看文件
-	PermissionFileCreate = "file:create" // 创建文件
-	PermissionFileUpdate = "file:update" // 更新文件
-	PermissionFileDelete = "file:delete" // 删除文件
-	PermissionFileUpload = "file:upload" // 上传文件
+	PermissionFileView     = "file:view"     // 查看文件（浏览目录和文件信息）
+	PermissionFileDownload = "file:download" // 下载文件（包括读取文件内容、缩略图和跟踪文件）
+	PermissionFileCreate   = "file:create"   // 创建文件
+	PermissionFileUpdate   = "file:update"   // 更新文件
+	PermissionFileDelete   = "file:delete"   // 删除文件
+	PermissionFileUpload   = "file:upload"   // 上传文件
 
 	// 审计日志权限
 	PermissionAuditView = "audit:view" // 查看审计日志
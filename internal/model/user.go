@@ -2,17 +2,72 @@ package model
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"web-panel-go/internal/config"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 // User 用户模型的辅助方法
 
-// SetPassword 设置密码（加密）
-func (u *User) SetPassword(password string) error {
-	if len(password) < 6 {
-		return errors.New("密码长度不能少于6位")
+// ErrInvalidPassword 密码不满足密码策略，具体原因包装在错误信息中
+var ErrInvalidPassword = errors.New("密码不合法")
+
+// ValidatePassword 按密码策略校验密码，错误信息列出所有未满足的要求；最短长度小于1时按1处理
+func ValidatePassword(password string, policy config.PasswordPolicyConfig) error {
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+
+	minLength := policy.MinLength
+	if minLength < 1 {
+		minLength = 1
+	}
+	var missing []string
+	if utf8.RuneCountInString(password) < minLength {
+		missing = append(missing, fmt.Sprintf("长度不能少于%d个字符", minLength))
+	}
+	if policy.RequireUpper && !upper {
+		missing = append(missing, "需包含大写字母")
+	}
+	if policy.RequireLower && !lower {
+		missing = append(missing, "需包含小写字母")
+	}
+	if policy.RequireDigit && !digit {
+		missing = append(missing, "需包含数字")
+	}
+	if policy.RequireSymbol && !symbol {
+		missing = append(missing, "需包含特殊字符")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidPassword, strings.Join(missing, "；"))
+	}
+
+	if policy.RejectCommon && isCommonPassword(password) {
+		return fmt.Errorf("%w: 密码过于常见，请换一个", ErrInvalidPassword)
+	}
+	return nil
+}
+
+// SetPassword 按密码策略校验后设置密码（加密），创建用户、重置密码、修改密码和初始化管理员都经过此处
+func (u *User) SetPassword(password string, policy config.PasswordPolicyConfig) error {
+	if err := ValidatePassword(password, policy); err != nil {
+		return err
 	}
 
 	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -46,15 +101,17 @@ func (u *User) GetRole() string {
 // ToSafeJSON 返回安全的用户信息（不包含密码）
 func (u *User) ToSafeJSON() map[string]interface{} {
 	return map[string]interface{}{
-		"id":         u.ID,
-		"username":   u.Username,
-		"email":      u.Email,
-		"nickname":   u.Nickname,
-		"avatar":     u.Avatar,
-		"phone":      u.Phone,
-		"status":     u.Status,
-		"last_login": u.LastLogin,
-		"created_at": u.CreatedAt,
-		"updated_at": u.UpdatedAt,
-	}
-}
\ No newline at end of file
+		"id":           u.ID,
+		"username":     u.Username,
+		"email":        u.Email,
+		"nickname":     u.Nickname,
+		"avatar":       u.Avatar,
+		"phone":        u.Phone,
+		"status":       u.Status,
+		"last_login":   u.LastLogin,
+		"is_locked":    u.IsLocked(),
+		"locked_until": u.LockedUntil,
+		"created_at":   u.CreatedAt,
+		"updated_at":   u.UpdatedAt,
+	}
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"web-panel-go/internal/config"
+)
+
+// defaultPolicy 与配置默认值相同的密码策略
+var defaultPolicy = config.PasswordPolicyConfig{
+	MinLength:    8,
+	RequireUpper: true,
+	RequireLower: true,
+	RequireDigit: true,
+	RejectCommon: true,
+}
+
+func TestValidatePassword(t *testing.T) {
+	strict := defaultPolicy
+	strict.RequireSymbol = true
+
+	tests := []struct {
+		name     string
+		password string
+		policy   config.PasswordPolicyConfig
+		valid    bool
+	}{
+		{"满足默认策略", "Passw0rdX", defaultPolicy, true},
+		{"长度不足", "Pa1x", defaultPolicy, false},
+		{"缺少大写字母", "passw0rdx", defaultPolicy, false},
+		{"缺少小写字母", "PASSW0RDX", defaultPolicy, false},
+		{"缺少数字", "Passwordx", defaultPolicy, false},
+		{"常见弱密码不区分大小写", "Password123", defaultPolicy, false},
+		{"要求特殊字符", "Passw0rdX", strict, false},
+		{"包含特殊字符", "Passw0rd!X", strict, true},
+		{"按字符而非字节计算长度", "密码Ab1", config.PasswordPolicyConfig{MinLength: 6}, false},
+		{"空策略仍拒绝空密码", "", config.PasswordPolicyConfig{}, false},
+		{"空策略", "x", config.PasswordPolicyConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePassword(tt.password, tt.policy)
+			if tt.valid && err != nil {
+				t.Fatalf("ValidatePassword(%q) 返回 %v，期望通过", tt.password, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidPassword) {
+				t.Fatalf("ValidatePassword(%q) 返回 %v，期望 ErrInvalidPassword", tt.password, err)
+			}
+		})
+	}
+}
+
+func TestSetPasswordEnforcesPolicy(t *testing.T) {
+	var u User
+	for _, weak := range []string{"admin123", "short", "nouppercase1"} {
+		if err := u.SetPassword(weak, defaultPolicy); !errors.Is(err, ErrInvalidPassword) {
+			t.Fatalf("SetPassword(%q) 返回 %v，期望 ErrInvalidPassword", weak, err)
+		}
+		if u.Password != "" {
+			t.Fatalf("SetPassword(%q) 被拒绝后仍写入了密码", weak)
+		}
+	}
+
+	if err := u.SetPassword("Passw0rdX", defaultPolicy); err != nil {
+		t.Fatalf("SetPassword 失败: %v", err)
+	}
+	if u.Password == "Passw0rdX" || u.CheckPassword("Passw0rdX") != nil {
+		t.Fatal("密码未以 bcrypt 哈希保存")
+	}
+}
@@ -10,10 +10,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// Setup 设置路由
-func Setup(cfg *config.Config, services *service.Services, wsManager *websocket.WebSocketManager) *gin.Engine {
+// Setup 设置路由，返回的停止函数在关闭服务时调用，停止中间件启动的后台清理
+func Setup(cfg *config.Config, services *service.Services, wsManager *websocket.WebSocketManager) (*gin.Engine, func()) {
 	// 设置Gin模式
-	if cfg.System.Mode == "release" {
+	if cfg.System.IsRelease() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
@@ -21,10 +21,28 @@ func Setup(cfg *config.Config, services *service.Services, wsManager *websocket.
 	r := gin.New()
 
 	// 设置基础中间件
-	r.Use(gin.Logger())
-	r.Use(gin.Recovery())
+	r.Use(middleware.LoggerMiddleware(cfg.Log.SlowRequestThreshold))
+	r.Use(middleware.RequestIDMiddleware())
+	r.Use(middleware.RecoveryMiddleware(services.Audit, !cfg.System.IsRelease()))
 	r.Use(middleware.CORS())
 
+	// 限流（未认证请求按IP计数，管理员可按配置豁免或按用户计数）
+	stop := func() {}
+	if cfg.Security.RateLimit.MaxRequests > 0 {
+		var rateLimit gin.HandlerFunc
+		rateLimit, stop = middleware.RateLimitMiddleware(cfg.Security.RateLimit, services.Auth)
+		r.Use(rateLimit)
+	}
+
+	// 记录用户最近的请求，用于关联HTTP与WebSocket活动
+	requestTracker := middleware.NewRequestTracker()
+	r.Use(requestTracker.Middleware())
+
+	// 有活动的会话自动延长过期时间
+	if cfg.Auth.SessionExtend {
+		r.Use(middleware.SessionExtensionMiddleware(services.Auth))
+	}
+
 	// 初始化处理器
 	handlers := handler.NewHandlers(services)
 
@@ -36,9 +54,18 @@ func Setup(cfg *config.Config, services *service.Services, wsManager *websocket.
 	handler.RegisterUserRoutes(api, handlers.User)
 	handler.RegisterSystemRoutes(api, handlers.System)
 	handler.RegisterFileRoutes(api, handlers.File)
+	handler.RegisterAuditRoutes(api, handlers.Audit)
+	handler.RegisterSettingRoutes(api, handlers.Setting)
+	handler.RegisterRoleRoutes(api, handlers.Role)
+	handler.RegisterPermissionRoutes(api, handlers.Permission)
+	handler.RegisterSessionRoutes(api, handlers.Session)
+	handler.RegisterWebSocketRoutes(api, handler.NewWebSocketHandler(wsManager, requestTracker, services.Auth))
+
+	// 健康检查路由（存活/就绪）
+	handler.RegisterHealthRoutes(r, handlers.Health)
 
 	// 注册WebSocket路由
-	r.GET("/ws", middleware.AuthMiddleware(services.Auth), wsManager.HandleWebSocket)
+	r.GET("/ws", middleware.WebSocketAuthMiddleware(services.Auth), wsManager.HandleWebSocket)
 
-	return r
+	return r, stop
 }
\ No newline at end of file
@@ -0,0 +1,221 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+)
+
+// Job 后台任务定义
+type Job struct {
+	Name       string
+	Interval   time.Duration
+	RunOnStart bool          // 启动时立即运行一次
+	Jitter     time.Duration // 每次运行前随机延迟 [0, Jitter)，避免多个任务同时触发
+	Run        func(ctx context.Context) error
+}
+
+// FailureHandler 任务连续失败达到阈值时的回调
+type FailureHandler func(name string, consecutiveFailures int, err error)
+
+// entry 已注册的任务及其运行状态
+type entry struct {
+	job    Job
+	status model.JobStatus
+}
+
+// Scheduler 后台任务调度器
+// 所有任务共享同一个context，Stop时取消并等待正在运行的任务结束
+type Scheduler struct {
+	mutex   sync.RWMutex
+	entries map[string]*entry
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	failureThreshold int
+	onFailure        FailureHandler
+}
+
+// New 创建调度器
+func New() *Scheduler {
+	return &Scheduler{entries: make(map[string]*entry)}
+}
+
+// SetFailureHandler 设置连续失败回调，threshold为0表示不回调
+func (s *Scheduler) SetFailureHandler(threshold int, handler FailureHandler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failureThreshold = threshold
+	s.onFailure = handler
+}
+
+// Register 注册任务，调度器已启动时立即开始调度
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return errors.New("任务名称和执行函数不能为空")
+	}
+	if job.Interval <= 0 {
+		return fmt.Errorf("任务 %s 的运行间隔无效: %s", job.Name, job.Interval)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.entries[job.Name]; exists {
+		return fmt.Errorf("任务 %s 已注册", job.Name)
+	}
+
+	e := &entry{
+		job: job,
+		status: model.JobStatus{
+			Name:     job.Name,
+			Interval: job.Interval.String(),
+			Status:   model.JobStatusPending,
+		},
+	}
+	s.entries[job.Name] = e
+
+	if s.ctx != nil {
+		s.launch(e)
+	}
+	return nil
+}
+
+// Start 启动所有已注册的任务，ctx取消或调用Stop时停止
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.ctx != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, e := range s.entries {
+		s.launch(e)
+	}
+}
+
+// Stop 停止所有任务并等待正在运行的任务结束
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	cancel := s.cancel
+	s.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+// launch 启动任务协程，调用方需持有锁
+func (s *Scheduler) launch(e *entry) {
+	ctx := s.ctx
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.loop(ctx, e)
+	}()
+}
+
+// loop 按间隔运行任务
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	if e.job.RunOnStart {
+		s.run(ctx, e)
+	}
+
+	for {
+		delay := e.job.Interval
+		if e.job.Jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(e.job.Jitter)))
+		}
+		s.setNextRun(e, time.Now().Add(delay))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.run(ctx, e)
+		}
+	}
+}
+
+// setNextRun 记录下次运行时间
+func (s *Scheduler) setNextRun(e *entry, next time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e.status.NextRun = &next
+}
+
+// run 运行一次任务并记录状态
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	s.mutex.Lock()
+	e.status.Status = model.JobStatusRunning
+	s.mutex.Unlock()
+
+	start := time.Now()
+	err := safeCall(ctx, e.job.Run)
+	duration := time.Since(start)
+
+	s.mutex.Lock()
+	e.status.LastRun = &start
+	e.status.LastDurationMs = duration.Milliseconds()
+	e.status.RunCount++
+	if err != nil {
+		e.status.Status = model.JobStatusFailed
+		e.status.LastError = err.Error()
+		e.status.FailureCount++
+		e.status.ConsecutiveFailures++
+	} else {
+		e.status.Status = model.JobStatusSuccess
+		e.status.LastError = ""
+		e.status.ConsecutiveFailures = 0
+	}
+	failures := e.status.ConsecutiveFailures
+	threshold, onFailure := s.failureThreshold, s.onFailure
+	s.mutex.Unlock()
+
+	if err == nil {
+		return
+	}
+
+	logger.Error("后台任务执行失败", "job", e.job.Name, "error", err, "consecutive_failures", failures)
+
+	// 连续失败达到阈值时只回调一次，恢复成功后重新计数
+	if onFailure != nil && threshold > 0 && failures == threshold {
+		onFailure(e.job.Name, failures, err)
+	}
+}
+
+// safeCall 执行任务函数，捕获panic
+func safeCall(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("任务panic: %v", recovered)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Jobs 获取所有任务的运行状态
+func (s *Scheduler) Jobs() []model.JobStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	jobs := make([]model.JobStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		jobs = append(jobs, e.status)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	// 调度器直接调用全局日志器，测试中丢弃输出
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// noop 什么都不做的任务函数
+func noop(context.Context) error { return nil }
+
+// waitFor 等待条件成立，超时则测试失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+	}
+}
+
+func TestRegisterValidatesJobs(t *testing.T) {
+	s := New()
+	tests := []Job{
+		{Interval: time.Second, Run: noop},
+		{Name: "no-func", Interval: time.Second},
+		{Name: "no-interval", Run: noop},
+		{Name: "negative", Interval: -time.Second, Run: noop},
+	}
+	for _, job := range tests {
+		if err := s.Register(job); err == nil {
+			t.Fatalf("注册 %+v 应返回错误", job)
+		}
+	}
+
+	if err := s.Register(Job{Name: "cleanup", Interval: time.Minute, Run: noop}); err != nil {
+		t.Fatalf("注册任务失败: %v", err)
+	}
+	if err := s.Register(Job{Name: "cleanup", Interval: time.Minute, Run: noop}); err == nil {
+		t.Fatal("重复注册应返回错误")
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 1 || jobs[0].Name != "cleanup" || jobs[0].Interval != "1m0s" || jobs[0].Status != model.JobStatusPending {
+		t.Fatalf("未启动时的任务状态 = %+v", jobs)
+	}
+}
+
+func TestJobsRunAtInterval(t *testing.T) {
+	s := New()
+	var ticks, starts, late atomic.Int32
+	s.Register(Job{Name: "tick", Interval: 10 * time.Millisecond, Jitter: 5 * time.Millisecond, Run: func(context.Context) error {
+		ticks.Add(1)
+		return nil
+	}})
+	s.Register(Job{Name: "on-start", Interval: time.Hour, RunOnStart: true, Run: func(context.Context) error {
+		starts.Add(1)
+		return nil
+	}})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	// 启动后注册的任务立即开始调度
+	s.Register(Job{Name: "late", Interval: 10 * time.Millisecond, Run: func(context.Context) error {
+		late.Add(1)
+		return nil
+	}})
+
+	waitFor(t, "任务按间隔运行", func() bool { return ticks.Load() >= 3 && late.Load() >= 3 })
+	if got := starts.Load(); got != 1 {
+		t.Fatalf("RunOnStart 任务运行 %d 次，期望启动时运行 1 次", got)
+	}
+
+	for _, job := range s.Jobs() {
+		if job.RunCount == 0 || job.LastRun == nil || job.NextRun == nil || job.Status != model.JobStatusSuccess {
+			t.Fatalf("任务 %s 状态 = %+v", job.Name, job)
+		}
+	}
+}
+
+func TestStopCancelsAndWaitsForRunningJobs(t *testing.T) {
+	s := New()
+	started := make(chan struct{})
+	var finished atomic.Bool
+	s.Register(Job{Name: "long", Interval: time.Hour, RunOnStart: true, Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		finished.Store(true)
+		return ctx.Err()
+	}})
+
+	var runs atomic.Int32
+	s.Register(Job{Name: "tick", Interval: 5 * time.Millisecond, Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+
+	s.Start(context.Background())
+	<-started
+	s.Stop()
+	if !finished.Load() {
+		t.Fatal("Stop 未等待正在运行的任务结束")
+	}
+
+	// 停止后不再运行
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	if got := runs.Load(); got != stopped {
+		t.Fatalf("停止后任务又运行了 %d 次", got-stopped)
+	}
+}
+
+func TestFailuresAndPanicsAreRecorded(t *testing.T) {
+	s := New()
+	var alerts atomic.Int32
+	s.SetFailureHandler(2, func(name string, failures int, err error) {
+		if name != "panicky" || failures != 2 {
+			t.Errorf("失败回调参数 %s, %d", name, failures)
+		}
+		alerts.Add(1)
+	})
+	s.Register(Job{Name: "panicky", Interval: 5 * time.Millisecond, RunOnStart: true, Run: func(context.Context) error {
+		panic("boom")
+	}})
+	s.Start(context.Background())
+	waitFor(t, "任务连续失败", func() bool { return s.Jobs()[0].ConsecutiveFailures >= 4 })
+	s.Stop()
+
+	job := s.Jobs()[0]
+	if job.Status != model.JobStatusFailed || job.LastError != "任务panic: boom" {
+		t.Fatalf("panic 任务状态 = %+v", job)
+	}
+	if got := alerts.Load(); got != 1 {
+		t.Fatalf("失败回调 %d 次，期望达到阈值时回调 1 次", got)
+	}
+}
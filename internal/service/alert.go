@@ -0,0 +1,147 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// alertState 单项指标的告警状态
+type alertState struct {
+	active bool // 是否处于告警中
+	streak int  // 连续满足切换条件（未告警时超过阈值，告警中低于恢复线）的采样次数
+}
+
+// alertMetric 参与检查的指标
+type alertMetric struct {
+	key   string
+	name  string
+	rule  config.AlertRule
+	value func(stats *model.SystemStats) float64
+}
+
+// AlertService 资源使用率告警服务
+// 每次采集系统统计后检查CPU、内存和磁盘使用率，连续超过阈值时广播告警并记录审计日志，
+// 回落到阈值减去回差以下后发送恢复通知
+type AlertService struct {
+	db       *gorm.DB
+	enabled  bool
+	metrics  []alertMetric
+	notifier NotificationBroadcaster
+
+	mutex  sync.Mutex
+	states map[string]*alertState
+}
+
+// NewAlertService 创建资源告警服务实例
+func NewAlertService(db *gorm.DB, cfg *config.Config) *AlertService {
+	return &AlertService{
+		db:      db,
+		enabled: cfg.Alerts.Enabled,
+		metrics: []alertMetric{
+			{key: "cpu", name: "CPU", rule: cfg.Alerts.CPU, value: func(stats *model.SystemStats) float64 { return stats.CPU.UsagePercent }},
+			{key: "memory", name: "内存", rule: cfg.Alerts.Memory, value: func(stats *model.SystemStats) float64 { return stats.Memory.UsedPercent }},
+			{key: "disk", name: "磁盘", rule: cfg.Alerts.Disk, value: func(stats *model.SystemStats) float64 { return stats.Disk.UsedPercent }},
+		},
+		states: make(map[string]*alertState),
+	}
+}
+
+// SetNotifier 设置通知广播器
+func (s *AlertService) SetNotifier(notifier NotificationBroadcaster) {
+	s.notifier = notifier
+}
+
+// Evaluate 用一次采样更新各指标的告警状态，状态切换时发送通知
+func (s *AlertService) Evaluate(stats *model.SystemStats) {
+	if !s.enabled || stats == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, metric := range s.metrics {
+		rule := metric.rule
+		if rule.Threshold <= 0 {
+			continue
+		}
+		samples := rule.Samples
+		if samples < 1 {
+			samples = 1
+		}
+
+		state, ok := s.states[metric.key]
+		if !ok {
+			state = &alertState{}
+			s.states[metric.key] = state
+		}
+		value := metric.value(stats)
+
+		// 告警中回落到恢复线以下、未告警时超过阈值才累计，其余情况重新计数
+		var crossing bool
+		if state.active {
+			crossing = value < rule.Threshold-rule.Hysteresis
+		} else {
+			crossing = value > rule.Threshold
+		}
+		if !crossing {
+			state.streak = 0
+			continue
+		}
+		state.streak++
+		if state.streak < samples {
+			continue
+		}
+
+		state.active = !state.active
+		state.streak = 0
+		if state.active {
+			s.raise(metric, value)
+		} else {
+			s.resolve(metric, value)
+		}
+	}
+}
+
+// raise 发送告警通知并记录审计日志
+func (s *AlertService) raise(metric alertMetric, value float64) {
+	title := fmt.Sprintf("%s使用率过高", metric.name)
+	details := fmt.Sprintf("%s使用率 %.1f%% 超过阈值 %.1f%%", metric.name, value, metric.rule.Threshold)
+	logger.Warn("资源告警", "metric", metric.key, "value", value, "threshold", metric.rule.Threshold)
+	s.record("resource_alert", details, "failed")
+
+	if s.notifier != nil {
+		s.notifier.BroadcastNotification(title, details, metric.rule.Level)
+	}
+}
+
+// resolve 发送恢复通知并记录审计日志
+func (s *AlertService) resolve(metric alertMetric, value float64) {
+	title := fmt.Sprintf("%s使用率已恢复", metric.name)
+	details := fmt.Sprintf("%s使用率 %.1f%% 已回落到 %.1f%% 以下", metric.name, value, metric.rule.Threshold-metric.rule.Hysteresis)
+	logger.Info("资源告警恢复", "metric", metric.key, "value", value, "threshold", metric.rule.Threshold)
+	s.record("resource_recovered", details, "success")
+
+	if s.notifier != nil {
+		s.notifier.BroadcastNotification(title, details, "success")
+	}
+}
+
+// record 记录告警审计日志
+func (s *AlertService) record(action, details, status string) {
+	auditLog := &model.AuditLog{
+		Action:   action,
+		Resource: "system",
+		Details:  details,
+		Status:   status,
+	}
+	if err := s.db.Create(auditLog).Error; err != nil {
+		logger.Error("记录审计日志失败", "error", err)
+	}
+}
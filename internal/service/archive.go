@@ -0,0 +1,668 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+)
+
+// 打包格式
+const (
+	ArchiveFormatTar   = "tar"
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+)
+
+// 打包和解压的参数校验错误
+var (
+	ErrArchiveTooLarge          = errors.New("打包文件总大小超出上限")
+	ErrUnsupportedArchiveFormat = errors.New("不支持的打包格式")
+	ErrUnknownArchiveFormat     = errors.New("无法识别的压缩包格式")
+	ErrNoArchivePaths           = errors.New("未指定要打包的路径")
+	ErrArchiveOutputInSource    = errors.New("输出文件不能位于待打包的目录中")
+)
+
+// NormalizeArchiveFormat 规范化打包格式，不支持时返回错误
+func NormalizeArchiveFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "tar.gz", "targz", "tgz":
+		return ArchiveFormatTarGz, nil
+	case "tar":
+		return ArchiveFormatTar, nil
+	case "zip":
+		return ArchiveFormatZip, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedArchiveFormat, format)
+	}
+}
+
+// PrepareArchive 校验待打包路径并检查总大小是否超出上限
+func (f *FileService) PrepareArchive(paths []string) (int64, error) {
+	if len(paths) == 0 {
+		return 0, ErrNoArchivePaths
+	}
+
+	var total int64
+	for _, path := range paths {
+		if !f.isValidPath(path) {
+			return 0, ErrInvalidPath
+		}
+		if _, err := os.Lstat(path); err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrFileNotFound, path)
+		}
+
+		// WalkDir不跟随符号链接，只统计普通文件
+		err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.Type().IsRegular() {
+				if info, err := d.Info(); err == nil {
+					total += info.Size()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if limit := f.config.File.MaxArchiveSize; limit > 0 && total > limit {
+		return total, fmt.Errorf("%w: %d > %d bytes", ErrArchiveTooLarge, total, limit)
+	}
+	return total, nil
+}
+
+// WriteArchive 按格式将路径打包写入w
+func (f *FileService) WriteArchive(w io.Writer, paths []string, format string, userID uint, clientIP, userAgent string) error {
+	if format == ArchiveFormatZip {
+		return f.WriteZipArchive(w, paths, userID, clientIP, userAgent)
+	}
+	return f.WriteTarArchive(w, paths, format, userID, clientIP, userAgent)
+}
+
+// CreateArchive 将路径打包保存到服务器上的 output 文件
+// 先写入同目录下的临时文件，完成后再重命名，避免留下不完整的压缩包
+func (f *FileService) CreateArchive(paths []string, format, output string, userID uint, clientIP, userAgent string) (*model.CreateArchiveResponse, error) {
+	if !f.isValidPath(output) {
+		f.logAuditAction(userID, "create_archive", "file", fmt.Sprintf("创建压缩包失败: 无效路径 %s", output), clientIP, userAgent, "failed")
+		return nil, ErrInvalidPath
+	}
+	output = filepath.Clean(output)
+
+	total, err := f.PrepareArchive(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	// 输出文件位于待打包目录中时会把自身打包进去
+	for _, path := range paths {
+		root := filepath.Clean(path)
+		if output == root || strings.HasPrefix(output, root+string(filepath.Separator)) {
+			return nil, ErrArchiveOutputInSource
+		}
+	}
+
+	dir := filepath.Dir(output)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, ErrTargetDirNotFound
+	}
+	if _, err := os.Lstat(output); err == nil {
+		f.logAuditAction(userID, "create_archive", "file", fmt.Sprintf("创建压缩包失败: 目标已存在 %s", output), clientIP, userAgent, "failed")
+		return nil, ErrTargetExists
+	}
+	if err := f.diskGuard.Check(dir, total); err != nil {
+		f.logAuditAction(userID, "create_archive", "file", fmt.Sprintf("创建压缩包失败: 磁盘空间不足 %s", output), clientIP, userAgent, "failed")
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(output)+".tmp-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建文件失败: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	err = f.WriteArchive(tmp, paths, format, userID, clientIP, userAgent)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, output)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		f.logAuditAction(userID, "create_archive", "file", fmt.Sprintf("创建压缩包失败: %s, 错误: %v", output, err), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("创建压缩包失败: %w", err)
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+
+	f.logAuditAction(userID, "create_archive", "file", fmt.Sprintf("创建压缩包(%s): %s -> %s (大小: %d bytes)", format, strings.Join(paths, ", "), output, info.Size()), clientIP, userAgent, "success")
+	logger.Info("压缩包创建成功", "output", output, "format", format, "size", info.Size(), "user_id", userID)
+	return &model.CreateArchiveResponse{
+		Path: output,
+		Size: info.Size(),
+	}, nil
+}
+
+// WriteZipArchive 将路径打包为zip写入w，符号链接按zip约定以链接目标作为内容写入
+func (f *FileService) WriteZipArchive(w io.Writer, paths []string, userID uint, clientIP, userAgent string) error {
+	zw := zip.NewWriter(w)
+	// 仅用于出错时的清理，成功时在下面显式关闭并检查错误
+	defer zw.Close()
+
+	var count int
+	for _, root := range paths {
+		base := filepath.Dir(filepath.Clean(root))
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// 跳过无法读取的条目
+				logger.Warn("打包时跳过无法读取的文件", "path", path, "error", err)
+				return nil
+			}
+
+			name, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			if err := addZipEntry(zw, path, filepath.ToSlash(name)); err != nil {
+				return err
+			}
+			count++
+			return nil
+		})
+		if err != nil {
+			f.logAuditAction(userID, "download_archive", "file", fmt.Sprintf("打包下载失败: %s, 错误: %v", strings.Join(paths, ", "), err), clientIP, userAgent, "failed")
+			return fmt.Errorf("打包失败: %w", err)
+		}
+	}
+
+	// 关闭时才写入中央目录，失败则压缩包不完整
+	if err := zw.Close(); err != nil {
+		f.logAuditAction(userID, "download_archive", "file", fmt.Sprintf("打包下载失败: %s, 错误: %v", strings.Join(paths, ", "), err), clientIP, userAgent, "failed")
+		return fmt.Errorf("打包失败: %w", err)
+	}
+
+	f.logAuditAction(userID, "download_archive", "file", fmt.Sprintf("打包下载(%s): %s (条目: %d)", ArchiveFormatZip, strings.Join(paths, ", "), count), clientIP, userAgent, "success")
+	return nil
+}
+
+// addZipEntry 写入单个zip条目
+func addZipEntry(zw *zip.Writer, path, name string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	} else {
+		header.Method = zip.Deflate
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err != nil {
+			return nil
+		}
+		header.Method = zip.Store
+		writer, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(writer, link)
+		return err
+
+	case info.IsDir():
+		_, err := zw.CreateHeader(header)
+		return err
+
+	case info.Mode().IsRegular():
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		writer, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyN(writer, file, info.Size())
+		return err
+	}
+
+	// 跳过设备文件、管道等特殊文件
+	return nil
+}
+
+// WriteTarArchive 将路径打包为tar/tar.gz写入w
+// 保留权限、属主和修改时间；符号链接作为链接写入，不跟随也不读取目标内容
+func (f *FileService) WriteTarArchive(w io.Writer, paths []string, format string, userID uint, clientIP, userAgent string) error {
+	// 延迟关闭仅用于出错时的清理，成功时在下面按 tar、gzip 的顺序显式关闭并检查错误
+	var gw *gzip.Writer
+	var tw *tar.Writer
+	if format == ArchiveFormatTarGz {
+		gw = gzip.NewWriter(w)
+		defer gw.Close()
+		tw = tar.NewWriter(gw)
+	} else {
+		tw = tar.NewWriter(w)
+	}
+	defer tw.Close()
+
+	var count int
+	for _, root := range paths {
+		base := filepath.Dir(filepath.Clean(root))
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// 跳过无法读取的条目
+				logger.Warn("打包时跳过无法读取的文件", "path", path, "error", err)
+				return nil
+			}
+
+			name, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			if err := addTarEntry(tw, path, filepath.ToSlash(name)); err != nil {
+				return err
+			}
+			count++
+			return nil
+		})
+		if err != nil {
+			f.logAuditAction(userID, "download_archive", "file", fmt.Sprintf("打包下载失败: %s, 错误: %v", strings.Join(paths, ", "), err), clientIP, userAgent, "failed")
+			return fmt.Errorf("打包失败: %w", err)
+		}
+	}
+
+	// 关闭时才写入tar结束块和gzip尾部，失败则压缩包不完整
+	err := tw.Close()
+	if err == nil && gw != nil {
+		err = gw.Close()
+	}
+	if err != nil {
+		f.logAuditAction(userID, "download_archive", "file", fmt.Sprintf("打包下载失败: %s, 错误: %v", strings.Join(paths, ", "), err), clientIP, userAgent, "failed")
+		return fmt.Errorf("打包失败: %w", err)
+	}
+
+	f.logAuditAction(userID, "download_archive", "file", fmt.Sprintf("打包下载(%s): %s (条目: %d)", format, strings.Join(paths, ", "), count), clientIP, userAgent, "success")
+	return nil
+}
+
+// addTarEntry 写入单个tar条目
+func addTarEntry(tw *tar.Writer, path, name string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(path); err != nil {
+			return nil
+		}
+	}
+
+	// FileInfoHeader 会带上权限位以及unix下的uid/gid和用户名/组名
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return nil
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.CopyN(tw, file, info.Size())
+	return err
+}
+
+// ErrUnsafeArchiveEntry 压缩包中的条目解压后会落在目标目录之外
+var ErrUnsafeArchiveEntry = errors.New("压缩包包含不安全的路径")
+
+// ErrArchiveEntrySize 条目解压出的数据超出其声明的大小
+var ErrArchiveEntrySize = errors.New("压缩包条目的实际大小超出声明")
+
+// gzipMagic、zipMagic 用于识别压缩包格式的文件头
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// detectArchiveFormat 根据文件头识别压缩包格式，无法识别时按扩展名判断
+func detectArchiveFormat(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	header := make([]byte, 4)
+	n, _ := io.ReadFull(file, header)
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, zipMagic):
+		return ArchiveFormatZip, nil
+	case bytes.HasPrefix(header, gzipMagic):
+		return ArchiveFormatTarGz, nil
+	}
+
+	name := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return ArchiveFormatZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ArchiveFormatTarGz, nil
+	case strings.HasSuffix(name, ".tar"):
+		return ArchiveFormatTar, nil
+	}
+	return "", ErrUnknownArchiveFormat
+}
+
+// archiveExtractor 解压时的公共状态
+type archiveExtractor struct {
+	dest      string
+	overwrite bool
+	result    *model.ExtractArchiveResponse
+}
+
+// targetPath 计算条目的解压路径，超出目标目录时返回 ErrUnsafeArchiveEntry
+func (e *archiveExtractor) targetPath(name string) (string, error) {
+	target := filepath.Join(e.dest, filepath.FromSlash(name))
+	if target != e.dest && !strings.HasPrefix(target, e.dest+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeArchiveEntry, name)
+	}
+	return target, nil
+}
+
+// checkResolved 展开 path 中已存在部分的符号链接，确认真实路径仍在目标目录之内
+// 目标目录中已有的符号链接可能指向外部，MkdirAll 和 OpenFile 会跟随这些链接
+func (e *archiveExtractor) checkResolved(path string) error {
+	real, err := evalSymlinksPartial(path)
+	if err != nil {
+		return err
+	}
+	if !isWithinDir(real, e.dest) {
+		return fmt.Errorf("%w: %s", ErrUnsafeArchiveEntry, path)
+	}
+	return nil
+}
+
+// writeDir 创建目录条目
+func (e *archiveExtractor) writeDir(target string, mode os.FileMode) error {
+	if err := e.checkResolved(target); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(target); err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("目标已存在且不是目录: %s", target)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(target, mode.Perm()|0700); err != nil {
+		return err
+	}
+	e.result.Directories++
+	return nil
+}
+
+// writeFile 写入普通文件条目，最多写入 size 字节，数据超出 size 时删除已写入的文件并返回 ErrArchiveEntrySize
+func (e *archiveExtractor) writeFile(target string, mode os.FileMode, size int64, r io.Reader) error {
+	if err := e.checkResolved(filepath.Dir(target)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	if info, err := os.Lstat(target); err == nil {
+		if !e.overwrite || info.IsDir() {
+			e.result.Skipped++
+			return nil
+		}
+		// 先删除再创建，避免写入指向目标目录外的符号链接
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return err
+	}
+	// 多读一个字节用于判断数据是否超出声明的大小，不信任条目头中的大小
+	n, err := io.Copy(file, io.LimitReader(r, size+1))
+	if err == nil && n > size {
+		err = fmt.Errorf("%w: %s", ErrArchiveEntrySize, target)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+	e.result.Files++
+	return nil
+}
+
+// ExtractArchive 把 zip/tar.gz/tar 压缩包解压到 dest
+// 任何条目解压后超出 dest 时中止整个解压；压缩包中的符号链接和特殊文件会被跳过
+func (f *FileService) ExtractArchive(archivePath, dest string, overwrite bool, userID uint, clientIP, userAgent string) (*model.ExtractArchiveResponse, error) {
+	if !f.isValidPath(archivePath) || !f.isValidPath(dest) {
+		f.logAuditAction(userID, "extract_archive", "file", fmt.Sprintf("解压失败: 无效路径 %s -> %s", archivePath, dest), clientIP, userAgent, "failed")
+		return nil, ErrInvalidPath
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil || info.IsDir() {
+		f.logAuditAction(userID, "extract_archive", "file", fmt.Sprintf("解压失败: 文件不存在 %s", archivePath), clientIP, userAgent, "failed")
+		return nil, ErrFileNotFound
+	}
+
+	format, err := detectArchiveFormat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err = filepath.Abs(filepath.Clean(dest))
+	if err != nil {
+		return nil, ErrInvalidPath
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	// 按真实路径判断条目是否在目标目录之内
+	if real, err := filepath.EvalSymlinks(dest); err == nil {
+		dest = real
+	}
+
+	extractor := &archiveExtractor{
+		dest:      dest,
+		overwrite: overwrite,
+		result:    &model.ExtractArchiveResponse{Format: format, Destination: dest},
+	}
+
+	// 先完整校验所有条目路径，确保出现不安全路径时不会写入任何文件
+	if format == ArchiveFormatZip {
+		err = f.extractZip(archivePath, extractor)
+	} else {
+		err = f.extractTar(archivePath, format, extractor)
+	}
+	if err != nil {
+		f.logAuditAction(userID, "extract_archive", "file", fmt.Sprintf("解压失败: %s -> %s, 错误: %v", archivePath, dest, err), clientIP, userAgent, "failed")
+		if errors.Is(err, ErrUnsafeArchiveEntry) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("解压失败: %w", err)
+	}
+
+	result := extractor.result
+	f.logAuditAction(userID, "extract_archive", "file", fmt.Sprintf("解压文件: %s -> %s (文件: %d, 目录: %d, 跳过: %d)", archivePath, dest, result.Files, result.Directories, result.Skipped), clientIP, userAgent, "success")
+	logger.Info("压缩包解压成功", "archive", archivePath, "dest", dest, "files", result.Files, "directories", result.Directories, "user_id", userID)
+	return result, nil
+}
+
+// extractZip 解压zip压缩包
+func (f *FileService) extractZip(archivePath string, e *archiveExtractor) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// 解压前检查所有条目路径和解压后总大小
+	var total int64
+	for _, entry := range reader.File {
+		if _, err := e.targetPath(entry.Name); err != nil {
+			return err
+		}
+		if entry.UncompressedSize64 > math.MaxInt64 {
+			return fmt.Errorf("%w: %s", ErrArchiveEntrySize, entry.Name)
+		}
+		total += int64(entry.UncompressedSize64)
+	}
+	if err := f.diskGuard.Check(e.dest, total); err != nil {
+		return err
+	}
+
+	for _, entry := range reader.File {
+		target, _ := e.targetPath(entry.Name)
+		mode := entry.Mode()
+
+		switch {
+		case mode.IsDir():
+			if err := e.writeDir(target, mode); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := entry.Open()
+			if err != nil {
+				return err
+			}
+			err = e.writeFile(target, mode, int64(entry.UncompressedSize64), rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			// 符号链接、设备文件等不解压
+			e.result.Skipped++
+		}
+	}
+	return nil
+}
+
+// extractTar 解压tar/tar.gz压缩包，tar无法随机访问，需要读取两遍：第一遍校验路径，第二遍写入
+func (f *FileService) extractTar(archivePath, format string, e *archiveExtractor) error {
+	var total int64
+	err := readTar(archivePath, format, func(header *tar.Header, _ io.Reader) error {
+		if _, err := e.targetPath(header.Name); err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg {
+			total += header.Size
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := f.diskGuard.Check(e.dest, total); err != nil {
+		return err
+	}
+
+	return readTar(archivePath, format, func(header *tar.Header, r io.Reader) error {
+		target, err := e.targetPath(header.Name)
+		if err != nil {
+			return err
+		}
+		mode := header.FileInfo().Mode()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			return e.writeDir(target, mode)
+		case tar.TypeReg:
+			return e.writeFile(target, mode, header.Size, r)
+		default:
+			// 符号链接、硬链接、设备文件等不解压
+			e.result.Skipped++
+			return nil
+		}
+	})
+}
+
+// readTar 依次读取tar条目
+func readTar(archivePath, format string, fn func(header *tar.Header, r io.Reader) error) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if format == ArchiveFormatTarGz {
+		gr, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(header, tr); err != nil {
+			return err
+		}
+	}
+}
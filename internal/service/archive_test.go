@@ -0,0 +1,224 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/model"
+)
+
+// testArchiveEntry 测试压缩包中的条目，Name 以 / 结尾时为目录
+type testArchiveEntry struct {
+	Name string
+	Body string
+}
+
+// writeTestZip 在 dir 中创建zip压缩包
+func writeTestZip(t *testing.T, dir string, entries ...testArchiveEntry) string {
+	t.Helper()
+	path := filepath.Join(dir, "test.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entry.Body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeTestTar 在 dir 中创建tar压缩包
+func writeTestTar(t *testing.T, dir string, entries ...testArchiveEntry) string {
+	t.Helper()
+	path := filepath.Join(dir, "test.tar")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tw := tar.NewWriter(file)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.Name, Mode: 0644, Size: int64(len(entry.Body)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(entry.Name, "/") {
+			header.Mode, header.Size, header.Typeflag = 0755, 0, tar.TypeDir
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.Body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// assertEmptyDir 确认目录中没有任何条目
+func assertEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("%s 中出现了 %d 个条目，期望为空", dir, len(entries))
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	archive := writeTestZip(t, root,
+		testArchiveEntry{Name: "docs/"},
+		testArchiveEntry{Name: "docs/readme.txt", Body: "hello"},
+		testArchiveEntry{Name: "top.txt", Body: "top"},
+	)
+	dest := filepath.Join(root, "out")
+
+	result, err := f.ExtractArchive(archive, dest, false, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("解压失败: %v", err)
+	}
+	if result.Files != 2 || result.Directories != 1 {
+		t.Fatalf("解压结果 files=%d dirs=%d，期望 2 和 1", result.Files, result.Directories)
+	}
+	data, err := os.ReadFile(filepath.Join(dest, "docs", "readme.txt"))
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("解压出的文件内容 = %q, %v", data, err)
+	}
+
+	var logs int64
+	f.db.Model(&model.AuditLog{}).Where("action = ? AND status = ?", "extract_archive", "success").Count(&logs)
+	if logs != 1 {
+		t.Fatalf("extract_archive 审计日志 %d 条，期望 1 条", logs)
+	}
+}
+
+func TestExtractArchiveRejectsZipSlip(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	archive := writeTestZip(t, root,
+		testArchiveEntry{Name: "ok.txt", Body: "ok"},
+		testArchiveEntry{Name: "../evil.txt", Body: "evil"},
+	)
+	dest := filepath.Join(root, "out")
+
+	_, err := f.ExtractArchive(archive, dest, false, admin.ID, "127.0.0.1", "test")
+	if !errors.Is(err, ErrUnsafeArchiveEntry) {
+		t.Fatalf("解压返回 %v，期望 ErrUnsafeArchiveEntry", err)
+	}
+	assertEmptyDir(t, dest)
+	if _, err := os.Stat(filepath.Join(root, "evil.txt")); !os.IsNotExist(err) {
+		t.Fatal("目标目录外被写入了 evil.txt")
+	}
+}
+
+func TestExtractArchiveRejectsSymlinkedParent(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, dir string, entries ...testArchiveEntry) string
+		entry testArchiveEntry
+	}{
+		{"zip文件", writeTestZip, testArchiveEntry{Name: "link/x.txt", Body: "x"}},
+		{"zip嵌套目录", writeTestZip, testArchiveEntry{Name: "link/sub/x.txt", Body: "x"}},
+		{"zip目录", writeTestZip, testArchiveEntry{Name: "link/sub/"}},
+		{"tar文件", writeTestTar, testArchiveEntry{Name: "link/x.txt", Body: "x"}},
+		{"tar目录", writeTestTar, testArchiveEntry{Name: "link/sub/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, root, admin := newTestFileService(t)
+			outside := t.TempDir()
+			dest := filepath.Join(root, "out")
+			mustMkdir(t, dest)
+			// 目标目录中已有指向外部的符号链接
+			mustSymlink(t, outside, filepath.Join(dest, "link"))
+			archive := tt.write(t, root, tt.entry)
+
+			_, err := f.ExtractArchive(archive, dest, true, admin.ID, "127.0.0.1", "test")
+			if !errors.Is(err, ErrUnsafeArchiveEntry) {
+				t.Fatalf("解压返回 %v，期望 ErrUnsafeArchiveEntry", err)
+			}
+			assertEmptyDir(t, outside)
+		})
+	}
+}
+
+func TestArchiveWriteFileCapsEntrySize(t *testing.T) {
+	dest := t.TempDir()
+	e := &archiveExtractor{dest: dest, result: &model.ExtractArchiveResponse{}}
+	target := filepath.Join(dest, "bomb.bin")
+
+	err := e.writeFile(target, 0644, 4, strings.NewReader("more than four bytes"))
+	if !errors.Is(err, ErrArchiveEntrySize) {
+		t.Fatalf("writeFile 返回 %v，期望 ErrArchiveEntrySize", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatal("超出声明大小的条目应被删除")
+	}
+
+	if err := e.writeFile(target, 0644, 4, strings.NewReader("four")); err != nil {
+		t.Fatalf("大小相符的条目写入失败: %v", err)
+	}
+	if e.result.Files != 1 {
+		t.Fatalf("Files = %d，期望 1", e.result.Files)
+	}
+}
+
+// shortWriter 写入 limit 字节后返回错误，模拟磁盘写满
+type shortWriter struct {
+	limit   int
+	written int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if w.written+len(p) > w.limit {
+		return 0, errors.New("磁盘已满")
+	}
+	w.written += len(p)
+	return len(p), nil
+}
+
+func TestWriteArchiveReportsCloseErrors(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	path := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 条目写入缓冲区成功，关闭时写入中央目录或尾部失败
+	tests := []struct {
+		format string
+		limit  int
+	}{
+		{ArchiveFormatZip, 0},
+		{ArchiveFormatTarGz, 10},
+		{ArchiveFormatTar, 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			w := &shortWriter{limit: tt.limit}
+			if err := f.WriteArchive(w, []string{path}, tt.format, admin.ID, "127.0.0.1", "test"); err == nil {
+				t.Fatalf("关闭失败时应返回错误（已写入 %d 字节）", w.written)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AuditService 审计日志服务
+type AuditService struct {
+	db *gorm.DB
+}
+
+// ErrAuditLogNotFound 审计日志不存在
+var ErrAuditLogNotFound = errors.New("审计日志不存在")
+
+// NewAuditService 创建审计日志服务实例
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// LogPanic 记录请求处理过程中发生的panic，userID 为0表示未认证请求
+func (s *AuditService) LogPanic(userID uint, method, path, requestID string, recovered interface{}, clientIP, userAgent string) {
+	auditLog := &model.AuditLog{
+		Action:    "panic",
+		Resource:  "system",
+		Details:   fmt.Sprintf("请求处理异常: %s %s (request_id: %s), 错误: %v", method, path, requestID, recovered),
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		Status:    "failed",
+	}
+	if userID != 0 {
+		auditLog.UserID = &userID
+	}
+
+	if err := s.db.Create(auditLog).Error; err != nil {
+		logger.Error("记录审计日志失败", "error", err)
+	}
+}
+
+// GetAuditLog 根据ID获取审计日志详情
+func (s *AuditService) GetAuditLog(id uint) (*model.AuditLogDetail, error) {
+	var auditLog model.AuditLog
+	if err := s.db.First(&auditLog, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAuditLogNotFound
+		}
+		return nil, fmt.Errorf("查询审计日志失败: %w", err)
+	}
+
+	detail := &model.AuditLogDetail{
+		AuditLog:    auditLog,
+		DetailsData: parseAuditDetails(auditLog.Details),
+	}
+
+	// 解析操作者用户名（包含已删除的用户）
+	if auditLog.UserID != nil {
+		var user model.User
+		if err := s.db.Unscoped().Select("id", "username").First(&user, *auditLog.UserID).Error; err == nil {
+			detail.Username = user.Username
+		}
+	}
+
+	return detail, nil
+}
+
+// auditSuccessStatuses 视为成功的审计状态
+var auditSuccessStatuses = []string{"success", "成功"}
+
+// auditFailedStatuses 视为失败的审计状态
+var auditFailedStatuses = []string{"failed", "失败"}
+
+// auditBucketFormats 时间分桶格式
+var auditBucketFormats = map[string]string{
+	"hour": "%Y-%m-%d %H:00",
+	"day":  "%Y-%m-%d",
+}
+
+// applyFilter 应用审计日志查询条件
+func (s *AuditService) applyFilter(query *gorm.DB, filter *model.AuditLogFilter) *gorm.DB {
+	if filter == nil {
+		return query
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.IPAddress != "" {
+		query = query.Where("ip_address = ?", filter.IPAddress)
+	}
+	// created_at 以带时区偏移的文本保存（写入时使用本地时区），按文本比较前需把查询时间换算到同一时区
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", filter.From.In(time.Local))
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", filter.To.In(time.Local))
+	}
+	return query
+}
+
+// GetAuditLogs 获取审计日志列表
+func (s *AuditService) GetAuditLogs(filter *model.AuditLogFilter, page, pageSize int) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var total int64
+
+	query := s.applyFilter(s.db.Model(&model.AuditLog{}), filter)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取审计日志总数失败: %w", err)
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Scopes(database.Paginate(page, pageSize)).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询审计日志失败: %w", err)
+	}
+
+	return logs, total, nil
+}
+
+// userTargetActions 以其他用户为操作对象的审计动作（不出现在个人活动记录中）
+var userTargetActions = []string{
+	"create_user", "update_user", "delete_user", "toggle_user_status", "unlock_user",
+	"approve_user", "reject_user", "revoke_session", "reset_2fa",
+	"修改用户状态", "重置用户密码",
+}
+
+// GetUserActivity 获取用户本人的活动记录
+func (s *AuditService) GetUserActivity(userID uint, page, pageSize int) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var total int64
+
+	query := s.db.Model(&model.AuditLog{}).
+		Where("user_id = ?", userID).
+		Where("action NOT IN ?", userTargetActions)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取活动记录总数失败: %w", err)
+	}
+
+	if err := query.Order("created_at DESC, id DESC").Scopes(database.Paginate(page, pageSize)).Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询活动记录失败: %w", err)
+	}
+
+	return logs, total, nil
+}
+
+// GetAuditStats 获取审计日志统计（按操作和时间段分组）
+func (s *AuditService) GetAuditStats(filter *model.AuditLogFilter, interval string) (*model.AuditStats, error) {
+	format, ok := auditBucketFormats[interval]
+	if !ok {
+		return nil, fmt.Errorf("不支持的统计间隔: %s", interval)
+	}
+
+	stats := &model.AuditStats{Interval: interval}
+
+	// 按操作分组
+	if err := s.applyFilter(s.db.Model(&model.AuditLog{}), filter).
+		Select("action, COUNT(*) AS count").
+		Group("action").
+		Order("count DESC").
+		Scan(&stats.ByAction).Error; err != nil {
+		return nil, fmt.Errorf("统计审计日志失败: %w", err)
+	}
+
+	// 按时间段分组
+	if err := s.applyFilter(s.db.Model(&model.AuditLog{}), filter).
+		Select("strftime(?, created_at) AS bucket, COUNT(*) AS total, "+
+			"SUM(CASE WHEN status IN ? THEN 1 ELSE 0 END) AS success, "+
+			"SUM(CASE WHEN status IN ? THEN 1 ELSE 0 END) AS failed",
+			format, auditSuccessStatuses, auditFailedStatuses).
+		Group("bucket").
+		Order("bucket").
+		Scan(&stats.Buckets).Error; err != nil {
+		return nil, fmt.Errorf("统计审计日志失败: %w", err)
+	}
+
+	for _, bucket := range stats.Buckets {
+		stats.Total += bucket.Total
+		stats.Success += bucket.Success
+		stats.Failed += bucket.Failed
+	}
+	if stats.Total > 0 {
+		stats.FailureRate = float64(stats.Failed) / float64(stats.Total)
+	}
+
+	return stats, nil
+}
+
+// logAuditAction 记录审计日志
+func (s *AuditService) logAuditAction(userID uint, action, resource, details, clientIP, userAgent, status string) {
+	auditLog := &model.AuditLog{
+		UserID:    &userID,
+		Action:    action,
+		Resource:  resource,
+		Details:   details,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		Status:    status,
+	}
+
+	if err := s.db.Create(auditLog).Error; err != nil {
+		logger.Error("记录审计日志失败", "error", err)
+	}
+}
+
+// parseAuditDetails 解析结构化（JSON）的审计详情，非JSON返回nil
+func parseAuditDetails(details string) interface{} {
+	trimmed := strings.TrimSpace(details)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(trimmed), &data); err != nil {
+		return nil
+	}
+	return data
+}
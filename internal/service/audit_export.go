@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+)
+
+// 审计日志导出格式
+const (
+	AuditExportCSV   = "csv"
+	AuditExportJSONL = "jsonl"
+)
+
+// auditExportHeader CSV表头
+var auditExportHeader = []string{"id", "user_id", "action", "resource", "details", "ip_address", "user_agent", "status", "created_at"}
+
+// ExportAuditLogs 按查询条件把审计日志逐行写入 w，使用数据库游标迭代，内存占用与结果集大小无关
+// 返回导出的行数；导出本身会记录一条审计日志
+func (s *AuditService) ExportAuditLogs(w io.Writer, filter *model.AuditLogFilter, format string, userID uint, clientIP, userAgent string) (int64, error) {
+	if format != AuditExportCSV && format != AuditExportJSONL {
+		return 0, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+
+	count, err := s.writeAuditLogs(w, filter, format)
+	details := fmt.Sprintf("导出审计日志: 格式 %s, %d 条%s", format, count, describeAuditRange(filter))
+	if err != nil {
+		s.logAuditAction(userID, "export_audit_logs", "audit", fmt.Sprintf("%s, 错误: %v", details, err), clientIP, userAgent, "failed")
+		return count, err
+	}
+
+	s.logAuditAction(userID, "export_audit_logs", "audit", details, clientIP, userAgent, "success")
+	logger.Info("审计日志导出完成", "format", format, "count", count, "user_id", userID)
+	return count, nil
+}
+
+// writeAuditLogs 迭代查询结果并按格式写出
+func (s *AuditService) writeAuditLogs(w io.Writer, filter *model.AuditLogFilter, format string) (int64, error) {
+	rows, err := s.applyFilter(s.db.Model(&model.AuditLog{}), filter).
+		Order("created_at ASC, id ASC").
+		Rows()
+	if err != nil {
+		return 0, fmt.Errorf("查询审计日志失败: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		csvWriter *csv.Writer
+		buffered  *bufio.Writer
+		encoder   *json.Encoder
+	)
+	if format == AuditExportCSV {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(auditExportHeader); err != nil {
+			return 0, err
+		}
+	} else {
+		buffered = bufio.NewWriter(w)
+		encoder = json.NewEncoder(buffered)
+	}
+
+	var count int64
+	for rows.Next() {
+		var entry model.AuditLog
+		if err := s.db.ScanRows(rows, &entry); err != nil {
+			return count, fmt.Errorf("读取审计日志失败: %w", err)
+		}
+
+		if csvWriter != nil {
+			err = csvWriter.Write(auditLogRecord(&entry))
+		} else {
+			err = encoder.Encode(&entry)
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("读取审计日志失败: %w", err)
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		return count, csvWriter.Error()
+	}
+	return count, buffered.Flush()
+}
+
+// auditLogRecord 转换为CSV行
+func auditLogRecord(entry *model.AuditLog) []string {
+	userID := ""
+	if entry.UserID != nil {
+		userID = strconv.FormatUint(uint64(*entry.UserID), 10)
+	}
+	return []string{
+		strconv.FormatUint(uint64(entry.ID), 10),
+		userID,
+		csvSafe(entry.Action),
+		csvSafe(entry.Resource),
+		csvSafe(entry.Details),
+		csvSafe(entry.IPAddress),
+		csvSafe(entry.UserAgent),
+		csvSafe(entry.Status),
+		entry.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// csvSafe 防止CSV公式注入：以公式字符开头的单元格加单引号前缀，表格软件会按文本显示
+// 审计详情和User-Agent等字段来自用户输入，不能原样交给表格软件解析
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// describeAuditRange 描述导出的时间范围，用于审计详情
+func describeAuditRange(filter *model.AuditLogFilter) string {
+	if filter == nil || (filter.From == nil && filter.To == nil) {
+		return ""
+	}
+	from, to := "-", "-"
+	if filter.From != nil {
+		from = filter.From.Format(time.RFC3339)
+	}
+	if filter.To != nil {
+		to = filter.To.Format(time.RFC3339)
+	}
+	return fmt.Sprintf(", 时间范围 %s ~ %s", from, to)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"web-panel-go/internal/model"
+)
+
+// seedAuditLogs 写入两条审计日志，其中一条的详情和User-Agent以公式字符开头
+func seedAuditLogs(t *testing.T, s *AuditService) {
+	t.Helper()
+	entries := []model.AuditLog{
+		{Action: "login", Resource: "auth", Details: "用户登录", IPAddress: "127.0.0.1", UserAgent: "test", Status: "success"},
+		{Action: "upload", Resource: "file", Details: "=HYPERLINK(\"http://evil\")", IPAddress: "127.0.0.1", UserAgent: "@cmd", Status: "success"},
+	}
+	for i := range entries {
+		if err := s.db.Create(&entries[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExportAuditLogsCSV(t *testing.T) {
+	s := NewAuditService(newTestDB(t))
+	seedAuditLogs(t, s)
+
+	var buf bytes.Buffer
+	count, err := s.ExportAuditLogs(&buf, &model.AuditLogFilter{}, AuditExportCSV, 1, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("解析CSV失败: %v", err)
+	}
+	if count != 2 || len(records) != 3 {
+		t.Fatalf("导出 %d 条，CSV共 %d 行", count, len(records))
+	}
+	if records[0][2] != "action" || records[1][2] != "login" || records[1][4] != "用户登录" {
+		t.Fatalf("CSV内容不正确: %v", records[:2])
+	}
+	// 以公式字符开头的单元格加单引号前缀
+	if got := records[2][4]; got != "'=HYPERLINK(\"http://evil\")" {
+		t.Fatalf("详情未转义: %q", got)
+	}
+	if got := records[2][6]; got != "'@cmd" {
+		t.Fatalf("User-Agent未转义: %q", got)
+	}
+}
+
+func TestExportAuditLogsJSONL(t *testing.T) {
+	s := NewAuditService(newTestDB(t))
+	seedAuditLogs(t, s)
+
+	var buf bytes.Buffer
+	count, err := s.ExportAuditLogs(&buf, nil, AuditExportJSONL, 1, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	var entries []model.AuditLog
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var entry model.AuditLog
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("解析JSON行失败: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if count != 2 || len(entries) != 2 {
+		t.Fatalf("导出 %d 条，解析出 %d 条", count, len(entries))
+	}
+	// JSONL 保留原始内容，不做CSV转义
+	if entries[1].Details != "=HYPERLINK(\"http://evil\")" || entries[1].UserAgent != "@cmd" {
+		t.Fatalf("JSONL内容不正确: %+v", entries[1])
+	}
+
+	// 导出本身记录一条审计日志
+	var exported int64
+	s.db.Model(&model.AuditLog{}).Where("action = ?", "export_audit_logs").Count(&exported)
+	if exported != 1 {
+		t.Fatalf("导出审计日志条数 = %d, 期望 1", exported)
+	}
+}
+
+func TestCSVSafe(t *testing.T) {
+	tests := map[string]string{
+		"":          "",
+		"normal":    "normal",
+		"=1+1":      "'=1+1",
+		"+1":        "'+1",
+		"-1":        "'-1",
+		"@SUM(A1)":  "'@SUM(A1)",
+		"\tcmd":     "'\tcmd",
+		"a=b":       "a=b",
+		"127.0.0.1": "127.0.0.1",
+	}
+	for in, want := range tests {
+		if got := csvSafe(in); got != want {
+			t.Errorf("csvSafe(%q) = %q, 期望 %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"web-panel-go/internal/model"
+)
+
+// createAuditLogAt 写入一条指定时间的审计日志
+func createAuditLogAt(t *testing.T, s *AuditService, action, ip, status string, at time.Time) {
+	t.Helper()
+	entry := &model.AuditLog{Action: action, Resource: "auth", IPAddress: ip, Status: status, CreatedAt: at}
+	if err := s.db.Create(entry).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetAuditLogsFiltersByIPAndTime(t *testing.T) {
+	s := NewAuditService(newTestDB(t))
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+	createAuditLogAt(t, s, "login", "10.0.0.1", "failed", base)
+	createAuditLogAt(t, s, "login", "10.0.0.1", "success", base.Add(2*time.Hour))
+	createAuditLogAt(t, s, "login", "10.0.0.2", "failed", base.Add(2*time.Hour))
+
+	logs, total, err := s.GetAuditLogs(&model.AuditLogFilter{IPAddress: "10.0.0.1"}, 1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(logs) != 2 {
+		t.Fatalf("按IP过滤得到 %d 条，期望 2 条", total)
+	}
+
+	// 查询时间使用与写入时不同的时区，按同一时刻比较
+	_, offset := base.Zone()
+	zone := time.FixedZone("test", offset+5*3600)
+	from := base.Add(time.Hour).In(zone)
+	to := base.Add(3 * time.Hour).In(zone)
+	logs, total, err = s.GetAuditLogs(&model.AuditLogFilter{IPAddress: "10.0.0.1", From: &from, To: &to}, 1, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || logs[0].Status != "success" {
+		t.Fatalf("按时间范围过滤结果不正确: %d 条 %+v", total, logs)
+	}
+}
+
+func TestGetAuditStatsBuckets(t *testing.T) {
+	s := NewAuditService(newTestDB(t))
+	base := time.Date(2026, 3, 1, 10, 15, 0, 0, time.UTC)
+	createAuditLogAt(t, s, "login", "10.0.0.1", "failed", base)
+	createAuditLogAt(t, s, "login", "10.0.0.1", "失败", base.Add(10*time.Minute))
+	createAuditLogAt(t, s, "upload", "10.0.0.1", "success", base.Add(time.Hour))
+	createAuditLogAt(t, s, "login", "10.0.0.1", "success", base.Add(24*time.Hour))
+
+	stats, err := s.GetAuditStats(&model.AuditLogFilter{IPAddress: "10.0.0.1"}, "hour")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Total != 4 || stats.Failed != 2 || stats.Success != 2 || stats.FailureRate != 0.5 {
+		t.Fatalf("统计汇总不正确: %+v", stats)
+	}
+	if len(stats.Buckets) != 3 || stats.Buckets[0].Bucket != "2026-03-01 10:00" || stats.Buckets[0].Failed != 2 {
+		t.Fatalf("按小时分组不正确: %+v", stats.Buckets)
+	}
+	if len(stats.ByAction) != 2 || stats.ByAction[0].Action != "login" || stats.ByAction[0].Count != 3 {
+		t.Fatalf("按操作分组不正确: %+v", stats.ByAction)
+	}
+
+	stats, err = s.GetAuditStats(&model.AuditLogFilter{IPAddress: "10.0.0.1"}, "day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Buckets) != 2 || stats.Buckets[0].Total != 3 || stats.Buckets[1].Bucket != "2026-03-02" {
+		t.Fatalf("按天分组不正确: %+v", stats.Buckets)
+	}
+
+	if _, err := s.GetAuditStats(nil, "week"); err == nil {
+		t.Fatal("不支持的统计间隔应返回错误")
+	}
+}
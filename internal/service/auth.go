@@ -1,38 +1,90 @@
 package service
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	"web-panel-go/internal/config"
+	"web-panel-go/internal/database"
 	"web-panel-go/internal/logger"
 	"web-panel-go/internal/model"
 
 	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // AuthService 认证服务
 type AuthService struct {
-	db     *gorm.DB
-	config *config.Config
+	db           *gorm.DB
+	config       *config.Config
+	alerter      SecurityAlerter
+	alertTracker *loginAlertTracker
+	rbac         *RBACCache
+	extender     *sessionExtender
+	validator    *CredentialValidator
 }
 
 // NewAuthService 创建认证服务实例
-func NewAuthService(db *gorm.DB, cfg *config.Config) *AuthService {
+func NewAuthService(db *gorm.DB, cfg *config.Config, rbac *RBACCache) *AuthService {
 	return &AuthService{
-		db:     db,
-		config: cfg,
+		db:           db,
+		config:       cfg,
+		alertTracker: newLoginAlertTracker(cfg.Auth.AlertWindow, cfg.Auth.AlertCooldown),
+		rbac:         rbac,
+		extender:     newSessionExtender(cfg.Auth.SessionExtendInterval),
+		validator:    NewCredentialValidator(&cfg.Auth),
 	}
 }
 
+// SetSecurityAlerter 设置安全告警推送器
+func (s *AuthService) SetSecurityAlerter(alerter SecurityAlerter) {
+	s.alerter = alerter
+}
+
+// 账户状态错误
+var (
+	ErrUserDisabled   = errors.New("用户已被禁用")
+	ErrAccountPending = errors.New("账户待审核")
+)
+
+// ErrWrongPassword 修改密码时旧密码错误
+var ErrWrongPassword = errors.New("旧密码错误")
+
+// 刷新令牌错误
+var (
+	ErrRefreshTokenInvalid = errors.New("无效的刷新令牌")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，会话已撤销")
+)
+
+const (
+	// tokenTypeAccess 访问令牌类型
+	tokenTypeAccess = "access"
+	// tokenTypeRefresh 刷新令牌类型
+	tokenTypeRefresh = "refresh"
+)
+
 // JWTClaims JWT声明
 type JWTClaims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// RefreshClaims 刷新令牌声明
+type RefreshClaims struct {
+	UserID     uint   `json:"user_id"`
+	SessionID  string `json:"sid"`
+	Generation int    `json:"gen"`
+	TokenType  string `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
@@ -40,64 +92,295 @@ type JWTClaims struct {
 func (s *AuthService) Login(req *model.LoginRequest, clientIP, userAgent string) (*model.LoginResponse, error) {
 	// 查找用户
 	var user model.User
-	if err := s.db.Where("username = ? OR email = ?", req.Username, req.Username).First(&user).Error; err != nil {
+	if err := s.db.Preload("Roles").Where("username = ? OR email = ?", req.Username, req.Username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			logger.LogAuth("login", req.Username, clientIP, false, "用户不存在")
+			s.checkIPFailures(req.Username, clientIP, userAgent)
 			return nil, errors.New("用户名或密码错误")
 		}
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
 
 	// 检查用户是否激活
+	if user.IsPending() {
+		logger.LogAuth("login", user.Username, clientIP, false, "账户待审核")
+		return nil, ErrAccountPending
+	}
 	if !user.IsActive() {
 		logger.LogAuth("login", user.Username, clientIP, false, "用户已被禁用")
-		return nil, errors.New("用户已被禁用")
+		return nil, ErrUserDisabled
+	}
+
+	// 检查账户是否被锁定
+	if user.IsLocked() {
+		logger.LogAuth("login", user.Username, clientIP, false, "账户已锁定")
+		return nil, fmt.Errorf("账户已锁定，请于 %s 后重试", user.LockedUntil.Format("2006-01-02 15:04:05"))
 	}
 
 	// 验证密码
 	if err := user.CheckPassword(req.Password); err != nil {
 		logger.LogAuth("login", user.Username, clientIP, false, "密码错误")
+		s.recordLoginFailure(&user, clientIP, userAgent)
+		s.checkIPFailures(user.Username, clientIP, userAgent)
 		return nil, errors.New("用户名或密码错误")
 	}
 
-	// 生成JWT令牌
-	token, expiresAt, err := s.GenerateToken(&user)
+	// 启用两步验证时必须提供有效的验证码，校验出错时拒绝登录
+	if err := s.verifyTwoFactor(user.ID, req.Code); err != nil {
+		logger.LogAuth("login", user.Username, clientIP, false, err.Error())
+		if errors.Is(err, ErrTwoFactorInvalid) {
+			s.recordLoginFailure(&user, clientIP, userAgent)
+			s.checkIPFailures(user.Username, clientIP, userAgent)
+		}
+		return nil, err
+	}
+
+	// 登录成功，清除失败计数
+	user.FailedAttempts = 0
+	user.LockedUntil = nil
+	s.alertTracker.resetIP(clientIP)
+
+	// 创建会话并签发令牌
+	resp, err := s.startSession(&user, clientIP, userAgent)
 	if err != nil {
-		return nil, fmt.Errorf("生成令牌失败: %w", err)
+		return nil, err
 	}
 
 	// 更新最后登录时间
 	user.UpdateLastLogin()
-	if err := s.db.Save(&user).Error; err != nil {
+	if err := s.db.Omit(clause.Associations).Save(&user).Error; err != nil {
 		logger.Error("更新用户最后登录时间失败", "error", err)
 	}
 
-	// 创建会话记录
-	session := &model.Session{
-		ID:        generateSessionID(),
-		UserID:    user.ID,
-		Token:     token,
-		IPAddress: clientIP,
-		UserAgent: userAgent,
-		ExpiresAt: time.Unix(expiresAt, 0),
+	// 记录审计日志
+	s.logAuditAction(user.ID, "login", "user", "用户登录", clientIP, userAgent, "success")
+
+	logger.LogAuth("login", user.Username, clientIP, true, "登录成功")
+
+	resp.User = user.ToSafeJSON()
+	return resp, nil
+}
+
+// startSession 创建会话记录并签发访问令牌和刷新令牌
+func (s *AuthService) startSession(user *model.User, clientIP, userAgent string) (*model.LoginResponse, error) {
+	sessionID := generateSessionID()
+	refreshExpiresAt := time.Now().Add(s.tokenLifetime()).Unix()
+
+	token, expiresAt, err := s.GenerateAccessToken(user, sessionID, refreshExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("生成令牌失败: %w", err)
+	}
+	refreshToken, err := s.GenerateRefreshToken(user.ID, sessionID, 1, refreshExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("生成令牌失败: %w", err)
 	}
 
+	session := &model.Session{
+		ID:                sessionID,
+		UserID:            user.ID,
+		Token:             token,
+		RefreshTokenHash:  hashToken(refreshToken),
+		RefreshGeneration: 1,
+		IPAddress:         clientIP,
+		UserAgent:         userAgent,
+		ExpiresAt:         s.initialSessionExpiry(refreshExpiresAt),
+	}
 	if err := s.db.Create(session).Error; err != nil {
-		logger.Error("创建会话记录失败", "error", err)
+		return nil, fmt.Errorf("创建会话失败: %w", err)
 	}
 
-	// 记录审计日志
-	s.logAuditAction(user.ID, "login", "user", "用户登录", clientIP, userAgent, "success")
+	return &model.LoginResponse{
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
 
-	logger.LogAuth("login", user.Username, clientIP, true, "登录成功")
+// RefreshSession 使用刷新令牌换取新的访问令牌和刷新令牌，旧的两个令牌随即失效
+// 已轮换过的刷新令牌再次出现说明令牌可能被盗用，此时撤销整个会话并发出安全告警
+func (s *AuthService) RefreshSession(refreshToken, clientIP, userAgent string) (*model.LoginResponse, error) {
+	claims, err := s.parseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	var session model.Session
+	if err := s.db.Where("id = ? AND user_id = ? AND expires_at > ?", claims.SessionID, claims.UserID, time.Now()).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefreshTokenInvalid
+		}
+		return nil, fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	if claims.Generation < session.RefreshGeneration {
+		s.revokeReusedSession(&session, claims.Generation, clientIP, userAgent)
+		return nil, ErrRefreshTokenReused
+	}
+	if claims.Generation != session.RefreshGeneration || session.RefreshTokenHash != hashToken(refreshToken) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	user, err := s.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiresAt := claims.ExpiresAt.Unix()
+	token, expiresAt, err := s.GenerateAccessToken(user, session.ID, refreshExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("生成令牌失败: %w", err)
+	}
+	generation := session.RefreshGeneration + 1
+	newRefreshToken, err := s.GenerateRefreshToken(user.ID, session.ID, generation, refreshExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("生成令牌失败: %w", err)
+	}
+
+	// 以代数为条件更新，同一刷新令牌并发使用时只有一个请求成功
+	result := s.db.Model(&model.Session{}).
+		Where("id = ? AND refresh_generation = ?", session.ID, session.RefreshGeneration).
+		Updates(map[string]interface{}{
+			"token":              token,
+			"refresh_token_hash": hashToken(newRefreshToken),
+			"refresh_generation": generation,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新会话失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRefreshTokenInvalid
+	}
+	s.extender.forget(session.Token)
+
+	s.logAuditAction(user.ID, "refresh_token", "session", fmt.Sprintf("刷新令牌: 会话 %s", session.ID), clientIP, userAgent, "success")
 
 	return &model.LoginResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
-		User:      user.ToSafeJSON(),
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     newRefreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		User:             user.ToSafeJSON(),
 	}, nil
 }
 
+// revokeReusedSession 撤销刷新令牌被重复使用的会话并发出安全告警
+func (s *AuthService) revokeReusedSession(session *model.Session, generation int, clientIP, userAgent string) {
+	if err := s.db.Where("id = ?", session.ID).Delete(&model.Session{}).Error; err != nil {
+		logger.Error("撤销会话失败", "session_id", session.ID, "error", err)
+	}
+	s.extender.forget(session.Token)
+
+	var user model.User
+	if err := s.db.Select("id", "username").First(&user, session.UserID).Error; err != nil {
+		logger.Error("查询用户失败", "user_id", session.UserID, "error", err)
+	}
+
+	s.logAuditAction(session.UserID, "refresh_token", "session",
+		fmt.Sprintf("刷新令牌重复使用，已撤销会话 %s（令牌代数 %d，当前代数 %d）", session.ID, generation, session.RefreshGeneration),
+		clientIP, userAgent, "failed")
+	s.raiseSecurityAlert(&model.SecurityAlert{
+		Type:      model.SecurityAlertRefreshReuse,
+		Username:  user.Username,
+		UserID:    session.UserID,
+		IPAddress: clientIP,
+		Message:   fmt.Sprintf("用户 %s 的已轮换刷新令牌被再次使用，会话已撤销", user.Username),
+	}, userAgent)
+}
+
+// recordLoginFailure 记录登录失败次数，达到阈值时锁定账户
+// 计数在数据库中原子递增，并发的失败登录不会互相覆盖
+func (s *AuthService) recordLoginFailure(user *model.User, clientIP, userAgent string) {
+	// 上一次锁定已过期，重新开始计数，否则解锁后一次输错就会再次锁定
+	lockExpired := user.LockedUntil != nil && !user.IsLocked()
+
+	maxAttempts := s.config.Auth.MaxFailedAttempts
+	var lockedUntil *time.Time
+	err := database.RetryTransaction(s.db, func(tx *gorm.DB) error {
+		lockedUntil = nil
+		users := tx.Model(&model.User{}).Where("id = ?", user.ID)
+		if lockExpired {
+			// 并发请求中只有第一个重置，之后的请求看到 locked_until 已清空
+			if err := users.Session(&gorm.Session{}).Where("locked_until IS NOT NULL").
+				Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": nil}).Error; err != nil {
+				return err
+			}
+		}
+		if err := users.Session(&gorm.Session{}).UpdateColumn("failed_attempts", gorm.Expr("failed_attempts + 1")).Error; err != nil {
+			return err
+		}
+		if err := users.Session(&gorm.Session{}).Pluck("failed_attempts", &user.FailedAttempts).Error; err != nil {
+			return err
+		}
+
+		if maxAttempts > 0 && user.FailedAttempts >= maxAttempts {
+			until := time.Now().Add(s.config.Auth.LockoutDuration)
+			if err := users.Session(&gorm.Session{}).UpdateColumn("locked_until", until).Error; err != nil {
+				return err
+			}
+			lockedUntil = &until
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("更新登录失败次数失败", "error", err, "user_id", user.ID)
+		return
+	}
+
+	if lockedUntil != nil {
+		user.LockedUntil = lockedUntil
+		s.logAuditAction(user.ID, "lock_account", "user", fmt.Sprintf("连续登录失败 %d 次，账户锁定至 %s", user.FailedAttempts, lockedUntil.Format("2006-01-02 15:04:05")), clientIP, userAgent, "success")
+	} else if lockExpired {
+		user.LockedUntil = nil
+	}
+
+	// 账户连续失败达到告警阈值
+	threshold := s.config.Auth.AlertThreshold
+	if threshold > 0 && user.FailedAttempts >= threshold && s.alertTracker.allow(fmt.Sprintf("user:%d", user.ID), time.Now()) {
+		s.raiseSecurityAlert(&model.SecurityAlert{
+			Type:      model.SecurityAlertAccountFailures,
+			Username:  user.Username,
+			UserID:    user.ID,
+			IPAddress: clientIP,
+			Failures:  user.FailedAttempts,
+			Message:   fmt.Sprintf("账户 %s 连续登录失败 %d 次", user.Username, user.FailedAttempts),
+		}, userAgent)
+	}
+}
+
+// checkIPFailures 统计IP在时间窗口内的登录失败次数，达到告警阈值时推送告警
+func (s *AuthService) checkIPFailures(username, clientIP, userAgent string) {
+	threshold := s.config.Auth.AlertThreshold
+	if threshold <= 0 {
+		return
+	}
+
+	now := time.Now()
+	failures := s.alertTracker.recordIPFailure(clientIP, now)
+	if failures < threshold || !s.alertTracker.allow("ip:"+clientIP, now) {
+		return
+	}
+
+	s.raiseSecurityAlert(&model.SecurityAlert{
+		Type:      model.SecurityAlertIPFailures,
+		Username:  username,
+		IPAddress: clientIP,
+		Failures:  failures,
+		Message:   fmt.Sprintf("IP %s 在 %s 内登录失败 %d 次", clientIP, s.config.Auth.AlertWindow, failures),
+	}, userAgent)
+}
+
+// raiseSecurityAlert 记录安全告警审计日志并推送给管理员
+func (s *AuthService) raiseSecurityAlert(alert *model.SecurityAlert, userAgent string) {
+	alert.CreatedAt = time.Now()
+	s.logAuditAction(alert.UserID, "security_alert", "user", alert.Message, alert.IPAddress, userAgent, "success")
+	logger.Warn("安全告警", "type", alert.Type, "ip", alert.IPAddress, "username", alert.Username, "failures", alert.Failures)
+
+	if s.alerter != nil {
+		s.alerter.SendSecurityAlert(alert)
+	}
+}
+
 // Logout 用户登出
 func (s *AuthService) Logout(token string, userID uint, clientIP, userAgent string) error {
 	// 删除会话记录
@@ -111,15 +394,29 @@ func (s *AuthService) Logout(token string, userID uint, clientIP, userAgent stri
 	return nil
 }
 
-// GenerateToken 生成JWT令牌
-func (s *AuthService) GenerateToken(user *model.User) (string, int64, error) {
-	expiresAt := time.Now().Add(s.config.Auth.JWTExpire).Unix()
+// GenerateAccessToken 生成访问令牌，有效期为 access_token_expire，且不超过刷新令牌的过期时间
+func (s *AuthService) GenerateAccessToken(user *model.User, sessionID string, refreshExpiresAt int64) (string, int64, error) {
+	expiresAt := refreshExpiresAt
+	if lifetime := s.config.Auth.AccessTokenExpire; lifetime > 0 {
+		if t := time.Now().Add(lifetime).Unix(); t < expiresAt {
+			expiresAt = t
+		}
+	}
+
+	// 同一秒内刷新时其余声明完全相同，用随机ID保证每次签发的令牌不同，旧令牌才能随会话更新失效
+	tokenID, err := generateTokenID()
+	if err != nil {
+		return "", 0, err
+	}
 
 	claims := &JWTClaims{
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.GetRole(),
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.GetRole(),
+		SessionID: sessionID,
+		TokenType: tokenTypeAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Unix(expiresAt, 0)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -137,6 +434,45 @@ func (s *AuthService) GenerateToken(user *model.User) (string, int64, error) {
 	return tokenString, expiresAt, nil
 }
 
+// GenerateRefreshToken 生成刷新令牌，generation 为会话当前的刷新令牌代数
+func (s *AuthService) GenerateRefreshToken(userID uint, sessionID string, generation int, expiresAt int64) (string, error) {
+	claims := &RefreshClaims{
+		UserID:     userID,
+		SessionID:  sessionID,
+		Generation: generation,
+		TokenType:  tokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Unix(expiresAt, 0)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "web-panel-go",
+			Subject:   strconv.Itoa(int(userID)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.config.Auth.JWTSecret))
+}
+
+// parseRefreshToken 校验刷新令牌的签名、有效期和类型
+func (s *AuthService) parseRefreshToken(tokenString string) (*RefreshClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("意外的签名方法: %v", token.Header["alg"])
+		}
+		return []byte(s.config.Auth.JWTSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*RefreshClaims)
+	if !ok || !token.Valid || claims.TokenType != tokenTypeRefresh || claims.SessionID == "" || claims.ExpiresAt == nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+	return claims, nil
+}
+
 // ValidateToken 验证JWT令牌
 func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
@@ -151,6 +487,11 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	}
 
 	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+		// 刷新令牌不能作为访问令牌使用
+		if claims.TokenType == tokenTypeRefresh {
+			return nil, errors.New("无效的令牌")
+		}
+
 		// 检查会话是否存在且未过期
 		var session model.Session
 		if err := s.db.Where("token = ? AND user_id = ? AND expires_at > ?", tokenString, claims.UserID, time.Now()).First(&session).Error; err != nil {
@@ -169,15 +510,24 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 // GetUserByID 根据ID获取用户
 func (s *AuthService) GetUserByID(userID uint) (*model.User, error) {
 	var user model.User
-	if err := s.db.First(&user, userID).Error; err != nil {
+	if err := s.db.Preload("Roles").First(&user, userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户不存在")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
 
+	// 角色权限从缓存读取
+	for i := range user.Roles {
+		permissions, err := s.rbac.RolePermissions(user.Roles[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("查询用户权限失败: %w", err)
+		}
+		user.Roles[i].Permissions = permissions
+	}
+
 	if !user.IsActive() {
-		return nil, errors.New("用户已被禁用")
+		return nil, ErrUserDisabled
 	}
 
 	return &user, nil
@@ -194,16 +544,20 @@ func (s *AuthService) ChangePassword(userID uint, req *model.ChangePasswordReque
 	// 验证旧密码
 	if err := user.CheckPassword(req.OldPassword); err != nil {
 		s.logAuditAction(userID, "change_password", "user", "修改密码失败：旧密码错误", clientIP, userAgent, "failed")
-		return errors.New("旧密码错误")
+		return ErrWrongPassword
+	}
+
+	if err := s.validator.ValidatePassword(req.NewPassword); err != nil {
+		return err
 	}
 
 	// 设置新密码
-	if err := user.SetPassword(req.NewPassword); err != nil {
+	if err := user.SetPassword(req.NewPassword, s.validator.PasswordPolicy()); err != nil {
 		return fmt.Errorf("设置新密码失败: %w", err)
 	}
 
 	// 保存用户
-	if err := s.db.Save(user).Error; err != nil {
+	if err := s.db.Omit(clause.Associations).Save(user).Error; err != nil {
 		return fmt.Errorf("保存用户失败: %w", err)
 	}
 
@@ -218,6 +572,62 @@ func (s *AuthService) ChangePassword(userID uint, req *model.ChangePasswordReque
 	return nil
 }
 
+// sessionExtendEnabled 是否启用会话自动延长
+func (s *AuthService) sessionExtendEnabled() bool {
+	return s.config.Auth.SessionExtend && s.config.Auth.SessionMaxLifetime > s.config.Auth.JWTExpire
+}
+
+// tokenLifetime 令牌有效期；启用会话自动延长时令牌按最长有效期签发，实际过期由会话记录控制
+func (s *AuthService) tokenLifetime() time.Duration {
+	if s.sessionExtendEnabled() {
+		return s.config.Auth.SessionMaxLifetime
+	}
+	return s.config.Auth.JWTExpire
+}
+
+// initialSessionExpiry 登录时会话的过期时间
+func (s *AuthService) initialSessionExpiry(tokenExpiresAt int64) time.Time {
+	if s.sessionExtendEnabled() {
+		return time.Now().Add(s.config.Auth.JWTExpire)
+	}
+	return time.Unix(tokenExpiresAt, 0)
+}
+
+// ExtendSession 在会话有活动时延长过期时间，同一会话在 session_extend_interval 内最多写一次数据库，
+// 延长后的过期时间不超过登录时间加 session_max_lifetime
+func (s *AuthService) ExtendSession(token string) error {
+	if !s.sessionExtendEnabled() {
+		return nil
+	}
+
+	now, due := s.extender.due(token)
+	if !due {
+		return nil
+	}
+
+	var session model.Session
+	if err := s.db.Where("token = ? AND expires_at > ?", token, now).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.extender.forget(token)
+			return nil
+		}
+		return fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	expiresAt := now.Add(s.config.Auth.JWTExpire)
+	if limit := session.CreatedAt.Add(s.config.Auth.SessionMaxLifetime); expiresAt.After(limit) {
+		expiresAt = limit
+	}
+	if !expiresAt.After(session.ExpiresAt) {
+		return nil
+	}
+
+	if err := s.db.Model(&model.Session{}).Where("id = ?", session.ID).Update("expires_at", expiresAt).Error; err != nil {
+		return fmt.Errorf("延长会话失败: %w", err)
+	}
+	return nil
+}
+
 // CleanExpiredSessions 清理过期会话
 func (s *AuthService) CleanExpiredSessions() error {
 	result := s.db.Where("expires_at < ?", time.Now()).Delete(&model.Session{})
@@ -249,7 +659,22 @@ func (s *AuthService) logAuditAction(userID uint, action, resource, details, cli
 	}
 }
 
+// hashToken 计算令牌的SHA-256，数据库中只保存刷新令牌的哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // generateSessionID 生成会话ID
 func generateSessionID() string {
 	return fmt.Sprintf("sess_%d_%d", time.Now().UnixNano(), time.Now().Unix())
+}
+
+// generateTokenID 生成随机令牌ID（jti）
+func generateTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
\ No newline at end of file
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/model"
+)
+
+// newTestAuthService 创建使用内存数据库的认证服务
+func newTestAuthService(t *testing.T) *AuthService {
+	t.Helper()
+	db := newTestDB(t)
+	return NewAuthService(db, newTestConfig(t), NewRBACCache(db))
+}
+
+// loginAdmin 以默认管理员登录
+func loginAdmin(t *testing.T, s *AuthService) *model.LoginResponse {
+	t.Helper()
+	resp, err := s.Login(&model.LoginRequest{Username: "admin", Password: testAdminPassword}, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	return resp
+}
+
+func TestRefreshSessionRotatesTokens(t *testing.T) {
+	s := newTestAuthService(t)
+	login := loginAdmin(t, s)
+	if login.RefreshToken == "" || login.RefreshToken == login.Token {
+		t.Fatal("登录应返回独立的刷新令牌")
+	}
+
+	// 刷新令牌不能当作访问令牌使用
+	if _, err := s.ValidateToken(login.RefreshToken); err == nil {
+		t.Fatal("刷新令牌通过了访问令牌校验")
+	}
+
+	refreshed, err := s.RefreshSession(login.RefreshToken, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Fatal("刷新后应轮换刷新令牌")
+	}
+	if refreshed.RefreshExpiresAt != login.RefreshExpiresAt {
+		t.Fatalf("刷新不应延长会话: %d -> %d", login.RefreshExpiresAt, refreshed.RefreshExpiresAt)
+	}
+	if _, err := s.ValidateToken(refreshed.Token); err != nil {
+		t.Fatalf("新的访问令牌无效: %v", err)
+	}
+	if _, err := s.ValidateToken(login.Token); err == nil {
+		t.Fatal("刷新后旧的访问令牌仍然有效")
+	}
+
+	// 新的刷新令牌可以继续轮换
+	if _, err := s.RefreshSession(refreshed.RefreshToken, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("第二次刷新失败: %v", err)
+	}
+}
+
+func TestRefreshSessionReuseRevokesSession(t *testing.T) {
+	s := newTestAuthService(t)
+	login := loginAdmin(t, s)
+
+	refreshed, err := s.RefreshSession(login.RefreshToken, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+
+	// 已轮换的刷新令牌再次使用，视为被盗用
+	if _, err := s.RefreshSession(login.RefreshToken, "10.0.0.9", "attacker"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("重复使用刷新令牌返回 %v，期望 ErrRefreshTokenReused", err)
+	}
+
+	// 整个会话被撤销：合法持有者的令牌也一并失效
+	if _, err := s.ValidateToken(refreshed.Token); err == nil {
+		t.Fatal("会话撤销后访问令牌仍然有效")
+	}
+	if _, err := s.RefreshSession(refreshed.RefreshToken, "127.0.0.1", "test"); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("会话撤销后刷新返回 %v，期望 ErrRefreshTokenInvalid", err)
+	}
+
+	var sessions int64
+	s.db.Model(&model.Session{}).Count(&sessions)
+	if sessions != 0 {
+		t.Fatalf("会话撤销后还剩 %d 个会话", sessions)
+	}
+	var alerts int64
+	s.db.Model(&model.AuditLog{}).Where("action = ?", "security_alert").Count(&alerts)
+	if alerts != 1 {
+		t.Fatalf("security_alert 审计日志 %d 条，期望 1 条", alerts)
+	}
+}
+
+func TestRefreshSessionRejectsInvalidToken(t *testing.T) {
+	s := newTestAuthService(t)
+	login := loginAdmin(t, s)
+
+	for _, token := range []string{"", "not-a-jwt", login.Token} {
+		if _, err := s.RefreshSession(token, "127.0.0.1", "test"); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("RefreshSession(%.20q) 返回 %v，期望 ErrRefreshTokenInvalid", token, err)
+		}
+	}
+}
+
+// lockAdmin 用错误密码登录直到默认管理员被锁定
+func lockAdmin(t *testing.T, s *AuthService) {
+	t.Helper()
+	s.config.Auth.MaxFailedAttempts = 3
+	s.config.Auth.LockoutDuration = time.Hour
+	for i := 0; i < 3; i++ {
+		s.Login(&model.LoginRequest{Username: "admin", Password: "wrong"}, "127.0.0.1", "test")
+	}
+	if _, err := s.Login(&model.LoginRequest{Username: "admin", Password: testAdminPassword}, "127.0.0.1", "test"); err == nil {
+		t.Fatal("连续失败后账户应被锁定")
+	}
+}
+
+func TestLoginAfterUnlock(t *testing.T) {
+	s := newTestAuthService(t)
+	lockAdmin(t, s)
+
+	users := NewUserService(s.db, s.config)
+	admin := testAdmin(t, s.db)
+	if _, err := users.UnlockUser(admin.ID, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("解锁失败: %v", err)
+	}
+	loginAdmin(t, s)
+}
+
+func TestLoginAfterLockoutExpires(t *testing.T) {
+	s := newTestAuthService(t)
+	lockAdmin(t, s)
+
+	// 模拟锁定到期
+	s.db.Model(&model.User{}).Where("username = ?", "admin").Update("locked_until", time.Now().Add(-time.Minute))
+
+	// 到期后重新计数，输错一次不会立即再次锁定
+	if _, err := s.Login(&model.LoginRequest{Username: "admin", Password: "wrong"}, "127.0.0.1", "test"); err == nil {
+		t.Fatal("错误密码登录应失败")
+	}
+	admin := testAdmin(t, s.db)
+	if admin.FailedAttempts != 1 || admin.IsLocked() {
+		t.Fatalf("锁定到期后输错一次：failed_attempts=%d locked=%v，期望 1 且未锁定", admin.FailedAttempts, admin.IsLocked())
+	}
+	loginAdmin(t, s)
+}
+
+func TestConcurrentLoginFailuresAreAllCounted(t *testing.T) {
+	s := newTestAuthService(t)
+	s.config.Auth.MaxFailedAttempts = 0
+	s.config.Auth.AlertThreshold = 0
+
+	// 并发的失败登录各自读取到相同的计数，计数必须在数据库中递增
+	const attempts = 8
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Login(&model.LoginRequest{Username: "admin", Password: "wrong"}, "127.0.0.1", "test")
+		}()
+	}
+	wg.Wait()
+
+	if got := testAdmin(t, s.db).FailedAttempts; got != attempts {
+		t.Fatalf("failed_attempts = %d，期望 %d", got, attempts)
+	}
+}
+
+func TestChangePasswordRejectsWeakPassword(t *testing.T) {
+	s := newTestAuthService(t)
+	admin := testAdmin(t, s.db)
+
+	req := &model.ChangePasswordRequest{OldPassword: testAdminPassword, NewPassword: "password"}
+	if err := s.ChangePassword(admin.ID, req, "127.0.0.1", "test"); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("修改为弱密码返回 %v，期望 ErrInvalidPassword", err)
+	}
+	loginAdmin(t, s)
+
+	req.NewPassword = "Stronger#2024"
+	if err := s.ChangePassword(admin.ID, req, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("修改密码失败: %v", err)
+	}
+	if _, err := s.Login(&model.LoginRequest{Username: "admin", Password: req.NewPassword}, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("新密码登录失败: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"web-panel-go/internal/logger"
+)
+
+// 校验和计算错误
+var (
+	ErrChecksumTooLarge             = errors.New("文件过大，无法计算校验和")
+	ErrChecksumTimeout              = errors.New("计算校验和超时")
+	ErrUnsupportedChecksumAlgorithm = errors.New("不支持的校验算法")
+	ErrChecksumDirectory            = errors.New("不能计算目录的校验和")
+)
+
+// DefaultChecksumAlgorithm 默认校验算法，上传响应中的校验和也使用该算法
+const DefaultChecksumAlgorithm = "sha256"
+
+// checksumAlgorithms 支持的校验和算法
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// checksumHex 返回哈希的十六进制摘要
+func checksumHex(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newUploadChecksum 创建上传时边写边算的哈希，结果与 ComputeChecksum 的默认算法一致
+func newUploadChecksum() hash.Hash {
+	return checksumAlgorithms[DefaultChecksumAlgorithm]()
+}
+
+// ComputeChecksum 流式计算文件校验和，支持 md5、sha1、sha256
+// 计算时间超过 file.checksum_timeout 或 ctx 取消时中止
+func (f *FileService) ComputeChecksum(ctx context.Context, path, algorithm string, userID uint, clientIP, userAgent string) (string, error) {
+	algorithm = strings.ToLower(algorithm)
+	newHash, ok := checksumAlgorithms[algorithm]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedChecksumAlgorithm, algorithm)
+	}
+
+	if !f.isValidPath(path) {
+		f.logAuditAction(userID, "checksum_file", "file", fmt.Sprintf("计算校验和失败: 无效路径 %s (%s)", path, algorithm), clientIP, userAgent, "failed")
+		return "", ErrInvalidPath
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		f.logAuditAction(userID, "checksum_file", "file", fmt.Sprintf("计算校验和失败: 文件不存在 %s (%s)", path, algorithm), clientIP, userAgent, "failed")
+		return "", ErrFileNotFound
+	}
+	if info.IsDir() {
+		return "", ErrChecksumDirectory
+	}
+	if limit := f.config.File.MaxChecksumSize; limit > 0 && info.Size() > limit {
+		f.logAuditAction(userID, "checksum_file", "file", fmt.Sprintf("计算校验和失败: 文件过大 %s (%s, %d bytes)", path, algorithm, info.Size()), clientIP, userAgent, "failed")
+		return "", ErrChecksumTooLarge
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if timeout := f.config.File.ChecksumTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	h := newHash()
+	if _, err := io.Copy(h, &contextReader{ctx: ctx, r: file}); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			f.logAuditAction(userID, "checksum_file", "file", fmt.Sprintf("计算校验和失败: 超时 %s (%s, %d bytes)", path, algorithm, info.Size()), clientIP, userAgent, "failed")
+			return "", ErrChecksumTimeout
+		}
+		f.logAuditAction(userID, "checksum_file", "file", fmt.Sprintf("计算校验和失败: %s (%s), 错误: %v", path, algorithm, err), clientIP, userAgent, "failed")
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	checksum := checksumHex(h)
+
+	f.logAuditAction(userID, "checksum_file", "file", fmt.Sprintf("计算校验和: %s (%s)", path, algorithm), clientIP, userAgent, "success")
+	logger.Info("计算文件校验和", "path", path, "algorithm", algorithm, "size", info.Size(), "user_id", userID)
+	return checksum, nil
+}
+
+// contextReader 每次读取前检查上下文，用于中止长时间的流式读取
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
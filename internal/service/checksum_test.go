@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComputeChecksumKnownContent(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	path := filepath.Join(root, "hello.txt")
+	if err := os.WriteFile(path, []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"sha256": "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		"SHA256": "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		"md5":    "5eb63bbbe01eeed093cb22bb8f5acdc3",
+		"sha1":   "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed",
+	}
+	for algorithm, want := range tests {
+		got, err := f.ComputeChecksum(context.Background(), path, algorithm, admin.ID, "127.0.0.1", "test")
+		if err != nil {
+			t.Fatalf("%s 计算失败: %v", algorithm, err)
+		}
+		if got != want {
+			t.Errorf("%s = %s, 期望 %s", algorithm, got, want)
+		}
+	}
+}
+
+func TestComputeChecksumRejectsInvalidInput(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	path := filepath.Join(root, "data.bin")
+	if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	compute := func(path, algorithm string) error {
+		_, err := f.ComputeChecksum(context.Background(), path, algorithm, admin.ID, "127.0.0.1", "test")
+		return err
+	}
+
+	if err := compute(path, "crc32"); !errors.Is(err, ErrUnsupportedChecksumAlgorithm) {
+		t.Fatalf("不支持的算法返回 %v", err)
+	}
+	if err := compute(root, "sha256"); !errors.Is(err, ErrChecksumDirectory) {
+		t.Fatalf("目录返回 %v", err)
+	}
+	if err := compute(filepath.Join(root, "missing"), "sha256"); !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("不存在的文件返回 %v", err)
+	}
+
+	f.config.File.MaxChecksumSize = 5
+	if err := compute(path, "sha256"); !errors.Is(err, ErrChecksumTooLarge) {
+		t.Fatalf("超出大小上限返回 %v", err)
+	}
+}
+
+func TestCompleteUploadChecksumMatchesComputeChecksum(t *testing.T) {
+	s, root, admin := newTestChunkedUploadService(t)
+	uploadID := initTestUpload(t, s, root, admin.ID, 11, 1)
+	if err := s.UploadChunk(uploadID, 0, strings.NewReader("hello world"), admin.ID); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.CompleteUpload(uploadID, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("完成上传失败: %v", err)
+	}
+
+	want, err := s.files.ComputeChecksum(context.Background(), resp.Path, DefaultChecksumAlgorithm, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Checksum != want {
+		t.Fatalf("上传校验和 = %s, 期望 %s", resp.Checksum, want)
+	}
+}
@@ -0,0 +1,537 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+)
+
+// 分片上传错误
+var (
+	ErrUploadNotFound     = errors.New("上传任务不存在或已过期")
+	ErrChunkTooLarge      = errors.New("分片大小超出限制")
+	ErrChunkOutOfRange    = errors.New("分片序号超出范围")
+	ErrChunksIncomplete   = errors.New("分片不完整")
+	ErrUploadSizeExceeded = errors.New("已上传的数据超过声明的文件大小")
+	ErrInvalidUploadSize  = errors.New("无效的文件大小或分片数量")
+)
+
+// MaxUploadChunks 单个分片上传任务允许的最大分片数
+const MaxUploadChunks = 10000
+
+// uploadIDPattern 上传ID格式，防止通过上传ID构造路径
+var uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// chunkUploadMeta 分片上传任务元数据，保存在临时目录的 meta.json 中
+type chunkUploadMeta struct {
+	ID          string    `json:"id"`
+	UserID      uint      `json:"user_id"`
+	TargetDir   string    `json:"target_dir"`
+	FileName    string    `json:"file_name"`
+	TotalSize   int64     `json:"total_size"`
+	TotalChunks int       `json:"total_chunks"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	ReceivedBytes int64         `json:"received_bytes"`        // 已接收分片的总字节数
+	ChunkSizes    map[int]int64 `json:"chunk_sizes,omitempty"` // 已接收分片的大小，按序号
+}
+
+// ChunkedUploadService 分片上传服务
+// 分片保存在 <data_dir>/.uploads/<upload_id>/ 下，完成时按序号拼接后移动到目标路径
+type ChunkedUploadService struct {
+	config *config.Config
+	files  *FileService
+	locks  *PathLocker // 按上传任务串行化元数据更新、完成和清理，不同上传互不阻塞
+}
+
+// NewChunkedUploadService 创建分片上传服务实例
+func NewChunkedUploadService(cfg *config.Config, files *FileService) *ChunkedUploadService {
+	return &ChunkedUploadService{
+		config: cfg,
+		files:  files,
+		locks:  NewPathLocker(),
+	}
+}
+
+// tempDir 分片临时目录
+func (s *ChunkedUploadService) tempDir() string {
+	return filepath.Join(s.config.System.DataDir, ".uploads")
+}
+
+// uploadDir 指定上传任务的临时目录
+func (s *ChunkedUploadService) uploadDir(uploadID string) string {
+	return filepath.Join(s.tempDir(), uploadID)
+}
+
+// chunkPath 分片文件路径
+func (s *ChunkedUploadService) chunkPath(uploadID string, index int) string {
+	return filepath.Join(s.uploadDir(uploadID), fmt.Sprintf("chunk_%06d", index))
+}
+
+// InitUpload 初始化分片上传任务
+func (s *ChunkedUploadService) InitUpload(req *model.ChunkUploadInitRequest, userID uint, clientIP, userAgent string) (*model.ChunkUploadInitResponse, error) {
+	if !s.files.isValidPath(req.Path) || !isValidFileName(req.FileName) {
+		s.files.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("初始化分片上传失败: 无效路径 %s/%s", req.Path, req.FileName), clientIP, userAgent, "failed")
+		return nil, ErrInvalidPath
+	}
+
+	// 每个分片至少1字节，分片数不能超过文件大小
+	if req.TotalSize < 1 || req.TotalChunks < 1 || req.TotalChunks > MaxUploadChunks || int64(req.TotalChunks) > req.TotalSize {
+		return nil, ErrInvalidUploadSize
+	}
+	chunkSize := s.config.File.ChunkSize
+	if chunkSize > 0 && req.TotalSize > chunkSize*int64(req.TotalChunks) {
+		return nil, fmt.Errorf("%w: 分片数量不足以容纳文件大小", ErrInvalidUploadSize)
+	}
+
+	targetPath := filepath.Join(req.Path, req.FileName)
+	if _, err := os.Stat(targetPath); !os.IsNotExist(err) {
+		s.files.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("初始化分片上传失败: 文件已存在 %s", targetPath), clientIP, userAgent, "failed")
+		return nil, ErrFileExists
+	}
+
+	// 提前检查磁盘空间，分片和最终文件各占一份
+	if err := s.files.diskGuard.Check(s.config.System.DataDir, req.TotalSize); err != nil {
+		return nil, err
+	}
+	if err := s.files.diskGuard.Check(req.Path, req.TotalSize); err != nil {
+		return nil, err
+	}
+
+	uploadID, err := generateUploadID()
+	if err != nil {
+		return nil, fmt.Errorf("生成上传ID失败: %w", err)
+	}
+
+	meta := &chunkUploadMeta{
+		ID:          uploadID,
+		UserID:      userID,
+		TargetDir:   filepath.Clean(req.Path),
+		FileName:    req.FileName,
+		TotalSize:   req.TotalSize,
+		TotalChunks: req.TotalChunks,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := os.MkdirAll(s.uploadDir(uploadID), 0700); err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	if err := s.writeMeta(meta); err != nil {
+		os.RemoveAll(s.uploadDir(uploadID))
+		return nil, err
+	}
+
+	logger.Info("分片上传已初始化", "upload_id", uploadID, "path", targetPath, "chunks", req.TotalChunks, "user_id", userID)
+	return &model.ChunkUploadInitResponse{
+		UploadID:  uploadID,
+		ChunkSize: chunkSize,
+		ExpiresAt: s.expiresAt(meta),
+	}, nil
+}
+
+// UploadChunk 保存一个分片，重复上传同一序号时覆盖之前的内容（用于断点续传重试）
+// 已接收的字节数记录在元数据中，累计超过声明的文件大小时拒绝分片
+func (s *ChunkedUploadService) UploadChunk(uploadID string, index int, data io.Reader, userID uint) error {
+	limit, err := s.chunkLimit(uploadID, index, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.files.diskGuard.Check(s.tempDir(), limit); err != nil {
+		return err
+	}
+
+	// 先写入临时文件，记账成功后再重命名，避免中断的请求留下不完整的分片
+	// 同一序号的并发重试各自写入不同的临时文件
+	dst, err := os.CreateTemp(s.uploadDir(uploadID), fmt.Sprintf("chunk_%06d.part-*", index))
+	if err != nil {
+		return fmt.Errorf("创建分片文件失败: %w", err)
+	}
+	tmpPath := dst.Name()
+
+	written, err := io.Copy(dst, io.LimitReader(data, limit+1))
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入分片失败: %w", err)
+	}
+	if written > limit {
+		os.Remove(tmpPath)
+		if s.config.File.ChunkSize > 0 && written > s.config.File.ChunkSize {
+			return ErrChunkTooLarge
+		}
+		return ErrUploadSizeExceeded
+	}
+
+	if err := s.commitChunk(uploadID, index, tmpPath, written, userID); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// chunkLimit 返回分片最多可写入的字节数：不超过单个分片上限，也不超过声明大小中尚未接收的部分
+func (s *ChunkedUploadService) chunkLimit(uploadID string, index int, userID uint) (int64, error) {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return 0, ErrUploadNotFound
+	}
+	unlock := s.locks.Lock(s.uploadDir(uploadID))
+	defer unlock()
+
+	meta, err := s.loadMeta(uploadID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if index < 0 || index >= meta.TotalChunks {
+		return 0, ErrChunkOutOfRange
+	}
+
+	limit := meta.TotalSize - (meta.ReceivedBytes - meta.ChunkSizes[index])
+	if chunkSize := s.config.File.ChunkSize; chunkSize > 0 && chunkSize < limit {
+		limit = chunkSize
+	}
+	return limit, nil
+}
+
+// commitChunk 重新读取元数据确认加上本分片后仍不超过声明大小，把分片移动到位并更新已接收字节数
+// 同一上传的分片可能并发写入，写入前的检查不能保证总量，这里在锁内再次检查
+func (s *ChunkedUploadService) commitChunk(uploadID string, index int, tmpPath string, size int64, userID uint) error {
+	unlock := s.locks.Lock(s.uploadDir(uploadID))
+	defer unlock()
+
+	meta, err := s.loadMeta(uploadID, userID)
+	if err != nil {
+		return err
+	}
+	received := meta.ReceivedBytes - meta.ChunkSizes[index] + size
+	if received > meta.TotalSize {
+		return ErrUploadSizeExceeded
+	}
+
+	if err := os.Rename(tmpPath, s.chunkPath(uploadID, index)); err != nil {
+		return fmt.Errorf("保存分片失败: %w", err)
+	}
+	if meta.ChunkSizes == nil {
+		meta.ChunkSizes = make(map[int]int64)
+	}
+	meta.ChunkSizes[index] = size
+	meta.ReceivedBytes = received
+	return s.writeMeta(meta)
+}
+
+// CompleteUpload 校验所有分片均已上传，按序号拼接后移动到目标路径
+func (s *ChunkedUploadService) CompleteUpload(uploadID string, userID uint, clientIP, userAgent string) (*model.ChunkUploadCompleteResponse, error) {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return nil, ErrUploadNotFound
+	}
+	unlock := s.locks.Lock(s.uploadDir(uploadID))
+	defer unlock()
+
+	meta, err := s.loadMeta(uploadID, userID)
+	if err != nil {
+		return nil, err
+	}
+	targetPath := filepath.Join(meta.TargetDir, meta.FileName)
+
+	// 校验分片是否齐全
+	var totalSize int64
+	var missing []int
+	for i := 0; i < meta.TotalChunks; i++ {
+		info, err := os.Stat(s.chunkPath(uploadID, i))
+		if err != nil {
+			missing = append(missing, i)
+			continue
+		}
+		totalSize += info.Size()
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: 缺少分片 %v", ErrChunksIncomplete, missing)
+	}
+	if totalSize != meta.TotalSize {
+		return nil, fmt.Errorf("%w: 分片总大小 %d 与文件大小 %d 不一致", ErrChunksIncomplete, totalSize, meta.TotalSize)
+	}
+
+	if err := os.MkdirAll(meta.TargetDir, 0755); err != nil {
+		s.files.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 创建目录失败 %s, 错误: %v", meta.TargetDir, err), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	if _, err := os.Stat(targetPath); !os.IsNotExist(err) {
+		s.files.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 文件已存在 %s", targetPath), clientIP, userAgent, "failed")
+		return nil, ErrFileExists
+	}
+	if err := s.files.diskGuard.Check(meta.TargetDir, totalSize); err != nil {
+		s.files.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 磁盘空间不足 %s (大小: %d bytes)", targetPath, totalSize), clientIP, userAgent, "failed")
+		return nil, err
+	}
+
+	// 在目标目录中拼接到临时文件，完成后原子重命名
+	assembled, err := os.CreateTemp(meta.TargetDir, "."+meta.FileName+".upload-*")
+	if err != nil {
+		s.files.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 创建文件失败 %s, 错误: %v", targetPath, err), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("创建文件失败: %w", err)
+	}
+	assembledPath := assembled.Name()
+
+	h := newUploadChecksum()
+	err = s.assembleChunks(io.MultiWriter(assembled, h), uploadID, meta.TotalChunks)
+	if closeErr := assembled.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(assembledPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(assembledPath, targetPath)
+	}
+	if err != nil {
+		os.Remove(assembledPath)
+		s.files.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 合并分片失败 %s, 错误: %v", targetPath, err), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("合并分片失败: %w", err)
+	}
+
+	if err := os.RemoveAll(s.uploadDir(uploadID)); err != nil {
+		logger.Warn("清理分片临时目录失败", "upload_id", uploadID, "error", err)
+	}
+
+	s.files.logAuditAction(userID, "upload_file", "file", fileAuditDetails("上传文件", targetPath, totalSize), clientIP, userAgent, "success")
+	logger.Info("分片上传完成", "upload_id", uploadID, "path", targetPath, "size", totalSize, "chunks", meta.TotalChunks, "user_id", userID)
+	return &model.ChunkUploadCompleteResponse{
+		Path:     targetPath,
+		Size:     totalSize,
+		Checksum: checksumHex(h),
+	}, nil
+}
+
+// assembleChunks 按序号把分片依次写入目标文件
+func (s *ChunkedUploadService) assembleChunks(dst io.Writer, uploadID string, totalChunks int) error {
+	for i := 0; i < totalChunks; i++ {
+		chunk, err := os.Open(s.chunkPath(uploadID, i))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, chunk)
+		chunk.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListUploads 列出进行中的分片上传，all 为false时只返回 userID 自己的上传
+func (s *ChunkedUploadService) ListUploads(userID uint, all bool) ([]model.UploadSession, error) {
+	entries, err := os.ReadDir(s.tempDir())
+	if os.IsNotExist(err) {
+		return []model.UploadSession{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取分片临时目录失败: %w", err)
+	}
+
+	sessions := make([]model.UploadSession, 0)
+	ownerIDs := make([]uint, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() || !uploadIDPattern.MatchString(entry.Name()) {
+			continue
+		}
+		meta, err := s.readMeta(entry.Name())
+		if err != nil || (!all && meta.UserID != userID) || s.isExpired(meta) {
+			continue
+		}
+
+		// 进度取自元数据中的记账，不逐个检查分片文件
+		sessions = append(sessions, model.UploadSession{
+			UploadID:       meta.ID,
+			TargetPath:     filepath.Join(meta.TargetDir, meta.FileName),
+			TotalSize:      meta.TotalSize,
+			ReceivedBytes:  meta.ReceivedBytes,
+			TotalChunks:    meta.TotalChunks,
+			ReceivedChunks: len(meta.ChunkSizes),
+			OwnerID:        meta.UserID,
+			StartedAt:      meta.CreatedAt,
+			ExpiresAt:      s.expiresAt(meta),
+		})
+		ownerIDs = append(ownerIDs, meta.UserID)
+	}
+
+	// 填充上传者用户名
+	if len(ownerIDs) > 0 {
+		var users []model.User
+		if err := s.files.db.Select("id", "username").Where("id IN ?", ownerIDs).Find(&users).Error; err == nil {
+			names := make(map[uint]string, len(users))
+			for _, user := range users {
+				names[user.ID] = user.Username
+			}
+			for i := range sessions {
+				sessions[i].OwnerName = names[sessions[i].OwnerID]
+			}
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+	return sessions, nil
+}
+
+// CancelUpload 取消分片上传并删除已上传的分片，非管理员只能取消自己的上传
+func (s *ChunkedUploadService) CancelUpload(uploadID string, userID uint, isAdmin bool, clientIP, userAgent string) error {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return ErrUploadNotFound
+	}
+	unlock := s.locks.Lock(s.uploadDir(uploadID))
+	defer unlock()
+
+	meta, err := s.readMeta(uploadID)
+	if err != nil || (!isAdmin && meta.UserID != userID) {
+		return ErrUploadNotFound
+	}
+
+	targetPath := filepath.Join(meta.TargetDir, meta.FileName)
+	if err := os.RemoveAll(s.uploadDir(uploadID)); err != nil {
+		s.files.logAuditAction(userID, "cancel_upload", "file", fmt.Sprintf("取消上传失败: %s (上传ID: %s), 错误: %v", targetPath, uploadID, err), clientIP, userAgent, "failed")
+		return fmt.Errorf("清理分片失败: %w", err)
+	}
+
+	s.files.logAuditAction(userID, "cancel_upload", "file", fmt.Sprintf("取消上传: %s (上传ID: %s, 上传者ID: %d)", targetPath, uploadID, meta.UserID), clientIP, userAgent, "success")
+	logger.Info("分片上传已取消", "upload_id", uploadID, "path", targetPath, "owner_id", meta.UserID, "user_id", userID)
+	return nil
+}
+
+// CleanupExpired 清理超过保留时间仍未完成的分片上传
+func (s *ChunkedUploadService) CleanupExpired() error {
+	if s.config.File.ChunkUploadTTL <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(s.tempDir())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取分片临时目录失败: %w", err)
+	}
+
+	cutoff := time.Now().Add(-s.config.File.ChunkUploadTTL)
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if s.removeIfExpired(entry.Name(), cutoff) {
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		logger.Info("已清理过期分片上传", "count", removed)
+	}
+	return nil
+}
+
+// removeIfExpired 在上传任务的锁内确认已过期后删除，避免与正在完成的上传冲突
+func (s *ChunkedUploadService) removeIfExpired(uploadID string, cutoff time.Time) bool {
+	dir := filepath.Join(s.tempDir(), uploadID)
+	unlock := s.locks.Lock(dir)
+	defer unlock()
+
+	// 目录修改时间在写入分片时更新，以元数据的创建时间为准
+	if meta, err := s.readMeta(uploadID); err == nil && meta.CreatedAt.After(cutoff) {
+		return false
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Warn("清理过期分片上传失败", "upload_id", uploadID, "error", err)
+		return false
+	}
+	return true
+}
+
+// loadMeta 读取上传任务元数据并校验所属用户和有效期
+func (s *ChunkedUploadService) loadMeta(uploadID string, userID uint) (*chunkUploadMeta, error) {
+	if !uploadIDPattern.MatchString(uploadID) {
+		return nil, ErrUploadNotFound
+	}
+
+	meta, err := s.readMeta(uploadID)
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+	if meta.UserID != userID || s.isExpired(meta) {
+		return nil, ErrUploadNotFound
+	}
+	return meta, nil
+}
+
+// isExpired 上传是否已超过保留时间
+func (s *ChunkedUploadService) isExpired(meta *chunkUploadMeta) bool {
+	return s.config.File.ChunkUploadTTL > 0 && time.Since(meta.CreatedAt) > s.config.File.ChunkUploadTTL
+}
+
+// expiresAt 上传的过期时间，未配置保留时间时永不过期，返回nil
+func (s *ChunkedUploadService) expiresAt(meta *chunkUploadMeta) *time.Time {
+	if s.config.File.ChunkUploadTTL <= 0 {
+		return nil
+	}
+	expiresAt := meta.CreatedAt.Add(s.config.File.ChunkUploadTTL)
+	return &expiresAt
+}
+
+// readMeta 读取 meta.json
+func (s *ChunkedUploadService) readMeta(uploadID string) (*chunkUploadMeta, error) {
+	data, err := os.ReadFile(filepath.Join(s.uploadDir(uploadID), "meta.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var meta chunkUploadMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// writeMeta 写入 meta.json
+func (s *ChunkedUploadService) writeMeta(meta *chunkUploadMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("序列化上传信息失败: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.uploadDir(meta.ID), "meta.json"), data, 0600); err != nil {
+		return fmt.Errorf("保存上传信息失败: %w", err)
+	}
+	return nil
+}
+
+// generateUploadID 生成随机上传ID
+func generateUploadID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// isValidFileName 检查文件名不包含路径分隔符
+func isValidFileName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, `/\`)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"web-panel-go/internal/model"
+)
+
+// newTestChunkedUploadService 创建分片上传服务，返回服务、文件根目录和默认管理员
+func newTestChunkedUploadService(t *testing.T) (*ChunkedUploadService, string, *model.User) {
+	t.Helper()
+	f, root, admin := newTestFileService(t)
+	return NewChunkedUploadService(f.config, f), root, admin
+}
+
+// initTestUpload 在根目录初始化一个分片上传任务
+func initTestUpload(t *testing.T, s *ChunkedUploadService, root string, userID uint, totalSize int64, totalChunks int) string {
+	t.Helper()
+	resp, err := s.InitUpload(&model.ChunkUploadInitRequest{
+		Path:        root,
+		FileName:    "data.bin",
+		TotalSize:   totalSize,
+		TotalChunks: totalChunks,
+	}, userID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("初始化上传失败: %v", err)
+	}
+	return resp.UploadID
+}
+
+func TestInitUploadRejectsInvalidSize(t *testing.T) {
+	s, root, admin := newTestChunkedUploadService(t)
+
+	tests := []struct {
+		name        string
+		totalSize   int64
+		totalChunks int
+	}{
+		{"零字节", 0, 1},
+		{"分片数超过上限", MaxUploadChunks + 1, MaxUploadChunks + 1},
+		{"分片数大于文件大小", 2, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.InitUpload(&model.ChunkUploadInitRequest{
+				Path:        root,
+				FileName:    "data.bin",
+				TotalSize:   tt.totalSize,
+				TotalChunks: tt.totalChunks,
+			}, admin.ID, "127.0.0.1", "test")
+			if !errors.Is(err, ErrInvalidUploadSize) {
+				t.Fatalf("期望 ErrInvalidUploadSize，实际 %v", err)
+			}
+		})
+	}
+}
+
+func TestUploadChunkRejectsDataPastTotalSize(t *testing.T) {
+	s, root, admin := newTestChunkedUploadService(t)
+	uploadID := initTestUpload(t, s, root, admin.ID, 10, 2)
+
+	if err := s.UploadChunk(uploadID, 0, strings.NewReader("123456"), admin.ID); err != nil {
+		t.Fatalf("上传第一个分片失败: %v", err)
+	}
+	if err := s.UploadChunk(uploadID, 1, strings.NewReader("12345"), admin.ID); !errors.Is(err, ErrUploadSizeExceeded) {
+		t.Fatalf("期望 ErrUploadSizeExceeded，实际 %v", err)
+	}
+	if _, err := os.Stat(s.chunkPath(uploadID, 1)); !os.IsNotExist(err) {
+		t.Fatalf("超出大小的分片不应保留: %v", err)
+	}
+
+	// 重传同一序号时按替换计算，不重复累计
+	if err := s.UploadChunk(uploadID, 0, strings.NewReader("12345"), admin.ID); err != nil {
+		t.Fatalf("重传第一个分片失败: %v", err)
+	}
+	if err := s.UploadChunk(uploadID, 1, strings.NewReader("67890"), admin.ID); err != nil {
+		t.Fatalf("上传第二个分片失败: %v", err)
+	}
+	meta, err := s.readMeta(uploadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.ReceivedBytes != 10 {
+		t.Fatalf("已接收字节数 = %d, 期望 10", meta.ReceivedBytes)
+	}
+
+	resp, err := s.CompleteUpload(uploadID, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("完成上传失败: %v", err)
+	}
+	data, err := os.ReadFile(resp.Path)
+	if err != nil || string(data) != "1234567890" {
+		t.Fatalf("合并后的内容 = %q, %v", data, err)
+	}
+}
+
+func TestUploadChunkConcurrentChunksStayWithinTotalSize(t *testing.T) {
+	s, root, admin := newTestChunkedUploadService(t)
+	uploadID := initTestUpload(t, s, root, admin.ID, 8, 4)
+
+	// 每个分片单独看都在剩余空间内，但总量超过声明大小，最多只能接受其中两个
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.UploadChunk(uploadID, i, strings.NewReader("abcd"), admin.ID)
+		}(i)
+	}
+	wg.Wait()
+
+	accepted := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			accepted++
+		case !errors.Is(err, ErrUploadSizeExceeded):
+			t.Fatalf("意外错误: %v", err)
+		}
+	}
+	meta, err := s.readMeta(uploadID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted > 2 || meta.ReceivedBytes != int64(accepted*4) {
+		t.Fatalf("接受了 %d 个分片，已接收字节数 %d", accepted, meta.ReceivedBytes)
+	}
+
+	// 临时分片文件不应残留
+	leftovers, _ := filepath.Glob(filepath.Join(s.uploadDir(uploadID), "*.part-*"))
+	if len(leftovers) > 0 {
+		t.Fatalf("残留临时分片: %v", leftovers)
+	}
+}
+
+func TestListUploadsScopeAndProgress(t *testing.T) {
+	s, root, admin := newTestChunkedUploadService(t)
+	otherID := admin.ID + 100
+	own := initTestUpload(t, s, root, admin.ID, 10, 2)
+	other := initTestUpload(t, s, root, otherID, 4, 1)
+	if err := s.UploadChunk(own, 1, strings.NewReader("abcd"), admin.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := s.ListUploads(admin.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].UploadID != own {
+		t.Fatalf("非管理员只应看到自己的上传: %+v", sessions)
+	}
+	got := sessions[0]
+	if got.ReceivedBytes != 4 || got.ReceivedChunks != 1 || got.TotalSize != 10 || got.OwnerName != "admin" {
+		t.Fatalf("上传进度不正确: %+v", got)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(got.StartedAt.Add(s.config.File.ChunkUploadTTL)) {
+		t.Fatalf("过期时间不正确: %v", got.ExpiresAt)
+	}
+
+	all, err := s.ListUploads(admin.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[1].UploadID != other {
+		t.Fatalf("管理员应看到全部上传: %+v", all)
+	}
+}
+
+func TestListUploadsOmitsExpiryWithoutTTL(t *testing.T) {
+	s, root, admin := newTestChunkedUploadService(t)
+	s.config.File.ChunkUploadTTL = 0
+	initTestUpload(t, s, root, admin.ID, 4, 1)
+
+	sessions, err := s.ListUploads(admin.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].ExpiresAt != nil {
+		t.Fatalf("未配置保留时间时不应返回过期时间: %+v", sessions)
+	}
+
+	// 未配置保留时间时清理任务不删除任何上传
+	if err := s.CleanupExpired(); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := s.ListUploads(admin.ID, false); len(sessions) != 1 {
+		t.Fatalf("上传不应被清理: %+v", sessions)
+	}
+}
+
+func TestCancelUpload(t *testing.T) {
+	s, root, admin := newTestChunkedUploadService(t)
+	otherID := admin.ID + 100
+	uploadID := initTestUpload(t, s, root, admin.ID, 4, 1)
+	if err := s.UploadChunk(uploadID, 0, strings.NewReader("abcd"), admin.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CancelUpload(uploadID, otherID, false, "127.0.0.1", "test"); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("其他用户不应能取消上传: %v", err)
+	}
+	if err := s.CancelUpload(uploadID, admin.ID, false, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("取消上传失败: %v", err)
+	}
+	if _, err := os.Stat(s.uploadDir(uploadID)); !os.IsNotExist(err) {
+		t.Fatalf("取消后分片目录应被删除: %v", err)
+	}
+	if err := s.UploadChunk(uploadID, 0, strings.NewReader("abcd"), admin.ID); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("取消后上传分片应返回 ErrUploadNotFound: %v", err)
+	}
+
+	// 管理员可以取消其他用户的上传
+	other := initTestUpload(t, s, root, otherID, 4, 1)
+	if err := s.CancelUpload(other, admin.ID, true, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("管理员取消上传失败: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"web-panel-go/internal/model"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnsupportedConfigType 不支持的配置文件类型
+var ErrUnsupportedConfigType = errors.New("不支持的配置文件类型")
+
+// yamlLinePattern 从YAML错误信息中提取行号
+var yamlLinePattern = regexp.MustCompile(`line (\d+)(?: column (\d+))?: (.+)`)
+
+// ValidateConfig 校验配置文件语法，只解析不写入
+// 支持 yaml、json、toml、ini，返回带行号的语法错误
+func (f *FileService) ValidateConfig(content, configType string) (*model.ValidateConfigResponse, error) {
+	configType = strings.ToLower(strings.TrimSpace(configType))
+
+	var syntaxErrors []model.ConfigSyntaxError
+	switch configType {
+	case "yaml", "yml":
+		configType = "yaml"
+		syntaxErrors = validateYAML(content)
+	case "json":
+		syntaxErrors = validateJSON(content)
+	case "toml":
+		syntaxErrors = validateTOML(content)
+	case "ini":
+		syntaxErrors = validateINI(content)
+	default:
+		return nil, ErrUnsupportedConfigType
+	}
+
+	return &model.ValidateConfigResponse{
+		Valid:  len(syntaxErrors) == 0,
+		Type:   configType,
+		Errors: syntaxErrors,
+	}, nil
+}
+
+// validateYAML 校验YAML语法，支持多文档
+func validateYAML(content string) []model.ConfigSyntaxError {
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			var result []model.ConfigSyntaxError
+			for _, message := range typeErr.Errors {
+				result = append(result, parseYAMLError(message))
+			}
+			return result
+		}
+		return []model.ConfigSyntaxError{parseYAMLError(err.Error())}
+	}
+}
+
+// parseYAMLError 解析YAML错误信息中的行列号
+func parseYAMLError(message string) model.ConfigSyntaxError {
+	message = strings.TrimPrefix(message, "yaml: ")
+	matches := yamlLinePattern.FindStringSubmatch(message)
+	if matches == nil {
+		return model.ConfigSyntaxError{Message: message}
+	}
+
+	line, _ := strconv.Atoi(matches[1])
+	column, _ := strconv.Atoi(matches[2])
+	return model.ConfigSyntaxError{Line: line, Column: column, Message: matches[3]}
+}
+
+// validateJSON 校验JSON语法
+func validateJSON(content string) []model.ConfigSyntaxError {
+	var value interface{}
+	err := json.Unmarshal([]byte(content), &value)
+	if err == nil {
+		return nil
+	}
+
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	}
+
+	line, column := offsetToPosition(content, offset)
+	return []model.ConfigSyntaxError{{Line: line, Column: column, Message: err.Error()}}
+}
+
+// offsetToPosition 将字节偏移转换为行列号（从1开始）
+func offsetToPosition(content string, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	prefix := []byte(content[:offset])
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	column := len(prefix) - bytes.LastIndexByte(prefix, '\n')
+	if column < 1 {
+		column = 1
+	}
+	return line, column
+}
+
+// validateTOML 校验TOML语法
+func validateTOML(content string) []model.ConfigSyntaxError {
+	var value map[string]interface{}
+	err := toml.Unmarshal([]byte(content), &value)
+	if err == nil {
+		return nil
+	}
+
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		line, column := decodeErr.Position()
+		return []model.ConfigSyntaxError{{Line: line, Column: column, Message: decodeErr.Error()}}
+	}
+	return []model.ConfigSyntaxError{{Message: err.Error()}}
+}
+
+// validateINI 校验INI语法
+func validateINI(content string) []model.ConfigSyntaxError {
+	if _, err := ini.Load([]byte(content)); err != nil {
+		return []model.ConfigSyntaxError{{Message: err.Error()}}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	f, _, _ := newTestFileService(t)
+
+	tests := []struct {
+		name       string
+		configType string
+		content    string
+		valid      bool
+		line       int // 语法错误所在行，0表示不检查
+	}{
+		{"yaml有效", "yaml", "server:\n  port: 80\n  hosts:\n    - a\n    - b\n", true, 0},
+		{"yaml多文档", "yml", "a: 1\n---\nb: 2\n", true, 0},
+		{"yaml缩进错误", "yaml", "a: 1\nb: 2\n  c: 3\n", false, 3},
+		{"yaml引号未闭合", "yaml", "a: 1\nb: \"x\n", false, 2},
+		{"json有效", "json", "{\n  \"port\": 80,\n  \"hosts\": [\"a\"]\n}\n", true, 0},
+		{"json缺少逗号", "json", "{\n  \"port\": 80\n  \"hosts\": []\n}\n", false, 3},
+		{"json未结束", "JSON", "{\"port\": 80", false, 1},
+		{"toml有效", "toml", "[server]\nport = 80\nhosts = [\"a\"]\n", true, 0},
+		{"toml缺少值", "toml", "[server]\nport = \n", false, 2},
+		{"ini有效", "ini", "[server]\nport = 80\n", true, 0},
+		{"ini节名未闭合", "ini", "[server\nport = 80\n", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := f.ValidateConfig(tt.content, tt.configType)
+			if err != nil {
+				t.Fatalf("校验失败: %v", err)
+			}
+			if resp.Valid != tt.valid {
+				t.Fatalf("valid = %t，期望 %t，错误: %+v", resp.Valid, tt.valid, resp.Errors)
+			}
+			if tt.valid {
+				if len(resp.Errors) != 0 {
+					t.Fatalf("有效内容返回了错误: %+v", resp.Errors)
+				}
+				return
+			}
+			if len(resp.Errors) == 0 || resp.Errors[0].Message == "" {
+				t.Fatalf("无效内容缺少错误信息: %+v", resp.Errors)
+			}
+			if tt.line != 0 && resp.Errors[0].Line != tt.line {
+				t.Fatalf("错误行号 = %d，期望 %d: %+v", resp.Errors[0].Line, tt.line, resp.Errors)
+			}
+		})
+	}
+
+	if resp, _ := f.ValidateConfig("a: 1", "YML"); resp.Type != "yaml" {
+		t.Fatalf("yml 应归一为 yaml，实际 %q", resp.Type)
+	}
+	if _, err := f.ValidateConfig("server {}", "nginx"); !errors.Is(err, ErrUnsupportedConfigType) {
+		t.Fatalf("不支持的类型返回 %v，期望 ErrUnsupportedConfigType", err)
+	}
+}
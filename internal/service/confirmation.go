@@ -0,0 +1,103 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// 二次确认错误
+var (
+	ErrInvalidConfirmToken  = errors.New("确认令牌无效或已过期")
+	ErrConfirmationRequired = errors.New("该操作需要二次确认，请先申请确认令牌")
+)
+
+// pendingConfirmation 待确认的危险操作
+type pendingConfirmation struct {
+	userID      uint
+	action      string
+	target      string
+	description string
+	expiresAt   time.Time
+}
+
+// ConfirmationStore 危险操作二次确认令牌存储（内存）
+type ConfirmationStore struct {
+	mutex   sync.Mutex
+	pending  map[string]*pendingConfirmation
+	ttl      time.Duration
+	required map[string]bool // 必须凭确认令牌执行的操作
+}
+
+// NewConfirmationStore 创建确认令牌存储，required 中的操作必须凭确认令牌执行
+func NewConfirmationStore(ttl time.Duration, required []string) *ConfirmationStore {
+	s := &ConfirmationStore{
+		pending:  make(map[string]*pendingConfirmation),
+		ttl:      ttl,
+		required: make(map[string]bool, len(required)),
+	}
+	for _, action := range required {
+		s.required[action] = true
+	}
+	return s
+}
+
+// Required 判断操作是否配置为必须二次确认
+func (s *ConfirmationStore) Required(action string) bool {
+	return s.required[action]
+}
+
+// Issue 为指定用户的操作签发一次性确认令牌
+func (s *ConfirmationStore) Issue(userID uint, action, target, description string) (string, time.Time, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+	expiresAt := time.Now().Add(s.ttl)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cleanupLocked()
+	s.pending[token] = &pendingConfirmation{
+		userID:      userID,
+		action:      action,
+		target:      target,
+		description: description,
+		expiresAt:   expiresAt,
+	}
+	return token, expiresAt, nil
+}
+
+// Consume 校验并消费确认令牌，令牌必须属于同一用户、同一操作和同一目标
+func (s *ConfirmationStore) Consume(token string, userID uint, action, target string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pending, ok := s.pending[token]
+	if !ok {
+		return "", ErrInvalidConfirmToken
+	}
+	delete(s.pending, token)
+
+	if time.Now().After(pending.expiresAt) ||
+		pending.userID != userID ||
+		pending.action != action ||
+		pending.target != target {
+		return "", ErrInvalidConfirmToken
+	}
+	return pending.description, nil
+}
+
+// cleanupLocked 清理过期令牌（调用方需持有锁）
+func (s *ConfirmationStore) cleanupLocked() {
+	now := time.Now()
+	for token, pending := range s.pending {
+		if now.After(pending.expiresAt) {
+			delete(s.pending, token)
+		}
+	}
+}
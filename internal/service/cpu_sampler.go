@@ -0,0 +1,115 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const (
+	// cpuSampleInterval 首次采样时两次读取CPU时间的间隔
+	cpuSampleInterval = 500 * time.Millisecond
+	// cpuSampleStaleAfter 上次采样超过该时间则重新预采样
+	cpuSampleStaleAfter = 30 * time.Second
+)
+
+// cpuSample 进程CPU时间采样
+type cpuSample struct {
+	total      float64 // 用户态+内核态CPU时间（秒）
+	createTime int64   // 进程创建时间，用于识别PID复用
+	at         time.Time
+}
+
+// cpuSampler 进程CPU使用率采样器
+// 按PID保存上一次的CPU时间，使用两次采样的差值计算近期CPU使用率，而不是进程生命周期内的平均值
+type cpuSampler struct {
+	mutex     sync.Mutex
+	samples   map[int32]cpuSample
+	lastSweep time.Time
+}
+
+// newCPUSampler 创建CPU采样器
+func newCPUSampler() *cpuSampler {
+	return &cpuSampler{samples: make(map[int32]cpuSample)}
+}
+
+// readSample 读取进程当前CPU时间
+func readSample(p *process.Process, now time.Time) (cpuSample, bool) {
+	times, err := p.Times()
+	if err != nil {
+		return cpuSample{}, false
+	}
+	createTime, _ := p.CreateTime()
+	return cpuSample{
+		total:      times.User + times.System,
+		createTime: createTime,
+		at:         now,
+	}, true
+}
+
+// prime 如果没有近期采样，先对所有进程采样一次并等待一个采样间隔
+func (s *cpuSampler) prime(processes []*process.Process) {
+	s.mutex.Lock()
+	stale := time.Since(s.lastSweep) > cpuSampleStaleAfter
+	s.mutex.Unlock()
+	if !stale {
+		return
+	}
+
+	now := time.Now()
+	samples := make(map[int32]cpuSample, len(processes))
+	for _, p := range processes {
+		if sample, ok := readSample(p, now); ok {
+			samples[p.Pid] = sample
+		}
+	}
+
+	s.mutex.Lock()
+	s.samples = samples
+	s.mutex.Unlock()
+
+	time.Sleep(cpuSampleInterval)
+}
+
+// percent 计算进程自上次采样以来的CPU使用率（单核100%，多核可超过100%）
+func (s *cpuSampler) percent(p *process.Process) float64 {
+	current, ok := readSample(p, time.Now())
+	if !ok {
+		return 0
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous, exists := s.samples[p.Pid]
+	s.samples[p.Pid] = current
+
+	if !exists || previous.createTime != current.createTime {
+		return 0
+	}
+
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	percent := (current.total - previous.total) / elapsed * 100
+	if percent < 0 {
+		return 0
+	}
+	return percent
+}
+
+// sweep 清理已退出进程的采样并记录本次采样时间
+func (s *cpuSampler) sweep(alive map[int32]bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for pid := range s.samples {
+		if !alive[pid] {
+			delete(s.samples, pid)
+		}
+	}
+	s.lastSweep = time.Now()
+}
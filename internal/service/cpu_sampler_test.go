@@ -0,0 +1,81 @@
+package service
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// startTestProcess 启动子进程，测试结束时结束它
+func startTestProcess(t *testing.T, name string, args ...string) *process.Process {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		t.Skipf("无法启动 %s: %v", name, err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	p, err := process.NewProcess(int32(cmd.Process.Pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestCPUSamplerTracksRecentUsage(t *testing.T) {
+	busy := startTestProcess(t, "sh", "-c", "while :; do :; done")
+	idle := startTestProcess(t, "sleep", "30")
+
+	s := newCPUSampler()
+	s.prime([]*process.Process{busy, idle})
+	if got := s.percent(busy); got < 30 {
+		t.Fatalf("忙循环进程CPU使用率 = %.1f%%，期望接近100%%", got)
+	}
+	if got := s.percent(idle); got > 5 {
+		t.Fatalf("空闲进程CPU使用率 = %.1f%%，期望接近0", got)
+	}
+
+	// 暂停忙循环进程后使用率随之下降，而不是停留在生命周期平均值
+	if err := syscall.Kill(int(busy.Pid), syscall.SIGSTOP); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Kill(int(busy.Pid), syscall.SIGCONT)
+	time.Sleep(50 * time.Millisecond)
+	s.percent(busy)
+	time.Sleep(300 * time.Millisecond)
+	if got := s.percent(busy); got > 5 {
+		t.Fatalf("暂停后CPU使用率 = %.1f%%，期望接近0", got)
+	}
+	// 生命周期平均值此时仍明显大于0，不能反映当前状态
+	if lifetime, err := busy.CPUPercent(); err == nil && lifetime < 10 {
+		t.Fatalf("生命周期平均CPU使用率 = %.1f%%，测试进程未处于忙碌状态", lifetime)
+	}
+}
+
+func TestCPUSamplerResetsOnPIDReuse(t *testing.T) {
+	p := startTestProcess(t, "sleep", "30")
+	s := newCPUSampler()
+	s.percent(p)
+
+	// 同一PID的采样来自另一个进程（创建时间不同）时不计算差值
+	s.mutex.Lock()
+	sample := s.samples[p.Pid]
+	sample.createTime--
+	sample.total = -1000
+	s.samples[p.Pid] = sample
+	s.mutex.Unlock()
+	if got := s.percent(p); got != 0 {
+		t.Fatalf("PID复用后CPU使用率 = %.1f%%，期望 0", got)
+	}
+
+	// 已退出进程的采样在 sweep 时清除
+	s.sweep(map[int32]bool{})
+	if len(s.samples) != 0 {
+		t.Fatalf("sweep 后仍有 %d 个采样", len(s.samples))
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/model"
+)
+
+// 用户名、邮箱、密码校验错误，具体原因包装在错误信息中
+var (
+	ErrInvalidUsername  = errors.New("用户名不合法")
+	ErrReservedUsername = errors.New("用户名为保留名称")
+	ErrInvalidEmail     = errors.New("邮箱不合法")
+	ErrInvalidPassword  = model.ErrInvalidPassword
+)
+
+// 数据库列长度决定的上限，配置值超出时按此截断
+const (
+	maxUsernameColumn = 50
+	maxEmailLength    = 100
+)
+
+// usernamePattern 用户名允许字母、数字、点、下划线和连字符，必须以字母或数字开头
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// IsCredentialValidationError 判断是否为用户名、邮箱或密码校验错误
+func IsCredentialValidationError(err error) bool {
+	return errors.Is(err, ErrInvalidUsername) || errors.Is(err, ErrReservedUsername) ||
+		errors.Is(err, ErrInvalidEmail) || errors.Is(err, ErrInvalidPassword)
+}
+
+// CredentialValidator 用户名、邮箱、密码的统一校验规则（创建、更新、重置和修改密码共用）
+type CredentialValidator struct {
+	usernameMin int
+	usernameMax int
+	policy      config.PasswordPolicyConfig
+	reserved    map[string]bool
+}
+
+// NewCredentialValidator 根据认证配置创建校验器
+func NewCredentialValidator(cfg *config.AuthConfig) *CredentialValidator {
+	v := &CredentialValidator{
+		usernameMin: cfg.UsernameMinLength,
+		usernameMax: cfg.UsernameMaxLength,
+		policy:      cfg.PasswordPolicy,
+		reserved:    make(map[string]bool, len(cfg.ReservedUsernames)),
+	}
+	if v.usernameMin < 1 {
+		v.usernameMin = 1
+	}
+	if v.usernameMax <= 0 || v.usernameMax > maxUsernameColumn {
+		v.usernameMax = maxUsernameColumn
+	}
+	if cfg.PasswordMinLength > v.policy.MinLength {
+		v.policy.MinLength = cfg.PasswordMinLength
+	}
+	for _, name := range cfg.ReservedUsernames {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			v.reserved[name] = true
+		}
+	}
+	return v
+}
+
+// ValidateUsername 校验用户名长度、字符集以及是否为保留名称（不区分大小写）
+func (v *CredentialValidator) ValidateUsername(username string) error {
+	if n := utf8.RuneCountInString(username); n < v.usernameMin || n > v.usernameMax {
+		return fmt.Errorf("%w: 长度需在%d到%d个字符之间", ErrInvalidUsername, v.usernameMin, v.usernameMax)
+	}
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("%w: 只能包含字母、数字、点、下划线和连字符，且以字母或数字开头", ErrInvalidUsername)
+	}
+	if v.reserved[strings.ToLower(username)] {
+		return fmt.Errorf("%w: %s", ErrReservedUsername, username)
+	}
+	return nil
+}
+
+// ValidateEmail 校验邮箱格式和长度，不接受带显示名的地址
+func (v *CredentialValidator) ValidateEmail(email string) error {
+	if email == "" || len(email) > maxEmailLength {
+		return fmt.Errorf("%w: 长度需在1到%d个字符之间", ErrInvalidEmail, maxEmailLength)
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return fmt.Errorf("%w: 格式错误", ErrInvalidEmail)
+	}
+	return nil
+}
+
+// ValidatePassword 按密码策略校验密码，错误信息列出所有未满足的要求
+func (v *CredentialValidator) ValidatePassword(password string) error {
+	return model.ValidatePassword(password, v.policy)
+}
+
+// PasswordPolicy 返回生效的密码策略（已合并废弃的 password_min_length），设置密码时传给 User.SetPassword
+func (v *CredentialValidator) PasswordPolicy() config.PasswordPolicyConfig {
+	return v.policy
+}
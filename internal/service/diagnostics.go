@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// diagnosticsErrorLines 诊断包中包含的最近错误日志条数
+const diagnosticsErrorLines = 100
+
+// redactedValue 脱敏后的占位值
+const redactedValue = "******"
+
+// secretFieldPattern 需要脱敏的配置项（按配置键名匹配，只作用于字符串值，password_policy 等结构和开关照常输出）
+var secretFieldPattern = regexp.MustCompile(`(?i)(secret|password|passwd|token|credential|api_key|private_key|smtp_user)`)
+
+// DiagnosticsService 诊断信息服务
+type DiagnosticsService struct {
+	db     *gorm.DB
+	config *config.Config
+	system *SystemService
+}
+
+// NewDiagnosticsService 创建诊断信息服务实例
+func NewDiagnosticsService(db *gorm.DB, cfg *config.Config, system *SystemService) *DiagnosticsService {
+	return &DiagnosticsService{
+		db:     db,
+		config: cfg,
+		system: system,
+	}
+}
+
+// CollectBundle 收集诊断信息包，单项收集失败时记录到 Errors 中继续收集其他信息
+func (s *DiagnosticsService) CollectBundle(userID uint, clientIP, userAgent string) *model.DiagnosticsBundle {
+	bundle := &model.DiagnosticsBundle{
+		GeneratedAt:  time.Now(),
+		Build:        buildInfo(),
+		Config:       RedactConfig(s.config),
+		RecentErrors: logger.RecentErrors(diagnosticsErrorLines),
+	}
+
+	if host, err := s.system.GetHostInfo(); err != nil {
+		bundle.Errors = append(bundle.Errors, err.Error())
+	} else {
+		bundle.Host = host
+	}
+
+	if stats, err := s.system.GetSystemOverview(); err != nil {
+		bundle.Errors = append(bundle.Errors, err.Error())
+	} else {
+		bundle.Stats = stats
+	}
+
+	if sqlDB, err := s.db.DB(); err != nil {
+		bundle.Errors = append(bundle.Errors, fmt.Sprintf("获取数据库连接失败: %v", err))
+	} else {
+		stats := sqlDB.Stats()
+		bundle.Database = model.DatabasePoolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDuration:       stats.WaitDuration.String(),
+			MaxIdleClosed:      stats.MaxIdleClosed,
+			MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+		}
+	}
+
+	s.logAuditAction(userID, "export_diagnostics", "system", fmt.Sprintf("导出诊断信息 (错误日志: %d 条)", len(bundle.RecentErrors)), clientIP, userAgent, "success")
+	return bundle
+}
+
+// RedactConfig 把配置转换为以配置键名为键的map，密钥、密码等敏感项替换为占位值
+func RedactConfig(cfg *config.Config) interface{} {
+	if cfg == nil {
+		return nil
+	}
+	return redactValue(reflect.ValueOf(*cfg), "")
+}
+
+// redactValue 递归转换配置值，key 为当前字段的配置键名
+func redactValue(v reflect.Value, key string) interface{} {
+	if key != "" && v.Kind() == reflect.String && secretFieldPattern.MatchString(key) {
+		if v.IsZero() {
+			return ""
+		}
+		return redactedValue
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		result := make(map[string]interface{}, v.NumField())
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := field.Tag.Get("mapstructure")
+			if name == "" || name == "-" {
+				name = field.Name
+			}
+			result[name] = redactValue(v.Field(i), name)
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items[i] = redactValue(v.Index(i), "")
+		}
+		return items
+	case reflect.Map:
+		result := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			name := fmt.Sprint(iter.Key().Interface())
+			result[name] = redactValue(iter.Value(), name)
+		}
+		return result
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), key)
+	}
+
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return v.Interface()
+}
+
+// buildInfo 读取版本和构建信息
+func buildInfo() model.BuildInfo {
+	info := model.BuildInfo{
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Module = bi.Main.Path
+	info.Version = bi.Main.Version
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.BuildTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// logAuditAction 记录审计日志
+func (s *DiagnosticsService) logAuditAction(userID uint, action, resource, details, clientIP, userAgent, status string) {
+	auditLog := &model.AuditLog{
+		UserID:    &userID,
+		Action:    action,
+		Resource:  resource,
+		Details:   details,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		Status:    status,
+	}
+
+	if err := s.db.Create(auditLog).Error; err != nil {
+		logger.Error("记录审计日志失败", "error", err)
+	}
+}
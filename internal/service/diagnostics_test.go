@@ -0,0 +1,59 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCollectBundleRedactsSecrets(t *testing.T) {
+	db := newTestDB(t)
+	cfg := newTestConfig(t)
+	cfg.System.LogDir = t.TempDir()
+	cfg.Auth.JWTSecret = "jwt-secret-value"
+	cfg.Database.Seed.AdminPassword = "seed-password-value"
+	cfg.Database.Path = "/var/lib/web-panel/panel.db"
+	s := NewDiagnosticsService(db, cfg, NewSystemService(db, cfg))
+
+	bundle := s.CollectBundle(testAdmin(t, db).ID, "127.0.0.1", "test")
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"jwt-secret-value", "seed-password-value"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("诊断包包含未脱敏的密钥 %q", secret)
+		}
+	}
+
+	config := bundle.Config.(map[string]interface{})
+	auth := config["auth"].(map[string]interface{})
+	if auth["jwt_secret"] != redactedValue {
+		t.Fatalf("jwt_secret = %v, 期望脱敏", auth["jwt_secret"])
+	}
+	database := config["database"].(map[string]interface{})
+	if seed := database["seed"].(map[string]interface{}); seed["admin_password"] != redactedValue {
+		t.Fatalf("admin_password = %v, 期望脱敏", seed["admin_password"])
+	}
+	// 数据库连接信息和非敏感配置原样输出
+	if database["path"] != cfg.Database.Path {
+		t.Fatalf("database.path = %v", database["path"])
+	}
+	policy, ok := auth["password_policy"].(map[string]interface{})
+	if !ok || policy["min_length"] != cfg.Auth.PasswordPolicy.MinLength {
+		t.Fatalf("密码策略不应被脱敏: %v", auth["password_policy"])
+	}
+}
+
+func TestRedactConfigKeepsEmptySecretsEmpty(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Auth.JWTSecret = ""
+
+	auth := RedactConfig(cfg).(map[string]interface{})["auth"].(map[string]interface{})
+	if auth["jwt_secret"] != "" {
+		t.Fatalf("未设置的密钥应输出为空，实际 %v", auth["jwt_secret"])
+	}
+	if RedactConfig(nil) != nil {
+		t.Fatal("nil 配置应返回 nil")
+	}
+}
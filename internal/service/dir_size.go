@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// dirSizeTimeout 计算目录大小的默认超时时间（单次列表请求内所有目录共享）
+	dirSizeTimeout = 5 * time.Second
+	// maxDirSizeCacheEntries 目录大小缓存的最大条目数，超出时清空重建
+	maxDirSizeCacheEntries = 10000
+)
+
+// ErrDirectorySizePartial 目录大小计算超时，只统计了部分文件
+var ErrDirectorySizePartial = errors.New("目录大小计算超时，结果不完整")
+
+// dirSizeEntry 目录大小缓存项
+type dirSizeEntry struct {
+	modTime time.Time
+	size    int64
+}
+
+// dirSizeCache 按路径和修改时间缓存已完整计算的目录大小
+// 注意目录修改时间只反映直接子项的增删，深层文件变化可能需要等缓存被替换后才能体现
+type dirSizeCache struct {
+	mutex   sync.Mutex
+	entries map[string]dirSizeEntry
+}
+
+func newDirSizeCache() *dirSizeCache {
+	return &dirSizeCache{entries: make(map[string]dirSizeEntry)}
+}
+
+func (c *dirSizeCache) get(path string, modTime time.Time) (int64, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[path]
+	if !ok || !entry.modTime.Equal(modTime) {
+		return 0, false
+	}
+	return entry.size, true
+}
+
+func (c *dirSizeCache) set(path string, modTime time.Time, size int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.entries) >= maxDirSizeCacheEntries {
+		c.entries = make(map[string]dirSizeEntry)
+	}
+	c.entries[path] = dirSizeEntry{modTime: modTime, size: size}
+}
+
+// GetDirectorySize 递归计算目录下所有普通文件的总大小
+// 超时时返回已统计的部分大小和 ErrDirectorySizePartial
+func (f *FileService) GetDirectorySize(path string) (int64, error) {
+	if !f.isValidPath(path) {
+		return 0, ErrInvalidPath
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dirSizeTimeout)
+	defer cancel()
+
+	size, complete, err := f.computeDirSize(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	if !complete {
+		return size, ErrDirectorySizePartial
+	}
+	return size, nil
+}
+
+// computeDirSize 计算目录大小，ctx 结束时中止并返回 complete=false
+func (f *FileService) computeDirSize(ctx context.Context, path string) (int64, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false, err
+	}
+	if !info.IsDir() {
+		return info.Size(), true, nil
+	}
+
+	if size, ok := f.dirSizes.get(path, info.ModTime()); ok {
+		return size, true, nil
+	}
+
+	var size int64
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			// 跳过无法访问的子目录
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if fi, err := d.Info(); err == nil {
+				size += fi.Size()
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return size, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	f.dirSizes.set(path, info.ModTime(), size)
+	return size, true, nil
+}
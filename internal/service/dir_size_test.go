@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSizedFile 创建指定大小的文件，必要时创建上级目录
+func writeSizedFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetDirectorySizeRecursesAndCaches(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	dir := filepath.Join(root, "data")
+	writeSizedFile(t, filepath.Join(dir, "a.txt"), 10)
+	writeSizedFile(t, filepath.Join(dir, "sub", "b.txt"), 20)
+	writeSizedFile(t, filepath.Join(dir, "sub", "deep", "c.txt"), 30)
+	// 符号链接不计入大小
+	if err := os.Symlink(filepath.Join(dir, "a.txt"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := f.GetDirectorySize(dir)
+	if err != nil || size != 60 {
+		t.Fatalf("目录大小 = %d, %v，期望 60", size, err)
+	}
+
+	// 目录修改时间未变时使用缓存，深层文件的变化不会立即体现
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(dir, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := f.GetDirectorySize(dir); size != 60 {
+		t.Fatalf("目录大小 = %d，期望 60", size)
+	}
+	writeSizedFile(t, filepath.Join(dir, "sub", "deep", "d.txt"), 40)
+	if size, _ := f.GetDirectorySize(dir); size != 60 {
+		t.Fatalf("修改时间未变时返回 %d，期望缓存的 60", size)
+	}
+
+	// 直接子项变化后修改时间改变，重新计算
+	writeSizedFile(t, filepath.Join(dir, "e.txt"), 5)
+	if size, _ := f.GetDirectorySize(dir); size != 105 {
+		t.Fatalf("修改时间变化后返回 %d，期望重新计算的 105", size)
+	}
+
+	if _, err := f.GetDirectorySize(filepath.Dir(root)); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("根目录之外返回 %v，期望 ErrInvalidPath", err)
+	}
+	if _, err := f.GetDirectorySize(filepath.Join(root, "missing")); err == nil {
+		t.Fatal("不存在的目录应返回错误")
+	}
+}
+
+func TestComputeDirSizePartialOnDeadline(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	dir := filepath.Join(root, "data")
+	writeSizedFile(t, filepath.Join(dir, "a.txt"), 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, complete, err := f.computeDirSize(ctx, dir); err != nil || complete {
+		t.Fatalf("上下文结束时 complete=%v, err=%v，期望返回不完整结果", complete, err)
+	}
+	// 不完整的结果不写入缓存
+	if info, err := os.Stat(dir); err != nil {
+		t.Fatal(err)
+	} else if _, ok := f.dirSizes.get(dir, info.ModTime()); ok {
+		t.Fatal("不完整的结果被缓存")
+	}
+}
+
+func TestListFilesComputeSize(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	writeSizedFile(t, filepath.Join(root, "data", "sub", "a.txt"), 25)
+	writeSizedFile(t, filepath.Join(root, "plain.txt"), 7)
+	opts := ListOptions{SortBy: "name", Order: "asc", ComputeSize: true}
+
+	files, _, _, err := f.ListFiles(root, 1, 0, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name != "data" || files[1].Name != "plain.txt" {
+		t.Fatalf("列表 = %+v", files)
+	}
+	if files[0].Size != 25 || !files[0].Computed {
+		t.Fatalf("目录大小 = %d, computed=%v，期望 25 且已计算", files[0].Size, files[0].Computed)
+	}
+	if files[1].Size != 7 || !files[1].Computed {
+		t.Fatalf("文件大小 = %d, computed=%v", files[1].Size, files[1].Computed)
+	}
+
+	// 未请求计算时目录不标记为已计算
+	opts.ComputeSize = false
+	if files, _, _, _ := f.ListFiles(root, 1, 0, opts); files[0].Computed {
+		t.Fatal("未计算的目录 computed 应为 false")
+	}
+
+	// 清空缓存后请求已取消，目录保持未计算
+	f.dirSizes = newDirSizeCache()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts.ComputeSize, opts.Context = true, ctx
+	files, _, _, err = f.ListFiles(root, 1, 0, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Computed || !files[1].Computed {
+		t.Fatalf("超时后 computed = %v/%v，期望目录未计算、文件已计算", files[0].Computed, files[1].Computed)
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// ErrInsufficientDiskSpace 写入后可用空间将低于保留值
+var ErrInsufficientDiskSpace = errors.New("磁盘空间不足")
+
+// FreeSpaceFunc 获取路径所在文件系统的可用空间（字节）
+type FreeSpaceFunc func(path string) (uint64, error)
+
+// DiskGuard 写入前检查磁盘剩余空间，防止面板把磁盘写满
+type DiskGuard struct {
+	reserve   uint64
+	freeSpace FreeSpaceFunc
+}
+
+// NewDiskGuard 创建磁盘空间检查器，reserve 为必须保留的可用空间，0表示不检查
+func NewDiskGuard(reserve int64, freeSpace FreeSpaceFunc) *DiskGuard {
+	if reserve < 0 {
+		reserve = 0
+	}
+	if freeSpace == nil {
+		freeSpace = diskFreeSpace
+	}
+	return &DiskGuard{
+		reserve:   uint64(reserve),
+		freeSpace: freeSpace,
+	}
+}
+
+// Check 检查向 path 写入 size 字节后可用空间是否仍不低于保留值
+func (g *DiskGuard) Check(path string, size int64) error {
+	if g == nil || g.reserve == 0 {
+		return nil
+	}
+	if size < 0 {
+		size = 0
+	}
+
+	free, err := g.freeSpace(existingAncestor(path))
+	if err != nil {
+		// 无法获取磁盘信息时不阻止写入
+		return nil
+	}
+
+	if free < g.reserve || free-g.reserve < uint64(size) {
+		return ErrInsufficientDiskSpace
+	}
+	return nil
+}
+
+// diskFreeSpace 通过gopsutil获取可用空间
+func diskFreeSpace(path string) (uint64, error) {
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return 0, err
+	}
+	return usage.Free, nil
+}
+
+// existingAncestor 返回路径自身或最近的已存在上级目录，用于定位目标文件系统
+func existingAncestor(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// pathSize 计算文件或目录占用的总字节数（不跟随符号链接）
+func pathSize(path string) int64 {
+	var total int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/model"
+)
+
+// fixedFreeSpace 返回固定可用空间的模拟实现，并记录查询的路径
+func fixedFreeSpace(free uint64, queried *[]string) FreeSpaceFunc {
+	return func(path string) (uint64, error) {
+		if queried != nil {
+			*queried = append(*queried, path)
+		}
+		return free, nil
+	}
+}
+
+func TestDiskGuardCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		reserve int64
+		free    uint64
+		size    int64
+		wantErr bool
+	}{
+		{"空间充足", 1000, 5000, 1000, false},
+		{"写入后恰好等于保留值", 1000, 5000, 4000, false},
+		{"写入后低于保留值", 1000, 5000, 4001, true},
+		{"可用空间已低于保留值", 1000, 500, 0, true},
+		{"未设置保留值", 0, 0, 1 << 30, false},
+		{"负数大小按0计算", 1000, 1000, -100, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewDiskGuard(tt.reserve, fixedFreeSpace(tt.free, nil)).Check(t.TempDir(), tt.size)
+			if got := errors.Is(err, ErrInsufficientDiskSpace); got != tt.wantErr {
+				t.Fatalf("Check 返回 %v，期望空间不足=%v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 无法获取磁盘信息时不阻止写入
+	failing := NewDiskGuard(1000, func(string) (uint64, error) { return 0, errors.New("不支持") })
+	if err := failing.Check(t.TempDir(), 1<<30); err != nil {
+		t.Fatalf("获取磁盘信息失败时返回 %v", err)
+	}
+
+	// 目标路径尚不存在时查询最近的已存在上级目录
+	root := t.TempDir()
+	var queried []string
+	NewDiskGuard(1, fixedFreeSpace(100, &queried)).Check(filepath.Join(root, "a", "b", "c.txt"), 1)
+	if len(queried) != 1 || queried[0] != root {
+		t.Fatalf("查询的路径 = %v，期望 %s", queried, root)
+	}
+}
+
+func TestWritesRefusedWhenDiskLow(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	f.diskGuard = NewDiskGuard(1000, fixedFreeSpace(1500, nil))
+
+	// 保存文件：写入后低于保留值时拒绝，文件不被创建
+	path := filepath.Join(root, "big.conf")
+	if _, err := f.SaveFileContent(path, strings.Repeat("a", 600), "", "", false, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrInsufficientDiskSpace) {
+		t.Fatalf("空间不足时保存返回 %v，期望 ErrInsufficientDiskSpace", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("空间不足时不应创建文件")
+	}
+	if _, err := f.SaveFileContent(path, strings.Repeat("a", 400), "", "", false, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("空间充足时保存失败: %v", err)
+	}
+	// 覆盖已有文件只计算增长部分
+	if _, err := f.SaveFileContent(path, strings.Repeat("b", 800), "", "", true, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("覆盖文件增长 400 字节时失败: %v", err)
+	}
+
+	var entry model.AuditLog
+	if err := f.db.Where("action = ? AND status = ?", "save_file", "failed").First(&entry).Error; err != nil || !strings.Contains(entry.Details, "磁盘空间不足") {
+		t.Fatalf("缺少磁盘空间不足的审计日志: %+v, %v", entry, err)
+	}
+
+	// 分片上传在初始化时检查
+	s, uploadRoot, uploadAdmin := newTestChunkedUploadService(t)
+	s.files.diskGuard = NewDiskGuard(1000, fixedFreeSpace(1500, nil))
+	_, err := s.InitUpload(&model.ChunkUploadInitRequest{Path: uploadRoot, FileName: "data.bin", TotalSize: 600, TotalChunks: 1}, uploadAdmin.ID, "127.0.0.1", "test")
+	if !errors.Is(err, ErrInsufficientDiskSpace) {
+		t.Fatalf("空间不足时初始化上传返回 %v，期望 ErrInsufficientDiskSpace", err)
+	}
+}
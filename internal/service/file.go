@@ -1,51 +1,155 @@
 package service
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"mime"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"web-panel-go/internal/config"
 	"web-panel-go/internal/logger"
 	"web-panel-go/internal/model"
 
 	"gorm.io/gorm"
 )
 
+// 文件操作错误，错误信息直接返回给客户端
+var (
+	ErrInvalidPath       = errors.New("无效的路径")
+	ErrFileNotFound      = errors.New("文件不存在")
+	ErrPathNotFound      = errors.New("路径不存在")
+	ErrNotDirectory      = errors.New("路径不是目录")
+	ErrIsDirectory       = errors.New("无法下载目录")
+	ErrFileExists        = errors.New("文件已存在")
+	ErrTargetExists      = errors.New("目标文件已存在")
+	ErrTargetDirNotFound = errors.New("目标目录不存在")
+	ErrSamePath          = errors.New("源路径和目标路径相同")
+	ErrMoveIntoSelf      = errors.New("不能将目录移动到其子目录中")
+	ErrCopyIntoSelf      = errors.New("不能将目录复制到其子目录中")
+	ErrRootPath          = errors.New("不能删除、移动或覆盖文件根目录")
+)
+
 // FileService 文件服务
 type FileService struct {
-	db *gorm.DB
+	db            *gorm.DB
+	config        *config.Config
+	uploadLimiter *UploadLimiter
+	settings      *SettingService
+	diskGuard     *DiskGuard
+	dirSizes      *dirSizeCache
+	saveLocks     *PathLocker   // 串行化同一文件的保存，保证版本检查和写入之间不会被其他保存插入
+	trashLocks    *PathLocker   // 按回收站条目串行化移入、恢复和清除，避免并发读写 meta.json
+	rootDir       string        // 解析后的文件管理根目录，为空表示不限制
+	thumbnailSem  chan struct{} // 限制同时解码原图生成缩略图的数量，为nil表示不限制
 }
 
 // NewFileService 创建文件服务实例
-func NewFileService(db *gorm.DB) *FileService {
-	return &FileService{db: db}
+func NewFileService(db *gorm.DB, cfg *config.Config, settings *SettingService) *FileService {
+	var thumbnailSem chan struct{}
+	if cfg.File.ThumbnailConcurrency > 0 {
+		thumbnailSem = make(chan struct{}, cfg.File.ThumbnailConcurrency)
+	}
+	return &FileService{
+		db:            db,
+		config:        cfg,
+		uploadLimiter: NewUploadLimiter(cfg.File.MaxConcurrentUploads, cfg.File.MaxConcurrentUploadsPerUser),
+		settings:      settings,
+		diskGuard:     NewDiskGuard(cfg.File.DiskReserve, nil),
+		dirSizes:      newDirSizeCache(),
+		saveLocks:     NewPathLocker(),
+		trashLocks:    NewPathLocker(),
+		rootDir:       resolveRootDir(cfg.System.FileRootDir),
+		thumbnailSem:  thumbnailSem,
+	}
+}
+
+// resolveRootDir 将配置的根目录解析为清理后的绝对路径（并展开符号链接）
+func resolveRootDir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		logger.Error("解析文件根目录失败", "error", err, "dir", dir)
+		return filepath.Clean(dir)
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		return real
+	}
+	return abs
+}
+
+// GetBrowserDefaults 获取文件浏览器默认设置（排序、隐藏文件、默认目录）
+// 默认目录不在文件根目录内时改为根目录
+func (f *FileService) GetBrowserDefaults() model.FileBrowserSettings {
+	defaults := f.settings.GetFileBrowserSettings()
+	if f.rootDir != "" && !f.isValidPath(defaults.HomeDir) {
+		defaults.HomeDir = f.rootDir
+	}
+	return defaults
 }
 
+// AcquireUploadSlot 占用上传名额，超出并发上限时返回false
+func (f *FileService) AcquireUploadSlot(userID uint) (func(), bool) {
+	return f.uploadLimiter.TryAcquire(userID)
+}
+
+// ListOptions 文件列表选项
+type ListOptions struct {
+	SniffMime  bool   // 对无扩展名的文件读取内容探测MIME类型（仅当前页）
+	SortBy     string // 排序字段：name, size, mod_time, type
+	Order      string // 排序方向：asc, desc
+	ShowHidden bool   // 是否包含隐藏文件
+
+	ComputeSize bool            // 递归计算目录大小（仅当前页，超时后剩余目录保持未计算）
+	Context     context.Context // 计算目录大小时使用的上下文，为nil时使用context.Background()
+}
+
+// listBatchSize 分批读取目录条目时每批的数量
+const listBatchSize = 1000
+
 // ListFiles 获取文件列表
-func (f *FileService) ListFiles(path string, page, pageSize int) ([]model.FileInfo, int64, error) {
+// 目录条目数超过 file.max_list_entries 时只处理前面的条目，truncated 返回 true，
+// 此时排序和分页仅作用于已读取的部分
+// pageSize<=0 表示不分页返回全部条目；条目数超过 file.list_all_max_entries 时退回分页，
+// 只返回第一页（每页 list_all_max_entries 条），truncated 同样返回 true
+func (f *FileService) ListFiles(path string, page, pageSize int, opts ListOptions) ([]model.FileInfo, int64, bool, error) {
 	// 安全检查：防止路径遍历攻击
 	if !f.isValidPath(path) {
-		return nil, 0, fmt.Errorf("无效的路径")
+		return nil, 0, false, ErrInvalidPath
 	}
 
 	// 检查路径是否存在
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil, 0, fmt.Errorf("路径不存在: %s", path)
+		return nil, 0, false, fmt.Errorf("路径不存在: %s", path)
 	}
 
 	// 读取目录内容
-	entries, err := os.ReadDir(path)
+	entries, truncated, err := readDirLimited(path, f.config.File.MaxListEntries)
 	if err != nil {
-		return nil, 0, fmt.Errorf("读取目录失败: %w", err)
+		return nil, 0, false, fmt.Errorf("读取目录失败: %w", err)
 	}
 
 	var files []model.FileInfo
 	for _, entry := range entries {
+		if !opts.ShowHidden && f.isHiddenFile(entry.Name()) {
+			continue
+		}
 		fileInfo, err := f.getFileInfo(path, entry)
 		if err != nil {
 			// 跳过无法获取信息的文件
@@ -54,19 +158,132 @@ func (f *FileService) ListFiles(path string, page, pageSize int) ([]model.FileIn
 		files = append(files, *fileInfo)
 	}
 
+	sortFiles(files, opts.SortBy, opts.Order)
+
+	// 不分页时一次返回全部条目，超过上限则退回分页
+	if pageSize <= 0 {
+		page, pageSize = 1, len(files)
+		if limit := f.config.File.ListAllMaxEntries; limit > 0 && len(files) > limit {
+			pageSize = limit
+			truncated = true
+		}
+	}
+
 	// 计算分页
 	total := int64(len(files))
 	start := (page - 1) * pageSize
 	end := start + pageSize
 
 	if start >= len(files) {
-		return []model.FileInfo{}, total, nil
+		return []model.FileInfo{}, total, truncated, nil
 	}
 	if end > len(files) {
 		end = len(files)
 	}
 
-	return files[start:end], total, nil
+	pageFiles := files[start:end]
+	if opts.SniffMime {
+		for i := range pageFiles {
+			if pageFiles[i].MimeType == "" && pageFiles[i].FileType == "file" {
+				pageFiles[i].MimeType = sniffMimeType(pageFiles[i].Path)
+			}
+		}
+	}
+	if opts.ComputeSize {
+		f.fillDirectorySizes(opts.Context, pageFiles)
+	}
+
+	return pageFiles, total, truncated, nil
+}
+
+// fillDirectorySizes 为列表中的目录计算递归大小，所有目录共享同一超时
+func (f *FileService) fillDirectorySizes(ctx context.Context, files []model.FileInfo) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, dirSizeTimeout)
+	defer cancel()
+
+	for i := range files {
+		if files[i].FileType != "directory" {
+			continue
+		}
+		size, complete, err := f.computeDirSize(ctx, files[i].Path)
+		if err != nil {
+			continue
+		}
+		files[i].Size = size
+		files[i].Computed = complete
+	}
+}
+
+// readDirLimited 分批读取目录条目，最多读取 limit 个（limit<=0 表示不限制），
+// 避免超大目录一次性读入全部条目
+func readDirLimited(path string, limit int) ([]fs.DirEntry, bool, error) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer dir.Close()
+
+	var entries []fs.DirEntry
+	for {
+		batch := listBatchSize
+		if limit > 0 && limit-len(entries) < batch {
+			batch = limit - len(entries)
+		}
+
+		if batch == 0 {
+			// 已达到上限，再探测一个条目判断是否还有剩余
+			more, err := dir.ReadDir(1)
+			if err != nil && err != io.EOF {
+				return nil, false, err
+			}
+			return entries, len(more) > 0, nil
+		}
+
+		chunk, err := dir.ReadDir(batch)
+		entries = append(entries, chunk...)
+		if err == io.EOF {
+			return entries, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// sortFiles 排序文件列表，目录始终排在文件前面
+func sortFiles(files []model.FileInfo, sortBy, order string) {
+	less := func(a, b *model.FileInfo) bool {
+		switch sortBy {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case "mod_time":
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.Before(b.ModTime)
+			}
+		case "type":
+			if a.FileExt != b.FileExt {
+				return strings.ToLower(a.FileExt) < strings.ToLower(b.FileExt)
+			}
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := &files[i], &files[j]
+		aDir, bDir := a.FileType == "directory", b.FileType == "directory"
+		if aDir != bDir {
+			return aDir
+		}
+		if order == "desc" {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
 }
 
 // getFileInfo 获取文件信息
@@ -88,8 +305,20 @@ func (f *FileService) getFileInfo(basePath string, entry fs.DirEntry) (*model.Fi
 		ext = strings.TrimPrefix(ext, ".")
 	}
 
-	// 获取文件权限
-	permissions := info.Mode().String()
+	// 获取文件权限（符号形式和八进制形式）
+	mode := info.Mode()
+	permissions := mode.String()
+
+	// 获取属主和属组
+	owner, group := fileOwner(info)
+
+	// 根据扩展名推断MIME类型
+	mimeType := ""
+	if info.IsDir() {
+		mimeType = "inode/directory"
+	} else if ext != "" {
+		mimeType = mime.TypeByExtension("." + ext)
+	}
 
 	return &model.FileInfo{
 		Name:        entry.Name(),
@@ -97,45 +326,141 @@ func (f *FileService) getFileInfo(basePath string, entry fs.DirEntry) (*model.Fi
 		Size:        info.Size(),
 		FileType:    fileType,
 		FileExt:     ext,
+		MimeType:    mimeType,
 		Permissions: permissions,
+		Octal:       formatFileMode(&mode),
+		Owner:       owner,
+		Group:       group,
 		ModTime:     info.ModTime(),
 		Hidden:      f.isHiddenFile(entry.Name()),
+		Computed:    !info.IsDir(),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}, nil
 }
 
+// sniffMimeType 读取文件头部内容探测MIME类型
+func sniffMimeType(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	buf := make([]byte, 512)
+	n, err := file.Read(buf)
+	if err != nil && err != io.EOF {
+		return ""
+	}
+	if n == 0 {
+		return ""
+	}
+	return http.DetectContentType(buf[:n])
+}
+
 // isHiddenFile 检查是否为隐藏文件
 func (f *FileService) isHiddenFile(name string) bool {
 	return strings.HasPrefix(name, ".")
 }
 
 // isValidPath 验证路径是否安全
+// 配置了 system.file_root_dir 时，路径解析符号链接后必须位于根目录之内，
+// 防止通过 ../、绝对路径或指向外部的符号链接访问根目录以外的文件
 func (f *FileService) isValidPath(path string) bool {
-	// 防止路径遍历攻击
-	if strings.Contains(path, "..") {
+	if path == "" {
 		return false
 	}
-	
+
 	// 清理路径
 	cleanPath := filepath.Clean(path)
-	
+
 	// 检查是否为绝对路径或相对路径
 	if !filepath.IsAbs(cleanPath) && !strings.HasPrefix(cleanPath, ".") {
 		return false
 	}
-	
-	return true
+
+	if f.rootDir == "" {
+		// 未限制根目录时仍拒绝路径遍历
+		return !strings.Contains(path, "..")
+	}
+
+	absPath, err := filepath.Abs(cleanPath)
+	if err != nil {
+		return false
+	}
+	realPath, err := evalSymlinksPartial(absPath)
+	if err != nil {
+		return false
+	}
+	return isWithinDir(realPath, f.rootDir)
+}
+
+// errDanglingSymlink 路径中包含目标不存在的符号链接
+var errDanglingSymlink = errors.New("路径包含无法解析的符号链接")
+
+// evalSymlinksPartial 展开路径中已存在部分的符号链接，不存在的尾部按原样拼接，
+// 使尚未创建的目标（新建、上传、重命名）也能按其真实父目录校验；
+// 目标不存在的符号链接本身是存在的条目，写入时会跟随到链接目标，因此直接拒绝
+func evalSymlinksPartial(path string) (string, error) {
+	existing := path
+	var rest []string
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if _, lerr := os.Lstat(existing); lerr == nil {
+			return "", errDanglingSymlink
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+}
+
+// isRootDir 判断路径解析符号链接后是否为文件根目录本身（未限制根目录时为文件系统根目录）
+// isValidPath 允许根目录本身以便列出和读取，删除、移动等破坏性操作需要额外拒绝
+func (f *FileService) isRootDir(path string) bool {
+	root := f.rootDir
+	if root == "" {
+		root = string(filepath.Separator)
+	}
+	absPath, err := filepath.Abs(filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	realPath, err := evalSymlinksPartial(absPath)
+	if err != nil {
+		return false
+	}
+	return realPath == root
+}
+
+// isWithinDir 判断清理后的绝对路径是否等于root或位于root之下
+func isWithinDir(path, root string) bool {
+	if path == root {
+		return true
+	}
+	if !strings.HasSuffix(root, string(filepath.Separator)) {
+		root += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, root)
 }
 
 // CreateDirectory 创建目录
 func (f *FileService) CreateDirectory(path, name string, userID uint, clientIP, userAgent string) error {
-	if !f.isValidPath(path) {
+	// 目录名不能包含路径分隔符，拼接后的路径同样需要在根目录之内
+	fullPath := filepath.Join(path, name)
+	if !f.isValidPath(path) || !isValidFileName(name) || !f.isValidPath(fullPath) {
 		f.logAuditAction(userID, "create_directory", "file", fmt.Sprintf("创建目录失败: 无效路径 %s/%s", path, name), clientIP, userAgent, "failed")
-		return fmt.Errorf("无效的路径")
+		return ErrInvalidPath
 	}
-
-	fullPath := filepath.Join(path, name)
 	
 	// 检查目录是否已存在
 	if _, err := os.Stat(fullPath); !os.IsNotExist(err) {
@@ -154,18 +479,33 @@ func (f *FileService) CreateDirectory(path, name string, userID uint, clientIP,
 	return nil
 }
 
+// 删除模式
+const (
+	DeleteModeTrash     = "trash"
+	DeleteModePermanent = "permanent"
+)
+
 // DeleteFile 删除文件或目录
-func (f *FileService) DeleteFile(path string, userID uint, clientIP, userAgent string) error {
+// 启用回收站且未指定permanent时移入回收站，否则永久删除
+func (f *FileService) DeleteFile(path string, permanent bool, userID uint, clientIP, userAgent string) (*model.DeleteFileResponse, error) {
 	if !f.isValidPath(path) {
 		f.logAuditAction(userID, "delete_file", "file", fmt.Sprintf("删除文件失败: 无效路径 %s", path), clientIP, userAgent, "failed")
-		return fmt.Errorf("无效的路径")
+		return nil, ErrInvalidPath
+	}
+	if f.isRootDir(path) {
+		f.logAuditAction(userID, "delete_file", "file", fmt.Sprintf("删除文件失败: 不能删除根目录 %s", path), clientIP, userAgent, "failed")
+		return nil, ErrRootPath
 	}
 
 	// 检查文件是否存在
 	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
 		f.logAuditAction(userID, "delete_file", "file", fmt.Sprintf("删除文件失败: 文件不存在 %s", path), clientIP, userAgent, "failed")
-		return fmt.Errorf("文件不存在")
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		f.logAuditAction(userID, "delete_file", "file", fmt.Sprintf("删除文件失败: %s, 错误: %v", path, err), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("获取文件信息失败: %w", err)
 	}
 
 	fileType := "file"
@@ -173,38 +513,84 @@ func (f *FileService) DeleteFile(path string, userID uint, clientIP, userAgent s
 		fileType = "directory"
 	}
 
+	// 移入回收站
+	if f.config.File.TrashEnabled && !permanent {
+		trashID, trashPath, err := f.moveToTrash(path, userID)
+		if err != nil {
+			f.logAuditAction(userID, "delete_file", "file", fmt.Sprintf("移入回收站失败: %s, 错误: %v", path, err), clientIP, userAgent, "failed")
+			return nil, fmt.Errorf("移入回收站失败: %w", err)
+		}
+
+		f.logAuditAction(userID, "delete_file", "file", fmt.Sprintf("移入回收站%s: %s -> %s", fileType, path, trashPath), clientIP, userAgent, "success")
+		logger.Info("文件已移入回收站", "path", path, "trash_path", trashPath, "type", fileType, "user_id", userID)
+		return &model.DeleteFileResponse{Path: path, Mode: DeleteModeTrash, TrashID: trashID, TrashPath: trashPath}, nil
+	}
+
 	// 删除文件或目录
 	if err := os.RemoveAll(path); err != nil {
 		f.logAuditAction(userID, "delete_file", "file", fmt.Sprintf("删除%s失败: %s, 错误: %v", fileType, path, err), clientIP, userAgent, "failed")
-		return fmt.Errorf("删除失败: %w", err)
+		return nil, fmt.Errorf("删除失败: %w", err)
 	}
 
 	f.logAuditAction(userID, "delete_file", "file", fmt.Sprintf("删除%s: %s", fileType, path), clientIP, userAgent, "success")
 	logger.Info("文件删除成功", "path", path, "type", fileType, "user_id", userID)
-	return nil
+	return &model.DeleteFileResponse{Path: path, Mode: DeleteModePermanent}, nil
+}
+
+// DeleteFiles 批量删除文件，每个路径独立删除并各自记录审计日志，单个失败不影响其他路径
+func (f *FileService) DeleteFiles(paths []string, permanent bool, userID uint, clientIP, userAgent string) *model.BatchDeleteResponse {
+	response := &model.BatchDeleteResponse{Results: make([]model.BatchDeleteResult, 0, len(paths))}
+
+	for _, path := range paths {
+		result := model.BatchDeleteResult{Path: path}
+
+		deleted, err := f.DeleteFile(path, permanent, userID, clientIP, userAgent)
+		if err != nil {
+			result.Error = err.Error()
+			response.Failed++
+		} else {
+			result.Success = true
+			result.Mode = deleted.Mode
+			result.TrashID = deleted.TrashID
+			result.TrashPath = deleted.TrashPath
+			response.Succeeded++
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	return response
 }
 
 // RenameFile 重命名文件或目录
 func (f *FileService) RenameFile(oldPath, newName string, userID uint, clientIP, userAgent string) error {
 	if !f.isValidPath(oldPath) {
 		f.logAuditAction(userID, "rename_file", "file", fmt.Sprintf("重命名文件失败: 无效路径 %s", oldPath), clientIP, userAgent, "failed")
-		return fmt.Errorf("无效的路径")
+		return ErrInvalidPath
+	}
+	if f.isRootDir(oldPath) {
+		f.logAuditAction(userID, "rename_file", "file", fmt.Sprintf("重命名文件失败: 不能重命名根目录 %s", oldPath), clientIP, userAgent, "failed")
+		return ErrRootPath
 	}
 
 	// 检查原文件是否存在
 	if _, err := os.Stat(oldPath); os.IsNotExist(err) {
 		f.logAuditAction(userID, "rename_file", "file", fmt.Sprintf("重命名文件失败: 文件不存在 %s", oldPath), clientIP, userAgent, "failed")
-		return fmt.Errorf("文件不存在")
+		return ErrFileNotFound
 	}
 
-	// 构建新路径
+	// 构建新路径，新名称不能包含路径分隔符，只能在原目录内重命名
 	dir := filepath.Dir(oldPath)
 	newPath := filepath.Join(dir, newName)
+	if !isValidFileName(newName) || !f.isValidPath(newPath) {
+		f.logAuditAction(userID, "rename_file", "file", fmt.Sprintf("重命名文件失败: 无效名称 %s", newName), clientIP, userAgent, "failed")
+		return fmt.Errorf("无效的文件名")
+	}
 
 	// 检查新文件名是否已存在
 	if _, err := os.Stat(newPath); !os.IsNotExist(err) {
 		f.logAuditAction(userID, "rename_file", "file", fmt.Sprintf("重命名文件失败: 目标文件已存在 %s", newPath), clientIP, userAgent, "failed")
-		return fmt.Errorf("目标文件已存在")
+		return ErrTargetExists
 	}
 
 	// 重命名文件
@@ -218,17 +604,426 @@ func (f *FileService) RenameFile(oldPath, newName string, userID uint, clientIP,
 	return nil
 }
 
-// UploadFile 上传文件
-func (f *FileService) UploadFile(targetPath string, file *multipart.FileHeader, userID uint, clientIP, userAgent string) error {
-	if !f.isValidPath(targetPath) {
-		f.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 无效路径 %s", targetPath), clientIP, userAgent, "failed")
-		return fmt.Errorf("无效的路径")
+// MoveFile 移动文件或目录到目标完整路径
+// 优先使用os.Rename，跨文件系统（EXDEV）时回退为复制后删除
+func (f *FileService) MoveFile(source, destination string, overwrite bool, userID uint, clientIP, userAgent string) error {
+	if !f.isValidPath(source) || !f.isValidPath(destination) {
+		f.logAuditAction(userID, "move_file", "file", fmt.Sprintf("移动文件失败: 无效路径 %s -> %s", source, destination), clientIP, userAgent, "failed")
+		return ErrInvalidPath
+	}
+	if f.isRootDir(source) || f.isRootDir(destination) {
+		f.logAuditAction(userID, "move_file", "file", fmt.Sprintf("移动文件失败: 不能移动或覆盖根目录 %s -> %s", source, destination), clientIP, userAgent, "failed")
+		return ErrRootPath
+	}
+
+	source = filepath.Clean(source)
+	destination = filepath.Clean(destination)
+	if source == destination {
+		return ErrSamePath
+	}
+
+	// 检查源文件是否存在
+	info, err := os.Lstat(source)
+	if os.IsNotExist(err) {
+		f.logAuditAction(userID, "move_file", "file", fmt.Sprintf("移动文件失败: 文件不存在 %s", source), clientIP, userAgent, "failed")
+		return ErrFileNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %w", err)
+	}
+
+	// 不允许把目录移动到自身内部
+	if info.IsDir() && strings.HasPrefix(destination, source+string(filepath.Separator)) {
+		f.logAuditAction(userID, "move_file", "file", fmt.Sprintf("移动文件失败: 不能移动到自身子目录 %s -> %s", source, destination), clientIP, userAgent, "failed")
+		return ErrMoveIntoSelf
+	}
+
+	// 检查目标目录是否存在
+	if parent, err := os.Stat(filepath.Dir(destination)); err != nil || !parent.IsDir() {
+		f.logAuditAction(userID, "move_file", "file", fmt.Sprintf("移动文件失败: 目标目录不存在 %s", filepath.Dir(destination)), clientIP, userAgent, "failed")
+		return ErrTargetDirNotFound
+	}
+
+	// 检查目标是否已存在
+	_, statErr := os.Lstat(destination)
+	exists := statErr == nil
+	if exists && !overwrite {
+		f.logAuditAction(userID, "move_file", "file", fmt.Sprintf("移动文件失败: 目标已存在 %s", destination), clientIP, userAgent, "failed")
+		return ErrTargetExists
+	}
+
+	// 覆盖时原目标先移到备份，源文件就位后才删除，失败时恢复原目标
+	method := "rename"
+	if exists {
+		err = replacePath(source, destination)
+	} else {
+		err = os.Rename(source, destination)
+	}
+	if errors.Is(err, syscall.EXDEV) {
+		// 跨文件系统：先检查空间，复制到目标旁的临时位置并替换后再删除源文件
+		method = "copy"
+		if err = f.diskGuard.Check(filepath.Dir(destination), pathSize(source)); err != nil {
+			f.logAuditAction(userID, "move_file", "file", fmt.Sprintf("移动文件失败: 磁盘空间不足 %s -> %s", source, destination), clientIP, userAgent, "failed")
+			return err
+		}
+		if err = installCopy(source, destination, exists); err == nil {
+			err = os.RemoveAll(source)
+		}
+	}
+	if err != nil {
+		f.logAuditAction(userID, "move_file", "file", fmt.Sprintf("移动文件失败: %s -> %s, 错误: %v", source, destination, err), clientIP, userAgent, "failed")
+		return fmt.Errorf("移动失败: %w", err)
+	}
+
+	f.logAuditAction(userID, "move_file", "file", fmt.Sprintf("移动文件: %s -> %s", source, destination), clientIP, userAgent, "success")
+	logger.Info("文件移动成功", "source", source, "destination", destination, "method", method, "user_id", userID)
+	return nil
+}
+
+// CopyFile 复制文件或目录到目标完整路径，目录递归复制并保留权限位
+func (f *FileService) CopyFile(source, destination string, overwrite bool, userID uint, clientIP, userAgent string) error {
+	if !f.isValidPath(source) || !f.isValidPath(destination) {
+		f.logAuditAction(userID, "copy_file", "file", fmt.Sprintf("复制文件失败: 无效路径 %s -> %s", source, destination), clientIP, userAgent, "failed")
+		return ErrInvalidPath
+	}
+	if f.isRootDir(destination) {
+		f.logAuditAction(userID, "copy_file", "file", fmt.Sprintf("复制文件失败: 不能覆盖根目录 %s", destination), clientIP, userAgent, "failed")
+		return ErrRootPath
+	}
+
+	source = filepath.Clean(source)
+	destination = filepath.Clean(destination)
+	if source == destination {
+		return ErrSamePath
+	}
+
+	// 检查源文件是否存在
+	info, err := os.Lstat(source)
+	if os.IsNotExist(err) {
+		f.logAuditAction(userID, "copy_file", "file", fmt.Sprintf("复制文件失败: 文件不存在 %s", source), clientIP, userAgent, "failed")
+		return ErrFileNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %w", err)
+	}
+
+	// 检查目标目录是否存在
+	parent := filepath.Dir(destination)
+	if parentInfo, err := os.Stat(parent); err != nil || !parentInfo.IsDir() {
+		f.logAuditAction(userID, "copy_file", "file", fmt.Sprintf("复制文件失败: 目标目录不存在 %s", parent), clientIP, userAgent, "failed")
+		return ErrTargetDirNotFound
+	}
+
+	// 不允许把目录复制到自身内部（解析符号链接后比较，防止通过链接绕过）
+	if info.IsDir() {
+		realSource, err1 := filepath.EvalSymlinks(source)
+		realParent, err2 := filepath.EvalSymlinks(parent)
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("解析路径失败")
+		}
+		if realParent == realSource || strings.HasPrefix(realParent, realSource+string(filepath.Separator)) {
+			f.logAuditAction(userID, "copy_file", "file", fmt.Sprintf("复制文件失败: 不能复制到自身子目录 %s -> %s", source, destination), clientIP, userAgent, "failed")
+			return ErrCopyIntoSelf
+		}
+	}
+
+	// 检查目标是否已存在
+	_, statErr := os.Lstat(destination)
+	exists := statErr == nil
+	if exists && !overwrite {
+		f.logAuditAction(userID, "copy_file", "file", fmt.Sprintf("复制文件失败: 目标已存在 %s", destination), clientIP, userAgent, "failed")
+		return ErrTargetExists
+	}
+
+	// 检查磁盘剩余空间，覆盖时新副本写完前原目标仍占用空间，按完整大小计算
+	size := pathSize(source)
+	if err := f.diskGuard.Check(parent, size); err != nil {
+		f.logAuditAction(userID, "copy_file", "file", fmt.Sprintf("复制文件失败: 磁盘空间不足 %s -> %s", source, destination), clientIP, userAgent, "failed")
+		return err
+	}
+
+	// 先复制到目标旁的临时位置再替换，复制失败时原目标保持不变
+	if err := installCopy(source, destination, exists); err != nil {
+		f.logAuditAction(userID, "copy_file", "file", fmt.Sprintf("复制文件失败: %s -> %s, 错误: %v", source, destination, err), clientIP, userAgent, "failed")
+		return fmt.Errorf("复制失败: %w", err)
+	}
+
+	f.logAuditAction(userID, "copy_file", "file", fmt.Sprintf("复制文件: %s -> %s (大小: %d bytes)", source, destination, size), clientIP, userAgent, "success")
+	logger.Info("文件复制成功", "source", source, "destination", destination, "size", size, "user_id", userID)
+	return nil
+}
+
+// installCopy 把 source 复制到 destination 所在目录的临时位置，完成后再移动到 destination，
+// replace 为true时通过 replacePath 替换已有的目标；复制或替换失败时清理临时副本，已有目标保持不变
+func installCopy(source, destination string, replace bool) error {
+	stageDir, err := os.MkdirTemp(filepath.Dir(destination), "."+filepath.Base(destination)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+
+	staged := filepath.Join(stageDir, filepath.Base(destination))
+	if err := copyPath(source, staged); err != nil {
+		return err
+	}
+	if replace {
+		return replacePath(staged, destination)
+	}
+	return os.Rename(staged, destination)
+}
+
+// replacePath 用 source 替换已存在的 destination（两者需在同一文件系统）
+// 原目标先改名为同目录下的备份，source 就位后再删除备份；移动失败时把备份恢复原位
+func replacePath(source, destination string) error {
+	backupDir, err := os.MkdirTemp(filepath.Dir(destination), "."+filepath.Base(destination)+".old-*")
+	if err != nil {
+		return err
+	}
+	backup := filepath.Join(backupDir, filepath.Base(destination))
+	if err := os.Rename(destination, backup); err != nil {
+		os.Remove(backupDir)
+		return err
+	}
+
+	if err := os.Rename(source, destination); err != nil {
+		if restoreErr := os.Rename(backup, destination); restoreErr != nil {
+			logger.Error("恢复被覆盖的目标失败", "error", restoreErr, "destination", destination, "backup", backup)
+			return err
+		}
+		os.Remove(backupDir)
+		return err
+	}
+
+	if err := os.RemoveAll(backupDir); err != nil {
+		logger.Warn("删除覆盖前的备份失败", "error", err, "backup", backupDir)
+	}
+	return nil
+}
+
+// copyPath 递归复制文件或目录，保留权限和修改时间，符号链接按链接复制
+func copyPath(source, destination string) error {
+	info, err := os.Lstat(source)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(source)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, destination)
+
+	case info.IsDir():
+		if err := os.Mkdir(destination, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(source)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyPath(filepath.Join(source, entry.Name()), filepath.Join(destination, entry.Name())); err != nil {
+				return err
+			}
+		}
+
+	case info.Mode().IsRegular():
+		if err := copyFile(source, destination, info.Mode().Perm()); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("不支持复制特殊文件: %s", source)
+	}
+
+	return os.Chtimes(destination, info.ModTime(), info.ModTime())
+}
+
+// copyFile 复制普通文件
+func copyFile(source, destination string, perm os.FileMode) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// fileModePattern 权限必须是3-4位八进制数字，如 "644"、"0755"、"1777"
+var fileModePattern = regexp.MustCompile(`^[0-7]{3,4}$`)
+
+// parseFileMode 解析八进制权限字符串，为空时返回nil
+// 第4位（最高位）对应 setuid(4)、setgid(2)、sticky(1)
+func parseFileMode(mode string) (*os.FileMode, error) {
+	mode = strings.TrimSpace(mode)
+	if mode == "" {
+		return nil, nil
+	}
+	if !fileModePattern.MatchString(mode) {
+		return nil, fmt.Errorf("无效的权限: %s", mode)
+	}
+
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的权限: %s", mode)
+	}
+
+	fileMode := os.FileMode(value & 0777)
+	if value&04000 != 0 {
+		fileMode |= os.ModeSetuid
+	}
+	if value&02000 != 0 {
+		fileMode |= os.ModeSetgid
+	}
+	if value&01000 != 0 {
+		fileMode |= os.ModeSticky
+	}
+	return &fileMode, nil
+}
+
+// ChangePermissions 批量修改文件权限
+// 文件和目录可以分别指定权限，recursive为true时应用到整个目录树；符号链接和无法读取的条目会被跳过
+func (f *FileService) ChangePermissions(req *model.ChangePermissionsRequest, userID uint, clientIP, userAgent string) (*model.ChangePermissionsResponse, error) {
+	mode, err := parseFileMode(req.Mode)
+	if err != nil {
+		return nil, err
+	}
+	fileMode, err := parseFileMode(req.FileMode)
+	if err != nil {
+		return nil, err
+	}
+	dirMode, err := parseFileMode(req.DirMode)
+	if err != nil {
+		return nil, err
+	}
+	if fileMode == nil {
+		fileMode = mode
+	}
+	if dirMode == nil {
+		dirMode = mode
+	}
+	if fileMode == nil && dirMode == nil {
+		return nil, fmt.Errorf("未指定权限")
+	}
+
+	paths := req.Paths
+	if req.Path != "" {
+		paths = append([]string{req.Path}, paths...)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("路径不能为空")
+	}
+
+	for _, path := range paths {
+		if !f.isValidPath(path) {
+			f.logAuditAction(userID, "chmod", "file", fmt.Sprintf("修改权限失败: 无效路径 %s", path), clientIP, userAgent, "failed")
+			return nil, ErrInvalidPath
+		}
+	}
+
+	result := &model.ChangePermissionsResponse{}
+	apply := func(path string, d fs.DirEntry) {
+		if d.Type()&fs.ModeSymlink != 0 {
+			result.Skipped++
+			return
+		}
+
+		target := fileMode
+		if d.IsDir() {
+			target = dirMode
+		}
+		if target == nil {
+			return
+		}
+
+		if err := os.Chmod(path, *target); err != nil {
+			result.Failed = append(result.Failed, path)
+			return
+		}
+		result.Changed++
+	}
+
+	// 记录修改前的权限，便于追溯
+	previous := make([]string, 0, len(paths))
+	for _, root := range paths {
+		info, err := os.Lstat(root)
+		if err != nil {
+			result.Skipped++
+			continue
+		}
+		oldMode := info.Mode()
+		previous = append(previous, fmt.Sprintf("%s(%s)", root, formatFileMode(&oldMode)))
+
+		if !req.Recursive || !info.IsDir() {
+			apply(root, fs.FileInfoToDirEntry(info))
+			continue
+		}
+
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// 跳过无法读取的条目
+				result.Skipped++
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			apply(path, d)
+			return nil
+		})
+	}
+
+	status := "success"
+	if len(result.Failed) > 0 {
+		status = "failed"
+	}
+	f.logAuditAction(userID, "chmod", "file", fmt.Sprintf("批量修改权限: %s (原权限: %s, 文件: %s, 目录: %s, 递归: %t, 修改: %d, 跳过: %d, 失败: %d)",
+		strings.Join(paths, ", "), strings.Join(previous, ", "), formatFileMode(fileMode), formatFileMode(dirMode), req.Recursive, result.Changed, result.Skipped, len(result.Failed)), clientIP, userAgent, status)
+	logger.Info("批量修改权限完成", "paths", len(paths), "changed", result.Changed, "skipped", result.Skipped, "failed", len(result.Failed), "user_id", userID)
+	return result, nil
+}
+
+// formatFileMode 格式化为四位八进制权限（用于文件信息和审计日志），包含setuid/setgid/sticky位
+func formatFileMode(mode *os.FileMode) string {
+	if mode == nil {
+		return "-"
+	}
+	value := uint32(mode.Perm())
+	if *mode&os.ModeSetuid != 0 {
+		value |= 04000
+	}
+	if *mode&os.ModeSetgid != 0 {
+		value |= 02000
+	}
+	if *mode&os.ModeSticky != 0 {
+		value |= 01000
+	}
+	return fmt.Sprintf("%04o", value)
+}
+
+// UploadFile 上传文件，写入时同时计算SHA-256供客户端校验
+func (f *FileService) UploadFile(targetPath string, file *multipart.FileHeader, userID uint, clientIP, userAgent string) (*model.UploadFileResponse, error) {
+	if !f.isValidPath(targetPath) || !isValidFileName(file.Filename) {
+		f.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 无效路径 %s/%s", targetPath, file.Filename), clientIP, userAgent, "failed")
+		return nil, ErrInvalidPath
 	}
 
 	// 确保目标目录存在
 	if err := os.MkdirAll(targetPath, 0755); err != nil {
 		f.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 创建目录失败 %s, 错误: %v", targetPath, err), clientIP, userAgent, "failed")
-		return fmt.Errorf("创建目录失败: %w", err)
+		return nil, fmt.Errorf("创建目录失败: %w", err)
 	}
 
 	// 构建完整文件路径
@@ -237,54 +1032,70 @@ func (f *FileService) UploadFile(targetPath string, file *multipart.FileHeader,
 	// 检查文件是否已存在
 	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
 		f.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 文件已存在 %s", filePath), clientIP, userAgent, "failed")
-		return fmt.Errorf("文件已存在")
+		return nil, ErrFileExists
+	}
+
+	// 检查磁盘剩余空间
+	if err := f.diskGuard.Check(targetPath, file.Size); err != nil {
+		f.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 磁盘空间不足 %s (大小: %d bytes)", filePath, file.Size), clientIP, userAgent, "failed")
+		return nil, err
 	}
 
 	// 打开上传的文件
 	src, err := file.Open()
 	if err != nil {
 		f.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 打开文件失败 %s, 错误: %v", file.Filename, err), clientIP, userAgent, "failed")
-		return fmt.Errorf("打开文件失败: %w", err)
+		return nil, fmt.Errorf("打开文件失败: %w", err)
 	}
 	defer src.Close()
 
-	// 创建目标文件
-	dst, err := os.Create(filePath)
+	// 创建目标文件，不跟随最后一级的符号链接
+	dst, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL|openNoFollow, 0644)
 	if err != nil {
 		f.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 创建文件失败 %s, 错误: %v", filePath, err), clientIP, userAgent, "failed")
-		return fmt.Errorf("创建文件失败: %w", err)
+		return nil, fmt.Errorf("创建文件失败: %w", err)
 	}
 	defer dst.Close()
 
 	// 复制文件内容
-	if _, err := io.Copy(dst, src); err != nil {
+	h := newUploadChecksum()
+	written, err := io.Copy(io.MultiWriter(dst, h), src)
+	if err != nil {
 		f.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件失败: 复制文件失败 %s, 错误: %v", filePath, err), clientIP, userAgent, "failed")
-		return fmt.Errorf("复制文件失败: %w", err)
+		return nil, fmt.Errorf("复制文件失败: %w", err)
 	}
 
-	f.logAuditAction(userID, "upload_file", "file", fmt.Sprintf("上传文件: %s (大小: %d bytes)", filePath, file.Size), clientIP, userAgent, "success")
+	f.logAuditAction(userID, "upload_file", "file", fileAuditDetails("上传文件", filePath, file.Size), clientIP, userAgent, "success")
 	logger.Info("文件上传成功", "path", filePath, "size", file.Size, "user_id", userID)
-	return nil
+	return &model.UploadFileResponse{
+		Path:     filePath,
+		Size:     written,
+		Checksum: checksumHex(h),
+	}, nil
 }
 
-// DownloadFile 下载文件
+// DownloadFile 打开要下载的文件并记录审计日志，调用方负责关闭返回的文件
 func (f *FileService) DownloadFile(filePath string, userID uint, clientIP, userAgent string) (*os.File, error) {
 	if !f.isValidPath(filePath) {
 		f.logAuditAction(userID, "download_file", "file", fmt.Sprintf("下载文件失败: 无效路径 %s", filePath), clientIP, userAgent, "failed")
-		return nil, fmt.Errorf("无效的路径")
+		return nil, ErrInvalidPath
 	}
 
 	// 检查文件是否存在
 	info, err := os.Stat(filePath)
 	if os.IsNotExist(err) {
 		f.logAuditAction(userID, "download_file", "file", fmt.Sprintf("下载文件失败: 文件不存在 %s", filePath), clientIP, userAgent, "failed")
-		return nil, fmt.Errorf("文件不存在")
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		f.logAuditAction(userID, "download_file", "file", fmt.Sprintf("下载文件失败: %s, 错误: %v", filePath, err), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("获取文件信息失败: %w", err)
 	}
 
 	// 检查是否为文件（不是目录）
 	if info.IsDir() {
 		f.logAuditAction(userID, "download_file", "file", fmt.Sprintf("下载文件失败: 路径是目录 %s", filePath), clientIP, userAgent, "failed")
-		return nil, fmt.Errorf("无法下载目录")
+		return nil, ErrIsDirectory
 	}
 
 	// 打开文件
@@ -300,66 +1111,330 @@ func (f *FileService) DownloadFile(filePath string, userID uint, clientIP, userA
 }
 
 // GetFileContent 获取文件内容（用于编辑）
-func (f *FileService) GetFileContent(filePath string, userID uint, clientIP, userAgent string) (string, error) {
+func (f *FileService) GetFileContent(filePath string, userID uint, clientIP, userAgent string) (*model.FileContentResponse, error) {
 	if !f.isValidPath(filePath) {
 		f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: 无效路径 %s", filePath), clientIP, userAgent, "failed")
-		return "", fmt.Errorf("无效的路径")
+		return nil, ErrInvalidPath
 	}
 
 	// 检查文件是否存在
 	info, err := os.Stat(filePath)
 	if os.IsNotExist(err) {
 		f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: 文件不存在 %s", filePath), clientIP, userAgent, "failed")
-		return "", fmt.Errorf("文件不存在")
+		return nil, ErrFileNotFound
 	}
 
 	// 检查是否为文件
 	if info.IsDir() {
 		f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: 路径是目录 %s", filePath), clientIP, userAgent, "failed")
-		return "", fmt.Errorf("无法读取目录")
+		return nil, fmt.Errorf("无法读取目录")
 	}
 
 	// 检查文件大小（限制为10MB）
 	if info.Size() > 10*1024*1024 {
 		f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: 文件过大 %s (大小: %d bytes)", filePath, info.Size()), clientIP, userAgent, "failed")
-		return "", fmt.Errorf("文件过大，无法编辑")
+		return nil, fmt.Errorf("文件过大，无法编辑")
 	}
 
 	// 读取文件内容
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: %s, 错误: %v", filePath, err), clientIP, userAgent, "failed")
-		return "", fmt.Errorf("读取文件失败: %w", err)
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	response := &model.FileContentResponse{
+		Path:        filePath,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		Permissions: info.Mode().String(),
+		Encoding:    detectEncoding(content),
+		Version:     fileVersion(info),
+	}
+
+	// 二进制文件不返回内容，非UTF-8文本转换为UTF-8供编辑，保存时按 Encoding 写回
+	if response.Encoding == EncodingBinary {
+		response.IsBinary = true
+	} else if response.Content, err = decodeText(content, response.Encoding); err != nil {
+		response.Encoding = EncodingBinary
+		response.IsBinary = true
+	}
+
+	f.logAuditAction(userID, "read_file", "file", fileAuditDetails("读取文件", filePath, int64(len(content))), clientIP, userAgent, "success")
+	logger.Info("文件读取成功", "path", filePath, "size", len(content), "encoding", response.Encoding, "user_id", userID)
+	return response, nil
+}
+
+// ErrFileVersionConflict 保存文件时的版本冲突错误
+var ErrFileVersionConflict = errors.New("文件已被修改，请重新加载后再保存")
+
+// fileVersion 文件版本号，由修改时间（纳秒）和大小组成
+func fileVersion(info os.FileInfo) string {
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// MaxLineWindow 按行分段读取时单次最多返回的行数
+const MaxLineWindow = 5000
+
+// maxWindowLineLength 分段读取时单行最多返回的字节数，超出部分截断
+const maxWindowLineLength = 64 * 1024
+
+// GetFileLines 按行分段读取文件，用于编辑器虚拟滚动查看超出整文件读取限制的大文本文件
+func (f *FileService) GetFileLines(filePath string, offsetLine, lineCount int, userID uint, clientIP, userAgent string) (*model.FileLinesResponse, error) {
+	if !f.isValidPath(filePath) {
+		f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: 无效路径 %s", filePath), clientIP, userAgent, "failed")
+		return nil, ErrInvalidPath
+	}
+	if offsetLine < 0 {
+		offsetLine = 0
+	}
+	if lineCount <= 0 || lineCount > MaxLineWindow {
+		lineCount = MaxLineWindow
+	}
+
+	// 检查文件是否存在
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: 文件不存在 %s", filePath), clientIP, userAgent, "failed")
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	if info.IsDir() {
+		f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: 路径是目录 %s", filePath), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("无法读取目录")
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: %s, 错误: %v", filePath, err), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("读取文件失败: %w", err)
 	}
+	defer file.Close()
 
-	f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件: %s (大小: %d bytes)", filePath, len(content)), clientIP, userAgent, "success")
-	logger.Info("文件读取成功", "path", filePath, "size", len(content), "user_id", userID)
-	return string(content), nil
+	// 逐行扫描整个文件以统计总行数，只保留窗口内的行
+	// 缓冲区大小即单行上限，超长行只保留开头部分，其余读取后丢弃，不会整行读入内存
+	lines := make([]string, 0)
+	reader := bufio.NewReaderSize(file, maxWindowLineLength)
+	total := 0
+	for {
+		line, err := reader.ReadSlice('\n')
+		if len(line) > 0 {
+			if total >= offsetLine && total < offsetLine+lineCount {
+				lines = append(lines, string(bytes.TrimRight(line, "\r\n")))
+			}
+			total++
+		}
+		for err == bufio.ErrBufferFull {
+			_, err = reader.ReadSlice('\n')
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件失败: %s, 错误: %v", filePath, err), clientIP, userAgent, "failed")
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+	}
+
+	f.logAuditAction(userID, "read_file", "file", fmt.Sprintf("读取文件片段: %s (行: %d-%d/%d)", filePath, offsetLine+1, offsetLine+len(lines), total), clientIP, userAgent, "success")
+	return &model.FileLinesResponse{
+		Path:       filePath,
+		Lines:      lines,
+		OffsetLine: offsetLine,
+		LineCount:  len(lines),
+		TotalLines: total,
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+	}, nil
+}
+
+// GetPathMeta 获取目录路径元数据（用于构建面包屑导航）
+// 配置了文件根目录时以根目录为顶层，上级目录不会超出根目录
+func (f *FileService) GetPathMeta(path string) (cleanPath, parentPath string, isRoot bool) {
+	cleanPath = filepath.Clean(path)
+	parentPath = filepath.Dir(cleanPath)
+	if f.rootDir == "" {
+		return cleanPath, parentPath, parentPath == cleanPath
+	}
+
+	if absPath, err := filepath.Abs(cleanPath); err == nil {
+		if realPath, err := evalSymlinksPartial(absPath); err == nil && realPath == f.rootDir {
+			return cleanPath, cleanPath, true
+		}
+	}
+	if !f.isValidPath(parentPath) {
+		parentPath = f.rootDir
+	}
+	return cleanPath, parentPath, false
 }
 
 // SaveFileContent 保存文件内容
-func (f *FileService) SaveFileContent(filePath, content string, userID uint, clientIP, userAgent string) error {
+// version 为读取时返回的版本号，文件在此期间被修改时返回 ErrFileVersionConflict；为空或 force 为true时跳过检查
+// encoding 为写回文件使用的编码（读取时返回的 Encoding），为空时沿用已有文件的编码，新文件使用UTF-8
+func (f *FileService) SaveFileContent(filePath, content, version, encoding string, force bool, userID uint, clientIP, userAgent string) (*model.SaveFileContentResponse, error) {
 	if !f.isValidPath(filePath) {
 		f.logAuditAction(userID, "save_file", "file", fmt.Sprintf("保存文件失败: 无效路径 %s", filePath), clientIP, userAgent, "failed")
-		return fmt.Errorf("无效的路径")
+		return nil, ErrInvalidPath
+	}
+
+	unlock := f.saveLocks.Lock(filePath)
+	defer unlock()
+
+	// 带了版本号时检查版本号，防止覆盖其他人的修改
+	info, statErr := os.Stat(filePath)
+	if !force && version != "" {
+		switch {
+		case statErr == nil && version != fileVersion(info), os.IsNotExist(statErr):
+			f.logAuditAction(userID, "save_file", "file", fmt.Sprintf("保存文件失败: 版本冲突 %s", filePath), clientIP, userAgent, "failed")
+			return nil, ErrFileVersionConflict
+		}
+	}
+
+	// 按原编码转换内容
+	if encoding == "" && statErr == nil {
+		encoding = f.existingFileEncoding(filePath)
+	}
+	data, err := encodeText(content, encoding)
+	if err != nil {
+		f.logAuditAction(userID, "save_file", "file", fmt.Sprintf("保存文件失败: %s, 编码 %s: %v", filePath, encoding, err), clientIP, userAgent, "failed")
+		return nil, err
+	}
+
+	// 检查磁盘剩余空间，覆盖已有文件时只计算增长部分
+	growth := int64(len(data))
+	if statErr == nil {
+		growth -= info.Size()
+	}
+	if err := f.diskGuard.Check(filePath, growth); err != nil {
+		f.logAuditAction(userID, "save_file", "file", fmt.Sprintf("保存文件失败: 磁盘空间不足 %s", filePath), clientIP, userAgent, "failed")
+		return nil, err
 	}
 
 	// 确保目录存在
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		f.logAuditAction(userID, "save_file", "file", fmt.Sprintf("保存文件失败: 创建目录失败 %s, 错误: %v", dir, err), clientIP, userAgent, "failed")
-		return fmt.Errorf("创建目录失败: %w", err)
+		return nil, fmt.Errorf("创建目录失败: %w", err)
 	}
 
-	// 写入文件
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+	// 写入链接指向的真实文件，打开时不再跟随符号链接，避免校验之后链接被替换到根目录之外
+	target, err := evalSymlinksPartial(filePath)
+	if err != nil {
+		f.logAuditAction(userID, "save_file", "file", fmt.Sprintf("保存文件失败: %s, 错误: %v", filePath, err), clientIP, userAgent, "failed")
+		return nil, ErrInvalidPath
+	}
+	if err := writeFileNoFollow(target, data, 0644); err != nil {
 		f.logAuditAction(userID, "save_file", "file", fmt.Sprintf("保存文件失败: %s, 错误: %v", filePath, err), clientIP, userAgent, "failed")
-		return fmt.Errorf("保存文件失败: %w", err)
+		return nil, fmt.Errorf("保存文件失败: %w", err)
 	}
 
-	f.logAuditAction(userID, "save_file", "file", fmt.Sprintf("保存文件: %s (大小: %d bytes)", filePath, len(content)), clientIP, userAgent, "success")
-	logger.Info("文件保存成功", "path", filePath, "size", len(content), "user_id", userID)
-	return nil
+	f.logAuditAction(userID, "save_file", "file", fileAuditDetails("保存文件", filePath, int64(len(data))), clientIP, userAgent, "success")
+	logger.Info("文件保存成功", "path", filePath, "size", len(data), "encoding", encoding, "force", force, "user_id", userID)
+
+	response := &model.SaveFileContentResponse{Path: filePath, Size: int64(len(data))}
+	if info, err := os.Stat(filePath); err == nil {
+		response.Version = fileVersion(info)
+	}
+	return response, nil
+}
+
+// writeFileNoFollow 与 os.WriteFile 相同，但路径最后一级是符号链接时打开失败而不是写入链接目标
+func writeFileNoFollow(name string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|openNoFollow, perm)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// existingFileEncoding 检测已有文件的编码，无法读取或为二进制时按UTF-8处理
+func (f *FileService) existingFileEncoding(filePath string) string {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return EncodingUTF8
+	}
+	if encoding := detectEncoding(content); encoding != EncodingBinary {
+		return encoding
+	}
+	return EncodingUTF8
+}
+
+// recentFileActions 计入最近文件的审计动作
+var recentFileActions = []string{"save_file", "upload_file", "read_file"}
+
+// fileAuditDetail 文件读写成功的审计详情，以JSON保存，最近文件按 path 字段查询
+type fileAuditDetail struct {
+	Message string `json:"message"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+}
+
+// fileAuditDetails 生成文件读写成功的JSON审计详情
+func fileAuditDetails(message, path string, size int64) string {
+	data, err := json.Marshal(fileAuditDetail{Message: message, Path: path, Size: size})
+	if err != nil {
+		return fmt.Sprintf("%s: %s (大小: %d bytes)", message, path, size)
+	}
+	return string(data)
+}
+
+// GetRecentFiles 获取用户最近访问的文件（基于审计日志，按路径去重，按时间倒序）
+// 路径取自JSON审计详情的 path 字段，按路径分组后每组取最新一条，已不存在的文件跳过
+func (f *FileService) GetRecentFiles(userID uint, limit int) ([]model.RecentFile, error) {
+	pathExpr := "json_extract(details, '$.path')"
+	recent := make([]model.RecentFile, 0, limit)
+	for offset := 0; len(recent) < limit; offset += limit {
+		var ids []uint
+		if err := f.db.Model(&model.AuditLog{}).
+			Select("MAX(id)").
+			Where("user_id = ? AND action IN ? AND status = ? AND json_valid(details) AND "+pathExpr+" IS NOT NULL", userID, recentFileActions, "success").
+			Group(pathExpr).
+			Order("MAX(id) DESC").
+			Limit(limit).Offset(offset).
+			Pluck("MAX(id)", &ids).Error; err != nil {
+			return nil, fmt.Errorf("查询最近文件失败: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		var logs []model.AuditLog
+		if err := f.db.Where("id IN ?", ids).Order("id DESC").Find(&logs).Error; err != nil {
+			return nil, fmt.Errorf("查询最近文件失败: %w", err)
+		}
+		for _, auditLog := range logs {
+			var detail fileAuditDetail
+			if err := json.Unmarshal([]byte(auditLog.Details), &detail); err != nil {
+				continue
+			}
+
+			// 跳过已不存在的文件
+			if _, err := os.Stat(detail.Path); err != nil {
+				continue
+			}
+
+			recent = append(recent, model.RecentFile{
+				Path:       detail.Path,
+				Name:       filepath.Base(detail.Path),
+				Action:     auditLog.Action,
+				AccessedAt: auditLog.CreatedAt,
+			})
+			if len(recent) >= limit {
+				break
+			}
+		}
+		if len(ids) < limit {
+			break
+		}
+	}
+
+	return recent, nil
 }
 
 // logAuditAction 记录审计日志
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrFavoriteNotFound 收藏不存在或不属于当前用户
+var ErrFavoriteNotFound = errors.New("收藏不存在")
+
+// AddFavorite 收藏文件路径；路径必须存在，重复收藏时返回已有记录
+func (f *FileService) AddFavorite(userID uint, path, clientIP, userAgent string) (*model.FavoriteFile, error) {
+	if !f.isValidPath(path) {
+		return nil, ErrInvalidPath
+	}
+	path = filepath.Clean(path)
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, ErrPathNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取路径信息失败: %w", err)
+	}
+
+	favorite := model.FileFavorite{UserID: userID, Path: path}
+	if err := f.db.Where("user_id = ? AND path = ?", userID, path).FirstOrCreate(&favorite).Error; err != nil {
+		f.logAuditAction(userID, "add_favorite", "file", fmt.Sprintf("收藏路径失败: %s, 错误: %v", path, err), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("收藏路径失败: %w", err)
+	}
+
+	f.logAuditAction(userID, "add_favorite", "file", fmt.Sprintf("收藏路径: %s", path), clientIP, userAgent, "success")
+
+	return &model.FavoriteFile{
+		ID:        favorite.ID,
+		Path:      favorite.Path,
+		Name:      filepath.Base(favorite.Path),
+		IsDir:     info.IsDir(),
+		CreatedAt: favorite.CreatedAt,
+	}, nil
+}
+
+// ListFavorites 获取用户收藏的路径，已不存在或不再允许访问的路径标记为 Stale
+func (f *FileService) ListFavorites(userID uint) ([]model.FavoriteFile, error) {
+	var favorites []model.FileFavorite
+	if err := f.db.Where("user_id = ?", userID).Order("created_at ASC, id ASC").Find(&favorites).Error; err != nil {
+		return nil, fmt.Errorf("查询收藏失败: %w", err)
+	}
+
+	items := make([]model.FavoriteFile, 0, len(favorites))
+	for _, favorite := range favorites {
+		item := model.FavoriteFile{
+			ID:        favorite.ID,
+			Path:      favorite.Path,
+			Name:      filepath.Base(favorite.Path),
+			CreatedAt: favorite.CreatedAt,
+		}
+		if !f.isValidPath(favorite.Path) {
+			item.Stale = true
+		} else if info, err := os.Stat(favorite.Path); err != nil {
+			item.Stale = true
+		} else {
+			item.IsDir = info.IsDir()
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// RemoveFavorite 取消收藏，只能删除当前用户自己的收藏
+func (f *FileService) RemoveFavorite(userID, favoriteID uint, clientIP, userAgent string) error {
+	var favorite model.FileFavorite
+	if err := f.db.Where("id = ? AND user_id = ?", favoriteID, userID).First(&favorite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrFavoriteNotFound
+		}
+		return fmt.Errorf("查询收藏失败: %w", err)
+	}
+
+	if err := f.db.Delete(&favorite).Error; err != nil {
+		f.logAuditAction(userID, "remove_favorite", "file", fmt.Sprintf("取消收藏失败: %s, 错误: %v", favorite.Path, err), clientIP, userAgent, "failed")
+		return fmt.Errorf("取消收藏失败: %w", err)
+	}
+
+	f.logAuditAction(userID, "remove_favorite", "file", fmt.Sprintf("取消收藏: %s", favorite.Path), clientIP, userAgent, "success")
+	return nil
+}
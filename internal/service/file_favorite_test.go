@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFavoritesAddListRemove(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	dir := filepath.Join(root, "projects")
+	mustMkdir(t, dir)
+	file := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(file, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := f.AddFavorite(admin.ID, dir, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("收藏目录失败: %v", err)
+	}
+	if !first.IsDir || first.Name != "projects" {
+		t.Fatalf("收藏结果 %+v", first)
+	}
+	// 重复收藏返回已有记录
+	again, err := f.AddFavorite(admin.ID, dir+string(filepath.Separator), "127.0.0.1", "test")
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("重复收藏返回 %+v, %v，期望已有记录 %d", again, err, first.ID)
+	}
+	if _, err := f.AddFavorite(admin.ID, file, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("收藏文件失败: %v", err)
+	}
+
+	if _, err := f.AddFavorite(admin.ID, filepath.Join(root, "missing"), "127.0.0.1", "test"); !errors.Is(err, ErrPathNotFound) {
+		t.Fatalf("收藏不存在的路径返回 %v，期望 ErrPathNotFound", err)
+	}
+	if _, err := f.AddFavorite(admin.ID, filepath.Dir(root), "127.0.0.1", "test"); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("收藏根目录之外返回 %v，期望 ErrInvalidPath", err)
+	}
+
+	favorites, err := f.ListFavorites(admin.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(favorites) != 2 || favorites[0].Path != dir || favorites[1].Path != file || favorites[1].IsDir {
+		t.Fatalf("收藏列表 %+v", favorites)
+	}
+
+	if err := f.RemoveFavorite(admin.ID, first.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("取消收藏失败: %v", err)
+	}
+	if err := f.RemoveFavorite(admin.ID, first.ID, "127.0.0.1", "test"); !errors.Is(err, ErrFavoriteNotFound) {
+		t.Fatalf("重复取消收藏返回 %v，期望 ErrFavoriteNotFound", err)
+	}
+	if favorites, _ := f.ListFavorites(admin.ID); len(favorites) != 1 || favorites[0].Path != file {
+		t.Fatalf("取消收藏后列表 %+v", favorites)
+	}
+}
+
+func TestFavoritesMarkStalePaths(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	file := filepath.Join(root, "report.txt")
+	if err := os.WriteFile(file, []byte("report"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.AddFavorite(admin.ID, file, "127.0.0.1", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 路径删除后列表中仍保留，但标记为失效
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	favorites, err := f.ListFavorites(admin.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(favorites) != 1 || !favorites[0].Stale {
+		t.Fatalf("已删除的路径应标记为失效: %+v", favorites)
+	}
+
+	// 重新创建后恢复有效
+	if err := os.WriteFile(file, []byte("report"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if favorites, _ := f.ListFavorites(admin.ID); len(favorites) != 1 || favorites[0].Stale {
+		t.Fatalf("重新创建的路径不应标记为失效: %+v", favorites)
+	}
+}
+
+func TestFavoritesScopedToUser(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	other := admin.ID + 1
+	favorite, err := f.AddFavorite(admin.ID, root, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if favorites, err := f.ListFavorites(other); err != nil || len(favorites) != 0 {
+		t.Fatalf("其他用户看到了收藏: %+v, %v", favorites, err)
+	}
+	if err := f.RemoveFavorite(other, favorite.ID, "127.0.0.1", "test"); !errors.Is(err, ErrFavoriteNotFound) {
+		t.Fatalf("删除他人收藏返回 %v，期望 ErrFavoriteNotFound", err)
+	}
+	if favorites, _ := f.ListFavorites(admin.ID); len(favorites) != 1 {
+		t.Fatalf("他人删除操作影响了收藏: %+v", favorites)
+	}
+}
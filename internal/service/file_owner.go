@@ -0,0 +1,99 @@
+package service
+
+import (
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// ownerNameTTL 属主名称缓存的有效期，过期后重新解析，系统中增删改用户后能及时反映
+	ownerNameTTL = 5 * time.Minute
+	// ownerNameCacheSize 用户名和组名各自最多缓存的条目数
+	ownerNameCacheSize = 1024
+)
+
+// ownerNameEntry 缓存的名称及其过期时间
+type ownerNameEntry struct {
+	name      string
+	expiresAt time.Time
+}
+
+// ownerNameCache 缓存UID/GID到用户名/组名的解析结果，列目录时避免重复查询
+type ownerNameCache struct {
+	mutex  sync.RWMutex
+	users  map[uint32]ownerNameEntry
+	groups map[uint32]ownerNameEntry
+	now    func() time.Time
+}
+
+// newOwnerNameCache 创建属主名称缓存
+func newOwnerNameCache() *ownerNameCache {
+	return &ownerNameCache{
+		users:  make(map[uint32]ownerNameEntry),
+		groups: make(map[uint32]ownerNameEntry),
+		now:    time.Now,
+	}
+}
+
+// ownerNames 全局的属主名称缓存
+var ownerNames = newOwnerNameCache()
+
+// userName 解析UID对应的用户名，无法解析时返回数字形式
+func (c *ownerNameCache) userName(uid uint32) string {
+	return c.lookup(c.users, uid, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+}
+
+// groupName 解析GID对应的组名，无法解析时返回数字形式
+func (c *ownerNameCache) groupName(gid uint32) string {
+	return c.lookup(c.groups, gid, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+}
+
+func (c *ownerNameCache) lookup(names map[uint32]ownerNameEntry, id uint32, resolve func(string) (string, error)) string {
+	now := c.now()
+	c.mutex.RLock()
+	entry, ok := names[id]
+	c.mutex.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.name
+	}
+
+	idStr := strconv.FormatUint(uint64(id), 10)
+	name, err := resolve(idStr)
+	if err != nil || name == "" {
+		name = idStr
+	}
+
+	c.mutex.Lock()
+	if _, exists := names[id]; !exists && len(names) >= ownerNameCacheSize {
+		evictOwnerNames(names, now)
+	}
+	names[id] = ownerNameEntry{name: name, expiresAt: now.Add(ownerNameTTL)}
+	c.mutex.Unlock()
+	return name
+}
+
+// evictOwnerNames 缓存已满时删除过期条目，仍然已满则清空，调用方需持有写锁
+func evictOwnerNames(names map[uint32]ownerNameEntry, now time.Time) {
+	for id, entry := range names {
+		if !now.Before(entry.expiresAt) {
+			delete(names, id)
+		}
+	}
+	if len(names) >= ownerNameCacheSize {
+		clear(names)
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestOwnerNameCacheExpiresEntries(t *testing.T) {
+	c := newOwnerNameCache()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	calls := 0
+	name := "alice"
+	resolve := func(string) (string, error) {
+		calls++
+		return name, nil
+	}
+
+	if got := c.lookup(c.users, 1000, resolve); got != "alice" || calls != 1 {
+		t.Fatalf("首次解析 = %q, 调用 %d 次", got, calls)
+	}
+	if got := c.lookup(c.users, 1000, resolve); got != "alice" || calls != 1 {
+		t.Fatalf("有效期内应命中缓存: %q, 调用 %d 次", got, calls)
+	}
+
+	// 过期后重新解析，反映用户名的变化
+	name = "bob"
+	now = now.Add(ownerNameTTL)
+	if got := c.lookup(c.users, 1000, resolve); got != "bob" || calls != 2 {
+		t.Fatalf("过期后应重新解析: %q, 调用 %d 次", got, calls)
+	}
+}
+
+func TestOwnerNameCacheIsBounded(t *testing.T) {
+	c := newOwnerNameCache()
+	resolve := func(id string) (string, error) { return "user" + id, nil }
+
+	for i := 0; i < ownerNameCacheSize*3; i++ {
+		c.lookup(c.users, uint32(i), resolve)
+		if len(c.users) > ownerNameCacheSize {
+			t.Fatalf("缓存条目数 %d 超过上限 %d", len(c.users), ownerNameCacheSize)
+		}
+	}
+
+	last := uint32(ownerNameCacheSize*3 - 1)
+	if got := c.lookup(c.users, last, resolve); got != "user"+strconv.Itoa(int(last)) {
+		t.Fatalf("解析结果 = %q", got)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package service
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwner 获取文件的属主和属组名称
+func fileOwner(info os.FileInfo) (owner, group string) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+	return ownerNames.userName(stat.Uid), ownerNames.groupName(stat.Gid)
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package service
+
+import "os"
+
+// fileOwner Windows 下没有UID/GID，属主和属组留空
+func fileOwner(info os.FileInfo) (owner, group string) {
+	return "", ""
+}
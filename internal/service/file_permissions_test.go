@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/model"
+)
+
+// assertMode 检查路径的权限位
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != want {
+		t.Errorf("%s 权限 = %04o，期望 %04o", path, got, want)
+	}
+}
+
+func TestChangePermissionsRecursiveFileAndDirModes(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	site := filepath.Join(root, "site")
+	sub := filepath.Join(site, "sub")
+	mustMkdir(t, sub)
+	for _, path := range []string{filepath.Join(site, "index.html"), filepath.Join(sub, "app.js")} {
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Chmod(sub, 0700)
+	os.Chmod(site, 0700)
+	// 符号链接不跟随，也不修改
+	if err := os.Symlink(filepath.Join(site, "index.html"), filepath.Join(site, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := f.ChangePermissions(&model.ChangePermissionsRequest{
+		Paths:     []string{site},
+		FileMode:  "644",
+		DirMode:   "755",
+		Recursive: true,
+	}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("递归修改权限失败: %v", err)
+	}
+	if resp.Changed != 4 || resp.Skipped != 1 || len(resp.Failed) != 0 {
+		t.Fatalf("修改结果 = %+v，期望修改 4 个、跳过 1 个符号链接", resp)
+	}
+	assertMode(t, site, 0755)
+	assertMode(t, sub, 0755)
+	assertMode(t, filepath.Join(site, "index.html"), 0644)
+	assertMode(t, filepath.Join(sub, "app.js"), 0644)
+
+	var entry model.AuditLog
+	if err := f.db.Where("action = ?", "chmod").Order("id DESC").First(&entry).Error; err != nil {
+		t.Fatalf("缺少批量修改权限审计日志: %v", err)
+	}
+	if !strings.Contains(entry.Details, "原权限: "+site+"(0700)") || !strings.Contains(entry.Details, "递归: true, 修改: 4, 跳过: 1") {
+		t.Fatalf("审计详情 = %q", entry.Details)
+	}
+}
+
+func TestChangePermissionsNonRecursiveAndMissingPaths(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	dir := filepath.Join(root, "dir")
+	mustMkdir(t, dir)
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 不递归时只修改指定的路径本身，mode 同时作为文件和目录的权限
+	resp, err := f.ChangePermissions(&model.ChangePermissionsRequest{
+		Paths: []string{dir, filepath.Join(root, "missing")},
+		Mode:  "750",
+	}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("修改权限失败: %v", err)
+	}
+	if resp.Changed != 1 || resp.Skipped != 1 {
+		t.Fatalf("修改结果 = %+v，期望修改 1 个、跳过不存在的路径", resp)
+	}
+	assertMode(t, dir, 0750)
+	assertMode(t, file, 0600)
+
+	// 只指定目录权限时文件保持不变
+	if _, err := f.ChangePermissions(&model.ChangePermissionsRequest{Path: dir, DirMode: "700", Recursive: true}, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatal(err)
+	}
+	assertMode(t, dir, 0700)
+	assertMode(t, file, 0600)
+
+	for _, req := range []*model.ChangePermissionsRequest{
+		{Path: dir},
+		{Path: dir, Mode: "999"},
+		{Mode: "644"},
+	} {
+		if _, err := f.ChangePermissions(req, admin.ID, "127.0.0.1", "test"); err == nil {
+			t.Fatalf("请求 %+v 应返回错误", req)
+		}
+	}
+	if _, err := f.ChangePermissions(&model.ChangePermissionsRequest{Path: "/etc", Mode: "644"}, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("根目录外的路径返回 %v，期望 ErrInvalidPath", err)
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"web-panel-go/internal/model"
+)
+
+const (
+	// DefaultFileStatsDepth 统计文件类型时默认向下遍历的目录层数（1表示只统计直接子文件）
+	DefaultFileStatsDepth = 5
+	// MaxFileStatsDepth 统计文件类型时允许的最大目录层数
+	MaxFileStatsDepth = 20
+
+	// fileStatsTimeout 单次统计的超时时间，超时后返回已统计的部分
+	fileStatsTimeout = 10 * time.Second
+	// fileStatsWorkers 同时遍历的子目录数
+	fileStatsWorkers = 4
+	// maxFileStatsEntries 单次统计最多访问的条目数
+	maxFileStatsEntries = 200000
+)
+
+// errFileStatsCapReached 访问条目数达到上限，停止遍历
+var errFileStatsCapReached = errors.New("达到统计条目上限")
+
+// fileStatsCollector 汇总各遍历协程的统计结果
+type fileStatsCollector struct {
+	mutex   sync.Mutex
+	types   map[string]*model.FileTypeStat
+	entries atomic.Int64
+	skipped atomic.Int64
+}
+
+// visit 计入一个条目，超过上限时返回 errFileStatsCapReached
+func (c *fileStatsCollector) visit() error {
+	if c.entries.Add(1) > maxFileStatsEntries {
+		return errFileStatsCapReached
+	}
+	return nil
+}
+
+// merge 合并单个协程的局部统计
+func (c *fileStatsCollector) merge(local map[string]*model.FileTypeStat) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for ext, stat := range local {
+		if total, ok := c.types[ext]; ok {
+			total.Count += stat.Count
+			total.Size += stat.Size
+			continue
+		}
+		c.types[ext] = stat
+	}
+}
+
+// addFileStat 按扩展名累加文件数量和大小
+func addFileStat(stats map[string]*model.FileTypeStat, name string, size int64) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	stat, ok := stats[ext]
+	if !ok {
+		stat = &model.FileTypeStat{Extension: ext, Category: fileCategory(ext)}
+		stats[ext] = stat
+	}
+	stat.Count++
+	stat.Size += size
+}
+
+// fileCategory 根据扩展名对应的MIME主类型归类
+func fileCategory(ext string) string {
+	if ext == "" {
+		return "other"
+	}
+	mimeType := mime.TypeByExtension("." + ext)
+	if mimeType == "" {
+		return "other"
+	}
+	return strings.SplitN(mimeType, "/", 2)[0]
+}
+
+// GetFileTypeStats 统计目录下按扩展名分组的文件数量和总大小
+// depth 为向下遍历的目录层数；超时或达到条目上限时返回已统计的部分并标记 Partial/Truncated
+func (f *FileService) GetFileTypeStats(ctx context.Context, path string, depth int) (*model.FileTypeStatsResponse, error) {
+	if !f.isValidPath(path) {
+		return nil, ErrInvalidPath
+	}
+	if depth <= 0 {
+		depth = DefaultFileStatsDepth
+	}
+	if depth > MaxFileStatsDepth {
+		depth = MaxFileStatsDepth
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, ErrPathNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取路径信息失败: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, ErrNotDirectory
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取目录失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fileStatsTimeout)
+	defer cancel()
+
+	collector := &fileStatsCollector{types: make(map[string]*model.FileTypeStat)}
+	var capReached atomic.Bool
+
+	// 直接子文件在当前协程统计，子目录交给有限数量的协程并发遍历
+	direct := make(map[string]*model.FileTypeStat)
+	var subdirs []string
+	for _, entry := range entries {
+		if err := collector.visit(); err != nil {
+			capReached.Store(true)
+			break
+		}
+		if entry.IsDir() {
+			if depth > 1 {
+				subdirs = append(subdirs, filepath.Join(path, entry.Name()))
+			}
+			continue
+		}
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			collector.skipped.Add(1)
+			continue
+		}
+		addFileStat(direct, entry.Name(), fi.Size())
+	}
+	collector.merge(direct)
+
+	sem := make(chan struct{}, fileStatsWorkers)
+	var wg sync.WaitGroup
+	for _, dir := range subdirs {
+		if capReached.Load() || ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(dir string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := f.walkFileStats(ctx, dir, depth, collector); errors.Is(err, errFileStatsCapReached) {
+				capReached.Store(true)
+			}
+		}(dir)
+	}
+	wg.Wait()
+
+	response := &model.FileTypeStatsResponse{
+		Path:      path,
+		Depth:     depth,
+		Types:     make([]model.FileTypeStat, 0, len(collector.types)),
+		Skipped:   collector.skipped.Load(),
+		Truncated: capReached.Load(),
+		Partial:   ctx.Err() != nil,
+	}
+	for _, stat := range collector.types {
+		response.Types = append(response.Types, *stat)
+		response.TotalFiles += stat.Count
+		response.TotalSize += stat.Size
+	}
+	sort.Slice(response.Types, func(i, j int) bool {
+		a, b := response.Types[i], response.Types[j]
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Extension < b.Extension
+	})
+	return response, nil
+}
+
+// walkFileStats 遍历第一层子目录 dir，统计不超过 depth 层的文件，无法读取的条目计入跳过数
+func (f *FileService) walkFileStats(ctx context.Context, dir string, depth int, collector *fileStatsCollector) error {
+	local := make(map[string]*model.FileTypeStat)
+	defer collector.merge(local)
+
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			collector.skipped.Add(1)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if p == dir {
+			return nil
+		}
+		if err := collector.visit(); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			// dir 位于第1层，其下目录的层数为 1 + 相对路径的层数
+			level := 1 + strings.Count(p[len(dir):], string(filepath.Separator))
+			if level >= depth {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			collector.skipped.Add(1)
+			return nil
+		}
+		addFileStat(local, d.Name(), fi.Size())
+		return nil
+	})
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/model"
+)
+
+// seedFileStatsTree 创建统计用的目录树，files 为相对路径到文件大小的映射
+func seedFileStatsTree(t *testing.T, root string, files map[string]int) {
+	t.Helper()
+	for name, size := range files {
+		path := filepath.Join(root, name)
+		mustMkdir(t, filepath.Dir(path))
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetFileTypeStatsGroupsByExtension(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	dir := filepath.Join(root, "data")
+	seedFileStatsTree(t, dir, map[string]int{
+		"a.log":          100,
+		"logs/b.LOG":     200,
+		"logs/old/c.log": 300,
+		"readme.txt":     10,
+		"img/photo.png":  50,
+		"Makefile":       5,
+	})
+
+	stats, err := f.GetFileTypeStats(context.Background(), dir, 0)
+	if err != nil {
+		t.Fatalf("统计失败: %v", err)
+	}
+	if stats.TotalFiles != 6 || stats.TotalSize != 665 {
+		t.Fatalf("总计 %d 个文件 %d 字节，期望 6 个 665 字节", stats.TotalFiles, stats.TotalSize)
+	}
+
+	// 扩展名不区分大小写，按总大小降序排列
+	want := []model.FileTypeStat{
+		{Extension: "log", Count: 3, Size: 600},
+		{Extension: "png", Category: "image", Count: 1, Size: 50},
+		{Extension: "txt", Category: "text", Count: 1, Size: 10},
+		{Extension: "", Category: "other", Count: 1, Size: 5},
+	}
+	if len(stats.Types) != len(want) {
+		t.Fatalf("分组 %+v，期望 %d 组", stats.Types, len(want))
+	}
+	for i, w := range want {
+		got := stats.Types[i]
+		if got.Extension != w.Extension || got.Count != w.Count || got.Size != w.Size {
+			t.Errorf("第 %d 组 = %+v，期望 %+v", i, got, w)
+		}
+		if w.Category != "" && got.Category != w.Category {
+			t.Errorf("%q 分类 = %s，期望 %s", w.Extension, got.Category, w.Category)
+		}
+	}
+	if stats.Truncated || stats.Partial {
+		t.Fatalf("完整统计不应标记为部分结果: %+v", stats)
+	}
+}
+
+func TestGetFileTypeStatsDepth(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	seedFileStatsTree(t, root, map[string]int{
+		"top.txt":        1,
+		"l1/mid.txt":     2,
+		"l1/l2/deep.txt": 4,
+		"l1/l2/l3/x.txt": 8,
+		"other/sub.txt":  16,
+	})
+
+	tests := []struct {
+		depth int
+		want  int64
+	}{
+		{1, 1},
+		{2, 1 + 2 + 16},
+		{3, 1 + 2 + 4 + 16},
+		{DefaultFileStatsDepth, 31},
+	}
+	for _, tt := range tests {
+		stats, err := f.GetFileTypeStats(context.Background(), root, tt.depth)
+		if err != nil {
+			t.Fatalf("depth=%d 统计失败: %v", tt.depth, err)
+		}
+		if stats.Depth != tt.depth || stats.TotalSize != tt.want {
+			t.Errorf("depth=%d 统计到 %d 字节，期望 %d", tt.depth, stats.TotalSize, tt.want)
+		}
+	}
+}
+
+func TestGetFileTypeStatsRejectsInvalidPaths(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	seedFileStatsTree(t, root, map[string]int{"file.txt": 1})
+
+	if _, err := f.GetFileTypeStats(context.Background(), filepath.Join(root, "file.txt"), 0); !errors.Is(err, ErrNotDirectory) {
+		t.Fatalf("统计文件返回 %v，期望 ErrNotDirectory", err)
+	}
+	if _, err := f.GetFileTypeStats(context.Background(), filepath.Join(root, "missing"), 0); !errors.Is(err, ErrPathNotFound) {
+		t.Fatalf("统计不存在的目录返回 %v，期望 ErrPathNotFound", err)
+	}
+	if _, err := f.GetFileTypeStats(context.Background(), filepath.Dir(root), 0); !errors.Is(err, ErrInvalidPath) {
+		t.Fatalf("统计根目录之外返回 %v，期望 ErrInvalidPath", err)
+	}
+}
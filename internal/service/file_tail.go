@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+)
+
+// 文件跟踪错误
+var (
+	ErrTooManyTails = errors.New("同时跟踪的文件数已达上限")
+	ErrTailNotFile  = errors.New("只能跟踪普通文件")
+	ErrTailEscaped  = errors.New("跟踪的文件已被替换为根目录以外的路径")
+)
+
+const (
+	// tailReadChunk 每次轮询最多推送的新增字节数，剩余部分在下一次轮询继续推送
+	tailReadChunk = 64 * 1024
+	// tailMaxInitialBytes 读取末尾N行时最多向前读取的字节数
+	tailMaxInitialBytes = 1 << 20
+)
+
+// FileTailService 文件跟踪服务，按轮询方式把文件新增内容推送给调用方
+type FileTailService struct {
+	files        *FileService
+	maxPerUser   int
+	maxLines     int
+	pollInterval time.Duration
+
+	mu     sync.Mutex
+	active map[uint]int // 用户ID -> 正在进行的跟踪数
+}
+
+// NewFileTailService 创建文件跟踪服务实例
+func NewFileTailService(cfg *config.Config, files *FileService) *FileTailService {
+	pollInterval := cfg.File.TailPollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	return &FileTailService{
+		files:        files,
+		maxPerUser:   cfg.File.TailMaxPerUser,
+		maxLines:     cfg.File.TailMaxLines,
+		pollInterval: pollInterval,
+		active:       make(map[uint]int),
+	}
+}
+
+// FileTail 一次正在进行的文件跟踪
+type FileTail struct {
+	Path    string
+	Initial string // 启动时文件末尾的N行
+
+	file   *os.File
+	info   os.FileInfo
+	offset int64
+	buf    []byte
+	done   chan struct{}
+}
+
+// Done 跟踪结束（上下文取消或文件无法继续读取）后关闭
+func (t *FileTail) Done() <-chan struct{} {
+	return t.done
+}
+
+// Start 开始跟踪文件：返回末尾 lines 行，之后新增内容通过 emit 推送，直到 ctx 取消
+// emit 在跟踪协程中调用；文件被截断或替换（如日志轮转）时 reset 为true，data 为新文件从头开始的内容
+func (s *FileTailService) Start(ctx context.Context, path string, lines int, userID uint, clientIP, userAgent string, emit func(data string, reset bool)) (*FileTail, error) {
+	if !s.files.isValidPath(path) {
+		s.files.logAuditAction(userID, "tail_file", "file", fmt.Sprintf("跟踪文件失败: 无效路径 %s", path), clientIP, userAgent, "failed")
+		return nil, ErrInvalidPath
+	}
+	if lines <= 0 {
+		lines = 10
+	}
+	if s.maxLines > 0 && lines > s.maxLines {
+		lines = s.maxLines
+	}
+
+	if !s.acquire(userID) {
+		s.files.logAuditAction(userID, "tail_file", "file", fmt.Sprintf("跟踪文件失败: 超过并发上限 %s", path), clientIP, userAgent, "failed")
+		return nil, ErrTooManyTails
+	}
+
+	tail, err := openTail(path, lines)
+	if err != nil {
+		s.release(userID)
+		s.files.logAuditAction(userID, "tail_file", "file", fmt.Sprintf("跟踪文件失败: %s, 错误: %v", path, err), clientIP, userAgent, "failed")
+		return nil, err
+	}
+
+	s.files.logAuditAction(userID, "tail_file", "file", fmt.Sprintf("跟踪文件: %s", path), clientIP, userAgent, "success")
+	logger.Info("开始跟踪文件", "path", path, "lines", lines, "user_id", userID)
+
+	go func() {
+		defer close(tail.done)
+		defer s.release(userID)
+		defer func() { tail.file.Close() }() // 轮转后 tail.file 会被替换，关闭最终打开的句柄
+		s.follow(ctx, tail, emit)
+		logger.Info("停止跟踪文件", "path", path, "user_id", userID)
+	}()
+	return tail, nil
+}
+
+// acquire 占用一个跟踪名额
+func (s *FileTailService) acquire(userID uint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxPerUser > 0 && s.active[userID] >= s.maxPerUser {
+		return false
+	}
+	s.active[userID]++
+	return true
+}
+
+// release 释放跟踪名额
+func (s *FileTailService) release(userID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[userID] <= 1 {
+		delete(s.active, userID)
+		return
+	}
+	s.active[userID]--
+}
+
+// openTail 打开文件并读取末尾 lines 行，后续从文件末尾开始跟踪
+func openTail(path string, lines int) (*FileTail, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrFileNotFound
+		}
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, ErrTailNotFile
+	}
+
+	initial, err := readLastLines(file, info.Size(), lines)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	return &FileTail{
+		Path:    path,
+		Initial: initial,
+		file:    file,
+		info:    info,
+		offset:  info.Size(),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// readLastLines 从文件末尾向前按块读取，直到找到 lines 行或达到读取上限
+func readLastLines(file *os.File, size int64, lines int) (string, error) {
+	const block = 8 * 1024
+	var buf []byte
+	pos := size
+	for pos > 0 && size-pos < tailMaxInitialBytes {
+		n := int64(block)
+		if pos < n {
+			n = pos
+		}
+		pos -= n
+		chunk := make([]byte, n)
+		if _, err := file.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return "", err
+		}
+		buf = append(chunk, buf...)
+		// 末尾换行不算作一行的开始，因此需要多找到一个换行符
+		if bytes.Count(buf, []byte{'\n'}) > lines {
+			break
+		}
+	}
+
+	trimmed := bytes.TrimSuffix(buf, []byte{'\n'})
+	for i := 0; i < lines; i++ {
+		idx := bytes.LastIndexByte(trimmed, '\n')
+		if idx < 0 {
+			return string(buf), nil
+		}
+		trimmed = trimmed[:idx]
+	}
+	return string(buf[len(trimmed)+1:]), nil
+}
+
+// follow 定期检查文件变化并推送新增内容
+func (s *FileTailService) follow(ctx context.Context, tail *FileTail, emit func(data string, reset bool)) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reset, err := tail.checkRotation(s.files.isValidPath)
+		if err != nil {
+			logger.Warn("跟踪文件失败", "path", tail.Path, "error", err)
+			return
+		}
+		data, err := tail.readAppended()
+		if err != nil {
+			logger.Warn("读取跟踪文件失败", "path", tail.Path, "error", err)
+			return
+		}
+		if len(data) > 0 || reset {
+			emit(string(data), reset)
+		}
+	}
+}
+
+// checkRotation 检测文件被替换（重新打开新文件）或被截断（从头读取）
+// 文件被替换时用 valid 重新校验路径，替换成指向根目录以外的符号链接时返回 ErrTailEscaped
+func (t *FileTail) checkRotation(valid func(path string) bool) (bool, error) {
+	current, err := os.Stat(t.Path)
+	if err != nil {
+		// 轮转过程中文件可能短暂不存在，继续读取旧句柄
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !os.SameFile(t.info, current) {
+		file, info, err := t.reopen(valid)
+		if err != nil {
+			return false, err
+		}
+		t.file.Close()
+		t.file, t.info, t.offset = file, info, 0
+		return true, nil
+	}
+
+	if current.Size() < t.offset {
+		t.offset = 0
+		return true, nil
+	}
+	return false, nil
+}
+
+// reopen 重新打开被替换的文件
+// 打开后再校验路径，并确认路径指向的仍是打开的文件，避免校验和打开之间路径被换成符号链接
+func (t *FileTail) reopen(valid func(path string) bool) (*os.File, os.FileInfo, error) {
+	file, err := os.Open(t.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, nil, ErrTailNotFile
+	}
+
+	current, err := os.Stat(t.Path)
+	if err != nil || !valid(t.Path) || !os.SameFile(info, current) {
+		file.Close()
+		return nil, nil, ErrTailEscaped
+	}
+	return file, info, nil
+}
+
+// readAppended 读取上次位置之后新增的内容，单次最多 tailReadChunk 字节
+func (t *FileTail) readAppended() ([]byte, error) {
+	if t.buf == nil {
+		t.buf = make([]byte, tailReadChunk)
+	}
+	n, err := t.file.ReadAt(t.buf, t.offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	t.offset += int64(n)
+	return t.buf[:n], nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tailRecorder 收集跟踪推送的内容
+type tailRecorder struct {
+	mu   sync.Mutex
+	data strings.Builder
+}
+
+func (r *tailRecorder) emit(data string, reset bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.data.WriteString(data)
+}
+
+func (r *tailRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.data.String()
+}
+
+// startTestTail 在文件根目录中创建 name 并以很短的轮询间隔开始跟踪
+func startTestTail(t *testing.T, name string) (*FileTail, *tailRecorder, string) {
+	t.Helper()
+	f, root, admin := newTestFileService(t)
+	f.config.File.TailPollInterval = 10 * time.Millisecond
+	tails := NewFileTailService(f.config, f)
+
+	path := filepath.Join(root, name)
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	recorder := &tailRecorder{}
+	tail, err := tails.Start(ctx, path, 10, admin.ID, "127.0.0.1", "test", recorder.emit)
+	if err != nil {
+		t.Fatalf("开始跟踪失败: %v", err)
+	}
+	return tail, recorder, path
+}
+
+func TestFileTailFollowsRotation(t *testing.T) {
+	tail, recorder, path := startTestTail(t, "app.log")
+
+	// 轮转：新文件替换原路径
+	rotated := path + ".new"
+	if err := os.WriteFile(rotated, []byte("rotated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(rotated, path); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(recorder.String(), "rotated") {
+		if time.Now().After(deadline) {
+			t.Fatalf("轮转后没有推送新文件内容，已推送 %q", recorder.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-tail.Done():
+		t.Fatal("正常轮转后跟踪不应结束")
+	default:
+	}
+}
+
+func TestFileTailStopsOnSymlinkEscape(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "shadow")
+	if err := os.WriteFile(secret, []byte("root:secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tail, recorder, path := startTestTail(t, "app.log")
+
+	// 把跟踪的路径换成指向根目录以外文件的符号链接
+	link := path + ".link"
+	mustSymlink(t, secret, link)
+	if err := os.Rename(link, path); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-tail.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("路径被换成逃逸的符号链接后跟踪没有停止")
+	}
+	if strings.Contains(recorder.String(), "secret") {
+		t.Fatalf("推送了根目录以外的文件内容: %q", recorder.String())
+	}
+}
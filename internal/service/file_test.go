@@ -0,0 +1,680 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/model"
+)
+
+// mustMkdir 创建测试目录
+func mustMkdir(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mustSymlink 创建符号链接，平台不支持时跳过测试
+func mustSymlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		if runtime.GOOS == "windows" {
+			t.Skipf("无法创建符号链接: %v", err)
+		}
+		t.Fatal(err)
+	}
+}
+
+func TestIsValidPathJail(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	outside := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "docs"))
+	mustSymlink(t, outside, filepath.Join(root, "escape"))
+	mustSymlink(t, filepath.Join(root, "docs"), filepath.Join(root, "docs-link"))
+
+	tests := []struct {
+		name string
+		path string
+		want bool
+	}{
+		{"根目录", root, true},
+		{"子目录", filepath.Join(root, "docs"), true},
+		{"尚不存在的文件", filepath.Join(root, "docs", "new.txt"), true},
+		{"指向根目录内的符号链接", filepath.Join(root, "docs-link", "a.txt"), true},
+		{"../逃逸", root + string(filepath.Separator) + ".." + string(filepath.Separator) + "etc", false},
+		{"中间的../逃逸", filepath.Join(root, "docs") + "/../../" + filepath.Base(outside), false},
+		{"绝对路径", outside, false},
+		{"根目录前缀相同的兄弟目录", root + "-sibling", false},
+		{"符号链接逃逸", filepath.Join(root, "escape"), false},
+		{"符号链接逃逸后的新文件", filepath.Join(root, "escape", "new.txt"), false},
+		{"空路径", "", false},
+		{"非绝对路径", "docs", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.isValidPath(tt.path); got != tt.want {
+				t.Fatalf("isValidPath(%q) = %v, 期望 %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateDirectoryRejectsEscape(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	outside := t.TempDir()
+	mustSymlink(t, outside, filepath.Join(root, "escape"))
+
+	for _, name := range []string{"../evil", "a/../../evil", "..", "", "escape/evil", `..\evil`} {
+		if err := f.CreateDirectory(root, name, admin.ID, "127.0.0.1", "test"); err == nil {
+			t.Errorf("CreateDirectory(%q) 期望失败", name)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Fatalf("根目录外被创建了 %d 个条目", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "evil")); !os.IsNotExist(err) {
+		t.Fatal("根目录的父目录中被创建了 evil")
+	}
+
+	if err := f.CreateDirectory(root, "ok", admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("创建普通目录失败: %v", err)
+	}
+}
+
+func TestWritesRejectDanglingSymlink(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	outside := t.TempDir()
+	target := filepath.Join(outside, "x")
+	mustSymlink(t, target, filepath.Join(root, "link"))
+	mustSymlink(t, filepath.Join(outside, "dir"), filepath.Join(root, "dirlink"))
+
+	for _, path := range []string{filepath.Join(root, "link"), filepath.Join(root, "dirlink", "a.txt")} {
+		if f.isValidPath(path) {
+			t.Errorf("isValidPath(%q) = true，期望悬空符号链接被拒绝", path)
+		}
+		if _, err := f.SaveFileContent(path, "pwned", "", "", true, admin.ID, "127.0.0.1", "test"); err == nil {
+			t.Errorf("SaveFileContent(%q) 期望失败", path)
+		}
+	}
+	if err := f.CreateDirectory(root, "dirlink", admin.ID, "127.0.0.1", "test"); err == nil {
+		t.Error("在悬空符号链接处创建目录期望失败")
+	}
+	if err := f.CreateDirectory(filepath.Join(root, "dirlink"), "sub", admin.ID, "127.0.0.1", "test"); err == nil {
+		t.Error("在悬空符号链接下创建目录期望失败")
+	}
+
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Fatalf("根目录外被创建了 %d 个条目", len(entries))
+	}
+}
+
+func TestWriteFileNoFollow(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	mustSymlink(t, target, filepath.Join(dir, "link"))
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 下没有 O_NOFOLLOW")
+	}
+
+	if err := writeFileNoFollow(filepath.Join(dir, "link"), []byte("x"), 0644); err == nil {
+		t.Fatal("写入符号链接期望失败")
+	}
+	if _, err := os.Lstat(target); !os.IsNotExist(err) {
+		t.Fatal("写入跟随符号链接创建了目标文件")
+	}
+}
+
+func TestRenameFileRejectsEscape(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	outside := t.TempDir()
+	mustSymlink(t, outside, filepath.Join(root, "escape"))
+	src := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(src, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../a.txt", "../../a.txt", "escape/a.txt", outside + "/a.txt", "..", ""} {
+		if err := f.RenameFile(src, name, admin.ID, "127.0.0.1", "test"); err == nil {
+			t.Errorf("RenameFile(%q) 期望失败", name)
+		}
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Fatalf("原文件被移走: %v", err)
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Fatalf("根目录外出现了 %d 个条目", len(entries))
+	}
+
+	if err := f.RenameFile(src, "b.txt", admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("普通重命名失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "b.txt")); err != nil {
+		t.Fatalf("重命名后的文件不存在: %v", err)
+	}
+}
+
+
+func TestDestructiveOperationsRejectRoot(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	f.config.File.TrashEnabled = true
+	mustMkdir(t, filepath.Join(root, "sub"))
+	marker := filepath.Join(root, "sub", "a.txt")
+	if err := os.WriteFile(marker, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{root, root + "/.", filepath.Join(root, "sub") + "/.."} {
+		for _, permanent := range []bool{false, true} {
+			if _, err := f.DeleteFile(path, permanent, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrRootPath) {
+				t.Errorf("删除根目录 %s (permanent=%v) 返回 %v，期望 ErrRootPath", path, permanent, err)
+			}
+		}
+		if err := f.RenameFile(path, "renamed", admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrRootPath) {
+			t.Errorf("重命名根目录 %s 返回 %v，期望 ErrRootPath", path, err)
+		}
+		if err := f.MoveFile(path, filepath.Join(root, "sub", "moved"), false, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrRootPath) {
+			t.Errorf("移动根目录 %s 返回 %v，期望 ErrRootPath", path, err)
+		}
+	}
+
+	// 不能用其他文件覆盖根目录
+	if err := f.MoveFile(marker, root, true, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrRootPath) {
+		t.Errorf("移动覆盖根目录返回 %v，期望 ErrRootPath", err)
+	}
+	if err := f.CopyFile(marker, root, true, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrRootPath) {
+		t.Errorf("复制覆盖根目录返回 %v，期望 ErrRootPath", err)
+	}
+
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("根目录内容被修改: %v", err)
+	}
+
+	// 根目录下的子目录仍可正常删除
+	if _, err := f.DeleteFile(filepath.Join(root, "sub"), true, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("删除子目录失败: %v", err)
+	}
+}
+func TestGetPathMetaJail(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	mustMkdir(t, filepath.Join(root, "docs", "sub"))
+	link := filepath.Join(t.TempDir(), "root-link")
+	mustSymlink(t, root, link)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantParent string
+		wantRoot   bool
+	}{
+		{"根目录", root, root, true},
+		{"根目录带结尾分隔符", root + string(filepath.Separator), root, true},
+		{"一级子目录", filepath.Join(root, "docs"), root, false},
+		{"二级子目录", filepath.Join(root, "docs", "sub"), filepath.Join(root, "docs"), false},
+		{"../回到根目录", filepath.Join(root, "docs") + "/..", root, true},
+		{"指向根目录的符号链接", link, link, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, parent, isRoot := f.GetPathMeta(tt.path)
+			if parent != tt.wantParent || isRoot != tt.wantRoot {
+				t.Fatalf("GetPathMeta(%q) = %q, %v, 期望 %q, %v", tt.path, parent, isRoot, tt.wantParent, tt.wantRoot)
+			}
+			// 返回的上级目录必须能通过路径校验，面包屑导航不会请求根目录以外的路径
+			if !f.isValidPath(parent) {
+				t.Fatalf("上级目录 %q 不在根目录内", parent)
+			}
+		})
+	}
+
+	// 未限制根目录时以文件系统根为顶层
+	f.rootDir = ""
+	top := filepath.VolumeName(root) + string(filepath.Separator)
+	if _, parent, isRoot := f.GetPathMeta(top); !isRoot || parent != top {
+		t.Fatalf("GetPathMeta(%q) = %q, %v, 期望为顶层", top, parent, isRoot)
+	}
+	if _, parent, isRoot := f.GetPathMeta(root); isRoot || parent != filepath.Dir(root) {
+		t.Fatalf("GetPathMeta(%q) = %q, %v, 期望上级为 %q", root, parent, isRoot, filepath.Dir(root))
+	}
+}
+
+func TestGetRecentFiles(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	read := func(name string) {
+		t.Helper()
+		if _, err := f.GetFileContent(filepath.Join(root, name), admin.ID, "127.0.0.1", "test"); err != nil {
+			t.Fatalf("读取 %s 失败: %v", name, err)
+		}
+	}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "gone.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 按时间顺序：a、b、c、a（再次读取）、gone，最后保存 b（保存同样计入）
+	read("a.txt")
+	read("b.txt")
+	read("c.txt")
+	read("a.txt")
+	read("gone.txt")
+	if _, err := f.SaveFileContent(filepath.Join(root, "b.txt"), "changed", "", "", true, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("保存 b.txt 失败: %v", err)
+	}
+	os.Remove(filepath.Join(root, "gone.txt"))
+
+	// 其他用户和失败的操作不计入
+	other := admin.ID + 100
+	f.GetFileContent(filepath.Join(root, "c.txt"), other, "127.0.0.1", "test")
+	f.GetFileContent(filepath.Join(root, "missing.txt"), admin.ID, "127.0.0.1", "test")
+
+	recent, err := f.GetRecentFiles(admin.ID, 10)
+	if err != nil {
+		t.Fatalf("查询最近文件失败: %v", err)
+	}
+	var names []string
+	for _, file := range recent {
+		names = append(names, file.Name)
+	}
+	want := []string{"b.txt", "a.txt", "c.txt"}
+	if len(names) != len(want) {
+		t.Fatalf("最近文件 = %v，期望 %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("最近文件 = %v，期望 %v", names, want)
+		}
+	}
+	if recent[0].Action != "save_file" || recent[1].Action != "read_file" {
+		t.Fatalf("动作 = %s, %s，期望取每个路径最新的一条", recent[0].Action, recent[1].Action)
+	}
+
+	// 数量限制在去重后生效，第一页中不存在的 gone.txt 被跳过后从下一页补足
+	recent, err = f.GetRecentFiles(admin.ID, 2)
+	if err != nil || len(recent) != 2 || recent[1].Name != "a.txt" {
+		t.Fatalf("limit=2 返回 %v, %v", recent, err)
+	}
+	recent, err = f.GetRecentFiles(admin.ID, 1)
+	if err != nil || len(recent) != 1 || recent[0].Name != "b.txt" {
+		t.Fatalf("limit=1 返回 %v, %v", recent, err)
+	}
+}
+
+func TestSaveFileContentVersion(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	path := filepath.Join(root, "config.ini")
+	if err := os.WriteFile(path, []byte("a=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	save := func(content, version string, force bool) (*model.SaveFileContentResponse, error) {
+		return f.SaveFileContent(path, content, version, "", force, admin.ID, "127.0.0.1", "test")
+	}
+
+	read, err := f.GetFileContent(path, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+
+	// 不带版本号的保存不做检查，兼容未升级的客户端
+	saved, err := save("a=2\n", "", false)
+	if err != nil {
+		t.Fatalf("不带版本号保存失败: %v", err)
+	}
+
+	// 读取后文件已被修改，旧版本号保存返回冲突且不写入
+	if _, err := save("a=3\n", read.Version, false); !errors.Is(err, ErrFileVersionConflict) {
+		t.Fatalf("旧版本号保存返回 %v，期望 ErrFileVersionConflict", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a=2\n" {
+		t.Fatalf("版本冲突时文件被覆盖为 %q", data)
+	}
+
+	// 使用上次保存返回的版本号可以继续保存，force 忽略版本号
+	if _, err := save("a=4\n", saved.Version, false); err != nil {
+		t.Fatalf("当前版本号保存失败: %v", err)
+	}
+	if _, err := save("a=5\n", read.Version, true); err != nil {
+		t.Fatalf("强制保存失败: %v", err)
+	}
+
+	// 带版本号保存已被删除的文件同样是冲突
+	os.Remove(path)
+	if _, err := save("a=6\n", read.Version, false); !errors.Is(err, ErrFileVersionConflict) {
+		t.Fatalf("文件已删除时返回 %v，期望 ErrFileVersionConflict", err)
+	}
+}
+
+// mustSocket 在 path 创建一个 Unix 套接字文件，作为无法复制的特殊文件
+func mustSocket(t *testing.T, path string) {
+	t.Helper()
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("无法创建 Unix 套接字: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+}
+
+// fullDisk 返回剩余空间始终为0的磁盘检查器
+func fullDisk() *DiskGuard {
+	return NewDiskGuard(1, func(string) (uint64, error) { return 0, nil })
+}
+
+func TestCopyFileOverwriteKeepsDestinationOnFailure(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	dst := filepath.Join(root, "dst")
+	mustMkdir(t, dst)
+	if err := os.WriteFile(filepath.Join(dst, "keep.txt"), []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(root, "src")
+	mustMkdir(t, src)
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	copyTo := func() error {
+		return f.CopyFile(src, dst, true, admin.ID, "127.0.0.1", "test")
+	}
+	assertKept := func(step string) {
+		t.Helper()
+		if data, err := os.ReadFile(filepath.Join(dst, "keep.txt")); err != nil || string(data) != "keep" {
+			t.Fatalf("%s后原目标被破坏: %q, %v", step, data, err)
+		}
+		entries, _ := os.ReadDir(root)
+		if len(entries) != 2 {
+			t.Fatalf("%s后根目录有 %d 个条目，期望临时文件已清理", step, len(entries))
+		}
+	}
+
+	// 磁盘空间不足时在动原目标之前拒绝
+	guard := f.diskGuard
+	f.diskGuard = fullDisk()
+	if err := copyTo(); !errors.Is(err, ErrInsufficientDiskSpace) {
+		t.Fatalf("磁盘空间不足时返回 %v，期望 ErrInsufficientDiskSpace", err)
+	}
+	assertKept("磁盘空间不足")
+	f.diskGuard = guard
+
+	// 复制中途失败时原目标保持不变
+	mustSocket(t, filepath.Join(src, "s.sock"))
+	if err := copyTo(); err == nil {
+		t.Fatal("源目录包含特殊文件时期望复制失败")
+	}
+	assertKept("复制失败")
+
+	// 成功覆盖后目标只有新内容
+	os.Remove(filepath.Join(src, "s.sock"))
+	if err := copyTo(); err != nil {
+		t.Fatalf("覆盖复制失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "keep.txt")); !os.IsNotExist(err) {
+		t.Fatal("覆盖后仍保留了原目标的内容")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a.txt")); string(data) != "a" {
+		t.Fatalf("覆盖后 a.txt = %q", data)
+	}
+}
+
+func TestMoveFileOverwrite(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	mustMkdir(t, src)
+	mustMkdir(t, dst)
+	os.WriteFile(filepath.Join(src, "new.txt"), []byte("new"), 0644)
+	os.WriteFile(filepath.Join(dst, "old.txt"), []byte("old"), 0644)
+
+	if err := f.MoveFile(src, dst, false, admin.ID, "127.0.0.1", "test"); err == nil {
+		t.Fatal("未指定覆盖时移动到已存在的目标期望失败")
+	}
+
+	// 非空目录同样可以被整体替换
+	if err := f.MoveFile(src, dst, true, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("覆盖移动失败: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "new.txt")); string(data) != "new" {
+		t.Fatalf("覆盖后 new.txt = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "old.txt")); !os.IsNotExist(err) {
+		t.Fatal("覆盖后仍保留了原目标的内容")
+	}
+	// 源已移走，备份目录已清理
+	if entries, _ := os.ReadDir(root); len(entries) != 1 {
+		t.Fatalf("移动后根目录有 %d 个条目，期望只剩 dst", len(entries))
+	}
+}
+
+func TestReplacePathRestoresDestinationOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "dst.txt")
+	os.WriteFile(dst, []byte("keep"), 0644)
+
+	// 源不存在时移动失败，原目标被恢复
+	if err := replacePath(filepath.Join(dir, "missing"), dst); err == nil {
+		t.Fatal("源不存在时期望失败")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "keep" {
+		t.Fatalf("替换失败后目标 = %q，期望恢复原内容", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("替换失败后目录有 %d 个条目，期望备份已清理", len(entries))
+	}
+}
+
+func TestGetFileLinesBoundsLongLines(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	path := filepath.Join(root, "big.log")
+	long := strings.Repeat("x", maxWindowLineLength*3+5)
+	content := "first\r\n" + long + "\nthird\n" + long + "\nlast"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := f.GetFileLines(path, 1, 3, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("读取失败: %v", err)
+	}
+	if resp.TotalLines != 5 || resp.LineCount != 3 {
+		t.Fatalf("总行数 = %d, 返回行数 = %d", resp.TotalLines, resp.LineCount)
+	}
+	if len(resp.Lines[0]) != maxWindowLineLength || resp.Lines[1] != "third" || len(resp.Lines[2]) != maxWindowLineLength {
+		t.Fatalf("超长行应截断到 %d 字节: %d, %q, %d", maxWindowLineLength, len(resp.Lines[0]), resp.Lines[1], len(resp.Lines[2]))
+	}
+
+	resp, err = f.GetFileLines(path, 0, 1, admin.ID, "127.0.0.1", "test")
+	if err != nil || len(resp.Lines) != 1 || resp.Lines[0] != "first" {
+		t.Fatalf("第一行 = %v, %v", resp, err)
+	}
+}
+
+func TestListFilesPermissionForms(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不支持 Unix 权限位")
+	}
+	f, root, _ := newTestFileService(t)
+	tests := []struct {
+		name     string
+		dir      bool
+		mode     os.FileMode
+		symbolic string
+		octal    string
+	}{
+		{"private.txt", false, 0640, "-rw-r-----", "0640"},
+		{"script.sh", false, 0755, "-rwxr-xr-x", "0755"},
+		{"setuid", false, 0755 | os.ModeSetuid, "urwxr-xr-x", "4755"},
+		{"public", true, 0755, "drwxr-xr-x", "0755"},
+		{"shared", true, 0777 | os.ModeSetgid | os.ModeSticky, "dgtrwxrwxrwx", "3777"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(root, tt.name)
+		if tt.dir {
+			mustMkdir(t, path)
+		} else if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		// Chmod 不受 umask 影响
+		if err := os.Chmod(path, tt.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, _, _, err := f.ListFiles(root, 1, 0, ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]model.FileInfo, len(files))
+	for _, file := range files {
+		byName[file.Name] = file
+	}
+	for _, tt := range tests {
+		got, ok := byName[tt.name]
+		if !ok {
+			t.Fatalf("列表中缺少 %s", tt.name)
+		}
+		if got.Permissions != tt.symbolic || got.Octal != tt.octal {
+			t.Errorf("%s 权限 = %q / %q, 期望 %q / %q", tt.name, got.Permissions, got.Octal, tt.symbolic, tt.octal)
+		}
+	}
+
+	if got := formatFileMode(nil); got != "-" {
+		t.Fatalf("formatFileMode(nil) = %q", got)
+	}
+}
+
+func TestListFilesAllEntries(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	for i := 0; i < 5; i++ {
+		if err := os.WriteFile(filepath.Join(root, fmt.Sprintf("file%d.txt", i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := ListOptions{SortBy: "name", Order: "asc"}
+
+	// 上限内一次返回全部条目
+	f.config.File.ListAllMaxEntries = 10
+	files, total, truncated, err := f.ListFiles(root, 1, 0, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 5 || total != 5 || truncated {
+		t.Fatalf("不分页返回 %d/%d 条，truncated=%v，期望全部 5 条", len(files), total, truncated)
+	}
+
+	// 超过上限时退回分页，只返回第一页并标记截断
+	f.config.File.ListAllMaxEntries = 3
+	files, total, truncated, err = f.ListFiles(root, 1, 0, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || total != 5 || !truncated || files[0].Name != "file0.txt" || files[2].Name != "file2.txt" {
+		t.Fatalf("超过上限时返回 %d/%d 条，truncated=%v，期望第一页 3 条", len(files), total, truncated)
+	}
+
+	// 上限为0表示不限制
+	f.config.File.ListAllMaxEntries = 0
+	if files, _, truncated, _ := f.ListFiles(root, 1, 0, opts); len(files) != 5 || truncated {
+		t.Fatalf("不限制时返回 %d 条，truncated=%v", len(files), truncated)
+	}
+}
+
+func TestListFilesCapsLargeDirectories(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	const count = 2500
+	for i := 0; i < count; i++ {
+		if err := os.WriteFile(filepath.Join(root, fmt.Sprintf("f%05d", i)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := ListOptions{SortBy: "name", Order: "asc"}
+
+	// 上限跨越批量读取的边界，只处理上限内的条目并标记截断
+	f.config.File.MaxListEntries = 1500
+	files, total, truncated, err := f.ListFiles(root, 1, 100, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1500 || len(files) != 100 || !truncated {
+		t.Fatalf("上限 1500 时 total=%d len=%d truncated=%v", total, len(files), truncated)
+	}
+
+	// 条目数恰好等于上限时不算截断
+	f.config.File.MaxListEntries = count
+	if _, total, truncated, _ = f.ListFiles(root, 1, 100, opts); total != count || truncated {
+		t.Fatalf("上限等于条目数时 total=%d truncated=%v", total, truncated)
+	}
+
+	f.config.File.MaxListEntries = 0
+	if _, total, truncated, _ = f.ListFiles(root, 1, 100, opts); total != count || truncated {
+		t.Fatalf("不限制时 total=%d truncated=%v", total, truncated)
+	}
+
+	entries, truncated, err := readDirLimited(root, 10)
+	if err != nil || len(entries) != 10 || !truncated {
+		t.Fatalf("readDirLimited 返回 %d 条，truncated=%v, %v", len(entries), truncated, err)
+	}
+}
+
+func TestListFilesMimeTypes(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	files := map[string][]byte{
+		"data.json":  []byte(`{"a":1}`),
+		"photo.PNG":  png,
+		"index.html": []byte("<html></html>"),
+		"notes.txt":  png, // 有扩展名时不读取内容
+		"image":      png,
+		"empty":      nil,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustMkdir(t, filepath.Join(root, "docs"))
+
+	mimeTypes := func(sniff bool) map[string]string {
+		list, _, _, err := f.ListFiles(root, 1, 0, ListOptions{SniffMime: sniff})
+		if err != nil {
+			t.Fatal(err)
+		}
+		types := make(map[string]string, len(list))
+		for _, file := range list {
+			types[file.Name] = file.MimeType
+		}
+		return types
+	}
+
+	// 默认只按扩展名推断，不打开文件
+	types := mimeTypes(false)
+	want := map[string]string{
+		"data.json":  "application/json",
+		"photo.PNG":  "image/png",
+		"index.html": "text/html",
+		"notes.txt":  "text/plain",
+		"image":      "",
+		"empty":      "",
+		"docs":       "inode/directory",
+	}
+	for name, prefix := range want {
+		if got := types[name]; (prefix == "" && got != "") || !strings.HasPrefix(got, prefix) {
+			t.Errorf("%s 的MIME类型 = %q，期望 %q", name, got, prefix)
+		}
+	}
+
+	// 请求探测时只探测无扩展名的文件
+	types = mimeTypes(true)
+	if types["image"] != "image/png" {
+		t.Errorf("探测得到 image 的MIME类型 = %q，期望 image/png", types["image"])
+	}
+	if !strings.HasPrefix(types["notes.txt"], "text/plain") {
+		t.Errorf("有扩展名的文件不应探测内容: %q", types["notes.txt"])
+	}
+	if types["empty"] != "" {
+		t.Errorf("空文件无法探测，实际 %q", types["empty"])
+	}
+}
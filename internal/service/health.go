@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// readinessTimeout 就绪检查中数据库探测的超时时间
+const readinessTimeout = 2 * time.Second
+
+// HealthService 健康检查服务
+type HealthService struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewHealthService 创建健康检查服务实例
+func NewHealthService(db *gorm.DB, cfg *config.Config) *HealthService {
+	return &HealthService{db: db, config: cfg}
+}
+
+// CheckReadiness 检查数据库连接和数据/日志目录，任一项失败即未就绪
+// 就绪检查无需认证，结果只包含各项状态，失败原因（可能含路径等内部信息）只写入日志
+func (s *HealthService) CheckReadiness() *model.ReadinessReport {
+	report := &model.ReadinessReport{
+		Ready:     true,
+		Checks:    make(map[string]string),
+		Timestamp: time.Now().Unix(),
+	}
+
+	record := func(name string, err error) {
+		if err != nil {
+			report.Ready = false
+			report.Checks[name] = "failed"
+			logger.Warn("就绪检查失败", "check", name, "error", err)
+			return
+		}
+		report.Checks[name] = "ok"
+	}
+
+	record("database", s.pingDatabase())
+	record("data_dir", checkDirectory(s.config.System.DataDir))
+	record("log_dir", checkDirectory(s.config.System.LogDir))
+
+	return report
+}
+
+// pingDatabase 探测数据库连接
+func (s *HealthService) pingDatabase() error {
+	if s.db == nil {
+		return fmt.Errorf("数据库连接未初始化")
+	}
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("数据库不可用: %w", err)
+	}
+	return nil
+}
+
+// checkDirectory 检查目录存在
+func checkDirectory(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("目录不可用: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("不是目录: %s", path)
+	}
+	return nil
+}
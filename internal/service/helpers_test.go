@@ -0,0 +1,102 @@
+package service
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// testAdminPassword 测试数据库中默认管理员的密码
+const testAdminPassword = "Admin@12345"
+
+func TestMain(m *testing.M) {
+	// 服务层直接调用全局日志器，测试中丢弃输出
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestConfig 返回默认配置，文件根目录和数据目录指向测试临时目录
+func newTestConfig(t testing.TB) *config.Config {
+	t.Helper()
+	cfg, err := config.Defaults()
+	if err != nil {
+		t.Fatalf("加载默认配置失败: %v", err)
+	}
+	dir := t.TempDir()
+	cfg.System.FileRootDir = dir
+	cfg.System.DataDir = t.TempDir()
+	cfg.File.ThumbnailCacheDir = t.TempDir()
+	return cfg
+}
+
+// newTestDB 创建已迁移并写入默认权限、角色和管理员的内存数据库
+func newTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := database.OpenInMemory(config.SeedConfig{
+		Enabled:       true,
+		AdminUsername: "admin",
+		AdminPassword: testAdminPassword,
+	})
+	if err != nil {
+		t.Fatalf("创建内存数据库失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newTestFileDB 创建基于临时文件的数据库，连接池中的多个连接并发写入时会产生真实的锁冲突
+func newTestFileDB(t *testing.T, cfg *config.Config) *gorm.DB {
+	t.Helper()
+	dbCfg := cfg.Database
+	dbCfg.Path = filepath.Join(t.TempDir(), "test.sqlite")
+	dbCfg.Seed = config.SeedConfig{Enabled: true, AdminUsername: "admin", AdminPassword: testAdminPassword}
+	db, err := database.Init(dbCfg)
+	if err != nil {
+		t.Fatalf("创建数据库失败: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return db
+}
+
+// testAdmin 返回种子数据中的默认管理员
+func testAdmin(t *testing.T, db *gorm.DB) *model.User {
+	t.Helper()
+	var admin model.User
+	if err := db.Preload("Roles").Where("username = ?", "admin").First(&admin).Error; err != nil {
+		t.Fatalf("查询默认管理员失败: %v", err)
+	}
+	return &admin
+}
+
+// testRole 按名称返回种子数据中的角色
+func testRole(t *testing.T, db *gorm.DB, name string) *model.Role {
+	t.Helper()
+	var role model.Role
+	if err := db.Where("name = ?", name).First(&role).Error; err != nil {
+		t.Fatalf("查询角色 %s 失败: %v", name, err)
+	}
+	return &role
+}
+
+// newTestFileService 创建以临时目录为文件根目录的文件服务，返回服务、根目录和默认管理员
+func newTestFileService(t *testing.T) (*FileService, string, *model.User) {
+	t.Helper()
+	db := newTestDB(t)
+	cfg := newTestConfig(t)
+	f := NewFileService(db, cfg, NewSettingService(db))
+	return f, f.rootDir, testAdmin(t, db)
+}
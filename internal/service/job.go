@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/scheduler"
+
+	"gorm.io/gorm"
+)
+
+// JobService 后台任务服务
+// 持有调度器，任务连续失败达到阈值时通知管理员并记录审计日志
+type JobService struct {
+	db        *gorm.DB
+	scheduler *scheduler.Scheduler
+	notifier  AdminNotifier
+}
+
+// NewJobService 创建后台任务服务实例
+func NewJobService(db *gorm.DB, cfg *config.Config) *JobService {
+	s := &JobService{
+		db:        db,
+		scheduler: scheduler.New(),
+	}
+	s.scheduler.SetFailureHandler(cfg.Monitoring.JobFailureThreshold, s.reportFailure)
+	return s
+}
+
+// SetNotifier 设置管理员通知器
+func (s *JobService) SetNotifier(notifier AdminNotifier) {
+	s.notifier = notifier
+}
+
+// Scheduler 获取调度器，用于注册、启动和停止任务
+func (s *JobService) Scheduler() *scheduler.Scheduler {
+	return s.scheduler
+}
+
+// GetJobs 获取所有后台任务的状态
+func (s *JobService) GetJobs() []model.JobStatus {
+	return s.scheduler.Jobs()
+}
+
+// reportFailure 通知管理员并记录审计日志
+func (s *JobService) reportFailure(name string, failures int, err error) {
+	details := fmt.Sprintf("后台任务 %s 连续失败 %d 次: %v", name, failures, err)
+
+	auditLog := &model.AuditLog{
+		Action:   "job_failed",
+		Resource: "system",
+		Details:  details,
+		Status:   "failed",
+	}
+	if err := s.db.Create(auditLog).Error; err != nil {
+		logger.Error("记录审计日志失败", "error", err)
+	}
+
+	if s.notifier != nil {
+		s.notifier.NotifyAdmins("后台任务失败", details, "error")
+	}
+}
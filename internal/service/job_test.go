@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/scheduler"
+)
+
+// recordingAdminNotifier 记录发给管理员的通知
+type recordingAdminNotifier struct {
+	mutex    sync.Mutex
+	contents []string
+}
+
+func (n *recordingAdminNotifier) NotifyAdmins(title, content, level string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.contents = append(n.contents, content)
+}
+
+func (n *recordingAdminNotifier) count() int {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return len(n.contents)
+}
+
+func TestJobServiceRecordsFailuresAndAlertsOnce(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Monitoring.JobFailureThreshold = 2
+	db := newTestDB(t)
+	s := NewJobService(db, cfg)
+	notifier := &recordingAdminNotifier{}
+	s.SetNotifier(notifier)
+
+	if err := s.Scheduler().Register(scheduler.Job{Name: "healthy", Interval: time.Hour, RunOnStart: true, Run: func(context.Context) error { return nil }}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Scheduler().Register(scheduler.Job{
+		Name:       "backup",
+		Interval:   5 * time.Millisecond,
+		RunOnStart: true,
+		Run:        func(context.Context) error { return errors.New("磁盘已满") },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.Scheduler().Start(context.Background())
+
+	var jobs []model.JobStatus
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		jobs = s.GetJobs()
+		if len(jobs) == 2 && jobs[0].ConsecutiveFailures >= 4 && jobs[1].RunCount > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("任务状态未更新: %+v", jobs)
+		}
+	}
+	s.Scheduler().Stop()
+	jobs = s.GetJobs()
+
+	// 按名称排序，失败任务的状态可以查询到
+	failed, healthy := jobs[0], jobs[1]
+	if failed.Name != "backup" || failed.Status != model.JobStatusFailed || failed.LastError != "磁盘已满" || failed.LastRun == nil {
+		t.Fatalf("失败任务状态 = %+v", failed)
+	}
+	if failed.FailureCount != failed.RunCount {
+		t.Fatalf("失败次数 %d，运行次数 %d", failed.FailureCount, failed.RunCount)
+	}
+	if healthy.Name != "healthy" || healthy.Status != model.JobStatusSuccess || healthy.ConsecutiveFailures != 0 {
+		t.Fatalf("正常任务状态 = %+v", healthy)
+	}
+
+	// 连续失败达到阈值时只通知一次并记录审计日志
+	if got := notifier.count(); got != 1 {
+		t.Fatalf("管理员收到 %d 条通知，期望 1 条", got)
+	}
+	if !strings.Contains(notifier.contents[0], "backup 连续失败 2 次") {
+		t.Fatalf("通知内容 = %q", notifier.contents[0])
+	}
+	var entries []model.AuditLog
+	db.Where("action = ?", "job_failed").Find(&entries)
+	if len(entries) != 1 || !strings.Contains(entries[0].Details, "磁盘已满") {
+		t.Fatalf("任务失败审计日志 = %+v", entries)
+	}
+}
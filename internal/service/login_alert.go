@@ -0,0 +1,79 @@
+package service
+
+import (
+	"time"
+
+	"web-panel-go/internal/shardmap"
+)
+
+const (
+	// maxTrackedIPsPerShard 每个分片最多跟踪的IP数，来自大量不同IP的失败登录不会耗尽内存
+	maxTrackedIPsPerShard = 1024
+	// maxIPFailureRecords 单个IP最多保留的失败时间记录，超出时只保留最近的记录
+	maxIPFailureRecords = 1000
+)
+
+// loginAlertTracker 登录失败告警跟踪器（内存）
+// 按IP在时间窗口内统计失败次数，并对同一告警目标做去抖，避免告警刷屏
+// 记录按键分片加锁，过期记录由后台清理，跟踪的IP数和每个IP的记录数都有上限
+type loginAlertTracker struct {
+	ipFailures *shardmap.Map[string, []time.Time]
+	lastAlert  *shardmap.Map[string, time.Time]
+	window     time.Duration
+	cooldown   time.Duration
+}
+
+// newLoginAlertTracker 创建登录失败告警跟踪器
+func newLoginAlertTracker(window, cooldown time.Duration) *loginAlertTracker {
+	t := &loginAlertTracker{
+		ipFailures: shardmap.NewBounded[string, []time.Time](shardmap.DefaultShards, maxTrackedIPsPerShard),
+		lastAlert:  shardmap.NewBounded[string, time.Time](shardmap.DefaultShards, maxTrackedIPsPerShard),
+		window:     window,
+		cooldown:   cooldown,
+	}
+
+	// 清理窗口内没有失败的IP和已过冷却期的告警记录
+	t.ipFailures.StartSweeper(window, func(_ string, failures []time.Time) bool {
+		return len(failures) == 0 || time.Since(failures[len(failures)-1]) >= t.window
+	})
+	t.lastAlert.StartSweeper(cooldown, func(_ string, last time.Time) bool {
+		return time.Since(last) >= t.cooldown
+	})
+	return t
+}
+
+// recordIPFailure 记录一次IP登录失败，返回时间窗口内的失败次数
+func (t *loginAlertTracker) recordIPFailure(ip string, now time.Time) int {
+	cutoff := now.Add(-t.window)
+	failures := t.ipFailures.Update(ip, func(previous []time.Time, _ bool) ([]time.Time, bool) {
+		failures := previous[:0]
+		for _, at := range previous {
+			if at.After(cutoff) {
+				failures = append(failures, at)
+			}
+		}
+		if len(failures) >= maxIPFailureRecords {
+			failures = append(failures[:0], failures[len(failures)-maxIPFailureRecords+1:]...)
+		}
+		return append(failures, now), true
+	})
+	return len(failures)
+}
+
+// resetIP 清除IP的失败记录（登录成功时调用）
+func (t *loginAlertTracker) resetIP(ip string) {
+	t.ipFailures.Delete(ip)
+}
+
+// allow 判断告警目标是否已过冷却期，允许时记录本次告警时间
+func (t *loginAlertTracker) allow(key string, now time.Time) bool {
+	allowed := false
+	t.lastAlert.Update(key, func(last time.Time, ok bool) (time.Time, bool) {
+		if ok && now.Sub(last) < t.cooldown {
+			return last, true
+		}
+		allowed = true
+		return now, true
+	})
+	return allowed
+}
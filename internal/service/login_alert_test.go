@@ -0,0 +1,116 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/model"
+	"web-panel-go/internal/shardmap"
+)
+
+func TestLoginAlertTrackerCountsWithinWindow(t *testing.T) {
+	tracker := newLoginAlertTracker(time.Minute, time.Minute)
+	now := time.Now()
+
+	for i := 1; i <= 3; i++ {
+		if got := tracker.recordIPFailure("10.0.0.1", now); got != i {
+			t.Fatalf("第 %d 次失败计数 = %d", i, got)
+		}
+	}
+	// 窗口外的失败不再计数
+	if got := tracker.recordIPFailure("10.0.0.1", now.Add(2*time.Minute)); got != 1 {
+		t.Fatalf("窗口过后计数 = %d, 期望 1", got)
+	}
+
+	tracker.resetIP("10.0.0.1")
+	if got := tracker.recordIPFailure("10.0.0.1", now); got != 1 {
+		t.Fatalf("重置后计数 = %d, 期望 1", got)
+	}
+}
+
+func TestLoginAlertTrackerIsBounded(t *testing.T) {
+	tracker := newLoginAlertTracker(time.Hour, time.Hour)
+	now := time.Now()
+
+	// 同一IP的记录数有上限
+	for i := 0; i < maxIPFailureRecords+10; i++ {
+		tracker.recordIPFailure("10.0.0.1", now)
+	}
+	if got := tracker.recordIPFailure("10.0.0.1", now); got != maxIPFailureRecords {
+		t.Fatalf("单个IP的失败记录数 = %d, 期望上限 %d", got, maxIPFailureRecords)
+	}
+
+	// 跟踪的IP数有上限
+	limit := shardmap.DefaultShards * maxTrackedIPsPerShard
+	for i := 0; i < limit*2; i++ {
+		tracker.recordIPFailure("ip-"+strconv.Itoa(i), now)
+		tracker.allow("ip:"+strconv.Itoa(i), now)
+	}
+	if n := tracker.ipFailures.Len(); n > limit {
+		t.Fatalf("跟踪的IP数 %d 超过上限 %d", n, limit)
+	}
+	if n := tracker.lastAlert.Len(); n > limit {
+		t.Fatalf("告警记录数 %d 超过上限 %d", n, limit)
+	}
+}
+
+func TestLoginAlertTrackerDebounces(t *testing.T) {
+	tracker := newLoginAlertTracker(time.Minute, time.Minute)
+	now := time.Now()
+
+	if !tracker.allow("ip:10.0.0.1", now) {
+		t.Fatal("首次告警应允许")
+	}
+	if tracker.allow("ip:10.0.0.1", now.Add(30*time.Second)) {
+		t.Fatal("冷却期内不应重复告警")
+	}
+	if !tracker.allow("ip:10.0.0.1", now.Add(time.Minute)) {
+		t.Fatal("冷却期过后应允许告警")
+	}
+}
+
+// recordingAlerter 记录推送的安全告警
+type recordingAlerter struct {
+	alerts []*model.SecurityAlert
+}
+
+func (a *recordingAlerter) SendSecurityAlert(alert *model.SecurityAlert) {
+	a.alerts = append(a.alerts, alert)
+}
+
+func TestLoginFailuresRaiseAlertAtThreshold(t *testing.T) {
+	s := newTestAuthService(t)
+	s.config.Auth.AlertThreshold = 3
+	s.config.Auth.MaxFailedAttempts = 0
+	alerter := &recordingAlerter{}
+	s.SetSecurityAlerter(alerter)
+
+	fail := func() {
+		if _, err := s.Login(&model.LoginRequest{Username: "ghost", Password: "wrong"}, "10.0.0.9", "test"); err == nil {
+			t.Fatal("错误的凭据登录成功")
+		}
+	}
+	fail()
+	fail()
+	if len(alerter.alerts) != 0 {
+		t.Fatalf("未达到阈值就推送了告警: %+v", alerter.alerts)
+	}
+
+	fail()
+	if len(alerter.alerts) != 1 || alerter.alerts[0].Type != model.SecurityAlertIPFailures || alerter.alerts[0].Failures != 3 {
+		t.Fatalf("达到阈值后告警不正确: %+v", alerter.alerts)
+	}
+
+	// 冷却期内不重复告警
+	fail()
+	if len(alerter.alerts) != 1 {
+		t.Fatalf("冷却期内重复告警: %d 条", len(alerter.alerts))
+	}
+
+	var logs int64
+	s.db.Model(&model.AuditLog{}).Where("action = ?", "security_alert").Count(&logs)
+	if logs != 1 {
+		t.Fatalf("security_alert 审计日志 %d 条，期望 1 条", logs)
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"web-panel-go/internal/config"
+)
+
+// smtpTimeout 连接SMTP服务器并发送一封邮件的总时长上限
+const smtpTimeout = 30 * time.Second
+
+// ErrInvalidMailAddress 邮件地址格式错误
+var ErrInvalidMailAddress = errors.New("邮件地址格式错误")
+
+// SMTPMailer 通过SMTP服务器发送纯文本邮件，服务器支持时使用STARTTLS
+type SMTPMailer struct {
+	config  config.MailConfig
+	timeout time.Duration
+}
+
+// NewSMTPMailer 创建SMTP邮件发送器
+func NewSMTPMailer(cfg config.MailConfig) *SMTPMailer {
+	return &SMTPMailer{config: cfg, timeout: smtpTimeout}
+}
+
+// SendMail 发送一封纯文本邮件
+func (m *SMTPMailer) SendMail(to, subject, body string) error {
+	from, err := mail.ParseAddress(m.config.From)
+	if err != nil {
+		return fmt.Errorf("%w: 发件人 %q", ErrInvalidMailAddress, m.config.From)
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("%w: 收件人 %q", ErrInvalidMailAddress, to)
+	}
+
+	addr := net.JoinHostPort(m.config.SMTPHost, strconv.Itoa(m.config.SMTPPort))
+	conn, err := net.DialTimeout("tcp", addr, m.timeout)
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(m.timeout)); err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.config.SMTPHost)
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.SMTPHost}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS 失败: %w", err)
+		}
+	}
+	if m.config.SMTPUser != "" {
+		auth := smtp.PlainAuth("", m.config.SMTPUser, m.config.SMTPPassword, m.config.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP认证失败: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if err := client.Rcpt(rcpt.Address); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if _, err := w.Write(buildMailMessage(from, rcpt, subject, body, time.Now())); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMailMessage 组装邮件内容，主题按RFC 2047编码，正文使用base64编码的UTF-8纯文本
+func buildMailMessage(from, to *mail.Address, subject, body string, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
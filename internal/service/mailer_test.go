@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/config"
+)
+
+// smtpTranscript 测试SMTP服务器收到的一封邮件
+type smtpTranscript struct {
+	from string
+	rcpt []string
+	data string
+}
+
+// startTestSMTPServer 启动只接收一封邮件的SMTP服务器（不支持STARTTLS和AUTH）
+func startTestSMTPServer(t *testing.T) (host string, port int, received <-chan smtpTranscript) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan smtpTranscript, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		var msg smtpTranscript
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+			switch upper := strings.ToUpper(cmd); {
+			case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(upper, "MAIL FROM:"):
+				msg.from = cmd[len("MAIL FROM:"):]
+				reply("250 OK")
+			case strings.HasPrefix(upper, "RCPT TO:"):
+				msg.rcpt = append(msg.rcpt, cmd[len("RCPT TO:"):])
+				reply("250 OK")
+			case upper == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				msg.data = data.String()
+				reply("250 OK")
+			case upper == "QUIT":
+				reply("221 bye")
+				ch <- msg
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+func TestSMTPMailerSendsEncodedMessage(t *testing.T) {
+	host, port, received := startTestSMTPServer(t)
+	m := NewSMTPMailer(config.MailConfig{
+		Enabled:  true,
+		SMTPHost: host,
+		SMTPPort: port,
+		From:     "Web Panel <panel@example.com>",
+	})
+
+	body := "alice，您好：\n\n您的账户密码已被管理员重置。\n"
+	if err := m.SendMail("alice@example.com", "密码已重置", body); err != nil {
+		t.Fatalf("发送邮件失败: %v", err)
+	}
+	got := <-received
+	if got.from != "<panel@example.com>" || len(got.rcpt) != 1 || got.rcpt[0] != "<alice@example.com>" {
+		t.Fatalf("信封 from=%s rcpt=%v", got.from, got.rcpt)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("解析邮件失败: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "密码已重置" {
+		t.Fatalf("主题 = %q, %v", subject, err)
+	}
+	if to := msg.Header.Get("To"); to != "<alice@example.com>" {
+		t.Fatalf("To = %q", to)
+	}
+	if ct := msg.Header.Get("Content-Type"); ct != "text/plain; charset=UTF-8" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, msg.Body))
+	if err != nil || string(decoded) != body {
+		t.Fatalf("正文 = %q, %v", decoded, err)
+	}
+}
+
+func TestSMTPMailerRejectsInvalidAddresses(t *testing.T) {
+	m := NewSMTPMailer(config.MailConfig{SMTPHost: "127.0.0.1", SMTPPort: 1, From: "panel@example.com"})
+	for _, to := range []string{"", "not-an-address", "alice@example.com\r\nBcc: mallory@example.com"} {
+		if err := m.SendMail(to, "subject", "body"); !errors.Is(err, ErrInvalidMailAddress) {
+			t.Fatalf("收件人 %q 返回 %v，期望 ErrInvalidMailAddress", to, err)
+		}
+	}
+
+	m.config.From = ""
+	if err := m.SendMail("alice@example.com", "subject", "body"); !errors.Is(err, ErrInvalidMailAddress) {
+		t.Fatalf("发件人为空返回 %v，期望 ErrInvalidMailAddress", err)
+	}
+}
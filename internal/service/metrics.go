@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultMetricsRange 未指定起始时间时查询的时间范围
+	defaultMetricsRange = 24 * time.Hour
+	// defaultMetricPoints 未指定分辨率时按该数据点数自动选择时间桶大小
+	defaultMetricPoints = 300
+	// maxMetricPoints 单次查询最多返回的数据点数，分辨率过细时自动放大时间桶
+	maxMetricPoints = 1000
+)
+
+// ErrInvalidMetricsRange 查询的时间范围无效
+var ErrInvalidMetricsRange = errors.New("无效的时间范围：from 必须早于 to")
+
+// MetricsService 系统指标历史服务
+// 按固定间隔保存系统统计采样，查询时按时间桶取平均值降采样，并定期清除过期采样
+type MetricsService struct {
+	db     *gorm.DB
+	config *config.Config
+
+	mutex      sync.Mutex
+	lastSample time.Time
+}
+
+// NewMetricsService 创建系统指标历史服务实例
+func NewMetricsService(db *gorm.DB, cfg *config.Config) *MetricsService {
+	return &MetricsService{
+		db:     db,
+		config: cfg,
+	}
+}
+
+// Record 保存一次系统统计采样，距上次保存不足 monitoring.metrics_sample_interval 时跳过
+func (s *MetricsService) Record(stats *model.SystemStats) error {
+	interval := s.config.Monitoring.MetricsSampleInterval
+	if interval <= 0 || stats == nil {
+		return nil
+	}
+
+	// 系统统计按广播间隔采集，留出半个广播间隔的余量，避免采集时间的抖动导致跳过一整个周期
+	now := time.Now()
+	s.mutex.Lock()
+	if !s.lastSample.IsZero() && now.Sub(s.lastSample) < interval-s.config.Monitoring.BroadcastInterval/2 {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.lastSample = now
+	s.mutex.Unlock()
+
+	sample := &model.MetricSample{
+		CPUPercent:    stats.CPU.UsagePercent,
+		MemoryPercent: stats.Memory.UsedPercent,
+		DiskPercent:   stats.Disk.UsedPercent,
+		Load1:         stats.Load.Load1,
+		Load5:         stats.Load.Load5,
+		Load15:        stats.Load.Load15,
+		CreatedAt:     now,
+	}
+	if err := s.db.Create(sample).Error; err != nil {
+		return fmt.Errorf("保存指标采样失败: %w", err)
+	}
+	return nil
+}
+
+// History 查询 [from, to) 内的指标历史，按 resolution 大小的时间桶取平均值
+// from、to 为零值时分别默认为 to 之前24小时和当前时间；resolution<=0 时自动选择，
+// 分辨率不会小于采样间隔，数据点过多时自动放大时间桶
+func (s *MetricsService) History(from, to time.Time, resolution time.Duration) (*model.MetricHistory, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultMetricsRange)
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidMetricsRange
+	}
+
+	span := to.Sub(from)
+	if resolution <= 0 {
+		resolution = span / defaultMetricPoints
+	}
+	if interval := s.config.Monitoring.MetricsSampleInterval; resolution < interval {
+		resolution = interval
+	}
+	if floor := span / maxMetricPoints; resolution < floor {
+		resolution = floor
+	}
+	// 时间桶向上取整到秒
+	if rem := resolution % time.Second; rem != 0 || resolution <= 0 {
+		resolution += time.Second - rem
+	}
+
+	var samples []model.MetricSample
+	if err := s.db.Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at ASC").
+		Find(&samples).Error; err != nil {
+		return nil, fmt.Errorf("查询指标历史失败: %w", err)
+	}
+
+	points := make([]model.MetricPoint, 0)
+	var current *model.MetricPoint
+	for _, sample := range samples {
+		bucket := from.Add(sample.CreatedAt.Sub(from) / resolution * resolution)
+		if current == nil || !current.Time.Equal(bucket) {
+			points = append(points, model.MetricPoint{Time: bucket})
+			current = &points[len(points)-1]
+		}
+		current.CPUPercent += sample.CPUPercent
+		current.MemoryPercent += sample.MemoryPercent
+		current.DiskPercent += sample.DiskPercent
+		current.Load1 += sample.Load1
+		current.Load5 += sample.Load5
+		current.Load15 += sample.Load15
+		current.Samples++
+	}
+	for i := range points {
+		n := float64(points[i].Samples)
+		points[i].CPUPercent /= n
+		points[i].MemoryPercent /= n
+		points[i].DiskPercent /= n
+		points[i].Load1 /= n
+		points[i].Load5 /= n
+		points[i].Load15 /= n
+	}
+
+	return &model.MetricHistory{
+		From:       from,
+		To:         to,
+		Resolution: resolution.String(),
+		Points:     points,
+	}, nil
+}
+
+// PurgeExpired 清除超过 monitoring.metrics_retention 的采样，由后台任务定期调用
+func (s *MetricsService) PurgeExpired() error {
+	retention := s.config.Monitoring.MetricsRetention
+	if retention <= 0 {
+		return nil
+	}
+
+	result := s.db.Where("created_at < ?", time.Now().Add(-retention)).Delete(&model.MetricSample{})
+	if result.Error != nil {
+		return fmt.Errorf("清除过期指标采样失败: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("已清除过期指标采样", "count", result.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,6 @@
+//go:build windows
+
+package service
+
+// openNoFollow Windows 下没有 O_NOFOLLOW，依赖写入前的路径校验
+const openNoFollow = 0
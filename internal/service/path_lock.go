@@ -0,0 +1,50 @@
+package service
+
+import (
+	"path/filepath"
+	"sync"
+
+	"web-panel-go/internal/shardmap"
+)
+
+// pathLock 单个路径的锁，refs 为正在持有或等待该锁的调用数
+type pathLock struct {
+	mutex sync.Mutex
+	refs  int
+}
+
+// PathLocker 按文件路径加锁，同一文件的操作串行执行，不同文件互不阻塞
+// 没有调用方持有或等待时锁会被移除，不会随访问过的路径数增长
+type PathLocker struct {
+	locks *shardmap.Map[string, *pathLock]
+}
+
+// NewPathLocker 创建路径锁
+func NewPathLocker() *PathLocker {
+	return &PathLocker{locks: shardmap.New[string, *pathLock](shardmap.DefaultShards)}
+}
+
+// Lock 锁定路径，返回解锁函数
+func (l *PathLocker) Lock(path string) (unlock func()) {
+	key := filepath.Clean(path)
+	if abs, err := filepath.Abs(key); err == nil {
+		key = abs
+	}
+
+	lock := l.locks.Update(key, func(lock *pathLock, ok bool) (*pathLock, bool) {
+		if !ok {
+			lock = &pathLock{}
+		}
+		lock.refs++
+		return lock, true
+	})
+	lock.mutex.Lock()
+
+	return func() {
+		lock.mutex.Unlock()
+		l.locks.Update(key, func(lock *pathLock, _ bool) (*pathLock, bool) {
+			lock.refs--
+			return lock, lock.refs > 0
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPathLockerSerializesSamePath(t *testing.T) {
+	l := NewPathLocker()
+	unlock := l.Lock("/data/a.txt")
+
+	// 同一文件（路径写法不同）需等待解锁
+	acquired := make(chan struct{})
+	go func() {
+		unlock := l.Lock("/data/./a.txt")
+		close(acquired)
+		unlock()
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("同一路径的锁被同时持有")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 其他文件不受影响
+	done := make(chan struct{})
+	go func() {
+		l.Lock("/data/b.txt")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("不同路径的锁互相阻塞")
+	}
+
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("解锁后等待者未获得锁")
+	}
+
+	// 全部释放后不保留锁
+	deadline := time.Now().Add(time.Second)
+	for l.locks.Len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := l.locks.Len(); n != 0 {
+		t.Fatalf("释放后仍保留 %d 把锁", n)
+	}
+}
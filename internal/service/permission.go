@@ -0,0 +1,266 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 权限分配错误
+var (
+	ErrRoleNotFound       = errors.New("角色不存在")
+	ErrPermissionNotFound = errors.New("权限不存在")
+)
+
+// PermissionService 权限服务
+type PermissionService struct {
+	db             *gorm.DB
+	rbac           *RBACCache
+	alerter        SecurityAlerter
+	accessNotifier AccessNotifier
+}
+
+// NewPermissionService 创建权限服务实例
+func NewPermissionService(db *gorm.DB, rbac *RBACCache) *PermissionService {
+	return &PermissionService{db: db, rbac: rbac}
+}
+
+// SetSecurityAlerter 设置安全告警推送器，角色权限变更时通知管理员
+func (s *PermissionService) SetSecurityAlerter(alerter SecurityAlerter) {
+	s.alerter = alerter
+}
+
+// SetAccessNotifier 设置权限变更通知器，角色权限变更时通知拥有该角色的用户
+func (s *PermissionService) SetAccessNotifier(notifier AccessNotifier) {
+	s.accessNotifier = notifier
+}
+
+// ListPermissions 获取权限列表，按资源和操作排序；resource、action 为空时不过滤
+func (s *PermissionService) ListPermissions(resource, action string) ([]model.Permission, error) {
+	catalog, err := s.rbac.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make([]model.Permission, 0, len(catalog))
+	for _, permission := range catalog {
+		if resource != "" && permission.Resource != resource {
+			continue
+		}
+		if action != "" && permission.Action != action {
+			continue
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+// GetRolePermissions 获取角色拥有的权限
+func (s *PermissionService) GetRolePermissions(roleID uint) ([]model.Permission, error) {
+	if _, err := s.getRole(roleID); err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.rbac.RolePermissions(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if permissions == nil {
+		permissions = []model.Permission{}
+	}
+	return permissions, nil
+}
+
+// SetRolePermissions 用给定的权限集合替换角色的全部权限
+// 所有权限ID必须存在，否则不做任何修改并返回 ErrPermissionNotFound
+func (s *PermissionService) SetRolePermissions(roleID uint, permissionIDs []uint, userID uint, clientIP, userAgent string) ([]model.Permission, error) {
+	role, err := s.getRole(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := s.GetRolePermissions(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := uniquePermissionIDs(permissionIDs)
+
+	err = database.RetryTransaction(s.db, func(tx *gorm.DB) error {
+		return replaceRolePermissions(tx, roleID, ids)
+	})
+	if err != nil {
+		s.logAuditAction(userID, "set_role_permissions", "role", fmt.Sprintf("设置角色权限失败: %s, %v", role.Name, err), clientIP, userAgent, "failed")
+		if errors.Is(err, ErrPermissionNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("设置角色权限失败: %w", err)
+	}
+
+	s.rbac.Invalidate()
+
+	after, err := s.GetRolePermissions(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	added, removed := diffNames(permissionNames(before), permissionNames(after))
+	details := fmt.Sprintf("设置角色权限: %s, 新增: %s, 移除: %s", role.Name, formatNameList(added), formatNameList(removed))
+	s.logAuditAction(userID, "set_role_permissions", "role", details, clientIP, userAgent, "success")
+	logger.Info("设置角色权限成功", "role", role.Name, "permissions", len(ids), "added", len(added), "removed", len(removed), "user_id", userID)
+
+	if len(added) > 0 || len(removed) > 0 {
+		s.notifyRoleChanged(role, details, userID, clientIP)
+	}
+
+	return after, nil
+}
+
+// notifyRoleChanged 向管理员推送安全告警，并通知拥有该角色的用户刷新权限
+func (s *PermissionService) notifyRoleChanged(role *model.Role, details string, operatorID uint, clientIP string) {
+	if s.alerter != nil {
+		s.alerter.SendSecurityAlert(&model.SecurityAlert{
+			Type:      model.SecurityAlertRolePermissions,
+			UserID:    operatorID,
+			IPAddress: clientIP,
+			Message:   details,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	if s.accessNotifier == nil {
+		return
+	}
+	userIDs, err := roleUserIDs(s.db, role.ID)
+	if err != nil {
+		logger.Error("查询角色用户失败", "role", role.Name, "error", err)
+		return
+	}
+	if len(userIDs) > 0 {
+		s.accessNotifier.NotifyAccessChanged(userIDs, fmt.Sprintf("角色 %s 的权限已变更", role.Name))
+	}
+}
+
+// replaceRolePermissions 在事务中用给定的权限ID替换角色的全部权限，有不存在的权限时返回 ErrPermissionNotFound
+func replaceRolePermissions(tx *gorm.DB, roleID uint, ids []uint) error {
+	if len(ids) > 0 {
+		var count int64
+		if err := tx.Model(&model.Permission{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(ids) {
+			return ErrPermissionNotFound
+		}
+	}
+
+	if err := tx.Where("role_id = ?", roleID).Delete(&model.RolePermission{}).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := tx.Create(&model.RolePermission{RoleID: roleID, PermissionID: id}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roleUserIDs 返回拥有该角色的用户ID
+func roleUserIDs(db *gorm.DB, roleID uint) ([]uint, error) {
+	var userIDs []uint
+	if err := db.Model(&model.UserRole{}).Where("role_id = ?", roleID).Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// permissionNames 提取权限名称
+func permissionNames(permissions []model.Permission) []string {
+	names := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		names = append(names, permission.Name)
+	}
+	return names
+}
+
+// diffNames 比较变更前后的名称集合，返回排序后的新增和移除项
+func diffNames(before, after []string) (added, removed []string) {
+	beforeSet := make(map[string]bool, len(before))
+	for _, name := range before {
+		beforeSet[name] = true
+	}
+	afterSet := make(map[string]bool, len(after))
+	for _, name := range after {
+		afterSet[name] = true
+		if !beforeSet[name] {
+			added = append(added, name)
+		}
+	}
+	for _, name := range before {
+		if !afterSet[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// formatNameList 格式化名称列表用于审计日志，为空时显示"无"
+func formatNameList(names []string) string {
+	if len(names) == 0 {
+		return "无"
+	}
+	return strings.Join(names, ", ")
+}
+
+// getRole 根据ID获取角色
+func (s *PermissionService) getRole(roleID uint) (*model.Role, error) {
+	var role model.Role
+	if err := s.db.First(&role, roleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	return &role, nil
+}
+
+// uniquePermissionIDs 去重并排序权限ID
+func uniquePermissionIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+	return unique
+}
+
+// logAuditAction 记录审计日志
+func (s *PermissionService) logAuditAction(userID uint, action, resource, details, clientIP, userAgent, status string) {
+	auditLog := &model.AuditLog{
+		UserID:    &userID,
+		Action:    action,
+		Resource:  resource,
+		Details:   details,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		Status:    status,
+	}
+
+	if err := s.db.Create(auditLog).Error; err != nil {
+		logger.Error("记录审计日志失败", "error", err)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxPreferenceKeys 每个用户最多保存的偏好项数
+	maxPreferenceKeys = 100
+	// maxPreferenceKeyLength 偏好项键名最大长度
+	maxPreferenceKeyLength = 100
+	// maxPreferenceValueSize 单个偏好值最大字节数
+	maxPreferenceValueSize = 16 * 1024
+	// MaxPreferencesPayloadSize 单次提交偏好的最大字节数
+	MaxPreferencesPayloadSize = 64 * 1024
+)
+
+// ErrPreferencesTooLarge 偏好数据超出限制
+var ErrPreferencesTooLarge = errors.New("偏好数据超出大小限制")
+
+// PreferenceService 用户偏好服务
+type PreferenceService struct {
+	db *gorm.DB
+}
+
+// NewPreferenceService 创建用户偏好服务实例
+func NewPreferenceService(db *gorm.DB) *PreferenceService {
+	return &PreferenceService{db: db}
+}
+
+// GetPreferences 获取用户的全部偏好
+func (s *PreferenceService) GetPreferences(userID uint) (map[string]json.RawMessage, error) {
+	var items []model.UserPreference
+	if err := s.db.Where("user_id = ?", userID).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("查询偏好失败: %w", err)
+	}
+
+	preferences := make(map[string]json.RawMessage, len(items))
+	for _, item := range items {
+		preferences[item.Key] = json.RawMessage(item.Value)
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences 合并更新用户偏好，值为null的键会被删除
+func (s *PreferenceService) UpdatePreferences(userID uint, updates map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	var upserts []model.UserPreference
+	var deletes []string
+	for key, value := range updates {
+		if key == "" || len(key) > maxPreferenceKeyLength {
+			return nil, fmt.Errorf("无效的偏好键: %q", key)
+		}
+		if len(value) > maxPreferenceValueSize {
+			return nil, fmt.Errorf("%w: %s", ErrPreferencesTooLarge, key)
+		}
+		if len(value) == 0 || string(value) == "null" {
+			deletes = append(deletes, key)
+			continue
+		}
+		if !json.Valid(value) {
+			return nil, fmt.Errorf("偏好值不是有效的JSON: %s", key)
+		}
+		upserts = append(upserts, model.UserPreference{UserID: userID, Key: key, Value: string(value)})
+	}
+
+	err := database.RetryTransaction(s.db, func(tx *gorm.DB) error {
+		if len(deletes) > 0 {
+			if err := tx.Where("user_id = ? AND key IN ?", userID, deletes).Delete(&model.UserPreference{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(upserts) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}).Create(&upserts).Error; err != nil {
+				return err
+			}
+		}
+
+		// 更新后检查总数
+		var count int64
+		if err := tx.Model(&model.UserPreference{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > maxPreferenceKeys {
+			return fmt.Errorf("%w: 最多保存 %d 项", ErrPreferencesTooLarge, maxPreferenceKeys)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(userID)
+}
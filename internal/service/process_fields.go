@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"web-panel-go/internal/model"
+)
+
+// 进程列表可选字段，与 model.ProcessInfo 的json字段名一致
+const (
+	ProcessFieldPID        = "pid"
+	ProcessFieldName       = "name"
+	ProcessFieldCmdline    = "cmdline"
+	ProcessFieldStatus     = "status"
+	ProcessFieldCPUPercent = "cpu_percent"
+	ProcessFieldMemoryMB   = "memory_mb"
+	ProcessFieldCreateTime = "create_time"
+	ProcessFieldUsername   = "username"
+	ProcessFieldIsRunning  = "is_running"
+)
+
+// processFieldNames 所有可选字段
+var processFieldNames = []string{
+	ProcessFieldPID, ProcessFieldName, ProcessFieldCmdline, ProcessFieldStatus, ProcessFieldCPUPercent,
+	ProcessFieldMemoryMB, ProcessFieldCreateTime, ProcessFieldUsername, ProcessFieldIsRunning,
+}
+
+// ProcessFields 需要采集的进程字段，nil表示全部字段；pid 总是包含
+type ProcessFields map[string]bool
+
+// ParseProcessFields 解析逗号分隔的字段列表，为空时返回nil（全部字段）
+func ParseProcessFields(value string) (ProcessFields, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	valid := make(map[string]bool, len(processFieldNames))
+	for _, name := range processFieldNames {
+		valid[name] = true
+	}
+
+	fields := ProcessFields{ProcessFieldPID: true}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !valid[name] {
+			return nil, fmt.Errorf("不支持的进程字段: %s", name)
+		}
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// Has 是否需要采集指定字段
+func (f ProcessFields) Has(name string) bool {
+	return f == nil || f[name]
+}
+
+// Project 只保留请求的字段，nil表示全部字段时原样返回
+func (f ProcessFields) Project(info *model.ProcessInfo) interface{} {
+	if f == nil {
+		return info
+	}
+
+	values := map[string]interface{}{
+		ProcessFieldPID:        info.PID,
+		ProcessFieldName:       info.Name,
+		ProcessFieldCmdline:    info.Cmdline,
+		ProcessFieldStatus:     info.Status,
+		ProcessFieldCPUPercent: info.CPUPercent,
+		ProcessFieldMemoryMB:   info.MemoryMB,
+		ProcessFieldCreateTime: info.CreateTime,
+		ProcessFieldUsername:   info.Username,
+		ProcessFieldIsRunning:  info.IsRunning,
+	}
+
+	projected := make(map[string]interface{}, len(f))
+	for name := range f {
+		projected[name] = values[name]
+	}
+	return projected
+}
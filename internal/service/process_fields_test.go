@@ -0,0 +1,57 @@
+package service
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGetProcessListSkipsUnrequestedFields(t *testing.T) {
+	cfg := newTestConfig(t)
+	s := NewSystemService(newTestDB(t), cfg)
+	fields, err := ParseProcessFields("name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	processes, _, err := s.GetProcessList(1, 100000, fields)
+	if err != nil {
+		t.Fatalf("获取进程列表失败: %v", err)
+	}
+	for _, p := range processes {
+		if p.PID != int32(os.Getpid()) {
+			continue
+		}
+		if p.Name == "" {
+			t.Fatal("请求的字段未采集")
+		}
+		if p.Cmdline != "" || p.Status != "" || p.Username != "" || p.MemoryMB != 0 {
+			t.Fatalf("未请求的字段不应采集: %+v", p)
+		}
+		return
+	}
+	t.Fatal("进程列表中缺少当前进程")
+}
+
+// benchmarkProcessList 以指定字段反复获取进程列表
+func benchmarkProcessList(b *testing.B, value string) {
+	s := NewSystemService(newTestDB(b), newTestConfig(b))
+	fields, err := ParseProcessFields(value)
+	if err != nil {
+		b.Fatal(err)
+	}
+	// 先预采样一次CPU，避免等待采样间隔计入结果
+	if _, _, err := s.GetProcessList(1, 50, fields); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := s.GetProcessList(1, 50, fields); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetProcessListAllFields(b *testing.B) { benchmarkProcessList(b, "") }
+
+func BenchmarkGetProcessListMinimalFields(b *testing.B) { benchmarkProcessList(b, "name") }
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package service
+
+import "syscall"
+
+// processSignals 可发送给进程的信号，键为不带 SIG 前缀的大写信号名
+var processSignals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"STOP": syscall.SIGSTOP,
+	"CONT": syscall.SIGCONT,
+}
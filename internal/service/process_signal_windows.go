@@ -0,0 +1,14 @@
+//go:build windows
+
+package service
+
+import "syscall"
+
+// processSignals Windows 下只能强制终止进程，其余信号发送时会返回不支持的错误
+var processSignals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// RBACCache 角色权限缓存
+// 缓存每个角色拥有的权限以及权限目录，避免每个请求都联表查询权限；
+// 角色或权限在本实例内变更后应调用 Invalidate，外部直接修改数据库后通过 Reload 刷新
+type RBACCache struct {
+	db              *gorm.DB
+	mutex           sync.RWMutex
+	loaded          bool
+	generation      uint64 // 每次失效加一，加载期间发生失效时丢弃查询结果
+	rolePermissions map[uint][]model.Permission
+	catalog         []model.Permission
+}
+
+// rbacReloadAttempts 加载期间缓存反复失效时最多重新查询的次数
+const rbacReloadAttempts = 3
+
+// errRBACCacheBusy 加载期间缓存持续失效
+var errRBACCacheBusy = errors.New("角色权限频繁变更，加载缓存失败")
+
+// NewRBACCache 创建角色权限缓存，首次使用时加载
+func NewRBACCache(db *gorm.DB) *RBACCache {
+	return &RBACCache{db: db}
+}
+
+// Invalidate 使缓存失效，下次使用时重新加载
+func (c *RBACCache) Invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generation++
+	c.loaded = false
+	c.rolePermissions = nil
+	c.catalog = nil
+}
+
+// Reload 立即从数据库重新加载角色权限和权限目录
+// 查询期间缓存被 Invalidate 时结果可能已过期，丢弃后重新查询
+func (c *RBACCache) Reload() error {
+	for attempt := 0; attempt < rbacReloadAttempts; attempt++ {
+		c.mutex.RLock()
+		generation := c.generation
+		c.mutex.RUnlock()
+
+		rolePermissions, catalog, err := c.query()
+		if err != nil {
+			return err
+		}
+
+		c.mutex.Lock()
+		if c.generation == generation {
+			c.rolePermissions = rolePermissions
+			c.catalog = catalog
+			c.loaded = true
+			c.mutex.Unlock()
+			return nil
+		}
+		c.mutex.Unlock()
+	}
+	return errRBACCacheBusy
+}
+
+// query 从数据库查询角色权限和权限目录
+func (c *RBACCache) query() (map[uint][]model.Permission, []model.Permission, error) {
+	var roles []model.Role
+	if err := c.db.Preload("Permissions").Find(&roles).Error; err != nil {
+		return nil, nil, fmt.Errorf("加载角色权限失败: %w", err)
+	}
+
+	var catalog []model.Permission
+	if err := c.db.Order("resource ASC, action ASC").Find(&catalog).Error; err != nil {
+		return nil, nil, fmt.Errorf("加载权限目录失败: %w", err)
+	}
+
+	rolePermissions := make(map[uint][]model.Permission, len(roles))
+	for _, role := range roles {
+		rolePermissions[role.ID] = role.Permissions
+	}
+	return rolePermissions, catalog, nil
+}
+
+// ensureLoaded 缓存未加载时从数据库加载
+func (c *RBACCache) ensureLoaded() error {
+	c.mutex.RLock()
+	loaded := c.loaded
+	c.mutex.RUnlock()
+
+	if loaded {
+		return nil
+	}
+	return c.Reload()
+}
+
+// RolePermissions 获取角色拥有的权限
+func (c *RBACCache) RolePermissions(roleID uint) ([]model.Permission, error) {
+	if err := c.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.rolePermissions[roleID], nil
+}
+
+// Catalog 获取全部权限定义
+func (c *RBACCache) Catalog() ([]model.Permission, error) {
+	if err := c.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.catalog, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"testing"
+
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func TestRBACCacheReloadDiscardsResultInvalidatedDuringQuery(t *testing.T) {
+	db := newTestDB(t)
+	cache := NewRBACCache(db)
+	role := testRole(t, db, "user")
+
+	var extra model.Permission
+	if err := db.Where("name = ?", model.PermissionUserDelete).First(&extra).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次查询角色后授予新权限并使缓存失效，模拟加载与变更交错
+	granted := false
+	err := db.Callback().Query().After("gorm:query").Register("test:grant_during_reload", func(tx *gorm.DB) {
+		if granted || tx.Statement.Table != "roles" {
+			return
+		}
+		granted = true
+		if err := tx.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Model(role).Association("Permissions").Append(&extra); err != nil {
+			t.Errorf("授予权限失败: %v", err)
+		}
+		cache.Invalidate()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Reload(); err != nil {
+		t.Fatalf("加载缓存失败: %v", err)
+	}
+	if !granted {
+		t.Fatal("测试回调未执行")
+	}
+
+	perms, err := cache.RolePermissions(role.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, perm := range perms {
+		if perm.Name == model.PermissionUserDelete {
+			return
+		}
+	}
+	t.Fatalf("缓存保留了失效前的查询结果: %v", perms)
+}
+
+func TestReloadRBACRefreshesExternalChanges(t *testing.T) {
+	s, _, _, admin := newTestRoleService(t)
+	role := testRole(t, s.db, "user")
+
+	hasPermission := func(name string) bool {
+		t.Helper()
+		perms, err := s.rbac.RolePermissions(role.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, perm := range perms {
+			if perm.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	if hasPermission(model.PermissionUserDelete) {
+		t.Fatal("初始角色不应包含 user:delete")
+	}
+
+	// 绕过服务直接修改数据库，缓存不会感知
+	var extra model.Permission
+	if err := s.db.Where("name = ?", model.PermissionUserDelete).First(&extra).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.Create(&model.RolePermission{RoleID: role.ID, PermissionID: extra.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+	added := model.Permission{Name: "backup:restore", Resource: "backup", Action: "restore"}
+	if err := s.db.Create(&added).Error; err != nil {
+		t.Fatal(err)
+	}
+	if hasPermission(model.PermissionUserDelete) {
+		t.Fatal("缓存应在重新加载前保留旧数据")
+	}
+
+	if err := s.ReloadRBAC(admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("重新加载失败: %v", err)
+	}
+	if !hasPermission(model.PermissionUserDelete) {
+		t.Fatal("重新加载后缓存未包含新授予的权限")
+	}
+	catalog, err := s.rbac.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, perm := range catalog {
+		found = found || perm.Name == added.Name
+	}
+	if !found {
+		t.Fatal("重新加载后权限目录未包含新权限")
+	}
+	if details := lastAuditDetails(t, s, "reload_rbac"); details == "" {
+		t.Fatal("缺少重新加载的审计日志")
+	}
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 角色管理错误
+var (
+	ErrRoleExists        = errors.New("角色名已存在")
+	ErrSystemRole        = errors.New("系统角色不能删除或禁用")
+	ErrInvalidRoleStatus = errors.New("无效的角色状态")
+)
+
+// RoleService 角色服务
+type RoleService struct {
+	db             *gorm.DB
+	rbac           *RBACCache
+	alerter        SecurityAlerter
+	accessNotifier AccessNotifier
+}
+
+// NewRoleService 创建角色服务实例
+func NewRoleService(db *gorm.DB, rbac *RBACCache) *RoleService {
+	return &RoleService{db: db, rbac: rbac}
+}
+
+// SetSecurityAlerter 设置安全告警推送器，角色创建、修改或删除时通知管理员
+func (s *RoleService) SetSecurityAlerter(alerter SecurityAlerter) {
+	s.alerter = alerter
+}
+
+// SetAccessNotifier 设置权限变更通知器，角色修改或删除时通知拥有该角色的用户
+func (s *RoleService) SetAccessNotifier(notifier AccessNotifier) {
+	s.accessNotifier = notifier
+}
+
+// ListRoles 获取角色列表及每个角色下的用户数，status 为 nil 时返回全部角色
+func (s *RoleService) ListRoles(status *model.RoleStatus) ([]model.RoleSummary, error) {
+	// 已删除的用户不计入角色用户数
+	query := s.db.Model(&model.Role{}).
+		Select("roles.id, roles.name, roles.display_name, roles.description, roles.is_system, roles.status, COUNT(users.id) AS user_count").
+		Joins("LEFT JOIN user_roles ON user_roles.role_id = roles.id").
+		Joins("LEFT JOIN users ON users.id = user_roles.user_id AND users.deleted_at IS NULL").
+		Group("roles.id").
+		Order("roles.name ASC")
+
+	if status != nil {
+		query = query.Where("roles.status = ?", *status)
+	}
+
+	roles := make([]model.RoleSummary, 0)
+	if err := query.Scan(&roles).Error; err != nil {
+		return nil, fmt.Errorf("获取角色列表失败: %w", err)
+	}
+
+	return roles, nil
+}
+
+// CreateRole 创建角色并分配权限，所有权限ID必须存在
+func (s *RoleService) CreateRole(req *model.CreateRoleRequest, operatorID uint, clientIP, userAgent string) (*model.Role, error) {
+	role := &model.Role{
+		Name:        strings.TrimSpace(req.Name),
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		Status:      model.RoleStatusActive,
+	}
+	ids := uniquePermissionIDs(req.PermissionIDs)
+
+	err := database.RetryTransaction(s.db, func(tx *gorm.DB) error {
+		// 已删除的角色同样占用角色名
+		var count int64
+		if err := tx.Unscoped().Model(&model.Role{}).Where("name = ?", role.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRoleExists
+		}
+		if err := tx.Omit("Users", "Permissions").Create(role).Error; err != nil {
+			return err
+		}
+		return replaceRolePermissions(tx, role.ID, ids)
+	})
+	if err != nil {
+		s.logAuditAction(operatorID, "create_role", "role", fmt.Sprintf("创建角色失败: %s, %v", role.Name, err), clientIP, userAgent, "failed")
+		if errors.Is(err, ErrRoleExists) || errors.Is(err, ErrPermissionNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("创建角色失败: %w", err)
+	}
+	s.rbac.Invalidate()
+
+	permissions, err := s.rolePermissionNames(role.ID)
+	if err != nil {
+		return nil, err
+	}
+	details := fmt.Sprintf("创建角色: %s, 权限: %s", role.Name, formatNameList(permissions))
+	s.logAuditAction(operatorID, "create_role", "role", details, clientIP, userAgent, "success")
+	s.notifyRoleChanged(role, nil, details, "", operatorID, clientIP)
+	logger.Info("创建角色成功", "role", role.Name, "permissions", len(permissions), "user_id", operatorID)
+	return role, nil
+}
+
+// UpdateRole 更新角色信息、状态和权限，PermissionIDs 为 nil 时不修改权限
+// 审计日志记录变更前后的差异，权限或状态变化时通知拥有该角色的用户
+func (s *RoleService) UpdateRole(id uint, req *model.UpdateRoleRequest, operatorID uint, clientIP, userAgent string) (*model.Role, error) {
+	role, err := s.getRole(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != nil && *req.Status != model.RoleStatusActive && *req.Status != model.RoleStatusInactive {
+		return nil, ErrInvalidRoleStatus
+	}
+	if role.IsSystem && req.Status != nil && *req.Status != model.RoleStatusActive {
+		return nil, ErrSystemRole
+	}
+
+	before, err := s.rolePermissionNames(role.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	updates := map[string]interface{}{}
+	if req.DisplayName != "" && req.DisplayName != role.DisplayName {
+		changes = append(changes, fmt.Sprintf("显示名称: %s -> %s", role.DisplayName, req.DisplayName))
+		updates["display_name"] = req.DisplayName
+	}
+	if req.Description != "" && req.Description != role.Description {
+		changes = append(changes, "描述已修改")
+		updates["description"] = req.Description
+	}
+	statusChanged := req.Status != nil && *req.Status != role.Status
+	if statusChanged {
+		changes = append(changes, fmt.Sprintf("状态: %s -> %s", role.Status, *req.Status))
+		updates["status"] = *req.Status
+	}
+
+	err = database.RetryTransaction(s.db, func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&model.Role{}).Where("id = ?", role.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if req.PermissionIDs != nil {
+			return replaceRolePermissions(tx, role.ID, uniquePermissionIDs(req.PermissionIDs))
+		}
+		return nil
+	})
+	if err != nil {
+		s.logAuditAction(operatorID, "update_role", "role", fmt.Sprintf("更新角色失败: %s, %v", role.Name, err), clientIP, userAgent, "failed")
+		if errors.Is(err, ErrPermissionNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("更新角色失败: %w", err)
+	}
+	s.rbac.Invalidate()
+
+	after, err := s.rolePermissionNames(role.ID)
+	if err != nil {
+		return nil, err
+	}
+	added, removed := diffNames(before, after)
+	if len(added) > 0 || len(removed) > 0 {
+		changes = append(changes, fmt.Sprintf("权限新增: %s, 移除: %s", formatNameList(added), formatNameList(removed)))
+	}
+
+	if len(changes) == 0 {
+		changes = append(changes, "无变更")
+	}
+	details := fmt.Sprintf("更新角色: %s, %s", role.Name, strings.Join(changes, ", "))
+	s.logAuditAction(operatorID, "update_role", "role", details, clientIP, userAgent, "success")
+
+	if statusChanged || len(added) > 0 || len(removed) > 0 {
+		userIDs, err := roleUserIDs(s.db, role.ID)
+		if err != nil {
+			logger.Error("查询角色用户失败", "role", role.Name, "error", err)
+		}
+		s.notifyRoleChanged(role, userIDs, details, fmt.Sprintf("角色 %s 的权限已变更", role.Name), operatorID, clientIP)
+	}
+	logger.Info("更新角色成功", "role", role.Name, "added", len(added), "removed", len(removed), "user_id", operatorID)
+	return s.getRole(role.ID)
+}
+
+// DeleteRole 删除角色及其权限和用户分配，系统角色不能删除
+func (s *RoleService) DeleteRole(id uint, operatorID uint, clientIP, userAgent string) error {
+	role, err := s.getRole(id)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		s.logAuditAction(operatorID, "delete_role", "role", fmt.Sprintf("删除角色失败: %s 是系统角色", role.Name), clientIP, userAgent, "failed")
+		return ErrSystemRole
+	}
+
+	permissions, err := s.rolePermissionNames(role.ID)
+	if err != nil {
+		return err
+	}
+	userIDs, err := roleUserIDs(s.db, role.ID)
+	if err != nil {
+		return fmt.Errorf("查询角色用户失败: %w", err)
+	}
+
+	err = database.RetryTransaction(s.db, func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", role.ID).Delete(&model.UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		// 硬删除以释放角色名
+		return tx.Unscoped().Delete(&model.Role{}, role.ID).Error
+	})
+	if err != nil {
+		s.logAuditAction(operatorID, "delete_role", "role", fmt.Sprintf("删除角色失败: %s, %v", role.Name, err), clientIP, userAgent, "failed")
+		return fmt.Errorf("删除角色失败: %w", err)
+	}
+	s.rbac.Invalidate()
+
+	details := fmt.Sprintf("删除角色: %s, 权限: %s, 受影响用户数: %d", role.Name, formatNameList(permissions), len(userIDs))
+	s.logAuditAction(operatorID, "delete_role", "role", details, clientIP, userAgent, "success")
+	s.notifyRoleChanged(role, userIDs, details, fmt.Sprintf("角色 %s 已被删除", role.Name), operatorID, clientIP)
+	logger.Info("删除角色成功", "role", role.Name, "users", len(userIDs), "user_id", operatorID)
+	return nil
+}
+
+// notifyRoleChanged 向管理员推送安全告警，并通知 userIDs 中的用户刷新权限
+func (s *RoleService) notifyRoleChanged(role *model.Role, userIDs []uint, details, reason string, operatorID uint, clientIP string) {
+	if s.alerter != nil {
+		s.alerter.SendSecurityAlert(&model.SecurityAlert{
+			Type:      model.SecurityAlertRoleChanged,
+			UserID:    operatorID,
+			IPAddress: clientIP,
+			Message:   details,
+			CreatedAt: time.Now(),
+		})
+	}
+	if s.accessNotifier != nil && len(userIDs) > 0 {
+		s.accessNotifier.NotifyAccessChanged(userIDs, reason)
+	}
+}
+
+// rolePermissionNames 从数据库读取角色当前的权限名称
+func (s *RoleService) rolePermissionNames(roleID uint) ([]string, error) {
+	var names []string
+	err := s.db.Model(&model.Permission{}).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Where("role_permissions.role_id = ?", roleID).
+		Order("permissions.name ASC").
+		Pluck("permissions.name", &names).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询角色权限失败: %w", err)
+	}
+	return names, nil
+}
+
+// getRole 根据ID获取角色
+func (s *RoleService) getRole(id uint) (*model.Role, error) {
+	var role model.Role
+	if err := s.db.First(&role, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	return &role, nil
+}
+
+// ReloadRBAC 重新加载角色权限缓存，用于数据库被外部修改后同步
+func (s *RoleService) ReloadRBAC(userID uint, clientIP, userAgent string) error {
+	if err := s.rbac.Reload(); err != nil {
+		s.logAuditAction(userID, "reload_rbac", "role", fmt.Sprintf("重新加载角色权限失败: %v", err), clientIP, userAgent, "failed")
+		return err
+	}
+
+	catalog, _ := s.rbac.Catalog()
+	s.logAuditAction(userID, "reload_rbac", "role", fmt.Sprintf("重新加载角色权限 (权限数: %d)", len(catalog)), clientIP, userAgent, "success")
+	logger.Info("角色权限缓存已重新加载", "permissions", len(catalog), "user_id", userID)
+	return nil
+}
+
+// logAuditAction 记录审计日志
+func (s *RoleService) logAuditAction(userID uint, action, resource, details, clientIP, userAgent, status string) {
+	auditLog := &model.AuditLog{
+		UserID:    &userID,
+		Action:    action,
+		Resource:  resource,
+		Details:   details,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		Status:    status,
+	}
+
+	if err := s.db.Create(auditLog).Error; err != nil {
+		logger.Error("记录审计日志失败", "error", err)
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"web-panel-go/internal/model"
+)
+
+// recordingAccessNotifier 记录收到权限变更通知的用户
+type recordingAccessNotifier struct {
+	userIDs []uint
+	reasons []string
+}
+
+func (n *recordingAccessNotifier) NotifyAccessChanged(userIDs []uint, reason string) {
+	n.userIDs = append(n.userIDs, userIDs...)
+	n.reasons = append(n.reasons, reason)
+}
+
+// newTestRoleService 创建带告警和通知记录器的角色服务
+func newTestRoleService(t *testing.T) (*RoleService, *recordingAlerter, *recordingAccessNotifier, *model.User) {
+	t.Helper()
+	db := newTestDB(t)
+	s := NewRoleService(db, NewRBACCache(db))
+	alerter := &recordingAlerter{}
+	notifier := &recordingAccessNotifier{}
+	s.SetSecurityAlerter(alerter)
+	s.SetAccessNotifier(notifier)
+	return s, alerter, notifier, testAdmin(t, db)
+}
+
+// testPermissionIDs 按名称返回权限ID
+func testPermissionIDs(t *testing.T, s *RoleService, names ...string) []uint {
+	t.Helper()
+	var ids []uint
+	if err := s.db.Model(&model.Permission{}).Where("name IN ?", names).Pluck("id", &ids).Error; err != nil || len(ids) != len(names) {
+		t.Fatalf("查询权限 %v 失败: %v", names, err)
+	}
+	return ids
+}
+
+// lastAuditDetails 返回指定操作最近一条审计日志的详情
+func lastAuditDetails(t *testing.T, s *RoleService, action string) string {
+	t.Helper()
+	var entry model.AuditLog
+	if err := s.db.Where("action = ?", action).Order("id DESC").First(&entry).Error; err != nil {
+		t.Fatalf("缺少 %s 审计日志: %v", action, err)
+	}
+	return entry.Details
+}
+
+func TestUpdateRoleAuditsDiffAndNotifiesUsers(t *testing.T) {
+	s, alerter, notifier, admin := newTestRoleService(t)
+	role, err := s.CreateRole(&model.CreateRoleRequest{
+		Name:          "auditor",
+		DisplayName:   "审计员",
+		PermissionIDs: testPermissionIDs(t, s, model.PermissionUserView, model.PermissionRoleView),
+	}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	if got := lastAuditDetails(t, s, "create_role"); got != "创建角色: auditor, 权限: role:view, user:view" {
+		t.Fatalf("创建角色审计详情 = %q", got)
+	}
+	if len(alerter.alerts) != 1 || alerter.alerts[0].Type != model.SecurityAlertRoleChanged {
+		t.Fatalf("创建角色应通知管理员: %+v", alerter.alerts)
+	}
+
+	// 分配给一个用户
+	if err := s.db.Create(&model.UserRole{UserID: admin.ID, RoleID: role.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 只修改显示名称时不通知用户
+	if _, err := s.UpdateRole(role.ID, &model.UpdateRoleRequest{DisplayName: "审计"}, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("更新角色失败: %v", err)
+	}
+	if got := lastAuditDetails(t, s, "update_role"); got != "更新角色: auditor, 显示名称: 审计员 -> 审计" {
+		t.Fatalf("更新角色审计详情 = %q", got)
+	}
+	if len(notifier.userIDs) != 0 {
+		t.Fatalf("未修改权限时不应通知用户: %v", notifier.userIDs)
+	}
+
+	updated, err := s.UpdateRole(role.ID, &model.UpdateRoleRequest{
+		PermissionIDs: testPermissionIDs(t, s, model.PermissionRoleView, model.PermissionAuditView),
+	}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("更新角色权限失败: %v", err)
+	}
+	if updated.DisplayName != "审计" {
+		t.Fatalf("更新权限不应修改其他字段: %+v", updated)
+	}
+	want := "更新角色: auditor, 权限新增: " + model.PermissionAuditView + ", 移除: user:view"
+	if got := lastAuditDetails(t, s, "update_role"); got != want {
+		t.Fatalf("更新角色审计详情 = %q, 期望 %q", got, want)
+	}
+	if len(notifier.userIDs) != 1 || notifier.userIDs[0] != admin.ID {
+		t.Fatalf("应通知拥有该角色的用户: %v", notifier.userIDs)
+	}
+	if last := alerter.alerts[len(alerter.alerts)-1]; last.Message != want {
+		t.Fatalf("管理员告警内容 = %q", last.Message)
+	}
+
+	// 权限无效时不做修改
+	if _, err := s.UpdateRole(role.ID, &model.UpdateRoleRequest{PermissionIDs: []uint{99999}}, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrPermissionNotFound) {
+		t.Fatalf("期望 ErrPermissionNotFound，实际 %v", err)
+	}
+	if names, _ := s.rolePermissionNames(role.ID); strings.Join(names, ",") != model.PermissionAuditView+",role:view" {
+		t.Fatalf("失败的更新修改了权限: %v", names)
+	}
+}
+
+func TestDeleteRoleNotifiesAffectedUsers(t *testing.T) {
+	s, _, notifier, admin := newTestRoleService(t)
+	role, err := s.CreateRole(&model.CreateRoleRequest{
+		Name:          "operator",
+		DisplayName:   "运维",
+		PermissionIDs: testPermissionIDs(t, s, model.PermissionSystemView),
+	}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.Create(&model.UserRole{UserID: admin.ID, RoleID: role.ID}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteRole(role.ID, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("删除角色失败: %v", err)
+	}
+	if got := lastAuditDetails(t, s, "delete_role"); got != "删除角色: operator, 权限: system:view, 受影响用户数: 1" {
+		t.Fatalf("删除角色审计详情 = %q", got)
+	}
+	if len(notifier.userIDs) != 1 || notifier.userIDs[0] != admin.ID {
+		t.Fatalf("应通知失去该角色的用户: %v", notifier.userIDs)
+	}
+	var assignments int64
+	s.db.Model(&model.UserRole{}).Where("role_id = ?", role.ID).Count(&assignments)
+	if assignments != 0 {
+		t.Fatal("角色分配未删除")
+	}
+
+	// 删除后角色名可以重新使用
+	if _, err := s.CreateRole(&model.CreateRoleRequest{Name: "operator", DisplayName: "运维"}, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("重新创建角色失败: %v", err)
+	}
+	if _, err := s.CreateRole(&model.CreateRoleRequest{Name: "operator", DisplayName: "运维"}, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrRoleExists) {
+		t.Fatalf("期望 ErrRoleExists，实际 %v", err)
+	}
+}
+
+func TestSystemRolesCannotBeDeletedOrDisabled(t *testing.T) {
+	s, _, notifier, admin := newTestRoleService(t)
+	adminRole := testRole(t, s.db, model.RoleAdmin)
+
+	if err := s.DeleteRole(adminRole.ID, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrSystemRole) {
+		t.Fatalf("删除系统角色返回 %v，期望 ErrSystemRole", err)
+	}
+	inactive := model.RoleStatusInactive
+	if _, err := s.UpdateRole(adminRole.ID, &model.UpdateRoleRequest{Status: &inactive}, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrSystemRole) {
+		t.Fatalf("禁用系统角色返回 %v，期望 ErrSystemRole", err)
+	}
+	if err := s.DeleteRole(99999, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrRoleNotFound) {
+		t.Fatalf("删除不存在的角色返回 %v", err)
+	}
+	if len(notifier.userIDs) != 0 {
+		t.Fatalf("失败的操作不应通知用户: %v", notifier.userIDs)
+	}
+}
+
+func TestListRolesCountsAssignedUsers(t *testing.T) {
+	s, _, _, admin := newTestRoleService(t)
+	userRole := testRole(t, s.db, model.RoleUser)
+	ops, err := s.CreateRole(&model.CreateRoleRequest{Name: "ops", DisplayName: "运维"}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inactive := model.RoleStatusInactive
+	if _, err := s.UpdateRole(ops.ID, &model.UpdateRoleRequest{Status: &inactive}, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// alice、bob 为普通用户，bob 同时属于 ops；carol 已删除，不计入
+	for _, assignment := range []struct {
+		username string
+		roles    []uint
+	}{
+		{"alice", []uint{userRole.ID}},
+		{"bob", []uint{userRole.ID, ops.ID}},
+		{"carol", []uint{userRole.ID, ops.ID}},
+	} {
+		user := &model.User{Username: assignment.username, Email: assignment.username + "@example.com", Status: model.UserStatusActive}
+		if err := s.db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, roleID := range assignment.roles {
+			if err := s.db.Create(&model.UserRole{UserID: user.ID, RoleID: roleID}).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := s.db.Where("username = ?", "carol").Delete(&model.User{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	roles, err := s.ListRoles(nil)
+	if err != nil {
+		t.Fatalf("获取角色列表失败: %v", err)
+	}
+	counts := make(map[string]int64)
+	var names []string
+	for _, role := range roles {
+		counts[role.Name] = role.UserCount
+		names = append(names, role.Name)
+	}
+	if counts[model.RoleAdmin] != 1 || counts[model.RoleUser] != 2 || counts["ops"] != 1 {
+		t.Fatalf("角色用户数 = %v，期望 admin:1 user:2 ops:1", counts)
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("角色未按名称排序: %v", names)
+	}
+
+	// 按状态过滤
+	roles, err = s.ListRoles(&inactive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 || roles[0].Name != "ops" || roles[0].Status != model.RoleStatusInactive {
+		t.Fatalf("停用角色列表 = %+v", roles)
+	}
+}
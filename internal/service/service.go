@@ -2,24 +2,97 @@ package service
 
 import (
 	"web-panel-go/internal/config"
+	"web-panel-go/internal/model"
 
 	"gorm.io/gorm"
 )
 
+// Notifier 用户通知接口（由WebSocket管理器等实现）
+type Notifier interface {
+	NotifyUser(userID uint, title, content, level string)
+}
+
+// SecurityAlerter 安全告警推送接口（由WebSocket管理器等实现）
+type SecurityAlerter interface {
+	SendSecurityAlert(alert *model.SecurityAlert)
+}
+
+// AdminNotifier 管理员通知接口（由WebSocket管理器等实现）
+type AdminNotifier interface {
+	NotifyAdmins(title, content, level string)
+}
+
+// NotificationBroadcaster 全员通知广播接口（由WebSocket管理器等实现）
+type NotificationBroadcaster interface {
+	BroadcastNotification(title, content, level string)
+}
+
+// SessionCloser 会话连接关闭接口（由WebSocket管理器等实现），会话被撤销时断开其实时连接
+type SessionCloser interface {
+	CloseSession(sessionID string) int
+}
+
+// AccessNotifier 权限变更通知接口（由WebSocket管理器等实现），用于让受影响用户的界面刷新权限
+type AccessNotifier interface {
+	NotifyAccessChanged(userIDs []uint, reason string)
+}
+
+// ConfigBroadcaster 设置变更广播接口（由WebSocket管理器等实现）
+type ConfigBroadcaster interface {
+	BroadcastConfigChanged(changes map[string]string)
+}
+
+// Mailer 邮件发送接口（由SMTPMailer等实现）
+type Mailer interface {
+	SendMail(to, subject, body string) error
+}
+
 // Services 服务集合
 type Services struct {
-	Auth   *AuthService
-	User   *UserService
-	System *SystemService
-	File   *FileService
+	Auth          *AuthService
+	User          *UserService
+	System        *SystemService
+	File          *FileService
+	Audit         *AuditService
+	Setting       *SettingService
+	Preference    *PreferenceService
+	Jobs          *JobService
+	Role          *RoleService
+	Permission    *PermissionService
+	ChunkedUpload *ChunkedUploadService
+	Diagnostics   *DiagnosticsService
+	Health        *HealthService
+	FileTail      *FileTailService
+	Session       *SessionService
+	Alerts        *AlertService
+	Metrics       *MetricsService
 }
 
 // NewServices 创建服务集合实例
 func NewServices(db *gorm.DB, cfg *config.Config) *Services {
+	settingService := NewSettingService(db)
+	rbacCache := NewRBACCache(db)
+	authService := NewAuthService(db, cfg, rbacCache)
+	fileService := NewFileService(db, cfg, settingService)
+	systemService := NewSystemService(db, cfg)
+
 	return &Services{
-		Auth:   NewAuthService(db, cfg),
-		User:   NewUserService(db),
-		System: NewSystemService(db),
-		File:   NewFileService(db),
+		Auth:          authService,
+		User:          NewUserService(db, cfg),
+		System:        systemService,
+		File:          fileService,
+		Audit:         NewAuditService(db),
+		Setting:       settingService,
+		Preference:    NewPreferenceService(db),
+		Jobs:          NewJobService(db, cfg),
+		Role:          NewRoleService(db, rbacCache),
+		Permission:    NewPermissionService(db, rbacCache),
+		ChunkedUpload: NewChunkedUploadService(cfg, fileService),
+		Diagnostics:   NewDiagnosticsService(db, cfg, systemService),
+		Health:        NewHealthService(db, cfg),
+		FileTail:      NewFileTailService(cfg, fileService),
+		Session:       NewSessionService(db, authService),
+		Alerts:        NewAlertService(db, cfg),
+		Metrics:       NewMetricsService(db, cfg),
 	}
-}
\ No newline at end of file
+}
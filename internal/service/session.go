@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ErrSessionNotFound 会话不存在或不属于指定用户
+var ErrSessionNotFound = errors.New("会话不存在")
+
+// SessionService 会话管理服务
+type SessionService struct {
+	db       *gorm.DB
+	extender *sessionExtender
+	closer   SessionCloser
+}
+
+// NewSessionService 创建会话管理服务实例，撤销会话时同时清理 auth 中该会话的延期记录
+func NewSessionService(db *gorm.DB, auth *AuthService) *SessionService {
+	return &SessionService{db: db, extender: auth.extender}
+}
+
+// SetSessionCloser 设置会话连接关闭器，撤销会话时断开该会话的WebSocket连接
+func (s *SessionService) SetSessionCloser(closer SessionCloser) {
+	s.closer = closer
+}
+
+// ListSessions 获取用户未过期的会话，按创建时间倒序；currentToken 对应的会话标记为当前会话
+func (s *SessionService) ListSessions(userID uint, currentToken string) ([]model.SessionInfo, error) {
+	var sessions []model.Session
+	if err := s.db.Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	infos := make([]model.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, model.SessionInfo{
+			ID:        session.ID,
+			UserID:    session.UserID,
+			IPAddress: session.IPAddress,
+			UserAgent: session.UserAgent,
+			CreatedAt: session.CreatedAt,
+			ExpiresAt: session.ExpiresAt,
+			Current:   currentToken != "" && session.Token == currentToken,
+		})
+	}
+	return infos, nil
+}
+
+// RevokeSession 撤销用户的指定会话并断开该会话已建立的WebSocket连接，会话不属于该用户时返回 ErrSessionNotFound
+func (s *SessionService) RevokeSession(sessionID string, userID uint, operatorID uint, clientIP, userAgent string) error {
+	var session model.Session
+	if err := s.db.Select("id", "token").Where("id = ? AND user_id = ?", sessionID, userID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	result := s.db.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&model.Session{})
+	if result.Error != nil {
+		s.logAuditAction(operatorID, "revoke_session", "session", fmt.Sprintf("撤销会话失败: 用户ID %d, 错误: %v", userID, result.Error), clientIP, userAgent, "failed")
+		return fmt.Errorf("撤销会话失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	s.extender.forget(session.Token)
+
+	closed := 0
+	if s.closer != nil {
+		closed = s.closer.CloseSession(sessionID)
+	}
+
+	s.logAuditAction(operatorID, "revoke_session", "session", fmt.Sprintf("撤销会话: 用户ID %d, 会话 %s", userID, sessionID), clientIP, userAgent, "success")
+	logger.Info("撤销会话成功", "user_id", userID, "session_id", sessionID, "operator", operatorID, "closed_connections", closed)
+	return nil
+}
+
+// logAuditAction 记录审计日志
+func (s *SessionService) logAuditAction(userID uint, action, resource, details, clientIP, userAgent, status string) {
+	auditLog := &model.AuditLog{
+		UserID:    &userID,
+		Action:    action,
+		Resource:  resource,
+		Details:   details,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		Status:    status,
+	}
+
+	if err := s.db.Create(auditLog).Error; err != nil {
+		logger.Error("记录审计日志失败", "error", err)
+	}
+}
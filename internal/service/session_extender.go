@@ -0,0 +1,50 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// sessionExtender 记录每个令牌最近一次延长会话的时间，保证同一会话在间隔内最多写一次数据库
+type sessionExtender struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	extended map[string]time.Time
+	now      func() time.Time
+}
+
+// newSessionExtender 创建会话延长记录器
+func newSessionExtender(interval time.Duration) *sessionExtender {
+	return &sessionExtender{
+		interval: interval,
+		extended: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// due 判断令牌是否需要延长会话，需要时同时记录本次时间，避免并发请求重复写入
+func (e *sessionExtender) due(token string) (time.Time, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	now := e.now()
+	if last, ok := e.extended[token]; ok && now.Sub(last) < e.interval {
+		return now, false
+	}
+	e.extended[token] = now
+
+	// 顺带清理已过间隔的记录，防止登出或过期的令牌常驻内存
+	for t, last := range e.extended {
+		if now.Sub(last) >= e.interval {
+			delete(e.extended, t)
+		}
+	}
+	return now, true
+}
+
+// forget 移除令牌的记录
+func (e *sessionExtender) forget(token string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	delete(e.extended, token)
+}
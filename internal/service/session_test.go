@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/model"
+)
+
+// recordingSessionCloser 记录被要求断开连接的会话
+type recordingSessionCloser struct {
+	closed []string
+}
+
+func (c *recordingSessionCloser) CloseSession(sessionID string) int {
+	c.closed = append(c.closed, sessionID)
+	return 1
+}
+
+func TestRevokeSessionClosesConnectionsAndForgetsExtension(t *testing.T) {
+	auth := newTestAuthService(t)
+	sessions := NewSessionService(auth.db, auth)
+	closer := &recordingSessionCloser{}
+	sessions.SetSessionCloser(closer)
+
+	login := loginAdmin(t, auth)
+	claims, err := auth.ValidateToken(login.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminID := claims.UserID
+	if _, due := auth.extender.due(login.Token); !due {
+		t.Fatal("首次请求应记录会话延期")
+	}
+
+	// 会话不属于指定用户时不做任何处理
+	if err := sessions.RevokeSession(claims.SessionID, adminID+1, adminID, "127.0.0.1", "test"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("期望 ErrSessionNotFound，实际 %v", err)
+	}
+	if len(closer.closed) != 0 {
+		t.Fatalf("未撤销的会话被断开: %v", closer.closed)
+	}
+
+	if err := sessions.RevokeSession(claims.SessionID, adminID, adminID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("撤销会话失败: %v", err)
+	}
+	if len(closer.closed) != 1 || closer.closed[0] != claims.SessionID {
+		t.Fatalf("断开的会话 %v，期望 [%s]", closer.closed, claims.SessionID)
+	}
+	auth.extender.mutex.Lock()
+	_, remembered := auth.extender.extended[login.Token]
+	auth.extender.mutex.Unlock()
+	if remembered {
+		t.Fatal("撤销后应移除会话的延期记录")
+	}
+
+	var count int64
+	auth.db.Model(&model.Session{}).Where("id = ?", claims.SessionID).Count(&count)
+	if count != 0 {
+		t.Fatal("会话记录未删除")
+	}
+}
+
+func TestExtendSessionSlidingWindow(t *testing.T) {
+	auth := newTestAuthService(t)
+	auth.config.Auth.SessionExtend = true
+	auth.config.Auth.JWTExpire = 30 * time.Minute
+	auth.config.Auth.SessionExtendInterval = 5 * time.Minute
+	auth.config.Auth.SessionMaxLifetime = time.Hour
+	auth.extender = newSessionExtender(auth.config.Auth.SessionExtendInterval)
+
+	login := loginAdmin(t, auth)
+	claims, err := auth.ValidateToken(login.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 固定登录时间，之后用 extender 的时钟模拟请求时间
+	start := time.Now().Truncate(time.Second)
+	if err := auth.db.Model(&model.Session{}).Where("id = ?", claims.SessionID).
+		Updates(map[string]interface{}{"created_at": start, "expires_at": start.Add(30 * time.Minute)}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		at      time.Duration
+		expires time.Duration
+	}{
+		{time.Minute, 31 * time.Minute},      // 首次活动，按空闲超时延长
+		{3 * time.Minute, 31 * time.Minute},  // 间隔内不再写数据库
+		{10 * time.Minute, 40 * time.Minute}, // 超过间隔后再次延长
+		{35 * time.Minute, time.Hour},        // 不超过登录时间加最长有效期
+		{45 * time.Minute, time.Hour},
+	}
+	for _, step := range steps {
+		now := start.Add(step.at)
+		auth.extender.now = func() time.Time { return now }
+		if err := auth.ExtendSession(login.Token); err != nil {
+			t.Fatalf("+%v 延长会话失败: %v", step.at, err)
+		}
+		var session model.Session
+		if err := auth.db.First(&session, "id = ?", claims.SessionID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if want := start.Add(step.expires); !session.ExpiresAt.Equal(want) {
+			t.Fatalf("+%v 后会话过期时间 = %v, 期望 %v", step.at, session.ExpiresAt, want)
+		}
+	}
+
+	// 会话过期后不再延长
+	now := start.Add(61 * time.Minute)
+	auth.extender.now = func() time.Time { return now }
+	if err := auth.ExtendSession(login.Token); err != nil {
+		t.Fatal(err)
+	}
+	var session model.Session
+	auth.db.First(&session, "id = ?", claims.SessionID)
+	if !session.ExpiresAt.Equal(start.Add(time.Hour)) {
+		t.Fatalf("过期会话被延长到 %v", session.ExpiresAt)
+	}
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"web-panel-go/internal/database"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 面板设置项
+const (
+	SettingFileDefaultSort  = "file.default_sort"  // 文件列表默认排序字段
+	SettingFileDefaultOrder = "file.default_order" // 文件列表默认排序方向
+	SettingFileShowHidden   = "file.show_hidden"   // 文件列表是否默认显示隐藏文件
+	SettingFileHomeDir      = "file.home_dir"      // 文件浏览器默认打开的目录
+)
+
+// 文件列表排序字段
+var fileSortFields = []string{"name", "size", "mod_time", "type"}
+
+// settingDefinition 设置项定义
+type settingDefinition struct {
+	defaultValue string
+	description  string
+	category     string
+	public       bool // 普通用户是否可读
+	validate     func(value string) error
+}
+
+// settingDefinitions 已知设置项，未保存到数据库时使用默认值
+var settingDefinitions = map[string]settingDefinition{
+	SettingFileDefaultSort: {
+		defaultValue: "name",
+		description:  "文件列表默认排序字段（name, size, mod_time, type）",
+		category:     "file",
+		public:       true,
+		validate:     validateOneOf(fileSortFields...),
+	},
+	SettingFileDefaultOrder: {
+		defaultValue: "asc",
+		description:  "文件列表默认排序方向（asc, desc）",
+		category:     "file",
+		public:       true,
+		validate:     validateOneOf("asc", "desc"),
+	},
+	SettingFileShowHidden: {
+		defaultValue: "true", // 与引入该设置前的行为一致，列表默认包含隐藏文件
+		description:  "文件列表是否默认显示隐藏文件",
+		category:     "file",
+		public:       true,
+		validate:     validateBool,
+	},
+	SettingFileHomeDir: {
+		defaultValue: "",
+		description:  "文件浏览器默认打开的目录（绝对路径，为空表示由前端决定）",
+		category:     "file",
+		public:       true,
+		validate:     validateHomeDir,
+	},
+}
+
+// validateOneOf 校验值是否在允许范围内
+func validateOneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, v := range allowed {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("取值必须为: %s", strings.Join(allowed, ", "))
+	}
+}
+
+// validateBool 校验布尔值
+func validateBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("取值必须为 true 或 false")
+	}
+	return nil
+}
+
+// validateHomeDir 校验默认目录
+func validateHomeDir(value string) error {
+	if value == "" {
+		return nil
+	}
+	if !filepath.IsAbs(value) || strings.Contains(value, "..") {
+		return fmt.Errorf("必须为绝对路径")
+	}
+	return nil
+}
+
+// SettingService 面板设置服务
+type SettingService struct {
+	db          *gorm.DB
+	broadcaster ConfigBroadcaster
+}
+
+// NewSettingService 创建面板设置服务实例
+func NewSettingService(db *gorm.DB) *SettingService {
+	return &SettingService{db: db}
+}
+
+// SetConfigBroadcaster 设置变更广播器，公开设置修改后推送给在线客户端
+func (s *SettingService) SetConfigBroadcaster(broadcaster ConfigBroadcaster) {
+	s.broadcaster = broadcaster
+}
+
+// GetSettings 获取设置列表，includePrivate为false时只返回公开设置
+func (s *SettingService) GetSettings(includePrivate bool) ([]model.SystemConfig, error) {
+	var stored []model.SystemConfig
+	if err := s.db.Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("查询设置失败: %w", err)
+	}
+
+	byKey := make(map[string]model.SystemConfig, len(stored))
+	for _, item := range stored {
+		byKey[item.Key] = item
+	}
+
+	// 补全未保存的默认设置
+	for key, def := range settingDefinitions {
+		if _, ok := byKey[key]; !ok {
+			byKey[key] = model.SystemConfig{
+				Key:         key,
+				Value:       def.defaultValue,
+				Description: def.description,
+				Category:    def.category,
+				IsPublic:    def.public,
+			}
+		}
+	}
+
+	settings := make([]model.SystemConfig, 0, len(byKey))
+	for _, item := range byKey {
+		if includePrivate || item.IsPublic {
+			settings = append(settings, item)
+		}
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+
+	return settings, nil
+}
+
+// GetValue 获取设置值，未保存时返回默认值
+func (s *SettingService) GetValue(key string) string {
+	var item model.SystemConfig
+	if err := s.db.Where("key = ?", key).First(&item).Error; err == nil {
+		return item.Value
+	}
+	return settingDefinitions[key].defaultValue
+}
+
+// UpdateSettings 批量更新设置，只允许修改已定义的设置项
+func (s *SettingService) UpdateSettings(values map[string]string, userID uint, clientIP, userAgent string) error {
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		def, ok := settingDefinitions[key]
+		if !ok {
+			return fmt.Errorf("未知的设置项: %s", key)
+		}
+		if def.validate != nil {
+			if err := def.validate(value); err != nil {
+				return fmt.Errorf("设置项 %s 无效: %w", key, err)
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	err := database.RetryTransaction(s.db, func(tx *gorm.DB) error {
+		for _, key := range keys {
+			def := settingDefinitions[key]
+			item := &model.SystemConfig{
+				Key:         key,
+				Value:       values[key],
+				Description: def.description,
+				Category:    def.category,
+				IsPublic:    def.public,
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}).Create(item).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logAuditAction(userID, "update_settings", "system", fmt.Sprintf("更新设置失败: %v", err), clientIP, userAgent, "failed")
+		return fmt.Errorf("更新设置失败: %w", err)
+	}
+
+	changes := make([]string, 0, len(keys))
+	for _, key := range keys {
+		changes = append(changes, fmt.Sprintf("%s=%s", key, values[key]))
+	}
+	s.logAuditAction(userID, "update_settings", "system", fmt.Sprintf("更新设置: %s", strings.Join(changes, ", ")), clientIP, userAgent, "success")
+	logger.Info("面板设置已更新", "keys", keys, "user_id", userID)
+
+	s.broadcastPublicChanges(keys, values)
+	return nil
+}
+
+// broadcastPublicChanges 推送公开设置的变更，非公开设置不会出现在广播中
+func (s *SettingService) broadcastPublicChanges(keys []string, values map[string]string) {
+	if s.broadcaster == nil {
+		return
+	}
+
+	public := make(map[string]string)
+	for _, key := range keys {
+		if settingDefinitions[key].public {
+			public[key] = values[key]
+		}
+	}
+	if len(public) > 0 {
+		s.broadcaster.BroadcastConfigChanged(public)
+	}
+}
+
+// GetFileBrowserSettings 获取文件浏览器默认设置
+func (s *SettingService) GetFileBrowserSettings() model.FileBrowserSettings {
+	showHidden, _ := strconv.ParseBool(s.GetValue(SettingFileShowHidden))
+	return model.FileBrowserSettings{
+		SortBy:     s.GetValue(SettingFileDefaultSort),
+		Order:      s.GetValue(SettingFileDefaultOrder),
+		ShowHidden: showHidden,
+		HomeDir:    s.GetValue(SettingFileHomeDir),
+	}
+}
+
+// logAuditAction 记录审计日志
+func (s *SettingService) logAuditAction(userID uint, action, resource, details, clientIP, userAgent, status string) {
+	auditLog := &model.AuditLog{
+		UserID:    &userID,
+		Action:    action,
+		Resource:  resource,
+		Details:   details,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+		Status:    status,
+	}
+
+	if err := s.db.Create(auditLog).Error; err != nil {
+		logger.Error("记录审计日志失败", "error", err)
+	}
+}
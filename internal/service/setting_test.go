@@ -0,0 +1,62 @@
+package service
+
+import "testing"
+
+// recordingBroadcaster 记录推送的设置变更
+type recordingBroadcaster struct {
+	changes []map[string]string
+}
+
+func (b *recordingBroadcaster) BroadcastConfigChanged(changes map[string]string) {
+	b.changes = append(b.changes, changes)
+}
+
+func TestFileBrowserSettingsShowHiddenByDefault(t *testing.T) {
+	s := NewSettingService(newTestDB(t))
+
+	// 引入该设置前文件列表总是包含隐藏文件，默认值保持这一行为
+	if !s.GetFileBrowserSettings().ShowHidden {
+		t.Fatal("file.show_hidden 默认应为 true")
+	}
+}
+
+func TestUpdateSettingsBroadcastsPublicKeysOnly(t *testing.T) {
+	const privateKey = "test.private"
+	settingDefinitions[privateKey] = settingDefinition{category: "test"}
+	t.Cleanup(func() { delete(settingDefinitions, privateKey) })
+
+	db := newTestDB(t)
+	admin := testAdmin(t, db)
+	s := NewSettingService(db)
+	b := &recordingBroadcaster{}
+	s.SetConfigBroadcaster(b)
+
+	if err := s.UpdateSettings(map[string]string{
+		SettingFileDefaultSort: "size",
+		privateKey:             "secret",
+	}, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("更新设置失败: %v", err)
+	}
+	if len(b.changes) != 1 {
+		t.Fatalf("期望推送一次，实际 %d 次", len(b.changes))
+	}
+	if got := b.changes[0]; len(got) != 1 || got[SettingFileDefaultSort] != "size" {
+		t.Fatalf("推送内容 = %v，应只包含公开设置", got)
+	}
+
+	// 只修改非公开设置时不推送
+	if err := s.UpdateSettings(map[string]string{privateKey: "other"}, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("更新设置失败: %v", err)
+	}
+	if len(b.changes) != 1 {
+		t.Fatalf("非公开设置不应推送: %v", b.changes[1:])
+	}
+
+	// 校验失败时不写入也不推送
+	if err := s.UpdateSettings(map[string]string{SettingFileDefaultOrder: "up"}, admin.ID, "127.0.0.1", "test"); err == nil {
+		t.Fatal("无效的设置值应返回错误")
+	}
+	if len(b.changes) != 1 {
+		t.Fatalf("失败的更新不应推送: %v", b.changes[1:])
+	}
+}
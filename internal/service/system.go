@@ -1,10 +1,18 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"web-panel-go/internal/config"
 	"web-panel-go/internal/logger"
 	"web-panel-go/internal/model"
 
@@ -18,14 +26,76 @@ import (
 	"gorm.io/gorm"
 )
 
+// confirmationTTL 危险操作确认令牌有效期
+const confirmationTTL = 60 * time.Second
+
 // SystemService 系统服务
 type SystemService struct {
-	db *gorm.DB
+	db            *gorm.DB
+	confirmations *ConfirmationStore
+	cpuSampler    *cpuSampler
+	overviewSlots chan struct{}                      // 同时进行的后台系统统计采集名额，包括超时后仍未结束的采集
+	collect       func() (*model.SystemStats, error) // CollectOverview 使用的采集函数，默认为 GetSystemOverview
+	logDir        string                             // 面板日志目录
+	startedAt     time.Time                          // 服务启动时间，带单调时钟读数，计算运行时长不受系统时间调整影响
 }
 
 // NewSystemService 创建系统服务实例
-func NewSystemService(db *gorm.DB) *SystemService {
-	return &SystemService{db: db}
+func NewSystemService(db *gorm.DB, cfg *config.Config) *SystemService {
+	slots := cfg.Monitoring.MaxConcurrentCollections
+	if slots < 1 {
+		slots = 1
+	}
+	logDir := cfg.System.LogDir
+	if logDir == "" {
+		logDir = "logs"
+	}
+	s := &SystemService{
+		db:            db,
+		confirmations: NewConfirmationStore(confirmationTTL, cfg.Security.ConfirmActions),
+		cpuSampler:    newCPUSampler(),
+		overviewSlots: make(chan struct{}, slots),
+		logDir:        logDir,
+		startedAt:     time.Now(),
+	}
+	s.collect = s.GetSystemOverview
+	return s
+}
+
+// ErrOverviewBusy 之前的系统统计采集仍未结束且名额已用尽
+var ErrOverviewBusy = errors.New("上一次系统统计采集尚未完成")
+
+// CollectOverview 在 ctx 期限内采集系统概览，供定时广播使用
+// 超时后不再等待，采集协程结束前继续占用名额；名额用尽时直接返回 ErrOverviewBusy，避免卡住的采集不断堆积
+func (s *SystemService) CollectOverview(ctx context.Context) (*model.SystemStats, error) {
+	select {
+	case s.overviewSlots <- struct{}{}:
+	default:
+		return nil, ErrOverviewBusy
+	}
+
+	type result struct {
+		stats *model.SystemStats
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-s.overviewSlots }()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- result{err: fmt.Errorf("采集系统统计panic: %v", recovered)}
+			}
+		}()
+		stats, err := s.collect()
+		done <- result{stats: stats, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.stats, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("采集系统统计超时: %w", ctx.Err())
+	}
 }
 
 // GetSystemOverview 获取系统概览信息
@@ -152,6 +222,60 @@ func (s *SystemService) getDiskStats() (model.DiskStats, error) {
 	}, nil
 }
 
+// pseudoFilesystems 不对应实际存储的文件系统类型，默认不列出
+var pseudoFilesystems = map[string]bool{
+	"tmpfs": true, "devtmpfs": true, "devfs": true, "proc": true, "sysfs": true, "cgroup": true, "cgroup2": true,
+	"overlay": true, "squashfs": true, "autofs": true, "mqueue": true, "debugfs": true, "tracefs": true,
+	"securityfs": true, "pstore": true, "bpf": true, "configfs": true, "fusectl": true, "hugetlbfs": true,
+	"binfmt_misc": true, "devpts": true, "nsfs": true, "rpc_pipefs": true, "ramfs": true, "efivarfs": true,
+}
+
+// GetDiskPartitions 获取各挂载点的磁盘使用情况，includeAll 为false时跳过伪文件系统
+// 同一挂载点只返回一次，无法读取使用情况的挂载点（如无权限）被跳过
+func (s *SystemService) GetDiskPartitions(includeAll bool) ([]model.DiskPartition, error) {
+	partitions, err := disk.Partitions(includeAll)
+	if err != nil {
+		return nil, fmt.Errorf("获取磁盘分区失败: %w", err)
+	}
+
+	result := make([]model.DiskPartition, 0, len(partitions))
+	seen := make(map[string]bool, len(partitions))
+	for _, partition := range partitions {
+		if seen[partition.Mountpoint] {
+			continue
+		}
+		if !includeAll && pseudoFilesystems[partition.Fstype] {
+			continue
+		}
+
+		usage, err := disk.Usage(partition.Mountpoint)
+		if err != nil {
+			logger.Debug("获取挂载点使用情况失败", "mountpoint", partition.Mountpoint, "error", err)
+			continue
+		}
+		// 容量为0的挂载点没有实际存储
+		if usage.Total == 0 && !includeAll {
+			continue
+		}
+		seen[partition.Mountpoint] = true
+
+		result = append(result, model.DiskPartition{
+			Device:      partition.Device,
+			Mountpoint:  partition.Mountpoint,
+			Fstype:      partition.Fstype,
+			Total:       usage.Total,
+			Used:        usage.Used,
+			Free:        usage.Free,
+			UsedPercent: usage.UsedPercent,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Mountpoint < result[j].Mountpoint
+	})
+	return result, nil
+}
+
 // getLoadStats 获取系统负载信息
 func (s *SystemService) getLoadStats() (model.LoadStats, error) {
 	loadAvg, err := load.Avg()
@@ -175,6 +299,28 @@ func (s *SystemService) getUptime() (int64, error) {
 	return int64(hostInfo.Uptime), nil
 }
 
+// GetServerTime 获取服务器当前时间、时区和服务运行时长，供客户端校正时钟偏差
+func (s *SystemService) GetServerTime() *model.ServerTime {
+	now := time.Now()
+	zone, offset := now.Zone()
+
+	// 未设置TZ时 time.Local 的名称为 "Local"，此时只能给出时区缩写
+	timezone := time.Local.String()
+	if timezone == "Local" {
+		timezone = zone
+	}
+
+	return &model.ServerTime{
+		Time:      now,
+		UnixMilli: now.UnixMilli(),
+		Timezone:  timezone,
+		Zone:      zone,
+		UTCOffset: offset,
+		StartedAt: s.startedAt,
+		Uptime:    int64(time.Since(s.startedAt).Seconds()),
+	}
+}
+
 // GetNetworkStats 获取网络统计信息
 func (s *SystemService) GetNetworkStats() ([]model.NetworkStats, error) {
 	ioCounters, err := net.IOCounters(true)
@@ -195,23 +341,34 @@ func (s *SystemService) GetNetworkStats() ([]model.NetworkStats, error) {
 	return stats, nil
 }
 
-// GetProcessList 获取进程列表
-func (s *SystemService) GetProcessList(page, pageSize int) ([]model.ProcessInfo, int64, error) {
+// GetProcessList 获取进程列表，fields 为nil时采集全部字段，否则跳过未请求字段的系统调用
+func (s *SystemService) GetProcessList(page, pageSize int, fields ProcessFields) ([]model.ProcessInfo, int64, error) {
 	// 获取所有进程
 	processes, err := process.Processes()
 	if err != nil {
 		return nil, 0, fmt.Errorf("获取进程列表失败: %w", err)
 	}
 
+	// 没有近期CPU采样时先预采样，保证CPU使用率反映的是近期负载
+	withCPU := fields.Has(ProcessFieldCPUPercent)
+	if withCPU {
+		s.cpuSampler.prime(processes)
+	}
+
 	var processInfos []model.ProcessInfo
+	alive := make(map[int32]bool, len(processes))
 	for _, p := range processes {
-		processInfo, err := s.getProcessInfo(p)
+		alive[p.Pid] = true
+		processInfo, err := s.getProcessInfo(p, fields)
 		if err != nil {
 			// 跳过无法获取信息的进程
 			continue
 		}
 		processInfos = append(processInfos, *processInfo)
 	}
+	if withCPU {
+		s.cpuSampler.sweep(alive)
+	}
 
 	// 计算分页
 	total := int64(len(processInfos))
@@ -228,92 +385,307 @@ func (s *SystemService) GetProcessList(page, pageSize int) ([]model.ProcessInfo,
 	return processInfos[start:end], total, nil
 }
 
-// getProcessInfo 获取单个进程信息
-func (s *SystemService) getProcessInfo(p *process.Process) (*model.ProcessInfo, error) {
-	pid := p.Pid
+// 进程排序方式
+const (
+	ProcessSortCPU    = "cpu"
+	ProcessSortMemory = "memory"
+)
 
-	name, err := p.Name()
-	if err != nil {
-		name = "Unknown"
+// ErrInvalidProcessSort 不支持的进程排序方式
+var ErrInvalidProcessSort = errors.New("无效的进程排序方式")
+
+// GetTopProcesses 获取按CPU或内存使用率从高到低排序的前 n 个进程（n<=0 表示全部）及进程总数
+// 先只采集排序需要的字段，其余字段只为保留下来的进程采集，避免每次为上千个进程做完整的系统调用
+func (s *SystemService) GetTopProcesses(n int, sortBy string) ([]model.ProcessInfo, int64, error) {
+	var usage func(info *model.ProcessInfo) float64
+	switch sortBy {
+	case ProcessSortCPU:
+		usage = func(info *model.ProcessInfo) float64 { return info.CPUPercent }
+	case ProcessSortMemory:
+		usage = func(info *model.ProcessInfo) float64 { return info.MemoryMB }
+	default:
+		return nil, 0, ErrInvalidProcessSort
 	}
 
-	cmdline, err := p.Cmdline()
+	processes, err := process.Processes()
 	if err != nil {
-		cmdline = ""
+		return nil, 0, fmt.Errorf("获取进程列表失败: %w", err)
 	}
+	s.cpuSampler.prime(processes)
 
-	statusSlice, err := p.Status()
-	status := "Unknown"
-	if err == nil && len(statusSlice) > 0 {
-		status = statusSlice[0]
+	type candidate struct {
+		proc *process.Process
+		info *model.ProcessInfo
+	}
+	sortFields := ProcessFields{ProcessFieldPID: true, ProcessFieldCPUPercent: true, ProcessFieldMemoryMB: true}
+	candidates := make([]candidate, 0, len(processes))
+	alive := make(map[int32]bool, len(processes))
+	for _, p := range processes {
+		alive[p.Pid] = true
+		info, err := s.getProcessInfo(p, sortFields)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, candidate{proc: p, info: info})
 	}
+	s.cpuSampler.sweep(alive)
 
-	cpuPercent, err := p.CPUPercent()
-	if err != nil {
-		cpuPercent = 0
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := usage(candidates[i].info), usage(candidates[j].info)
+		if a != b {
+			return a > b
+		}
+		return candidates[i].info.PID < candidates[j].info.PID
+	})
+
+	total := int64(len(candidates))
+	if n > 0 && n < len(candidates) {
+		candidates = candidates[:n]
 	}
 
-	memInfo, err := p.MemoryInfo()
-	memoryMB := 0.0
-	if err == nil {
-		memoryMB = float64(memInfo.RSS) / 1024 / 1024
+	// 补充其余字段，CPU和内存沿用排序时的采样，保证结果顺序与数值一致
+	detailFields := ProcessFields{}
+	for _, name := range processFieldNames {
+		if !sortFields[name] {
+			detailFields[name] = true
+		}
+	}
+	result := make([]model.ProcessInfo, 0, len(candidates))
+	for _, c := range candidates {
+		info, err := s.getProcessInfo(c.proc, detailFields)
+		if err != nil {
+			continue
+		}
+		info.CPUPercent = c.info.CPUPercent
+		info.MemoryMB = c.info.MemoryMB
+		result = append(result, *info)
 	}
 
-	createTime, err := p.CreateTime()
-	var createTimeObj time.Time
-	if err == nil {
-		createTimeObj = time.Unix(createTime/1000, 0)
+	return result, total, nil
+}
+
+// getProcessInfo 获取单个进程信息，只采集 fields 中请求的字段
+func (s *SystemService) getProcessInfo(p *process.Process, fields ProcessFields) (*model.ProcessInfo, error) {
+	info := &model.ProcessInfo{
+		PID:       p.Pid,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if fields.Has(ProcessFieldName) {
+		name, err := p.Name()
+		if err != nil {
+			name = "Unknown"
+		}
+		info.Name = name
+	}
+
+	if fields.Has(ProcessFieldCmdline) {
+		cmdline, err := p.Cmdline()
+		if err != nil {
+			cmdline = ""
+		}
+		info.Cmdline = cmdline
+	}
+
+	if fields.Has(ProcessFieldStatus) {
+		info.Status = "Unknown"
+		if statusSlice, err := p.Status(); err == nil && len(statusSlice) > 0 {
+			info.Status = statusSlice[0]
+		}
+	}
+
+	if fields.Has(ProcessFieldCPUPercent) {
+		info.CPUPercent = s.cpuSampler.percent(p)
 	}
 
-	username, err := p.Username()
+	if fields.Has(ProcessFieldMemoryMB) {
+		if memInfo, err := p.MemoryInfo(); err == nil {
+			info.MemoryMB = float64(memInfo.RSS) / 1024 / 1024
+		}
+	}
+
+	if fields.Has(ProcessFieldCreateTime) {
+		if createTime, err := p.CreateTime(); err == nil {
+			info.CreateTime = time.Unix(createTime/1000, 0)
+		}
+	}
+
+	if fields.Has(ProcessFieldUsername) {
+		username, err := p.Username()
+		if err != nil {
+			username = "Unknown"
+		}
+		info.Username = username
+	}
+
+	if fields.Has(ProcessFieldIsRunning) {
+		isRunning, err := p.IsRunning()
+		if err != nil {
+			isRunning = false
+		}
+		info.IsRunning = isRunning
+	}
+
+	return info, nil
+}
+
+// RotateLogs 手动轮转面板日志文件
+func (s *SystemService) RotateLogs(userID uint, clientIP, userAgent string) error {
+	if err := logger.Rotate(); err != nil {
+		s.logAuditAction(userID, "rotate_logs", "system", fmt.Sprintf("轮转日志失败: %v", err), clientIP, userAgent, "failed")
+		return err
+	}
+
+	s.logAuditAction(userID, "rotate_logs", "system", "手动轮转日志", clientIP, userAgent, "success")
+	logger.Info("日志已手动轮转", "user_id", userID)
+	return nil
+}
+
+// SetLogRetention 运行时修改日志轮转的保留设置，重启后恢复为配置文件中的设置
+func (s *SystemService) SetLogRetention(req *model.SetLogRetentionRequest, userID uint, clientIP, userAgent string) (logger.Settings, error) {
+	previous := logger.CurrentSettings()
+	settings, err := logger.SetRetention(req.MaxSize, req.MaxBackups, req.MaxAge)
 	if err != nil {
-		username = "Unknown"
+		s.logAuditAction(userID, "set_log_retention", "system", fmt.Sprintf("修改日志保留设置失败: %v", err), clientIP, userAgent, "failed")
+		return logger.Settings{}, err
+	}
+
+	s.logAuditAction(userID, "set_log_retention", "system", fmt.Sprintf("修改日志保留设置: 大小 %dMB -> %dMB, 备份数 %d -> %d, 保留天数 %d -> %d",
+		previous.MaxSize, settings.MaxSize, previous.MaxBackups, settings.MaxBackups, previous.MaxAge, settings.MaxAge), clientIP, userAgent, "success")
+	logger.Info("日志保留设置已修改", "max_size", settings.MaxSize, "max_backups", settings.MaxBackups, "max_age", settings.MaxAge, "user_id", userID)
+	return settings, nil
+}
+
+// SetLogLevel 运行时修改面板日志级别，重启后恢复为配置文件中的级别
+func (s *SystemService) SetLogLevel(level string, userID uint, clientIP, userAgent string) error {
+	previous := logger.CurrentSettings().Level
+	if err := logger.SetLevel(level); err != nil {
+		return fmt.Errorf("无效的日志级别: %s", level)
+	}
+
+	s.logAuditAction(userID, "set_log_level", "system", fmt.Sprintf("修改日志级别: %s -> %s", previous, level), clientIP, userAgent, "success")
+	logger.Warn("日志级别已修改", "from", previous, "to", level, "user_id", userID)
+	return nil
+}
+
+var (
+	// ErrInvalidSignal 不支持的信号名
+	ErrInvalidSignal = errors.New("不支持的信号")
+	// ErrProtectedProcess 不允许向 init 进程和面板自身发送信号
+	ErrProtectedProcess = errors.New("不允许向该进程发送信号")
+)
+
+// parseSignal 解析信号名，忽略大小写，可带 SIG 前缀，返回规范的信号名
+func parseSignal(signal string) (string, syscall.Signal, error) {
+	name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(signal)), "SIG")
+	sig, ok := processSignals[name]
+	if !ok {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidSignal, signal)
 	}
+	return name, sig, nil
+}
+
+// IsKillSignal 判断信号名是否为 KILL，KILL 与终止进程接口走同一确认流程
+func IsKillSignal(signal string) bool {
+	_, sig, err := parseSignal(signal)
+	return err == nil && sig == syscall.SIGKILL
+}
 
-	isRunning, err := p.IsRunning()
+// SendSignal 向进程发送信号，不允许向 PID 1 和面板自身发送；KILL 等同于 KillProcess
+func (s *SystemService) SendSignal(pid int32, signal string, userID uint, clientIP, userAgent string) error {
+	name, sig, err := parseSignal(signal)
 	if err != nil {
-		isRunning = false
-	}
-
-	return &model.ProcessInfo{
-		PID:         pid,
-		Name:        name,
-		Cmdline:     cmdline,
-		Status:      status,
-		CPUPercent:  cpuPercent,
-		MemoryMB:    memoryMB,
-		CreateTime:  createTimeObj,
-		Username:    username,
-		IsRunning:   isRunning,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}, nil
+		return err
+	}
+	if sig == syscall.SIGKILL {
+		return s.KillProcess(pid, userID, clientIP, userAgent)
+	}
+	return s.sendSignal(pid, name, sig, userID, clientIP, userAgent)
 }
 
-// KillProcess 终止进程
-func (s *SystemService) KillProcess(pid int32, userID uint, clientIP, userAgent string) error {
+// sendSignal 发送已解析的信号；KILL 记为 kill_process，与 kill_process_request/kill_process_confirm 对应
+func (s *SystemService) sendSignal(pid int32, name string, sig syscall.Signal, userID uint, clientIP, userAgent string) error {
+	action := "signal_process"
+	if sig == syscall.SIGKILL {
+		action = "kill_process"
+	}
+	if pid == 1 || int(pid) == os.Getpid() {
+		s.logAuditAction(userID, action, "process", fmt.Sprintf("拒绝发送信号: PID=%d, Signal=SIG%s", pid, name), clientIP, userAgent, "failed")
+		return ErrProtectedProcess
+	}
+
 	p, err := process.NewProcess(pid)
 	if err != nil {
 		return fmt.Errorf("进程不存在: %w", err)
 	}
 
 	// 获取进程名称用于日志
-	name, _ := p.Name()
+	processName, _ := p.Name()
 
-	// 终止进程
-	if err := p.Kill(); err != nil {
-		// 记录失败的审计日志
-		s.logAuditAction(userID, "kill_process", "process", fmt.Sprintf("终止进程失败: PID=%d, Name=%s", pid, name), clientIP, userAgent, "failed")
-		return fmt.Errorf("终止进程失败: %w", err)
+	// KILL 走 Kill()，在不支持任意信号的平台上也能终止进程
+	if sig == syscall.SIGKILL {
+		err = p.Kill()
+	} else {
+		err = p.SendSignal(sig)
+	}
+	if err != nil {
+		s.logAuditAction(userID, action, "process", fmt.Sprintf("发送信号失败: PID=%d, Name=%s, Signal=SIG%s", pid, processName, name), clientIP, userAgent, "failed")
+		return fmt.Errorf("发送信号失败: %w", err)
 	}
 
-	// 记录成功的审计日志
-	s.logAuditAction(userID, "kill_process", "process", fmt.Sprintf("终止进程: PID=%d, Name=%s", pid, name), clientIP, userAgent, "success")
+	s.logAuditAction(userID, action, "process", fmt.Sprintf("发送信号: PID=%d, Name=%s, Signal=SIG%s", pid, processName, name), clientIP, userAgent, "success")
 
-	logger.Info("进程已终止", "pid", pid, "name", name, "user_id", userID)
+	logger.Info("已向进程发送信号", "pid", pid, "name", processName, "signal", name, "user_id", userID)
 	return nil
 }
 
+// KillProcess 终止进程（发送 SIGKILL）
+// kill_process 配置为必须二次确认时拒绝执行，只能通过 ConfirmKillProcess 凭令牌终止
+func (s *SystemService) KillProcess(pid int32, userID uint, clientIP, userAgent string) error {
+	if s.confirmations.Required("kill_process") {
+		s.logAuditAction(userID, "kill_process", "process", fmt.Sprintf("拒绝终止进程: PID=%d, 未携带确认令牌", pid), clientIP, userAgent, "failed")
+		return ErrConfirmationRequired
+	}
+	return s.sendSignal(pid, "KILL", syscall.SIGKILL, userID, clientIP, userAgent)
+}
+
+// RequestKillConfirmation 申请终止进程的确认令牌
+func (s *SystemService) RequestKillConfirmation(pid int32, userID uint, clientIP, userAgent string) (*model.ConfirmationResponse, error) {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return nil, fmt.Errorf("进程不存在: %w", err)
+	}
+	name, _ := p.Name()
+
+	description := fmt.Sprintf("将终止 PID %d (%s)", pid, name)
+	token, expiresAt, err := s.confirmations.Issue(userID, "kill_process", strconv.Itoa(int(pid)), description)
+	if err != nil {
+		return nil, fmt.Errorf("生成确认令牌失败: %w", err)
+	}
+
+	s.logAuditAction(userID, "kill_process_request", "process", description, clientIP, userAgent, "pending")
+
+	return &model.ConfirmationResponse{
+		ConfirmToken: token,
+		Action:       "kill_process",
+		Description:  description,
+		ExpiresAt:    expiresAt.Unix(),
+	}, nil
+}
+
+// ConfirmKillProcess 凭确认令牌终止进程
+func (s *SystemService) ConfirmKillProcess(pid int32, token string, userID uint, clientIP, userAgent string) error {
+	description, err := s.confirmations.Consume(token, userID, "kill_process", strconv.Itoa(int(pid)))
+	if err != nil {
+		s.logAuditAction(userID, "kill_process_confirm", "process", fmt.Sprintf("确认终止进程失败: PID=%d, 令牌无效", pid), clientIP, userAgent, "failed")
+		return err
+	}
+
+	s.logAuditAction(userID, "kill_process_confirm", "process", description, clientIP, userAgent, "success")
+	return s.sendSignal(pid, "KILL", syscall.SIGKILL, userID, clientIP, userAgent)
+}
+
 // GetHostInfo 获取主机信息
 func (s *SystemService) GetHostInfo() (map[string]interface{}, error) {
 	hostInfo, err := host.Info()
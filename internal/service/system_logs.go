@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"web-panel-go/internal/model"
+)
+
+// 日志文件下载错误
+var (
+	ErrInvalidLogName  = errors.New("无效的日志文件名")
+	ErrLogFileNotFound = errors.New("日志文件不存在")
+)
+
+// 面板日志文件名：当前文件为 app.log，lumberjack 轮转的备份为 app-<时间>.log，压缩后追加 .gz
+const (
+	logFileName   = "app.log"
+	logBackupHead = "app-"
+	logBackupTail = ".log"
+)
+
+// isPanelLogFile 判断文件名是否为面板日志或其轮转备份
+func isPanelLogFile(name string) bool {
+	if name == logFileName {
+		return true
+	}
+	name = strings.TrimSuffix(name, ".gz")
+	return strings.HasPrefix(name, logBackupHead) && strings.HasSuffix(name, logBackupTail)
+}
+
+// ListLogFiles 列出日志目录中的面板日志及其轮转备份，按修改时间倒序
+func (s *SystemService) ListLogFiles() ([]model.LogFileInfo, error) {
+	entries, err := os.ReadDir(s.logDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []model.LogFileInfo{}, nil
+		}
+		return nil, fmt.Errorf("读取日志目录失败: %w", err)
+	}
+
+	files := make([]model.LogFileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isPanelLogFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, model.LogFileInfo{
+			Name:       entry.Name(),
+			Size:       info.Size(),
+			ModTime:    info.ModTime(),
+			Current:    entry.Name() == logFileName,
+			Compressed: strings.HasSuffix(entry.Name(), ".gz"),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	return files, nil
+}
+
+// PrepareLogDownload 校验日志文件名并返回文件路径，同时记录审计日志（日志中可能包含敏感信息）
+// 文件名只能是日志目录下面板日志文件的文件名，不允许包含路径
+func (s *SystemService) PrepareLogDownload(name string, userID uint, clientIP, userAgent string) (string, error) {
+	if name == "" || filepath.Base(name) != name || strings.ContainsAny(name, `/\`) || !isPanelLogFile(name) {
+		s.logAuditAction(userID, "download_log", "system", fmt.Sprintf("下载日志被拒绝: 无效的文件名 %q", name), clientIP, userAgent, "failed")
+		return "", ErrInvalidLogName
+	}
+
+	path := filepath.Join(s.logDir, name)
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrLogFileNotFound
+		}
+		return "", fmt.Errorf("读取日志文件失败: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", ErrLogFileNotFound
+	}
+
+	s.logAuditAction(userID, "download_log", "system", fmt.Sprintf("下载日志文件: %s (%d 字节)", name, info.Size()), clientIP, userAgent, "success")
+	return path, nil
+}
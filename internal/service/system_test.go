@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/model"
+)
+
+func TestCollectOverviewDoesNotWaitForHangingCollector(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Monitoring.MaxConcurrentCollections = 1
+	s := NewSystemService(newTestDB(t), cfg)
+
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	s.collect = func() (*model.SystemStats, error) {
+		defer close(finished)
+		<-release
+		return &model.SystemStats{}, nil
+	}
+
+	// 采集卡住时按期限返回，不等待采集结束
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := s.CollectOverview(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("期望超时错误，实际 %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("卡住的采集阻塞了 %v", elapsed)
+	}
+
+	// 卡住的采集仍占用名额，下一次立即跳过而不是再启动一个
+	if _, err := s.CollectOverview(context.Background()); !errors.Is(err, ErrOverviewBusy) {
+		t.Fatalf("期望 ErrOverviewBusy，实际 %v", err)
+	}
+
+	// 卡住的采集结束后名额释放，后续采集恢复正常
+	close(release)
+	<-finished
+	want := &model.SystemStats{Uptime: 42}
+	s.collect = func() (*model.SystemStats, error) { return want, nil }
+	var stats *model.SystemStats
+	var err error
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if stats, err = s.CollectOverview(context.Background()); !errors.Is(err, ErrOverviewBusy) {
+			break
+		}
+	}
+	if err != nil || stats != want {
+		t.Fatalf("恢复后采集结果 %v, %v", stats, err)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// 文本编辑支持的文件编码
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF8BOM = "utf-8-bom"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "iso-8859-1"
+	EncodingBinary  = "binary"
+)
+
+// sniffLength 判断文本/二进制时检查的前缀长度
+const sniffLength = 8 * 1024
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// ErrUnsupportedEncoding 不支持的文件编码
+var ErrUnsupportedEncoding = errors.New("不支持的文件编码")
+
+// ErrUnencodableContent 内容包含目标编码无法表示的字符
+var ErrUnencodableContent = errors.New("内容包含目标编码无法表示的字符")
+
+// detectEncoding 检测文件内容编码
+// 有BOM时按BOM判断；否则检查前8KB，含NUL或控制字符视为二进制，
+// 整体是合法UTF-8时为utf-8，其余按Latin-1处理
+func detectEncoding(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		return EncodingUTF8BOM
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return EncodingUTF16BE
+	}
+
+	sample := content
+	if len(sample) > sniffLength {
+		sample = sample[:sniffLength]
+	}
+	if looksBinary(sample) {
+		return EncodingBinary
+	}
+	if utf8.Valid(content) {
+		return EncodingUTF8
+	}
+	return EncodingLatin1
+}
+
+// looksBinary 样本中出现NUL或除常见空白、ESC以外的C0控制字符时视为二进制
+func looksBinary(sample []byte) bool {
+	for _, b := range sample {
+		if b >= 0x20 || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == 0x1B {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// decodeText 将指定编码的内容转换为UTF-8字符串
+func decodeText(content []byte, encoding string) (string, error) {
+	switch encoding {
+	case EncodingUTF8:
+		return string(content), nil
+	case EncodingUTF8BOM:
+		return string(bytes.TrimPrefix(content, bomUTF8)), nil
+	case EncodingUTF16LE, EncodingUTF16BE:
+		var order binary.ByteOrder = binary.LittleEndian
+		bom := bomUTF16LE
+		if encoding == EncodingUTF16BE {
+			order, bom = binary.BigEndian, bomUTF16BE
+		}
+		content = bytes.TrimPrefix(content, bom)
+		if len(content)%2 != 0 {
+			return "", fmt.Errorf("UTF-16内容长度不完整")
+		}
+		units := make([]uint16, len(content)/2)
+		for i := range units {
+			units[i] = order.Uint16(content[2*i:])
+		}
+		return string(utf16.Decode(units)), nil
+	case EncodingLatin1:
+		var sb strings.Builder
+		sb.Grow(len(content))
+		for _, b := range content {
+			sb.WriteRune(rune(b))
+		}
+		return sb.String(), nil
+	default:
+		return "", ErrUnsupportedEncoding
+	}
+}
+
+// encodeText 将UTF-8字符串按指定编码写回，带BOM的编码会重新写入BOM
+func encodeText(text, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", EncodingUTF8:
+		return []byte(text), nil
+	case EncodingUTF8BOM:
+		return append(append([]byte{}, bomUTF8...), text...), nil
+	case EncodingUTF16LE, EncodingUTF16BE:
+		var order binary.AppendByteOrder = binary.LittleEndian
+		bom := bomUTF16LE
+		if encoding == EncodingUTF16BE {
+			order, bom = binary.BigEndian, bomUTF16BE
+		}
+		units := utf16.Encode([]rune(text))
+		out := make([]byte, len(bom), len(bom)+2*len(units))
+		copy(out, bom)
+		for _, u := range units {
+			out = order.AppendUint16(out, u)
+		}
+		return out, nil
+	case EncodingLatin1:
+		out := make([]byte, 0, len(text))
+		for _, r := range text {
+			if r > 0xFF {
+				return nil, ErrUnencodableContent
+			}
+			out = append(out, byte(r))
+		}
+		return out, nil
+	default:
+		return nil, ErrUnsupportedEncoding
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"空文件", nil, EncodingUTF8},
+		{"ASCII", []byte("hello\r\n\tworld\n"), EncodingUTF8},
+		{"UTF-8", []byte("你好，世界"), EncodingUTF8},
+		{"UTF-8 BOM", append([]byte{0xEF, 0xBB, 0xBF}, "hi"...), EncodingUTF8BOM},
+		{"UTF-16LE BOM", []byte{0xFF, 0xFE, 'h', 0, 'i', 0}, EncodingUTF16LE},
+		{"UTF-16BE BOM", []byte{0xFE, 0xFF, 0, 'h', 0, 'i'}, EncodingUTF16BE},
+		{"Latin-1", []byte("caf\xe9 cr\xe8me"), EncodingLatin1},
+		{"NUL", []byte("abc\x00def"), EncodingBinary},
+		{"控制字符", []byte("abc\x01def"), EncodingBinary},
+		{"ESC序列不是二进制", []byte("\x1b[31mred\x1b[0m"), EncodingUTF8},
+		{"PNG头", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), EncodingBinary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectEncoding(tt.content); got != tt.want {
+				t.Fatalf("detectEncoding = %q, 期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectEncodingOnlySniffsPrefix(t *testing.T) {
+	// 二进制判断只看前8KB，之后的控制字符不影响结果
+	content := append(bytes.Repeat([]byte("a"), sniffLength), 0x00)
+	if got := detectEncoding(content); got != EncodingUTF8 {
+		t.Fatalf("detectEncoding = %q, 期望 %q", got, EncodingUTF8)
+	}
+}
+
+func TestTextEncodingRoundTrip(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		encoded  []byte
+	}{
+		{EncodingUTF8, "héllo", []byte("héllo")},
+		{EncodingUTF8BOM, "hi", []byte{0xEF, 0xBB, 0xBF, 'h', 'i'}},
+		{EncodingUTF16LE, "h€", []byte{0xFF, 0xFE, 'h', 0x00, 0xAC, 0x20}},
+		{EncodingUTF16BE, "h€", []byte{0xFE, 0xFF, 0x00, 'h', 0x20, 0xAC}},
+		{EncodingUTF16LE, "😀", []byte{0xFF, 0xFE, 0x3D, 0xD8, 0x00, 0xDE}},
+		{EncodingLatin1, "café", []byte("caf\xe9")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding+"/"+tt.text, func(t *testing.T) {
+			encoded, err := encodeText(tt.text, tt.encoding)
+			if err != nil {
+				t.Fatalf("encodeText 失败: %v", err)
+			}
+			if !bytes.Equal(encoded, tt.encoded) {
+				t.Fatalf("encodeText = % x, 期望 % x", encoded, tt.encoded)
+			}
+			if got := detectEncoding(encoded); got != tt.encoding {
+				t.Fatalf("detectEncoding = %q, 期望 %q", got, tt.encoding)
+			}
+			decoded, err := decodeText(encoded, tt.encoding)
+			if err != nil {
+				t.Fatalf("decodeText 失败: %v", err)
+			}
+			if decoded != tt.text {
+				t.Fatalf("decodeText = %q, 期望 %q", decoded, tt.text)
+			}
+		})
+	}
+}
+
+func TestEncodeTextErrors(t *testing.T) {
+	if _, err := encodeText("价格 €", EncodingLatin1); !errors.Is(err, ErrUnencodableContent) {
+		t.Fatalf("Latin-1 编码非Latin-1字符返回 %v, 期望 ErrUnencodableContent", err)
+	}
+	if _, err := encodeText("x", "gbk"); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Fatalf("不支持的编码返回 %v, 期望 ErrUnsupportedEncoding", err)
+	}
+	if _, err := decodeText([]byte("x"), "gbk"); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Fatalf("不支持的编码返回 %v, 期望 ErrUnsupportedEncoding", err)
+	}
+}
+
+func TestSaveFileContentKeepsEncoding(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+
+	tests := []struct {
+		name     string
+		original []byte
+		encoding string
+	}{
+		{"utf16le.txt", []byte{0xFF, 0xFE, 'a', 0x00, '\n', 0x00}, EncodingUTF16LE},
+		{"utf16be.txt", []byte{0xFE, 0xFF, 0x00, 'a', 0x00, '\n'}, EncodingUTF16BE},
+		{"latin1.txt", []byte("caf\xe9\n"), EncodingLatin1},
+		{"bom.txt", []byte("\xEF\xBB\xBFa\n"), EncodingUTF8BOM},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(root, tt.name)
+			if err := os.WriteFile(path, tt.original, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			read, err := f.GetFileContent(path, admin.ID, "127.0.0.1", "test")
+			if err != nil {
+				t.Fatalf("读取文件失败: %v", err)
+			}
+			if read.Encoding != tt.encoding || read.IsBinary {
+				t.Fatalf("读取编码 = %q (binary=%v), 期望 %q", read.Encoding, read.IsBinary, tt.encoding)
+			}
+
+			// 编辑后按读取时的版本号保存，不指定编码时沿用原编码
+			edited := read.Content + "é\n"
+			if _, err := f.SaveFileContent(path, edited, read.Version, "", false, admin.ID, "127.0.0.1", "test"); err != nil {
+				t.Fatalf("保存文件失败: %v", err)
+			}
+
+			reread, err := f.GetFileContent(path, admin.ID, "127.0.0.1", "test")
+			if err != nil {
+				t.Fatalf("重新读取文件失败: %v", err)
+			}
+			if reread.Encoding != tt.encoding || reread.Content != edited {
+				t.Fatalf("保存后编码 = %q 内容 = %q, 期望 %q %q", reread.Encoding, reread.Content, tt.encoding, edited)
+			}
+		})
+	}
+}
+
+func TestGetFileContentBinary(t *testing.T) {
+	f, root, admin := newTestFileService(t)
+	path := filepath.Join(root, "blob.bin")
+	if err := os.WriteFile(path, []byte{0x00, 0x01, 0x02, 0xFF}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := f.GetFileContent(path, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+	if !read.IsBinary || read.Encoding != EncodingBinary || read.Content != "" {
+		t.Fatalf("二进制文件返回 encoding=%q binary=%v content=%q", read.Encoding, read.IsBinary, read.Content)
+	}
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // 注册GIF解码器
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"web-panel-go/internal/logger"
+)
+
+// 缩略图生成错误
+var (
+	ErrNotImage          = errors.New("不是支持的图片格式")
+	ErrThumbnailTooLarge = errors.New("图片过大，无法生成缩略图")
+)
+
+const (
+	// defaultThumbnailSize 未指定宽高时缩略图的边长
+	defaultThumbnailSize = 256
+	// thumbnailJPEGQuality 缩略图JPEG编码质量
+	thumbnailJPEGQuality = 85
+	// thumbnailTouchInterval 命中缓存时更新修改时间的最小间隔，修改时间作为缓存清理时的最近使用时间
+	thumbnailTouchInterval = time.Hour
+)
+
+// thumbnailBuckets 缩略图尺寸档位，请求的宽高向上取整到档位，限制同一张图片最多生成的缓存文件数
+var thumbnailBuckets = []int{64, 128, 256, 512, 1024}
+
+// Thumbnail 生成图片缩略图并返回缓存文件路径，缩略图按比例缩放到 width×height 以内且不放大
+// width、height 向上取整到尺寸档位；缓存按路径、修改时间、大小和尺寸区分，原图修改后自动生成新的缩略图
+// 同时解码的原图数受 file.thumbnail_concurrency 限制，超出时排队等待
+func (f *FileService) Thumbnail(path string, width, height int) (string, error) {
+	if !f.isValidPath(path) {
+		return "", ErrInvalidPath
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", ErrFileNotFound
+	}
+	if info.IsDir() {
+		return "", ErrNotImage
+	}
+	if limit := f.config.File.ThumbnailMaxSourceSize; limit > 0 && info.Size() > limit {
+		return "", ErrThumbnailTooLarge
+	}
+
+	width, height = f.thumbnailBounds(width, height)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	// 先只读取图片头部的尺寸，超过像素上限时不解码
+	cfg, format, err := image.DecodeConfig(file)
+	if err != nil {
+		return "", ErrNotImage
+	}
+	if limit := f.config.File.ThumbnailMaxPixels; limit > 0 && int64(cfg.Width)*int64(cfg.Height) > limit {
+		return "", ErrThumbnailTooLarge
+	}
+
+	// GIF和PNG可能带透明通道，缩略图输出PNG，其他格式输出JPEG
+	ext := ".jpg"
+	if format == "png" || format == "gif" {
+		ext = ".png"
+	}
+	cachePath := filepath.Join(f.config.File.ThumbnailCacheDir, thumbnailCacheKey(path, info, width, height)+ext)
+	if touchThumbnail(cachePath) {
+		return cachePath, nil
+	}
+
+	// 解码整张原图占用大量内存，限制同时解码的数量；等待期间其他请求可能已经生成了同一张缩略图
+	if f.thumbnailSem != nil {
+		f.thumbnailSem <- struct{}{}
+		defer func() { <-f.thumbnailSem }()
+		if touchThumbnail(cachePath) {
+			return cachePath, nil
+		}
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return "", ErrNotImage
+	}
+
+	thumb := resizeImage(src, width, height)
+	if err := writeThumbnail(cachePath, thumb, ext); err != nil {
+		return "", err
+	}
+	return cachePath, nil
+}
+
+// thumbnailBounds 规范化请求的缩略图宽高：未指定时使用默认值，向上取整到尺寸档位，超过上限时截断
+func (f *FileService) thumbnailBounds(width, height int) (int, int) {
+	maxSize := f.config.File.ThumbnailMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultThumbnailSize
+	}
+	clamp := func(v int) int {
+		if v <= 0 {
+			v = defaultThumbnailSize
+		}
+		bucket := maxSize
+		for _, size := range thumbnailBuckets {
+			if v <= size {
+				bucket = size
+				break
+			}
+		}
+		if bucket > maxSize {
+			bucket = maxSize
+		}
+		return bucket
+	}
+	return clamp(width), clamp(height)
+}
+
+// touchThumbnail 判断缓存的缩略图是否存在，存在时更新修改时间记录最近使用
+func touchThumbnail(cachePath string) bool {
+	info, err := os.Stat(cachePath)
+	if err != nil {
+		return false
+	}
+	if now := time.Now(); now.Sub(info.ModTime()) > thumbnailTouchInterval {
+		os.Chtimes(cachePath, now, now)
+	}
+	return true
+}
+
+// PruneThumbnailCache 缩略图缓存超过 file.thumbnail_cache_max_size 时按最近使用时间从旧到新删除，由后台任务定期调用
+func (f *FileService) PruneThumbnailCache() error {
+	limit := f.config.File.ThumbnailCacheMaxSize
+	dir := f.config.File.ThumbnailCacheDir
+	if limit <= 0 || dir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取缩略图缓存目录失败: %w", err)
+	}
+
+	type cachedThumbnail struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var cached []cachedThumbnail
+	var total int64
+	for _, entry := range entries {
+		// 跳过子目录和正在写入的临时文件
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		cached = append(cached, cachedThumbnail{path: filepath.Join(dir, entry.Name()), size: info.Size(), used: info.ModTime()})
+		total += info.Size()
+	}
+	if total <= limit {
+		return nil
+	}
+
+	sort.Slice(cached, func(i, j int) bool { return cached[i].used.Before(cached[j].used) })
+	removed := 0
+	for _, thumb := range cached {
+		if total <= limit {
+			break
+		}
+		if err := os.Remove(thumb.path); err != nil && !os.IsNotExist(err) {
+			logger.Warn("删除缩略图缓存失败", "path", thumb.path, "error", err)
+			continue
+		}
+		total -= thumb.size
+		removed++
+	}
+
+	logger.Info("已清理缩略图缓存", "count", removed, "size", total)
+	return nil
+}
+
+// thumbnailCacheKey 缩略图缓存文件名
+func thumbnailCacheKey(path string, info os.FileInfo, width, height int) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d|%dx%d", absPath, info.ModTime().UnixNano(), info.Size(), width, height)))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeThumbnail 编码缩略图并原子写入缓存，避免并发请求读到写了一半的文件
+func writeThumbnail(cachePath string, img image.Image, ext string) error {
+	dir := filepath.Dir(cachePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建缩略图缓存目录失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".thumb-*")
+	if err != nil {
+		return fmt.Errorf("写入缩略图失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if ext == ".png" {
+		err = png.Encode(tmp, img)
+	} else {
+		err = jpeg.Encode(tmp, img, &jpeg.Options{Quality: thumbnailJPEGQuality})
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入缩略图失败: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), cachePath); err != nil {
+		return fmt.Errorf("写入缩略图失败: %w", err)
+	}
+	return nil
+}
+
+// resizeImage 按比例缩小图片到 maxWidth×maxHeight 以内（不放大），每个目标像素取对应源区域的平均值
+// 逐行把源图转换为RGBA后累加，除原图外只额外占用一行源像素和一行累加值，不复制整张原图
+func resizeImage(src image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if sw <= maxWidth && sh <= maxHeight {
+		return src
+	}
+
+	dw, dh := maxWidth, sh*maxWidth/sw
+	if dh > maxHeight {
+		dw, dh = sw*maxHeight/sh, maxHeight
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	row := image.NewRGBA(image.Rect(0, 0, sw, 1))
+	sums := make([]uint64, dw*4)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, (y+1)*sh/dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		clear(sums)
+		for sy := y0; sy < y1; sy++ {
+			draw.Draw(row, row.Bounds(), src, image.Pt(bounds.Min.X, bounds.Min.Y+sy), draw.Src)
+			for x := 0; x < dw; x++ {
+				x0, x1 := x*sw/dw, (x+1)*sw/dw
+				if x1 <= x0 {
+					x1 = x0 + 1
+				}
+				sum := sums[x*4 : x*4+4]
+				for i := x0 * 4; i < x1*4; i += 4 {
+					sum[0] += uint64(row.Pix[i])
+					sum[1] += uint64(row.Pix[i+1])
+					sum[2] += uint64(row.Pix[i+2])
+					sum[3] += uint64(row.Pix[i+3])
+				}
+			}
+		}
+
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, (x+1)*sw/dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			n := uint64((x1 - x0) * (y1 - y0))
+			off := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[off+c] = uint8(sums[x*4+c] / n)
+			}
+		}
+	}
+	return dst
+}
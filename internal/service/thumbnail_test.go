@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestPNG 在 dir 下写入纯色PNG图片
+func writeTestPNG(t *testing.T, dir, name string, width, height int, c color.Color) string {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	path := filepath.Join(dir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("创建图片失败: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("编码图片失败: %v", err)
+	}
+	return path
+}
+
+func TestResizeImage(t *testing.T) {
+	tests := []struct {
+		name         string
+		src          image.Rectangle
+		maxW, maxH   int
+		wantW, wantH int
+	}{
+		{"横图按宽缩放", image.Rect(0, 0, 400, 200), 100, 100, 100, 50},
+		{"竖图按高缩放", image.Rect(0, 0, 200, 400), 100, 100, 50, 100},
+		{"非零原点", image.Rect(10, 20, 410, 220), 100, 100, 100, 50},
+		{"小图不放大", image.Rect(0, 0, 40, 30), 100, 100, 40, 30},
+		{"极窄图至少一个像素", image.Rect(0, 0, 1000, 2), 100, 100, 100, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resizeImage(image.NewRGBA(tt.src), tt.maxW, tt.maxH).Bounds()
+			if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+				t.Fatalf("缩放后 %dx%d，期望 %dx%d", got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestResizeImageAveragesPixels(t *testing.T) {
+	// 左半黑右半白，缩成 1×1 后应为中灰
+	src := image.NewGray(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		src.SetGray(2, y, color.Gray{Y: 255})
+		src.SetGray(3, y, color.Gray{Y: 255})
+	}
+	r, g, b, _ := resizeImage(src, 1, 1).At(0, 0).RGBA()
+	for _, v := range []uint32{r >> 8, g >> 8, b >> 8} {
+		if v < 126 || v > 128 {
+			t.Fatalf("平均颜色 = %d，期望约 127", v)
+		}
+	}
+}
+
+func TestThumbnailBounds(t *testing.T) {
+	f, _, _ := newTestFileService(t)
+	f.config.File.ThumbnailMaxSize = 1024
+
+	tests := []struct{ in, want int }{
+		{0, defaultThumbnailSize},
+		{1, 64},
+		{64, 64},
+		{65, 128},
+		{300, 512},
+		{1024, 1024},
+		{5000, 1024},
+	}
+	for _, tt := range tests {
+		if got, _ := f.thumbnailBounds(tt.in, tt.in); got != tt.want {
+			t.Errorf("thumbnailBounds(%d) = %d，期望 %d", tt.in, got, tt.want)
+		}
+	}
+
+	// 上限不是档位时超出最大档位的尺寸取上限
+	f.config.File.ThumbnailMaxSize = 300
+	if got, _ := f.thumbnailBounds(1000, 1000); got != 300 {
+		t.Errorf("上限 300 时 thumbnailBounds(1000) = %d，期望 300", got)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	path := writeTestPNG(t, root, "photo.png", 800, 400, color.NRGBA{R: 255, A: 255})
+
+	cachePath, err := f.Thumbnail(path, 100, 100)
+	if err != nil {
+		t.Fatalf("生成缩略图失败: %v", err)
+	}
+	file, err := os.Open(cachePath)
+	if err != nil {
+		t.Fatalf("打开缩略图失败: %v", err)
+	}
+	defer file.Close()
+	cfg, format, err := image.DecodeConfig(file)
+	if err != nil {
+		t.Fatalf("解码缩略图失败: %v", err)
+	}
+	// 100 向上取整到 128 档位
+	if format != "png" || cfg.Width != 128 || cfg.Height != 64 {
+		t.Fatalf("缩略图 %s %dx%d，期望 png 128x64", format, cfg.Width, cfg.Height)
+	}
+
+	// 同一档位内的尺寸共用缓存
+	again, err := f.Thumbnail(path, 120, 120)
+	if err != nil || again != cachePath {
+		t.Fatalf("同档位请求返回 %q, %v，期望复用 %q", again, err, cachePath)
+	}
+}
+
+func TestThumbnailRejectsNonImage(t *testing.T) {
+	f, root, _ := newTestFileService(t)
+	text := filepath.Join(root, "notes.png")
+	if err := os.WriteFile(text, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustMkdir(t, filepath.Join(root, "dir"))
+
+	for _, path := range []string{text, filepath.Join(root, "dir")} {
+		if _, err := f.Thumbnail(path, 64, 64); !errors.Is(err, ErrNotImage) {
+			t.Errorf("Thumbnail(%s) 返回 %v，期望 ErrNotImage", filepath.Base(path), err)
+		}
+	}
+
+	entries, _ := os.ReadDir(f.config.File.ThumbnailCacheDir)
+	if len(entries) != 0 {
+		t.Fatalf("非图片文件生成了 %d 个缓存文件", len(entries))
+	}
+}
+
+func TestPruneThumbnailCache(t *testing.T) {
+	f, _, _ := newTestFileService(t)
+	dir := f.config.File.ThumbnailCacheDir
+	f.config.File.ThumbnailCacheMaxSize = 250
+
+	// 三个 100 字节的缓存文件，最近使用时间依次变新
+	now := time.Now()
+	for i, name := range []string{"oldest.jpg", "middle.jpg", "newest.jpg"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+		used := now.Add(time.Duration(i-3) * time.Hour)
+		os.Chtimes(path, used, used)
+	}
+	// 正在写入的临时文件不计入也不删除
+	if err := os.WriteFile(filepath.Join(dir, ".thumb-123"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.PruneThumbnailCache(); err != nil {
+		t.Fatalf("清理缩略图缓存失败: %v", err)
+	}
+	for name, want := range map[string]bool{"oldest.jpg": false, "middle.jpg": true, "newest.jpg": true, ".thumb-123": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s 存在=%v，期望 %v", name, exists, want)
+		}
+	}
+}
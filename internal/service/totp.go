@@ -0,0 +1,283 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// 两步验证错误
+var (
+	ErrTwoFactorRequired = errors.New("需要两步验证码")
+	ErrTwoFactorInvalid  = errors.New("两步验证码错误")
+	ErrTwoFactorEnabled  = errors.New("两步验证已启用")
+	ErrTwoFactorNotSetup = errors.New("请先设置两步验证")
+	ErrTwoFactorDisabled = errors.New("用户未设置两步验证")
+)
+
+const (
+	// totpPeriod TOTP时间步长（秒）
+	totpPeriod = 30
+	// totpSkew 允许前后偏差的时间步数
+	totpSkew = 1
+	// totpSecretBytes 共享密钥的字节数（RFC 4226 推荐160位）
+	totpSecretBytes = 20
+	// recoveryCodeCount 启用两步验证时生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+// totpCodeOpts 验证码算法参数（RFC 6238 默认的6位HMAC-SHA1，与主流验证器应用一致）
+var totpCodeOpts = hotp.ValidateOpts{Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// SetupTwoFactor 为用户生成新的TOTP密钥，需通过 EnableTwoFactor 验证后才生效
+// 已启用两步验证时返回 ErrTwoFactorEnabled；重复调用会替换尚未启用的密钥
+func (s *AuthService) SetupTwoFactor(userID uint, clientIP, userAgent string) (*model.TwoFactorSetupResponse, error) {
+	var user model.User
+	if err := s.db.Select("id", "username").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	userTOTP, err := s.getUserTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if userTOTP == nil {
+		userTOTP = &model.UserTOTP{UserID: userID}
+	} else if userTOTP.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.config.Auth.TOTPIssuer,
+		AccountName: user.Username,
+		Period:      totpPeriod,
+		SecretSize:  totpSecretBytes,
+		Digits:      totpCodeOpts.Digits,
+		Algorithm:   totpCodeOpts.Algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+	userTOTP.Secret = key.Secret()
+	userTOTP.LastUsedStep = 0
+	if err := s.db.Save(userTOTP).Error; err != nil {
+		return nil, fmt.Errorf("保存两步验证配置失败: %w", err)
+	}
+
+	s.logAuditAction(userID, "setup_2fa", "user", "生成两步验证密钥", clientIP, userAgent, "success")
+
+	return &model.TwoFactorSetupResponse{
+		Secret:     userTOTP.Secret,
+		OTPAuthURL: key.URL(),
+	}, nil
+}
+
+// EnableTwoFactor 校验验证码后启用两步验证，返回只显示一次的恢复码
+func (s *AuthService) EnableTwoFactor(userID uint, code, clientIP, userAgent string) (*model.EnableTwoFactorResponse, error) {
+	userTOTP, err := s.getUserTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if userTOTP == nil {
+		return nil, ErrTwoFactorNotSetup
+	}
+	if userTOTP.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := matchTOTP(userTOTP.Secret, code, time.Now())
+	if !ok {
+		s.logAuditAction(userID, "enable_2fa", "user", "启用两步验证失败：验证码错误", clientIP, userAgent, "failed")
+		return nil, ErrTwoFactorInvalid
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	userTOTP.Enabled = true
+	userTOTP.EnabledAt = &now
+	userTOTP.LastUsedStep = step
+	userTOTP.RecoveryCodes = hashes
+	if err := s.db.Save(userTOTP).Error; err != nil {
+		return nil, fmt.Errorf("保存两步验证配置失败: %w", err)
+	}
+
+	s.logAuditAction(userID, "enable_2fa", "user", "启用两步验证", clientIP, userAgent, "success")
+	logger.Info("用户启用两步验证", "user_id", userID)
+
+	return &model.EnableTwoFactorResponse{RecoveryCodes: codes}, nil
+}
+
+// ResetTwoFactor 管理员清除用户的两步验证配置（用户丢失验证器和恢复码时使用）
+// 清除后用户仅凭密码即可登录，可重新设置两步验证
+func (s *AuthService) ResetTwoFactor(userID, operatorID uint, clientIP, userAgent string) error {
+	var user model.User
+	if err := s.db.Select("id", "username").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	result := s.db.Where("user_id = ?", userID).Delete(&model.UserTOTP{})
+	if result.Error != nil {
+		return fmt.Errorf("重置两步验证失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTwoFactorDisabled
+	}
+
+	s.logAuditAction(operatorID, "reset_2fa", "user", fmt.Sprintf("重置用户两步验证: %s", user.Username), clientIP, userAgent, "success")
+	logger.Warn("管理员重置用户两步验证", "username", user.Username, "operator", operatorID)
+	return nil
+}
+
+// verifyTwoFactor 登录时校验两步验证码，未启用两步验证时直接通过
+// 查询失败时返回错误（拒绝登录），code 可以是TOTP验证码或未使用过的恢复码
+func (s *AuthService) verifyTwoFactor(userID uint, code string) error {
+	userTOTP, err := s.getUserTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if userTOTP == nil || !userTOTP.Enabled {
+		return nil
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrTwoFactorRequired
+	}
+
+	if step, ok := matchTOTP(userTOTP.Secret, code, time.Now()); ok {
+		// 条件更新保证同一时间步的验证码只能使用一次
+		result := s.db.Model(&model.UserTOTP{}).
+			Where("id = ? AND last_used_step < ?", userTOTP.ID, step).
+			Update("last_used_step", step)
+		if result.Error != nil {
+			return fmt.Errorf("更新两步验证状态失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrTwoFactorInvalid
+		}
+		return nil
+	}
+
+	return s.useRecoveryCode(userTOTP, code)
+}
+
+// useRecoveryCode 校验并消耗一个恢复码
+func (s *AuthService) useRecoveryCode(userTOTP *model.UserTOTP, code string) error {
+	var hashes []string
+	if userTOTP.RecoveryCodes != "" {
+		if err := json.Unmarshal([]byte(userTOTP.RecoveryCodes), &hashes); err != nil {
+			return fmt.Errorf("解析恢复码失败: %w", err)
+		}
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	for i, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(normalized)) != nil {
+			continue
+		}
+
+		remaining := append(hashes[:i:i], hashes[i+1:]...)
+		data, err := json.Marshal(remaining)
+		if err != nil {
+			return fmt.Errorf("保存恢复码失败: %w", err)
+		}
+		// 以原值为条件更新，并发使用同一个恢复码时只有一个请求成功
+		result := s.db.Model(&model.UserTOTP{}).
+			Where("id = ? AND recovery_codes = ?", userTOTP.ID, userTOTP.RecoveryCodes).
+			Update("recovery_codes", string(data))
+		if result.Error != nil {
+			return fmt.Errorf("保存恢复码失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrTwoFactorInvalid
+		}
+		logger.Warn("用户使用恢复码登录", "user_id", userTOTP.UserID, "remaining", len(remaining))
+		return nil
+	}
+	return ErrTwoFactorInvalid
+}
+
+// getUserTOTP 获取用户的两步验证配置，不存在时返回nil
+func (s *AuthService) getUserTOTP(userID uint) (*model.UserTOTP, error) {
+	var userTOTP model.UserTOTP
+	if err := s.db.Where("user_id = ?", userID).First(&userTOTP).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询两步验证配置失败: %w", err)
+	}
+	return &userTOTP, nil
+}
+
+// matchTOTP 校验验证码，允许前后 totpSkew 个时间步的偏差，返回匹配的时间步
+// 验证码由 hotp 按时间步计算，调用方用返回的时间步防止同一验证码被重复使用
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpCodeOpts.Digits.Length() {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := hotp.GenerateCodeCustom(secret, uint64(step), totpCodeOpts)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// generateRecoveryCodes 生成恢复码，返回明文（展示给用户）和bcrypt哈希的JSON（保存到数据库）
+func generateRecoveryCodes() ([]string, string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, "", fmt.Errorf("生成恢复码失败: %w", err)
+		}
+		raw := hex.EncodeToString(buf)
+		hash, err := bcrypt.GenerateFromPassword([]byte(raw), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, "", fmt.Errorf("生成恢复码失败: %w", err)
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, string(hash))
+	}
+
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, "", fmt.Errorf("生成恢复码失败: %w", err)
+	}
+	return codes, string(data), nil
+}
+
+// normalizeRecoveryCode 去掉恢复码中的分隔符和空白并转为小写
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
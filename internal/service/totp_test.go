@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/model"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// enableTestTwoFactor 为用户设置并启用两步验证，返回密钥、启用时使用的验证码和恢复码
+func enableTestTwoFactor(t *testing.T, s *AuthService, userID uint) (string, string, []string) {
+	t.Helper()
+	setup, err := s.SetupTwoFactor(userID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("设置两步验证失败: %v", err)
+	}
+	code, err := totp.GenerateCode(setup.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.EnableTwoFactor(userID, code, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("启用两步验证失败: %v", err)
+	}
+	return setup.Secret, code, resp.RecoveryCodes
+}
+
+func TestMatchTOTPKnownVector(t *testing.T) {
+	// RFC 6238 附录B的SHA1测试向量（取低6位）
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	now := time.Unix(59, 0)
+
+	step, ok := matchTOTP(secret, "287082", now)
+	if !ok || step != 1 {
+		t.Fatalf("matchTOTP = %d, %v，期望时间步 1", step, ok)
+	}
+	if _, ok := matchTOTP(secret, "287082", now.Add(5*totpPeriod*time.Second)); ok {
+		t.Fatal("超出允许偏差的验证码不应通过")
+	}
+	if _, ok := matchTOTP(secret, "28708", now); ok {
+		t.Fatal("位数不正确的验证码不应通过")
+	}
+}
+
+func TestVerifyTwoFactorRejectsReplay(t *testing.T) {
+	s := newTestAuthService(t)
+	admin := testAdmin(t, s.db)
+	secret, code, _ := enableTestTwoFactor(t, s, admin.ID)
+
+	// 启用时使用的验证码不能再用于登录
+	if err := s.verifyTwoFactor(admin.ID, code); !errors.Is(err, ErrTwoFactorInvalid) {
+		t.Fatalf("重复使用验证码返回 %v，期望 ErrTwoFactorInvalid", err)
+	}
+
+	// 下一个时间步的验证码只能使用一次
+	next, _ := totp.GenerateCode(secret, time.Now().Add(totpPeriod*time.Second))
+	if err := s.verifyTwoFactor(admin.ID, next); err != nil {
+		t.Fatalf("有效验证码校验失败: %v", err)
+	}
+	if err := s.verifyTwoFactor(admin.ID, next); !errors.Is(err, ErrTwoFactorInvalid) {
+		t.Fatalf("重复使用验证码返回 %v，期望 ErrTwoFactorInvalid", err)
+	}
+
+	if err := s.verifyTwoFactor(admin.ID, ""); !errors.Is(err, ErrTwoFactorRequired) {
+		t.Fatalf("缺少验证码返回 %v，期望 ErrTwoFactorRequired", err)
+	}
+}
+
+func TestVerifyTwoFactorRecoveryCodes(t *testing.T) {
+	s := newTestAuthService(t)
+	admin := testAdmin(t, s.db)
+	_, _, codes := enableTestTwoFactor(t, s, admin.ID)
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("恢复码数量 = %d, 期望 %d", len(codes), recoveryCodeCount)
+	}
+
+	if err := s.verifyTwoFactor(admin.ID, codes[0]); err != nil {
+		t.Fatalf("恢复码校验失败: %v", err)
+	}
+	if err := s.verifyTwoFactor(admin.ID, codes[0]); !errors.Is(err, ErrTwoFactorInvalid) {
+		t.Fatalf("恢复码应只能使用一次: %v", err)
+	}
+
+	// 忽略大小写和分隔符
+	loose := strings.ToUpper(strings.ReplaceAll(codes[1], "-", " "))
+	if err := s.verifyTwoFactor(admin.ID, loose); err != nil {
+		t.Fatalf("格式不同的恢复码校验失败: %v", err)
+	}
+	if err := s.verifyTwoFactor(admin.ID, "00000-00000"); !errors.Is(err, ErrTwoFactorInvalid) {
+		t.Fatalf("错误的恢复码返回 %v，期望 ErrTwoFactorInvalid", err)
+	}
+}
+
+func TestResetTwoFactor(t *testing.T) {
+	s := newTestAuthService(t)
+	admin := testAdmin(t, s.db)
+	enableTestTwoFactor(t, s, admin.ID)
+
+	if err := s.ResetTwoFactor(admin.ID, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("重置两步验证失败: %v", err)
+	}
+	// 重置后仅凭密码即可登录
+	if err := s.verifyTwoFactor(admin.ID, ""); err != nil {
+		t.Fatalf("重置后仍要求验证码: %v", err)
+	}
+	if _, err := s.Login(&model.LoginRequest{Username: "admin", Password: testAdminPassword}, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("重置后登录失败: %v", err)
+	}
+
+	if err := s.ResetTwoFactor(admin.ID, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrTwoFactorDisabled) {
+		t.Fatalf("未设置两步验证时返回 %v，期望 ErrTwoFactorDisabled", err)
+	}
+	if err := s.ResetTwoFactor(9999, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("用户不存在时返回 %v，期望 ErrUserNotFound", err)
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+)
+
+// 回收站相关错误
+var (
+	ErrTrashNotFound      = errors.New("回收站条目不存在")
+	ErrRestoreTargetExist = errors.New("原位置已存在同名文件")
+)
+
+// trashMeta 回收站条目元数据，保存在条目目录的 meta.json 中
+type trashMeta struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"original_path"`
+	IsDirectory  bool      `json:"is_directory"`
+	Size         int64     `json:"size"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	DeletedBy    uint      `json:"deleted_by"`
+}
+
+// trashDir 回收站目录，每个条目占用一个子目录：<id>/meta.json 和 <id>/<原文件名>
+func (f *FileService) trashDir() string {
+	return filepath.Join(f.config.System.DataDir, ".trash")
+}
+
+// TrashEnabled 是否启用回收站
+func (f *FileService) TrashEnabled() bool {
+	return f.config.File.TrashEnabled
+}
+
+// trashIDAttempts 生成回收站条目ID时遇到已存在的条目最多重试的次数
+const trashIDAttempts = 5
+
+// moveToTrash 将文件移入回收站并记录原路径，返回条目ID和回收站中的路径
+func (f *FileService) moveToTrash(path string, userID uint) (string, string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return "", "", err
+	}
+
+	id, itemDir, err := f.createTrashItemDir()
+	if err != nil {
+		return "", "", err
+	}
+	unlock := f.trashLocks.Lock(itemDir)
+	defer unlock()
+
+	meta := &trashMeta{
+		ID:           id,
+		Name:         filepath.Base(absPath),
+		OriginalPath: absPath,
+		IsDirectory:  info.IsDir(),
+		Size:         pathSize(absPath),
+		DeletedAt:    time.Now(),
+		DeletedBy:    userID,
+	}
+	if err := writeTrashMeta(itemDir, meta); err != nil {
+		os.RemoveAll(itemDir)
+		return "", "", err
+	}
+
+	trashPath := filepath.Join(itemDir, meta.Name)
+	if err := f.movePath(absPath, trashPath); err != nil {
+		os.RemoveAll(itemDir)
+		return "", "", err
+	}
+	return id, trashPath, nil
+}
+
+// createTrashItemDir 生成条目ID并独占创建条目目录，ID已被占用时重新生成，不会覆盖已有条目
+func (f *FileService) createTrashItemDir() (string, string, error) {
+	if err := os.MkdirAll(f.trashDir(), 0700); err != nil {
+		return "", "", err
+	}
+	for attempt := 0; attempt < trashIDAttempts; attempt++ {
+		id, err := generateUploadID()
+		if err != nil {
+			return "", "", err
+		}
+		itemDir := filepath.Join(f.trashDir(), id)
+		err = os.Mkdir(itemDir, 0700)
+		if err == nil {
+			return id, itemDir, nil
+		}
+		if !os.IsExist(err) {
+			return "", "", err
+		}
+	}
+	return "", "", fmt.Errorf("生成回收站条目ID失败")
+}
+
+// movePath 把 source 移动到尚不存在的 destination
+// 回收站目录可能与文件在不同的文件系统上，跨文件系统（EXDEV）时复制到目标旁的临时位置后再删除源文件
+func (f *FileService) movePath(source, destination string) error {
+	err := os.Rename(source, destination)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := f.diskGuard.Check(filepath.Dir(destination), pathSize(source)); err != nil {
+		return err
+	}
+	if err := installCopy(source, destination, false); err != nil {
+		return err
+	}
+	return os.RemoveAll(source)
+}
+
+// ListTrash 列出回收站条目，按删除时间倒序；all 为false时只返回 userID 删除的条目
+func (f *FileService) ListTrash(userID uint, all bool) ([]model.TrashItem, error) {
+	entries, err := os.ReadDir(f.trashDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []model.TrashItem{}, nil
+		}
+		return nil, fmt.Errorf("读取回收站失败: %w", err)
+	}
+
+	items := make([]model.TrashItem, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || !uploadIDPattern.MatchString(entry.Name()) {
+			continue
+		}
+		meta, err := readTrashMeta(filepath.Join(f.trashDir(), entry.Name()))
+		if err != nil || (!all && meta.DeletedBy != userID) {
+			continue
+		}
+		items = append(items, model.TrashItem(*meta))
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// RestoreTrash 将回收站条目恢复到原路径，原路径已存在时拒绝恢复
+// 非管理员只能恢复自己删除的条目；原路径来自磁盘上的 meta.json，恢复前重新校验是否位于根目录之内
+func (f *FileService) RestoreTrash(id string, userID uint, isAdmin bool, clientIP, userAgent string) (*model.TrashItem, error) {
+	if !uploadIDPattern.MatchString(id) {
+		return nil, ErrTrashNotFound
+	}
+
+	itemDir := filepath.Join(f.trashDir(), id)
+	unlock := f.trashLocks.Lock(itemDir)
+	defer unlock()
+
+	meta, err := readTrashMeta(itemDir)
+	if err != nil || (!isAdmin && meta.DeletedBy != userID) {
+		return nil, ErrTrashNotFound
+	}
+	if !f.isValidPath(meta.OriginalPath) || f.isRootDir(meta.OriginalPath) || !isValidFileName(meta.Name) {
+		f.logAuditAction(userID, "restore_file", "file", fmt.Sprintf("恢复文件失败: 无效路径 %s", meta.OriginalPath), clientIP, userAgent, "failed")
+		return nil, ErrInvalidPath
+	}
+
+	if _, err := os.Lstat(meta.OriginalPath); err == nil {
+		f.logAuditAction(userID, "restore_file", "file", fmt.Sprintf("恢复文件失败: 原位置已存在 %s", meta.OriginalPath), clientIP, userAgent, "failed")
+		return nil, ErrRestoreTargetExist
+	}
+	if err := os.MkdirAll(filepath.Dir(meta.OriginalPath), 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	if err := f.movePath(filepath.Join(itemDir, meta.Name), meta.OriginalPath); err != nil {
+		f.logAuditAction(userID, "restore_file", "file", fmt.Sprintf("恢复文件失败: %s, 错误: %v", meta.OriginalPath, err), clientIP, userAgent, "failed")
+		return nil, fmt.Errorf("恢复文件失败: %w", err)
+	}
+	if err := os.RemoveAll(itemDir); err != nil {
+		logger.Warn("清理回收站条目失败", "id", id, "error", err)
+	}
+
+	f.logAuditAction(userID, "restore_file", "file", fmt.Sprintf("从回收站恢复: %s", meta.OriginalPath), clientIP, userAgent, "success")
+	logger.Info("文件已从回收站恢复", "path", meta.OriginalPath, "id", id, "user_id", userID)
+	item := model.TrashItem(*meta)
+	return &item, nil
+}
+
+// PurgeExpiredTrash 清除超过保留时间的回收站条目，由后台任务定期调用
+// 缺少 meta.json 的条目按修改时间判断
+func (f *FileService) PurgeExpiredTrash() error {
+	retention := f.config.File.TrashRetention
+	if retention <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(f.trashDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取回收站失败: %w", err)
+	}
+
+	cutoff := time.Now().Add(-retention)
+	purged := 0
+	for _, entry := range entries {
+		if f.purgeTrashItem(entry, cutoff) {
+			purged++
+		}
+	}
+
+	if purged > 0 {
+		logger.Info("清除过期回收站条目", "count", purged)
+	}
+	return nil
+}
+
+// purgeTrashItem 在条目锁内确认已过期后删除，返回是否已删除
+func (f *FileService) purgeTrashItem(entry os.DirEntry, cutoff time.Time) bool {
+	path := filepath.Join(f.trashDir(), entry.Name())
+	unlock := f.trashLocks.Lock(path)
+	defer unlock()
+
+	deletedAt := time.Time{}
+	if meta, err := readTrashMeta(path); err == nil {
+		deletedAt = meta.DeletedAt
+	} else if info, err := entry.Info(); err == nil {
+		deletedAt = info.ModTime()
+	}
+	if deletedAt.IsZero() || deletedAt.After(cutoff) {
+		return false
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		logger.Warn("清除回收站条目失败", "path", path, "error", err)
+		return false
+	}
+	return true
+}
+
+// readTrashMeta 读取条目目录中的 meta.json
+func readTrashMeta(itemDir string) (*trashMeta, error) {
+	data, err := os.ReadFile(filepath.Join(itemDir, "meta.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var meta trashMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// writeTrashMeta 写入条目目录中的 meta.json，先写临时文件再重命名，列出回收站时不会读到写了一半的内容
+// 调用方需持有条目锁
+func writeTrashMeta(itemDir string, meta *trashMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("序列化回收站信息失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(itemDir, ".meta-*.json")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(itemDir, "meta.json"))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
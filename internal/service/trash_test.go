@@ -0,0 +1,244 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+// newTestTrashFileService 创建启用回收站的文件服务
+func newTestTrashFileService(t *testing.T) (*FileService, string, uint) {
+	t.Helper()
+	f, root, admin := newTestFileService(t)
+	f.config.File.TrashEnabled = true
+	return f, root, admin.ID
+}
+
+func TestMoveToTrashConcurrentDeletesGetDistinctEntries(t *testing.T) {
+	f, root, userID := newTestTrashFileService(t)
+
+	// 同名文件位于不同目录，并发移入回收站
+	const count = 20
+	paths := make([]string, count)
+	for i := range paths {
+		dir := filepath.Join(root, fmt.Sprintf("dir%d", i))
+		mustMkdir(t, dir)
+		paths[i] = filepath.Join(dir, "same.txt")
+		if err := os.WriteFile(paths[i], []byte(fmt.Sprint(i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	ids := make([]string, count)
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := f.DeleteFile(paths[i], false, userID, "127.0.0.1", "test")
+			if err != nil {
+				t.Errorf("移入回收站失败: %v", err)
+				return
+			}
+			ids[i] = resp.TrashID
+		}(i)
+	}
+	wg.Wait()
+
+	items, err := f.ListTrash(0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != count {
+		t.Fatalf("回收站条目 %d 个，期望 %d 个", len(items), count)
+	}
+	seen := make(map[string]string)
+	for _, item := range items {
+		if prev, ok := seen[item.ID]; ok {
+			t.Fatalf("条目ID重复: %s (%s, %s)", item.ID, prev, item.OriginalPath)
+		}
+		seen[item.ID] = item.OriginalPath
+	}
+	for i, id := range ids {
+		if seen[id] != paths[i] {
+			t.Fatalf("条目 %s 的原路径为 %q，期望 %q", id, seen[id], paths[i])
+		}
+	}
+}
+
+func TestTrashAcrossFilesystems(t *testing.T) {
+	f, root, userID := newTestTrashFileService(t)
+
+	// 数据目录放到另一个文件系统上，不支持时跳过
+	dataDir, err := os.MkdirTemp("/dev/shm", "trash-test-")
+	if err != nil {
+		t.Skipf("无法创建跨文件系统的数据目录: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dataDir) })
+	probe := filepath.Join(root, "probe")
+	if err := os.WriteFile(probe, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(probe, filepath.Join(dataDir, "probe")); !errors.Is(err, syscall.EXDEV) {
+		t.Skipf("数据目录与文件根目录在同一文件系统上: %v", err)
+	}
+	f.config.System.DataDir = dataDir
+
+	dir := filepath.Join(root, "docs")
+	mustMkdir(t, dir)
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := f.DeleteFile(dir, false, userID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("跨文件系统移入回收站失败: %v", err)
+	}
+	if _, err := os.Lstat(dir); !os.IsNotExist(err) {
+		t.Fatalf("移入回收站后原目录应不存在: %v", err)
+	}
+
+	if _, err := f.RestoreTrash(resp.TrashID, userID, false, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("跨文件系统恢复失败: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatalf("恢复后文件不存在: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(data) != "hello" || info.Mode().Perm() != 0640 {
+		t.Fatalf("恢复后内容 %q 权限 %v", data, info.Mode().Perm())
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, ".trash", resp.TrashID)); !os.IsNotExist(err) {
+		t.Fatalf("恢复后回收站条目应被删除: %v", err)
+	}
+}
+
+func TestDeleteFileModes(t *testing.T) {
+	f, root, userID := newTestTrashFileService(t)
+	write := func(name string) string {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// 启用回收站时默认移入回收站
+	path := write("soft.txt")
+	resp, err := f.DeleteFile(path, false, userID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if resp.Mode != DeleteModeTrash || resp.TrashID == "" || resp.TrashPath == "" {
+		t.Fatalf("默认删除结果 = %+v，期望移入回收站", resp)
+	}
+	if _, err := os.Stat(resp.TrashPath); err != nil {
+		t.Fatalf("回收站中缺少文件: %v", err)
+	}
+
+	// permanent=true 时永久删除
+	path = write("hard.txt")
+	resp, err = f.DeleteFile(path, true, userID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("永久删除失败: %v", err)
+	}
+	if resp.Mode != DeleteModePermanent || resp.TrashPath != "" {
+		t.Fatalf("permanent 删除结果 = %+v", resp)
+	}
+
+	// 未启用回收站时即使不指定 permanent 也永久删除
+	f.config.File.TrashEnabled = false
+	path = write("off.txt")
+	resp, err = f.DeleteFile(path, false, userID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if resp.Mode != DeleteModePermanent {
+		t.Fatalf("回收站关闭时删除模式 = %s，期望 %s", resp.Mode, DeleteModePermanent)
+	}
+	for _, name := range []string{"soft.txt", "hard.txt", "off.txt"} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s 删除后仍存在: %v", name, err)
+		}
+	}
+	items, err := f.ListTrash(0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].OriginalPath != filepath.Join(root, "soft.txt") {
+		t.Fatalf("回收站条目 = %+v，期望只有 soft.txt", items)
+	}
+}
+
+func TestTrashOwnershipAndRestoreValidation(t *testing.T) {
+	f, root, userID := newTestTrashFileService(t)
+	otherID := userID + 100
+	for _, name := range []string{"mine.txt", "theirs.txt", "tampered.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mine, err := f.DeleteFile(filepath.Join(root, "mine.txt"), false, userID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, err := f.DeleteFile(filepath.Join(root, "theirs.txt"), false, otherID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered, err := f.DeleteFile(filepath.Join(root, "tampered.txt"), false, userID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 非管理员只能看到自己删除的条目
+	items, err := f.ListTrash(otherID, false)
+	if err != nil || len(items) != 1 || items[0].ID != theirs.TrashID {
+		t.Fatalf("其他用户的回收站 = %+v, %v", items, err)
+	}
+	if items, _ := f.ListTrash(userID, false); len(items) != 2 {
+		t.Fatalf("本人的回收站条目 %d 个，期望 2 个", len(items))
+	}
+	if items, _ := f.ListTrash(userID, true); len(items) != 3 {
+		t.Fatalf("管理员看到 %d 个条目，期望 3 个", len(items))
+	}
+
+	// 非管理员不能恢复其他用户的条目
+	if _, err := f.RestoreTrash(theirs.TrashID, userID, false, "127.0.0.1", "test"); !errors.Is(err, ErrTrashNotFound) {
+		t.Fatalf("恢复其他用户的条目返回 %v，期望 ErrTrashNotFound", err)
+	}
+	if _, err := f.RestoreTrash(theirs.TrashID, userID, true, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("管理员恢复失败: %v", err)
+	}
+	if _, err := f.RestoreTrash(mine.TrashID, userID, false, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("恢复本人的条目失败: %v", err)
+	}
+
+	// 被篡改的原路径指向根目录之外时拒绝恢复
+	outside := t.TempDir()
+	itemDir := filepath.Join(f.trashDir(), tampered.TrashID)
+	meta, err := readTrashMeta(itemDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(outside, "escaped.txt"), filepath.Join(root, "..", "escaped.txt"), root} {
+		meta.OriginalPath = path
+		if err := writeTrashMeta(itemDir, meta); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.RestoreTrash(tampered.TrashID, userID, false, "127.0.0.1", "test"); !errors.Is(err, ErrInvalidPath) {
+			t.Errorf("恢复到 %s 返回 %v，期望 ErrInvalidPath", path, err)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Fatalf("根目录外出现了 %d 个条目", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(itemDir, "tampered.txt")); err != nil {
+		t.Fatalf("拒绝恢复后回收站中的文件丢失: %v", err)
+	}
+}
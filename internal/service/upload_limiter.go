@@ -0,0 +1,79 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"web-panel-go/internal/shardmap"
+)
+
+// UploadLimiter 上传并发限制器（全局 + 单用户）
+// 单用户计数按用户分片加锁，全局计数使用原子操作，不同用户的上传互不阻塞
+type UploadLimiter struct {
+	maxGlobal  int
+	maxPerUser int
+	global     atomic.Int64
+	perUser    *shardmap.Map[uint, int]
+}
+
+// NewUploadLimiter 创建上传并发限制器，上限为0表示不限制
+func NewUploadLimiter(maxGlobal, maxPerUser int) *UploadLimiter {
+	return &UploadLimiter{
+		maxGlobal:  maxGlobal,
+		maxPerUser: maxPerUser,
+		perUser:    shardmap.New[uint, int](shardmap.DefaultShards),
+	}
+}
+
+// TryAcquire 尝试占用一个上传名额，成功时返回释放函数
+func (l *UploadLimiter) TryAcquire(userID uint) (func(), bool) {
+	// 先占用单用户名额，再占用全局名额，全局名额不足时归还单用户名额
+	acquired := false
+	l.perUser.Update(userID, func(count int, _ bool) (int, bool) {
+		if l.maxPerUser > 0 && count >= l.maxPerUser {
+			return count, count > 0
+		}
+		acquired = true
+		return count + 1, true
+	})
+	if !acquired {
+		return nil, false
+	}
+
+	if !l.acquireGlobal() {
+		l.releaseUser(userID)
+		return nil, false
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.global.Add(-1)
+			l.releaseUser(userID)
+		})
+	}, true
+}
+
+// acquireGlobal 占用一个全局名额
+func (l *UploadLimiter) acquireGlobal() bool {
+	if l.maxGlobal <= 0 {
+		l.global.Add(1)
+		return true
+	}
+	for {
+		current := l.global.Load()
+		if current >= int64(l.maxGlobal) {
+			return false
+		}
+		if l.global.CompareAndSwap(current, current+1) {
+			return true
+		}
+	}
+}
+
+// releaseUser 归还一个单用户名额，计数归零时删除该用户的记录
+func (l *UploadLimiter) releaseUser(userID uint) {
+	l.perUser.Update(userID, func(count int, _ bool) (int, bool) {
+		return count - 1, count > 1
+	})
+}
@@ -0,0 +1,51 @@
+package service
+
+import "testing"
+
+func TestUploadLimiterPerUserAndGlobal(t *testing.T) {
+	l := NewUploadLimiter(3, 2)
+
+	release1, ok := l.TryAcquire(1)
+	if !ok {
+		t.Fatal("第一个上传应获得名额")
+	}
+	release2, ok := l.TryAcquire(1)
+	if !ok {
+		t.Fatal("第二个上传应获得名额")
+	}
+	// 单用户超出上限
+	if _, ok := l.TryAcquire(1); ok {
+		t.Fatal("单用户第三个上传应被拒绝")
+	}
+
+	// 其他用户占用剩余的全局名额，全局超出上限
+	if _, ok := l.TryAcquire(2); !ok {
+		t.Fatal("其他用户应获得名额")
+	}
+	if _, ok := l.TryAcquire(3); ok {
+		t.Fatal("全局名额已满时应被拒绝")
+	}
+
+	// 释放后可以再次上传，重复释放不会多归还名额
+	release1()
+	release1()
+	if _, ok := l.TryAcquire(3); !ok {
+		t.Fatal("释放后应获得名额")
+	}
+	if _, ok := l.TryAcquire(1); ok {
+		t.Fatal("重复释放归还了多余的名额")
+	}
+	release2()
+	if _, ok := l.TryAcquire(1); !ok {
+		t.Fatal("释放后应获得名额")
+	}
+}
+
+func TestUploadLimiterUnlimited(t *testing.T) {
+	l := NewUploadLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		if _, ok := l.TryAcquire(1); !ok {
+			t.Fatalf("不限制时第 %d 个上传被拒绝", i+1)
+		}
+	}
+}
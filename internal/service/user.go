@@ -3,34 +3,169 @@ package service
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"web-panel-go/internal/config"
 	"web-panel-go/internal/database"
 	"web-panel-go/internal/logger"
 	"web-panel-go/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserService 用户服务
 type UserService struct {
-	db *gorm.DB
+	db             *gorm.DB
+	config         *config.Config
+	notifier       Notifier
+	alerter        SecurityAlerter
+	accessNotifier AccessNotifier
+	mailer         Mailer
+	validator      *CredentialValidator
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(db *gorm.DB, cfg *config.Config) *UserService {
+	return &UserService{db: db, config: cfg, validator: NewCredentialValidator(&cfg.Auth)}
 }
 
+// SetNotifier 设置用户通知器
+func (s *UserService) SetNotifier(notifier Notifier) {
+	s.notifier = notifier
+}
+
+// SetSecurityAlerter 设置安全告警推送器，用户角色变更时通知管理员
+func (s *UserService) SetSecurityAlerter(alerter SecurityAlerter) {
+	s.alerter = alerter
+}
+
+// SetAccessNotifier 设置权限变更通知器，用户角色变更时通知该用户
+func (s *UserService) SetAccessNotifier(notifier AccessNotifier) {
+	s.accessNotifier = notifier
+}
+
+// SetMailer 设置邮件发送器，用于向用户发送密码重置等通知邮件
+func (s *UserService) SetMailer(mailer Mailer) {
+	s.mailer = mailer
+}
+
+// ErrUserNotFound 用户不存在
+var ErrUserNotFound = errors.New("用户不存在")
+
+// ErrTooManyRoles 分配的角色数超过单用户上限
+var ErrTooManyRoles = errors.New("角色数量超过上限")
+
+// ErrUserNotPending 用户不处于待审核状态
+var ErrUserNotPending = errors.New("用户不处于待审核状态")
+
+// ErrLastAdmin 操作会导致系统中没有可用的管理员
+var ErrLastAdmin = errors.New("必须至少保留一个管理员")
+
+// ErrAdminRequired 非管理员分配管理员角色或修改管理员账户
+var ErrAdminRequired = errors.New("只有管理员可以分配管理员角色或修改管理员账户")
+
+// ErrUsernameExists 用户名已被其他用户使用
+var ErrUsernameExists = errors.New("用户名已存在")
+
+// ErrEmailExists 邮箱已被其他用户使用
+var ErrEmailExists = errors.New("邮箱已存在")
+
+// requireAdminOperator 目标用户是管理员或要分配的角色包含管理员时，要求操作者本身是管理员，
+// 避免只拥有 user:create/user:update 等权限的用户给自己提权或接管管理员账户
+func (s *UserService) requireAdminOperator(operatorID uint, target *model.User, roleIDs []uint) error {
+	needsAdmin := target != nil && target.IsAdmin()
+	if !needsAdmin && len(roleIDs) > 0 {
+		var err error
+		if needsAdmin, err = s.rolesIncludeAdmin(roleIDs); err != nil {
+			return err
+		}
+	}
+	if !needsAdmin {
+		return nil
+	}
+
+	operator, err := s.GetUserByID(operatorID)
+	if err != nil || !operator.IsAdmin() {
+		return ErrAdminRequired
+	}
+	return nil
+}
+
+// ensureAdminRemains 用户是启用状态的管理员且操作后不再是可用管理员时，检查是否还有其他可用的管理员，
+// 没有则返回 ErrLastAdmin，避免所有人被锁在系统之外；管理员角色已禁用或账户处于登录锁定中的不算可用管理员
+func (s *UserService) ensureAdminRemains(user *model.User, stillAdmin bool) error {
+	if stillAdmin || !user.IsActive() || !user.IsAdmin() {
+		return nil
+	}
+
+	var others int64
+	if err := s.db.Model(&model.User{}).
+		Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Joins("JOIN roles ON roles.id = user_roles.role_id AND roles.deleted_at IS NULL").
+		Where("roles.name = ? AND roles.status = ?", model.RoleAdmin, model.RoleStatusActive).
+		Where("users.status = ? AND users.id <> ?", model.UserStatusActive, user.ID).
+		Where("users.locked_until IS NULL OR users.locked_until <= ?", time.Now()).
+		Distinct("users.id").
+		Count(&others).Error; err != nil {
+		return fmt.Errorf("统计管理员数量失败: %w", err)
+	}
+	if others == 0 {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// rolesIncludeAdmin 判断角色ID列表中是否包含管理员角色
+func (s *UserService) rolesIncludeAdmin(roleIDs []uint) (bool, error) {
+	var count int64
+	if err := s.db.Model(&model.Role{}).Where("id IN ? AND name = ?", roleIDs, model.RoleAdmin).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询角色失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+// checkRoleLimit 检查分配给单个用户的角色数是否超过 max_roles_per_user
+func (s *UserService) checkRoleLimit(roleIDs []uint) error {
+	limit := s.config.Auth.MaxRolesPerUser
+	if limit <= 0 {
+		return nil
+	}
+
+	unique := make(map[uint]struct{}, len(roleIDs))
+	for _, id := range roleIDs {
+		unique[id] = struct{}{}
+	}
+	if len(unique) > limit {
+		return fmt.Errorf("%w: 最多 %d 个，请求 %d 个", ErrTooManyRoles, limit, len(unique))
+	}
+	return nil
+}
+
+// likeEscaper 转义LIKE通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // GetUsers 获取用户列表
-func (s *UserService) GetUsers(page, pageSize int, search string) ([]model.User, int64, error) {
+func (s *UserService) GetUsers(page, pageSize int, filter model.UserFilter) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
 
 	query := s.db.Model(&model.User{})
 
-	// 搜索条件
-	if search != "" {
-		query = query.Where("username LIKE ? OR email LIKE ?", "%"+search+"%", "%"+search+"%")
+	// 精确匹配条件
+	if filter.Username != "" {
+		query = query.Where("username = ?", filter.Username)
+	}
+	if filter.Email != "" {
+		query = query.Where("LOWER(email) = LOWER(?)", filter.Email)
+	}
+
+	// 模糊搜索条件，统一转小写保证各数据库行为一致
+	if filter.Search != "" {
+		pattern := "%" + strings.ToLower(likeEscaper.Replace(filter.Search)) + "%"
+		query = query.Where(`LOWER(username) LIKE ? ESCAPE '\' OR LOWER(email) LIKE ? ESCAPE '\' OR LOWER(nickname) LIKE ? ESCAPE '\' OR LOWER(phone) LIKE ? ESCAPE '\'`,
+			pattern, pattern, pattern, pattern)
 	}
 
 	// 获取总数
@@ -49,9 +184,9 @@ func (s *UserService) GetUsers(page, pageSize int, search string) ([]model.User,
 // GetUserByID 根据ID获取用户
 func (s *UserService) GetUserByID(id uint) (*model.User, error) {
 	var user model.User
-	if err := s.db.First(&user, id).Error; err != nil {
+	if err := s.db.Preload("Roles").First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户不存在")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
@@ -61,9 +196,9 @@ func (s *UserService) GetUserByID(id uint) (*model.User, error) {
 // GetUserByUsername 根据用户名获取用户
 func (s *UserService) GetUserByUsername(username string) (*model.User, error) {
 	var user model.User
-	if err := s.db.Where("username = ?", username).First(&user).Error; err != nil {
+	if err := s.db.Preload("Roles").Where("username = ?", username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户不存在")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
@@ -73,66 +208,108 @@ func (s *UserService) GetUserByUsername(username string) (*model.User, error) {
 // GetUserByEmail 根据邮箱获取用户
 func (s *UserService) GetUserByEmail(email string) (*model.User, error) {
 	var user model.User
-	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := s.db.Preload("Roles").Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("用户不存在")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("查询用户失败: %w", err)
 	}
 	return &user, nil
 }
 
+// loadRoles 从数据库重新加载用户角色
+func (s *UserService) loadRoles(user *model.User) error {
+	user.Roles = nil
+	return s.db.Model(user).Association("Roles").Find(&user.Roles)
+}
+
+// validateNewUser 按统一规则校验新用户的用户名、邮箱和密码
+func (s *UserService) validateNewUser(req *model.CreateUserRequest) error {
+	if err := s.validator.ValidateUsername(req.Username); err != nil {
+		return err
+	}
+	if err := s.validator.ValidateEmail(req.Email); err != nil {
+		return err
+	}
+	return s.validator.ValidatePassword(req.Password)
+}
+
 // CreateUser 创建用户
 func (s *UserService) CreateUser(req *model.CreateUserRequest, operatorID uint, clientIP, userAgent string) (*model.User, error) {
+	if err := s.validateNewUser(req); err != nil {
+		s.logAuditAction(operatorID, "create_user", "user", fmt.Sprintf("创建用户失败: %s, %v", req.Username, err), clientIP, userAgent, "failed")
+		return nil, err
+	}
+	if err := s.checkRoleLimit(req.RoleIDs); err != nil {
+		s.logAuditAction(operatorID, "create_user", "user", fmt.Sprintf("创建用户失败: %s, %v", req.Username, err), clientIP, userAgent, "failed")
+		return nil, err
+	}
+	if err := s.requireAdminOperator(operatorID, nil, req.RoleIDs); err != nil {
+		s.logAuditAction(operatorID, "create_user", "user", fmt.Sprintf("创建用户失败: %s, %v", req.Username, err), clientIP, userAgent, "failed")
+		return nil, err
+	}
+
 	// 检查用户名是否已存在
 	var existingUser model.User
 	if err := s.db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		return nil, errors.New("用户名已存在")
+		return nil, ErrUsernameExists
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("检查用户名失败: %w", err)
 	}
 
 	// 检查邮箱是否已存在
 	if err := s.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		return nil, errors.New("邮箱已存在")
+		return nil, ErrEmailExists
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, fmt.Errorf("检查邮箱失败: %w", err)
 	}
 
-	// 创建用户
+	// 创建用户（启用账户审核时可创建为待审核账户）
+	status := model.UserStatusActive
+	if req.Pending && s.config.Auth.RequireApproval {
+		status = model.UserStatusPending
+	}
 	user := &model.User{
 		Username: req.Username,
 		Email:    req.Email,
 		Nickname: req.Nickname,
 		Phone:    req.Phone,
-		Status:   model.UserStatusActive,
+		Status:   status,
 	}
 
 	// 设置密码
-	if err := user.SetPassword(req.Password); err != nil {
+	if err := user.SetPassword(req.Password, s.validator.PasswordPolicy()); err != nil {
 		return nil, fmt.Errorf("设置密码失败: %w", err)
 	}
 
-	// 保存到数据库
-	if err := s.db.Create(user).Error; err != nil {
-		return nil, fmt.Errorf("创建用户失败: %w", err)
-	}
-
-	// 分配角色
-	if len(req.RoleIDs) > 0 {
+	// 保存到数据库并分配角色
+	err := database.RetryTransaction(s.db, func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
 		for _, roleID := range req.RoleIDs {
 			userRole := &model.UserRole{
 				UserID: user.ID,
 				RoleID: roleID,
 			}
-			if err := s.db.Create(userRole).Error; err != nil {
+			if err := tx.Create(userRole).Error; err != nil {
+				if database.IsBusyError(err) {
+					return err
+				}
 				logger.Error("分配角色失败", "error", err, "user_id", user.ID, "role_id", roleID)
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建用户失败: %w", err)
+	}
+	if err := s.loadRoles(user); err != nil {
+		logger.Error("加载用户角色失败", "error", err, "user_id", user.ID)
 	}
 
 	// 记录审计日志
-	s.logAuditAction(operatorID, "create_user", "user", fmt.Sprintf("创建用户: %s", user.Username), clientIP, userAgent, "success")
+	s.logAuditAction(operatorID, "create_user", "user", fmt.Sprintf("创建用户: %s, 状态: %s", user.Username, user.Status), clientIP, userAgent, "success")
 
 	logger.Info("创建用户成功", "username", user.Username, "operator", operatorID)
 	return user, nil
@@ -145,12 +322,46 @@ func (s *UserService) UpdateUser(id uint, req *model.UpdateUserRequest, operator
 	if err != nil {
 		return nil, err
 	}
+	previousRoles := roleNames(user.Roles)
+
+	if req.Username != "" && req.Username != user.Username {
+		if err := s.validator.ValidateUsername(req.Username); err != nil {
+			s.logAuditAction(operatorID, "update_user", "user", fmt.Sprintf("更新用户失败: %s, %v", user.Username, err), clientIP, userAgent, "failed")
+			return nil, err
+		}
+	}
+	if req.Email != "" && req.Email != user.Email {
+		if err := s.validator.ValidateEmail(req.Email); err != nil {
+			s.logAuditAction(operatorID, "update_user", "user", fmt.Sprintf("更新用户失败: %s, %v", user.Username, err), clientIP, userAgent, "failed")
+			return nil, err
+		}
+	}
+	if err := s.checkRoleLimit(req.RoleIDs); err != nil {
+		s.logAuditAction(operatorID, "update_user", "user", fmt.Sprintf("更新用户失败: %s, %v", user.Username, err), clientIP, userAgent, "failed")
+		return nil, err
+	}
+
+	// 状态改为非启用或移除管理员角色时，不能移除最后一个管理员
+	stillAdmin := req.Status == nil || *req.Status == model.UserStatusActive
+	if stillAdmin && len(req.RoleIDs) > 0 {
+		if stillAdmin, err = s.rolesIncludeAdmin(req.RoleIDs); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.ensureAdminRemains(user, stillAdmin); err != nil {
+		s.logAuditAction(operatorID, "update_user", "user", fmt.Sprintf("更新用户失败: %s, %v", user.Username, err), clientIP, userAgent, "failed")
+		return nil, err
+	}
+	if err := s.requireAdminOperator(operatorID, user, req.RoleIDs); err != nil {
+		s.logAuditAction(operatorID, "update_user", "user", fmt.Sprintf("更新用户失败: %s, %v", user.Username, err), clientIP, userAgent, "failed")
+		return nil, err
+	}
 
 	// 检查用户名是否已被其他用户使用
 	if req.Username != "" && req.Username != user.Username {
 		var existingUser model.User
 		if err := s.db.Where("username = ? AND id != ?", req.Username, id).First(&existingUser).Error; err == nil {
-			return nil, errors.New("用户名已存在")
+			return nil, ErrUsernameExists
 		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("检查用户名失败: %w", err)
 		}
@@ -161,7 +372,7 @@ func (s *UserService) UpdateUser(id uint, req *model.UpdateUserRequest, operator
 	if req.Email != "" && req.Email != user.Email {
 		var existingUser model.User
 		if err := s.db.Where("email = ? AND id != ?", req.Email, id).First(&existingUser).Error; err == nil {
-			return nil, errors.New("邮箱已存在")
+			return nil, ErrEmailExists
 		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("检查邮箱失败: %w", err)
 		}
@@ -179,36 +390,80 @@ func (s *UserService) UpdateUser(id uint, req *model.UpdateUserRequest, operator
 		user.Status = *req.Status
 	}
 
-	// 更新角色
-	if len(req.RoleIDs) > 0 {
-		// 删除现有角色
-		if err := s.db.Where("user_id = ?", user.ID).Delete(&model.UserRole{}).Error; err != nil {
-			logger.Error("删除用户角色失败", "error", err, "user_id", user.ID)
-		}
-		// 添加新角色
-		for _, roleID := range req.RoleIDs {
-			userRole := &model.UserRole{
-				UserID: user.ID,
-				RoleID: roleID,
+	// 更新角色并保存
+	err = database.RetryTransaction(s.db, func(tx *gorm.DB) error {
+		if len(req.RoleIDs) > 0 {
+			// 删除现有角色
+			if err := tx.Where("user_id = ?", user.ID).Delete(&model.UserRole{}).Error; err != nil {
+				if database.IsBusyError(err) {
+					return err
+				}
+				logger.Error("删除用户角色失败", "error", err, "user_id", user.ID)
 			}
-			if err := s.db.Create(userRole).Error; err != nil {
-				logger.Error("分配角色失败", "error", err, "user_id", user.ID, "role_id", roleID)
+			// 添加新角色
+			for _, roleID := range req.RoleIDs {
+				userRole := &model.UserRole{
+					UserID: user.ID,
+					RoleID: roleID,
+				}
+				if err := tx.Create(userRole).Error; err != nil {
+					if database.IsBusyError(err) {
+						return err
+					}
+					logger.Error("分配角色失败", "error", err, "user_id", user.ID, "role_id", roleID)
+				}
 			}
 		}
-	}
-
-	// 保存更新
-	if err := s.db.Save(user).Error; err != nil {
+		// 角色已单独维护，保存时不写关联，避免把旧角色重新插入
+		return tx.Omit(clause.Associations).Save(user).Error
+	})
+	if err != nil {
 		return nil, fmt.Errorf("更新用户失败: %w", err)
 	}
+	details := fmt.Sprintf("更新用户: %s", user.Username)
+	if len(req.RoleIDs) > 0 {
+		if err := s.loadRoles(user); err != nil {
+			logger.Error("加载用户角色失败", "error", err, "user_id", user.ID)
+		} else if added, removed := diffNames(previousRoles, roleNames(user.Roles)); len(added) > 0 || len(removed) > 0 {
+			details += fmt.Sprintf(", 角色新增: %s, 移除: %s", formatNameList(added), formatNameList(removed))
+			s.notifyRolesChanged(user, details, operatorID, clientIP)
+		}
+	}
 
 	// 记录审计日志
-	s.logAuditAction(operatorID, "update_user", "user", fmt.Sprintf("更新用户: %s", user.Username), clientIP, userAgent, "success")
+	s.logAuditAction(operatorID, "update_user", "user", details, clientIP, userAgent, "success")
 
 	logger.Info("更新用户成功", "username", user.Username, "operator", operatorID)
 	return user, nil
 }
 
+// notifyRolesChanged 用户角色变更后向管理员推送安全告警，并通知该用户刷新权限
+func (s *UserService) notifyRolesChanged(user *model.User, details string, operatorID uint, clientIP string) {
+	if s.alerter != nil {
+		s.alerter.SendSecurityAlert(&model.SecurityAlert{
+			Type:      model.SecurityAlertUserRoles,
+			Username:  user.Username,
+			UserID:    user.ID,
+			IPAddress: clientIP,
+			Message:   details,
+			CreatedAt: time.Now(),
+		})
+	}
+	if s.accessNotifier != nil {
+		s.accessNotifier.NotifyAccessChanged([]uint{user.ID}, "你的角色已变更")
+	}
+	logger.Info("用户角色已变更", "user_id", user.ID, "operator", operatorID)
+}
+
+// roleNames 提取角色名称
+func roleNames(roles []model.Role) []string {
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
 // DeleteUser 删除用户
 func (s *UserService) DeleteUser(id uint, operatorID uint, clientIP, userAgent string) error {
 	// 获取用户
@@ -222,8 +477,17 @@ func (s *UserService) DeleteUser(id uint, operatorID uint, clientIP, userAgent s
 		return errors.New("不能删除自己")
 	}
 
+	if err := s.ensureAdminRemains(user, false); err != nil {
+		s.logAuditAction(operatorID, "delete_user", "user", fmt.Sprintf("删除用户失败: %s, %v", user.Username, err), clientIP, userAgent, "failed")
+		return err
+	}
+	if err := s.requireAdminOperator(operatorID, user, nil); err != nil {
+		s.logAuditAction(operatorID, "delete_user", "user", fmt.Sprintf("删除用户失败: %s, %v", user.Username, err), clientIP, userAgent, "failed")
+		return err
+	}
+
 	// 软删除用户
-	if err := s.db.Delete(user).Error; err != nil {
+	if err := database.WithRetry(func() error { return s.db.Delete(user).Error }); err != nil {
 		return fmt.Errorf("删除用户失败: %w", err)
 	}
 
@@ -253,23 +517,21 @@ func (s *UserService) ToggleUserStatus(id uint, operatorID uint, clientIP, userA
 	}
 
 	// 切换状态
+	newStatus := model.UserStatusActive
 	if user.Status == model.UserStatusActive {
-		user.Status = model.UserStatusInactive
-	} else {
-		user.Status = model.UserStatusActive
-	}
-
-	// 保存更新
-	if err := s.db.Save(user).Error; err != nil {
-		return nil, fmt.Errorf("更新用户状态失败: %w", err)
+		newStatus = model.UserStatusInactive
 	}
-
-	// 如果禁用用户，删除其所有会话
-	if user.Status == model.UserStatusInactive {
-		if err := s.db.Where("user_id = ?", id).Delete(&model.Session{}).Error; err != nil {
-			logger.Error("删除用户会话失败", "error", err)
+	if newStatus != model.UserStatusActive {
+		if err := s.ensureAdminRemains(user, false); err != nil {
+			return nil, err
 		}
 	}
+	if err := s.requireAdminOperator(operatorID, user, nil); err != nil {
+		return nil, err
+	}
+	if err := s.applyUserStatus(user, newStatus); err != nil {
+		return nil, err
+	}
 
 	// 记录审计日志
 	status := "启用"
@@ -290,10 +552,20 @@ func (s *UserService) ChangeUserStatus(id uint, status model.UserStatus, operato
 		return nil, err
 	}
 
+	if status != model.UserStatusActive {
+		if err := s.ensureAdminRemains(user, false); err != nil {
+			s.logAuditAction(operatorID, "修改用户状态", "用户", fmt.Sprintf("用户ID: %d, %v", id, err), clientIP, userAgent, "失败")
+			return nil, err
+		}
+	}
+	if err := s.requireAdminOperator(operatorID, user, nil); err != nil {
+		s.logAuditAction(operatorID, "修改用户状态", "用户", fmt.Sprintf("用户ID: %d, %v", id, err), clientIP, userAgent, "失败")
+		return nil, err
+	}
+
 	// 更新状态
-	user.Status = status
-	if err := s.db.Save(user).Error; err != nil {
-		return nil, fmt.Errorf("更新用户状态失败: %w", err)
+	if err := s.applyUserStatus(user, status); err != nil {
+		return nil, err
 	}
 
 	// 记录审计日志
@@ -306,31 +578,165 @@ func (s *UserService) ChangeUserStatus(id uint, status model.UserStatus, operato
 	return user, nil
 }
 
+// applyUserStatus 保存用户状态，非启用状态下同时删除该用户的所有会话
+func (s *UserService) applyUserStatus(user *model.User, status model.UserStatus) error {
+	user.Status = status
+	if err := s.db.Omit(clause.Associations).Save(user).Error; err != nil {
+		return fmt.Errorf("更新用户状态失败: %w", err)
+	}
+
+	if status != model.UserStatusActive {
+		if err := s.db.Where("user_id = ?", user.ID).Delete(&model.Session{}).Error; err != nil {
+			logger.Error("删除用户会话失败", "error", err, "user_id", user.ID)
+		}
+	}
+	return nil
+}
+
+// GetPendingUsers 获取待审核的用户列表，按创建时间先后排序
+func (s *UserService) GetPendingUsers() ([]model.User, error) {
+	var users []model.User
+	if err := s.db.Preload("Roles").Where("status = ?", model.UserStatusPending).Order("created_at ASC").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("获取待审核用户失败: %w", err)
+	}
+	return users, nil
+}
+
+// ApproveUser 批准待审核用户，账户启用后按配置通知用户
+func (s *UserService) ApproveUser(id uint, operatorID uint, clientIP, userAgent string) (*model.User, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsPending() {
+		s.logAuditAction(operatorID, "approve_user", "user", fmt.Sprintf("批准用户失败: %s, 当前状态: %s", user.Username, user.Status), clientIP, userAgent, "failed")
+		return nil, ErrUserNotPending
+	}
+
+	if err := s.applyUserStatus(user, model.UserStatusActive); err != nil {
+		return nil, err
+	}
+
+	if s.config.Auth.NotifyOnApproval && s.notifier != nil {
+		s.notifier.NotifyUser(user.ID, "账户已通过审核", "您的账户已通过管理员审核，现在可以登录使用", "success")
+	}
+
+	s.logAuditAction(operatorID, "approve_user", "user", fmt.Sprintf("批准用户: %s", user.Username), clientIP, userAgent, "success")
+
+	logger.Info("批准用户成功", "username", user.Username, "operator", operatorID)
+	return user, nil
+}
+
+// RejectUser 拒绝待审核用户，账户转为禁用状态并保留记录
+func (s *UserService) RejectUser(id uint, reason string, operatorID uint, clientIP, userAgent string) (*model.User, error) {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsPending() {
+		s.logAuditAction(operatorID, "reject_user", "user", fmt.Sprintf("拒绝用户失败: %s, 当前状态: %s", user.Username, user.Status), clientIP, userAgent, "failed")
+		return nil, ErrUserNotPending
+	}
+
+	if err := s.applyUserStatus(user, model.UserStatusInactive); err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("拒绝用户: %s", user.Username)
+	if reason != "" {
+		details += ", 原因: " + reason
+	}
+	s.logAuditAction(operatorID, "reject_user", "user", details, clientIP, userAgent, "success")
+
+	logger.Info("拒绝用户成功", "username", user.Username, "operator", operatorID)
+	return user, nil
+}
+
 // ResetUserPassword 重置用户密码
 func (s *UserService) ResetUserPassword(id uint, newPassword string, operatorID uint, clientIP, userAgent string) error {
+	if err := s.validator.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
 	// 获取用户
 	user, err := s.GetUserByID(id)
 	if err != nil {
 		return err
 	}
+	if err := s.requireAdminOperator(operatorID, user, nil); err != nil {
+		s.logAuditAction(operatorID, "重置用户密码", "用户", fmt.Sprintf("用户ID: %d, %v", id, err), clientIP, userAgent, "失败")
+		return err
+	}
 
 	// 更新密码
-	user.Password = newPassword
-	if err := s.db.Save(user).Error; err != nil {
+	if err := user.SetPassword(newPassword, s.validator.PasswordPolicy()); err != nil {
+		return fmt.Errorf("设置新密码失败: %w", err)
+	}
+	if err := s.db.Omit(clause.Associations).Save(user).Error; err != nil {
 		return fmt.Errorf("重置用户密码失败: %w", err)
 	}
 
 	// 记录审计日志
 	s.logAuditAction(operatorID, "重置用户密码", "用户", fmt.Sprintf("用户ID: %d", id), clientIP, userAgent, "成功")
 
+	// 通知用户密码已被管理员重置
+	if s.config.Auth.NotifyPasswordReset && s.notifier != nil {
+		s.notifier.NotifyUser(user.ID, "密码已重置", "您的密码已被管理员重置，如非本人申请请联系管理员", "warning")
+	}
+	if s.config.Auth.EmailPasswordReset && s.mailer != nil && user.Email != "" {
+		body := fmt.Sprintf("%s，您好：\n\n您的账户密码已于 %s 被管理员重置。如非本人申请，请立即联系管理员。\n",
+			user.Username, time.Now().Format("2006-01-02 15:04:05"))
+		if err := s.mailer.SendMail(user.Email, "密码已重置", body); err != nil {
+			// 密码已经重置成功，邮件发送失败只记录日志
+			logger.Error("发送密码重置通知邮件失败", "error", err, "user_id", user.ID)
+		}
+	}
+
+	// 删除该用户的所有会话（强制重新登录）
+	if s.config.Auth.RevokeOnReset {
+		if err := s.db.Where("user_id = ?", id).Delete(&model.Session{}).Error; err != nil {
+			logger.Error("删除用户会话失败", "error", err)
+		}
+	}
+
 	return nil
 }
 
+// UnlockUser 解除用户登录锁定
+func (s *UserService) UnlockUser(id uint, operatorID uint, clientIP, userAgent string) (*model.User, error) {
+	// 获取用户
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireAdminOperator(operatorID, user, nil); err != nil {
+		s.logAuditAction(operatorID, "unlock_user", "user", fmt.Sprintf("解锁用户失败: %s, %v", user.Username, err), clientIP, userAgent, "failed")
+		return nil, err
+	}
+
+	// 清除失败计数和锁定时间
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"failed_attempts": 0,
+		"locked_until":    nil,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("解锁用户失败: %w", err)
+	}
+	user.FailedAttempts = 0
+	user.LockedUntil = nil
+
+	// 记录审计日志
+	s.logAuditAction(operatorID, "unlock_user", "user", fmt.Sprintf("解锁用户: %s", user.Username), clientIP, userAgent, "success")
+
+	logger.Info("解锁用户成功", "username", user.Username, "operator", operatorID)
+	return user, nil
+}
+
 // GetUserStats 获取用户统计信息
 func (s *UserService) GetUserStats() (map[string]interface{}, error) {
 	var totalUsers int64
 	var activeUsers int64
 	var inactiveUsers int64
+	var pendingUsers int64
 
 	// 获取总用户数
 	if err := s.db.Model(&model.User{}).Count(&totalUsers).Error; err != nil {
@@ -347,10 +753,16 @@ func (s *UserService) GetUserStats() (map[string]interface{}, error) {
 		return nil, fmt.Errorf("获取非活跃用户数失败: %w", err)
 	}
 
+	// 获取待审核用户数
+	if err := s.db.Model(&model.User{}).Where("status = ?", model.UserStatusPending).Count(&pendingUsers).Error; err != nil {
+		return nil, fmt.Errorf("获取待审核用户数失败: %w", err)
+	}
+
 	return map[string]interface{}{
 		"total":    totalUsers,
 		"active":   activeUsers,
 		"inactive": inactiveUsers,
+		"pending":  pendingUsers,
 	}, nil
 }
 
@@ -0,0 +1,681 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/model"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestUserService 创建使用内存数据库的用户服务
+func newTestUserService(t *testing.T) (*UserService, *model.User) {
+	t.Helper()
+	db := newTestDB(t)
+	return NewUserService(db, newTestConfig(t)), testAdmin(t, db)
+}
+
+// createTestUser 以管理员身份创建普通用户
+func createTestUser(t *testing.T, s *UserService, admin *model.User, username string) *model.User {
+	t.Helper()
+	user, err := s.CreateUser(&model.CreateUserRequest{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "Passw0rd!",
+		RoleIDs:  []uint{testRole(t, s.db, model.RoleUser).ID},
+	}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("创建用户 %s 失败: %v", username, err)
+	}
+	return user
+}
+
+func TestUserServiceCreateUser(t *testing.T) {
+	s, admin := newTestUserService(t)
+
+	user := createTestUser(t, s, admin, "alice")
+	if user.ID == 0 || user.Status != model.UserStatusActive {
+		t.Fatalf("新用户 ID=%d 状态=%d，期望已保存且为启用状态", user.ID, user.Status)
+	}
+	if len(user.Roles) != 1 || user.Roles[0].Name != model.RoleUser {
+		t.Fatalf("新用户角色 = %v，期望只有 %s", roleNames(user.Roles), model.RoleUser)
+	}
+	if err := user.CheckPassword("Passw0rd!"); err != nil {
+		t.Fatalf("新用户密码校验失败: %v", err)
+	}
+
+	var logs int64
+	s.db.Model(&model.AuditLog{}).Where("action = ? AND status = ?", "create_user", "success").Count(&logs)
+	if logs != 1 {
+		t.Fatalf("create_user 审计日志 %d 条，期望 1 条", logs)
+	}
+}
+
+func TestUserServiceCreateUserRejectsInvalidInput(t *testing.T) {
+	s, admin := newTestUserService(t)
+	createTestUser(t, s, admin, "alice")
+	userRole := testRole(t, s.db, model.RoleUser).ID
+
+	tests := []struct {
+		name string
+		req  model.CreateUserRequest
+	}{
+		{"重复用户名", model.CreateUserRequest{Username: "alice", Email: "other@example.com", Password: "Passw0rd!", RoleIDs: []uint{userRole}}},
+		{"重复邮箱", model.CreateUserRequest{Username: "bob", Email: "alice@example.com", Password: "Passw0rd!", RoleIDs: []uint{userRole}}},
+		{"保留用户名", model.CreateUserRequest{Username: "root", Email: "root@example.com", Password: "Passw0rd!", RoleIDs: []uint{userRole}}},
+		{"弱密码", model.CreateUserRequest{Username: "carol", Email: "carol@example.com", Password: "password", RoleIDs: []uint{userRole}}},
+		{"无效邮箱", model.CreateUserRequest{Username: "dave", Email: "not-an-email", Password: "Passw0rd!", RoleIDs: []uint{userRole}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.CreateUser(&tt.req, admin.ID, "127.0.0.1", "test"); err == nil {
+				t.Fatal("期望创建失败")
+			}
+		})
+	}
+
+	var users int64
+	s.db.Model(&model.User{}).Count(&users)
+	if users != 2 {
+		t.Fatalf("用户总数 = %d，期望 2（管理员和 alice）", users)
+	}
+}
+
+func TestUserServiceGetUsers(t *testing.T) {
+	s, admin := newTestUserService(t)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		createTestUser(t, s, admin, name)
+	}
+
+	users, total, err := s.GetUsers(1, 2, model.UserFilter{})
+	if err != nil {
+		t.Fatalf("查询用户列表失败: %v", err)
+	}
+	if total != 4 || len(users) != 2 {
+		t.Fatalf("total=%d len=%d，期望 total=4 len=2", total, len(users))
+	}
+
+	users, total, err = s.GetUsers(1, 10, model.UserFilter{Search: "ALI"})
+	if err != nil {
+		t.Fatalf("搜索用户失败: %v", err)
+	}
+	if total != 1 || users[0].Username != "alice" {
+		t.Fatalf("搜索 ALI 返回 %d 个用户，期望只有 alice", total)
+	}
+
+	// LIKE 通配符按字面匹配
+	if _, total, _ = s.GetUsers(1, 10, model.UserFilter{Search: "%"}); total != 0 {
+		t.Fatalf("搜索 %% 返回 %d 个用户，期望 0", total)
+	}
+}
+
+func TestUserServiceGetUsersMatchesNicknameAndPhone(t *testing.T) {
+	s, admin := newTestUserService(t)
+	alice := createTestUser(t, s, admin, "alice")
+	bob := createTestUser(t, s, admin, "bob")
+	s.db.Model(alice).Updates(map[string]interface{}{"nickname": "Wonder Land", "phone": "13800138000"})
+	s.db.Model(bob).Updates(map[string]interface{}{"nickname": "Builder", "phone": "13900139000"})
+
+	search := func(filter model.UserFilter) []string {
+		t.Helper()
+		users, total, err := s.GetUsers(1, 10, filter)
+		if err != nil {
+			t.Fatalf("查询用户 %+v 失败: %v", filter, err)
+		}
+		if int(total) != len(users) {
+			t.Fatalf("total=%d len=%d", total, len(users))
+		}
+		names := make([]string, len(users))
+		for i, u := range users {
+			names[i] = u.Username
+		}
+		return names
+	}
+
+	tests := []struct {
+		filter model.UserFilter
+		want   string
+	}{
+		{model.UserFilter{Search: "wonder"}, "alice"},            // 昵称，不区分大小写
+		{model.UserFilter{Search: "BUILD"}, "bob"},               // 昵称
+		{model.UserFilter{Search: "0138"}, "alice"},              // 手机号
+		{model.UserFilter{Search: "1390013"}, "bob"},             // 手机号
+		{model.UserFilter{Username: "bob"}, "bob"},               // 精确用户名
+		{model.UserFilter{Email: "ALICE@example.com"}, "alice"},  // 精确邮箱，不区分大小写
+		{model.UserFilter{Username: "al"}, ""},                   // 精确匹配不做模糊搜索
+		{model.UserFilter{Username: "alice", Search: "139"}, ""}, // 精确条件与模糊搜索同时满足
+		{model.UserFilter{Username: "bob", Search: "139"}, "bob"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(search(tt.filter), ","); got != tt.want {
+			t.Errorf("查询 %+v 返回 %q，期望 %q", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestUserServiceRejectsTooManyRoles(t *testing.T) {
+	s, admin := newTestUserService(t)
+	s.config.Auth.MaxRolesPerUser = 2
+	userRole := testRole(t, s.db, model.RoleUser).ID
+	moderator := testRole(t, s.db, model.RoleModerator).ID
+	guest := testRole(t, s.db, model.RoleGuest).ID
+
+	req := &model.CreateUserRequest{
+		Username: "alice",
+		Email:    "alice@example.com",
+		Password: "Passw0rd!",
+		RoleIDs:  []uint{userRole, moderator, guest},
+	}
+	if _, err := s.CreateUser(req, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrTooManyRoles) {
+		t.Fatalf("创建时超过角色上限返回 %v，期望 ErrTooManyRoles", err)
+	}
+
+	// 重复的角色ID只计一次
+	req.RoleIDs = []uint{userRole, moderator, moderator}
+	user, err := s.CreateUser(req, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("未超过上限时创建失败: %v", err)
+	}
+
+	if _, err := s.UpdateUser(user.ID, &model.UpdateUserRequest{RoleIDs: []uint{userRole, moderator, guest}}, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrTooManyRoles) {
+		t.Fatalf("更新时超过角色上限返回 %v，期望 ErrTooManyRoles", err)
+	}
+	reloaded, err := s.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.Roles) != 2 {
+		t.Fatalf("被拒绝的更新修改了角色: %v", roleNames(reloaded.Roles))
+	}
+
+	// 0 表示不限制
+	s.config.Auth.MaxRolesPerUser = 0
+	if _, err := s.UpdateUser(user.ID, &model.UpdateUserRequest{RoleIDs: []uint{userRole, moderator, guest}}, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("不限制角色数时更新失败: %v", err)
+	}
+}
+
+func TestUserServiceLookupsLoadRoles(t *testing.T) {
+	s, admin := newTestUserService(t)
+
+	// 未预加载角色时 GetRole 会退回 "user"，查询接口必须带上角色
+	lookups := map[string]func() (*model.User, error){
+		"GetUserByID":       func() (*model.User, error) { return s.GetUserByID(admin.ID) },
+		"GetUserByUsername": func() (*model.User, error) { return s.GetUserByUsername(admin.Username) },
+		"GetUserByEmail":    func() (*model.User, error) { return s.GetUserByEmail(admin.Email) },
+	}
+	for name, lookup := range lookups {
+		user, err := lookup()
+		if err != nil {
+			t.Fatalf("%s 失败: %v", name, err)
+		}
+		if user.GetRole() != model.RoleAdmin || !user.IsAdmin() {
+			t.Fatalf("%s 返回的默认管理员角色为 %q, IsAdmin=%v", name, user.GetRole(), user.IsAdmin())
+		}
+	}
+
+	var seeded model.User
+	if err := s.db.Preload("Roles").First(&seeded, admin.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if seeded.GetRole() != "admin" || !seeded.IsAdmin() {
+		t.Fatalf("预加载后默认管理员角色为 %q, IsAdmin=%v", seeded.GetRole(), seeded.IsAdmin())
+	}
+}
+
+func TestUserServiceUpdateUser(t *testing.T) {
+	s, admin := newTestUserService(t)
+	user := createTestUser(t, s, admin, "alice")
+
+	updated, err := s.UpdateUser(user.ID, &model.UpdateUserRequest{Nickname: "Alice"}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("更新用户失败: %v", err)
+	}
+	if updated.Nickname != "Alice" || updated.Username != "alice" {
+		t.Fatalf("更新后 nickname=%q username=%q", updated.Nickname, updated.Username)
+	}
+
+	createTestUser(t, s, admin, "bob")
+	if _, err := s.UpdateUser(user.ID, &model.UpdateUserRequest{Username: "bob"}, admin.ID, "127.0.0.1", "test"); err == nil {
+		t.Fatal("改成已存在的用户名时期望失败")
+	}
+}
+
+func TestUserServiceDeleteUser(t *testing.T) {
+	s, admin := newTestUserService(t)
+	user := createTestUser(t, s, admin, "alice")
+	s.db.Create(&model.Session{ID: "alice-session", UserID: user.ID, Token: "alice-token", ExpiresAt: time.Now().Add(time.Hour)})
+
+	if err := s.DeleteUser(user.ID, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("删除用户失败: %v", err)
+	}
+	if _, err := s.GetUserByID(user.ID); err == nil {
+		t.Fatal("删除后仍能查到用户")
+	}
+	var sessions int64
+	s.db.Model(&model.Session{}).Where("user_id = ?", user.ID).Count(&sessions)
+	if sessions != 0 {
+		t.Fatalf("删除用户后还剩 %d 个会话", sessions)
+	}
+
+	if err := s.DeleteUser(admin.ID, admin.ID, "127.0.0.1", "test"); err == nil {
+		t.Fatal("删除自己时期望失败")
+	}
+}
+
+func TestUserServiceKeepsLastAdmin(t *testing.T) {
+	s, admin := newTestUserService(t)
+	operator := createTestUser(t, s, admin, "alice")
+
+	if err := s.DeleteUser(admin.ID, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("删除最后一个管理员返回 %v，期望 ErrLastAdmin", err)
+	}
+
+	disabled := model.UserStatusInactive
+	if _, err := s.UpdateUser(admin.ID, &model.UpdateUserRequest{Status: &disabled}, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("禁用最后一个管理员返回 %v，期望 ErrLastAdmin", err)
+	}
+
+	if _, err := s.ChangeUserStatus(admin.ID, model.UserStatusBlocked, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("封禁最后一个管理员返回 %v，期望 ErrLastAdmin", err)
+	}
+
+	userRole := testRole(t, s.db, model.RoleUser).ID
+	if _, err := s.UpdateUser(admin.ID, &model.UpdateUserRequest{RoleIDs: []uint{userRole}}, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("移除最后一个管理员的管理员角色返回 %v，期望 ErrLastAdmin", err)
+	}
+
+	if reloaded := testAdmin(t, s.db); !reloaded.IsActive() || !reloaded.IsAdmin() {
+		t.Fatalf("被拒绝的操作修改了管理员: status=%v admin=%v", reloaded.Status, reloaded.IsAdmin())
+	}
+}
+
+func TestUserServiceLastAdminIgnoresUnusableAdmins(t *testing.T) {
+	s, admin := newTestUserService(t)
+	operator := createTestUser(t, s, admin, "alice")
+	adminRole := testRole(t, s.db, model.RoleAdmin)
+	bob, err := s.CreateUser(&model.CreateUserRequest{
+		Username: "bob",
+		Email:    "bob@example.com",
+		Password: "Passw0rd!",
+		RoleIDs:  []uint{adminRole.ID},
+	}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("创建管理员 bob 失败: %v", err)
+	}
+
+	// 另一个管理员处于登录锁定中，不算可用管理员
+	s.db.Model(bob).Update("locked_until", time.Now().Add(time.Hour))
+	if _, err := s.ChangeUserStatus(admin.ID, model.UserStatusBlocked, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("另一个管理员被锁定时封禁管理员返回 %v，期望 ErrLastAdmin", err)
+	}
+	s.db.Model(bob).Update("locked_until", nil)
+
+	// 管理员角色被禁用时同样没有可用管理员
+	s.db.Model(adminRole).Update("status", model.RoleStatusInactive)
+	if err := s.DeleteUser(admin.ID, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("管理员角色禁用时删除管理员返回 %v，期望 ErrLastAdmin", err)
+	}
+	s.db.Model(adminRole).Update("status", model.RoleStatusActive)
+
+	// 还有可用的管理员时允许移除
+	userRole := testRole(t, s.db, model.RoleUser).ID
+	if _, err := s.UpdateUser(admin.ID, &model.UpdateUserRequest{RoleIDs: []uint{userRole}}, bob.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("还有其他管理员时移除管理员角色失败: %v", err)
+	}
+}
+
+func TestUserServiceAdminChangesRequireAdminOperator(t *testing.T) {
+	s, admin := newTestUserService(t)
+	operator := createTestUser(t, s, admin, "alice")
+	adminRole := testRole(t, s.db, model.RoleAdmin).ID
+	bob, err := s.CreateUser(&model.CreateUserRequest{
+		Username: "bob",
+		Email:    "bob@example.com",
+		Password: "Passw0rd!",
+		RoleIDs:  []uint{adminRole},
+	}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("创建管理员 bob 失败: %v", err)
+	}
+
+	// 非管理员不能给自己或他人分配管理员角色
+	if _, err := s.UpdateUser(operator.ID, &model.UpdateUserRequest{RoleIDs: []uint{adminRole}}, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrAdminRequired) {
+		t.Fatalf("非管理员给自己分配管理员角色返回 %v，期望 ErrAdminRequired", err)
+	}
+	if _, err := s.CreateUser(&model.CreateUserRequest{
+		Username: "carol",
+		Email:    "carol@example.com",
+		Password: "Passw0rd!",
+		RoleIDs:  []uint{adminRole},
+	}, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrAdminRequired) {
+		t.Fatalf("非管理员创建管理员返回 %v，期望 ErrAdminRequired", err)
+	}
+
+	// 非管理员不能修改管理员账户
+	if err := s.ResetUserPassword(bob.ID, "N3w-Passw0rd!", operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrAdminRequired) {
+		t.Fatalf("非管理员重置管理员密码返回 %v，期望 ErrAdminRequired", err)
+	}
+	if _, err := s.UpdateUser(bob.ID, &model.UpdateUserRequest{Email: "mallory@example.com"}, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrAdminRequired) {
+		t.Fatalf("非管理员修改管理员邮箱返回 %v，期望 ErrAdminRequired", err)
+	}
+	if err := s.DeleteUser(bob.ID, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrAdminRequired) {
+		t.Fatalf("非管理员删除管理员返回 %v，期望 ErrAdminRequired", err)
+	}
+	// 非管理员不能解除正在被暴力破解的管理员账户的锁定
+	lockedUntil := time.Now().Add(time.Hour)
+	s.db.Model(bob).Updates(map[string]interface{}{"failed_attempts": 5, "locked_until": lockedUntil})
+	if _, err := s.UnlockUser(bob.ID, operator.ID, "127.0.0.1", "test"); !errors.Is(err, ErrAdminRequired) {
+		t.Fatalf("非管理员解锁管理员返回 %v，期望 ErrAdminRequired", err)
+	}
+	if reloaded, _ := s.GetUserByID(bob.ID); reloaded == nil || reloaded.Email != "bob@example.com" || reloaded.CheckPassword("Passw0rd!") != nil || !reloaded.IsLocked() {
+		t.Fatal("被拒绝的操作修改了管理员账户")
+	}
+	var failed model.AuditLog
+	if err := s.db.Where("action = ? AND status = ?", "unlock_user", "failed").First(&failed).Error; err != nil {
+		t.Fatalf("缺少解锁失败的审计日志: %v", err)
+	}
+
+	// 非管理员仍可修改普通用户，管理员可以修改管理员
+	carol := createTestUser(t, s, admin, "carol")
+	if _, err := s.UpdateUser(carol.ID, &model.UpdateUserRequest{Nickname: "Carol"}, operator.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("非管理员修改普通用户失败: %v", err)
+	}
+	if err := s.ResetUserPassword(bob.ID, "N3w-Passw0rd!", admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("管理员重置管理员密码失败: %v", err)
+	}
+	if _, err := s.UnlockUser(bob.ID, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("管理员解锁管理员失败: %v", err)
+	}
+}
+
+func TestUserServiceUnlockUser(t *testing.T) {
+	s, admin := newTestUserService(t)
+	user := createTestUser(t, s, admin, "alice")
+	s.db.Model(user).Updates(map[string]interface{}{"failed_attempts": 5, "locked_until": time.Now().Add(time.Hour)})
+
+	unlocked, err := s.UnlockUser(user.ID, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("解锁用户失败: %v", err)
+	}
+	if unlocked.IsLocked() || unlocked.FailedAttempts != 0 {
+		t.Fatalf("解锁后 locked=%v failed_attempts=%d", unlocked.IsLocked(), unlocked.FailedAttempts)
+	}
+
+	reloaded, _ := s.GetUserByID(user.ID)
+	if reloaded.IsLocked() || reloaded.FailedAttempts != 0 {
+		t.Fatal("解锁未保存到数据库")
+	}
+}
+
+func TestUserServiceResetUserPassword(t *testing.T) {
+	s, admin := newTestUserService(t)
+	s.config.Auth.RevokeOnReset = true
+	user := createTestUser(t, s, admin, "alice")
+	s.db.Create(&model.Session{ID: "alice-session", UserID: user.ID, Token: "alice-token", ExpiresAt: time.Now().Add(time.Hour)})
+
+	const newPassword = "NewPassw0rd!"
+	if err := s.ResetUserPassword(user.ID, newPassword, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+
+	reloaded, err := s.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	if reloaded.Password == newPassword {
+		t.Fatal("密码以明文保存")
+	}
+	if _, err := bcrypt.Cost([]byte(reloaded.Password)); err != nil {
+		t.Fatalf("保存的密码不是 bcrypt 哈希: %v", err)
+	}
+	if err := reloaded.CheckPassword(newPassword); err != nil {
+		t.Fatalf("新密码校验失败: %v", err)
+	}
+	if err := reloaded.CheckPassword("Passw0rd!"); err == nil {
+		t.Fatal("旧密码仍然有效")
+	}
+
+	var sessions int64
+	s.db.Model(&model.Session{}).Where("user_id = ?", user.ID).Count(&sessions)
+	if sessions != 0 {
+		t.Fatalf("重置密码后还剩 %d 个会话", sessions)
+	}
+}
+
+func TestUserServiceResetUserPasswordKeepsSessionsWhenRevocationDisabled(t *testing.T) {
+	s, admin := newTestUserService(t)
+	s.config.Auth.RevokeOnReset = false
+	s.config.Auth.NotifyPasswordReset = true
+	notifier := &recordingNotifier{}
+	s.SetNotifier(notifier)
+	user := createTestUser(t, s, admin, "alice")
+	s.db.Create(&model.Session{ID: "alice-session", UserID: user.ID, Token: "alice-token", ExpiresAt: time.Now().Add(time.Hour)})
+
+	if err := s.ResetUserPassword(user.ID, "NewPassw0rd!", admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+
+	var sessions int64
+	s.db.Model(&model.Session{}).Where("user_id = ?", user.ID).Count(&sessions)
+	if sessions != 1 {
+		t.Fatalf("关闭 revoke_on_reset 时会话数 = %d, 期望保留 1 个", sessions)
+	}
+	if len(notifier.userIDs) != 1 || notifier.userIDs[0] != user.ID {
+		t.Fatalf("应通知被重置的用户: %v", notifier.userIDs)
+	}
+
+	var logs int64
+	s.db.Model(&model.AuditLog{}).Where("action = ? AND user_id = ?", "重置用户密码", admin.ID).Count(&logs)
+	if logs != 1 {
+		t.Fatalf("重置密码审计日志 %d 条，期望 1 条", logs)
+	}
+}
+
+// recordingMailer 记录发送的邮件
+type recordingMailer struct {
+	to       []string
+	subjects []string
+	bodies   []string
+}
+
+func (m *recordingMailer) SendMail(to, subject, body string) error {
+	m.to = append(m.to, to)
+	m.subjects = append(m.subjects, subject)
+	m.bodies = append(m.bodies, body)
+	return nil
+}
+
+func TestUserServiceResetUserPasswordEmailsUser(t *testing.T) {
+	s, admin := newTestUserService(t)
+	mailer := &recordingMailer{}
+	s.SetMailer(mailer)
+	user := createTestUser(t, s, admin, "alice")
+
+	// 未启用邮件通知时不发送
+	s.config.Auth.EmailPasswordReset = false
+	if err := s.ResetUserPassword(user.ID, "NewPassw0rd!", admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+	if len(mailer.to) != 0 {
+		t.Fatalf("未启用 email_password_reset 时发送了邮件: %v", mailer.to)
+	}
+
+	s.config.Auth.EmailPasswordReset = true
+	if err := s.ResetUserPassword(user.ID, "OtherPassw0rd!", admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+	if len(mailer.to) != 1 || mailer.to[0] != "alice@example.com" {
+		t.Fatalf("应向用户邮箱发送重置通知: %v", mailer.to)
+	}
+	if mailer.subjects[0] != "密码已重置" || !strings.Contains(mailer.bodies[0], "被管理员重置") {
+		t.Fatalf("重置通知内容 = %q / %q", mailer.subjects[0], mailer.bodies[0])
+	}
+	if strings.Contains(mailer.bodies[0], "OtherPassw0rd!") {
+		t.Fatal("通知邮件不应包含新密码")
+	}
+
+	// 用户未设置邮箱时跳过
+	s.db.Model(user).Update("email", "")
+	if err := s.ResetUserPassword(user.ID, "ThirdPassw0rd!", admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+	if len(mailer.to) != 1 {
+		t.Fatalf("用户没有邮箱时不应发送: %v", mailer.to)
+	}
+}
+
+func TestUserServiceResetUserPasswordRejectsWeakPassword(t *testing.T) {
+	s, admin := newTestUserService(t)
+	user := createTestUser(t, s, admin, "alice")
+
+	for _, weak := range []string{"admin123", "short1A", "NoDigitsHere"} {
+		if err := s.ResetUserPassword(user.ID, weak, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrInvalidPassword) {
+			t.Fatalf("重置为 %q 返回 %v，期望 ErrInvalidPassword", weak, err)
+		}
+	}
+	reloaded, _ := s.GetUserByID(user.ID)
+	if err := reloaded.CheckPassword("Passw0rd!"); err != nil {
+		t.Fatal("弱密码被拒绝后原密码失效")
+	}
+}
+
+func TestUserServiceConcurrentCreate(t *testing.T) {
+	cfg := newTestConfig(t)
+	db := newTestFileDB(t, cfg)
+	s := NewUserService(db, cfg)
+	admin := testAdmin(t, db)
+	userRole := testRole(t, db, model.RoleUser).ID
+
+	// 多个请求同时创建用户，锁冲突由 busy_timeout 和重试处理，不应返回给调用方
+	const workers = 20
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("user%02d", i)
+			_, err := s.CreateUser(&model.CreateUserRequest{
+				Username: name,
+				Email:    name + "@example.com",
+				Password: "Passw0rd!",
+				RoleIDs:  []uint{userRole},
+			}, admin.ID, "127.0.0.1", "test")
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("并发创建用户失败: %v", err)
+		}
+	}
+	var users int64
+	db.Model(&model.User{}).Count(&users)
+	if users != workers+1 {
+		t.Fatalf("用户总数 = %d，期望 %d", users, workers+1)
+	}
+}
+
+// recordingNotifier 记录发送给用户的通知
+type recordingNotifier struct {
+	mutex   sync.Mutex
+	userIDs []uint
+}
+
+func (n *recordingNotifier) NotifyUser(userID uint, title, content, level string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.userIDs = append(n.userIDs, userID)
+}
+
+// createPendingUser 以管理员身份创建待审核用户
+func createPendingUser(t *testing.T, s *UserService, admin *model.User, username string) *model.User {
+	t.Helper()
+	user, err := s.CreateUser(&model.CreateUserRequest{
+		Username: username,
+		Email:    username + "@example.com",
+		Password: "Passw0rd!",
+		RoleIDs:  []uint{testRole(t, s.db, model.RoleUser).ID},
+		Pending:  true,
+	}, admin.ID, "127.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("创建用户 %s 失败: %v", username, err)
+	}
+	if !user.IsPending() {
+		t.Fatalf("用户 %s 状态为 %s，期望待审核", username, user.Status)
+	}
+	return user
+}
+
+func TestUserServiceApprovalWorkflow(t *testing.T) {
+	s, admin := newTestUserService(t)
+	s.config.Auth.RequireApproval = true
+	s.config.Auth.NotifyOnApproval = true
+	notifier := &recordingNotifier{}
+	s.SetNotifier(notifier)
+	auth := NewAuthService(s.db, s.config, NewRBACCache(s.db))
+	login := func(username string) error {
+		_, err := auth.Login(&model.LoginRequest{Username: username, Password: "Passw0rd!"}, "127.0.0.1", "test")
+		return err
+	}
+
+	alice := createPendingUser(t, s, admin, "alice")
+	bob := createPendingUser(t, s, admin, "bob")
+
+	// 待审核用户不能登录
+	if err := login("alice"); !errors.Is(err, ErrAccountPending) {
+		t.Fatalf("待审核用户登录应返回 ErrAccountPending，实际 %v", err)
+	}
+	pending, err := s.GetPendingUsers()
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("待审核用户 %d 个, %v", len(pending), err)
+	}
+
+	// 批准后可以登录并收到通知，重复批准返回 ErrUserNotPending
+	if _, err := s.ApproveUser(alice.ID, admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("批准用户失败: %v", err)
+	}
+	if err := login("alice"); err != nil {
+		t.Fatalf("批准后登录失败: %v", err)
+	}
+	if len(notifier.userIDs) != 1 || notifier.userIDs[0] != alice.ID {
+		t.Fatalf("批准通知 %v，期望通知用户 %d", notifier.userIDs, alice.ID)
+	}
+	if _, err := s.ApproveUser(alice.ID, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrUserNotPending) {
+		t.Fatalf("重复批准应返回 ErrUserNotPending，实际 %v", err)
+	}
+
+	// 拒绝后账户被禁用，不能登录也不能再批准
+	if _, err := s.RejectUser(bob.ID, "信息不完整", admin.ID, "127.0.0.1", "test"); err != nil {
+		t.Fatalf("拒绝用户失败: %v", err)
+	}
+	if err := login("bob"); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("被拒绝用户登录应返回 ErrUserDisabled，实际 %v", err)
+	}
+	if _, err := s.ApproveUser(bob.ID, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrUserNotPending) {
+		t.Fatalf("批准已拒绝的用户应返回 ErrUserNotPending，实际 %v", err)
+	}
+	if _, err := s.ApproveUser(9999, admin.ID, "127.0.0.1", "test"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("批准不存在的用户应返回 ErrUserNotFound，实际 %v", err)
+	}
+
+	// 审核操作以其他用户为对象，不出现在操作者的个人活动记录中
+	logs, _, err := NewAuditService(s.db).GetUserActivity(admin.ID, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, log := range logs {
+		if log.Action == "approve_user" || log.Action == "reject_user" {
+			t.Fatalf("个人活动记录中出现了 %s", log.Action)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package shardmap 提供分片加锁的并发map
+// 键按哈希分配到固定数量的分片，每个分片一把锁，不同分片上的读写互不阻塞，
+// 用于限流计数、登录失败统计、上传名额等高并发访问的内存状态
+package shardmap
+
+import (
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// DefaultShards 默认分片数
+const DefaultShards = 32
+
+// shard 单个分片
+type shard[K comparable, V any] struct {
+	mutex sync.Mutex
+	items map[K]V
+}
+
+// Map 分片并发map，零值不可用，需通过 New 或 NewBounded 创建
+type Map[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []*shard[K, V]
+	limit  int // 每个分片的最大条目数，0表示不限制
+}
+
+// New 创建分片map，shards<=0 时使用 DefaultShards
+func New[K comparable, V any](shards int) *Map[K, V] {
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	m := &Map[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*shard[K, V], shards),
+	}
+	for i := range m.shards {
+		m.shards[i] = &shard[K, V]{items: make(map[K]V)}
+	}
+	return m
+}
+
+// NewBounded 创建每个分片最多保存 limit 个条目的分片map，limit<=0 时不限制
+// 分片已满时写入新键会先淘汰该分片中任意一个已有条目，用于键由客户端决定（如客户端IP）的场景，
+// 防止大量不同的键耗尽内存
+func NewBounded[K comparable, V any](shards, limit int) *Map[K, V] {
+	m := New[K, V](shards)
+	if limit > 0 {
+		m.limit = limit
+	}
+	return m
+}
+
+// shardFor 键所在的分片
+func (m *Map[K, V]) shardFor(key K) *shard[K, V] {
+	return m.shards[maphash.Comparable(m.seed, key)%uint64(len(m.shards))]
+}
+
+// Get 获取键对应的值
+func (m *Map[K, V]) Get(key K) (V, bool) {
+	s := m.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	value, ok := s.items[key]
+	return value, ok
+}
+
+// Set 设置键对应的值
+func (m *Map[K, V]) Set(key K, value V) {
+	s := m.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	m.store(s, key, value)
+}
+
+// Delete 删除键
+func (m *Map[K, V]) Delete(key K) {
+	s := m.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.items, key)
+}
+
+// Update 在分片锁内读取、修改并写回键对应的值，保证检查和更新之间不会被其他操作插入
+// fn 收到当前值（不存在时为零值和false），返回新值和是否保留；keep 为false时删除该键
+// fn 在持有分片锁时调用，不能再访问同一个 Map
+func (m *Map[K, V]) Update(key K, fn func(value V, ok bool) (newValue V, keep bool)) V {
+	s := m.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	value, ok := s.items[key]
+	value, keep := fn(value, ok)
+	if keep {
+		m.store(s, key, value)
+	} else {
+		delete(s.items, key)
+	}
+	return value
+}
+
+// store 写入条目，分片已满且键不存在时先淘汰任意一个条目，调用方需持有分片锁
+func (m *Map[K, V]) store(s *shard[K, V], key K, value V) {
+	if m.limit > 0 && len(s.items) >= m.limit {
+		if _, exists := s.items[key]; !exists {
+			for victim := range s.items {
+				delete(s.items, victim)
+				break
+			}
+		}
+	}
+	s.items[key] = value
+}
+
+// Len 返回条目总数，各分片分别加锁统计，并发修改时结果是近似值
+func (m *Map[K, V]) Len() int {
+	n := 0
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		n += len(s.items)
+		s.mutex.Unlock()
+	}
+	return n
+}
+
+// Sweep 删除 expired 返回true的条目，逐个分片加锁处理，返回删除的条目数
+// expired 在持有分片锁时调用，不能再访问同一个 Map
+func (m *Map[K, V]) Sweep(expired func(key K, value V) bool) int {
+	removed := 0
+	for _, s := range m.shards {
+		s.mutex.Lock()
+		for key, value := range s.items {
+			if expired(key, value) {
+				delete(s.items, key)
+				removed++
+			}
+		}
+		s.mutex.Unlock()
+	}
+	return removed
+}
+
+// StartSweeper 启动后台清理协程，每隔 interval 对所有分片执行一次 Sweep，返回停止函数
+// 清理时一次只锁一个分片，其余分片上的读写不受影响；interval<=0 时不启动
+func (m *Map[K, V]) StartSweeper(interval time.Duration, expired func(key K, value V) bool) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				m.Sweep(expired)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
@@ -0,0 +1,171 @@
+package shardmap
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMapBasicOperations(t *testing.T) {
+	m := New[string, int](4)
+	m.Set("a", 1)
+	m.Set("b", 2)
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %d, %v，期望 1, true", v, ok)
+	}
+
+	m.Update("a", func(v int, ok bool) (int, bool) { return v + 10, true })
+	if v, _ := m.Get("a"); v != 11 {
+		t.Fatalf("Update 后 a = %d，期望 11", v)
+	}
+	m.Update("b", func(int, bool) (int, bool) { return 0, false })
+	if _, ok := m.Get("b"); ok {
+		t.Fatal("keep=false 时应删除键")
+	}
+
+	m.Delete("a")
+	if m.Len() != 0 {
+		t.Fatalf("Len = %d，期望 0", m.Len())
+	}
+}
+
+func TestMapConcurrentUpdate(t *testing.T) {
+	m := New[int, int](DefaultShards)
+	const workers, perWorker, keys = 16, 1000, 64
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				m.Update(i%keys, func(v int, _ bool) (int, bool) { return v + 1, true })
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for k := 0; k < keys; k++ {
+		v, _ := m.Get(k)
+		total += v
+	}
+	if total != workers*perWorker {
+		t.Fatalf("计数总和 = %d，期望 %d", total, workers*perWorker)
+	}
+}
+
+func TestMapSweep(t *testing.T) {
+	m := New[int, int](4)
+	for i := 0; i < 10; i++ {
+		m.Set(i, i)
+	}
+	if removed := m.Sweep(func(_ int, v int) bool { return v%2 == 0 }); removed != 5 {
+		t.Fatalf("Sweep 删除 %d 个，期望 5 个", removed)
+	}
+	if m.Len() != 5 {
+		t.Fatalf("Len = %d，期望 5", m.Len())
+	}
+}
+
+func TestBoundedMapLimitsEntriesPerShard(t *testing.T) {
+	m := NewBounded[int, int](4, 3)
+	for i := 0; i < 100; i++ {
+		m.Update(i, func(int, bool) (int, bool) { return i, true })
+	}
+	if n := m.Len(); n > 4*3 {
+		t.Fatalf("Len = %d，超过上限 %d", n, 4*3)
+	}
+	for _, s := range m.shards {
+		if len(s.items) > 3 {
+			t.Fatalf("分片条目数 %d 超过上限 3", len(s.items))
+		}
+	}
+
+	// 更新已有的键不淘汰其他条目
+	m.Set(99, 1)
+	before := m.Len()
+	m.Set(99, 2)
+	if v, ok := m.Get(99); !ok || v != 2 || m.Len() != before {
+		t.Fatalf("更新已有键后 Get = %d, %v，Len %d -> %d", v, ok, before, m.Len())
+	}
+}
+
+func TestMapStartSweeperStops(t *testing.T) {
+	m := New[string, int](4)
+	stop := m.StartSweeper(time.Millisecond, func(string, int) bool { return true })
+
+	m.Set("a", 1)
+	deadline := time.Now().Add(time.Second)
+	for m.Len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if m.Len() != 0 {
+		t.Fatal("后台清理未删除过期条目")
+	}
+
+	stop()
+	stop() // 重复调用不会panic
+	time.Sleep(5 * time.Millisecond)
+	m.Set("b", 1)
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := m.Get("b"); !ok {
+		t.Fatal("停止后仍在清理")
+	}
+}
+
+// lockedMap 单把锁保护的map，作为基准测试的对照
+type lockedMap[K comparable, V any] struct {
+	mutex sync.Mutex
+	items map[K]V
+}
+
+func (m *lockedMap[K, V]) Update(key K, fn func(value V, ok bool) (V, bool)) V {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	value, ok := m.items[key]
+	value, keep := fn(value, ok)
+	if keep {
+		m.items[key] = value
+	} else {
+		delete(m.items, key)
+	}
+	return value
+}
+
+// benchmarkKeys 模拟限流器按客户端IP计数的键
+func benchmarkKeys() []string {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "10.0." + strconv.Itoa(i/256) + "." + strconv.Itoa(i%256)
+	}
+	return keys
+}
+
+// increment 限流计数的更新操作
+func increment(v int, _ bool) (int, bool) { return v + 1, true }
+
+func BenchmarkSingleMutex(b *testing.B) {
+	m := &lockedMap[string, int]{items: make(map[string]int)}
+	keys := benchmarkKeys()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Update(keys[i%len(keys)], increment)
+			i++
+		}
+	})
+}
+
+func BenchmarkSharded(b *testing.B) {
+	m := New[string, int](DefaultShards)
+	keys := benchmarkKeys()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Update(keys[i%len(keys)], increment)
+			i++
+		}
+	})
+}
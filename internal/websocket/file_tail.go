@@ -0,0 +1,185 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientTail 连接上的一次文件跟踪
+type clientTail struct {
+	cancel context.CancelFunc
+	tail   *service.FileTail
+}
+
+// fileTailRequest 客户端发送的 file_tail 消息数据
+type fileTailRequest struct {
+	Path  string `json:"path"`
+	Lines int    `json:"lines"`
+}
+
+// fileTailStopRequest 客户端发送的 file_tail_stop 消息数据
+type fileTailStopRequest struct {
+	TailID string `json:"tail_id"`
+}
+
+// decodeMessageData 将消息中的 data 字段解码到目标结构
+func decodeMessageData(data interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// startTail 开始跟踪文件：先发送末尾N行，之后只向本连接推送新增内容
+func (c *Client) startTail(data interface{}) {
+	if c.manager.fileTail == nil {
+		c.sendTailError("", "文件跟踪不可用")
+		return
+	}
+
+	var req fileTailRequest
+	if err := decodeMessageData(data, &req); err != nil || req.Path == "" {
+		c.sendTailError(req.Path, "无效的跟踪请求")
+		return
+	}
+	if !c.canDownload {
+		c.sendTailError(req.Path, "权限不足")
+		return
+	}
+
+	tailID := generateConnectionID()
+	ctx, cancel := context.WithCancel(context.Background())
+	emit := func(chunk string, reset bool) {
+		c.manager.sendToClient(c, Message{
+			Type: MessageTypeFileTail,
+			Data: gin.H{
+				"tail_id": tailID,
+				"path":    req.Path,
+				"data":    chunk,
+				"reset":   reset,
+			},
+			Timestamp: time.Now(),
+		})
+	}
+
+	tail, err := c.manager.fileTail.Start(ctx, req.Path, req.Lines, c.userID, c.remoteAddr, c.userAgent, emit)
+	if err != nil {
+		cancel()
+		c.sendTailError(req.Path, err.Error())
+		return
+	}
+
+	c.tailMu.Lock()
+	if c.tails == nil {
+		c.tails = make(map[string]*clientTail)
+	}
+	c.tails[tailID] = &clientTail{cancel: cancel, tail: tail}
+	c.tailMu.Unlock()
+
+	c.manager.sendToClient(c, Message{
+		Type: MessageTypeFileTail,
+		Data: gin.H{
+			"tail_id": tailID,
+			"path":    req.Path,
+			"data":    tail.Initial,
+			"initial": true,
+		},
+		Timestamp: time.Now(),
+	})
+
+	// 跟踪因文件不可读等原因自行结束时通知客户端并清理记录
+	go func() {
+		<-tail.Done()
+		c.tailMu.Lock()
+		_, active := c.tails[tailID]
+		delete(c.tails, tailID)
+		c.tailMu.Unlock()
+		if active && ctx.Err() == nil {
+			c.manager.sendToClient(c, Message{
+				Type:      MessageTypeFileTailEnd,
+				Data:      gin.H{"tail_id": tailID, "path": req.Path},
+				Timestamp: time.Now(),
+			})
+		}
+	}()
+}
+
+// stopTail 停止客户端指定的文件跟踪
+func (c *Client) stopTail(data interface{}) {
+	var req fileTailStopRequest
+	if err := decodeMessageData(data, &req); err != nil || req.TailID == "" {
+		c.sendTailError("", "无效的停止跟踪请求")
+		return
+	}
+
+	c.tailMu.Lock()
+	t, ok := c.tails[req.TailID]
+	delete(c.tails, req.TailID)
+	c.tailMu.Unlock()
+	if !ok {
+		return
+	}
+
+	t.cancel()
+	<-t.tail.Done()
+	c.manager.sendToClient(c, Message{
+		Type:      MessageTypeFileTailEnd,
+		Data:      gin.H{"tail_id": req.TailID, "path": t.tail.Path},
+		Timestamp: time.Now(),
+	})
+}
+
+// stopAllTails 连接断开时停止所有跟踪并等待跟踪协程退出
+func (c *Client) stopAllTails() {
+	c.tailMu.Lock()
+	tails := c.tails
+	c.tails = nil
+	c.tailMu.Unlock()
+
+	for _, t := range tails {
+		t.cancel()
+	}
+	for _, t := range tails {
+		<-t.tail.Done()
+	}
+}
+
+// sendTailError 向客户端发送文件跟踪错误
+func (c *Client) sendTailError(path, message string) {
+	c.manager.sendToClient(c, Message{
+		Type: MessageTypeError,
+		Data: gin.H{
+			"request": MessageTypeFileTail,
+			"path":    path,
+			"message": message,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// sendToClient 向单个仍在连接中的客户端发送消息，发送队列满时丢弃
+func (manager *WebSocketManager) sendToClient(client *Client, message Message) {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		logger.Error("WebSocket消息序列化失败", "error", err)
+		return
+	}
+
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	if !manager.clients[client] {
+		return
+	}
+	select {
+	case client.send <- messageBytes:
+	default:
+		logger.Warn("WebSocket客户端发送队列已满", "user_id", client.userID)
+	}
+}
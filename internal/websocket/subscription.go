@@ -0,0 +1,138 @@
+package websocket
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// subscribableTopics 需要订阅才会收到的广播主题（与消息类型同名），其他广播发送给所有连接
+var subscribableTopics = map[string]bool{
+	MessageTypeSystemStats: true,
+	MessageTypeProcessList: true,
+	MessageTypeUserJoined:  true,
+	MessageTypeUserLeft:    true,
+}
+
+// monitorTopics 需要系统监控权限才能订阅的主题
+var monitorTopics = map[string]bool{
+	MessageTypeProcessList: true,
+}
+
+// broadcastItem 广播队列中的消息，topic 为空表示发送给所有连接
+type broadcastItem struct {
+	topic string
+	data  []byte
+}
+
+// subscriptionRequest subscribe/unsubscribe 消息的数据
+type subscriptionRequest struct {
+	Topics []string `json:"topics"`
+}
+
+// updateSubscriptions 订阅或取消订阅主题，完成后把当前订阅列表回复给客户端
+// 请求中有未知主题时整个请求不生效
+func (c *Client) updateSubscriptions(data interface{}, subscribe bool) {
+	request := MessageTypeUnsubscribe
+	if subscribe {
+		request = MessageTypeSubscribe
+	}
+
+	var req subscriptionRequest
+	if err := decodeMessageData(data, &req); err != nil || len(req.Topics) == 0 {
+		c.sendSubscriptionError(request, "无效的订阅请求", nil)
+		return
+	}
+
+	var unknown []string
+	for _, topic := range req.Topics {
+		if !subscribableTopics[topic] {
+			unknown = append(unknown, topic)
+		}
+	}
+	if len(unknown) > 0 {
+		c.sendSubscriptionError(request, "未知的订阅主题", unknown)
+		return
+	}
+	if subscribe && !c.canMonitor {
+		var forbidden []string
+		for _, topic := range req.Topics {
+			if monitorTopics[topic] {
+				forbidden = append(forbidden, topic)
+			}
+		}
+		if len(forbidden) > 0 {
+			c.sendSubscriptionError(request, "无权订阅的主题", forbidden)
+			return
+		}
+	}
+
+	c.subMu.Lock()
+	if c.subscriptions == nil {
+		c.subscriptions = make(map[string]bool)
+	}
+	for _, topic := range req.Topics {
+		if subscribe {
+			c.subscriptions[topic] = true
+		} else {
+			delete(c.subscriptions, topic)
+		}
+	}
+	c.subMu.Unlock()
+
+	c.manager.sendToClient(c, Message{
+		Type:      MessageTypeSubscriptions,
+		Data:      gin.H{"topics": c.subscribedTopics()},
+		Timestamp: time.Now(),
+	})
+}
+
+// subscribed 是否已订阅主题
+func (c *Client) subscribed(topic string) bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	return c.subscriptions[topic]
+}
+
+// HasSubscribers 是否有连接订阅了主题，用于在无人订阅时跳过采集
+func (manager *WebSocketManager) HasSubscribers(topic string) bool {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	for client := range manager.clients {
+		if client.subscribed(topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribedTopics 已订阅的主题，按名称排序
+func (c *Client) subscribedTopics() []string {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+
+	topics := make([]string, 0, len(c.subscriptions))
+	for topic := range c.subscriptions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// sendSubscriptionError 向客户端发送订阅请求的错误
+func (c *Client) sendSubscriptionError(request, message string, topics []string) {
+	data := gin.H{
+		"request": request,
+		"message": message,
+	}
+	if len(topics) > 0 {
+		data["topics"] = topics
+	}
+	c.manager.sendToClient(c, Message{
+		Type:      MessageTypeError,
+		Data:      data,
+		Timestamp: time.Now(),
+	})
+}
@@ -1,14 +1,22 @@
 package websocket
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"web-panel-go/internal/config"
 	"web-panel-go/internal/logger"
 	"web-panel-go/internal/middleware"
 	"web-panel-go/internal/model"
+	"web-panel-go/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -16,21 +24,44 @@ import (
 
 // WebSocketManager WebSocket管理器
 type WebSocketManager struct {
-	clients    map[*Client]bool
-	broadcast  chan []byte
-	register   chan *Client
-	unregister chan *Client
-	mutex      sync.RWMutex
-	upgrader   websocket.Upgrader
+	clients     map[*Client]bool
+	broadcast   chan broadcastItem
+	register    chan *Client
+	unregister  chan *Client
+	mutex       sync.RWMutex
+	upgrader    websocket.Upgrader
+	idleTimeout time.Duration
+	fileTail    *service.FileTailService
+	closing     atomic.Bool // 服务器正在关闭，不再接受新连接
 }
 
 // Client WebSocket客户端
 type Client struct {
-	conn     *websocket.Conn
-	send     chan []byte
-	userID   uint
-	username string
-	manager  *WebSocketManager
+	conn        *websocket.Conn
+	send        chan []byte
+	userID      uint
+	username    string
+	sessionID   string // 建立连接时令牌所属的会话，会话被撤销时断开
+	role        string // 连接建立时用户的角色
+	isAdmin     bool
+	canMonitor  bool // 连接建立时是否拥有系统监控权限，订阅进程列表需要
+	canDownload bool // 连接建立时是否拥有下载文件权限，跟踪文件需要
+	manager     *WebSocketManager
+
+	// 连接元数据
+	id          string
+	remoteAddr  string
+	userAgent   string
+	requestID   string
+	connectedAt time.Time
+
+	lastActivity atomic.Int64 // 最后一次收到客户端消息的时间（UnixNano）
+
+	tailMu sync.Mutex
+	tails  map[string]*clientTail // 该连接上正在进行的文件跟踪，按 tail_id 索引
+
+	subMu         sync.RWMutex
+	subscriptions map[string]bool // 已订阅的主题，未订阅的主题消息不会发送给该连接
 }
 
 // Message WebSocket消息
@@ -54,9 +85,20 @@ type SystemStatsMessage struct {
 const (
 	// WebSocket消息类型
 	MessageTypeSystemStats = "system_stats"
+	MessageTypeProcessList = "process_list"
 	MessageTypeUserJoined  = "user_joined"
 	MessageTypeUserLeft    = "user_left"
 	MessageTypeNotification = "notification"
+	MessageTypeSecurityAlert = "security_alert"
+	MessageTypeConfigChanged = "config_changed"
+	MessageTypeAccessChanged = "access_changed"
+	MessageTypeServerRestart = "server_restarting"
+	MessageTypeSubscribe     = "subscribe"
+	MessageTypeUnsubscribe   = "unsubscribe"
+	MessageTypeSubscriptions = "subscriptions"
+	MessageTypeFileTail      = "file_tail"
+	MessageTypeFileTailStop  = "file_tail_stop"
+	MessageTypeFileTailEnd   = "file_tail_end"
 	MessageTypeError       = "error"
 	MessageTypePing        = "ping"
 	MessageTypePong        = "pong"
@@ -66,15 +108,29 @@ const (
 	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 512
+
+	// defaultBroadcastBuffer 未配置广播队列容量时的默认值
+	defaultBroadcastBuffer = 256
+
+	// shutdownPollInterval 关闭时检查客户端是否已全部断开的间隔
+	shutdownPollInterval = 50 * time.Millisecond
 )
 
 // NewWebSocketManager 创建WebSocket管理器
-func NewWebSocketManager() *WebSocketManager {
+func NewWebSocketManager(cfg config.WebSocketConfig) *WebSocketManager {
+	// 广播队列带缓冲，Run 循环忙于处理连接注册等事件时消息先排队，
+	// Run 自身在处理注册/注销时发出的加入/离开消息也依赖该缓冲
+	broadcastBuffer := cfg.BroadcastBuffer
+	if broadcastBuffer <= 0 {
+		broadcastBuffer = defaultBroadcastBuffer
+	}
+
 	return &WebSocketManager{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		clients:     make(map[*Client]bool),
+		broadcast:   make(chan broadcastItem, broadcastBuffer),
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		idleTimeout: cfg.IdleTimeout,
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
@@ -86,6 +142,11 @@ func NewWebSocketManager() *WebSocketManager {
 	}
 }
 
+// SetFileTailService 设置文件跟踪服务，未设置时不处理 file_tail 消息
+func (manager *WebSocketManager) SetFileTailService(fileTail *service.FileTailService) {
+	manager.fileTail = fileTail
+}
+
 // Run 运行WebSocket管理器
 func (manager *WebSocketManager) Run() {
 	for {
@@ -95,12 +156,12 @@ func (manager *WebSocketManager) Run() {
 			manager.clients[client] = true
 			manager.mutex.Unlock()
 			
-			logger.Info("WebSocket客户端连接", "user_id", client.userID, "username", client.username)
+			logger.Info("WebSocket客户端连接", "connection_id", client.id, "request_id", client.requestID, "user_id", client.userID, "username", client.username)
 			
 			// 广播用户加入消息
 			message := Message{
 				Type:      MessageTypeUserJoined,
-				Data:      gin.H{"username": client.username},
+				Data:      gin.H{"username": client.username, "connection_id": client.id},
 				Timestamp: time.Now(),
 				UserID:    client.userID,
 				Username:  client.username,
@@ -113,12 +174,12 @@ func (manager *WebSocketManager) Run() {
 				delete(manager.clients, client)
 				close(client.send)
 				
-				logger.Info("WebSocket客户端断开", "user_id", client.userID, "username", client.username)
+				logger.Info("WebSocket客户端断开", "connection_id", client.id, "user_id", client.userID, "username", client.username)
 				
 				// 广播用户离开消息
 				message := Message{
 					Type:      MessageTypeUserLeft,
-					Data:      gin.H{"username": client.username},
+					Data:      gin.H{"username": client.username, "connection_id": client.id},
 					Timestamp: time.Now(),
 					UserID:    client.userID,
 					Username:  client.username,
@@ -127,23 +188,34 @@ func (manager *WebSocketManager) Run() {
 			}
 			manager.mutex.Unlock()
 
-		case message := <-manager.broadcast:
-			manager.mutex.RLock()
+		case item := <-manager.broadcast:
+			// 发送队列已满的客户端会被移除，需要写锁
+			manager.mutex.Lock()
 			for client := range manager.clients {
+				if item.topic != "" && !client.subscribed(item.topic) {
+					continue
+				}
 				select {
-				case client.send <- message:
+				case client.send <- item.data:
 				default:
 					close(client.send)
 					delete(manager.clients, client)
 				}
 			}
-			manager.mutex.RUnlock()
+			manager.mutex.Unlock()
 		}
 	}
 }
 
 // HandleWebSocket 处理WebSocket连接
+// 握手请求通过 Authorization: Bearer 头或查询参数 ?token=<jwt> 认证（浏览器只能使用后者），
+// 令牌必须对应一个未过期的会话
 func (manager *WebSocketManager) HandleWebSocket(c *gin.Context) {
+	if manager.closing.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "服务器正在关闭"})
+		return
+	}
+
 	// 验证用户身份
 	user, exists := middleware.GetCurrentUser(c)
 	if !exists || user == nil {
@@ -158,14 +230,27 @@ func (manager *WebSocketManager) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	sessionID, _ := middleware.GetCurrentSessionID(c)
+
 	// 创建客户端
 	client := &Client{
-		conn:     conn,
-		send:     make(chan []byte, 256),
-		userID:   user.ID,
-		username: user.Username,
-		manager:  manager,
+		conn:        conn,
+		send:        make(chan []byte, 256),
+		userID:      user.ID,
+		username:    user.Username,
+		sessionID:   sessionID,
+		role:        user.GetRole(),
+		isAdmin:     user.IsAdmin(),
+		canMonitor:  user.IsAdmin() || user.HasPermission(model.PermissionSystemMonitor),
+		canDownload: user.IsAdmin() || user.HasPermission(model.PermissionFileDownload),
+		manager:     manager,
+		id:          generateConnectionID(),
+		remoteAddr:  c.ClientIP(),
+		userAgent:   c.GetHeader("User-Agent"),
+		requestID:   c.GetString("request_id"),
+		connectedAt: time.Now(),
 	}
+	client.lastActivity.Store(client.connectedAt.UnixNano())
 
 	// 注册客户端
 	manager.register <- client
@@ -178,6 +263,8 @@ func (manager *WebSocketManager) HandleWebSocket(c *gin.Context) {
 // readPump 读取客户端消息
 func (c *Client) readPump() {
 	defer func() {
+		// 先停止文件跟踪，确保跟踪协程不会在发送通道关闭后继续发送
+		c.stopAllTails()
 		c.manager.unregister <- c
 		c.conn.Close()
 	}()
@@ -197,6 +284,7 @@ func (c *Client) readPump() {
 			}
 			break
 		}
+		c.lastActivity.Store(time.Now().UnixNano())
 
 		// 解析消息
 		var message Message
@@ -213,6 +301,15 @@ func (c *Client) readPump() {
 // writePump 向客户端发送消息
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
+
+	// 空闲检查，未启用时通道永远不会触发
+	var idleCheck <-chan time.Time
+	if c.manager.idleTimeout > 0 {
+		idleTicker := time.NewTicker(idleCheckInterval(c.manager.idleTimeout))
+		defer idleTicker.Stop()
+		idleCheck = idleTicker.C
+	}
+
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
@@ -220,6 +317,16 @@ func (c *Client) writePump() {
 
 	for {
 		select {
+		case <-idleCheck:
+			idle := time.Since(time.Unix(0, c.lastActivity.Load()))
+			if idle < c.manager.idleTimeout {
+				continue
+			}
+			logger.Info("WebSocket连接空闲超时，关闭连接", "connection_id", c.id, "user_id", c.userID, "idle", idle.String())
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "idle timeout"))
+			return
+
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
@@ -253,6 +360,18 @@ func (c *Client) writePump() {
 	}
 }
 
+// idleCheckInterval 空闲检查间隔，取超时时间的一半并限制在1秒到1分钟之间
+func idleCheckInterval(timeout time.Duration) time.Duration {
+	interval := timeout / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	return interval
+}
+
 // handleMessage 处理客户端消息
 func (c *Client) handleMessage(message Message) {
 	switch message.Type {
@@ -264,6 +383,18 @@ func (c *Client) handleMessage(message Message) {
 		}
 		c.sendMessage(response)
 
+	case MessageTypeFileTail:
+		c.startTail(message.Data)
+
+	case MessageTypeFileTailStop:
+		c.stopTail(message.Data)
+
+	case MessageTypeSubscribe:
+		c.updateSubscriptions(message.Data, true)
+
+	case MessageTypeUnsubscribe:
+		c.updateSubscriptions(message.Data, false)
+
 	default:
 		logger.Info("收到未知WebSocket消息类型", "type", message.Type, "user_id", c.userID)
 	}
@@ -293,10 +424,15 @@ func (manager *WebSocketManager) broadcastMessage(message Message) {
 		return
 	}
 
+	item := broadcastItem{data: messageBytes}
+	if subscribableTopics[message.Type] {
+		item.topic = message.Type
+	}
+
 	select {
-	case manager.broadcast <- messageBytes:
+	case manager.broadcast <- item:
 	default:
-		logger.Error("WebSocket广播队列已满")
+		logger.Error("WebSocket广播队列已满，消息被丢弃", "type", message.Type, "capacity", cap(manager.broadcast))
 	}
 }
 
@@ -317,6 +453,28 @@ func (manager *WebSocketManager) BroadcastSystemStats(stats *model.SystemStats)
 	manager.broadcastMessage(message)
 }
 
+// ProcessListMessage 进程列表消息
+type ProcessListMessage struct {
+	Processes []model.ProcessInfo `json:"processes"`
+	Total     int64               `json:"total"`
+	SortBy    string              `json:"sort_by"`
+}
+
+// BroadcastProcessList 向订阅了进程列表的客户端广播使用率最高的进程
+func (manager *WebSocketManager) BroadcastProcessList(processes []model.ProcessInfo, total int64, sortBy string) {
+	message := Message{
+		Type: MessageTypeProcessList,
+		Data: ProcessListMessage{
+			Processes: processes,
+			Total:     total,
+			SortBy:    sortBy,
+		},
+		Timestamp: time.Now(),
+	}
+
+	manager.broadcastMessage(message)
+}
+
 // BroadcastNotification 广播通知消息
 func (manager *WebSocketManager) BroadcastNotification(title, content string, level string) {
 	message := Message{
@@ -332,6 +490,208 @@ func (manager *WebSocketManager) BroadcastNotification(title, content string, le
 	manager.broadcastMessage(message)
 }
 
+// NotifyUser 向指定用户的所有连接发送通知消息
+func (manager *WebSocketManager) NotifyUser(userID uint, title, content, level string) {
+	message := Message{
+		Type: MessageTypeNotification,
+		Data: gin.H{
+			"title":   title,
+			"content": content,
+			"level":   level,
+		},
+		Timestamp: time.Now(),
+	}
+	manager.SendToUser(userID, message)
+}
+
+// NotifyAdmins 向所有在线管理员发送通知消息
+func (manager *WebSocketManager) NotifyAdmins(title, content, level string) {
+	message := Message{
+		Type: MessageTypeNotification,
+		Data: gin.H{
+			"title":   title,
+			"content": content,
+			"level":   level,
+		},
+		Timestamp: time.Now(),
+	}
+	manager.sendToAdmins(message)
+}
+
+// SendSecurityAlert 向所有在线管理员推送安全告警
+func (manager *WebSocketManager) SendSecurityAlert(alert *model.SecurityAlert) {
+	message := Message{
+		Type:      MessageTypeSecurityAlert,
+		Data:      alert,
+		Timestamp: time.Now(),
+	}
+	manager.sendToAdmins(message)
+}
+
+// BroadcastConfigChanged 向所有客户端广播公开设置的变更
+func (manager *WebSocketManager) BroadcastConfigChanged(changes map[string]string) {
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	message := Message{
+		Type: MessageTypeConfigChanged,
+		Data: gin.H{
+			"keys":   keys,
+			"values": changes,
+		},
+		Timestamp: time.Now(),
+	}
+	manager.broadcastMessage(message)
+}
+
+// NotifyAccessChanged 通知指定用户其角色或权限已变更，客户端收到后应重新获取权限
+func (manager *WebSocketManager) NotifyAccessChanged(userIDs []uint, reason string) {
+	message := Message{
+		Type:      MessageTypeAccessChanged,
+		Data:      gin.H{"reason": reason},
+		Timestamp: time.Now(),
+	}
+
+	targets := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		targets[id] = true
+	}
+	manager.sendTo(message, func(client *Client) bool {
+		return targets[client.userID]
+	})
+}
+
+// SendToUser 向指定用户的所有连接发送消息，用户不在线时不做任何事，返回送达的连接数
+func (manager *WebSocketManager) SendToUser(userID uint, message Message) int {
+	return manager.sendTo(message, func(client *Client) bool {
+		return client.userID == userID
+	})
+}
+
+// SendToRole 向指定角色的所有在线用户发送消息，角色按连接建立时的用户角色匹配，返回送达的连接数
+func (manager *WebSocketManager) SendToRole(role string, message Message) int {
+	return manager.sendTo(message, func(client *Client) bool {
+		return client.role == role
+	})
+}
+
+// sendToAdmins 向所有在线管理员发送消息
+func (manager *WebSocketManager) sendToAdmins(message Message) {
+	manager.sendTo(message, func(client *Client) bool {
+		return client.isAdmin
+	})
+}
+
+// sendTo 向满足条件的所有连接发送消息，发送队列已满的连接跳过本条消息，返回送达的连接数
+func (manager *WebSocketManager) sendTo(message Message, match func(client *Client) bool) int {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		logger.Error("WebSocket消息序列化失败", "error", err)
+		return 0
+	}
+
+	delivered := 0
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+	for client := range manager.clients {
+		if !match(client) {
+			continue
+		}
+		select {
+		case client.send <- messageBytes:
+			delivered++
+		default:
+			logger.Warn("WebSocket客户端发送队列已满", "user_id", client.userID, "type", message.Type)
+		}
+	}
+	return delivered
+}
+
+// BeginShutdown 开始关闭：拒绝新的WebSocket连接，并通知已连接的客户端服务器即将重启
+func (manager *WebSocketManager) BeginShutdown() {
+	if !manager.closing.CompareAndSwap(false, true) {
+		return
+	}
+
+	message := Message{
+		Type:      MessageTypeServerRestart,
+		Data:      gin.H{"message": "服务器正在重启，请稍后重新连接"},
+		Timestamp: time.Now(),
+	}
+	manager.broadcastMessage(message)
+}
+
+// CloseAll 向所有客户端发送关闭帧并等待其断开，ctx 结束时强制关闭剩余连接
+func (manager *WebSocketManager) CloseAll(ctx context.Context) {
+	manager.closing.Store(true)
+
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server restarting")
+	deadline := time.Now().Add(writeWait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	// WriteControl 可以与 writePump 中的写操作并发调用
+	for _, conn := range manager.connections() {
+		conn.WriteControl(websocket.CloseMessage, closeMessage, deadline)
+	}
+
+	// 客户端回复关闭帧后 readPump 退出并注销连接
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for manager.GetConnectedUsers() > 0 {
+		select {
+		case <-ctx.Done():
+			remaining := manager.connections()
+			for _, conn := range remaining {
+				conn.Close()
+			}
+			logger.Warn("WebSocket连接未在关闭超时内断开，已强制关闭", "count", len(remaining))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CloseSession 断开指定会话的所有连接，返回断开的连接数；会话被撤销后不应继续接收推送
+func (manager *WebSocketManager) CloseSession(sessionID string) int {
+	if sessionID == "" {
+		return 0
+	}
+
+	manager.mutex.RLock()
+	conns := make([]*websocket.Conn, 0)
+	for client := range manager.clients {
+		if client.sessionID == sessionID {
+			conns = append(conns, client.conn)
+		}
+	}
+	manager.mutex.RUnlock()
+
+	// 发送关闭帧后直接关闭连接，readPump 读取失败后注销客户端
+	closeMessage := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked")
+	for _, conn := range conns {
+		conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(writeWait))
+		conn.Close()
+	}
+	return len(conns)
+}
+
+// connections 返回当前所有客户端连接的快照，避免在持有锁时进行网络写入
+func (manager *WebSocketManager) connections() []*websocket.Conn {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	conns := make([]*websocket.Conn, 0, len(manager.clients))
+	for client := range manager.clients {
+		conns = append(conns, client.conn)
+	}
+	return conns
+}
+
 // GetConnectedUsers 获取已连接的用户数量
 func (manager *WebSocketManager) GetConnectedUsers() int {
 	manager.mutex.RLock()
@@ -352,4 +712,50 @@ func (manager *WebSocketManager) GetConnectedUserList() []gin.H {
 		})
 	}
 	return users
-}
\ No newline at end of file
+}
+
+// GetUserConnections 获取指定用户的WebSocket连接信息
+func (manager *WebSocketManager) GetUserConnections(userID uint) []model.WebSocketConnection {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	connections := make([]model.WebSocketConnection, 0)
+	for client := range manager.clients {
+		if client.userID != userID {
+			continue
+		}
+		connections = append(connections, model.WebSocketConnection{
+			ID:          client.id,
+			UserID:      client.userID,
+			Username:    client.username,
+			RemoteAddr:  client.remoteAddr,
+			UserAgent:   client.userAgent,
+			RequestID:   client.requestID,
+			ConnectedAt: client.connectedAt,
+			Topics:      client.topics(),
+		})
+	}
+
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].ConnectedAt.Before(connections[j].ConnectedAt)
+	})
+	return connections
+}
+
+// topics 客户端会收到的消息类型：无需订阅的消息类型加上已订阅的主题
+func (c *Client) topics() []string {
+	topics := []string{MessageTypeNotification, MessageTypeConfigChanged, MessageTypeAccessChanged, MessageTypeServerRestart}
+	if c.isAdmin {
+		topics = append(topics, MessageTypeSecurityAlert)
+	}
+	return append(topics, c.subscribedTopics()...)
+}
+
+// generateConnectionID 生成WebSocket连接ID
+func generateConnectionID() string {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(bytes)
+}
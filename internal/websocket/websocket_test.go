@@ -0,0 +1,238 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"web-panel-go/internal/config"
+	"web-panel-go/internal/logger"
+	"web-panel-go/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	// 管理器直接调用全局日志器，测试中丢弃输出
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// newTestClient 直接加入管理器的客户端，send 队列容量为 size
+func newTestClient(manager *WebSocketManager, size int) *Client {
+	client := &Client{send: make(chan []byte, size), manager: manager, subscriptions: make(map[string]bool)}
+	manager.clients[client] = true
+	return client
+}
+
+func TestBroadcastBurstWithinBufferNotDropped(t *testing.T) {
+	const capacity = 64
+	manager := NewWebSocketManager(config.WebSocketConfig{BroadcastBuffer: capacity})
+	client := newTestClient(manager, 2*capacity)
+
+	// Run 尚未读取时并发突发广播，队列容量内的消息全部排队
+	var wg sync.WaitGroup
+	for i := 0; i < capacity; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			manager.BroadcastNotification("burst", fmt.Sprint(i), "info")
+		}(i)
+	}
+	wg.Wait()
+	if got := len(manager.broadcast); got != capacity {
+		t.Fatalf("广播队列中有 %d 条消息，期望 %d 条", got, capacity)
+	}
+
+	// 队列已满时新消息被丢弃而不是阻塞调用方
+	manager.BroadcastNotification("overflow", "", "info")
+	if got := len(manager.broadcast); got != capacity {
+		t.Fatalf("队列已满时广播队列长度 %d", got)
+	}
+
+	go manager.Run()
+	seen := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(seen) < capacity {
+		select {
+		case data := <-client.send:
+			var message struct {
+				Data struct {
+					Title   string `json:"title"`
+					Content string `json:"content"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(data, &message); err != nil {
+				t.Fatal(err)
+			}
+			if message.Data.Title != "burst" {
+				t.Fatalf("收到意外的消息 %s", data)
+			}
+			seen[message.Data.Content] = true
+		case <-timeout:
+			t.Fatalf("只收到 %d/%d 条广播消息", len(seen), capacity)
+		}
+	}
+}
+
+func TestBroadcastBufferDefault(t *testing.T) {
+	manager := NewWebSocketManager(config.WebSocketConfig{})
+	if got := cap(manager.broadcast); got != defaultBroadcastBuffer {
+		t.Fatalf("未配置时广播队列容量为 %d，期望 %d", got, defaultBroadcastBuffer)
+	}
+}
+
+func TestHandleWebSocketRejectedDuringShutdown(t *testing.T) {
+	manager := NewWebSocketManager(config.WebSocketConfig{})
+	manager.BeginShutdown()
+
+	// 通知已连接的客户端服务器即将重启
+	item := <-manager.broadcast
+	var message Message
+	if err := json.Unmarshal(item.data, &message); err != nil || message.Type != MessageTypeServerRestart {
+		t.Fatalf("期望 %s 消息，实际 %s", MessageTypeServerRestart, item.data)
+	}
+
+	// 关闭期间拒绝新连接
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/ws", nil)
+	manager.HandleWebSocket(c)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("关闭期间新连接返回 %d，期望 503", w.Code)
+	}
+}
+
+// received 取出客户端发送队列中已有的消息类型
+func received(client *Client) []string {
+	var types []string
+	for {
+		select {
+		case data := <-client.send:
+			var message Message
+			json.Unmarshal(data, &message)
+			types = append(types, message.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestSendToUserAndRole(t *testing.T) {
+	manager := NewWebSocketManager(config.WebSocketConfig{})
+	// alice 有两个连接，bob 一个连接
+	alice1 := newTestClient(manager, 4)
+	alice1.userID, alice1.role = 1, "admin"
+	alice2 := newTestClient(manager, 4)
+	alice2.userID, alice2.role = 1, "admin"
+	bob := newTestClient(manager, 4)
+	bob.userID, bob.role = 2, "user"
+
+	message := Message{Type: MessageTypeNotification}
+	if got := manager.SendToUser(1, message); got != 2 {
+		t.Fatalf("SendToUser 送达 %d 个连接，期望 2 个", got)
+	}
+	if len(received(alice1)) != 1 || len(received(alice2)) != 1 || len(received(bob)) != 0 {
+		t.Fatal("消息应只发送给目标用户的全部连接")
+	}
+
+	// 不在线的用户和角色不做任何事
+	if got := manager.SendToUser(3, message); got != 0 {
+		t.Fatalf("不在线的用户送达 %d 个连接", got)
+	}
+	if got := manager.SendToRole("auditor", message); got != 0 {
+		t.Fatalf("没有在线用户的角色送达 %d 个连接", got)
+	}
+
+	if got := manager.SendToRole("user", message); got != 1 {
+		t.Fatalf("SendToRole 送达 %d 个连接，期望 1 个", got)
+	}
+	if len(received(bob)) != 1 || len(received(alice1)) != 0 {
+		t.Fatal("消息应只发送给目标角色的连接")
+	}
+
+	// 发送队列已满的连接跳过本条消息，但不影响其他连接
+	for i := 0; i < cap(alice1.send); i++ {
+		alice1.send <- nil
+	}
+	if got := manager.SendToUser(1, message); got != 1 {
+		t.Fatalf("队列已满时送达 %d 个连接，期望 1 个", got)
+	}
+	if manager.GetConnectedUsers() != 3 {
+		t.Fatal("定向发送不应移除连接")
+	}
+}
+
+// startTestServer 启动以固定用户身份接受WebSocket连接的测试服务器，返回 ws:// 地址
+func startTestServer(t *testing.T, manager *WebSocketManager) string {
+	t.Helper()
+	router := gin.New()
+	router.GET("/ws", func(c *gin.Context) {
+		c.Set("user", &model.User{ID: 1, Username: "alice"})
+		manager.HandleWebSocket(c)
+	})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+}
+
+func TestIdleConnectionClosedWhileActiveSurvives(t *testing.T) {
+	manager := NewWebSocketManager(config.WebSocketConfig{IdleTimeout: time.Second})
+	go manager.Run()
+	url := startTestServer(t, manager)
+
+	idle, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	closed := make(chan error, 1)
+	go func() {
+		for {
+			if _, _, err := idle.ReadMessage(); err != nil {
+				closed <- err
+				return
+			}
+		}
+	}()
+
+	active, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer active.Close()
+
+	// 活跃连接持续发送消息，超过空闲超时后仍然可用
+	for end := time.Now().Add(2500 * time.Millisecond); time.Now().Before(end); time.Sleep(200 * time.Millisecond) {
+		if err := active.WriteJSON(Message{Type: MessageTypePing}); err != nil {
+			t.Fatalf("活跃连接发送失败: %v", err)
+		}
+		active.SetReadDeadline(time.Now().Add(time.Second))
+		var reply Message
+		if err := active.ReadJSON(&reply); err != nil || reply.Type != MessageTypePong {
+			t.Fatalf("活跃连接被关闭: %v, %+v", err, reply)
+		}
+	}
+
+	select {
+	case err := <-closed:
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseNormalClosure || closeErr.Text != "idle timeout" {
+			t.Fatalf("空闲连接关闭原因 = %v，期望 idle timeout", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("空闲连接未在超时后关闭")
+	}
+}